@@ -28,6 +28,7 @@ func main() {
 	app.AddCommands(
 		NewServerCommand(),
 		NewOpsCommand(),
+		NewPreflightCommand(),
 	)
 	if err := app.Run(); err != nil {
 		log.Fatal(err)
@@ -150,7 +150,15 @@ func (s *ops) run(ctx context.Context, t cli.Telemetry) error {
 
 	// Start running HTTP server.
 	{
-		handler := handler.NewHandler(s.httpPort, datastore.NewProjectStore(ds), cfg.SharedSSOConfigs, s.gracePeriod, t.Logger)
+		handler := handler.NewHandler(
+			s.httpPort,
+			datastore.NewProjectStore(ds),
+			datastore.NewEnvironmentStore(ds),
+			datastore.NewPipedStore(ds),
+			cfg.SharedSSOConfigs,
+			s.gracePeriod,
+			t.Logger,
+		)
 		group.Go(func() error {
 			return handler.Run(ctx)
 		})
@@ -0,0 +1,119 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/ops/preflight"
+	"github.com/pipe-cd/pipe/pkg/cli"
+	"github.com/pipe-cd/pipe/pkg/crypto"
+	"github.com/pipe-cd/pipe/pkg/redis"
+)
+
+type preflightCheck struct {
+	configFile        string
+	encryptionKeyFile string
+	cacheAddress      string
+	timeout           time.Duration
+}
+
+// NewPreflightCommand creates a new cobra command for verifying
+// the control-plane configuration and its dependencies.
+func NewPreflightCommand() *cobra.Command {
+	p := &preflightCheck{
+		cacheAddress: "cache:6379",
+		timeout:      10 * time.Second,
+	}
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Verify the control-plane configuration and the reachability of its dependencies.",
+		RunE:  cli.WithContext(p.run),
+	}
+
+	cmd.Flags().StringVar(&p.configFile, "config-file", p.configFile, "The path to the configuration file.")
+	cmd.MarkFlagRequired("config-file")
+	cmd.Flags().StringVar(&p.encryptionKeyFile, "encryption-key-file", p.encryptionKeyFile, "The path to file containing a random string of bits used to encrypt sensitive data.")
+	cmd.Flags().StringVar(&p.cacheAddress, "cache-address", p.cacheAddress, "The address to cache service.")
+	cmd.Flags().DurationVar(&p.timeout, "timeout", p.timeout, "Maximum execution time of each check.")
+
+	return cmd
+}
+
+func (p *preflightCheck) run(ctx context.Context, t cli.Telemetry) error {
+	cfg, err := loadConfig(p.configFile)
+	if err != nil {
+		t.Logger.Error("failed to load control-plane configuration",
+			zap.String("config-file", p.configFile),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	checks := []preflight.Check{
+		preflight.ProjectsCheck(cfg),
+		preflight.SSOConfigsCheck(cfg),
+	}
+
+	if p.encryptionKeyFile != "" {
+		checks = append(checks, preflight.Check{
+			Name: "encryption key is loadable",
+			Hint: "the encryption key file must contain a random string used to encrypt sensitive data",
+			Run: func(_ context.Context) error {
+				_, err := crypto.NewAESEncryptDecrypter(p.encryptionKeyFile)
+				return err
+			},
+		})
+	}
+
+	const (
+		datastoreName = "datastore is reachable"
+		datastoreHint = "make sure the type and config of datastore are correct and the credentials file is readable"
+		filestoreName = "filestore is writable"
+		filestoreHint = "make sure the type and config of filestore are correct and the credentials file is readable"
+	)
+
+	if ds, err := createDatastore(ctx, cfg, t.Logger); err != nil {
+		checks = append(checks, preflight.FailedCheck(datastoreName, datastoreHint, err))
+	} else {
+		defer ds.Close()
+		checks = append(checks, preflight.DatastoreCheck(ds))
+	}
+
+	if fs, err := createFilestore(ctx, cfg, t.Logger); err != nil {
+		checks = append(checks, preflight.FailedCheck(filestoreName, filestoreHint, err))
+	} else {
+		defer fs.Close()
+		checks = append(checks, preflight.FilestoreCheck(fs))
+	}
+
+	rd := redis.NewRedis(p.cacheAddress, "", redis.WithDialConnectTimeout(p.timeout))
+	defer rd.Close()
+	checks = append(checks, preflight.CacheCheck(rd))
+
+	report := preflight.Run(ctx, p.timeout, checks...)
+	report.Write(os.Stdout)
+
+	if report.Failed() {
+		return errors.New("preflight checks failed")
+	}
+	return nil
+}
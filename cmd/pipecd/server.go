@@ -31,11 +31,14 @@ import (
 	"github.com/pipe-cd/pipe/pkg/admin"
 	"github.com/pipe-cd/pipe/pkg/app/api/apikeyverifier"
 	"github.com/pipe-cd/pipe/pkg/app/api/applicationlivestatestore"
+	"github.com/pipe-cd/pipe/pkg/app/api/approvallink"
 	"github.com/pipe-cd/pipe/pkg/app/api/authhandler"
+	"github.com/pipe-cd/pipe/pkg/app/api/commandoutputstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/commandstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/grpcapi"
 	"github.com/pipe-cd/pipe/pkg/app/api/pipedverifier"
 	"github.com/pipe-cd/pipe/pkg/app/api/service/webservice"
+	"github.com/pipe-cd/pipe/pkg/app/api/sessionverifier"
 	"github.com/pipe-cd/pipe/pkg/app/api/stagelogstore"
 	"github.com/pipe-cd/pipe/pkg/cache/rediscache"
 	"github.com/pipe-cd/pipe/pkg/cli"
@@ -83,6 +86,14 @@ type server struct {
 	configFile        string
 
 	enableGRPCReflection bool
+
+	maxRequestSizeMB            int
+	methodMaxRequestSizeMB      map[string]int
+	slowRequestThreshold        time.Duration
+	methodSlowRequestThresholds map[string]string
+
+	maxChunkedReportSizeMB int
+	maxChunkedReportChunks int
 }
 
 // NewServerCommand creates a new cobra command for executing api server.
@@ -96,6 +107,12 @@ func NewServerCommand() *cobra.Command {
 		staticDir:    "pkg/app/web/public_files",
 		cacheAddress: "cache:6379",
 		gracePeriod:  30 * time.Second,
+
+		maxRequestSizeMB:     4,
+		slowRequestThreshold: 5 * time.Second,
+
+		maxChunkedReportSizeMB: 64,
+		maxChunkedReportChunks: 4096,
 	}
 	cmd := &cobra.Command{
 		Use:   "server",
@@ -122,6 +139,13 @@ func NewServerCommand() *cobra.Command {
 	cmd.Flags().StringVar(&s.configFile, "config-file", s.configFile, "The path to the configuration file.")
 	cmd.MarkFlagRequired("config-file")
 
+	cmd.Flags().IntVar(&s.maxRequestSizeMB, "max-request-size-mb", s.maxRequestSizeMB, "The maximum size in megabytes of a gRPC request payload. Zero means no limit.")
+	cmd.Flags().StringToIntVar(&s.methodMaxRequestSizeMB, "method-max-request-size-mb", s.methodMaxRequestSizeMB, "The maximum size in megabytes of a gRPC request payload for each method, e.g. ReportStageLogs=16.")
+	cmd.Flags().DurationVar(&s.slowRequestThreshold, "slow-request-threshold", s.slowRequestThreshold, "How long a gRPC request can take before being logged as a slow request. Zero means never logging.")
+	cmd.Flags().StringToStringVar(&s.methodSlowRequestThresholds, "method-slow-request-threshold", s.methodSlowRequestThresholds, "How long a gRPC request to each method can take before being logged as a slow request, e.g. ListDeployments=10s.")
+	cmd.Flags().IntVar(&s.maxChunkedReportSizeMB, "max-chunked-report-size-mb", s.maxChunkedReportSizeMB, "The maximum total size in megabytes of the data reassembled from the chunks sent by piped, such as a large application live state. Zero means no limit.")
+	cmd.Flags().IntVar(&s.maxChunkedReportChunks, "max-chunked-report-chunks", s.maxChunkedReportChunks, "The maximum number of chunks piped can send in one chunked report. Zero means no limit.")
+
 	// For debugging early in development
 	cmd.Flags().BoolVar(&s.enableGRPCReflection, "enable-grpc-reflection", s.enableGRPCReflection, "Whether to enable the reflection service or not.")
 
@@ -142,6 +166,12 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 	}
 	t.Logger.Info("successfully loaded control-plane configuration")
 
+	requestLimitOpts, err := s.requestLimitOptions(t.Logger)
+	if err != nil {
+		t.Logger.Error("invalid request limit configuration", zap.Error(err))
+		return err
+	}
+
 	ds, err := createDatastore(ctx, cfg, t.Logger)
 	if err != nil {
 		t.Logger.Error("failed to create datastore", zap.Error(err))
@@ -176,9 +206,22 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 	cache := rediscache.NewTTLCache(rd, cfg.Cache.TTLDuration())
 	sls := stagelogstore.NewStore(fs, cache, t.Logger)
 	alss := applicationlivestatestore.NewStore(fs, cache, t.Logger)
-	cmds := commandstore.NewStore(ds, cache, t.Logger)
+	cmds := commandstore.NewStore(ds, cache, rd, t.Logger)
+	cmdOutputStore := commandoutputstore.NewStore(fs, t.Logger)
 	is := insightstore.NewStore(fs)
 
+	// Start receiving the commands added via all servers
+	// to push them to the watching pipeds.
+	group.Go(func() error {
+		return cmds.Run(ctx)
+	})
+
+	approvalLinkSigner, err := approvallink.NewSigner(s.encryptionKeyFile)
+	if err != nil {
+		t.Logger.Error("failed to create a new approval link signer", zap.Error(err))
+		return err
+	}
+
 	// Start a gRPC server for handling PipedAPI requests.
 	{
 		var (
@@ -189,16 +232,22 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 				datastore.NewPipedStore(ds),
 				t.Logger,
 			)
-			service = grpcapi.NewPipedAPI(ctx, ds, sls, alss, cmds, t.Logger)
+			limits = grpcapi.ChunkedReportLimits{
+				MaxSize:   s.maxChunkedReportSizeMB * 1024 * 1024,
+				MaxChunks: s.maxChunkedReportChunks,
+			}
+			service = grpcapi.NewPipedAPI(ctx, ds, sls, alss, cmds, cmdOutputStore, approvalLinkSigner, cfg.Address, limits, t.Logger)
 			opts    = []rpc.Option{
 				rpc.WithPort(s.pipedAPIPort),
 				rpc.WithGracePeriod(s.gracePeriod),
 				rpc.WithLogger(t.Logger),
 				rpc.WithLogUnaryInterceptor(t.Logger),
 				rpc.WithPipedTokenAuthUnaryInterceptor(verifier, t.Logger),
+				rpc.WithPipedTokenAuthStreamInterceptor(verifier, t.Logger),
 				rpc.WithRequestValidationUnaryInterceptor(),
 			}
 		)
+		opts = append(opts, requestLimitOpts...)
 		if s.tls {
 			opts = append(opts, rpc.WithTLS(s.certFile, s.keyFile))
 		}
@@ -220,7 +269,7 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 				datastore.NewAPIKeyStore(ds),
 				t.Logger,
 			)
-			service = grpcapi.NewAPI(ds, cmds, t.Logger)
+			service = grpcapi.NewAPI(ds, cmds, cmdOutputStore, t.Logger)
 			opts    = []rpc.Option{
 				rpc.WithPort(s.apiPort),
 				rpc.WithGracePeriod(s.gracePeriod),
@@ -230,6 +279,7 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 				rpc.WithRequestValidationUnaryInterceptor(),
 			}
 		)
+		opts = append(opts, requestLimitOpts...)
 		if s.tls {
 			opts = append(opts, rpc.WithTLS(s.certFile, s.keyFile))
 		}
@@ -246,15 +296,21 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 		return err
 	}
 
+	jwtVerifier, err := jwt.NewVerifier(defaultSigningMethod, s.encryptionKeyFile)
+	if err != nil {
+		t.Logger.Error("failed to create a new JWT verifier", zap.Error(err))
+		return err
+	}
+	verifier := sessionverifier.NewVerifier(
+		ctx,
+		jwtVerifier,
+		datastore.NewSessionStore(ds),
+		t.Logger,
+	)
+
 	// Start a gRPC server for handling WebAPI requests.
 	{
-		verifier, err := jwt.NewVerifier(defaultSigningMethod, s.encryptionKeyFile)
-		if err != nil {
-			t.Logger.Error("failed to create a new JWT verifier", zap.Error(err))
-			return err
-		}
-
-		service := grpcapi.NewWebAPI(ctx, ds, sls, alss, cmds, is, rd, cfg.ProjectMap(), encryptDecrypter, t.Logger)
+		service := grpcapi.NewWebAPI(ctx, ds, sls, alss, cmds, cmdOutputStore, is, rd, cfg.ProjectMap(), encryptDecrypter, t.Logger)
 		opts := []rpc.Option{
 			rpc.WithPort(s.webAPIPort),
 			rpc.WithGracePeriod(s.gracePeriod),
@@ -262,6 +318,7 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 			rpc.WithJWTAuthUnaryInterceptor(verifier, webservice.NewRBACAuthorizer(), t.Logger),
 			rpc.WithRequestValidationUnaryInterceptor(),
 		}
+		opts = append(opts, requestLimitOpts...)
 		if s.tls {
 			opts = append(opts, rpc.WithTLS(s.certFile, s.keyFile))
 		}
@@ -294,6 +351,8 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 		handlers := []httpHandler{
 			authhandler.NewHandler(
 				signer,
+				datastore.NewSessionStore(ds),
+				cfg.Session,
 				encryptDecrypter,
 				cfg.Address,
 				cfg.StateKey,
@@ -303,6 +362,13 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 				!s.insecureCookie,
 				t.Logger,
 			),
+			approvallink.NewHandler(
+				approvalLinkSigner,
+				verifier,
+				datastore.NewDeploymentStore(ds),
+				cmds,
+				t.Logger,
+			),
 		}
 
 		for _, h := range handlers {
@@ -359,6 +425,28 @@ func (s *server) run(ctx context.Context, t cli.Telemetry) error {
 	return nil
 }
 
+// requestLimitOptions returns the options for limiting the size of the requests
+// and logging the slow ones on all gRPC servers.
+func (s *server) requestLimitOptions(logger *zap.Logger) ([]rpc.Option, error) {
+	const mb = 1024 * 1024
+	methodMaxBytes := make(map[string]int, len(s.methodMaxRequestSizeMB))
+	for method, size := range s.methodMaxRequestSizeMB {
+		methodMaxBytes[method] = size * mb
+	}
+	methodThresholds := make(map[string]time.Duration, len(s.methodSlowRequestThresholds))
+	for method, v := range s.methodSlowRequestThresholds {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slow request threshold for %s: %w", method, err)
+		}
+		methodThresholds[method] = d
+	}
+	return []rpc.Option{
+		rpc.WithPayloadSizeUnaryInterceptor(s.maxRequestSizeMB*mb, methodMaxBytes),
+		rpc.WithSlowRequestLogUnaryInterceptor(logger, s.slowRequestThreshold, methodThresholds),
+	}, nil
+}
+
 func runHTTPServer(ctx context.Context, httpServer *http.Server, gracePeriod time.Duration, logger *zap.Logger) error {
 	doneCh := make(chan error, 1)
 	ctx, cancel := context.WithCancel(ctx)
@@ -441,6 +529,9 @@ func createFilestore(ctx context.Context, cfg *config.ControlPlaneSpec, logger *
 		if gcsCfg.CredentialsFile != "" {
 			options = append(options, gcs.WithCredentialsFile(gcsCfg.CredentialsFile))
 		}
+		if gcsCfg.ImpersonateServiceAccount != "" {
+			options = append(options, gcs.WithImpersonateServiceAccount(gcsCfg.ImpersonateServiceAccount))
+		}
 		return gcs.NewStore(ctx, gcsCfg.Bucket, options...)
 
 	case model.FileStoreS3:
@@ -20,7 +20,10 @@ import (
 
 	"github.com/pipe-cd/pipe/pkg/app/pipectl/cmd/application"
 	"github.com/pipe-cd/pipe/pkg/app/pipectl/cmd/deployment"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/cmd/encrypt"
 	"github.com/pipe-cd/pipe/pkg/app/pipectl/cmd/event"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/cmd/piped"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/cmd/planpreview"
 	"github.com/pipe-cd/pipe/pkg/cli"
 )
 
@@ -33,7 +36,10 @@ func main() {
 	app.AddCommands(
 		application.NewCommand(),
 		deployment.NewCommand(),
+		encrypt.NewCommand(),
 		event.NewCommand(),
+		piped.NewCommand(),
+		planpreview.NewCommand(),
 	)
 
 	if err := app.Run(); err != nil {
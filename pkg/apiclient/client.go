@@ -0,0 +1,161 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apiclient provides a client to interact with the API service of the control-plane.
+// It wraps the generated gRPC stubs and handles the authentication by API key,
+// so that other tools can integrate with PipeCD easily.
+//
+//	cli, err := apiclient.New(ctx, "pipecd.example.com:443", apiclient.WithAPIKeyFile("/path/to/api-key"))
+//	if err != nil {
+//		return err
+//	}
+//	defer cli.Close()
+//
+//	resp, err := cli.GetApplication(ctx, &apiservice.GetApplicationRequest{ApplicationId: id})
+package apiclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
+	"github.com/pipe-cd/pipe/pkg/rpc/rpcclient"
+)
+
+const defaultDialTimeout = 5 * time.Second
+
+// Client is a client for the API service of the control-plane.
+// It must be closed after use.
+type Client = apiservice.Client
+
+type options struct {
+	apiKey      string
+	apiKeyFile  string
+	insecure    bool
+	certFile    string
+	dialTimeout time.Duration
+	dialOptions []rpcclient.DialOption
+}
+
+// Option configures how the client connects to the control-plane.
+type Option func(*options)
+
+// WithAPIKey sets the API key used while authenticating with the control-plane.
+func WithAPIKey(key string) Option {
+	return func(o *options) {
+		o.apiKey = key
+	}
+}
+
+// WithAPIKeyFile sets the path to the file containing the API key
+// used while authenticating with the control-plane.
+func WithAPIKeyFile(path string) Option {
+	return func(o *options) {
+		o.apiKeyFile = path
+	}
+}
+
+// WithInsecure disables the transport security while connecting to the control-plane.
+func WithInsecure() Option {
+	return func(o *options) {
+		o.insecure = true
+	}
+}
+
+// WithCertFile sets the path to the TLS certificate file of the control-plane.
+// The system's root CAs are used when this is not specified.
+func WithCertFile(path string) Option {
+	return func(o *options) {
+		o.certFile = path
+	}
+}
+
+// WithDialTimeout sets the maximum duration for establishing the connection.
+// Default is 5 seconds.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = d
+	}
+}
+
+// WithDialOptions appends the additional options used while dialing such as interceptors.
+func WithDialOptions(opts ...rpcclient.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// New creates a new client connected to the API service at the given address.
+// Either WithAPIKey or WithAPIKeyFile must be specified.
+func New(ctx context.Context, address string, opts ...Option) (Client, error) {
+	o := &options{
+		dialTimeout: defaultDialTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dialOptions, err := o.makeDialOptions(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.dialTimeout)
+	defer cancel()
+
+	return apiservice.NewClient(ctx, address, dialOptions...)
+}
+
+func (o *options) makeDialOptions(address string) ([]rpcclient.DialOption, error) {
+	if address == "" {
+		return nil, errors.New("address must be set")
+	}
+	if o.apiKey == "" && o.apiKeyFile == "" {
+		return nil, errors.New("either api key or api key file must be set")
+	}
+
+	var (
+		creds credentials.PerRPCCredentials
+		err   error
+	)
+	if o.apiKey != "" {
+		creds = rpcclient.NewPerRPCCredentials(o.apiKey, rpcauth.APIKeyCredentials, !o.insecure)
+	} else {
+		creds, err = rpcclient.NewPerRPCCredentialsFromFile(o.apiKeyFile, rpcauth.APIKeyCredentials, !o.insecure)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	options := []rpcclient.DialOption{
+		rpcclient.WithBlock(),
+		rpcclient.WithPerRPCCredentials(creds),
+	}
+
+	switch {
+	case o.insecure:
+		options = append(options, rpcclient.WithInsecure())
+	case o.certFile != "":
+		options = append(options, rpcclient.WithTLS(o.certFile))
+	default:
+		options = append(options, rpcclient.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	}
+
+	return append(options, o.dialOptions...), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeAPIServer struct {
+	apiservice.UnimplementedAPIServiceServer
+}
+
+func (s *fakeAPIServer) GetApplication(ctx context.Context, req *apiservice.GetApplicationRequest) (*apiservice.GetApplicationResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &apiservice.GetApplicationResponse{
+		Application: &model.Application{
+			Id:   req.ApplicationId,
+			Name: md.Get("authorization")[0],
+		},
+	}, nil
+}
+
+func TestNew(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	apiservice.RegisterAPIServiceServer(server, &fakeAPIServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx := context.Background()
+	cli, err := New(ctx, lis.Addr().String(),
+		WithAPIKey("api-key"),
+		WithInsecure(),
+		WithDialTimeout(time.Second),
+	)
+	require.NoError(t, err)
+	defer cli.Close()
+
+	resp, err := cli.GetApplication(ctx, &apiservice.GetApplicationRequest{ApplicationId: "app-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "app-1", resp.Application.Id)
+	assert.Equal(t, "API-KEY api-key", resp.Application.Name)
+}
+
+func TestMakeDialOptions(t *testing.T) {
+	testcases := []struct {
+		name    string
+		address string
+		opts    []Option
+		wantErr bool
+	}{
+		{
+			name:    "missing address",
+			opts:    []Option{WithAPIKey("key")},
+			wantErr: true,
+		},
+		{
+			name:    "missing api key",
+			address: "localhost:443",
+			wantErr: true,
+		},
+		{
+			name:    "missing api key file",
+			address: "localhost:443",
+			opts:    []Option{WithAPIKeyFile("not-found")},
+			wantErr: true,
+		},
+		{
+			name:    "ok",
+			address: "localhost:443",
+			opts:    []Option{WithAPIKey("key")},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &options{}
+			for _, opt := range tc.opts {
+				opt(o)
+			}
+			_, err := o.makeDialOptions(tc.address)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approvallink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/datastore"
+	"github.com/pipe-cd/pipe/pkg/jwt"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const (
+	// Path is the path of the endpoint handling the approval links.
+	Path = "/approvals"
+
+	approveAction = "approve"
+	rejectAction  = "reject"
+)
+
+// URL returns the approval link for the given token.
+func URL(address, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", strings.TrimRight(address, "/"), Path, url.QueryEscape(token))
+}
+
+type deploymentGetter interface {
+	GetDeployment(ctx context.Context, id string) (*model.Deployment, error)
+}
+
+type commandStore interface {
+	AddCommand(ctx context.Context, cmd *model.Command) error
+	GetCommand(ctx context.Context, id string) (*model.Command, error)
+}
+
+// Handler serves the approval links.
+// Opening a link shows a confirmation page instead of deciding immediately
+// so that the links are not used by the mail scanners following them.
+// The decision is made only when the approver submits the form of that page
+// while logging in to the web console as the user the link was issued for.
+// Each link can be used only once.
+type Handler struct {
+	signer          *Signer
+	sessionVerifier jwt.Verifier
+	deploymentStore deploymentGetter
+	commandStore    commandStore
+	nowFunc         func() time.Time
+	logger          *zap.Logger
+}
+
+// NewHandler returns a handler for the approval links.
+func NewHandler(signer *Signer, verifier jwt.Verifier, ds deploymentGetter, cs commandStore, logger *zap.Logger) *Handler {
+	return &Handler{
+		signer:          signer,
+		sessionVerifier: verifier,
+		deploymentStore: ds,
+		commandStore:    cs,
+		nowFunc:         time.Now,
+		logger:          logger.Named("approval-link"),
+	}
+}
+
+// Register registers all handler into the specified registry.
+func (h *Handler) Register(r func(string, func(http.ResponseWriter, *http.Request))) {
+	r(Path, h.handle)
+}
+
+var pageTemplate = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>PipeCD</title></head>
+<body>
+<p>{{ .Message }}</p>
+{{ if .Token }}
+<form method="POST" action="{{ .Path }}">
+<input type="hidden" name="token" value="{{ .Token }}">
+<button type="submit" name="action" value="approve">Approve</button>
+<button type="submit" name="action" value="reject">Reject</button>
+</form>
+{{ end }}
+</body>
+</html>
+`))
+
+type page struct {
+	Message string
+	Path    string
+	Token   string
+}
+
+func (h *Handler) handle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleConfirm(w, r)
+	case http.MethodPost:
+		h.handleDecide(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleConfirm shows the page to let the approver choose approving or rejecting.
+func (h *Handler) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	claims, d, status, msg := h.validate(r.Context(), token)
+	if status != http.StatusOK {
+		h.render(w, status, page{Message: msg})
+		return
+	}
+	h.render(w, http.StatusOK, page{
+		Message: fmt.Sprintf("Deployment %s of application %q is waiting for an approval at stage %s. You are deciding as %s.",
+			d.Id, d.ApplicationName, claims.StageID, claims.Approver),
+		Path:  Path,
+		Token: token,
+	})
+}
+
+// handleDecide creates the command to approve or reject the stage.
+func (h *Handler) handleDecide(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.render(w, http.StatusBadRequest, page{Message: "Malformed request."})
+		return
+	}
+	claims, d, status, msg := h.validate(r.Context(), r.PostForm.Get("token"))
+	if status != http.StatusOK {
+		h.render(w, status, page{Message: msg})
+		return
+	}
+	if status, msg := h.authenticate(r, claims); status != http.StatusOK {
+		h.render(w, status, page{Message: msg})
+		return
+	}
+
+	cmd := &model.Command{
+		// Using the link ID as the command ID ensures that each link is used only once.
+		Id:            claims.ID,
+		PipedId:       d.PipedId,
+		ApplicationId: d.ApplicationId,
+		ProjectId:     d.ProjectId,
+		DeploymentId:  d.Id,
+		StageId:       claims.StageID,
+		Commander:     claims.Approver,
+	}
+	var decision string
+	switch r.PostForm.Get("action") {
+	case approveAction:
+		decision = "approved"
+		cmd.Type = model.Command_APPROVE_STAGE
+		cmd.ApproveStage = &model.Command_ApproveStage{
+			DeploymentId: d.Id,
+			StageId:      claims.StageID,
+		}
+	case rejectAction:
+		decision = "rejected"
+		cmd.Type = model.Command_REJECT_STAGE
+		cmd.RejectStage = &model.Command_RejectStage{
+			DeploymentId: d.Id,
+			StageId:      claims.StageID,
+		}
+	default:
+		h.render(w, http.StatusBadRequest, page{Message: "Unsupported action."})
+		return
+	}
+
+	err := h.commandStore.AddCommand(r.Context(), cmd)
+	if errors.Is(err, datastore.ErrAlreadyExists) {
+		h.render(w, http.StatusConflict, page{Message: "This link has already been used."})
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to create command", zap.Error(err))
+		h.render(w, http.StatusInternalServerError, page{Message: "Failed to record the decision, please try again later."})
+		return
+	}
+
+	h.logger.Info(fmt.Sprintf("stage %s of deployment %s was %s by %s via approval link", claims.StageID, d.Id, decision, claims.Approver))
+	h.render(w, http.StatusOK, page{
+		Message: fmt.Sprintf("The stage was %s by %s.", decision, claims.Approver),
+	})
+}
+
+// authenticate checks that the request was sent from a web console session
+// of the user the link was issued for.
+func (h *Handler) authenticate(r *http.Request, claims *Claims) (int, string) {
+	loginMsg := fmt.Sprintf("Please log in to the web console as %s and open this link again.", claims.Approver)
+	cookie, err := r.Cookie(jwt.SignedTokenKey)
+	if err != nil {
+		return http.StatusUnauthorized, loginMsg
+	}
+	session, err := h.sessionVerifier.Verify(cookie.Value)
+	if err != nil {
+		h.logger.Info("unable to verify session for approval link", zap.Error(err))
+		return http.StatusUnauthorized, loginMsg
+	}
+	if session.Subject != claims.Approver || session.Role.ProjectId != claims.ProjectID {
+		return http.StatusForbidden, fmt.Sprintf("This link was issued for %s, you are logged in as %s.", claims.Approver, session.Subject)
+	}
+	return http.StatusOK, ""
+}
+
+// validate verifies the given token and checks that the stage is still waiting for a decision.
+// The returned message describes the reason when the status is not OK.
+func (h *Handler) validate(ctx context.Context, token string) (*Claims, *model.Deployment, int, string) {
+	if token == "" {
+		return nil, nil, http.StatusBadRequest, "Missing token."
+	}
+	claims, err := h.signer.Verify(token, h.nowFunc())
+	switch {
+	case errors.Is(err, ErrExpiredToken):
+		return nil, nil, http.StatusUnauthorized, "This link has expired."
+	case err != nil:
+		return nil, nil, http.StatusUnauthorized, "Invalid link."
+	}
+
+	d, err := h.deploymentStore.GetDeployment(ctx, claims.DeploymentID)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return nil, nil, http.StatusNotFound, "The deployment was not found."
+	}
+	if err != nil {
+		h.logger.Error("failed to get deployment", zap.String("deployment-id", claims.DeploymentID), zap.Error(err))
+		return nil, nil, http.StatusInternalServerError, "Failed to get the deployment, please try again later."
+	}
+	// The signature ensures the claims were issued by this control plane,
+	// but checking the project as well keeps a link bound to the project it was issued for.
+	if d.ProjectId != claims.ProjectID {
+		return nil, nil, http.StatusForbidden, "Invalid link."
+	}
+
+	stage, ok := d.StageStatusMap()[claims.StageID]
+	if !ok {
+		return nil, nil, http.StatusNotFound, "The stage was not found in the deployment."
+	}
+	if model.IsCompletedStage(stage) {
+		return nil, nil, http.StatusConflict, "The stage was already completed."
+	}
+
+	// The command created by a link has the same ID with the link.
+	_, err = h.commandStore.GetCommand(ctx, claims.ID)
+	if err == nil {
+		return nil, nil, http.StatusConflict, "This link has already been used."
+	}
+	if !errors.Is(err, datastore.ErrNotFound) {
+		h.logger.Error("failed to get command", zap.String("command-id", claims.ID), zap.Error(err))
+		return nil, nil, http.StatusInternalServerError, "Failed to check the link, please try again later."
+	}
+	return claims, d, http.StatusOK, ""
+}
+
+func (h *Handler) render(w http.ResponseWriter, status int, p page) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := pageTemplate.Execute(w, p); err != nil {
+		h.logger.Error("failed to render approval page", zap.Error(err))
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approvallink
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/datastore"
+	"github.com/pipe-cd/pipe/pkg/jwt"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeDeploymentGetter struct {
+	deployment *model.Deployment
+}
+
+func (f *fakeDeploymentGetter) GetDeployment(_ context.Context, id string) (*model.Deployment, error) {
+	if f.deployment == nil || f.deployment.Id != id {
+		return nil, datastore.ErrNotFound
+	}
+	return f.deployment, nil
+}
+
+type fakeCommandStore struct {
+	commands map[string]*model.Command
+}
+
+func (f *fakeCommandStore) AddCommand(_ context.Context, cmd *model.Command) error {
+	if _, ok := f.commands[cmd.Id]; ok {
+		return datastore.ErrAlreadyExists
+	}
+	f.commands[cmd.Id] = cmd
+	return nil
+}
+
+func (f *fakeCommandStore) GetCommand(_ context.Context, id string) (*model.Command, error) {
+	cmd, ok := f.commands[id]
+	if !ok {
+		return nil, datastore.ErrNotFound
+	}
+	return cmd, nil
+}
+
+// fakeSessionVerifier treats the token as "<project>/<username>".
+type fakeSessionVerifier struct{}
+
+func (fakeSessionVerifier) Verify(token string) (*jwt.Claims, error) {
+	parts := strings.Split(token, "/")
+	if len(parts) != 2 {
+		return nil, errors.New("invalid token")
+	}
+	claims := &jwt.Claims{Role: model.Role{ProjectId: parts[0]}}
+	claims.Subject = parts[1]
+	return claims, nil
+}
+
+func TestHandler(t *testing.T) {
+	now := time.Unix(1000, 0)
+	signer := newSigner([]byte("secret"))
+	sign := func(projectID, stageID string, expiresAt time.Time) string {
+		token, err := signer.Sign(&Claims{
+			ID:           "link-id",
+			ProjectID:    projectID,
+			DeploymentID: "deployment-id",
+			StageID:      stageID,
+			Approver:     "foo",
+			ExpiresAt:    expiresAt.Unix(),
+		})
+		require.NoError(t, err)
+		return token
+	}
+	valid := sign("project", "wait-stage", now.Add(time.Hour))
+
+	newHandler := func() (*Handler, *fakeCommandStore) {
+		cs := &fakeCommandStore{commands: map[string]*model.Command{}}
+		h := NewHandler(signer, fakeSessionVerifier{}, &fakeDeploymentGetter{
+			deployment: &model.Deployment{
+				Id:        "deployment-id",
+				ProjectId: "project",
+				PipedId:   "piped-id",
+				Stages: []*model.PipelineStage{
+					{Id: "wait-stage", Status: model.StageStatus_STAGE_RUNNING},
+					{Id: "sync-stage", Status: model.StageStatus_STAGE_SUCCESS},
+				},
+			},
+		}, cs, zap.NewNop())
+		h.nowFunc = func() time.Time { return now }
+		return h, cs
+	}
+	postAs := func(h *Handler, session, token, action string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {token}, "action": {action}}
+		req := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if session != "" {
+			req.AddCookie(&http.Cookie{Name: jwt.SignedTokenKey, Value: session})
+		}
+		rec := httptest.NewRecorder()
+		h.handle(rec, req)
+		return rec
+	}
+	post := func(h *Handler, token, action string) *httptest.ResponseRecorder {
+		return postAs(h, "project/foo", token, action)
+	}
+
+	t.Run("opening a link does not decide", func(t *testing.T) {
+		h, cs := newHandler()
+		rec := httptest.NewRecorder()
+		h.handle(rec, httptest.NewRequest(http.MethodGet, URL("", valid), nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<form method="POST"`)
+		assert.Empty(t, cs.commands)
+	})
+
+	t.Run("approve and reuse", func(t *testing.T) {
+		h, cs := newHandler()
+		rec := post(h, valid, approveAction)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, cs.commands, "link-id")
+		cmd := cs.commands["link-id"]
+		assert.Equal(t, model.Command_APPROVE_STAGE, cmd.Type)
+		assert.Equal(t, "foo", cmd.Commander)
+		assert.Equal(t, "wait-stage", cmd.StageId)
+
+		rec = post(h, valid, rejectAction)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		// The used link can not be opened anymore.
+		rec = httptest.NewRecorder()
+		h.handle(rec, httptest.NewRequest(http.MethodGet, URL("", valid), nil))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("not logged in", func(t *testing.T) {
+		h, cs := newHandler()
+		rec := postAs(h, "", valid, approveAction)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, cs.commands)
+	})
+
+	t.Run("logged in as another user", func(t *testing.T) {
+		h, cs := newHandler()
+		rec := postAs(h, "project/bar", valid, approveAction)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, cs.commands)
+
+		rec = postAs(h, "another/foo", valid, approveAction)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, cs.commands)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		h, cs := newHandler()
+		rec := post(h, valid, rejectAction)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, cs.commands, "link-id")
+		assert.Equal(t, model.Command_REJECT_STAGE, cs.commands["link-id"].Type)
+	})
+
+	testcases := []struct {
+		name     string
+		token    string
+		action   string
+		expected int
+	}{
+		{
+			name:     "unsupported action",
+			token:    valid,
+			action:   "skip",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "missing token",
+			action:   approveAction,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "expired link",
+			token:    sign("project", "wait-stage", now.Add(-time.Minute)),
+			action:   approveAction,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "issued for another project",
+			token:    sign("another", "wait-stage", now.Add(time.Hour)),
+			action:   approveAction,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "unknown stage",
+			token:    sign("project", "unknown", now.Add(time.Hour)),
+			action:   approveAction,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "completed stage",
+			token:    sign("project", "sync-stage", now.Add(time.Hour)),
+			action:   approveAction,
+			expected: http.StatusConflict,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, cs := newHandler()
+			rec := post(h, tc.token, tc.action)
+			assert.Equal(t, tc.expected, rec.Code)
+			assert.Empty(t, cs.commands)
+		})
+	}
+}
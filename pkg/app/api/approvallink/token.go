@@ -0,0 +1,135 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package approvallink provides the signed one-time links
+// used to approve or reject WAIT_APPROVAL stages from outside the web console,
+// e.g. from the email notifications sent by piped.
+package approvallink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// signingKeyContext separates the key used to sign the approval links
+// from the other usages of the same secret.
+const signingKeyContext = "pipecd-approval-link"
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpiredToken     = errors.New("token is expired")
+)
+
+// Claims represents the data embedded in an approval link.
+// A link can be used only once by the approver to approve or reject the specified stage.
+type Claims struct {
+	// The unique identifier of the link.
+	// It is also used as the ID of the command to be created.
+	ID           string `json:"jti"`
+	ProjectID    string `json:"pid"`
+	DeploymentID string `json:"did"`
+	StageID      string `json:"sid"`
+	// The PipeCD username of the user who is allowed to use the link.
+	Approver  string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Validate checks whether all required fields are set.
+func (c *Claims) Validate() error {
+	if c.ID == "" || c.ProjectID == "" || c.DeploymentID == "" || c.StageID == "" || c.Approver == "" {
+		return ErrMalformedToken
+	}
+	return nil
+}
+
+// Signer signs and verifies the approval tokens by using HMAC-SHA256.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a signer whose key is derived from the content of the given file.
+func NewSigner(keyFile string) (*Signer, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key file: %w", err)
+	}
+	return newSigner(data), nil
+}
+
+func newSigner(secret []byte) *Signer {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingKeyContext))
+	return &Signer{
+		key: mac.Sum(nil),
+	}
+}
+
+// Sign returns a token containing the given claims.
+func (s *Signer) Sign(c *Claims) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+// Verify checks the signature and the expiration of the given token
+// and returns its claims.
+func (s *Signer) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrMalformedToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if !hmac.Equal(sig, s.sign(parts[0])) {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+	return &c, nil
+}
+
+func (s *Signer) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
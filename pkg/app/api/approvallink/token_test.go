@@ -0,0 +1,98 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approvallink
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1000, 0)
+	signer := newSigner([]byte("secret"))
+	claims := &Claims{
+		ID:           "link-id",
+		ProjectID:    "project",
+		DeploymentID: "deployment-id",
+		StageID:      "stage-id",
+		Approver:     "foo",
+		ExpiresAt:    now.Add(time.Hour).Unix(),
+	}
+	token, err := signer.Sign(claims)
+	require.NoError(t, err)
+
+	expired, err := signer.Sign(&Claims{
+		ID:           "link-id",
+		ProjectID:    "project",
+		DeploymentID: "deployment-id",
+		StageID:      "stage-id",
+		Approver:     "foo",
+		ExpiresAt:    now.Add(-time.Second).Unix(),
+	})
+	require.NoError(t, err)
+
+	parts := strings.SplitN(token, ".", 2)
+	require.Len(t, parts, 2)
+
+	testcases := []struct {
+		name        string
+		signer      *Signer
+		token       string
+		expected    *Claims
+		expectedErr error
+	}{
+		{
+			name:     "valid token",
+			signer:   signer,
+			token:    token,
+			expected: claims,
+		},
+		{
+			name:        "expired token",
+			signer:      signer,
+			token:       expired,
+			expectedErr: ErrExpiredToken,
+		},
+		{
+			name:        "signed by another key",
+			signer:      newSigner([]byte("another")),
+			token:       token,
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "tampered payload",
+			signer:      signer,
+			token:       parts[0] + "x." + parts[1],
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "malformed token",
+			signer:      signer,
+			token:       "malformed",
+			expectedErr: ErrMalformedToken,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.signer.Verify(tc.token, now)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -25,7 +25,6 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/net/xsrftoken"
 
-	"github.com/pipe-cd/pipe/pkg/jwt"
 	"github.com/pipe-cd/pipe/pkg/model"
 	"github.com/pipe-cd/pipe/pkg/oauth/github"
 )
@@ -83,13 +82,7 @@ func (h *Handler) handleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := jwt.NewClaims(
-		user.Username,
-		user.AvatarUrl,
-		defaultTokenTTL,
-		*user.Role,
-	)
-	signedToken, err := h.signer.Sign(claims)
+	tokens, err := h.startSession(ctx, user.Username, user.AvatarUrl, user.Role)
 	if err != nil {
 		h.handleError(w, r, "Internal error", err)
 		return
@@ -101,7 +94,8 @@ func (h *Handler) handleCallback(w http.ResponseWriter, r *http.Request) {
 		zap.String("project-role", user.Role.String()),
 	)
 
-	http.SetCookie(w, makeTokenCookie(signedToken, true))
+	http.SetCookie(w, makeTokenCookie(tokens.accessToken, tokens.cookieMaxAge, true))
+	http.SetCookie(w, makeRefreshTokenCookie(tokens.refreshToken, tokens.cookieMaxAge, true))
 	http.SetCookie(w, makeExpiredStateCookie(h.secureCookie))
 	http.Redirect(w, r, rootPath, http.StatusFound)
 }
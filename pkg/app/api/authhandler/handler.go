@@ -38,6 +38,10 @@ const (
 	callbackPath = "/auth/callback"
 	// logoutPath is the path for logging out from current session.
 	logoutPath = "/auth/logout"
+	// refreshPath is the path for issuing a new access token for current session.
+	refreshPath = "/auth/refresh"
+	// refreshTokenCookiePath limits the refresh token cookie to be sent only to the auth paths.
+	refreshTokenCookiePath = "/auth"
 
 	projectFormKey  = "project"
 	usernameFormKey = "username"
@@ -45,13 +49,12 @@ const (
 	authCodeFormKey = "code"
 	stateFormKey    = "state"
 
-	stateCookieKey = "state"
-	errorCookieKey = "error"
+	stateCookieKey        = "state"
+	errorCookieKey        = "error"
+	refreshTokenCookieKey = "refresh_token"
 
-	defaultTokenTTL          = 7 * 24 * time.Hour
 	defaultStateCookieMaxAge = 30 * 60
 	defaultErrorCookieMaxAge = 10 * 60
-	defaultTokenCookieMaxAge = 7 * 24 * 60 * 60
 )
 
 type projectGetter interface {
@@ -65,6 +68,8 @@ type decrypter interface {
 // Handler handles all imcoming requests about authentication.
 type Handler struct {
 	signer           jwt.Signer
+	sessionStore     sessionStore
+	sessionConfig    config.ControlPlaneSession
 	decrypter        decrypter
 	callbackURL      string
 	stateKey         string
@@ -72,12 +77,15 @@ type Handler struct {
 	sharedSSOConfigs map[string]*model.ProjectSSOConfig
 	projectGetter    projectGetter
 	secureCookie     bool
+	nowFunc          func() time.Time
 	logger           *zap.Logger
 }
 
 // NewHandler returns a handler that will used for authentication.
 func NewHandler(
 	signer jwt.Signer,
+	sessionStore sessionStore,
+	sessionConfig config.ControlPlaneSession,
 	decrypter decrypter,
 	address string,
 	stateKey string,
@@ -89,6 +97,8 @@ func NewHandler(
 ) *Handler {
 	return &Handler{
 		signer:           signer,
+		sessionStore:     sessionStore,
+		sessionConfig:    sessionConfig,
 		decrypter:        decrypter,
 		callbackURL:      strings.TrimSuffix(address, "/") + callbackPath,
 		stateKey:         stateKey,
@@ -96,6 +106,7 @@ func NewHandler(
 		sharedSSOConfigs: sharedSSOConfigs,
 		projectGetter:    projectGetter,
 		secureCookie:     secureCookie,
+		nowFunc:          time.Now,
 		logger:           logger,
 	}
 }
@@ -106,13 +117,17 @@ func (h *Handler) Register(r func(string, func(http.ResponseWriter, *http.Reques
 	r(staticLoginPath, h.handleStaticAdminLogin)
 	r(callbackPath, h.handleCallback)
 	r(logoutPath, h.handleLogout)
+	r(refreshPath, h.handleRefresh)
 }
 
-// handleLogout cleans current cookies and redirects to login page.
+// handleLogout revokes current session, cleans current cookies and redirects to login page.
 func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
+	h.revokeCurrentSession(r)
+
 	http.SetCookie(w, makeExpiredTokenCookie(h.secureCookie))
+	http.SetCookie(w, makeExpiredRefreshTokenCookie(h.secureCookie))
 	http.SetCookie(w, makeExpiredStateCookie(h.secureCookie))
 
 	http.Redirect(w, r, rootPath, http.StatusFound)
@@ -146,11 +161,11 @@ func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, responseMe
 	http.Redirect(w, r, rootPath, http.StatusSeeOther)
 }
 
-func makeTokenCookie(value string, secure bool) *http.Cookie {
+func makeTokenCookie(value string, maxAge int, secure bool) *http.Cookie {
 	return &http.Cookie{
 		Name:     jwt.SignedTokenKey,
 		Value:    value,
-		MaxAge:   defaultTokenCookieMaxAge,
+		MaxAge:   maxAge,
 		Path:     rootPath,
 		Secure:   secure,
 		HttpOnly: true,
@@ -170,6 +185,30 @@ func makeExpiredTokenCookie(secure bool) *http.Cookie {
 	}
 }
 
+func makeRefreshTokenCookie(value string, maxAge int, secure bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     refreshTokenCookieKey,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     refreshTokenCookiePath,
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+func makeExpiredRefreshTokenCookie(secure bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     refreshTokenCookieKey,
+		Value:    "",
+		MaxAge:   -1,
+		Path:     refreshTokenCookiePath,
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
 func makeStateCookie(value string, secure bool) *http.Cookie {
 	return &http.Cookie{
 		Name:     stateCookieKey,
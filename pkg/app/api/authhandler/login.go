@@ -24,7 +24,6 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/net/xsrftoken"
 
-	"github.com/pipe-cd/pipe/pkg/jwt"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
@@ -131,16 +130,14 @@ func (h *Handler) handleStaticAdminLogin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	claims := jwt.NewClaims(
-		admin.Username,
-		"",
-		defaultTokenTTL,
-		model.Role{
-			ProjectId:   projectID,
-			ProjectRole: model.Role_ADMIN,
-		},
-	)
-	signedToken, err := h.signer.Sign(claims)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	role := &model.Role{
+		ProjectId:   projectID,
+		ProjectRole: model.Role_ADMIN,
+	}
+	tokens, err := h.startSession(ctx, admin.Username, "", role)
 	if err != nil {
 		h.handleError(w, r, "Internal error", err)
 		return
@@ -151,6 +148,7 @@ func (h *Handler) handleStaticAdminLogin(w http.ResponseWriter, r *http.Request)
 		zap.String("project-id", projectID),
 		zap.String("project-role", model.Role_ADMIN.String()),
 	)
-	http.SetCookie(w, makeTokenCookie(signedToken, h.secureCookie))
+	http.SetCookie(w, makeTokenCookie(tokens.accessToken, tokens.cookieMaxAge, h.secureCookie))
+	http.SetCookie(w, makeRefreshTokenCookie(tokens.refreshToken, tokens.cookieMaxAge, h.secureCookie))
 	http.Redirect(w, r, rootPath, http.StatusFound)
 }
@@ -0,0 +1,222 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authhandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/jwt"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type sessionStore interface {
+	AddSession(ctx context.Context, s *model.Session) error
+	GetSession(ctx context.Context, id string) (*model.Session, error)
+	RefreshSession(ctx context.Context, id, currentRefreshTokenHash, newRefreshTokenHash string) error
+	RevokeSession(ctx context.Context, id, projectID, revokedBy string) error
+}
+
+// sessionTokens contains the tokens issued for a session
+// and how long the cookies holding them should be kept.
+type sessionTokens struct {
+	accessToken  string
+	refreshToken string
+	cookieMaxAge int
+}
+
+// startSession creates a new session for the given user
+// and issues the first pair of access and refresh tokens for it.
+func (h *Handler) startSession(ctx context.Context, username, avatarURL string, role *model.Role) (*sessionTokens, error) {
+	var (
+		now       = h.nowFunc()
+		id        = uuid.New().String()
+		expiresAt = now.Add(h.sessionConfig.MaxLifetimeDuration())
+	)
+	refreshToken, hash, err := model.GenerateSessionRefreshToken(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	session := &model.Session{
+		Id:               id,
+		ProjectId:        role.ProjectId,
+		Username:         username,
+		AvatarUrl:        avatarURL,
+		Role:             role,
+		RefreshTokenHash: hash,
+		LastActiveAt:     now.Unix(),
+		ExpiresAt:        expiresAt.Unix(),
+	}
+	if err := h.sessionStore.AddSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to add session: %w", err)
+	}
+
+	accessToken, err := h.signAccessToken(session, now)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionTokens{
+		accessToken:  accessToken,
+		refreshToken: refreshToken,
+		cookieMaxAge: int(expiresAt.Sub(now).Seconds()),
+	}, nil
+}
+
+// signAccessToken issues a new access token for the given session.
+// The token never outlives the session.
+func (h *Handler) signAccessToken(session *model.Session, now time.Time) (string, error) {
+	ttl := h.sessionConfig.AccessTokenTTLDuration()
+	if remaining := time.Unix(session.ExpiresAt, 0).Sub(now); remaining < ttl {
+		ttl = remaining
+	}
+
+	claims := jwt.NewClaims(
+		session.Username,
+		session.AvatarUrl,
+		ttl,
+		model.Role{
+			ProjectId:   session.Role.ProjectId,
+			ProjectRole: session.Role.ProjectRole,
+		},
+		session.Id,
+	)
+	token, err := h.signer.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return token, nil
+}
+
+// handleRefresh rotates the refresh token of current session
+// and issues a new access token for it.
+// The web client calls this when its access token was rejected as expired.
+func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, token, err := h.findRequestSession(ctx, r)
+	if err != nil {
+		h.handleRefreshError(w, "Unable to find session", err)
+		return
+	}
+
+	now := h.nowFunc()
+	if err := session.CheckActive(now, h.sessionConfig.IdleTimeoutDuration()); err != nil {
+		h.handleRefreshError(w, "Session is no longer active", err)
+		return
+	}
+
+	// A refresh token not matching the current one was already rotated,
+	// which means it may be leaked, so the whole session is revoked.
+	if err := session.CompareRefreshToken(token); err != nil {
+		if e := h.sessionStore.RevokeSession(ctx, session.Id, session.ProjectId, model.SessionRevokedByReuseDetection); e != nil {
+			h.logger.Error("auth-handler: failed to revoke session of reused refresh token",
+				zap.String("session-id", session.Id),
+				zap.Error(e),
+			)
+		}
+		h.handleRefreshError(w, "Refresh token was reused", err)
+		return
+	}
+
+	refreshToken, hash, err := model.GenerateSessionRefreshToken(session.Id)
+	if err != nil {
+		h.handleRefreshError(w, "Internal error", err)
+		return
+	}
+	if err := h.sessionStore.RefreshSession(ctx, session.Id, session.RefreshTokenHash, hash); err != nil {
+		h.handleRefreshError(w, "Unable to refresh session", err)
+		return
+	}
+
+	accessToken, err := h.signAccessToken(session, now)
+	if err != nil {
+		h.handleRefreshError(w, "Internal error", err)
+		return
+	}
+
+	maxAge := int(time.Unix(session.ExpiresAt, 0).Sub(now).Seconds())
+	http.SetCookie(w, makeTokenCookie(accessToken, maxAge, h.secureCookie))
+	http.SetCookie(w, makeRefreshTokenCookie(refreshToken, maxAge, h.secureCookie))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findCurrentSession returns the session that the refresh token in the request cookie belongs to.
+func (h *Handler) findCurrentSession(ctx context.Context, r *http.Request) (*model.Session, error) {
+	session, token, err := h.findRequestSession(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := session.CompareRefreshToken(token); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// findRequestSession returns the session whose ID is contained in the refresh token
+// of the request cookie together with that token without verifying it.
+func (h *Handler) findRequestSession(ctx context.Context, r *http.Request) (*model.Session, string, error) {
+	c, err := r.Cookie(refreshTokenCookieKey)
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := model.ExtractSessionID(c.Value)
+	if err != nil {
+		return nil, "", err
+	}
+	session, err := h.sessionStore.GetSession(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, c.Value, nil
+}
+
+// revokeCurrentSession revokes the session of the request if it has one.
+func (h *Handler) revokeCurrentSession(r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := h.findCurrentSession(ctx, r)
+	if err != nil {
+		return
+	}
+	if err := h.sessionStore.RevokeSession(ctx, session.Id, session.ProjectId, session.Username); err != nil {
+		h.logger.Error("auth-handler: failed to revoke session",
+			zap.String("session-id", session.Id),
+			zap.Error(err),
+		)
+	}
+}
+
+// handleRefreshError cleans the token cookies and responds an unauthorized error
+// so that the web client can redirect to the login page.
+func (h *Handler) handleRefreshError(w http.ResponseWriter, responseMessage string, err error) {
+	h.logger.Info(fmt.Sprintf("auth-handler: %s", responseMessage), zap.Error(err))
+
+	http.SetCookie(w, makeExpiredTokenCookie(h.secureCookie))
+	http.SetCookie(w, makeExpiredRefreshTokenCookie(h.secureCookie))
+	http.Error(w, responseMessage, http.StatusUnauthorized)
+}
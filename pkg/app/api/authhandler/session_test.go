@@ -0,0 +1,179 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authhandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/jwt"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(claims *jwt.Claims) (string, error) {
+	return fmt.Sprintf("%s:%s:%d", claims.Subject, claims.SessionID(), claims.ExpiresAt-claims.IssuedAt), nil
+}
+
+type fakeSessionStore struct {
+	sessions map[string]*model.Session
+}
+
+func (s *fakeSessionStore) AddSession(_ context.Context, session *model.Session) error {
+	s.sessions[session.Id] = session
+	return nil
+}
+
+func (s *fakeSessionStore) GetSession(_ context.Context, id string) (*model.Session, error) {
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return proto.Clone(session).(*model.Session), nil
+}
+
+func (s *fakeSessionStore) RefreshSession(_ context.Context, id, currentRefreshTokenHash, newRefreshTokenHash string) error {
+	session, ok := s.sessions[id]
+	if !ok {
+		return fmt.Errorf("not found")
+	}
+	if session.RefreshTokenHash != currentRefreshTokenHash {
+		session.Revoked = true
+		return fmt.Errorf("reused")
+	}
+	session.RefreshTokenHash = newRefreshTokenHash
+	return nil
+}
+
+func (s *fakeSessionStore) RevokeSession(_ context.Context, id, projectID, revokedBy string) error {
+	session, ok := s.sessions[id]
+	if !ok || session.ProjectId != projectID {
+		return fmt.Errorf("not found")
+	}
+	session.Revoked = true
+	session.RevokedBy = revokedBy
+	return nil
+}
+
+func findCookie(t *testing.T, rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("cookie %s was not found", name)
+	return nil
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeSessionStore{sessions: make(map[string]*model.Session)}
+	h := NewHandler(
+		fakeSigner{},
+		store,
+		config.ControlPlaneSession{
+			AccessTokenTTL: config.Duration(10 * time.Minute),
+			IdleTimeout:    config.Duration(time.Hour),
+			MaxLifetime:    config.Duration(24 * time.Hour),
+		},
+		nil,
+		"https://pipecd.dev",
+		"state-key",
+		nil,
+		nil,
+		nil,
+		true,
+		zap.NewNop(),
+	)
+	h.nowFunc = func() time.Time { return now }
+
+	// Start a new session.
+	tokens, err := h.startSession(context.Background(), "user", "avatar", &model.Role{
+		ProjectId:   "project",
+		ProjectRole: model.Role_EDITOR,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(store.sessions))
+	assert.Equal(t, 24*60*60, tokens.cookieMaxAge)
+
+	var session *model.Session
+	for _, s := range store.sessions {
+		session = s
+	}
+	assert.Equal(t, "project", session.ProjectId)
+	assert.Equal(t, now.Add(24*time.Hour).Unix(), session.ExpiresAt)
+	assert.Equal(t, fmt.Sprintf("user:%s:600", session.Id), tokens.accessToken)
+
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, refreshPath, nil)
+		req.AddCookie(&http.Cookie{Name: refreshTokenCookieKey, Value: refreshToken})
+		rec := httptest.NewRecorder()
+		h.handleRefresh(rec, req)
+		return rec
+	}
+
+	// Refresh with the issued refresh token.
+	now = now.Add(30 * time.Minute)
+	rec := refresh(tokens.refreshToken)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	newRefreshToken := findCookie(t, rec, refreshTokenCookieKey).Value
+	assert.NotEqual(t, tokens.refreshToken, newRefreshToken)
+	assert.Equal(t, fmt.Sprintf("user:%s:600", session.Id), findCookie(t, rec, jwt.SignedTokenKey).Value)
+
+	// Reusing the old refresh token after rotation revokes the session.
+	rec = refresh(tokens.refreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.True(t, session.Revoked)
+	assert.Equal(t, model.SessionRevokedByReuseDetection, session.RevokedBy)
+
+	rec = refresh(newRefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Restore the session to check the other cases.
+	session.Revoked = false
+	session.RevokedBy = ""
+
+	// Refresh is rejected after being idle for too long.
+	now = now.Add(2 * time.Hour)
+	rec = refresh(newRefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Refresh is rejected after the session was revoked.
+	session.LastActiveAt = now.Unix()
+	req := httptest.NewRequest(http.MethodGet, logoutPath, nil)
+	req.AddCookie(&http.Cookie{Name: refreshTokenCookieKey, Value: newRefreshToken})
+	h.handleLogout(httptest.NewRecorder(), req)
+	assert.True(t, session.Revoked)
+	assert.Equal(t, "user", session.RevokedBy)
+
+	rec = refresh(newRefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Only POST is allowed.
+	rec = httptest.NewRecorder()
+	h.handleRefresh(rec, httptest.NewRequest(http.MethodGet, refreshPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commandoutputstore provides a way to store the output data
+// reported by piped after handling a command.
+package commandoutputstore
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/filestore"
+)
+
+type Store interface {
+	// Get returns the output data of the specified command.
+	Get(ctx context.Context, commandID string) ([]byte, error)
+	// Put saves the output data of the specified command.
+	Put(ctx context.Context, commandID string, data []byte) error
+}
+
+type store struct {
+	backend filestore.Store
+	logger  *zap.Logger
+}
+
+func NewStore(fs filestore.Store, logger *zap.Logger) Store {
+	return &store{
+		backend: fs,
+		logger:  logger.Named("command-output-store"),
+	}
+}
+
+func (s *store) Get(ctx context.Context, commandID string) ([]byte, error) {
+	obj, err := s.backend.GetObject(ctx, commandOutputPath(commandID))
+	if err != nil {
+		if err != filestore.ErrNotFound {
+			s.logger.Error("failed to get command output from filestore",
+				zap.String("command-id", commandID),
+				zap.Error(err),
+			)
+		}
+		return nil, err
+	}
+	return obj.Content, nil
+}
+
+func (s *store) Put(ctx context.Context, commandID string, data []byte) error {
+	if err := s.backend.PutObject(ctx, commandOutputPath(commandID), data); err != nil {
+		s.logger.Error("failed to put command output to filestore",
+			zap.String("command-id", commandID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+func commandOutputPath(commandID string) string {
+	return fmt.Sprintf("command-output/%s.data", commandID)
+}
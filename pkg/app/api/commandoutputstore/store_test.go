@@ -0,0 +1,81 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandoutputstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/filestore"
+	"github.com/pipe-cd/pipe/pkg/filestore/filestoretest"
+)
+
+func TestGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := []struct {
+		name        string
+		commandID   string
+		content     string
+		getErr      error
+		expected    []byte
+		expectedErr error
+	}{
+		{
+			name:        "not found",
+			commandID:   "command-1",
+			getErr:      filestore.ErrNotFound,
+			expectedErr: filestore.ErrNotFound,
+		},
+		{
+			name:      "found",
+			commandID: "command-2",
+			content:   "output",
+			expected:  []byte("output"),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := filestoretest.NewMockStore(ctrl)
+			fs.EXPECT().
+				GetObject(gomock.Any(), "command-output/"+tc.commandID+".data").
+				Return(filestore.Object{Content: []byte(tc.content)}, tc.getErr)
+
+			s := NewStore(fs, zap.NewNop())
+			got, err := s.Get(context.Background(), tc.commandID)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestPut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fs := filestoretest.NewMockStore(ctrl)
+	fs.EXPECT().PutObject(gomock.Any(), "command-output/command-1.data", []byte("output")).Return(nil)
+	fs.EXPECT().PutObject(gomock.Any(), "command-output/command-2.data", []byte("output")).Return(errors.New("error"))
+
+	s := NewStore(fs, zap.NewNop())
+	assert.NoError(t, s.Put(context.Background(), "command-1", []byte("output")))
+	assert.Error(t, s.Put(context.Background(), "command-2", []byte("output")))
+}
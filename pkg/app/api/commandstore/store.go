@@ -23,6 +23,7 @@ import (
 	"github.com/pipe-cd/pipe/pkg/cache"
 	"github.com/pipe-cd/pipe/pkg/datastore"
 	"github.com/pipe-cd/pipe/pkg/model"
+	"github.com/pipe-cd/pipe/pkg/redis"
 )
 
 type Store interface {
@@ -30,21 +31,32 @@ type Store interface {
 	AddCommand(ctx context.Context, command *model.Command) error
 	GetCommand(ctx context.Context, id string) (*model.Command, error)
 	UpdateCommandHandled(ctx context.Context, id string, status model.CommandStatus, metadata map[string]string, unhandledAt int64) error
+	// WatchUnhandledCommands returns a channel notified whenever a new command
+	// was added for the given piped and a function to stop watching.
+	WatchUnhandledCommands(pipedID string) (<-chan struct{}, func())
+	// Run receives the commands added via all servers of the control plane
+	// to notify the watchers until the given context is done.
+	Run(ctx context.Context) error
 }
 
 type store struct {
 	backend datastore.CommandStore
 	cache   *commandCache
+	watcher *watcher
 	logger  *zap.Logger
 }
 
-func NewStore(ds datastore.DataStore, c cache.Cache, logger *zap.Logger) Store {
+// NewStore creates a new command store.
+// The given redis is used to notify the watchers at all servers about the added commands.
+// Only the local watchers are notified when it is nil.
+func NewStore(ds datastore.DataStore, c cache.Cache, rd redis.Redis, logger *zap.Logger) Store {
 	return &store{
 		backend: datastore.NewCommandStore(ds),
 		cache: &commandCache{
 			backend: c,
 		},
-		logger: logger,
+		watcher: newWatcher(rd, logger),
+		logger:  logger,
 	}
 }
 
@@ -79,6 +91,8 @@ func (s *store) AddCommand(ctx context.Context, command *model.Command) error {
 	if err := s.cache.Put(command.Id, command); err != nil {
 		s.logger.Error("failed to put command to cache", zap.Error(err))
 	}
+
+	s.watcher.publish(command.PipedId)
 	return nil
 }
 
@@ -119,3 +133,11 @@ func (s *store) UpdateCommandHandled(ctx context.Context, id string, status mode
 	}
 	return nil
 }
+
+func (s *store) WatchUnhandledCommands(pipedID string) (<-chan struct{}, func()) {
+	return s.watcher.watch(pipedID)
+}
+
+func (s *store) Run(ctx context.Context) error {
+	return s.watcher.run(ctx)
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/redis"
+)
+
+const commandAddedChannel = "pipecd:command-added"
+
+var resubscribeInterval = 5 * time.Second
+
+// watcher notifies the watching pipeds whenever a new command was added for them.
+// Since the commands can be added via any server of the control plane,
+// the ID of the piped is published through Redis to all servers.
+type watcher struct {
+	redis    redis.Redis
+	watchers map[string]map[chan struct{}]struct{}
+	mu       sync.Mutex
+	logger   *zap.Logger
+}
+
+func newWatcher(rd redis.Redis, logger *zap.Logger) *watcher {
+	return &watcher{
+		redis:    rd,
+		watchers: make(map[string]map[chan struct{}]struct{}),
+		logger:   logger,
+	}
+}
+
+// watch returns a channel notified whenever a new command was added for the given piped
+// and a function to stop watching.
+func (w *watcher) watch(pipedID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	if _, ok := w.watchers[pipedID]; !ok {
+		w.watchers[pipedID] = make(map[chan struct{}]struct{})
+	}
+	w.watchers[pipedID][ch] = struct{}{}
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.watchers[pipedID], ch)
+		if len(w.watchers[pipedID]) == 0 {
+			delete(w.watchers, pipedID)
+		}
+	}
+}
+
+// notify notifies the local watchers of the given piped without blocking.
+func (w *watcher) notify(pipedID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.watchers[pipedID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// publish notifies the watchers of the given piped at all servers.
+// The local watchers are still notified when it was unable to publish.
+func (w *watcher) publish(pipedID string) {
+	if w.redis == nil {
+		w.notify(pipedID)
+		return
+	}
+	conn := w.redis.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PUBLISH", commandAddedChannel, pipedID); err != nil {
+		w.logger.Error("failed to publish the added command", zap.Error(err))
+		w.notify(pipedID)
+	}
+}
+
+// run receives the IDs of pipeds published by all servers
+// and notifies their local watchers until the given context is done.
+func (w *watcher) run(ctx context.Context) error {
+	if w.redis == nil {
+		return nil
+	}
+	for {
+		if err := w.subscribe(ctx); err != nil {
+			w.logger.Error("failed to subscribe the added commands", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(resubscribeInterval):
+		}
+	}
+}
+
+func (w *watcher) subscribe(ctx context.Context) error {
+	conn := redigo.PubSubConn{Conn: w.redis.Get()}
+	defer conn.Close()
+
+	if err := conn.Subscribe(commandAddedChannel); err != nil {
+		return err
+	}
+
+	// Receive is blocked until a message arrives
+	// so it is unsubscribed to unblock it when the context is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Unsubscribe()
+		case <-done:
+		}
+	}()
+
+	for {
+		switch v := conn.Receive().(type) {
+		case redigo.Message:
+			w.notify(string(v.Data))
+		case redigo.Subscription:
+			if v.Count == 0 {
+				return nil
+			}
+		case error:
+			return v
+		}
+	}
+}
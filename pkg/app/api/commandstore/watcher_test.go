@@ -0,0 +1,44 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWatcher(t *testing.T) {
+	w := newWatcher(nil, zap.NewNop())
+
+	ch1, unwatch1 := w.watch("piped-1")
+	ch2, unwatch2 := w.watch("piped-2")
+	defer unwatch2()
+
+	// Multiple notifications must not block.
+	w.publish("piped-1")
+	w.publish("piped-1")
+
+	assert.Len(t, ch1, 1)
+	assert.Len(t, ch2, 0)
+	<-ch1
+
+	unwatch1()
+	w.publish("piped-1")
+	assert.Len(t, ch1, 0)
+	assert.NotContains(t, w.watchers, "piped-1")
+	assert.Contains(t, w.watchers, "piped-2")
+}
@@ -16,8 +16,10 @@ package grpcapi
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -25,21 +27,25 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/pipe-cd/pipe/pkg/app/api/commandoutputstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/commandstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
 	"github.com/pipe-cd/pipe/pkg/datastore"
+	"github.com/pipe-cd/pipe/pkg/filestore"
+	"github.com/pipe-cd/pipe/pkg/git"
 	"github.com/pipe-cd/pipe/pkg/model"
 	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
 )
 
 // API implements the behaviors for the gRPC definitions of API.
 type API struct {
-	applicationStore datastore.ApplicationStore
-	environmentStore datastore.EnvironmentStore
-	deploymentStore  datastore.DeploymentStore
-	pipedStore       datastore.PipedStore
-	eventStore       datastore.EventStore
-	commandStore     commandstore.Store
+	applicationStore   datastore.ApplicationStore
+	environmentStore   datastore.EnvironmentStore
+	deploymentStore    datastore.DeploymentStore
+	pipedStore         datastore.PipedStore
+	eventStore         datastore.EventStore
+	commandStore       commandstore.Store
+	commandOutputStore commandoutputstore.Store
 
 	logger *zap.Logger
 }
@@ -48,16 +54,18 @@ type API struct {
 func NewAPI(
 	ds datastore.DataStore,
 	cmds commandstore.Store,
+	cop commandoutputstore.Store,
 	logger *zap.Logger,
 ) *API {
 	a := &API{
-		applicationStore: datastore.NewApplicationStore(ds),
-		environmentStore: datastore.NewEnvironmentStore(ds),
-		deploymentStore:  datastore.NewDeploymentStore(ds),
-		pipedStore:       datastore.NewPipedStore(ds),
-		eventStore:       datastore.NewEventStore(ds),
-		commandStore:     cmds,
-		logger:           logger.Named("api"),
+		applicationStore:   datastore.NewApplicationStore(ds),
+		environmentStore:   datastore.NewEnvironmentStore(ds),
+		deploymentStore:    datastore.NewDeploymentStore(ds),
+		pipedStore:         datastore.NewPipedStore(ds),
+		eventStore:         datastore.NewEventStore(ds),
+		commandStore:       cmds,
+		commandOutputStore: cop,
+		logger:             logger.Named("api"),
 	}
 	return a
 }
@@ -131,6 +139,9 @@ func (a *API) SyncApplication(ctx context.Context, req *apiservice.SyncApplicati
 	if key.ProjectId != app.ProjectId {
 		return nil, status.Error(codes.InvalidArgument, "Requested application does not belong to your project")
 	}
+	if err := ensureApplicationEnabled(app); err != nil {
+		return nil, err
+	}
 
 	cmd := model.Command{
 		Id:            uuid.New().String(),
@@ -294,6 +305,52 @@ func (a *API) ListApplications(ctx context.Context, req *apiservice.ListApplicat
 	}, nil
 }
 
+// EnableApplication allows the specified application to be deployed again.
+func (a *API) EnableApplication(ctx context.Context, req *apiservice.EnableApplicationRequest) (*apiservice.EnableApplicationResponse, error) {
+	if err := a.updateApplicationEnable(ctx, req.ApplicationId, true, ""); err != nil {
+		return nil, err
+	}
+	return &apiservice.EnableApplicationResponse{}, nil
+}
+
+// DisableApplication temporarily stops deploying the specified application.
+// The given reason is shown on the web console and returned when triggering the application.
+func (a *API) DisableApplication(ctx context.Context, req *apiservice.DisableApplicationRequest) (*apiservice.DisableApplicationResponse, error) {
+	if err := a.updateApplicationEnable(ctx, req.ApplicationId, false, req.Reason); err != nil {
+		return nil, err
+	}
+	return &apiservice.DisableApplicationResponse{}, nil
+}
+
+func (a *API) updateApplicationEnable(ctx context.Context, appID string, enable bool, reason string) error {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_WRITE, a.logger)
+	if err != nil {
+		return err
+	}
+
+	app, err := getApplication(ctx, a.applicationStore, appID, a.logger)
+	if err != nil {
+		return err
+	}
+	if key.ProjectId != app.ProjectId {
+		return status.Error(codes.InvalidArgument, "Requested application does not belong to your project")
+	}
+
+	if enable {
+		err = a.applicationStore.EnableApplication(ctx, appID)
+	} else {
+		err = a.applicationStore.DisableApplication(ctx, appID, reason)
+	}
+	if err != nil {
+		a.logger.Error("failed to update the application",
+			zap.String("application-id", appID),
+			zap.Error(err),
+		)
+		return status.Error(codes.Internal, "Failed to update the application")
+	}
+	return nil
+}
+
 func (a *API) GetDeployment(ctx context.Context, req *apiservice.GetDeploymentRequest) (*apiservice.GetDeploymentResponse, error) {
 	key, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
 	if err != nil {
@@ -314,6 +371,156 @@ func (a *API) GetDeployment(ctx context.Context, req *apiservice.GetDeploymentRe
 	}, nil
 }
 
+// AddDeploymentNote attaches a free-text note such as an incident ticket
+// or a change record number to the specified deployment.
+func (a *API) AddDeploymentNote(ctx context.Context, req *apiservice.AddDeploymentNoteRequest) (*apiservice.AddDeploymentNoteResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_WRITE, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if key.ProjectId != deployment.ProjectId {
+		return nil, status.Error(codes.InvalidArgument, "Requested deployment does not belong to your project")
+	}
+
+	note := &model.DeploymentNote{
+		Text:      req.Text,
+		Url:       req.Url,
+		Commander: key.Id,
+	}
+	if err := a.deploymentStore.AddDeploymentNote(ctx, req.DeploymentId, note); err != nil {
+		a.logger.Error("failed to add a note to the deployment",
+			zap.String("deployment-id", req.DeploymentId),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, "Failed to add a note to the deployment")
+	}
+	return &apiservice.AddDeploymentNoteResponse{}, nil
+}
+
+// ListDeployments returns the deployment list of the project where the caller belongs to.
+// Currently, the maximum number of returned deployments per request is set to 10.
+// The response contains a "cursor" value, which should be passed in the next request in order to get
+// the next 10 deployments. If the cursor is not provided in the request, only 10 latest deployments will be returned.
+func (a *API) ListDeployments(ctx context.Context, req *apiservice.ListDeploymentsRequest) (*apiservice.ListDeploymentsResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	const limit = 10
+	orders := []datastore.Order{
+		{
+			Field:     "UpdatedAt",
+			Direction: datastore.Desc,
+		},
+		{
+			Field:     "Id",
+			Direction: datastore.Asc,
+		},
+	}
+	filters := []datastore.ListFilter{
+		{
+			Field:    "ProjectId",
+			Operator: "==",
+			Value:    key.ProjectId,
+		},
+	}
+
+	if req.ApplicationId != "" {
+		filters = append(filters, datastore.ListFilter{
+			Field:    "ApplicationId",
+			Operator: "==",
+			Value:    req.ApplicationId,
+		})
+	}
+	if req.EnvId != "" {
+		filters = append(filters, datastore.ListFilter{
+			Field:    "EnvId",
+			Operator: "==",
+			Value:    req.EnvId,
+		})
+	}
+	if req.Kind != "" {
+		kind, ok := model.ApplicationKind_value[req.Kind]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "Invalid application kind")
+		}
+		filters = append(filters, datastore.ListFilter{
+			Field:    "Kind",
+			Operator: "==",
+			Value:    model.ApplicationKind(kind),
+		})
+	}
+	if req.Status != "" {
+		s, ok := model.DeploymentStatus_value[req.Status]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "Invalid deployment status")
+		}
+		filters = append(filters, datastore.ListFilter{
+			Field:    "Status",
+			Operator: "==",
+			Value:    model.DeploymentStatus(s),
+		})
+	}
+
+	deployments, cursor, err := a.deploymentStore.ListDeployments(ctx, datastore.ListOptions{
+		Orders:  orders,
+		Filters: filters,
+		Limit:   limit,
+		Cursor:  req.Cursor,
+	})
+	if err != nil {
+		a.logger.Error("failed to list deployments", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list deployments")
+	}
+
+	return &apiservice.ListDeploymentsResponse{
+		Deployments: deployments,
+		Cursor:      cursor,
+	}, nil
+}
+
+// ListPipeds returns the piped list of the project where the caller belongs to.
+// All sensitive data such as the key hashes are redacted.
+func (a *API) ListPipeds(ctx context.Context, req *apiservice.ListPipedsRequest) (*apiservice.ListPipedsResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeds, err := a.pipedStore.ListPipeds(ctx, datastore.ListOptions{
+		Filters: []datastore.ListFilter{
+			{
+				Field:    "ProjectId",
+				Operator: "==",
+				Value:    key.ProjectId,
+			},
+			{
+				Field:    "Disabled",
+				Operator: "==",
+				Value:    req.Disabled,
+			},
+		},
+	})
+	if err != nil {
+		a.logger.Error("failed to list pipeds", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list pipeds")
+	}
+
+	for i := range pipeds {
+		pipeds[i].RedactSensitiveData()
+	}
+
+	return &apiservice.ListPipedsResponse{
+		Pipeds: pipeds,
+	}, nil
+}
+
 func (a *API) GetCommand(ctx context.Context, req *apiservice.GetCommandRequest) (*apiservice.GetCommandResponse, error) {
 	_, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
 	if err != nil {
@@ -341,6 +548,7 @@ func (a *API) RegisterEvent(ctx context.Context, req *apiservice.RegisterEventRe
 		Name:      req.Name,
 		Data:      req.Data,
 		Labels:    req.Labels,
+		Contexts:  req.Contexts,
 		EventKey:  model.MakeEventKey(req.Name, req.Labels),
 		ProjectId: key.ProjectId,
 	})
@@ -355,6 +563,178 @@ func (a *API) RegisterEvent(ctx context.Context, req *apiservice.RegisterEventRe
 	return &apiservice.RegisterEventResponse{}, nil
 }
 
+// EncryptSecret encrypts the given data by using the public key of the specified piped.
+// The returned ciphertext can be stored in a SealedSecret file to be decrypted by that piped.
+func (a *API) EncryptSecret(ctx context.Context, req *apiservice.EncryptSecretRequest) (*apiservice.EncryptSecretResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	piped, err := getPiped(ctx, a.pipedStore, req.PipedId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if piped.ProjectId != key.ProjectId {
+		return nil, status.Error(codes.PermissionDenied, "Requested piped does not belong to your project")
+	}
+
+	ciphertext, err := encryptSecret(piped, req.Data, req.Base64Encoding, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiservice.EncryptSecretResponse{
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// RequestPlanPreview sends a command to build the plan preview to every piped
+// that has the requested repository in its configuration.
+// The returned command IDs should be passed to GetPlanPreviewResults to retrieve the results.
+func (a *API) RequestPlanPreview(ctx context.Context, req *apiservice.RequestPlanPreviewRequest) (*apiservice.RequestPlanPreviewResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeds, err := a.pipedStore.ListPipeds(ctx, datastore.ListOptions{
+		Filters: []datastore.ListFilter{
+			{
+				Field:    "ProjectId",
+				Operator: "==",
+				Value:    key.ProjectId,
+			},
+			{
+				Field:    "Disabled",
+				Operator: "==",
+				Value:    false,
+			},
+		},
+	})
+	if err != nil {
+		a.logger.Error("failed to list pipeds", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list pipeds")
+	}
+
+	commands := make([]string, 0, len(pipeds))
+	for _, piped := range pipeds {
+		for _, repo := range piped.Repositories {
+			if !git.IsSameRepository(repo.Remote, req.RepoRemoteUrl) {
+				continue
+			}
+			cmd := model.Command{
+				Id:        uuid.New().String(),
+				PipedId:   piped.Id,
+				ProjectId: piped.ProjectId,
+				Type:      model.Command_BUILD_PLAN_PREVIEW,
+				Commander: key.Id,
+				BuildPlanPreview: &model.Command_BuildPlanPreview{
+					RepositoryId: repo.Id,
+					HeadBranch:   req.HeadBranch,
+					HeadCommit:   req.HeadCommit,
+					BaseBranch:   req.BaseBranch,
+					Timeout:      req.Timeout,
+				},
+			}
+			if err := addCommand(ctx, a.commandStore, &cmd, a.logger); err != nil {
+				return nil, err
+			}
+			commands = append(commands, cmd.Id)
+			// Only one command is needed for each piped.
+			break
+		}
+	}
+
+	return &apiservice.RequestPlanPreviewResponse{
+		Commands: commands,
+	}, nil
+}
+
+// GetPlanPreviewResults returns the results of the given plan preview commands.
+// NotFound error is returned while any of the commands is still being handled
+// so the caller is expected to retry after a while.
+func (a *API) GetPlanPreviewResults(ctx context.Context, req *apiservice.GetPlanPreviewResultsRequest) (*apiservice.GetPlanPreviewResultsResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	const defaultCommandHandleTimeout = 5 * time.Minute
+	var (
+		handledCommands = make([]*model.Command, 0, len(req.Commands))
+		results         = make([]*model.PlanPreviewCommandResult, 0, len(req.Commands))
+		timeout         = time.Duration(req.CommandHandleTimeout) * time.Second
+	)
+	if timeout == 0 {
+		timeout = defaultCommandHandleTimeout
+	}
+
+	// Ensure that all commands have been handled or timed out.
+	for _, id := range req.Commands {
+		cmd, err := getCommand(ctx, a.commandStore, id, a.logger)
+		if err != nil {
+			return nil, err
+		}
+		if cmd.ProjectId != key.ProjectId {
+			return nil, status.Error(codes.InvalidArgument, "Requested command does not belong to your project")
+		}
+		if cmd.Type != model.Command_BUILD_PLAN_PREVIEW {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Requested command %s is not a plan preview command", id))
+		}
+
+		if cmd.Status != model.CommandStatus_COMMAND_NOT_HANDLED_YET {
+			handledCommands = append(handledCommands, cmd)
+			continue
+		}
+		if time.Since(time.Unix(cmd.CreatedAt, 0)) <= timeout {
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("Command %s is still being handled", id))
+		}
+		results = append(results, &model.PlanPreviewCommandResult{
+			CommandId: cmd.Id,
+			PipedId:   cmd.PipedId,
+			Error:     "Timed out, maybe the piped is offline currently",
+		})
+	}
+
+	for _, cmd := range handledCommands {
+		result, err := a.getPlanPreviewCommandResult(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return &apiservice.GetPlanPreviewResultsResponse{
+		Results: results,
+	}, nil
+}
+
+func (a *API) getPlanPreviewCommandResult(ctx context.Context, cmd *model.Command) (*model.PlanPreviewCommandResult, error) {
+	data, err := a.commandOutputStore.Get(ctx, cmd.Id)
+	if errors.Is(err, filestore.ErrNotFound) {
+		result := &model.PlanPreviewCommandResult{
+			CommandId: cmd.Id,
+			PipedId:   cmd.PipedId,
+			Error:     fmt.Sprintf("No output was reported for the command (status: %s)", cmd.Status),
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retrieve output data of command")
+	}
+
+	var result model.PlanPreviewCommandResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		a.logger.Error("failed to unmarshal plan preview command result",
+			zap.String("command-id", cmd.Id),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, "Failed to decode output data of command")
+	}
+	return &result, nil
+}
+
 // requireAPIKey checks the existence of an API key inside the given context
 // and ensures that it has enough permissions for the give role.
 func requireAPIKey(ctx context.Context, role model.APIKey_Role, logger *zap.Logger) (*model.APIKey, error) {
@@ -17,14 +17,21 @@ package grpcapi
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/applicationlivestatestore"
+	"github.com/pipe-cd/pipe/pkg/app/api/approvallink"
+	"github.com/pipe-cd/pipe/pkg/app/api/commandoutputstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/commandstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	"github.com/pipe-cd/pipe/pkg/app/api/stagelogstore"
@@ -35,6 +42,35 @@ import (
 	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
 )
 
+// maxApprovalLinkTTL is the maximum duration the approval links are valid for.
+const maxApprovalLinkTTL = 7 * 24 * time.Hour
+
+type approvalLinkSigner interface {
+	Sign(c *approvallink.Claims) (string, error)
+}
+
+// ChunkedReportLimits limits the data reassembled on the server
+// from the chunks sent by piped in one stream.
+// Zero means no limit for each of them.
+type ChunkedReportLimits struct {
+	// The maximum total size in bytes of all chunks.
+	MaxSize int
+	// The maximum number of chunks.
+	MaxChunks int
+}
+
+// check returns a ResourceExhausted error if the given number of chunks
+// or their total size exceeded the limits.
+func (l ChunkedReportLimits) check(chunks, size int) error {
+	if l.MaxChunks > 0 && chunks > l.MaxChunks {
+		return status.Error(codes.ResourceExhausted, fmt.Sprintf("too many chunks: exceeded the limit of %d chunks", l.MaxChunks))
+	}
+	if l.MaxSize > 0 && size > l.MaxSize {
+		return status.Error(codes.ResourceExhausted, fmt.Sprintf("reported data is too large: exceeded the limit of %d bytes", l.MaxSize))
+	}
+	return nil
+}
+
 // PipedAPI implements the behaviors for the gRPC definitions of PipedAPI.
 type PipedAPI struct {
 	applicationStore          datastore.ApplicationStore
@@ -47,16 +83,22 @@ type PipedAPI struct {
 	stageLogStore             stagelogstore.Store
 	applicationLiveStateStore applicationlivestatestore.Store
 	commandStore              commandstore.Store
+	commandOutputStore        commandoutputstore.Store
+	approvalLinkSigner        approvalLinkSigner
+	address                   string
+	chunkedReportLimits       ChunkedReportLimits
 
 	appPipedCache        cache.Cache
+	appRepositoryCache   cache.Cache
 	deploymentPipedCache cache.Cache
+	deploymentAppCache   cache.Cache
 	envProjectCache      cache.Cache
 
 	logger *zap.Logger
 }
 
 // NewPipedAPI creates a new PipedAPI instance.
-func NewPipedAPI(ctx context.Context, ds datastore.DataStore, sls stagelogstore.Store, alss applicationlivestatestore.Store, cs commandstore.Store, logger *zap.Logger) *PipedAPI {
+func NewPipedAPI(ctx context.Context, ds datastore.DataStore, sls stagelogstore.Store, alss applicationlivestatestore.Store, cs commandstore.Store, cop commandoutputstore.Store, als approvalLinkSigner, address string, limits ChunkedReportLimits, logger *zap.Logger) *PipedAPI {
 	a := &PipedAPI{
 		applicationStore:          datastore.NewApplicationStore(ds),
 		deploymentStore:           datastore.NewDeploymentStore(ds),
@@ -68,8 +110,14 @@ func NewPipedAPI(ctx context.Context, ds datastore.DataStore, sls stagelogstore.
 		stageLogStore:             sls,
 		applicationLiveStateStore: alss,
 		commandStore:              cs,
+		commandOutputStore:        cop,
+		approvalLinkSigner:        als,
+		address:                   address,
+		chunkedReportLimits:       limits,
 		appPipedCache:             memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
+		appRepositoryCache:        memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
 		deploymentPipedCache:      memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
+		deploymentAppCache:        memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
 		envProjectCache:           memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
 		logger:                    logger.Named("piped-api"),
 	}
@@ -83,9 +131,32 @@ func (a *PipedAPI) Register(server *grpc.Server) {
 
 // Ping is periodically sent to report its realtime status/stats to control-plane.
 // The received stats will be pushed to the metrics collector.
+// The reported component statuses will be saved to let web show the health of piped.
 func (a *PipedAPI) Ping(ctx context.Context, req *pipedservice.PingRequest) (*pipedservice.PingResponse, error) {
+	statuses := req.PipedStats.ComponentStatuses
+	if len(statuses) == 0 {
+		return &pipedservice.PingResponse{}, nil
+	}
+
+	_, pipedID, _, err := rpcauth.ExtractPipedToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.pipedStore.UpdatePiped(ctx, pipedID, datastore.PipedComponentStatusesUpdater(statuses)); err != nil {
+		switch err {
+		case datastore.ErrNotFound:
+			return nil, status.Error(codes.InvalidArgument, "piped is not found")
+		case datastore.ErrInvalidArgument:
+			return nil, status.Error(codes.InvalidArgument, "invalid value for update")
+		default:
+			a.logger.Error("failed to update the piped component statuses",
+				zap.String("piped-id", pipedID),
+				zap.Error(err),
+			)
+			return nil, status.Error(codes.Internal, "failed to update the piped component statuses")
+		}
+	}
 	return &pipedservice.PingResponse{}, nil
-	// return nil, status.Error(codes.Unimplemented, "")
 }
 
 // ReportPipedMeta is sent by piped while starting up to report its metadata
@@ -99,7 +170,7 @@ func (a *PipedAPI) ReportPipedMeta(ctx context.Context, req *pipedservice.Report
 	now := time.Now().Unix()
 	connStatus := model.Piped_ONLINE
 
-	if err = a.pipedStore.UpdatePiped(ctx, pipedID, datastore.PipedMetadataUpdater(req.CloudProviders, req.Repositories, connStatus, req.SealedSecretEncryption, req.Version, now)); err != nil {
+	if err = a.pipedStore.UpdatePiped(ctx, pipedID, datastore.PipedMetadataUpdater(req.CloudProviders, req.Repositories, connStatus, req.SealedSecretEncryption, req.ComponentStatuses, req.Version, now)); err != nil {
 		switch err {
 		case datastore.ErrNotFound:
 			return nil, status.Error(codes.InvalidArgument, "piped is not found")
@@ -155,26 +226,62 @@ func (a *PipedAPI) ListApplications(ctx context.Context, req *pipedservice.ListA
 				Operator: "==",
 				Value:    projectID,
 			},
-			{
+		},
+	}
+	// Only the changes since the given time are returned when it was specified.
+	// The disabled applications are included to let piped remove them from its snapshot.
+	// The applications of all pipeds are listed to find the ones moved to another piped.
+	if req.UpdatedAfter > 0 {
+		opts.Filters = append(opts.Filters, datastore.ListFilter{
+			Field:    "UpdatedAt",
+			Operator: ">=",
+			Value:    req.UpdatedAfter,
+		})
+	} else {
+		opts.Filters = append(opts.Filters,
+			datastore.ListFilter{
 				Field:    "PipedId",
 				Operator: "==",
 				Value:    pipedID,
 			},
-			{
+			datastore.ListFilter{
 				Field:    "Disabled",
 				Operator: "==",
 				Value:    false,
 			},
-		},
+		)
 	}
+
+	syncedAt := time.Now().Unix()
 	// TODO: Support pagination in ListApplications
 	apps, _, err := a.applicationStore.ListApplications(ctx, opts)
 	if err != nil {
 		a.logger.Error("failed to fetch applications", zap.Error(err))
 		return nil, status.Error(codes.Internal, "failed to fetch applications")
 	}
+
+	var (
+		scope        = rpcauth.ExtractPipedKeyScope(ctx)
+		managed      = make([]*model.Application, 0, len(apps))
+		unmanagedIDs []string
+	)
+	for _, app := range apps {
+		// The applications out of the scope of the piped key must not be exposed.
+		if !scope.IsUnrestricted() && !scope.AllowApplication(app.Id, app.GitPath.GetRepo().GetId()) {
+			continue
+		}
+		// Only the IDs of the applications of other pipeds are returned
+		// to let piped remove the ones moved away from its snapshot.
+		if app.PipedId != pipedID {
+			unmanagedIDs = append(unmanagedIDs, app.Id)
+			continue
+		}
+		managed = append(managed, app)
+	}
 	return &pipedservice.ListApplicationsResponse{
-		Applications: apps,
+		Applications:            managed,
+		SyncedAt:                syncedAt,
+		UnmanagedApplicationIds: unmanagedIDs,
 	}, nil
 }
 
@@ -313,25 +420,47 @@ func (a *PipedAPI) ListNotCompletedDeployments(ctx context.Context, req *pipedse
 				Operator: "==",
 				Value:    pipedID,
 			},
-			// TODO: Change to simple conditional clause without using OR clause for portability
-			// Note: firestore does not support OR operator.
-			// See more: https://firebase.google.com/docs/firestore/query-data/queries?hl=en
-			{
-				Field:    "Status",
-				Operator: "in",
-				Value:    model.GetNotCompletedDeploymentStatuses(),
-			},
 		},
 	}
+	// Only the changes since the given time are returned when it was specified.
+	// The completed deployments are included to let piped remove them from its snapshot.
+	if req.UpdatedAfter > 0 {
+		opts.Filters = append(opts.Filters, datastore.ListFilter{
+			Field:    "UpdatedAt",
+			Operator: ">=",
+			Value:    req.UpdatedAfter,
+		})
+	} else {
+		// TODO: Change to simple conditional clause without using OR clause for portability
+		// Note: firestore does not support OR operator.
+		// See more: https://firebase.google.com/docs/firestore/query-data/queries?hl=en
+		opts.Filters = append(opts.Filters, datastore.ListFilter{
+			Field:    "Status",
+			Operator: "in",
+			Value:    model.GetNotCompletedDeploymentStatuses(),
+		})
+	}
 
+	syncedAt := time.Now().Unix()
 	deployments, cursor, err := a.deploymentStore.ListDeployments(ctx, opts)
 	if err != nil {
 		a.logger.Error("failed to fetch deployments", zap.Error(err))
 		return nil, status.Error(codes.Internal, "failed to fetch deployments")
 	}
+	// The deployments out of the scope of the piped key must not be exposed.
+	if scope := rpcauth.ExtractPipedKeyScope(ctx); !scope.IsUnrestricted() {
+		filtered := make([]*model.Deployment, 0, len(deployments))
+		for _, d := range deployments {
+			if scope.AllowApplication(d.ApplicationId, d.GitPath.GetRepo().GetId()) {
+				filtered = append(filtered, d)
+			}
+		}
+		deployments = filtered
+	}
 	return &pipedservice.ListNotCompletedDeploymentsResponse{
 		Deployments: deployments,
 		Cursor:      cursor,
+		SyncedAt:    syncedAt,
 	}, nil
 }
 
@@ -502,6 +631,92 @@ func (a *PipedAPI) SaveStageMetadata(ctx context.Context, req *pipedservice.Save
 	return &pipedservice.SaveStageMetadataResponse{}, nil
 }
 
+// CreateApprovalLinks issues the one-time links for the given users
+// to approve or reject a WAIT_APPROVAL stage of a deployment of the piped.
+func (a *PipedAPI) CreateApprovalLinks(ctx context.Context, req *pipedservice.CreateApprovalLinksRequest) (*pipedservice.CreateApprovalLinksResponse, error) {
+	projectID, pipedID, _, err := rpcauth.ExtractPipedToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.validateDeploymentBelongsToPiped(ctx, req.DeploymentId, pipedID); err != nil {
+		return nil, err
+	}
+
+	d, err := a.deploymentStore.GetDeployment(ctx, req.DeploymentId)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "the deployment is not found")
+	}
+	if err != nil {
+		a.logger.Error("failed to get deployment", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get deployment")
+	}
+	if err := validateApprovalLinkTarget(d, req.StageId, req.Approvers); err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(req.Ttl) * time.Second
+	if ttl > maxApprovalLinkTTL {
+		ttl = maxApprovalLinkTTL
+	}
+	expiresAt := time.Now().Add(ttl).Unix()
+
+	links := make(map[string]string, len(req.Approvers))
+	for _, approver := range req.Approvers {
+		token, err := a.approvalLinkSigner.Sign(&approvallink.Claims{
+			ID:           uuid.New().String(),
+			ProjectID:    projectID,
+			DeploymentID: req.DeploymentId,
+			StageID:      req.StageId,
+			Approver:     approver,
+			ExpiresAt:    expiresAt,
+		})
+		if err != nil {
+			a.logger.Error("failed to sign approval link", zap.Error(err))
+			return nil, status.Error(codes.Internal, "failed to sign approval link")
+		}
+		links[approver] = approvallink.URL(a.address, token)
+	}
+	return &pipedservice.CreateApprovalLinksResponse{
+		Links: links,
+	}, nil
+}
+
+// validateApprovalLinkTarget checks that the given stage is a running WAIT_APPROVAL stage
+// and all the given users are allowed to approve it.
+func validateApprovalLinkTarget(d *model.Deployment, stageID string, approvers []string) error {
+	var stage *model.PipelineStage
+	for _, s := range d.Stages {
+		if s.Id == stageID {
+			stage = s
+			break
+		}
+	}
+	if stage == nil {
+		return status.Error(codes.NotFound, "the stage is not found")
+	}
+	if stage.Name != model.StageWaitApproval.String() {
+		return status.Error(codes.FailedPrecondition, "the stage is not a WAIT_APPROVAL stage")
+	}
+	if stage.Status != model.StageStatus_STAGE_RUNNING {
+		return status.Error(codes.FailedPrecondition, "the stage is not waiting for an approval")
+	}
+
+	allowed := stage.Metadata[model.MetadataKeyStageApprovers]
+	if allowed == "" {
+		return nil
+	}
+	users := make(map[string]struct{})
+	for _, u := range strings.Split(allowed, ",") {
+		users[u] = struct{}{}
+	}
+	for _, approver := range approvers {
+		if _, ok := users[approver]; !ok {
+			return status.Errorf(codes.PermissionDenied, "%s is not an approver of the stage", approver)
+		}
+	}
+	return nil
+}
+
 // ReportStageLogs is sent by piped to save the log of a pipeline stage.
 func (a *PipedAPI) ReportStageLogs(ctx context.Context, req *pipedservice.ReportStageLogsRequest) (*pipedservice.ReportStageLogsResponse, error) {
 	_, pipedID, _, err := rpcauth.ExtractPipedToken(ctx)
@@ -512,7 +727,14 @@ func (a *PipedAPI) ReportStageLogs(ctx context.Context, req *pipedservice.Report
 		return nil, err
 	}
 
-	err = a.stageLogStore.AppendLogs(ctx, req.DeploymentId, req.StageId, req.RetriedCount, req.Blocks)
+	blocks := req.Blocks
+	if len(req.CompressedBlocks) > 0 {
+		if blocks, err = pipedservice.DecompressLogBlocks(req.CompressedBlocks); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	err = a.stageLogStore.AppendLogs(ctx, req.DeploymentId, req.StageId, req.RetriedCount, blocks)
 	if errors.Is(err, stagelogstore.ErrAlreadyCompleted) {
 		return nil, status.Error(codes.FailedPrecondition, "could not append the logs because the stage was already completed")
 	}
@@ -533,7 +755,14 @@ func (a *PipedAPI) ReportStageLogsFromLastCheckpoint(ctx context.Context, req *p
 		return nil, err
 	}
 
-	err = a.stageLogStore.AppendLogsFromLastCheckpoint(ctx, req.DeploymentId, req.StageId, req.RetriedCount, req.Blocks, req.Completed)
+	blocks := req.Blocks
+	if len(req.CompressedBlocks) > 0 {
+		if blocks, err = pipedservice.DecompressLogBlocks(req.CompressedBlocks); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	err = a.stageLogStore.AppendLogsFromLastCheckpoint(ctx, req.DeploymentId, req.StageId, req.RetriedCount, blocks, req.Completed)
 	if errors.Is(err, stagelogstore.ErrAlreadyCompleted) {
 		return nil, status.Error(codes.FailedPrecondition, "could not append the logs because the stage was already completed")
 	}
@@ -595,10 +824,76 @@ func (a *PipedAPI) ListUnhandledCommands(ctx context.Context, req *pipedservice.
 		return nil, status.Error(codes.Internal, "failed to unhandled commands")
 	}
 	return &pipedservice.ListUnhandledCommandsResponse{
-		Commands: cmds,
+		Commands: a.filterCommandsInKeyScope(ctx, cmds),
 	}, nil
 }
 
+// commandResyncInterval is the maximum interval between sending the unhandled commands
+// through WatchUnhandledCommands. It also keeps the stream from being closed as idle.
+var commandResyncInterval = 30 * time.Second
+
+// WatchUnhandledCommands sends all unhandled commands of the piped whenever a new one was added,
+// the piped requested to resync, or commandResyncInterval passed since the last sending.
+func (a *PipedAPI) WatchUnhandledCommands(stream pipedservice.PipedService_WatchUnhandledCommandsServer) error {
+	ctx := stream.Context()
+	_, pipedID, _, err := rpcauth.ExtractPipedToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	added, stop := a.commandStore.WatchUnhandledCommands(pipedID)
+	defer stop()
+
+	// Receive the requests in another goroutine
+	// since Recv is blocked until a new one arrives.
+	requests := make(chan struct{}, 1)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			if _, err := stream.Recv(); err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	send := func() error {
+		cmds, err := a.commandStore.ListUnhandledCommands(ctx, pipedID)
+		if err != nil {
+			a.logger.Error("failed to fetch unhandled commands", zap.Error(err))
+			return status.Error(codes.Internal, "failed to unhandled commands")
+		}
+		return stream.Send(&pipedservice.WatchUnhandledCommandsResponse{
+			Commands: a.filterCommandsInKeyScope(ctx, cmds),
+		})
+	}
+
+	ticker := time.NewTicker(commandResyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-requests:
+		case <-added:
+		case <-ticker.C:
+		}
+		if err := send(); err != nil {
+			return err
+		}
+	}
+}
+
 // ReportCommandHandled is called by piped to mark a specific command as handled.
 // The request payload will contain the handle status as well as any additional result data.
 // The handle result should be updated to both datastore and cache (for reading from web).
@@ -615,6 +910,15 @@ func (a *PipedAPI) ReportCommandHandled(ctx context.Context, req *pipedservice.R
 	if pipedID != cmd.PipedId {
 		return nil, status.Error(codes.PermissionDenied, "The current piped does not have requested command")
 	}
+	if err := a.validateCommandInKeyScope(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	if len(req.Output) > 0 {
+		if err := a.commandOutputStore.Put(ctx, req.CommandId, req.Output); err != nil {
+			return nil, status.Error(codes.Internal, "failed to store output of command")
+		}
+	}
 
 	err = a.commandStore.UpdateCommandHandled(ctx, req.CommandId, req.Status, req.Metadata, req.HandledAt)
 	if err != nil {
@@ -634,6 +938,63 @@ func (a *PipedAPI) ReportCommandHandled(ctx context.Context, req *pipedservice.R
 	return &pipedservice.ReportCommandHandledResponse{}, nil
 }
 
+// ReportCommandOutputChunks is used by piped to send a large output of a command
+// by splitting into multiple chunks. All received chunks are reassembled into one output
+// and stored before the command is reported as handled.
+func (a *PipedAPI) ReportCommandOutputChunks(stream pipedservice.PipedService_ReportCommandOutputChunksServer) error {
+	ctx := stream.Context()
+	_, pipedID, _, err := rpcauth.ExtractPipedToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		commandID string
+		output    []byte
+		num, size int
+	)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		num++
+		size += proto.Size(chunk)
+		if err := a.chunkedReportLimits.check(num, size); err != nil {
+			return err
+		}
+		if commandID == "" {
+			if chunk.CommandId == "" {
+				return status.Error(codes.InvalidArgument, "command_id must be specified in the first chunk")
+			}
+			// Reject before receiving the rest of chunks.
+			cmd, err := a.getCommand(ctx, chunk.CommandId)
+			if err != nil {
+				return err
+			}
+			if pipedID != cmd.PipedId {
+				return status.Error(codes.PermissionDenied, "The current piped does not have requested command")
+			}
+			if err := a.validateCommandInKeyScope(ctx, cmd); err != nil {
+				return err
+			}
+			commandID = chunk.CommandId
+		}
+		output = append(output, chunk.Data...)
+	}
+	if commandID == "" {
+		return status.Error(codes.InvalidArgument, "no chunk was received")
+	}
+
+	if err := a.commandOutputStore.Put(ctx, commandID, output); err != nil {
+		return status.Error(codes.Internal, "failed to store output of command")
+	}
+	return stream.SendAndClose(&pipedservice.ReportCommandOutputChunksResponse{})
+}
+
 func (a *PipedAPI) getCommand(ctx context.Context, pipedID string) (*model.Command, error) {
 	cmd, err := a.commandStore.GetCommand(ctx, pipedID)
 	if errors.Is(err, datastore.ErrNotFound) {
@@ -663,6 +1024,67 @@ func (a *PipedAPI) ReportApplicationLiveState(ctx context.Context, req *pipedser
 	return &pipedservice.ReportApplicationLiveStateResponse{}, nil
 }
 
+// ReportApplicationLiveStateChunks is used by piped to send a large snapshot of application live state
+// by splitting into multiple chunks. All received chunks are reassembled into one snapshot
+// and then handled as same as ReportApplicationLiveState.
+func (a *PipedAPI) ReportApplicationLiveStateChunks(stream pipedservice.PipedService_ReportApplicationLiveStateChunksServer) error {
+	ctx := stream.Context()
+	_, pipedID, _, err := rpcauth.ExtractPipedToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		snapshot  *model.ApplicationLiveStateSnapshot
+		num, size int
+	)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		num++
+		size += proto.Size(chunk)
+		if err := a.chunkedReportLimits.check(num, size); err != nil {
+			return err
+		}
+		if snapshot == nil {
+			if chunk.Snapshot == nil {
+				return status.Error(codes.InvalidArgument, "snapshot must be specified in the first chunk")
+			}
+			// Reject before receiving the rest of chunks.
+			if err := a.validateAppBelongsToPiped(ctx, chunk.Snapshot.ApplicationId, pipedID); err != nil {
+				return err
+			}
+			snapshot = chunk.Snapshot
+		}
+		if len(chunk.KubernetesResources) == 0 {
+			continue
+		}
+		if snapshot.Kubernetes == nil {
+			snapshot.Kubernetes = &model.KubernetesApplicationLiveState{}
+		}
+		snapshot.Kubernetes.Resources = append(snapshot.Kubernetes.Resources, chunk.KubernetesResources...)
+	}
+
+	req := &pipedservice.ReportApplicationLiveStateRequest{
+		Snapshot: snapshot,
+	}
+	// The stream requests are not checked by the validation interceptor
+	// so the reassembled one must be validated here.
+	if err := req.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp, err := a.ReportApplicationLiveState(ctx, req)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(resp)
+}
+
 // ReportApplicationLiveStateEvents is sent by piped to submit one or multiple events
 // about the changes of application state.
 // Control plane uses the received events to update the state of application-resource-tree.
@@ -676,6 +1098,21 @@ func (a *PipedAPI) ReportApplicationLiveState(ctx context.Context, req *pipedser
 // and then another Handler service will pick them inorder to apply to build new state.
 // By that way we can control the traffic to the datastore in a better way.
 func (a *PipedAPI) ReportApplicationLiveStateEvents(ctx context.Context, req *pipedservice.ReportApplicationLiveStateEventsRequest) (*pipedservice.ReportApplicationLiveStateEventsResponse, error) {
+	_, pipedID, _, err := rpcauth.ExtractPipedToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validated := make(map[string]struct{})
+	for _, e := range req.KubernetesEvents {
+		if _, ok := validated[e.ApplicationId]; ok {
+			continue
+		}
+		if err := a.validateAppBelongsToPiped(ctx, e.ApplicationId, pipedID); err != nil {
+			return nil, err
+		}
+		validated[e.ApplicationId] = struct{}{}
+	}
+
 	a.applicationLiveStateStore.PatchKubernetesApplicationLiveState(ctx, req.KubernetesEvents)
 	// TODO: Patch Terraform application live state
 	// TODO: Patch Cloud Run application live state
@@ -802,14 +1239,15 @@ func (a *PipedAPI) ListEvents(ctx context.Context, req *pipedservice.ListEventsR
 }
 
 // validateAppBelongsToPiped checks if the given application belongs to the given piped.
-// It gives back an error unless the application belongs to the piped.
+// It gives back an error unless the application belongs to the piped
+// and is within the scope of the key used by the piped.
 func (a *PipedAPI) validateAppBelongsToPiped(ctx context.Context, appID, pipedID string) error {
 	pid, err := a.appPipedCache.Get(appID)
 	if err == nil {
 		if pid != pipedID {
 			return status.Error(codes.PermissionDenied, "requested application doesn't belong to the piped")
 		}
-		return nil
+		return a.validateAppInKeyScope(ctx, appID)
 	}
 
 	app, err := a.applicationStore.GetApplication(ctx, appID)
@@ -825,18 +1263,90 @@ func (a *PipedAPI) validateAppBelongsToPiped(ctx context.Context, appID, pipedID
 	if app.PipedId != pipedID {
 		return status.Error(codes.PermissionDenied, "requested application doesn't belong to the piped")
 	}
+	return a.validateAppInKeyScope(ctx, appID)
+}
+
+// validateAppInKeyScope checks if the given application is within the scope of the key used by the piped.
+// It gives back an error unless the key is allowed to access the application.
+func (a *PipedAPI) validateAppInKeyScope(ctx context.Context, appID string) error {
+	scope := rpcauth.ExtractPipedKeyScope(ctx)
+	if scope.IsUnrestricted() {
+		return nil
+	}
+	if !scope.AllowApplicationID(appID) {
+		return status.Error(codes.PermissionDenied, "requested application is out of the scope of the piped key")
+	}
+	if len(scope.RepositoryIds) == 0 {
+		return nil
+	}
+
+	var repoID string
+	if v, err := a.appRepositoryCache.Get(appID); err == nil {
+		repoID = v.(string)
+	} else {
+		app, err := a.applicationStore.GetApplication(ctx, appID)
+		if errors.Is(err, datastore.ErrNotFound) {
+			return status.Error(codes.NotFound, "the application is not found")
+		}
+		if err != nil {
+			a.logger.Error("failed to get application", zap.Error(err))
+			return status.Error(codes.Internal, "failed to get application")
+		}
+		repoID = app.GitPath.GetRepo().GetId()
+		a.appRepositoryCache.Put(appID, repoID)
+	}
+
+	if !scope.AllowRepository(repoID) {
+		return status.Error(codes.PermissionDenied, "requested application is out of the scope of the piped key")
+	}
+	return nil
+}
+
+// validateCommandInKeyScope checks if the target of the given command
+// is within the scope of the key used by the piped.
+func (a *PipedAPI) validateCommandInKeyScope(ctx context.Context, cmd *model.Command) error {
+	scope := rpcauth.ExtractPipedKeyScope(ctx)
+	if scope.IsUnrestricted() {
+		return nil
+	}
+	switch {
+	case cmd.ApplicationId != "":
+		return a.validateAppInKeyScope(ctx, cmd.ApplicationId)
+	case cmd.DeploymentId != "":
+		return a.validateDeploymentInKeyScope(ctx, cmd.DeploymentId)
+	case cmd.BuildPlanPreview != nil:
+		if !scope.AllowRepository(cmd.BuildPlanPreview.RepositoryId) {
+			return status.Error(codes.PermissionDenied, "requested repository is out of the scope of the piped key")
+		}
+	}
 	return nil
 }
 
+// filterCommandsInKeyScope returns only the commands those are within the scope of the key used by the piped.
+func (a *PipedAPI) filterCommandsInKeyScope(ctx context.Context, cmds []*model.Command) []*model.Command {
+	if rpcauth.ExtractPipedKeyScope(ctx).IsUnrestricted() {
+		return cmds
+	}
+	filtered := make([]*model.Command, 0, len(cmds))
+	for _, cmd := range cmds {
+		if err := a.validateCommandInKeyScope(ctx, cmd); err != nil {
+			continue
+		}
+		filtered = append(filtered, cmd)
+	}
+	return filtered
+}
+
 // validateDeploymentBelongsToPiped checks if the given deployment belongs to the given piped.
-// It gives back an error unless the deployment belongs to the piped.
+// It gives back an error unless the deployment belongs to the piped
+// and its application is within the scope of the key used by the piped.
 func (a *PipedAPI) validateDeploymentBelongsToPiped(ctx context.Context, deploymentID, pipedID string) error {
 	pid, err := a.deploymentPipedCache.Get(deploymentID)
 	if err == nil {
 		if pid != pipedID {
 			return status.Error(codes.PermissionDenied, "requested deployment doesn't belong to the piped")
 		}
-		return nil
+		return a.validateDeploymentInKeyScope(ctx, deploymentID)
 	}
 
 	deployment, err := a.deploymentStore.GetDeployment(ctx, deploymentID)
@@ -852,7 +1362,32 @@ func (a *PipedAPI) validateDeploymentBelongsToPiped(ctx context.Context, deploym
 	if deployment.PipedId != pipedID {
 		return status.Error(codes.PermissionDenied, "requested deployment doesn't belong to the piped")
 	}
-	return nil
+	return a.validateDeploymentInKeyScope(ctx, deploymentID)
+}
+
+// validateDeploymentInKeyScope checks if the application of the given deployment
+// is within the scope of the key used by the piped.
+func (a *PipedAPI) validateDeploymentInKeyScope(ctx context.Context, deploymentID string) error {
+	if rpcauth.ExtractPipedKeyScope(ctx).IsUnrestricted() {
+		return nil
+	}
+
+	var appID string
+	if v, err := a.deploymentAppCache.Get(deploymentID); err == nil {
+		appID = v.(string)
+	} else {
+		deployment, err := a.deploymentStore.GetDeployment(ctx, deploymentID)
+		if errors.Is(err, datastore.ErrNotFound) {
+			return status.Error(codes.NotFound, "the deployment is not found")
+		}
+		if err != nil {
+			a.logger.Error("failed to get deployment", zap.Error(err))
+			return status.Error(codes.Internal, "failed to get deployment")
+		}
+		appID = deployment.ApplicationId
+		a.deploymentAppCache.Put(deploymentID, appID)
+	}
+	return a.validateAppInKeyScope(ctx, appID)
 }
 
 // validateEnvBelongsToProject checks if the given environment belongs to the given project.
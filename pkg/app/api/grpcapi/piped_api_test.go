@@ -17,16 +17,23 @@ package grpcapi
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	"github.com/pipe-cd/pipe/pkg/cache"
 	"github.com/pipe-cd/pipe/pkg/cache/cachetest"
 	"github.com/pipe-cd/pipe/pkg/datastore"
 	"github.com/pipe-cd/pipe/pkg/datastore/datastoretest"
 	"github.com/pipe-cd/pipe/pkg/model"
+	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
 )
 
 func TestValidateAppBelongsToPiped(t *testing.T) {
@@ -121,6 +128,282 @@ func TestValidateAppBelongsToPiped(t *testing.T) {
 	}
 }
 
+func TestValidateAppInKeyScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name               string
+		appID              string
+		scope              *model.PipedKeyScope
+		appRepositoryCache cache.Cache
+		applicationStore   datastore.ApplicationStore
+		wantErr            bool
+	}{
+		{
+			name:    "unrestricted key",
+			appID:   "appID",
+			wantErr: false,
+		},
+		{
+			name:  "application out of scope",
+			appID: "appID",
+			scope: &model.PipedKeyScope{
+				ApplicationIds: []string{"otherAppID"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "valid with cached repository",
+			appID: "appID",
+			scope: &model.PipedKeyScope{
+				RepositoryIds: []string{"repoID"},
+			},
+			appRepositoryCache: func() cache.Cache {
+				c := cachetest.NewMockCache(ctrl)
+				c.EXPECT().
+					Get("appID").Return("repoID", nil)
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name:  "invalid with stored repository",
+			appID: "appID",
+			scope: &model.PipedKeyScope{
+				RepositoryIds: []string{"repoID"},
+			},
+			appRepositoryCache: func() cache.Cache {
+				c := cachetest.NewMockCache(ctrl)
+				c.EXPECT().
+					Get("appID").Return("", errors.New("not found"))
+				c.EXPECT().
+					Put("appID", "otherRepoID").Return(nil)
+				return c
+			}(),
+			applicationStore: func() datastore.ApplicationStore {
+				s := datastoretest.NewMockApplicationStore(ctrl)
+				s.EXPECT().
+					GetApplication(gomock.Any(), "appID").Return(&model.Application{
+					GitPath: &model.ApplicationGitPath{
+						Repo: &model.ApplicationGitRepository{Id: "otherRepoID"},
+					},
+				}, nil)
+				return s
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &PipedAPI{
+				appRepositoryCache: tt.appRepositoryCache,
+				applicationStore:   tt.applicationStore,
+			}
+			ctx := rpcauth.ContextWithPipedToken(context.Background(), "projectID", "pipedID", "pipedKey", tt.scope)
+			err := api.validateAppInKeyScope(ctx, tt.appID)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestFilterCommandsInKeyScope(t *testing.T) {
+	api := &PipedAPI{}
+	cmds := []*model.Command{
+		{Id: "in-scope", ApplicationId: "appID"},
+		{Id: "out-of-scope", ApplicationId: "otherAppID"},
+		{Id: "plan-preview", BuildPlanPreview: &model.Command_BuildPlanPreview{RepositoryId: "repoID"}},
+	}
+
+	ctx := rpcauth.ContextWithPipedToken(context.Background(), "projectID", "pipedID", "pipedKey", nil)
+	assert.Equal(t, cmds, api.filterCommandsInKeyScope(ctx, cmds))
+
+	scope := &model.PipedKeyScope{
+		ApplicationIds: []string{"appID"},
+	}
+	ctx = rpcauth.ContextWithPipedToken(context.Background(), "projectID", "pipedID", "pipedKey", scope)
+	got := api.filterCommandsInKeyScope(ctx, cmds)
+
+	ids := make([]string, 0, len(got))
+	for _, c := range got {
+		ids = append(ids, c.Id)
+	}
+	assert.Equal(t, []string{"in-scope", "plan-preview"}, ids)
+}
+
+func TestReportApplicationLiveStateEventsOutOfScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	appPipedCache := cachetest.NewMockCache(ctrl)
+	appPipedCache.EXPECT().
+		Get("otherAppID").Return("pipedID", nil)
+
+	api := &PipedAPI{
+		appPipedCache: appPipedCache,
+	}
+	scope := &model.PipedKeyScope{
+		ApplicationIds: []string{"appID"},
+	}
+	ctx := rpcauth.ContextWithPipedToken(context.Background(), "projectID", "pipedID", "pipedKey", scope)
+	req := &pipedservice.ReportApplicationLiveStateEventsRequest{
+		KubernetesEvents: []*model.KubernetesResourceStateEvent{
+			{Id: "event", ApplicationId: "otherAppID"},
+		},
+	}
+	_, err := api.ReportApplicationLiveStateEvents(ctx, req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+type fakeLiveStateChunksServer struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks []*pipedservice.ReportApplicationLiveStateChunk
+}
+
+func (s *fakeLiveStateChunksServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeLiveStateChunksServer) Recv() (*pipedservice.ReportApplicationLiveStateChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *fakeLiveStateChunksServer) SendAndClose(*pipedservice.ReportApplicationLiveStateResponse) error {
+	return nil
+}
+
+func TestReportApplicationLiveStateChunksExceedingLimits(t *testing.T) {
+	// The received snapshot is updated while reassembling so new chunks are made for each case.
+	makeChunks := func() []*pipedservice.ReportApplicationLiveStateChunk {
+		return []*pipedservice.ReportApplicationLiveStateChunk{
+			{
+				Snapshot:            &model.ApplicationLiveStateSnapshot{ApplicationId: "appID"},
+				KubernetesResources: []*model.KubernetesResourceState{{Id: "resource-1"}},
+			},
+			{
+				KubernetesResources: []*model.KubernetesResourceState{{Id: "resource-2"}},
+			},
+		}
+	}
+	testcases := []struct {
+		name   string
+		limits ChunkedReportLimits
+	}{
+		{
+			name:   "too many chunks",
+			limits: ChunkedReportLimits{MaxChunks: 1},
+		},
+		{
+			name:   "too large size",
+			limits: ChunkedReportLimits{MaxSize: proto.Size(makeChunks()[0])},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			appPipedCache := cachetest.NewMockCache(ctrl)
+			appPipedCache.EXPECT().
+				Get("appID").Return("pipedID", nil)
+
+			api := &PipedAPI{
+				appPipedCache:       appPipedCache,
+				chunkedReportLimits: tc.limits,
+			}
+			stream := &fakeLiveStateChunksServer{
+				ctx:    rpcauth.ContextWithPipedToken(context.Background(), "projectID", "pipedID", "pipedKey", nil),
+				chunks: makeChunks(),
+			}
+			err := api.ReportApplicationLiveStateChunks(stream)
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		})
+	}
+}
+
+func TestValidateApprovalLinkTarget(t *testing.T) {
+	d := &model.Deployment{
+		Stages: []*model.PipelineStage{
+			{
+				Id:     "approval",
+				Name:   model.StageWaitApproval.String(),
+				Status: model.StageStatus_STAGE_RUNNING,
+			},
+			{
+				Id:       "restricted-approval",
+				Name:     model.StageWaitApproval.String(),
+				Status:   model.StageStatus_STAGE_RUNNING,
+				Metadata: map[string]string{model.MetadataKeyStageApprovers: "foo,bar"},
+			},
+			{
+				Id:     "completed-approval",
+				Name:   model.StageWaitApproval.String(),
+				Status: model.StageStatus_STAGE_SUCCESS,
+			},
+			{
+				Id:     "sync",
+				Name:   model.StageK8sSync.String(),
+				Status: model.StageStatus_STAGE_RUNNING,
+			},
+		},
+	}
+	tests := []struct {
+		name      string
+		stageID   string
+		approvers []string
+		wantCode  codes.Code
+	}{
+		{
+			name:      "any user can approve",
+			stageID:   "approval",
+			approvers: []string{"foo", "baz"},
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "configured approvers",
+			stageID:   "restricted-approval",
+			approvers: []string{"foo", "bar"},
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "not configured approver",
+			stageID:   "restricted-approval",
+			approvers: []string{"foo", "baz"},
+			wantCode:  codes.PermissionDenied,
+		},
+		{
+			name:      "completed stage",
+			stageID:   "completed-approval",
+			approvers: []string{"foo"},
+			wantCode:  codes.FailedPrecondition,
+		},
+		{
+			name:      "not an approval stage",
+			stageID:   "sync",
+			approvers: []string{"foo"},
+			wantCode:  codes.FailedPrecondition,
+		},
+		{
+			name:      "unknown stage",
+			stageID:   "unknown",
+			approvers: []string{"foo"},
+			wantCode:  codes.NotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateApprovalLinkTarget(d, tt.stageID, tt.approvers)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
 func TestValidateDeploymentBelongsToPiped(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
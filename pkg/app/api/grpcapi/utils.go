@@ -16,6 +16,7 @@ package grpcapi
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 
 	"go.uber.org/zap"
@@ -23,6 +24,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/commandstore"
+	"github.com/pipe-cd/pipe/pkg/crypto"
 	"github.com/pipe-cd/pipe/pkg/datastore"
 	"github.com/pipe-cd/pipe/pkg/git"
 	"github.com/pipe-cd/pipe/pkg/model"
@@ -54,6 +56,18 @@ func getApplication(ctx context.Context, store datastore.ApplicationStore, id st
 	return app, nil
 }
 
+// ensureApplicationEnabled returns an error containing the disabled reason
+// when the given application is not allowed to be deployed.
+func ensureApplicationEnabled(app *model.Application) error {
+	if !app.Disabled {
+		return nil
+	}
+	if app.DisabledReason == "" {
+		return status.Error(codes.FailedPrecondition, "The application is disabled")
+	}
+	return status.Errorf(codes.FailedPrecondition, "The application is disabled: %s", app.DisabledReason)
+}
+
 func listApplications(ctx context.Context, store datastore.ApplicationStore, opts datastore.ListOptions, logger *zap.Logger) ([]*model.Application, string, error) {
 	apps, cursor, err := store.ListApplications(ctx, opts)
 	if err != nil {
@@ -117,6 +131,12 @@ func makeGitPath(repoID, path, cfgFilename string, piped *model.Piped, logger *z
 		return nil, status.Error(codes.Internal, "Failed to make GitPath URL")
 	}
 
+	// The file name is recorded even when it was not specified
+	// so that the default configured for the directory is kept.
+	if cfgFilename == "" {
+		cfgFilename = repo.FindConfigFilename(path)
+	}
+
 	return &model.ApplicationGitPath{
 		Repo:           repo,
 		Path:           path,
@@ -134,3 +154,41 @@ func listEnvironments(ctx context.Context, store datastore.EnvironmentStore, opt
 
 	return envs, nil
 }
+
+// encryptSecret encrypts the given data by using the sealed secret encryption configuration of the given piped.
+func encryptSecret(piped *model.Piped, data string, base64Encoding bool, logger *zap.Logger) (string, error) {
+	sse := piped.SealedSecretEncryption
+	if sse == nil {
+		return "", status.Error(codes.FailedPrecondition, "The piped does not contain the encryption configuration")
+	}
+
+	if base64Encoding {
+		data = base64.StdEncoding.EncodeToString([]byte(data))
+	}
+
+	var (
+		enc encrypter
+		err error
+	)
+	switch model.SealedSecretManagementType(sse.Type) {
+	case model.SealedSecretManagementSealingKey:
+		if sse.PublicKey == "" {
+			return "", status.Error(codes.FailedPrecondition, "The piped does not contain a public key")
+		}
+		enc, err = crypto.NewHybridEncrypter(sse.PublicKey)
+		if err != nil {
+			logger.Error("failed to initialize the crypter", zap.Error(err))
+			return "", status.Error(codes.FailedPrecondition, "Failed to initialize the encrypter")
+		}
+
+	default:
+		return "", status.Error(codes.FailedPrecondition, "The piped does not contain a valid encryption type")
+	}
+
+	encryptedText, err := enc.Encrypt(data)
+	if err != nil {
+		logger.Error("failed to encrypt the secret", zap.Error(err))
+		return "", status.Error(codes.FailedPrecondition, "Failed to encrypt the secret")
+	}
+	return encryptedText, nil
+}
@@ -16,9 +16,9 @@ package grpcapi
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,6 +28,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/applicationlivestatestore"
+	"github.com/pipe-cd/pipe/pkg/app/api/commandoutputstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/commandstore"
 	"github.com/pipe-cd/pipe/pkg/app/api/service/webservice"
 	"github.com/pipe-cd/pipe/pkg/app/api/stagelogstore"
@@ -35,8 +36,8 @@ import (
 	"github.com/pipe-cd/pipe/pkg/cache/memorycache"
 	"github.com/pipe-cd/pipe/pkg/cache/rediscache"
 	"github.com/pipe-cd/pipe/pkg/config"
-	"github.com/pipe-cd/pipe/pkg/crypto"
 	"github.com/pipe-cd/pipe/pkg/datastore"
+	"github.com/pipe-cd/pipe/pkg/filestore"
 	"github.com/pipe-cd/pipe/pkg/git"
 	"github.com/pipe-cd/pipe/pkg/insight/insightstore"
 	"github.com/pipe-cd/pipe/pkg/model"
@@ -44,6 +45,10 @@ import (
 	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
 )
 
+// maxDebugLoggingTTL is the maximum number of seconds
+// the debug logging can be enabled by one command.
+const maxDebugLoggingTTL = 24 * 60 * 60
+
 type encrypter interface {
 	Encrypt(text string) (string, error)
 }
@@ -56,10 +61,13 @@ type WebAPI struct {
 	pipedStore                datastore.PipedStore
 	projectStore              datastore.ProjectStore
 	apiKeyStore               datastore.APIKeyStore
+	sessionStore              datastore.SessionStore
+	auditEventStore           datastore.AuditEventStore
 	stageLogStore             stagelogstore.Store
 	applicationLiveStateStore applicationlivestatestore.Store
 	insightstore              insightstore.Store
 	commandStore              commandstore.Store
+	commandOutputStore        commandoutputstore.Store
 	encrypter                 encrypter
 
 	appProjectCache        cache.Cache
@@ -78,6 +86,7 @@ func NewWebAPI(
 	sls stagelogstore.Store,
 	alss applicationlivestatestore.Store,
 	cmds commandstore.Store,
+	cop commandoutputstore.Store,
 	is insightstore.Store,
 	rd redis.Redis,
 	projs map[string]config.ControlPlaneProject,
@@ -90,16 +99,19 @@ func NewWebAPI(
 		pipedStore:                datastore.NewPipedStore(ds),
 		projectStore:              datastore.NewProjectStore(ds),
 		apiKeyStore:               datastore.NewAPIKeyStore(ds),
+		sessionStore:              datastore.NewSessionStore(ds),
+		auditEventStore:           datastore.NewAuditEventStore(ds),
 		stageLogStore:             sls,
 		insightstore:              is,
 		applicationLiveStateStore: alss,
 		commandStore:              cmds,
+		commandOutputStore:        cop,
 		projectsInConfig:          projs,
 		encrypter:                 encrypter,
 		appProjectCache:           memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
 		deploymentProjectCache:    memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
 		pipedProjectCache:         memorycache.NewTTLCache(ctx, 24*time.Hour, 3*time.Hour),
-		insightCache:              rediscache.NewTTLCache(rd, 3*time.Hour),
+		insightCache:              rediscache.NewTTLCache(rd, 3*time.Hour, rediscache.WithCodec(insightstore.ChunkCodec{})),
 		logger:                    logger.Named("web-api"),
 	}
 	return a
@@ -122,6 +134,8 @@ func (a *WebAPI) AddEnvironment(ctx context.Context, req *webservice.AddEnvironm
 		Name:      req.Name,
 		Desc:      req.Desc,
 		ProjectId: claims.Role.ProjectId,
+		Order:     req.Order,
+		Protected: req.Protected,
 	}
 	err = a.environmentStore.AddEnvironment(ctx, &env)
 	if errors.Is(err, datastore.ErrAlreadyExists) {
@@ -136,7 +150,73 @@ func (a *WebAPI) AddEnvironment(ctx context.Context, req *webservice.AddEnvironm
 }
 
 func (a *WebAPI) UpdateEnvironmentDesc(ctx context.Context, req *webservice.UpdateEnvironmentDescRequest) (*webservice.UpdateEnvironmentDescResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	updater := func(ctx context.Context, envID string) error {
+		return a.environmentStore.UpdateEnvironmentDesc(ctx, envID, req.Desc)
+	}
+	if err := a.updateEnvironment(ctx, req.EnvironmentId, updater); err != nil {
+		return nil, err
+	}
+	return &webservice.UpdateEnvironmentDescResponse{}, nil
+}
+
+func (a *WebAPI) UpdateEnvironmentOrder(ctx context.Context, req *webservice.UpdateEnvironmentOrderRequest) (*webservice.UpdateEnvironmentOrderResponse, error) {
+	updater := func(ctx context.Context, envID string) error {
+		return a.environmentStore.UpdateEnvironmentOrder(ctx, envID, req.Order)
+	}
+	if err := a.updateEnvironment(ctx, req.EnvironmentId, updater); err != nil {
+		return nil, err
+	}
+	return &webservice.UpdateEnvironmentOrderResponse{}, nil
+}
+
+func (a *WebAPI) EnableEnvironmentProtection(ctx context.Context, req *webservice.EnableEnvironmentProtectionRequest) (*webservice.EnableEnvironmentProtectionResponse, error) {
+	if err := a.updateEnvironment(ctx, req.EnvironmentId, a.environmentStore.EnableEnvironmentProtection); err != nil {
+		return nil, err
+	}
+	return &webservice.EnableEnvironmentProtectionResponse{}, nil
+}
+
+func (a *WebAPI) DisableEnvironmentProtection(ctx context.Context, req *webservice.DisableEnvironmentProtectionRequest) (*webservice.DisableEnvironmentProtectionResponse, error) {
+	if err := a.updateEnvironment(ctx, req.EnvironmentId, a.environmentStore.DisableEnvironmentProtection); err != nil {
+		return nil, err
+	}
+	return &webservice.DisableEnvironmentProtectionResponse{}, nil
+}
+
+func (a *WebAPI) updateEnvironment(ctx context.Context, envID string, updater func(context.Context, string) error) error {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return err
+	}
+
+	env, err := a.environmentStore.GetEnvironment(ctx, envID)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return status.Error(codes.NotFound, "The environment is not found")
+	}
+	if err != nil {
+		a.logger.Error("failed to get environment", zap.Error(err))
+		return status.Error(codes.Internal, "Failed to get environment")
+	}
+	if env.ProjectId != claims.Role.ProjectId {
+		return status.Error(codes.PermissionDenied, "Requested environment doesn't belong to the project you logged in")
+	}
+
+	if err := updater(ctx, envID); err != nil {
+		switch err {
+		case datastore.ErrNotFound:
+			return status.Error(codes.InvalidArgument, "The environment is not found")
+		case datastore.ErrInvalidArgument:
+			return status.Error(codes.InvalidArgument, "Invalid value for update")
+		default:
+			a.logger.Error("failed to update the environment",
+				zap.String("env-id", envID),
+				zap.Error(err),
+			)
+			return status.Error(codes.Internal, "Failed to update the environment")
+		}
+	}
+	return nil
 }
 
 func (a *WebAPI) ListEnvironments(ctx context.Context, req *webservice.ListEnvironmentsRequest) (*webservice.ListEnvironmentsResponse, error) {
@@ -160,6 +240,12 @@ func (a *WebAPI) ListEnvironments(ctx context.Context, req *webservice.ListEnvir
 		a.logger.Error("failed to get environments", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to get environments")
 	}
+	sort.Slice(envs, func(i, j int) bool {
+		if envs[i].Order != envs[j].Order {
+			return envs[i].Order < envs[j].Order
+		}
+		return envs[i].Name < envs[j].Name
+	})
 
 	return &webservice.ListEnvironmentsResponse{
 		Environments: envs,
@@ -187,7 +273,7 @@ func (a *WebAPI) RegisterPiped(ctx context.Context, req *webservice.RegisterPipe
 		EnvIds:    req.EnvIds,
 		Status:    model.Piped_OFFLINE,
 	}
-	if err := piped.AddKey(keyHash, claims.Subject, time.Now()); err != nil {
+	if err := piped.AddKey(keyHash, claims.Subject, time.Now(), req.KeyScope); err != nil {
 		return nil, status.Error(codes.FailedPrecondition, fmt.Sprintf("Failed to create key: %v", err))
 	}
 
@@ -235,7 +321,7 @@ func (a *WebAPI) RecreatePipedKey(ctx context.Context, req *webservice.RecreateP
 	}
 
 	updater := func(ctx context.Context, pipedID string) error {
-		return a.pipedStore.AddKey(ctx, pipedID, keyHash, claims.Subject, time.Now())
+		return a.pipedStore.AddKey(ctx, pipedID, keyHash, claims.Subject, time.Now(), req.KeyScope)
 	}
 	if err := a.updatePiped(ctx, req.Id, updater); err != nil {
 		return nil, err
@@ -506,14 +592,14 @@ func (a *WebAPI) updateApplication(ctx context.Context, id, pipedID string, upda
 }
 
 func (a *WebAPI) EnableApplication(ctx context.Context, req *webservice.EnableApplicationRequest) (*webservice.EnableApplicationResponse, error) {
-	if err := a.updateApplicationEnable(ctx, req.ApplicationId, true); err != nil {
+	if err := a.updateApplicationEnable(ctx, req.ApplicationId, true, ""); err != nil {
 		return nil, err
 	}
 	return &webservice.EnableApplicationResponse{}, nil
 }
 
 func (a *WebAPI) DisableApplication(ctx context.Context, req *webservice.DisableApplicationRequest) (*webservice.DisableApplicationResponse, error) {
-	if err := a.updateApplicationEnable(ctx, req.ApplicationId, false); err != nil {
+	if err := a.updateApplicationEnable(ctx, req.ApplicationId, false, req.Reason); err != nil {
 		return nil, err
 	}
 	return &webservice.DisableApplicationResponse{}, nil
@@ -548,7 +634,7 @@ func (a *WebAPI) DeleteApplication(ctx context.Context, req *webservice.DeleteAp
 	return &webservice.DeleteApplicationResponse{}, nil
 }
 
-func (a *WebAPI) updateApplicationEnable(ctx context.Context, appID string, enable bool) error {
+func (a *WebAPI) updateApplicationEnable(ctx context.Context, appID string, enable bool, reason string) error {
 	claims, err := rpcauth.ExtractClaims(ctx)
 	if err != nil {
 		a.logger.Error("failed to authenticate the current user", zap.Error(err))
@@ -559,11 +645,11 @@ func (a *WebAPI) updateApplicationEnable(ctx context.Context, appID string, enab
 		return err
 	}
 
-	var updater func(context.Context, string) error
-	if enable {
-		updater = a.applicationStore.EnableApplication
-	} else {
-		updater = a.applicationStore.DisableApplication
+	updater := a.applicationStore.EnableApplication
+	if !enable {
+		updater = func(ctx context.Context, id string) error {
+			return a.applicationStore.DisableApplication(ctx, id, reason)
+		}
 	}
 
 	if err := updater(ctx, appID); err != nil {
@@ -676,6 +762,9 @@ func (a *WebAPI) SyncApplication(ctx context.Context, req *webservice.SyncApplic
 	if claims.Role.ProjectId != app.ProjectId {
 		return nil, status.Error(codes.InvalidArgument, "Requested application does not belong to your project")
 	}
+	if err := ensureApplicationEnabled(app); err != nil {
+		return nil, err
+	}
 
 	cmd := model.Command{
 		Id:            uuid.New().String(),
@@ -734,36 +823,9 @@ func (a *WebAPI) GenerateApplicationSealedSecret(ctx context.Context, req *webse
 		return nil, err
 	}
 
-	sse := piped.SealedSecretEncryption
-	if sse == nil {
-		return nil, status.Error(codes.FailedPrecondition, "The piped does not contain the encryption configuration")
-	}
-
-	data := req.Data
-	if req.Base64Encoding {
-		data = base64.StdEncoding.EncodeToString([]byte(data))
-	}
-
-	var enc encrypter
-	switch model.SealedSecretManagementType(sse.Type) {
-	case model.SealedSecretManagementSealingKey:
-		if sse.PublicKey == "" {
-			return nil, status.Error(codes.FailedPrecondition, "The piped does not contain a public key")
-		}
-		enc, err = crypto.NewHybridEncrypter(sse.PublicKey)
-		if err != nil {
-			a.logger.Error("failed to initialize the crypter", zap.Error(err))
-			return nil, status.Error(codes.FailedPrecondition, "Failed to initialize the encrypter")
-		}
-
-	default:
-		return nil, status.Error(codes.FailedPrecondition, "The piped does not contain a valid encryption type")
-	}
-
-	encryptedText, err := enc.Encrypt(data)
+	encryptedText, err := encryptSecret(piped, req.Data, req.Base64Encoding, a.logger)
 	if err != nil {
-		a.logger.Error("failed to encrypt the secret", zap.Error(err))
-		return nil, status.Error(codes.FailedPrecondition, "Failed to encrypt the secret")
+		return nil, err
 	}
 
 	return &webservice.GenerateApplicationSealedSecretResponse{
@@ -1033,6 +1095,120 @@ func (a *WebAPI) ApproveStage(ctx context.Context, req *webservice.ApproveStageR
 	}, nil
 }
 
+// skippableStages is the list of stages can be skipped by users.
+var skippableStages = map[string]struct{}{
+	model.StageAnalysis.String(): {},
+	model.StageWait.String():     {},
+}
+
+func (a *WebAPI) SkipStage(ctx context.Context, req *webservice.SkipStageRequest) (*webservice.SkipStageResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.validateDeploymentBelongsToProject(ctx, req.DeploymentId, claims.Role.ProjectId); err != nil {
+		return nil, err
+	}
+	stage, ok := deployment.FindStage(req.StageId)
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "The stage was not found in the deployment")
+	}
+	if _, ok := skippableStages[stage.Name]; !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "Could not skip the stage because %s stage is not skippable", stage.Name)
+	}
+	if model.IsCompletedStage(stage.Status) {
+		return nil, status.Errorf(codes.FailedPrecondition, "Could not skip the stage because it was already completed")
+	}
+
+	commandID := uuid.New().String()
+	cmd := model.Command{
+		Id:            commandID,
+		PipedId:       deployment.PipedId,
+		ApplicationId: deployment.ApplicationId,
+		ProjectId:     deployment.ProjectId,
+		DeploymentId:  req.DeploymentId,
+		StageId:       req.StageId,
+		Type:          model.Command_SKIP_STAGE,
+		Commander:     claims.Subject,
+		SkipStage: &model.Command_SkipStage{
+			DeploymentId: req.DeploymentId,
+			StageId:      req.StageId,
+		},
+	}
+	if err := addCommand(ctx, a.commandStore, &cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	return &webservice.SkipStageResponse{
+		CommandId: commandID,
+	}, nil
+}
+
+func (a *WebAPI) EnableDebugLogging(ctx context.Context, req *webservice.EnableDebugLoggingRequest) (*webservice.EnableDebugLoggingResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	if (req.ApplicationId == "") == (req.DeploymentId == "") {
+		return nil, status.Error(codes.InvalidArgument, "Exactly one of application_id and deployment_id must be specified")
+	}
+	if req.Ttl > maxDebugLoggingTTL {
+		return nil, status.Errorf(codes.InvalidArgument, "TTL must not be greater than %d seconds", maxDebugLoggingTTL)
+	}
+
+	cmd := model.Command{
+		Id:        uuid.New().String(),
+		Type:      model.Command_ENABLE_DEBUG_LOGGING,
+		Commander: claims.Subject,
+		EnableDebugLogging: &model.Command_EnableDebugLogging{
+			ApplicationId: req.ApplicationId,
+			DeploymentId:  req.DeploymentId,
+			Ttl:           req.Ttl,
+		},
+	}
+
+	if req.DeploymentId != "" {
+		deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+		if err != nil {
+			return nil, err
+		}
+		if claims.Role.ProjectId != deployment.ProjectId {
+			return nil, status.Error(codes.InvalidArgument, "Requested deployment does not belong to your project")
+		}
+		cmd.PipedId = deployment.PipedId
+		cmd.ProjectId = deployment.ProjectId
+		cmd.ApplicationId = deployment.ApplicationId
+		cmd.DeploymentId = deployment.Id
+	} else {
+		app, err := getApplication(ctx, a.applicationStore, req.ApplicationId, a.logger)
+		if err != nil {
+			return nil, err
+		}
+		if claims.Role.ProjectId != app.ProjectId {
+			return nil, status.Error(codes.InvalidArgument, "Requested application does not belong to your project")
+		}
+		cmd.PipedId = app.PipedId
+		cmd.ProjectId = app.ProjectId
+		cmd.ApplicationId = app.Id
+	}
+
+	if err := addCommand(ctx, a.commandStore, &cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	return &webservice.EnableDebugLoggingResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
 func (a *WebAPI) GetApplicationLiveState(ctx context.Context, req *webservice.GetApplicationLiveStateRequest) (*webservice.GetApplicationLiveStateResponse, error) {
 	claims, err := rpcauth.ExtractClaims(ctx)
 	if err != nil {
@@ -1054,6 +1230,206 @@ func (a *WebAPI) GetApplicationLiveState(ctx context.Context, req *webservice.Ge
 	}, nil
 }
 
+// ExecuteResourceAction requests the piped to execute an action such as restarting a workload
+// against a resource found in the live state of the application.
+func (a *WebAPI) ExecuteResourceAction(ctx context.Context, req *webservice.ExecuteResourceActionRequest) (*webservice.ExecuteResourceActionResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	app, err := getApplication(ctx, a.applicationStore, req.ApplicationId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Role.ProjectId != app.ProjectId {
+		return nil, status.Error(codes.InvalidArgument, "Requested application does not belong to your project")
+	}
+	if err := ensureApplicationEnabled(app); err != nil {
+		return nil, err
+	}
+	if app.Kind != model.ApplicationKind_KUBERNETES {
+		return nil, status.Error(codes.InvalidArgument, "Resource actions are available only for Kubernetes application")
+	}
+
+	// Only the resources managed by the application can be the target of actions.
+	snapshot, err := a.applicationLiveStateStore.GetStateSnapshot(ctx, app.Id)
+	if err != nil {
+		a.logger.Error("failed to get application live state", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to get application live state")
+	}
+	var resource *model.KubernetesResourceState
+	for _, r := range snapshot.GetKubernetes().GetResources() {
+		if r.Id == req.ResourceId {
+			resource = r
+			break
+		}
+	}
+	if resource == nil {
+		return nil, status.Error(codes.NotFound, "The requested resource was not found in the live state of the application")
+	}
+	if !req.Action.IsAvailableFor(resource.Kind) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s action is not available for %s", req.Action, resource.Kind)
+	}
+
+	cmd := model.Command{
+		Id:            uuid.New().String(),
+		PipedId:       app.PipedId,
+		ApplicationId: app.Id,
+		ProjectId:     app.ProjectId,
+		Type:          model.Command_EXECUTE_RESOURCE_ACTION,
+		Commander:     claims.Subject,
+		ExecuteResourceAction: &model.Command_ExecuteResourceAction{
+			ApplicationId:      app.Id,
+			Action:             req.Action,
+			ResourceApiVersion: resource.ApiVersion,
+			ResourceKind:       resource.Kind,
+			ResourceNamespace:  resource.Namespace,
+			ResourceName:       resource.Name,
+			Replicas:           req.Replicas,
+			TailLines:          req.TailLines,
+		},
+	}
+	if req.Action == model.Command_ExecuteResourceAction_GET_POD_LOGS {
+		enabled, err := a.isPodDebuggingEnabled(ctx, app.ProjectId)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			return nil, status.Error(codes.PermissionDenied, "Pod debugging is not enabled in your project")
+		}
+	}
+
+	// The request is recorded before being sent to piped
+	// so that no action can be executed without leaving the trail.
+	event := &model.AuditEvent{
+		ProjectId:     app.ProjectId,
+		Type:          model.AuditEventType_RESOURCE_ACTION_REQUESTED,
+		Actor:         claims.Subject,
+		ApplicationId: app.Id,
+		CommandId:     cmd.Id,
+		Description:   fmt.Sprintf("%s %s/%s/%s", req.Action, resource.Kind, resource.Namespace, resource.Name),
+	}
+	switch req.Action {
+	case model.Command_ExecuteResourceAction_GET_POD_LOGS:
+		event.Type = model.AuditEventType_POD_LOGS_REQUESTED
+		event.Description = fmt.Sprintf("%s/%s/%s", resource.Kind, resource.Namespace, resource.Name)
+	case model.Command_ExecuteResourceAction_SCALE_WORKLOAD:
+		event.Description += fmt.Sprintf(" to %d replicas", req.Replicas)
+	}
+	if err := a.addAuditEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	if err := addCommand(ctx, a.commandStore, &cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("resource action was requested",
+		zap.String("command-id", cmd.Id),
+		zap.String("commander", cmd.Commander),
+		zap.String("project-id", app.ProjectId),
+		zap.String("application-id", app.Id),
+		zap.String("action", req.Action.String()),
+		zap.String("resource", fmt.Sprintf("%s/%s/%s", resource.Kind, resource.Namespace, resource.Name)),
+		zap.Int32("replicas", req.Replicas),
+	)
+
+	return &webservice.ExecuteResourceActionResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
+// GetResourceActionOutput returns the output such as the pod logs
+// reported by piped for the given resource action command.
+func (a *WebAPI) GetResourceActionOutput(ctx context.Context, req *webservice.GetResourceActionOutputRequest) (*webservice.GetResourceActionOutputResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	cmd, err := getCommand(ctx, a.commandStore, req.CommandId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Role.ProjectId != cmd.ProjectId {
+		return nil, status.Error(codes.InvalidArgument, "Requested command does not belong to your project")
+	}
+	if cmd.Type != model.Command_EXECUTE_RESOURCE_ACTION {
+		return nil, status.Error(codes.InvalidArgument, "Requested command is not a resource action")
+	}
+	if cmd.Status == model.CommandStatus_COMMAND_NOT_HANDLED_YET {
+		return &webservice.GetResourceActionOutputResponse{
+			Status: cmd.Status,
+		}, nil
+	}
+
+	data, err := a.commandOutputStore.Get(ctx, cmd.Id)
+	if errors.Is(err, filestore.ErrNotFound) {
+		return &webservice.GetResourceActionOutputResponse{
+			Status: cmd.Status,
+		}, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to retrieve output data of command")
+	}
+
+	if cmd.ExecuteResourceAction.GetAction() == model.Command_ExecuteResourceAction_GET_POD_LOGS {
+		err := a.addAuditEvent(ctx, &model.AuditEvent{
+			ProjectId:     cmd.ProjectId,
+			Type:          model.AuditEventType_POD_LOGS_RETRIEVED,
+			Actor:         claims.Subject,
+			ApplicationId: cmd.ApplicationId,
+			CommandId:     cmd.Id,
+			Description:   fmt.Sprintf("%s/%s/%s", cmd.ExecuteResourceAction.ResourceKind, cmd.ExecuteResourceAction.ResourceNamespace, cmd.ExecuteResourceAction.ResourceName),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a.logger.Info("output of resource action was retrieved",
+		zap.String("command-id", cmd.Id),
+		zap.String("user", claims.Subject),
+		zap.String("project-id", cmd.ProjectId),
+		zap.String("application-id", cmd.ApplicationId),
+	)
+
+	return &webservice.GetResourceActionOutputResponse{
+		Status: cmd.Status,
+		Output: string(data),
+	}, nil
+}
+
+// isPodDebuggingEnabled reports whether the debugging of live pods was enabled in the given project.
+func (a *WebAPI) isPodDebuggingEnabled(ctx context.Context, projectID string) (bool, error) {
+	// The projects specified in the control-plane configuration are used for debugging.
+	if p, ok := a.projectsInConfig[projectID]; ok {
+		return p.PodDebugging, nil
+	}
+	project, err := a.projectStore.GetProject(ctx, projectID)
+	if err != nil {
+		a.logger.Error("failed to get project", zap.Error(err))
+		return false, status.Error(codes.Internal, "Failed to get project")
+	}
+	return project.PodDebuggingEnabled, nil
+}
+
+// addAuditEvent persists the given operation for auditing.
+func (a *WebAPI) addAuditEvent(ctx context.Context, e *model.AuditEvent) error {
+	e.Id = uuid.New().String()
+	if err := a.auditEventStore.AddAuditEvent(ctx, e); err != nil {
+		a.logger.Error("failed to add audit event",
+			zap.String("type", e.Type.String()),
+			zap.String("actor", e.Actor),
+			zap.Error(err),
+		)
+		return status.Error(codes.Internal, "Failed to record audit event")
+	}
+	return nil
+}
+
 // GetProject gets the specified porject without sensitive data.
 func (a *WebAPI) GetProject(ctx context.Context, req *webservice.GetProjectRequest) (*webservice.GetProjectResponse, error) {
 	claims, err := rpcauth.ExtractClaims(ctx)
@@ -1136,6 +1512,68 @@ func (a *WebAPI) EnableStaticAdmin(ctx context.Context, req *webservice.EnableSt
 	return &webservice.EnableStaticAdminResponse{}, nil
 }
 
+// EnablePodDebugging enables the debugging of live pods from the web console.
+func (a *WebAPI) EnablePodDebugging(ctx context.Context, req *webservice.EnablePodDebuggingRequest) (*webservice.EnablePodDebuggingResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	if _, ok := a.projectsInConfig[claims.Role.ProjectId]; ok {
+		return nil, status.Error(codes.FailedPrecondition, "Failed to update a debug project specified in the control-plane configuration")
+	}
+
+	if err := a.projectStore.EnablePodDebugging(ctx, claims.Role.ProjectId); err != nil {
+		a.logger.Error("failed to enable pod debugging", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to enable pod debugging")
+	}
+	err = a.addAuditEvent(ctx, &model.AuditEvent{
+		ProjectId: claims.Role.ProjectId,
+		Type:      model.AuditEventType_POD_DEBUGGING_ENABLED,
+		Actor:     claims.Subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.logger.Info("pod debugging was enabled",
+		zap.String("user", claims.Subject),
+		zap.String("project-id", claims.Role.ProjectId),
+	)
+	return &webservice.EnablePodDebuggingResponse{}, nil
+}
+
+// DisablePodDebugging disables the debugging of live pods from the web console.
+func (a *WebAPI) DisablePodDebugging(ctx context.Context, req *webservice.DisablePodDebuggingRequest) (*webservice.DisablePodDebuggingResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	if _, ok := a.projectsInConfig[claims.Role.ProjectId]; ok {
+		return nil, status.Error(codes.FailedPrecondition, "Failed to update a debug project specified in the control-plane configuration")
+	}
+
+	if err := a.projectStore.DisablePodDebugging(ctx, claims.Role.ProjectId); err != nil {
+		a.logger.Error("failed to disable pod debugging", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to disable pod debugging")
+	}
+	err = a.addAuditEvent(ctx, &model.AuditEvent{
+		ProjectId: claims.Role.ProjectId,
+		Type:      model.AuditEventType_POD_DEBUGGING_DISABLED,
+		Actor:     claims.Subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.logger.Info("pod debugging was disabled",
+		zap.String("user", claims.Subject),
+		zap.String("project-id", claims.Role.ProjectId),
+	)
+	return &webservice.DisablePodDebuggingResponse{}, nil
+}
+
 // DisableStaticAdmin disables static admin login.
 func (a *WebAPI) DisableStaticAdmin(ctx context.Context, req *webservice.DisableStaticAdminRequest) (*webservice.DisableStaticAdminResponse, error) {
 	claims, err := rpcauth.ExtractClaims(ctx)
@@ -1269,7 +1707,7 @@ func (a *WebAPI) ListDeploymentConfigTemplates(ctx context.Context, req *webserv
 		g := app.GetGitPath()
 		filename := g.ConfigFilename
 		if filename == "" {
-			filename = ".pipe.yaml"
+			filename = model.DefaultDeploymentConfigFileName
 		}
 		t.FileCreationUrl, err = git.MakeFileCreationURL(g.Repo.Remote, g.Path, g.Repo.Branch, filename, t.Content)
 		if err != nil {
@@ -1406,6 +1844,72 @@ func (a *WebAPI) ListAPIKeys(ctx context.Context, req *webservice.ListAPIKeysReq
 	}, nil
 }
 
+// RevokeSessions revokes all web console sessions of the given user
+// or of the whole project when no user was specified.
+// Revoked sessions can no longer be refreshed and their access tokens are rejected.
+func (a *WebAPI) RevokeSessions(ctx context.Context, req *webservice.RevokeSessionsRequest) (*webservice.RevokeSessionsResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	opts := datastore.ListOptions{
+		Filters: []datastore.ListFilter{
+			{
+				Field:    "ProjectId",
+				Operator: "==",
+				Value:    claims.Role.ProjectId,
+			},
+			{
+				Field:    "Revoked",
+				Operator: "==",
+				Value:    false,
+			},
+		},
+	}
+	if req.Username != "" {
+		opts.Filters = append(opts.Filters, datastore.ListFilter{
+			Field:    "Username",
+			Operator: "==",
+			Value:    req.Username,
+		})
+	}
+
+	sessions, err := a.sessionStore.ListSessions(ctx, opts)
+	if err != nil {
+		a.logger.Error("failed to list sessions", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list sessions")
+	}
+
+	now := time.Now().Unix()
+	var revoked int64
+	for _, s := range sessions {
+		// No need to revoke the already expired ones.
+		if s.ExpiresAt <= now {
+			continue
+		}
+		if err := a.sessionStore.RevokeSession(ctx, s.Id, claims.Role.ProjectId, claims.Subject); err != nil {
+			a.logger.Error("failed to revoke the session",
+				zap.String("session-id", s.Id),
+				zap.Error(err),
+			)
+			return nil, status.Error(codes.Internal, "Failed to revoke sessions")
+		}
+		revoked++
+	}
+
+	a.logger.Info("revoked sessions",
+		zap.String("project-id", claims.Role.ProjectId),
+		zap.String("username", req.Username),
+		zap.String("revoked-by", claims.Subject),
+		zap.Int64("count", revoked),
+	)
+	return &webservice.RevokeSessionsResponse{
+		RevokedCount: revoked,
+	}, nil
+}
+
 // GetInsightData returns the accumulated insight data.
 func (a *WebAPI) GetInsightData(ctx context.Context, req *webservice.GetInsightDataRequest) (*webservice.GetInsightDataResponse, error) {
 	claims, err := rpcauth.ExtractClaims(ctx)
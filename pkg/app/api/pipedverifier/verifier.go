@@ -63,16 +63,18 @@ func NewVerifier(
 	}
 }
 
-func (v *Verifier) Verify(ctx context.Context, projectID, pipedID, pipedKey string) error {
+// Verify checks the given piped token and returns the scope embedded into the matched key.
+// The returned scope is nil when the key is not restricted.
+func (v *Verifier) Verify(ctx context.Context, projectID, pipedID, pipedKey string) (*model.PipedKeyScope, error) {
 	// Check the project information.
 	if err := v.verifyProject(ctx, projectID, pipedID); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Fail-fast for the invalid keys.
 	keyID := fmt.Sprintf("%s#%s#%s", projectID, pipedID, pipedKey)
 	if item, err := v.invalidKeyCache.Get(keyID); err == nil {
-		return item.(error)
+		return nil, item.(error)
 	}
 
 	// Check the piped information.
@@ -83,8 +85,8 @@ func (v *Verifier) Verify(ctx context.Context, projectID, pipedID, pipedKey stri
 		// When an error is returned, it is probably because
 		// the requested key has just been added but not updated in the cache.
 		// So in that case, we should refresh the cache data.
-		if _, err := checkPiped(piped, projectID, pipedID, pipedKey); err == nil {
-			return nil
+		if scope, _, err := checkPiped(piped, projectID, pipedID, pipedKey); err == nil {
+			return scope, nil
 		}
 	}
 
@@ -92,13 +94,13 @@ func (v *Verifier) Verify(ctx context.Context, projectID, pipedID, pipedKey stri
 	// we have to retrieve from datastore and save it to the cache.
 	piped, err = v.pipedStore.GetPiped(ctx, pipedID)
 	if err != nil {
-		return fmt.Errorf("unable to find piped %s from datastore, %w", pipedID, err)
+		return nil, fmt.Errorf("unable to find piped %s from datastore, %w", pipedID, err)
 	}
 	if err := v.pipedCache.Put(pipedID, piped); err != nil {
 		v.logger.Warn("unable to store piped in memory cache", zap.Error(err))
 	}
 
-	keyNotMatch, err := checkPiped(piped, projectID, pipedID, pipedKey)
+	scope, keyNotMatch, err := checkPiped(piped, projectID, pipedID, pipedKey)
 	if err != nil {
 		v.logger.Info("detected an invalid piped key",
 			zap.String("project", projectID),
@@ -108,10 +110,10 @@ func (v *Verifier) Verify(ctx context.Context, projectID, pipedID, pipedKey stri
 		if keyNotMatch {
 			v.invalidKeyCache.Put(keyID, err)
 		}
-		return err
+		return nil, err
 	}
 
-	return nil
+	return scope, nil
 }
 
 func (v *Verifier) verifyProject(ctx context.Context, projectID, pipedID string) error {
@@ -136,15 +138,19 @@ func (v *Verifier) verifyProject(ctx context.Context, projectID, pipedID string)
 	return nil
 }
 
-func checkPiped(piped *model.Piped, projectID, pipedID, pipedKey string) (keyNotMatch bool, err error) {
+func checkPiped(piped *model.Piped, projectID, pipedID, pipedKey string) (scope *model.PipedKeyScope, keyNotMatch bool, err error) {
 	if piped.ProjectId != projectID {
-		return false, fmt.Errorf("the project of piped %s is not matched, expected=%s, got=%s", pipedID, projectID, piped.ProjectId)
+		return nil, false, fmt.Errorf("the project of piped %s is not matched, expected=%s, got=%s", pipedID, projectID, piped.ProjectId)
 	}
 	if piped.Disabled {
-		return false, fmt.Errorf("piped %s was already disabled", pipedID)
+		return nil, false, fmt.Errorf("piped %s was already disabled", pipedID)
+	}
+	key, err := piped.FindKey(pipedKey)
+	if err != nil {
+		return nil, true, fmt.Errorf("the key of piped %s is not matched, %v", pipedID, err)
 	}
-	if err := piped.CheckKey(pipedKey); err != nil {
-		return true, fmt.Errorf("the key of piped %s is not matched, %v", pipedID, err)
+	if key != nil {
+		scope = key.Scope
 	}
-	return false, nil
+	return scope, false, nil
 }
@@ -131,50 +131,50 @@ func TestVerify(t *testing.T) {
 	)
 
 	// A piped from a project that was specified in the control-plane configuration.
-	err := v.Verify(ctx, "project-0", "piped-0-1", "piped-key-0-1")
+	_, err := v.Verify(ctx, "project-0", "piped-0-1", "piped-key-0-1")
 	assert.Equal(t, nil, err)
 	require.Equal(t, 0, projectGetter.calls)
 	require.Equal(t, 1, pipedGetter.calls)
 
 	// Non-existence project.
-	err = v.Verify(ctx, "project-not-found", "piped-1-1", "piped-key-1-1")
+	_, err = v.Verify(ctx, "project-not-found", "piped-1-1", "piped-key-1-1")
 	assert.Equal(t, fmt.Errorf("project project-not-found for piped piped-1-1 was not found"), err)
 	require.Equal(t, 1, projectGetter.calls)
 	require.Equal(t, 1, pipedGetter.calls)
 
 	// Found piped but project id was not correct.
-	err = v.Verify(ctx, "project-1", "piped-1-2", "piped-key-1-2")
+	_, err = v.Verify(ctx, "project-1", "piped-1-2", "piped-key-1-2")
 	assert.Equal(t, fmt.Errorf("the project of piped piped-1-2 is not matched, expected=project-1, got=project-non-existence"), err)
 	require.Equal(t, 2, projectGetter.calls)
 	require.Equal(t, 2, pipedGetter.calls)
 
 	// Found piped but it was disabled.
-	err = v.Verify(ctx, "project-1", "piped-1-3", "piped-key-1-3")
+	_, err = v.Verify(ctx, "project-1", "piped-1-3", "piped-key-1-3")
 	assert.Equal(t, fmt.Errorf("piped piped-1-3 was already disabled"), err)
 	require.Equal(t, 2, projectGetter.calls)
 	require.Equal(t, 3, pipedGetter.calls)
 
 	piped13 := pipedGetter.pipeds["piped-1-3"]
 	piped13.Disabled = false
-	err = v.Verify(ctx, "project-1", "piped-1-3", "piped-key-1-3")
+	_, err = v.Verify(ctx, "project-1", "piped-1-3", "piped-key-1-3")
 	assert.Equal(t, nil, err)
 	require.Equal(t, 2, projectGetter.calls)
 	require.Equal(t, 4, pipedGetter.calls)
 
 	// OK.
-	err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1")
+	_, err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1")
 	assert.Equal(t, nil, err)
 	require.Equal(t, 2, projectGetter.calls)
 	require.Equal(t, 5, pipedGetter.calls)
 
 	// Wrong piped key.
-	err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1-wrong")
+	_, err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1-wrong")
 	assert.Equal(t, fmt.Errorf("the key of piped piped-1-1 is not matched, crypto/bcrypt: hashedPassword is not the hash of the given password"), err)
 	require.Equal(t, 2, projectGetter.calls)
 	require.Equal(t, 6, pipedGetter.calls)
 
 	// The invalid key should be cached.
-	err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1-wrong")
+	_, err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1-wrong")
 	assert.Equal(t, fmt.Errorf("the key of piped piped-1-1 is not matched, crypto/bcrypt: hashedPassword is not the hash of the given password"), err)
 	require.Equal(t, 2, projectGetter.calls)
 	require.Equal(t, 6, pipedGetter.calls)
@@ -184,8 +184,22 @@ func TestVerify(t *testing.T) {
 	piped11.Keys = append(piped11.Keys, &model.PipedKey{
 		Hash: hashGenerator("piped-key-1-1-new"),
 	})
-	err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1-new")
+	_, err = v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1-new")
 	assert.Equal(t, nil, err)
 	require.Equal(t, 2, projectGetter.calls)
 	require.Equal(t, 7, pipedGetter.calls)
+
+	// The scope of the matched key should be returned.
+	scope := &model.PipedKeyScope{
+		RepositoryIds: []string{"repo-1"},
+	}
+	piped11.Keys = append(piped11.Keys, &model.PipedKey{
+		Hash:  hashGenerator("piped-key-1-1-scoped"),
+		Scope: scope,
+	})
+	got, err := v.Verify(ctx, "project-1", "piped-1-1", "piped-key-1-1-scoped")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, scope, got)
+	require.Equal(t, 2, projectGetter.calls)
+	require.Equal(t, 8, pipedGetter.calls)
 }
@@ -29,20 +29,33 @@ type Client interface {
 
 type client struct {
 	PipedServiceClient
-	conn *grpc.ClientConn
+	conn   *grpc.ClientConn
+	cancel context.CancelFunc
 }
 
+// NewClient creates a new client to connect to the piped service.
+// All calls made through this client are instrumented
+// and exposed as prometheus metrics.
 func NewClient(ctx context.Context, addr string, opts ...rpcclient.DialOption) (Client, error) {
+	opts = append(opts, rpcclient.WithUnaryInterceptor(metricsUnaryClientInterceptor()))
 	conn, err := rpcclient.DialContext(ctx, addr, opts...)
 	if err != nil {
 		return nil, err
 	}
+
+	// The connection lives longer than the given context
+	// so its state should be watched until it is closed.
+	watchCtx, cancel := context.WithCancel(context.Background())
+	go watchConnectionState(watchCtx, conn)
+
 	return &client{
 		PipedServiceClient: NewPipedServiceClient(conn),
 		conn:               conn,
+		cancel:             cancel,
 	}, nil
 }
 
 func (c *client) Close() error {
+	c.cancel()
 	return c.conn.Close()
 }
@@ -0,0 +1,79 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipedservice
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// maxDecompressedLogBlocksSize is the maximum size in bytes of the log blocks
+// restored from the compressed data to prevent a small payload from being
+// inflated to a huge one.
+const maxDecompressedLogBlocksSize = 64 * 1024 * 1024
+
+// CompressLogBlocks marshals the given log blocks and compresses them by gzip.
+func CompressLogBlocks(blocks []*model.LogBlock) ([]byte, error) {
+	data, err := proto.Marshal(&LogBlocks{Blocks: blocks})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal log blocks: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress log blocks: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress log blocks: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressLogBlocks restores the log blocks from the data compressed by CompressLogBlocks.
+// An error is returned if the decompressed data is larger than maxDecompressedLogBlocksSize.
+func DecompressLogBlocks(data []byte) ([]*model.LogBlock, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress log blocks: %w", err)
+	}
+	defer r.Close()
+
+	raw, err := ioutil.ReadAll(io.LimitReader(r, maxDecompressedLogBlocksSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress log blocks: %w", err)
+	}
+	if len(raw) > maxDecompressedLogBlocksSize {
+		return nil, fmt.Errorf("decompressed log blocks exceeded the limit of %d bytes", maxDecompressedLogBlocksSize)
+	}
+
+	var lbs LogBlocks
+	if err := proto.Unmarshal(raw, &lbs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal log blocks: %w", err)
+	}
+	for _, b := range lbs.Blocks {
+		if err := b.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid log block: %w", err)
+		}
+	}
+	return lbs.Blocks, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipedservice
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestCompressLogBlocks(t *testing.T) {
+	blocks := []*model.LogBlock{
+		{
+			Index:     1,
+			Log:       "terraform plan",
+			Severity:  model.LogSeverity_INFO,
+			CreatedAt: 1,
+		},
+		{
+			Index:     2,
+			Log:       "Plan: 1 to add, 0 to change, 0 to destroy.",
+			Severity:  model.LogSeverity_SUCCESS,
+			CreatedAt: 2,
+		},
+	}
+
+	data, err := CompressLogBlocks(blocks)
+	require.NoError(t, err)
+
+	got, err := DecompressLogBlocks(data)
+	require.NoError(t, err)
+	require.Equal(t, len(blocks), len(got))
+	for i := range blocks {
+		assert.True(t, proto.Equal(blocks[i], got[i]))
+	}
+
+	_, err = DecompressLogBlocks([]byte("invalid"))
+	assert.Error(t, err)
+
+	data, err = CompressLogBlocks([]*model.LogBlock{{Index: 1}})
+	require.NoError(t, err)
+	_, err = DecompressLogBlocks(data)
+	assert.Error(t, err)
+}
+
+func TestDecompressLogBlocksExceedingLimit(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(make([]byte, maxDecompressedLogBlocksSize+1))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = DecompressLogBlocks(buf.Bytes())
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipedservice
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	metricsLabelMethod = "method"
+	metricsLabelCode   = "code"
+	metricsLabelState  = "state"
+)
+
+var (
+	metricsClientCalls = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pipedservice_client_calls_total",
+			Help: "Number of RPC calls made by piped to control-plane.",
+		},
+		[]string{
+			metricsLabelMethod,
+			metricsLabelCode,
+		},
+	)
+	metricsClientCallDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pipedservice_client_call_duration_seconds",
+			Help:    "Latency of RPC calls made by piped to control-plane.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{
+			metricsLabelMethod,
+		},
+	)
+	metricsClientConnectionState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pipedservice_client_connection_state",
+			Help: "Current state of the connection from piped to control-plane. The value of the current state is 1, the others are 0.",
+		},
+		[]string{
+			metricsLabelState,
+		},
+	)
+)
+
+var connectivityStates = []connectivity.State{
+	connectivity.Idle,
+	connectivity.Connecting,
+	connectivity.Ready,
+	connectivity.TransientFailure,
+	connectivity.Shutdown,
+}
+
+func init() {
+	registerMetrics()
+}
+
+func registerMetrics() {
+	prometheus.MustRegister(
+		metricsClientCalls,
+		metricsClientCallDuration,
+		metricsClientConnectionState,
+	)
+}
+
+// metricsUnaryClientInterceptor records the number and the latency
+// of all unary RPC calls along with their status code.
+func metricsUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		name := path.Base(method)
+		metricsClientCallDuration.With(prometheus.Labels{
+			metricsLabelMethod: name,
+		}).Observe(time.Since(start).Seconds())
+		metricsClientCalls.With(prometheus.Labels{
+			metricsLabelMethod: name,
+			metricsLabelCode:   status.Code(err).String(),
+		}).Inc()
+
+		return err
+	}
+}
+
+// watchConnectionState keeps updating the connection state gauge
+// until the given context is done or the connection is shutdown.
+func watchConnectionState(ctx context.Context, conn *grpc.ClientConn) {
+	for {
+		state := conn.GetState()
+		setConnectionState(state)
+		if state == connectivity.Shutdown {
+			return
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return
+		}
+	}
+}
+
+func setConnectionState(current connectivity.State) {
+	for _, s := range connectivityStates {
+		var v float64
+		if s == current {
+			v = 1
+		}
+		metricsClientConnectionState.With(prometheus.Labels{
+			metricsLabelState: s.String(),
+		}).Set(v)
+	}
+}
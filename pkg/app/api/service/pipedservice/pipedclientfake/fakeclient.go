@@ -360,6 +360,15 @@ func (c *fakeClient) SaveDeploymentMetadata(ctx context.Context, req *pipedservi
 
 // SaveStageMetadata used by piped to persist the metadata
 // of a specific stage of a deployment.
+func (c *fakeClient) CreateApprovalLinks(ctx context.Context, req *pipedservice.CreateApprovalLinksRequest, opts ...grpc.CallOption) (*pipedservice.CreateApprovalLinksResponse, error) {
+	c.logger.Info("fake client received CreateApprovalLinks rpc", zap.Any("request", req))
+	links := make(map[string]string, len(req.Approvers))
+	for _, approver := range req.Approvers {
+		links[approver] = "https://pipecd.dev/approvals?token=fake"
+	}
+	return &pipedservice.CreateApprovalLinksResponse{Links: links}, nil
+}
+
 func (c *fakeClient) SaveStageMetadata(ctx context.Context, req *pipedservice.SaveStageMetadataRequest, opts ...grpc.CallOption) (*pipedservice.SaveStageMetadataResponse, error) {
 	c.logger.Info("fake client received SaveStageMetadata rpc", zap.Any("request", req))
 	c.mu.Lock()
@@ -430,6 +439,13 @@ func (c *fakeClient) ListUnhandledCommands(ctx context.Context, req *pipedservic
 	return &pipedservice.ListUnhandledCommandsResponse{}, nil
 }
 
+// WatchUnhandledCommands is not supported by the fake client
+// so that piped falls back to calling ListUnhandledCommands periodically.
+func (c *fakeClient) WatchUnhandledCommands(ctx context.Context, opts ...grpc.CallOption) (pipedservice.PipedService_WatchUnhandledCommandsClient, error) {
+	c.logger.Info("fake client received WatchUnhandledCommands rpc")
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
 // ReportCommandHandled is called by piped to mark a specific command as handled.
 // The request payload will contain the handle status as well as any additional result data.
 // The handle result should be updated to both datastore and cache (for reading from web).
@@ -438,6 +454,33 @@ func (c *fakeClient) ReportCommandHandled(ctx context.Context, req *pipedservice
 	return &pipedservice.ReportCommandHandledResponse{}, nil
 }
 
+// ReportCommandOutputChunks is used to send a large output of a command by splitting into multiple chunks.
+func (c *fakeClient) ReportCommandOutputChunks(ctx context.Context, opts ...grpc.CallOption) (pipedservice.PipedService_ReportCommandOutputChunksClient, error) {
+	return &fakeCommandOutputChunksClient{
+		ctx:    ctx,
+		logger: c.logger,
+	}, nil
+}
+
+type fakeCommandOutputChunksClient struct {
+	grpc.ClientStream
+	ctx    context.Context
+	logger *zap.Logger
+}
+
+func (c *fakeCommandOutputChunksClient) Context() context.Context {
+	return c.ctx
+}
+
+func (c *fakeCommandOutputChunksClient) Send(chunk *pipedservice.ReportCommandOutputChunk) error {
+	c.logger.Info("fake client received ReportCommandOutputChunks chunk", zap.String("command-id", chunk.CommandId), zap.Int("size", len(chunk.Data)))
+	return nil
+}
+
+func (c *fakeCommandOutputChunksClient) CloseAndRecv() (*pipedservice.ReportCommandOutputChunksResponse, error) {
+	return &pipedservice.ReportCommandOutputChunksResponse{}, nil
+}
+
 // ReportApplicationLiveState is periodically sent to correct full state of an application.
 // For kubernetes application, this contains a full tree of its kubernetes resources.
 // The tree data should be written into filestore immediately and then the state in cache should be refreshsed too.
@@ -446,6 +489,33 @@ func (c *fakeClient) ReportApplicationLiveState(ctx context.Context, req *pipeds
 	return &pipedservice.ReportApplicationLiveStateResponse{}, nil
 }
 
+// ReportApplicationLiveStateChunks is used to send a large snapshot by splitting into multiple chunks.
+func (c *fakeClient) ReportApplicationLiveStateChunks(ctx context.Context, opts ...grpc.CallOption) (pipedservice.PipedService_ReportApplicationLiveStateChunksClient, error) {
+	return &fakeLiveStateChunksClient{
+		ctx:    ctx,
+		logger: c.logger,
+	}, nil
+}
+
+type fakeLiveStateChunksClient struct {
+	grpc.ClientStream
+	ctx    context.Context
+	logger *zap.Logger
+}
+
+func (c *fakeLiveStateChunksClient) Context() context.Context {
+	return c.ctx
+}
+
+func (c *fakeLiveStateChunksClient) Send(chunk *pipedservice.ReportApplicationLiveStateChunk) error {
+	c.logger.Info("fake client received ReportApplicationLiveStateChunks chunk", zap.Any("chunk", chunk))
+	return nil
+}
+
+func (c *fakeLiveStateChunksClient) CloseAndRecv() (*pipedservice.ReportApplicationLiveStateResponse, error) {
+	return &pipedservice.ReportApplicationLiveStateResponse{}, nil
+}
+
 // ReportApplicationLiveStateEvents is sent by piped to submit one or multiple events
 // about the changes of application state.
 // Control plane uses the received events to update the state of application-resource-tree.
@@ -23,6 +23,9 @@ import (
 	"github.com/pipe-cd/pipe/pkg/backoff"
 )
 
+// apiRetryBudget is the maximum time spent for calling an API with retries.
+const apiRetryBudget = 5 * time.Minute
+
 // Retriable checks whether the caller should retry the api call for the given error.
 func Retriable(err error) bool {
 	switch status.Code(err) {
@@ -49,7 +52,12 @@ func Retriable(err error) bool {
 
 // NewRetry returns a new backoff.Retry for piped API caller.
 // 0s 997.867435ms 2.015381172s 3.485134345s 4.389600179s 18.118099328s 48.73058264s
+// Its Do stops retrying once a non-retriable error was returned
+// or the total time spent exceeded 5 minutes.
 func NewRetry(maxRetries int) backoff.Retry {
 	bo := backoff.NewExponential(2*time.Second, time.Minute)
-	return backoff.NewRetry(maxRetries, bo)
+	return backoff.NewRetry(maxRetries, bo,
+		backoff.WithRetryable(Retriable),
+		backoff.WithMaxElapsedTime(apiRetryBudget),
+	)
 }
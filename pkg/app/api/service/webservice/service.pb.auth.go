@@ -46,6 +46,12 @@ func (a *authorizer) Authorize(method string, r model.Role) bool {
 		return isAdmin(r)
 	case "/pipe.api.service.webservice.WebService/UpdateEnvironmentDesc":
 		return isAdmin(r)
+	case "/pipe.api.service.webservice.WebService/UpdateEnvironmentOrder":
+		return isAdmin(r)
+	case "/pipe.api.service.webservice.WebService/EnableEnvironmentProtection":
+		return isAdmin(r)
+	case "/pipe.api.service.webservice.WebService/DisableEnvironmentProtection":
+		return isAdmin(r)
 	case "/pipe.api.service.webservice.WebService/RegisterPiped":
 		return isAdmin(r)
 	case "/pipe.api.service.webservice.WebService/UpdatePiped":
@@ -76,6 +82,10 @@ func (a *authorizer) Authorize(method string, r model.Role) bool {
 		return isAdmin(r)
 	case "/pipe.api.service.webservice.WebService/DisableStaticAdmin":
 		return isAdmin(r)
+	case "/pipe.api.service.webservice.WebService/EnablePodDebugging":
+		return isAdmin(r)
+	case "/pipe.api.service.webservice.WebService/DisablePodDebugging":
+		return isAdmin(r)
 	case "/pipe.api.service.webservice.WebService/UpdateProjectSSOConfig":
 		return isAdmin(r)
 	case "/pipe.api.service.webservice.WebService/UpdateProjectRBACConfig":
@@ -86,6 +96,8 @@ func (a *authorizer) Authorize(method string, r model.Role) bool {
 		return isAdmin(r)
 	case "/pipe.api.service.webservice.WebService/ListAPIKeys":
 		return isAdmin(r)
+	case "/pipe.api.service.webservice.WebService/RevokeSessions":
+		return isAdmin(r)
 
 	case "/pipe.api.service.webservice.WebService/SyncApplication":
 		return isAdmin(r) || isEditor(r)
@@ -93,8 +105,16 @@ func (a *authorizer) Authorize(method string, r model.Role) bool {
 		return isAdmin(r) || isEditor(r)
 	case "/pipe.api.service.webservice.WebService/ApproveStage":
 		return isAdmin(r) || isEditor(r)
+	case "/pipe.api.service.webservice.WebService/SkipStage":
+		return isAdmin(r) || isEditor(r)
+	case "/pipe.api.service.webservice.WebService/EnableDebugLogging":
+		return isAdmin(r) || isEditor(r)
 	case "/pipe.api.service.webservice.WebService/GenerateApplicationSealedSecret":
 		return isAdmin(r) || isEditor(r)
+	case "/pipe.api.service.webservice.WebService/ExecuteResourceAction":
+		return isAdmin(r) || isEditor(r)
+	case "/pipe.api.service.webservice.WebService/GetResourceActionOutput":
+		return isAdmin(r) || isEditor(r)
 
 	case "/pipe.api.service.webservice.WebService/GetApplicationLiveState":
 		return isAdmin(r) || isEditor(r) || isViewer(r)
@@ -0,0 +1,105 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionverifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/cache"
+	"github.com/pipe-cd/pipe/pkg/cache/memorycache"
+	"github.com/pipe-cd/pipe/pkg/jwt"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type sessionGetter interface {
+	GetSession(ctx context.Context, id string) (*model.Session, error)
+}
+
+// Verifier verifies the given access token and also checks
+// whether the session it was issued for is still usable.
+// Because the sessions are cached for a minute,
+// revoking a session takes effect within that duration.
+type Verifier struct {
+	verifier     jwt.Verifier
+	sessionCache cache.Cache
+	sessionStore sessionGetter
+	nowFunc      func() time.Time
+	logger       *zap.Logger
+}
+
+func NewVerifier(ctx context.Context, verifier jwt.Verifier, getter sessionGetter, logger *zap.Logger) *Verifier {
+	return &Verifier{
+		verifier:     verifier,
+		sessionCache: memorycache.NewTTLCache(ctx, time.Minute, 30*time.Second),
+		sessionStore: getter,
+		nowFunc:      time.Now,
+		logger:       logger,
+	}
+}
+
+func (v *Verifier) Verify(token string) (*jwt.Claims, error) {
+	claims, err := v.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	id := claims.SessionID()
+	if id == "" {
+		return nil, fmt.Errorf("missing session id")
+	}
+
+	var session *model.Session
+	item, err := v.sessionCache.Get(id)
+	if err == nil {
+		session = item.(*model.Session)
+	} else {
+		// If the cache data was not found,
+		// we have to retrieve from datastore and save it to the cache.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		session, err = v.sessionStore.GetSession(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find session %s from datastore, %w", id, err)
+		}
+		if err := v.sessionCache.Put(id, session); err != nil {
+			v.logger.Warn("unable to store session in memory cache", zap.Error(err))
+		}
+	}
+
+	if err := checkSession(session, claims, v.nowFunc()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func checkSession(session *model.Session, claims *jwt.Claims, now time.Time) error {
+	// The idle timeout is checked only while refreshing the session
+	// since the last active time in the cache may be outdated.
+	if err := session.CheckActive(now, 0); err != nil {
+		return err
+	}
+	if session.ProjectId != claims.Role.ProjectId {
+		return fmt.Errorf("session %s does not belong to project %s", session.Id, claims.Role.ProjectId)
+	}
+	if session.Username != claims.Subject {
+		return fmt.Errorf("session %s does not belong to user %s", session.Id, claims.Subject)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionverifier
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/pipe-cd/pipe/pkg/jwt"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeJWTVerifier struct {
+	claims map[string]*jwt.Claims
+}
+
+func (v *fakeJWTVerifier) Verify(token string) (*jwt.Claims, error) {
+	c, ok := v.claims[token]
+	if !ok {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return c, nil
+}
+
+type fakeSessionGetter struct {
+	calls    int
+	sessions map[string]*model.Session
+}
+
+func (g *fakeSessionGetter) GetSession(_ context.Context, id string) (*model.Session, error) {
+	g.calls++
+	s, ok := g.sessions[id]
+	if ok {
+		msg := proto.Clone(s)
+		return msg.(*model.Session), nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+func TestVerify(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newClaims := func(username, sessionID string) *jwt.Claims {
+		return jwt.NewClaims(username, "", time.Hour, model.Role{
+			ProjectId:   "project",
+			ProjectRole: model.Role_VIEWER,
+		}, sessionID)
+	}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	jwtVerifier := &fakeJWTVerifier{
+		claims: map[string]*jwt.Claims{
+			"active-token":     newClaims("user", "active-session"),
+			"revoked-token":    newClaims("user", "revoked-session"),
+			"other-user-token": newClaims("other-user", "active-session"),
+			"no-session-token": newClaims("user", ""),
+			"missing-token":    newClaims("user", "missing-session"),
+		},
+	}
+	sessionGetter := &fakeSessionGetter{
+		sessions: map[string]*model.Session{
+			"active-session": {
+				Id:        "active-session",
+				ProjectId: "project",
+				Username:  "user",
+				ExpiresAt: expiresAt,
+			},
+			"revoked-session": {
+				Id:        "revoked-session",
+				ProjectId: "project",
+				Username:  "user",
+				ExpiresAt: expiresAt,
+				Revoked:   true,
+			},
+		},
+	}
+	v := NewVerifier(ctx, jwtVerifier, sessionGetter, zap.NewNop())
+
+	// Not found token.
+	_, err := v.Verify("unknown-token")
+	require.Error(t, err)
+	assert.Equal(t, 0, sessionGetter.calls)
+
+	// Token without session id.
+	_, err = v.Verify("no-session-token")
+	require.Error(t, err)
+	assert.Equal(t, 0, sessionGetter.calls)
+
+	// Not found session.
+	_, err = v.Verify("missing-token")
+	require.Error(t, err)
+	assert.Equal(t, 1, sessionGetter.calls)
+
+	// Active session.
+	claims, err := v.Verify("active-token")
+	require.NoError(t, err)
+	assert.Equal(t, "user", claims.Subject)
+	assert.Equal(t, 2, sessionGetter.calls)
+
+	// Active session from cache.
+	_, err = v.Verify("active-token")
+	require.NoError(t, err)
+	assert.Equal(t, 2, sessionGetter.calls)
+
+	// Session of another user.
+	_, err = v.Verify("other-user-token")
+	require.Error(t, err)
+	assert.Equal(t, 2, sessionGetter.calls)
+
+	// Revoked session.
+	_, err = v.Verify("revoked-token")
+	require.Error(t, err)
+	assert.Equal(t, 3, sessionGetter.calls)
+}
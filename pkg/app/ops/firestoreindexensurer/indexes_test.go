@@ -170,6 +170,22 @@ func TestParseIndexes(t *testing.T) {
 				},
 			},
 		},
+		{
+			CollectionGroup: "Application",
+			QueryScope:      "COLLECTION",
+			Fields: []field{
+				{
+					FieldPath:   "ProjectId",
+					Order:       "ASCENDING",
+					ArrayConfig: "",
+				},
+				{
+					FieldPath:   "UpdatedAt",
+					Order:       "ASCENDING",
+					ArrayConfig: "",
+				},
+			},
+		},
 		{
 			CollectionGroup: "Command",
 			QueryScope:      "COLLECTION",
@@ -291,6 +307,27 @@ func TestParseIndexes(t *testing.T) {
 				},
 			},
 		},
+		{
+			CollectionGroup: "Deployment",
+			QueryScope:      "COLLECTION",
+			Fields: []field{
+				{
+					FieldPath:   "PipedId",
+					Order:       "ASCENDING",
+					ArrayConfig: "",
+				},
+				{
+					FieldPath:   "ProjectId",
+					Order:       "ASCENDING",
+					ArrayConfig: "",
+				},
+				{
+					FieldPath:   "UpdatedAt",
+					Order:       "ASCENDING",
+					ArrayConfig: "",
+				},
+			},
+		},
 		{
 			CollectionGroup: "Event",
 			QueryScope:      "COLLECTION",
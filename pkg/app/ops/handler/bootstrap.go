@@ -0,0 +1,231 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const (
+	bootstrapProjectCreator = "ops"
+	maxBootstrapRequestSize = 1 << 20
+)
+
+type environmentStore interface {
+	AddEnvironment(ctx context.Context, env *model.Environment) error
+}
+
+type pipedStore interface {
+	AddPiped(ctx context.Context, piped *model.Piped) error
+}
+
+type bootstrapProjectRequest struct {
+	// The unique identifier of the project.
+	ID string `json:"id"`
+	// The description about the project.
+	Description string `json:"description"`
+	// The name of the shared SSO configuration the project should be bound to.
+	SharedSSOName string `json:"sharedSSOName"`
+	// Whether the generated static admin should be disabled.
+	// This is allowed only when the project was bound to a shared SSO configuration.
+	DisableStaticAdmin bool `json:"disableStaticAdmin"`
+	// The first environment to be created in the project.
+	Environment *bootstrapEnvironment `json:"environment"`
+	// The first piped to be registered in the project.
+	// It belongs to the created environment if specified.
+	Piped *bootstrapPiped `json:"piped"`
+}
+
+type bootstrapEnvironment struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type bootstrapPiped struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type bootstrapProjectResponse struct {
+	ProjectID     string                `json:"projectId"`
+	StaticAdmin   *bootstrapStaticAdmin `json:"staticAdmin,omitempty"`
+	EnvironmentID string                `json:"environmentId,omitempty"`
+	PipedID       string                `json:"pipedId,omitempty"`
+	PipedKey      string                `json:"pipedKey,omitempty"`
+}
+
+type bootstrapStaticAdmin struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (r *bootstrapProjectRequest) validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if r.DisableStaticAdmin && r.SharedSSOName == "" {
+		return fmt.Errorf("static admin can be disabled only when sharedSSOName was specified")
+	}
+	if r.Environment != nil && r.Environment.Name == "" {
+		return fmt.Errorf("environment.name is required")
+	}
+	if r.Piped != nil && r.Piped.Name == "" {
+		return fmt.Errorf("piped.name is required")
+	}
+	return nil
+}
+
+// handleBootstrapProject creates a new project together with its first environment and piped
+// in one call, so that provisioning projects can be done by scripts.
+// Both request and response are JSON encoded.
+func (h *Handler) handleBootstrapProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var req bootstrapProjectRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBootstrapRequestSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body (%v)", err), http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request (%v)", err), http.StatusBadRequest)
+		return
+	}
+	if req.SharedSSOName != "" && !h.hasSharedSSOConfig(req.SharedSSOName) {
+		http.Error(w, fmt.Sprintf("SharedSSOConfig %q was not found in Control Plane configuration", req.SharedSSOName), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.bootstrapProject(ctx, &req)
+	if err != nil {
+		h.logger.Error("failed to bootstrap project",
+			zap.String("id", req.ID),
+			zap.Error(err),
+		)
+		http.Error(w, fmt.Sprintf("Unable to bootstrap the project (%v)", err), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info("successfully bootstrapped a new project",
+		zap.String("id", resp.ProjectID),
+		zap.String("environment-id", resp.EnvironmentID),
+		zap.String("piped-id", resp.PipedID),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to write bootstrap project response", zap.Error(err))
+	}
+}
+
+// bootstrapProject adds the project and then its environment and piped.
+// Since those are not done in a transaction, the returned error tells
+// which resources have already been created.
+func (h *Handler) bootstrapProject(ctx context.Context, req *bootstrapProjectRequest) (*bootstrapProjectResponse, error) {
+	project, username, password, err := newProject(req.ID, req.Description, req.SharedSSOName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set static admin: %w", err)
+	}
+	project.StaticAdminDisabled = req.DisableStaticAdmin
+
+	if err := h.projectStore.AddProject(ctx, project); err != nil {
+		return nil, fmt.Errorf("failed to add project: %w", err)
+	}
+
+	resp := &bootstrapProjectResponse{
+		ProjectID: project.Id,
+	}
+	if !req.DisableStaticAdmin {
+		resp.StaticAdmin = &bootstrapStaticAdmin{
+			Username: username,
+			Password: password,
+		}
+	}
+
+	if req.Environment != nil {
+		env := &model.Environment{
+			Id:        uuid.New().String(),
+			Name:      req.Environment.Name,
+			Desc:      req.Environment.Description,
+			ProjectId: project.Id,
+		}
+		if err := h.environmentStore.AddEnvironment(ctx, env); err != nil {
+			return nil, fmt.Errorf("project %s was added but failed to add environment: %w", project.Id, err)
+		}
+		resp.EnvironmentID = env.Id
+	}
+
+	if req.Piped != nil {
+		key, keyHash, err := model.GeneratePipedKey()
+		if err != nil {
+			return nil, fmt.Errorf("project %s was added but failed to generate piped key: %w", project.Id, err)
+		}
+		piped := &model.Piped{
+			Id:        uuid.New().String(),
+			Name:      req.Piped.Name,
+			Desc:      req.Piped.Description,
+			ProjectId: project.Id,
+			Status:    model.Piped_OFFLINE,
+		}
+		if resp.EnvironmentID != "" {
+			piped.EnvIds = []string{resp.EnvironmentID}
+		}
+		if err := piped.AddKey(keyHash, bootstrapProjectCreator, time.Now(), nil); err != nil {
+			return nil, fmt.Errorf("project %s was added but failed to add piped key: %w", project.Id, err)
+		}
+		if err := h.pipedStore.AddPiped(ctx, piped); err != nil {
+			return nil, fmt.Errorf("project %s was added but failed to register piped: %w", project.Id, err)
+		}
+		resp.PipedID = piped.Id
+		resp.PipedKey = key
+	}
+
+	return resp, nil
+}
+
+// newProject returns a new project with a randomly generated static admin.
+func newProject(id, description, sharedSSOName string) (project *model.Project, username, password string, err error) {
+	project = &model.Project{
+		Id:            id,
+		Desc:          description,
+		SharedSsoName: sharedSSOName,
+	}
+	username = model.GenerateRandomString(10)
+	password = model.GenerateRandomString(30)
+	err = project.SetStaticAdmin(username, password)
+	return
+}
+
+func (h *Handler) hasSharedSSOConfig(name string) bool {
+	for i := range h.sharedSSOConfigs {
+		if h.sharedSSOConfigs[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
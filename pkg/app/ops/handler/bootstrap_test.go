@@ -0,0 +1,175 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/datastore"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeStore struct {
+	projects     []*model.Project
+	environments []*model.Environment
+	pipeds       []*model.Piped
+	envErr       error
+}
+
+func (s *fakeStore) AddProject(_ context.Context, proj *model.Project) error {
+	s.projects = append(s.projects, proj)
+	return nil
+}
+
+func (s *fakeStore) ListProjects(_ context.Context, _ datastore.ListOptions) ([]model.Project, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) AddEnvironment(_ context.Context, env *model.Environment) error {
+	if s.envErr != nil {
+		return s.envErr
+	}
+	s.environments = append(s.environments, env)
+	return nil
+}
+
+func (s *fakeStore) AddPiped(_ context.Context, piped *model.Piped) error {
+	s.pipeds = append(s.pipeds, piped)
+	return nil
+}
+
+func TestHandleBootstrapProject(t *testing.T) {
+	testcases := []struct {
+		name         string
+		method       string
+		body         string
+		envErr       error
+		expectedCode int
+		check        func(t *testing.T, s *fakeStore, resp *bootstrapProjectResponse)
+	}{
+		{
+			name:         "wrong method",
+			method:       http.MethodGet,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "malformed body",
+			method:       http.MethodPost,
+			body:         `{"id":`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing id",
+			method:       http.MethodPost,
+			body:         `{"description": "desc"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "disable static admin without sso",
+			method:       http.MethodPost,
+			body:         `{"id": "project", "disableStaticAdmin": true}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown shared sso",
+			method:       http.MethodPost,
+			body:         `{"id": "project", "sharedSSOName": "unknown"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "only project",
+			method:       http.MethodPost,
+			body:         `{"id": "project", "description": "desc"}`,
+			expectedCode: http.StatusOK,
+			check: func(t *testing.T, s *fakeStore, resp *bootstrapProjectResponse) {
+				require.Len(t, s.projects, 1)
+				assert.Equal(t, "project", s.projects[0].Id)
+				assert.Equal(t, "desc", s.projects[0].Desc)
+				assert.Len(t, s.environments, 0)
+				assert.Len(t, s.pipeds, 0)
+
+				assert.Equal(t, "project", resp.ProjectID)
+				require.NotNil(t, resp.StaticAdmin)
+				assert.NoError(t, s.projects[0].StaticAdmin.Auth(resp.StaticAdmin.Username, resp.StaticAdmin.Password))
+				assert.Empty(t, resp.PipedKey)
+			},
+		},
+		{
+			name:   "project with sso, environment and piped",
+			method: http.MethodPost,
+			body: `{
+				"id": "project",
+				"sharedSSOName": "github",
+				"disableStaticAdmin": true,
+				"environment": {"name": "dev"},
+				"piped": {"name": "piped-1"}
+			}`,
+			expectedCode: http.StatusOK,
+			check: func(t *testing.T, s *fakeStore, resp *bootstrapProjectResponse) {
+				require.Len(t, s.projects, 1)
+				assert.Equal(t, "github", s.projects[0].SharedSsoName)
+				assert.True(t, s.projects[0].StaticAdminDisabled)
+				assert.Nil(t, resp.StaticAdmin)
+
+				require.Len(t, s.environments, 1)
+				assert.Equal(t, "project", s.environments[0].ProjectId)
+				assert.Equal(t, "dev", s.environments[0].Name)
+				assert.Equal(t, s.environments[0].Id, resp.EnvironmentID)
+
+				require.Len(t, s.pipeds, 1)
+				assert.Equal(t, "project", s.pipeds[0].ProjectId)
+				assert.Equal(t, []string{resp.EnvironmentID}, s.pipeds[0].EnvIds)
+				assert.Equal(t, s.pipeds[0].Id, resp.PipedID)
+				assert.NoError(t, s.pipeds[0].CheckKey(resp.PipedKey))
+			},
+		},
+		{
+			name:         "failed to add environment",
+			method:       http.MethodPost,
+			body:         `{"id": "project", "environment": {"name": "dev"}}`,
+			envErr:       fmt.Errorf("error"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &fakeStore{envErr: tc.envErr}
+			h := NewHandler(0, s, s, s, []config.SharedSSOConfig{{Name: "github"}}, 0, zap.NewNop())
+
+			req := httptest.NewRequest(tc.method, "/api/projects/bootstrap", bytes.NewBufferString(tc.body))
+			rec := httptest.NewRecorder()
+			h.handleBootstrapProject(rec, req)
+
+			require.Equal(t, tc.expectedCode, rec.Code, rec.Body.String())
+			if tc.check == nil {
+				return
+			}
+			var resp bootstrapProjectResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			tc.check(t, s, &resp)
+		})
+	}
+}
@@ -44,16 +44,20 @@ type projectStore interface {
 type Handler struct {
 	port             int
 	projectStore     projectStore
+	environmentStore environmentStore
+	pipedStore       pipedStore
 	sharedSSOConfigs []config.SharedSSOConfig
 	server           *http.Server
 	gracePeriod      time.Duration
 	logger           *zap.Logger
 }
 
-func NewHandler(port int, ps projectStore, sharedSSOConfigs []config.SharedSSOConfig, gracePeriod time.Duration, logger *zap.Logger) *Handler {
+func NewHandler(port int, ps projectStore, es environmentStore, pps pipedStore, sharedSSOConfigs []config.SharedSSOConfig, gracePeriod time.Duration, logger *zap.Logger) *Handler {
 	mux := http.NewServeMux()
 	h := &Handler{
 		projectStore:     ps,
+		environmentStore: es,
+		pipedStore:       pps,
 		sharedSSOConfigs: sharedSSOConfigs,
 		server: &http.Server{
 			Addr:    fmt.Sprintf(":%d", port),
@@ -66,6 +70,7 @@ func NewHandler(port int, ps projectStore, sharedSSOConfigs []config.SharedSSOCo
 	mux.HandleFunc("/", h.handleTop)
 	mux.HandleFunc("/projects", h.handleListProjects)
 	mux.HandleFunc("/projects/add", h.handleAddProject)
+	mux.HandleFunc("/api/projects/bootstrap", h.handleBootstrapProject)
 
 	return h
 }
@@ -167,31 +172,13 @@ func (h *Handler) handleAddProject(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid id", http.StatusBadRequest)
 		return
 	}
-	if sharedSSOName != "" {
-		found := false
-		for i := range h.sharedSSOConfigs {
-			if h.sharedSSOConfigs[i].Name == sharedSSOName {
-				found = true
-				break
-			}
-		}
-		if !found {
-			http.Error(w, fmt.Sprintf("SharedSSOConfig %q was not found in Control Plane configuration", sharedSSOName), http.StatusBadRequest)
-			return
-		}
+	if sharedSSOName != "" && !h.hasSharedSSOConfig(sharedSSOName) {
+		http.Error(w, fmt.Sprintf("SharedSSOConfig %q was not found in Control Plane configuration", sharedSSOName), http.StatusBadRequest)
+		return
 	}
 
-	var (
-		project = &model.Project{
-			Id:            id,
-			Desc:          description,
-			SharedSsoName: sharedSSOName,
-		}
-		username = model.GenerateRandomString(10)
-		password = model.GenerateRandomString(30)
-	)
-
-	if err := project.SetStaticAdmin(username, password); err != nil {
+	project, username, password, err := newProject(id, description, sharedSSOName)
+	if err != nil {
 		h.logger.Error("failed to set static admin",
 			zap.String("id", id),
 			zap.Error(err),
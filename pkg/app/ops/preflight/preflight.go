@@ -0,0 +1,271 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preflight provides a set of checks to verify that the control-plane
+// is configured correctly and all of its dependencies are reachable
+// before starting the server.
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/datastore"
+	"github.com/pipe-cd/pipe/pkg/filestore"
+	"github.com/pipe-cd/pipe/pkg/model"
+	"github.com/pipe-cd/pipe/pkg/redis"
+)
+
+const filestoreCheckPath = "preflight/check.txt"
+
+// Check represents a single preflight check.
+type Check struct {
+	// The name of the check shown in the report.
+	Name string
+	// The hint shown in the report to fix the problem when the check failed.
+	Hint string
+	// The function to perform the check.
+	Run func(ctx context.Context) error
+}
+
+// Result represents the result of a check.
+type Result struct {
+	Name string
+	Hint string
+	Err  error
+}
+
+// Report contains the results of all executed checks.
+type Report []Result
+
+// Failed reports whether any check in the report failed.
+func (r Report) Failed() bool {
+	for _, res := range r {
+		if res.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the human-readable form of the report to the given writer.
+func (r Report) Write(w io.Writer) {
+	var failed int
+	for _, res := range r {
+		if res.Err == nil {
+			fmt.Fprintf(w, "[OK]   %s\n", res.Name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "[FAIL] %s: %v\n", res.Name, res.Err)
+		if res.Hint != "" {
+			fmt.Fprintf(w, "       hint: %s\n", res.Hint)
+		}
+	}
+	fmt.Fprintf(w, "\n%d checks, %d failed\n", len(r), failed)
+}
+
+// Run executes the given checks in order and returns their results.
+// Each check is executed with the given timeout.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) Report {
+	report := make(Report, 0, len(checks))
+	for _, c := range checks {
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		err := c.Run(cctx)
+		cancel()
+
+		report = append(report, Result{
+			Name: c.Name,
+			Hint: c.Hint,
+			Err:  err,
+		})
+	}
+	return report
+}
+
+// FailedCheck returns a check that always fails with the given error.
+// This is used to report the dependencies which could not be even initialized.
+func FailedCheck(name, hint string, err error) Check {
+	return Check{
+		Name: name,
+		Hint: hint,
+		Run: func(_ context.Context) error {
+			return err
+		},
+	}
+}
+
+// DatastoreCheck returns a check to ensure that the datastore is reachable
+// and the configured credentials have permission to read data.
+func DatastoreCheck(ds datastore.DataStore) Check {
+	return Check{
+		Name: "datastore is reachable",
+		Hint: "make sure the datastore address, database name and credentials in the datastore config are correct",
+		Run: func(ctx context.Context) error {
+			it, err := ds.Find(ctx, datastore.ProjectModelKind, datastore.ListOptions{Limit: 1})
+			if err != nil {
+				return err
+			}
+			var p model.Project
+			if err := it.Next(&p); err != nil && !errors.Is(err, datastore.ErrIteratorDone) {
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// FilestoreCheck returns a check to ensure that the filestore is writable and readable.
+func FilestoreCheck(fs filestore.Store) Check {
+	return Check{
+		Name: "filestore is writable",
+		Hint: "make sure the bucket exists and the configured credentials have permission to write and read objects",
+		Run: func(ctx context.Context) error {
+			content := []byte(time.Now().UTC().Format(time.RFC3339))
+			if err := fs.PutObject(ctx, filestoreCheckPath, content); err != nil {
+				return fmt.Errorf("failed to put object: %w", err)
+			}
+			obj, err := fs.GetObject(ctx, filestoreCheckPath)
+			if err != nil {
+				return fmt.Errorf("failed to get object: %w", err)
+			}
+			if !bytes.Equal(obj.Content, content) {
+				return errors.New("the read object is different from the written one")
+			}
+			return nil
+		},
+	}
+}
+
+// CacheCheck returns a check to ensure that the cache is reachable.
+func CacheCheck(rd redis.Redis) Check {
+	return Check{
+		Name: "cache is reachable",
+		Hint: "make sure the cache address is correct and the redis server is running",
+		Run: func(_ context.Context) error {
+			conn := rd.Get()
+			defer conn.Close()
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+}
+
+// ProjectsCheck returns a check to ensure that the static admins of
+// the projects defined in the configuration are valid.
+func ProjectsCheck(cfg *config.ControlPlaneSpec) Check {
+	return Check{
+		Name: "project configs are valid",
+		Hint: "the passwordHash must be a bcrypt hash of the password, e.g. generated by htpasswd -nbBC 10",
+		Run: func(_ context.Context) error {
+			var errs []string
+			ids := make(map[string]struct{}, len(cfg.Projects))
+			for _, p := range cfg.Projects {
+				if p.Id == "" {
+					errs = append(errs, "project id must not be empty")
+					continue
+				}
+				if _, ok := ids[p.Id]; ok {
+					errs = append(errs, fmt.Sprintf("project %s: duplicated id", p.Id))
+				}
+				ids[p.Id] = struct{}{}
+
+				if p.StaticAdmin.Username == "" {
+					errs = append(errs, fmt.Sprintf("project %s: static admin username must not be empty", p.Id))
+				}
+				if _, err := bcrypt.Cost([]byte(p.StaticAdmin.PasswordHash)); err != nil {
+					errs = append(errs, fmt.Sprintf("project %s: invalid static admin password hash (%v)", p.Id, err))
+				}
+			}
+			return joinErrors(errs)
+		},
+	}
+}
+
+// SSOConfigsCheck returns a check to ensure that the shared SSO configs are sane.
+func SSOConfigsCheck(cfg *config.ControlPlaneSpec) Check {
+	return Check{
+		Name: "shared SSO configs are valid",
+		Hint: "every shared SSO config requires a unique name and the client id/secret of the configured provider",
+		Run: func(_ context.Context) error {
+			var errs []string
+			names := make(map[string]struct{}, len(cfg.SharedSSOConfigs))
+			for i := range cfg.SharedSSOConfigs {
+				sso := &cfg.SharedSSOConfigs[i]
+				if sso.Name == "" {
+					errs = append(errs, "shared SSO config name must not be empty")
+					continue
+				}
+				if _, ok := names[sso.Name]; ok {
+					errs = append(errs, fmt.Sprintf("shared SSO config %s: duplicated name", sso.Name))
+				}
+				names[sso.Name] = struct{}{}
+
+				if err := validateSSOConfig(&sso.ProjectSSOConfig); err != nil {
+					errs = append(errs, fmt.Sprintf("shared SSO config %s: %v", sso.Name, err))
+				}
+			}
+			return joinErrors(errs)
+		},
+	}
+}
+
+func validateSSOConfig(sso *model.ProjectSSOConfig) error {
+	switch sso.Provider {
+	case model.ProjectSSOConfig_GITHUB, model.ProjectSSOConfig_GITHUB_ENTERPRISE:
+		if sso.Github == nil {
+			return errors.New("missing github config")
+		}
+		if sso.Github.ClientId == "" || sso.Github.ClientSecret == "" {
+			return errors.New("github clientId and clientSecret must be set")
+		}
+		for _, u := range []string{sso.Github.BaseUrl, sso.Github.UploadUrl, sso.Github.ProxyUrl} {
+			if u == "" {
+				continue
+			}
+			if _, err := url.ParseRequestURI(u); err != nil {
+				return fmt.Errorf("invalid github url %q: %w", u, err)
+			}
+		}
+		return nil
+
+	case model.ProjectSSOConfig_GOOGLE:
+		if sso.Google == nil {
+			return errors.New("missing google config")
+		}
+		if sso.Google.ClientId == "" || sso.Google.ClientSecret == "" {
+			return errors.New("google clientId and clientSecret must be set")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported provider %s", sso.Provider)
+	}
+}
+
+func joinErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
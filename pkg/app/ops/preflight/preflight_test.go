@@ -0,0 +1,224 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/datastore"
+	"github.com/pipe-cd/pipe/pkg/filestore"
+	"github.com/pipe-cd/pipe/pkg/filestore/filestoretest"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestRun(t *testing.T) {
+	report := Run(context.Background(), time.Second,
+		Check{
+			Name: "ok",
+			Run:  func(_ context.Context) error { return nil },
+		},
+		FailedCheck("failed", "fix it", errors.New("broken")),
+	)
+	require.Equal(t, 2, len(report))
+	assert.True(t, report.Failed())
+
+	var buf bytes.Buffer
+	report.Write(&buf)
+	expected := "[OK]   ok\n" +
+		"[FAIL] failed: broken\n" +
+		"       hint: fix it\n" +
+		"\n2 checks, 1 failed\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestProjectsCheck(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name     string
+		projects []config.ControlPlaneProject
+		wantErr  bool
+	}{
+		{
+			name: "valid",
+			projects: []config.ControlPlaneProject{
+				{
+					Id:          "quickstart",
+					StaticAdmin: config.ProjectStaticUser{Username: "hello-pipecd", PasswordHash: string(hash)},
+				},
+			},
+		},
+		{
+			name: "plain password",
+			projects: []config.ControlPlaneProject{
+				{
+					Id:          "quickstart",
+					StaticAdmin: config.ProjectStaticUser{Username: "hello-pipecd", PasswordHash: "password"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated id",
+			projects: []config.ControlPlaneProject{
+				{
+					Id:          "quickstart",
+					StaticAdmin: config.ProjectStaticUser{Username: "hello-pipecd", PasswordHash: string(hash)},
+				},
+				{
+					Id:          "quickstart",
+					StaticAdmin: config.ProjectStaticUser{Username: "hello-pipecd", PasswordHash: string(hash)},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := ProjectsCheck(&config.ControlPlaneSpec{Projects: tc.projects})
+			err := check.Run(context.Background())
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestSSOConfigsCheck(t *testing.T) {
+	testcases := []struct {
+		name    string
+		configs []config.SharedSSOConfig
+		wantErr bool
+	}{
+		{
+			name: "valid github",
+			configs: []config.SharedSSOConfig{
+				{
+					Name: "github",
+					ProjectSSOConfig: model.ProjectSSOConfig{
+						Provider: model.ProjectSSOConfig_GITHUB,
+						Github: &model.ProjectSSOConfig_GitHub{
+							ClientId:     "id",
+							ClientSecret: "secret",
+							BaseUrl:      "https://github.example.com",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "missing client secret",
+			configs: []config.SharedSSOConfig{
+				{
+					Name: "google",
+					ProjectSSOConfig: model.ProjectSSOConfig{
+						Provider: model.ProjectSSOConfig_GOOGLE,
+						Google: &model.ProjectSSOConfig_Google{
+							ClientId: "id",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid base url",
+			configs: []config.SharedSSOConfig{
+				{
+					Name: "github",
+					ProjectSSOConfig: model.ProjectSSOConfig{
+						Provider: model.ProjectSSOConfig_GITHUB,
+						Github: &model.ProjectSSOConfig_GitHub{
+							ClientId:     "id",
+							ClientSecret: "secret",
+							BaseUrl:      "github.example.com",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing name",
+			configs: []config.SharedSSOConfig{
+				{
+					ProjectSSOConfig: model.ProjectSSOConfig{
+						Provider: model.ProjectSSOConfig_GOOGLE,
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := SSOConfigsCheck(&config.ControlPlaneSpec{SharedSSOConfigs: tc.configs})
+			err := check.Run(context.Background())
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestDatastoreCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	it := datastore.NewMockIterator(ctrl)
+	it.EXPECT().Next(gomock.Any()).Return(datastore.ErrIteratorDone)
+	ds := datastore.NewMockDataStore(ctrl)
+	ds.EXPECT().Find(gomock.Any(), datastore.ProjectModelKind, gomock.Any()).Return(it, nil)
+
+	err := DatastoreCheck(ds).Run(context.Background())
+	assert.NoError(t, err)
+
+	ds.EXPECT().Find(gomock.Any(), datastore.ProjectModelKind, gomock.Any()).Return(nil, errors.New("permission denied"))
+	err = DatastoreCheck(ds).Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFilestoreCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var written []byte
+	fs := filestoretest.NewMockStore(ctrl)
+	fs.EXPECT().PutObject(gomock.Any(), filestoreCheckPath, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, content []byte) error {
+			written = content
+			return nil
+		},
+	)
+	fs.EXPECT().GetObject(gomock.Any(), filestoreCheckPath).DoAndReturn(
+		func(_ context.Context, path string) (filestore.Object, error) {
+			return filestore.Object{Path: path, Content: written}, nil
+		},
+	)
+
+	err := FilestoreCheck(fs).Run(context.Background())
+	assert.NoError(t, err)
+
+	fs.EXPECT().PutObject(gomock.Any(), filestoreCheckPath, gomock.Any()).Return(errors.New("access denied"))
+	err = FilestoreCheck(fs).Run(context.Background())
+	assert.Error(t, err)
+}
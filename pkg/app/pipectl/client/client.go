@@ -16,17 +16,13 @@ package client
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
-	"time"
 
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc/credentials"
 
+	"github.com/pipe-cd/pipe/pkg/apiclient"
 	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
 	"github.com/pipe-cd/pipe/pkg/model"
-	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
-	"github.com/pipe-cd/pipe/pkg/rpc/rpcclient"
 )
 
 type Options struct {
@@ -60,43 +56,15 @@ func (o *Options) NewClient(ctx context.Context) (apiservice.Client, error) {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	var creds credentials.PerRPCCredentials
-	var err error
-
-	if o.APIKey != "" {
-		creds = rpcclient.NewPerRPCCredentials(o.APIKey, rpcauth.APIKeyCredentials, !o.Insecure)
-	} else {
-		creds, err = rpcclient.NewPerRPCCredentialsFromFile(o.APIKeyFile, rpcauth.APIKeyCredentials, !o.Insecure)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	options := []rpcclient.DialOption{
-		rpcclient.WithBlock(),
-		rpcclient.WithPerRPCCredentials(creds),
-	}
-
-	if !o.Insecure {
-		if o.CertFile != "" {
-			options = append(options, rpcclient.WithTLS(o.CertFile))
-		} else {
-			config := &tls.Config{}
-			options = append(options, rpcclient.WithTransportCredentials(credentials.NewTLS(config)))
-		}
-	} else {
-		options = append(options, rpcclient.WithInsecure())
+	options := []apiclient.Option{
+		apiclient.WithAPIKey(o.APIKey),
+		apiclient.WithAPIKeyFile(o.APIKeyFile),
+		apiclient.WithCertFile(o.CertFile),
 	}
-
-	client, err := apiservice.NewClient(ctx, o.Address, options...)
-	if err != nil {
-		return nil, err
+	if o.Insecure {
+		options = append(options, apiclient.WithInsecure())
 	}
-
-	return client, nil
+	return apiclient.New(ctx, o.Address, options...)
 }
 
 func getCommand(ctx context.Context, cli apiservice.Client, cmdID string) (*model.Command, error) {
@@ -41,8 +41,7 @@ type add struct {
 
 func newAddCommand(root *command) *cobra.Command {
 	c := &add{
-		root:           root,
-		configFileName: model.DefaultDeploymentConfigFileName,
+		root: root,
 	}
 	cmd := &cobra.Command{
 		Use:   "add",
@@ -58,7 +57,7 @@ func newAddCommand(root *command) *cobra.Command {
 
 	cmd.Flags().StringVar(&c.repoID, "repo-id", c.repoID, "The repository ID. One the registered repositories in the piped configuration.")
 	cmd.Flags().StringVar(&c.appDir, "app-dir", c.appDir, "The relative path from the root of repository to the application directory.")
-	cmd.Flags().StringVar(&c.configFileName, "config-file-name", c.configFileName, "The configuration file name. Default is .pipe.yaml")
+	cmd.Flags().StringVar(&c.configFileName, "config-file-name", c.configFileName, "The configuration file name. Default is the one configured for the application directory in piped, or .pipe.yaml if nothing was configured")
 
 	cmd.MarkFlagRequired("app-name")
 	cmd.MarkFlagRequired("app-kind")
@@ -38,6 +38,8 @@ func NewCommand() *cobra.Command {
 		newSyncCommand(c),
 		newGetCommand(c),
 		newListCommand(c),
+		newEnableCommand(c),
+		newDisableCommand(c),
 	)
 
 	c.clientOptions.RegisterPersistentFlags(cmd)
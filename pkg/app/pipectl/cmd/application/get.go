@@ -16,14 +16,15 @@ package application
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/printer"
 	"github.com/pipe-cd/pipe/pkg/cli"
 )
 
@@ -31,12 +32,14 @@ type get struct {
 	root *command
 
 	appID  string
+	output printer.Format
 	stdout io.Writer
 }
 
 func newGetCommand(root *command) *cobra.Command {
 	c := &get{
 		root:   root,
+		output: printer.FormatJSON,
 		stdout: os.Stdout,
 	}
 	cmd := &cobra.Command{
@@ -46,6 +49,7 @@ func newGetCommand(root *command) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&c.appID, "app-id", c.appID, "The application ID.")
+	cmd.Flags().VarP(&c.output, "output", "o", fmt.Sprintf("The output format. (%s)", strings.Join(printer.FormatStrings(), "|")))
 	cmd.MarkFlagRequired("app-id")
 
 	return cmd
@@ -67,11 +71,5 @@ func (c *get) run(ctx context.Context, _ cli.Telemetry) error {
 		return fmt.Errorf("failed to get application: %w", err)
 	}
 
-	bytes, err := json.Marshal(resp.Application)
-	if err != nil {
-		return fmt.Errorf("failed to marshal application: %w", err)
-	}
-
-	fmt.Fprintln(c.stdout, string(bytes))
-	return nil
+	return printer.Print(c.stdout, c.output, resp.Application, printer.ApplicationTable(resp.Application))
 }
@@ -16,7 +16,6 @@ package application
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -25,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/printer"
 	"github.com/pipe-cd/pipe/pkg/cli"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
@@ -38,12 +38,14 @@ type list struct {
 	appKind  string
 	disabled bool
 	cursor   string
+	output   printer.Format
 	stdout   io.Writer
 }
 
 func newListCommand(root *command) *cobra.Command {
 	c := &list{
 		root:   root,
+		output: printer.FormatJSON,
 		stdout: os.Stdout,
 	}
 	cmd := &cobra.Command{
@@ -58,6 +60,7 @@ func newListCommand(root *command) *cobra.Command {
 	cmd.Flags().StringVar(&c.appKind, "app-kind", c.appKind, fmt.Sprintf("The kind of application. (%s)", strings.Join(model.ApplicationKindStrings(), "|")))
 	cmd.Flags().BoolVar(&c.disabled, "disabled", c.disabled, "True to show only disabled applications.")
 	cmd.Flags().StringVar(&c.cursor, "cursor", c.cursor, "The cursor which returned by the previous request applications list.")
+	cmd.Flags().VarP(&c.output, "output", "o", fmt.Sprintf("The output format. (%s)", strings.Join(printer.FormatStrings(), "|")))
 
 	return cmd
 }
@@ -89,11 +92,5 @@ func (c *list) run(ctx context.Context, _ cli.Telemetry) error {
 		return fmt.Errorf("failed to list application: %w", err)
 	}
 
-	bytes, err := json.Marshal(resp)
-	if err != nil {
-		return fmt.Errorf("failed to marshal applications: %w", err)
-	}
-
-	fmt.Fprintln(c.stdout, string(bytes))
-	return nil
+	return printer.Print(c.stdout, c.output, resp, printer.ApplicationTable(resp.Applications...))
 }
@@ -0,0 +1,74 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/cli"
+)
+
+type addNote struct {
+	root *command
+
+	deploymentID string
+	text         string
+	url          string
+}
+
+func newAddNoteCommand(root *command) *cobra.Command {
+	c := &addNote{
+		root: root,
+	}
+	cmd := &cobra.Command{
+		Use:   "add-note",
+		Short: "Attach a note such as an incident ticket or a change record to a deployment.",
+		RunE:  cli.WithContext(c.run),
+	}
+
+	cmd.Flags().StringVar(&c.deploymentID, "deployment-id", c.deploymentID, "The deployment ID.")
+	cmd.Flags().StringVar(&c.text, "text", c.text, "The text of the note.")
+	cmd.Flags().StringVar(&c.url, "url", c.url, "The optional link related to the note.")
+
+	cmd.MarkFlagRequired("deployment-id")
+	cmd.MarkFlagRequired("text")
+
+	return cmd
+}
+
+func (c *addNote) run(ctx context.Context, t cli.Telemetry) error {
+	cli, err := c.root.clientOptions.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+	defer cli.Close()
+
+	req := &apiservice.AddDeploymentNoteRequest{
+		DeploymentId: c.deploymentID,
+		Text:         c.text,
+		Url:          c.url,
+	}
+
+	if _, err := cli.AddDeploymentNote(ctx, req); err != nil {
+		return fmt.Errorf("failed to add note to deployment: %w", err)
+	}
+
+	t.Logger.Info(fmt.Sprintf("Successfully added a note to deployment %s", c.deploymentID))
+	return nil
+}
@@ -33,7 +33,12 @@ func NewCommand() *cobra.Command {
 		Short: "Manage deployment resources.",
 	}
 
-	cmd.AddCommand(newWaitStatusCommand(c))
+	cmd.AddCommand(
+		newAddNoteCommand(c),
+		newGetCommand(c),
+		newListCommand(c),
+		newWaitStatusCommand(c),
+	)
 
 	c.clientOptions.RegisterPersistentFlags(cmd)
 
@@ -0,0 +1,75 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/printer"
+	"github.com/pipe-cd/pipe/pkg/cli"
+)
+
+type get struct {
+	root *command
+
+	deploymentID string
+	output       printer.Format
+	stdout       io.Writer
+}
+
+func newGetCommand(root *command) *cobra.Command {
+	c := &get{
+		root:   root,
+		output: printer.FormatJSON,
+		stdout: os.Stdout,
+	}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show the information about the specified deployment.",
+		RunE:  cli.WithContext(c.run),
+	}
+
+	cmd.Flags().StringVar(&c.deploymentID, "deployment-id", c.deploymentID, "The deployment ID.")
+	cmd.Flags().VarP(&c.output, "output", "o", fmt.Sprintf("The output format. (%s)", strings.Join(printer.FormatStrings(), "|")))
+	cmd.MarkFlagRequired("deployment-id")
+
+	return cmd
+}
+
+func (c *get) run(ctx context.Context, _ cli.Telemetry) error {
+	cli, err := c.root.clientOptions.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+	defer cli.Close()
+
+	req := &apiservice.GetDeploymentRequest{
+		DeploymentId: c.deploymentID,
+	}
+
+	resp, err := cli.GetDeployment(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	return printer.Print(c.stdout, c.output, resp.Deployment, printer.DeploymentTable(resp.Deployment))
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/printer"
+	"github.com/pipe-cd/pipe/pkg/cli"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type list struct {
+	root *command
+
+	appID   string
+	envID   string
+	appKind string
+	status  string
+	cursor  string
+	output  printer.Format
+	stdout  io.Writer
+}
+
+func newListCommand(root *command) *cobra.Command {
+	c := &list{
+		root:   root,
+		output: printer.FormatJSON,
+		stdout: os.Stdout,
+	}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Show the list of deployments. Currently, the maximum number of returned deployments is 10.",
+		RunE:  cli.WithContext(c.run),
+	}
+
+	cmd.Flags().StringVar(&c.appID, "app-id", c.appID, "The application ID.")
+	cmd.Flags().StringVar(&c.envID, "env-id", c.envID, "The environment ID.")
+	cmd.Flags().StringVar(&c.appKind, "app-kind", c.appKind, fmt.Sprintf("The kind of application. (%s)", strings.Join(model.ApplicationKindStrings(), "|")))
+	cmd.Flags().StringVar(&c.status, "status", c.status, fmt.Sprintf("The deployment status. (%s)", strings.Join(model.DeploymentStatusStrings(), "|")))
+	cmd.Flags().StringVar(&c.cursor, "cursor", c.cursor, "The cursor which returned by the previous request deployments list.")
+	cmd.Flags().VarP(&c.output, "output", "o", fmt.Sprintf("The output format. (%s)", strings.Join(printer.FormatStrings(), "|")))
+
+	return cmd
+}
+
+func (c *list) run(ctx context.Context, _ cli.Telemetry) error {
+	if c.appKind != "" {
+		if _, ok := model.ApplicationKind_value[c.appKind]; !ok {
+			return fmt.Errorf("invalid application kind")
+		}
+	}
+	if c.status != "" {
+		if _, ok := model.DeploymentStatus_value[c.status]; !ok {
+			return fmt.Errorf("invalid deployment status")
+		}
+	}
+
+	cli, err := c.root.clientOptions.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+	defer cli.Close()
+
+	req := &apiservice.ListDeploymentsRequest{
+		ApplicationId: c.appID,
+		EnvId:         c.envID,
+		Kind:          c.appKind,
+		Status:        c.status,
+		Cursor:        c.cursor,
+	}
+
+	resp, err := cli.ListDeployments(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to list deployment: %w", err)
+	}
+
+	return printer.Print(c.stdout, c.output, resp, printer.DeploymentTable(resp.Deployments...))
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypt
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/spf13/cobra"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/client"
+	"github.com/pipe-cd/pipe/pkg/cli"
+	"github.com/pipe-cd/pipe/pkg/crypto/gcpkms"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
+)
+
+type command struct {
+	clientOptions *client.Options
+
+	pipedID           string
+	gcpKMSKeyName     string
+	gcpCredentials    string
+	inputFile         string
+	inputDir          string
+	outDir            string
+	appDir            string
+	useBase64Encoding bool
+
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func NewCommand() *cobra.Command {
+	c := &command{
+		clientOptions: &client.Options{},
+		appDir:        ".",
+		stdin:         os.Stdin,
+		stdout:        os.Stdout,
+	}
+	cmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt secret data to be stored as SealedSecret files.",
+		Long: "Encrypt secret data by using the public key of the specified piped.\n" +
+			"When --gcp-kms-key-name is specified instead of --piped-id, the data is encrypted locally by that Cloud KMS key.\n" +
+			"The data is read from the input file or stdin, and the SealedSecret file is printed to stdout.\n" +
+			"When --input-dir is specified, all files under that directory are encrypted into --out-dir preserving the directory structure, " +
+			"and the sealedSecrets field to be added to the application configuration is printed to stdout.",
+		RunE: cli.WithContext(c.run),
+	}
+
+	cmd.Flags().StringVar(&c.pipedID, "piped-id", c.pipedID, "The ID of piped which will decrypt the secret.")
+	cmd.Flags().StringVar(&c.gcpKMSKeyName, "gcp-kms-key-name", c.gcpKMSKeyName, "The resource name of Cloud KMS key configured in the piped using GCP_KMS sealed secret management.")
+	cmd.Flags().StringVar(&c.gcpCredentials, "gcp-credentials-file", c.gcpCredentials, "The path to the service account file used to encrypt by Cloud KMS. Empty means the application default credentials are used.")
+	cmd.Flags().StringVar(&c.inputFile, "input-file", c.inputFile, "The path to the file to be encrypted. Read from stdin if this is not specified or \"-\".")
+	cmd.Flags().StringVar(&c.inputDir, "input-dir", c.inputDir, "The path to the directory containing the files to be encrypted.")
+	cmd.Flags().StringVar(&c.outDir, "out-dir", c.outDir, "The path to the directory where to write the SealedSecret files. Required when --input-dir is specified.")
+	cmd.Flags().StringVar(&c.appDir, "app-dir", c.appDir, "The path to the application directory used to make the paths in the printed sealedSecrets field relative.")
+	cmd.Flags().BoolVar(&c.useBase64Encoding, "use-base64-encoding", c.useBase64Encoding, "Whether the data should be base64 encoded before encrypting or not.")
+	c.clientOptions.RegisterPersistentFlags(cmd)
+
+	return cmd
+}
+
+func (c *command) run(ctx context.Context, t cli.Telemetry) error {
+	if c.inputDir != "" && c.inputFile != "" {
+		return errors.New("only one of input-file and input-dir can be specified")
+	}
+	if c.inputDir != "" && c.outDir == "" {
+		return errors.New("out-dir must be specified along with input-dir")
+	}
+	if (c.pipedID == "") == (c.gcpKMSKeyName == "") {
+		return errors.New("exactly one of piped-id and gcp-kms-key-name must be specified")
+	}
+
+	var encrypt encryptFunc
+	if c.gcpKMSKeyName != "" {
+		e, closer, err := c.newGCPKMSEncrypter(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		encrypt = e
+	} else {
+		cli, err := c.clientOptions.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+		defer cli.Close()
+
+		encrypt = func(ctx context.Context, data []byte) (string, error) {
+			resp, err := cli.EncryptSecret(ctx, &apiservice.EncryptSecretRequest{
+				PipedId:        c.pipedID,
+				Data:           string(data),
+				Base64Encoding: c.useBase64Encoding,
+			})
+			if err != nil {
+				return "", err
+			}
+			return resp.Ciphertext, nil
+		}
+	}
+
+	if c.inputDir != "" {
+		mappings, err := encryptDir(ctx, encrypt, c.inputDir, c.outDir, c.appDir)
+		if err != nil {
+			return err
+		}
+		snippet, err := renderMappings(mappings)
+		if err != nil {
+			return err
+		}
+		t.Logger.Info(fmt.Sprintf("Successfully encrypted %d files into %s", len(mappings), c.outDir))
+		_, err = c.stdout.Write(snippet)
+		return err
+	}
+
+	data, err := c.readInput()
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	ciphertext, err := encrypt(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	secret, err := renderSealedSecret(ciphertext)
+	if err != nil {
+		return err
+	}
+	_, err = c.stdout.Write(secret)
+	return err
+}
+
+// newGCPKMSEncrypter returns the function to encrypt data locally by the specified Cloud KMS key.
+func (c *command) newGCPKMSEncrypter(ctx context.Context) (encryptFunc, func() error, error) {
+	options, err := gcpcredentials.ClientOptions(ctx, gcpcredentials.Options{
+		CredentialsFile: c.gcpCredentials,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find gcp credentials: %w", err)
+	}
+	client, err := kms.NewKeyManagementClient(ctx, options...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create gcp kms client: %w", err)
+	}
+	e, err := gcpkms.NewEncrypter(client, c.gcpKMSKeyName)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+
+	encrypt := func(ctx context.Context, data []byte) (string, error) {
+		text := string(data)
+		if c.useBase64Encoding {
+			text = base64.StdEncoding.EncodeToString(data)
+		}
+		return e.Encrypt(ctx, text)
+	}
+	return encrypt, client.Close, nil
+}
+
+func (c *command) readInput() ([]byte, error) {
+	if c.inputFile == "" || c.inputFile == "-" {
+		return ioutil.ReadAll(c.stdin)
+	}
+	return ioutil.ReadFile(c.inputFile)
+}
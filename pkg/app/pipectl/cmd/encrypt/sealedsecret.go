@@ -0,0 +1,117 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypt
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+const sealedSecretFileSuffix = ".sealed.yaml"
+
+type encryptFunc func(ctx context.Context, data []byte) (string, error)
+
+// renderSealedSecret returns the content of a SealedSecret file containing the given encrypted data.
+func renderSealedSecret(ciphertext string) ([]byte, error) {
+	return yaml.Marshal(map[string]interface{}{
+		"apiVersion": "pipecd.dev/v1beta1",
+		"kind":       string(config.KindSealedSecret),
+		"spec": map[string]string{
+			"encryptedData": ciphertext,
+		},
+	})
+}
+
+// renderMappings returns the sealedSecrets field of the application configuration.
+func renderMappings(mappings []config.SealedSecretMapping) ([]byte, error) {
+	return yaml.Marshal(map[string]interface{}{
+		"sealedSecrets": mappings,
+	})
+}
+
+// encryptDir encrypts all files under the input directory and writes them as SealedSecret files
+// into the output directory with the same structure.
+// The returned mappings restore every file at its original location relative to the application directory.
+func encryptDir(ctx context.Context, encrypt encryptFunc, inputDir, outDir, appDir string) ([]config.SealedSecretMapping, error) {
+	absOutDir, err := filepath.Abs(outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []config.SealedSecretMapping
+	err = filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip the output directory in case it is placed inside the input directory.
+		if abs, err := filepath.Abs(path); err == nil && info.IsDir() && abs == absOutDir {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(inputDir, path)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		ciphertext, err := encrypt(ctx, data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", path, err)
+		}
+		secret, err := renderSealedSecret(ciphertext)
+		if err != nil {
+			return err
+		}
+
+		secretPath := filepath.Join(outDir, rel+sealedSecretFileSuffix)
+		if err := os.MkdirAll(filepath.Dir(secretPath), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(secretPath, secret, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", secretPath, err)
+		}
+
+		relSecretPath, err := filepath.Rel(appDir, secretPath)
+		if err != nil {
+			return err
+		}
+		relOriginalDir, err := filepath.Rel(appDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		mappings = append(mappings, config.SealedSecretMapping{
+			Path:        filepath.ToSlash(relSecretPath),
+			OutFilename: info.Name(),
+			OutDir:      filepath.ToSlash(relOriginalDir),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypt
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func fakeEncrypt(_ context.Context, data []byte) (string, error) {
+	return "encrypted-" + string(data), nil
+}
+
+func TestRenderSealedSecret(t *testing.T) {
+	data, err := renderSealedSecret("encrypted-data")
+	require.NoError(t, err)
+
+	cfg, err := config.DecodeYAML(data)
+	require.NoError(t, err)
+	require.Equal(t, config.KindSealedSecret, cfg.Kind)
+	assert.Equal(t, "encrypted-data", cfg.SealedSecretSpec.EncryptedData)
+}
+
+func TestEncryptDir(t *testing.T) {
+	appDir, err := ioutil.TempDir("", "encrypt")
+	require.NoError(t, err)
+	defer os.RemoveAll(appDir)
+
+	inputDir := filepath.Join(appDir, "secrets")
+	require.NoError(t, os.MkdirAll(filepath.Join(inputDir, "db"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(inputDir, "token"), []byte("token"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(inputDir, "db", "password"), []byte("password"), 0644))
+
+	// The output directory is placed inside the input directory to ensure it is skipped.
+	outDir := filepath.Join(inputDir, "sealed")
+	require.NoError(t, os.MkdirAll(outDir, 0755))
+
+	mappings, err := encryptDir(context.Background(), fakeEncrypt, inputDir, outDir, appDir)
+	require.NoError(t, err)
+
+	expected := []config.SealedSecretMapping{
+		{
+			Path:        "secrets/sealed/db/password.sealed.yaml",
+			OutFilename: "password",
+			OutDir:      "secrets/db",
+		},
+		{
+			Path:        "secrets/sealed/token.sealed.yaml",
+			OutFilename: "token",
+			OutDir:      "secrets",
+		},
+	}
+	assert.Equal(t, expected, mappings)
+
+	cfg, err := config.LoadFromYAML(filepath.Join(outDir, "db", "password.sealed.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "encrypted-password", cfg.SealedSecretSpec.EncryptedData)
+
+	snippet, err := renderMappings(mappings)
+	require.NoError(t, err)
+	expectedSnippet := `sealedSecrets:
+- outDir: secrets/db
+  outFilename: password
+  path: secrets/sealed/db/password.sealed.yaml
+- outDir: secrets
+  outFilename: token
+  path: secrets/sealed/token.sealed.yaml
+`
+	assert.Equal(t, expectedSnippet, string(snippet))
+}
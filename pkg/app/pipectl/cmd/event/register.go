@@ -27,9 +27,10 @@ import (
 type register struct {
 	root *command
 
-	name   string
-	data   string
-	labels map[string]string
+	name     string
+	data     string
+	labels   map[string]string
+	contexts map[string]string
 }
 
 func newRegisterCommand(root *command) *cobra.Command {
@@ -45,6 +46,7 @@ func newRegisterCommand(root *command) *cobra.Command {
 	cmd.Flags().StringVar(&r.name, "name", r.name, "The name of event.")
 	cmd.Flags().StringVar(&r.data, "data", r.data, "The string value of event data.")
 	cmd.Flags().StringToStringVar(&r.labels, "labels", r.labels, "The list of labels for event. Format: key=value,key2=value2")
+	cmd.Flags().StringToStringVar(&r.contexts, "contexts", r.contexts, "The list of extra values attached to the event. Unlike labels, they are not used to match events but are added to the commit message. Format: key=value,key2=value2")
 
 	cmd.MarkFlagRequired("name")
 	cmd.MarkFlagRequired("data")
@@ -60,9 +62,10 @@ func (r *register) run(ctx context.Context, t cli.Telemetry) error {
 	defer cli.Close()
 
 	req := &apiservice.RegisterEventRequest{
-		Name:   r.name,
-		Data:   r.data,
-		Labels: r.labels,
+		Name:     r.name,
+		Data:     r.data,
+		Labels:   r.labels,
+		Contexts: r.contexts,
 	}
 
 	if _, err := cli.RegisterEvent(ctx, req); err != nil {
@@ -0,0 +1,134 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piped
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pipe-cd/pipe/pkg/cli"
+	"github.com/pipe-cd/pipe/pkg/version"
+)
+
+type generate struct {
+	root *command
+
+	format       string
+	projectID    string
+	pipedID      string
+	pipedKeyFile string
+	apiAddress   string
+	webAddress   string
+	insecure     bool
+	version      string
+	namespace    string
+	repoID       string
+	repoRemote   string
+	repoBranch   string
+	outDir       string
+	stdout       io.Writer
+}
+
+func newGenerateCommand(root *command) *cobra.Command {
+	c := &generate{
+		root:       root,
+		format:     generateFormatKubernetes,
+		version:    version.Get().Version,
+		namespace:  "pipecd",
+		repoBranch: "master",
+		stdout:     os.Stdout,
+	}
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate the manifests for running a registered piped.",
+		RunE:  cli.WithContext(c.run),
+	}
+
+	cmd.Flags().StringVar(&c.format, "format", c.format, fmt.Sprintf("The format of generated manifests. (%s)", strings.Join(generateFormats, "|")))
+	cmd.Flags().StringVar(&c.projectID, "project-id", c.projectID, "The ID of the project where the piped belongs to.")
+	cmd.Flags().StringVar(&c.pipedID, "piped-id", c.pipedID, "The ID of the registered piped.")
+	cmd.Flags().StringVar(&c.pipedKeyFile, "piped-key-file", c.pipedKeyFile, "The path to the file containing the generated key of the piped.")
+	cmd.Flags().StringVar(&c.apiAddress, "api-address", c.apiAddress, "The address to control-plane api used by the piped.")
+	cmd.Flags().StringVar(&c.webAddress, "web-address", c.webAddress, "The address to control-plane web.")
+	cmd.Flags().BoolVar(&c.insecure, "piped-insecure", c.insecure, "Whether the piped disables transport security while connecting to control-plane.")
+	cmd.Flags().StringVar(&c.version, "version", c.version, "The version of piped image.")
+	cmd.Flags().StringVar(&c.namespace, "namespace", c.namespace, "The Kubernetes namespace where the piped will be installed.")
+	cmd.Flags().StringVar(&c.repoID, "repo-id", c.repoID, "The ID of the Git repository watched by the piped. Optional.")
+	cmd.Flags().StringVar(&c.repoRemote, "repo-remote", c.repoRemote, "The remote URL of the Git repository watched by the piped. Optional.")
+	cmd.Flags().StringVar(&c.repoBranch, "repo-branch", c.repoBranch, "The branch of the Git repository watched by the piped.")
+	cmd.Flags().StringVar(&c.outDir, "out-dir", c.outDir, "The directory to write the generated files. The Kubernetes manifests are printed to stdout when this is not specified.")
+
+	cmd.MarkFlagRequired("project-id")
+	cmd.MarkFlagRequired("piped-id")
+	cmd.MarkFlagRequired("piped-key-file")
+	cmd.MarkFlagRequired("api-address")
+	cmd.MarkFlagRequired("web-address")
+
+	return cmd
+}
+
+func (c *generate) run(ctx context.Context, t cli.Telemetry) error {
+	if c.version == "" || c.version == "unspecified" {
+		return errors.New("version must be specified")
+	}
+	if c.repoRemote != "" && c.repoID == "" {
+		return errors.New("repo-id must be specified along with repo-remote")
+	}
+	if c.format != generateFormatKubernetes && c.outDir == "" {
+		return fmt.Errorf("out-dir must be specified for %s format", c.format)
+	}
+
+	key, err := ioutil.ReadFile(c.pipedKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read piped key file: %w", err)
+	}
+
+	files, err := generateFiles(c.format, generateParams{
+		ProjectID:  c.projectID,
+		PipedID:    c.pipedID,
+		PipedKey:   strings.TrimSpace(string(key)),
+		APIAddress: c.apiAddress,
+		WebAddress: c.webAddress,
+		Insecure:   c.insecure,
+		Version:    c.version,
+		Namespace:  c.namespace,
+		RepoID:     c.repoID,
+		RepoRemote: c.repoRemote,
+		RepoBranch: c.repoBranch,
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.outDir == "" {
+		for _, data := range files {
+			fmt.Fprint(c.stdout, string(data))
+		}
+		return nil
+	}
+
+	if err := writeFiles(c.outDir, files); err != nil {
+		return fmt.Errorf("failed to write generated files: %w", err)
+	}
+	t.Logger.Info(fmt.Sprintf("Successfully generated %d files into %s", len(files), c.outDir))
+	return nil
+}
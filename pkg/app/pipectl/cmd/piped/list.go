@@ -0,0 +1,74 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piped
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/printer"
+	"github.com/pipe-cd/pipe/pkg/cli"
+)
+
+type list struct {
+	root *command
+
+	disabled bool
+	output   printer.Format
+	stdout   io.Writer
+}
+
+func newListCommand(root *command) *cobra.Command {
+	c := &list{
+		root:   root,
+		output: printer.FormatJSON,
+		stdout: os.Stdout,
+	}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Show the list of pipeds registered in the project.",
+		RunE:  cli.WithContext(c.run),
+	}
+
+	cmd.Flags().BoolVar(&c.disabled, "disabled", c.disabled, "True to show only disabled pipeds.")
+	cmd.Flags().VarP(&c.output, "output", "o", fmt.Sprintf("The output format. (%s)", strings.Join(printer.FormatStrings(), "|")))
+
+	return cmd
+}
+
+func (c *list) run(ctx context.Context, _ cli.Telemetry) error {
+	cli, err := c.root.clientOptions.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+	defer cli.Close()
+
+	req := &apiservice.ListPipedsRequest{
+		Disabled: c.disabled,
+	}
+
+	resp, err := cli.ListPipeds(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to list piped: %w", err)
+	}
+
+	return printer.Print(c.stdout, c.output, resp, printer.PipedTable(resp.Pipeds...))
+}
@@ -0,0 +1,283 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piped
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	generateFormatKubernetes    = "kubernetes"
+	generateFormatDockerCompose = "docker-compose"
+
+	pipedImageRepository = "gcr.io/pipecd/piped"
+	pipedConfigFileName  = "piped-config.yaml"
+	pipedKeyFileName     = "piped-key"
+)
+
+var generateFormats = []string{
+	generateFormatKubernetes,
+	generateFormatDockerCompose,
+}
+
+// generateParams contains the values used to render the manifests for running a piped.
+type generateParams struct {
+	ProjectID  string
+	PipedID    string
+	PipedKey   string
+	APIAddress string
+	WebAddress string
+	Insecure   bool
+	Version    string
+	Namespace  string
+	RepoID     string
+	RepoRemote string
+	RepoBranch string
+}
+
+func (p generateParams) Image() string {
+	return fmt.Sprintf("%s:%s", pipedImageRepository, p.Version)
+}
+
+func (p generateParams) EncodedPipedKey() string {
+	return base64.StdEncoding.EncodeToString([]byte(p.PipedKey))
+}
+
+var templateFuncs = template.FuncMap{
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.ReplaceAll(strings.TrimSuffix(s, "\n"), "\n", "\n"+pad)
+	},
+}
+
+const pipedConfigTemplate = `apiVersion: pipecd.dev/v1beta1
+kind: Piped
+spec:
+  projectID: {{ .ProjectID }}
+  pipedID: {{ .PipedID }}
+  pipedKeyFile: /etc/piped-secret/piped-key
+  apiAddress: {{ .APIAddress }}
+  webAddress: {{ .WebAddress }}
+  syncInterval: 1m
+{{- if .RepoRemote }}
+  repositories:
+    - repoId: {{ .RepoID }}
+      remote: {{ .RepoRemote }}
+      branch: {{ .RepoBranch }}
+{{- end }}
+`
+
+const kubernetesTemplate = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: piped
+  namespace: {{ .Params.Namespace }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: piped
+rules:
+- apiGroups:
+  - '*'
+  resources:
+  - '*'
+  verbs:
+  - '*'
+- nonResourceURLs:
+  - '*'
+  verbs:
+  - '*'
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: piped
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: piped
+subjects:
+- kind: ServiceAccount
+  name: piped
+  namespace: {{ .Params.Namespace }}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: piped
+  namespace: {{ .Params.Namespace }}
+type: Opaque
+data:
+  piped-key: {{ .Params.EncodedPipedKey }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: piped
+  namespace: {{ .Params.Namespace }}
+data:
+  piped-config.yaml: |
+{{ indent 4 .Config }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: piped
+  namespace: {{ .Params.Namespace }}
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app: piped
+  template:
+    metadata:
+      labels:
+        app: piped
+      annotations:
+        sidecar.istio.io/inject: "false"
+    spec:
+      serviceAccountName: piped
+      containers:
+        - name: piped
+          image: {{ .Params.Image }}
+          imagePullPolicy: IfNotPresent
+          args:
+          - piped
+          - --config-file=/etc/piped-config/piped-config.yaml
+          - --enable-default-kubernetes-cloud-provider=true
+          - --insecure={{ .Params.Insecure }}
+          ports:
+            - name: admin
+              containerPort: 9085
+              protocol: TCP
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: admin
+          readinessProbe:
+            httpGet:
+              path: /healthz
+              port: admin
+          volumeMounts:
+            - name: piped-secret
+              mountPath: /etc/piped-secret
+              readOnly: true
+            - name: piped-config
+              mountPath: /etc/piped-config
+              readOnly: true
+      volumes:
+        - name: piped-secret
+          secret:
+            secretName: piped
+            defaultMode: 0400
+        - name: piped-config
+          configMap:
+            name: piped
+`
+
+const dockerComposeTemplate = `version: "3"
+services:
+  piped:
+    image: {{ .Params.Image }}
+    restart: always
+    command:
+    - piped
+    - --config-file=/etc/piped-config/piped-config.yaml
+    - --insecure={{ .Params.Insecure }}
+    volumes:
+    - ./piped-config.yaml:/etc/piped-config/piped-config.yaml:ro
+    - ./piped-key:/etc/piped-secret/piped-key:ro
+`
+
+// generateFiles renders the files needed to run a piped in the given format.
+// The returned map is keyed by the file name.
+func generateFiles(format string, p generateParams) (map[string][]byte, error) {
+	cfg, err := render(pipedConfigTemplate, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render piped config: %w", err)
+	}
+	data := struct {
+		Params generateParams
+		Config string
+	}{
+		Params: p,
+		Config: string(cfg),
+	}
+
+	switch format {
+	case generateFormatKubernetes:
+		manifests, err := render(kubernetesTemplate, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render kubernetes manifests: %w", err)
+		}
+		return map[string][]byte{
+			"piped.yaml": manifests,
+		}, nil
+
+	case generateFormatDockerCompose:
+		compose, err := render(dockerComposeTemplate, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render docker-compose file: %w", err)
+		}
+		return map[string][]byte{
+			"docker-compose.yaml": compose,
+			pipedConfigFileName:   cfg,
+			pipedKeyFileName:      []byte(p.PipedKey),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported format %q, must be one of %s", format, strings.Join(generateFormats, "|"))
+	}
+}
+
+func render(text string, data interface{}) ([]byte, error) {
+	t, err := template.New("").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFiles writes the given files into the given directory.
+// The piped key file is written with the restricted permission.
+func writeFiles(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		perm := os.FileMode(0644)
+		if name == pipedKeyFileName {
+			perm = 0600
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
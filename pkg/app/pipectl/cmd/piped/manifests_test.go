@@ -0,0 +1,88 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piped
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestGenerateFiles(t *testing.T) {
+	params := generateParams{
+		ProjectID:  "project-1",
+		PipedID:    "piped-1",
+		PipedKey:   "piped-key",
+		APIAddress: "pipecd.example.com:443",
+		WebAddress: "https://pipecd.example.com",
+		Version:    "v0.9.0",
+		Namespace:  "pipecd",
+		RepoID:     "examples",
+		RepoRemote: "git@github.com:pipe-cd/examples.git",
+		RepoBranch: "master",
+	}
+
+	t.Run("kubernetes", func(t *testing.T) {
+		files, err := generateFiles(generateFormatKubernetes, params)
+		require.NoError(t, err)
+		require.Contains(t, files, "piped.yaml")
+
+		var (
+			kinds       []string
+			pipedConfig string
+		)
+		for _, doc := range strings.Split(string(files["piped.yaml"]), "\n---\n") {
+			var m struct {
+				Kind string            `json:"kind"`
+				Data map[string]string `json:"data"`
+			}
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &m))
+			kinds = append(kinds, m.Kind)
+			if m.Kind == "ConfigMap" {
+				pipedConfig = m.Data[pipedConfigFileName]
+			}
+		}
+		assert.Equal(t, []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Secret", "ConfigMap", "Deployment"}, kinds)
+
+		cfg, err := config.DecodeYAML([]byte(pipedConfig))
+		require.NoError(t, err)
+		require.Equal(t, config.KindPiped, cfg.Kind)
+		assert.Equal(t, "project-1", cfg.PipedSpec.ProjectID)
+		assert.Equal(t, "piped-1", cfg.PipedSpec.PipedID)
+		assert.Equal(t, "git@github.com:pipe-cd/examples.git", cfg.PipedSpec.Repositories[0].Remote)
+	})
+
+	t.Run("docker-compose", func(t *testing.T) {
+		files, err := generateFiles(generateFormatDockerCompose, params)
+		require.NoError(t, err)
+		assert.Equal(t, 3, len(files))
+		assert.Equal(t, "piped-key", string(files[pipedKeyFileName]))
+		assert.Contains(t, string(files["docker-compose.yaml"]), "image: gcr.io/pipecd/piped:v0.9.0")
+
+		cfg, err := config.DecodeYAML(files[pipedConfigFileName])
+		require.NoError(t, err)
+		assert.Equal(t, "pipecd.example.com:443", cfg.PipedSpec.APIAddress)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := generateFiles("terraform", params)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/apiservice"
+	"github.com/pipe-cd/pipe/pkg/app/pipectl/client"
+	"github.com/pipe-cd/pipe/pkg/cli"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type command struct {
+	repoRemoteURL      string
+	headBranch         string
+	headCommit         string
+	baseBranch         string
+	out                string
+	checkInterval      time.Duration
+	timeout            time.Duration
+	pipedHandleTimeout time.Duration
+
+	clientOptions *client.Options
+}
+
+func NewCommand() *cobra.Command {
+	c := &command{
+		clientOptions:      &client.Options{},
+		checkInterval:      10 * time.Second,
+		timeout:            10 * time.Minute,
+		pipedHandleTimeout: 5 * time.Minute,
+	}
+	cmd := &cobra.Command{
+		Use:   "plan-preview",
+		Short: "Show plan preview against the specified commit.",
+		RunE:  cli.WithContext(c.run),
+	}
+
+	c.clientOptions.RegisterPersistentFlags(cmd)
+
+	cmd.Flags().StringVar(&c.repoRemoteURL, "repo", c.repoRemoteURL, "The remote URL of git repository, e.g. git@github.com:org/repo.git.")
+	cmd.Flags().StringVar(&c.headBranch, "branch", c.headBranch, "The head branch of the change.")
+	cmd.Flags().StringVar(&c.headCommit, "commit", c.headCommit, "The head commit of the change.")
+	cmd.Flags().StringVar(&c.baseBranch, "base-branch", c.baseBranch, "The base branch of the change.")
+	cmd.Flags().StringVar(&c.out, "out", c.out, "Write the markdown result to the given file instead of stdout.")
+	cmd.Flags().DurationVar(&c.checkInterval, "check-interval", c.checkInterval, "The interval of checking the requested command.")
+	cmd.Flags().DurationVar(&c.timeout, "timeout", c.timeout, "Maximum execution time.")
+	cmd.Flags().DurationVar(&c.pipedHandleTimeout, "piped-handle-timeout", c.pipedHandleTimeout, "Maximum time a piped can take for handling the command.")
+
+	cmd.MarkFlagRequired("repo")
+	cmd.MarkFlagRequired("branch")
+	cmd.MarkFlagRequired("commit")
+	cmd.MarkFlagRequired("base-branch")
+
+	return cmd
+}
+
+func (c *command) run(ctx context.Context, t cli.Telemetry) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cli, err := c.clientOptions.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+	defer cli.Close()
+
+	req := &apiservice.RequestPlanPreviewRequest{
+		RepoRemoteUrl: c.repoRemoteURL,
+		HeadBranch:    c.headBranch,
+		HeadCommit:    c.headCommit,
+		BaseBranch:    c.baseBranch,
+		Timeout:       int64(c.pipedHandleTimeout.Seconds()),
+	}
+	resp, err := cli.RequestPlanPreview(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to request plan preview: %w", err)
+	}
+
+	var results []*model.PlanPreviewCommandResult
+	if len(resp.Commands) == 0 {
+		t.Logger.Info("No piped is watching the given repository")
+	} else {
+		t.Logger.Info(fmt.Sprintf("Requested plan preview to %d pipeds, waiting for the results...", len(resp.Commands)))
+		results, err = c.waitResults(ctx, cli, resp.Commands, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	markdown := convert(results, c.headBranch, c.headCommit)
+	if c.out == "" {
+		fmt.Println(markdown)
+		return nil
+	}
+	if err := ioutil.WriteFile(c.out, []byte(markdown), 0644); err != nil {
+		return fmt.Errorf("failed to write the result to %s: %w", c.out, err)
+	}
+	t.Logger.Info(fmt.Sprintf("Successfully wrote the result to %s", c.out))
+	return nil
+}
+
+// waitResults polls the results of the given commands until all of them have been handled.
+func (c *command) waitResults(ctx context.Context, cli apiservice.Client, commands []string, t cli.Telemetry) ([]*model.PlanPreviewCommandResult, error) {
+	ticker := time.NewTicker(c.checkInterval)
+	defer ticker.Stop()
+
+	req := &apiservice.GetPlanPreviewResultsRequest{
+		Commands:             commands,
+		CommandHandleTimeout: int64(c.pipedHandleTimeout.Seconds()),
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out while waiting for the plan preview results: %w", ctx.Err())
+
+		case <-ticker.C:
+			resp, err := cli.GetPlanPreviewResults(ctx, req)
+			if err == nil {
+				return resp.Results, nil
+			}
+			if status.Code(err) != codes.NotFound {
+				return nil, fmt.Errorf("failed to get plan preview results: %w", err)
+			}
+			t.Logger.Info("...")
+		}
+	}
+}
+
+// convert renders the given results into a markdown text
+// those can be posted as a comment of pull request.
+func convert(results []*model.PlanPreviewCommandResult, headBranch, headCommit string) string {
+	var (
+		apps         []*model.ApplicationPlanPreviewResult
+		failedApps   []*model.ApplicationPlanPreviewResult
+		failedPipeds []*model.PlanPreviewCommandResult
+	)
+	for _, r := range results {
+		if r.Error != "" {
+			failedPipeds = append(failedPipeds, r)
+		}
+		for _, a := range r.Results {
+			if a.Error != "" {
+				failedApps = append(failedApps, a)
+				continue
+			}
+			apps = append(apps, a)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Ran plan-preview against head commit %s of branch %s.\n", headCommit, headBranch))
+
+	if len(apps)+len(failedApps)+len(failedPipeds) == 0 {
+		b.WriteString("No application will be triggered by this change.\n")
+		return b.String()
+	}
+
+	if len(apps) > 0 {
+		b.WriteString(fmt.Sprintf("\n## %d application(s) will be triggered\n", len(apps)))
+		for _, a := range apps {
+			b.WriteString(fmt.Sprintf("\n### app: %s, env: %s, kind: %s\n", a.ApplicationName, a.EnvId, a.ApplicationKind))
+			b.WriteString(fmt.Sprintf("\nSync strategy: %s\nSummary: %s\n", a.SyncStrategy, a.PlanSummary))
+			if len(a.PlanDetails) == 0 {
+				continue
+			}
+			b.WriteString("\n<details>\n<summary>Details (Click me)</summary>\n<p>\n\n``` diff\n")
+			b.WriteString(strings.TrimRight(string(a.PlanDetails), "\n"))
+			b.WriteString("\n```\n</p>\n</details>\n")
+		}
+	}
+
+	if len(failedApps) > 0 {
+		b.WriteString(fmt.Sprintf("\n## %d application(s) failed to build plan preview\n", len(failedApps)))
+		for _, a := range failedApps {
+			b.WriteString(fmt.Sprintf("\n### app: %s, env: %s, kind: %s\n", a.ApplicationName, a.EnvId, a.ApplicationKind))
+			b.WriteString(fmt.Sprintf("\nReason: %s\n", a.Error))
+		}
+	}
+
+	if len(failedPipeds) > 0 {
+		b.WriteString(fmt.Sprintf("\n## %d piped(s) failed to build plan preview\n", len(failedPipeds)))
+		for _, p := range failedPipeds {
+			b.WriteString(fmt.Sprintf("\n### piped: %s\n", p.PipedId))
+			b.WriteString(fmt.Sprintf("\nReason: %s\n", p.Error))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestConvert(t *testing.T) {
+	testcases := []struct {
+		name     string
+		results  []*model.PlanPreviewCommandResult
+		expected string
+	}{
+		{
+			name: "no changes",
+			results: []*model.PlanPreviewCommandResult{
+				{CommandId: "cmd-1", PipedId: "piped-1"},
+			},
+			expected: "Ran plan-preview against head commit abc of branch feature.\n" +
+				"No application will be triggered by this change.\n",
+		},
+		{
+			name: "has changes and failures",
+			results: []*model.PlanPreviewCommandResult{
+				{
+					CommandId: "cmd-1",
+					PipedId:   "piped-1",
+					Results: []*model.ApplicationPlanPreviewResult{
+						{
+							ApplicationName: "app-1",
+							ApplicationKind: model.ApplicationKind_KUBERNETES,
+							EnvId:           "env-1",
+							SyncStrategy:    model.SyncStrategy_QUICK_SYNC,
+							PlanSummary:     "Quick sync by applying all manifests",
+							PlanDetails:     []byte("- replicas: 2\n+ replicas: 3\n"),
+						},
+						{
+							ApplicationName: "app-2",
+							ApplicationKind: model.ApplicationKind_TERRAFORM,
+							EnvId:           "env-1",
+							Error:           "Failed while planning",
+						},
+					},
+				},
+				{
+					CommandId: "cmd-2",
+					PipedId:   "piped-2",
+					Error:     "Timed out",
+				},
+			},
+			expected: "Ran plan-preview against head commit abc of branch feature.\n" +
+				"\n## 1 application(s) will be triggered\n" +
+				"\n### app: app-1, env: env-1, kind: KUBERNETES\n" +
+				"\nSync strategy: QUICK_SYNC\nSummary: Quick sync by applying all manifests\n" +
+				"\n<details>\n<summary>Details (Click me)</summary>\n<p>\n\n``` diff\n" +
+				"- replicas: 2\n+ replicas: 3" +
+				"\n```\n</p>\n</details>\n" +
+				"\n## 1 application(s) failed to build plan preview\n" +
+				"\n### app: app-2, env: env-1, kind: TERRAFORM\n" +
+				"\nReason: Failed while planning\n" +
+				"\n## 1 piped(s) failed to build plan preview\n" +
+				"\n### piped: piped-2\n" +
+				"\nReason: Timed out\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := convert(tc.results, "feature", "abc")
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
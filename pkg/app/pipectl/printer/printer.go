@@ -0,0 +1,113 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package printer provides the ways to print
+// the resources fetched by pipectl in various formats.
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Format represents the output format of pipectl.
+// This implements pflag.Value interface so that it can be used as a flag directly.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatWide Format = "wide"
+)
+
+var formats = []Format{
+	FormatJSON,
+	FormatYAML,
+	FormatWide,
+}
+
+// FormatStrings returns the list of supported formats in string.
+func FormatStrings() []string {
+	out := make([]string, 0, len(formats))
+	for _, f := range formats {
+		out = append(out, string(f))
+	}
+	return out
+}
+
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(v string) error {
+	for _, s := range formats {
+		if string(s) == v {
+			*f = s
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, must be one of %s", v, strings.Join(FormatStrings(), "|"))
+}
+
+func (f *Format) Type() string {
+	return "format"
+}
+
+// Table represents the tabular form of resources used by the wide format.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Print writes the given object to the given writer in the specified format.
+// The table is used instead of the object when the format is wide.
+func Print(w io.Writer, f Format, obj interface{}, table Table) error {
+	switch f {
+	case FormatJSON, "":
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+
+	case FormatYAML:
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal: %w", err)
+		}
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return fmt.Errorf("failed to convert to yaml: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+
+	case FormatWide:
+		tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(table.Header, "\t"))
+		for _, row := range table.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+
+	default:
+		return fmt.Errorf("unsupported output format %q", f)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestPrint(t *testing.T) {
+	app := &model.Application{
+		Id:        "app-1",
+		Name:      "simple",
+		Kind:      model.ApplicationKind_KUBERNETES,
+		EnvId:     "env-1",
+		PipedId:   "piped-1",
+		UpdatedAt: 1609459200,
+	}
+
+	testcases := []struct {
+		name     string
+		format   Format
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "json",
+			format:   FormatJSON,
+			expected: "{\"id\":\"app-1\",\"name\":\"simple\",\"env_id\":\"env-1\",\"piped_id\":\"piped-1\",\"updated_at\":1609459200}\n",
+		},
+		{
+			name:     "yaml",
+			format:   FormatYAML,
+			expected: "env_id: env-1\nid: app-1\nname: simple\npiped_id: piped-1\nupdated_at: 1609459200\n",
+		},
+		{
+			name:   "wide",
+			format: FormatWide,
+			expected: "ID      NAME     KIND         ENV     PIPED     SYNC STATUS   DISABLED   UPDATED\n" +
+				"app-1   simple   KUBERNETES   env-1   piped-1                 false      2021-01-01T00:00:00Z\n",
+		},
+		{
+			name:    "unsupported format",
+			format:  Format("xml"),
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Print(&buf, tc.format, app, ApplicationTable(app))
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestFormatSet(t *testing.T) {
+	var f Format
+	require.NoError(t, f.Set("yaml"))
+	assert.Equal(t, FormatYAML, f)
+
+	assert.Error(t, f.Set("xml"))
+	assert.Equal(t, FormatYAML, f)
+}
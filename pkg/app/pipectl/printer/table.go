@@ -0,0 +1,100 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package printer
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// ApplicationTable returns the tabular form of the given applications.
+func ApplicationTable(apps ...*model.Application) Table {
+	t := Table{
+		Header: []string{"ID", "NAME", "KIND", "ENV", "PIPED", "SYNC STATUS", "DISABLED", "UPDATED"},
+		Rows:   make([][]string, 0, len(apps)),
+	}
+	for _, app := range apps {
+		syncStatus := ""
+		if app.SyncState != nil {
+			syncStatus = app.SyncState.Status.String()
+		}
+		t.Rows = append(t.Rows, []string{
+			app.Id,
+			app.Name,
+			app.Kind.String(),
+			app.EnvId,
+			app.PipedId,
+			syncStatus,
+			strconv.FormatBool(app.Disabled),
+			formatUnix(app.UpdatedAt),
+		})
+	}
+	return t
+}
+
+// DeploymentTable returns the tabular form of the given deployments.
+func DeploymentTable(deployments ...*model.Deployment) Table {
+	t := Table{
+		Header: []string{"ID", "APPLICATION", "KIND", "ENV", "STATUS", "COMMIT", "CREATED"},
+		Rows:   make([][]string, 0, len(deployments)),
+	}
+	for _, d := range deployments {
+		commit := ""
+		if d.Trigger != nil && d.Trigger.Commit != nil {
+			commit = d.Trigger.Commit.Hash
+			if len(commit) > 7 {
+				commit = commit[:7]
+			}
+		}
+		t.Rows = append(t.Rows, []string{
+			d.Id,
+			d.ApplicationName,
+			d.Kind.String(),
+			d.EnvId,
+			d.Status.String(),
+			commit,
+			formatUnix(d.CreatedAt),
+		})
+	}
+	return t
+}
+
+// PipedTable returns the tabular form of the given pipeds.
+func PipedTable(pipeds ...*model.Piped) Table {
+	t := Table{
+		Header: []string{"ID", "NAME", "VERSION", "STATUS", "DISABLED", "STARTED"},
+		Rows:   make([][]string, 0, len(pipeds)),
+	}
+	for _, p := range pipeds {
+		t.Rows = append(t.Rows, []string{
+			p.Id,
+			p.Name,
+			p.Version,
+			p.Status.String(),
+			strconv.FormatBool(p.Disabled),
+			formatUnix(p.StartedAt),
+		})
+	}
+	return t
+}
+
+func formatUnix(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
@@ -19,7 +19,10 @@ package http
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/pipe-cd/pipe/pkg/config"
@@ -28,6 +31,8 @@ import (
 const (
 	ProviderType   = "HTTP"
 	defaultTimeout = 30 * time.Second
+	// The response body larger than this is truncated before matching.
+	maxResponseBodySize = 1024 * 1024
 )
 
 type Provider struct {
@@ -48,33 +53,69 @@ func NewProvider(timeout time.Duration) *Provider {
 }
 
 // Run sends an HTTP request and then evaluate whether the response is expected one.
+// It checks the status code, the response body and the latency of the response.
 func (p *Provider) Run(ctx context.Context, cfg *config.AnalysisHTTP) (bool, string, error) {
 	req, err := p.makeRequest(ctx, cfg)
 	if err != nil {
 		return false, "", err
 	}
 
+	start := time.Now()
 	res, err := p.client.Do(req)
 	if err != nil {
 		return false, "", err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != cfg.ExpectedCode {
-		return false, "", fmt.Errorf("unexpected status code %d", res.StatusCode)
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBodySize))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	latency := time.Since(start)
+
+	if !containsCode(cfg.StatusCodes(), res.StatusCode) {
+		return false, fmt.Sprintf("unexpected status code %d, expected one of %v", res.StatusCode, cfg.StatusCodes()), nil
+	}
+	if cfg.ExpectedResponse != "" {
+		re, err := regexp.Compile(cfg.ExpectedResponse)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid expected response %q: %w", cfg.ExpectedResponse, err)
+		}
+		if !re.Match(body) {
+			return false, fmt.Sprintf("response body does not match %q", cfg.ExpectedResponse), nil
+		}
+	}
+	if max := time.Duration(cfg.MaxLatency); max > 0 && latency > max {
+		return false, fmt.Sprintf("latency %v exceeded the threshold %v", latency, max), nil
 	}
-	// TODO: Decide how to check if the body is expected one.
-	return true, "", nil
+	return true, fmt.Sprintf("status code %d, latency %v", res.StatusCode, latency), nil
 }
 
 func (p *Provider) makeRequest(ctx context.Context, cfg *config.AnalysisHTTP) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, nil)
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if cfg.Body != "" {
+		body = strings.NewReader(cfg.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, cfg.URL, body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header = make(http.Header, len(cfg.Headers))
 	for _, h := range cfg.Headers {
-		req.Header.Set(h.Key, h.Value)
+		req.Header.Add(h.Key, h.Value)
 	}
 	return req, nil
 }
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
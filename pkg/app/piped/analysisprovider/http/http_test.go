@@ -0,0 +1,127 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestProviderRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.Write([]byte(`{"status": "ok"}`))
+		case "/echo":
+			if r.Method != http.MethodPost || r.Header.Get("X-Token") != "token" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testcases := []struct {
+		name     string
+		cfg      config.AnalysisHTTP
+		expected bool
+		wantErr  bool
+	}{
+		{
+			name: "expected response",
+			cfg: config.AnalysisHTTP{
+				URL:              server.URL + "/healthz",
+				ExpectedResponse: `"status":\s*"ok"`,
+			},
+			expected: true,
+		},
+		{
+			name: "unexpected status code",
+			cfg: config.AnalysisHTTP{
+				URL: server.URL + "/unknown",
+			},
+			expected: false,
+		},
+		{
+			name: "one of expected status codes",
+			cfg: config.AnalysisHTTP{
+				URL:           server.URL + "/unknown",
+				ExpectedCodes: []int{200, 404},
+			},
+			expected: true,
+		},
+		{
+			name: "body and headers are sent",
+			cfg: config.AnalysisHTTP{
+				URL:    server.URL + "/echo",
+				Method: http.MethodPost,
+				Headers: []config.AnalysisHeader{
+					{Key: "X-Token", Value: "token"},
+				},
+				Body:             `{"message": "hello"}`,
+				ExpectedCodes:    []int{201},
+				ExpectedResponse: "hello",
+			},
+			expected: true,
+		},
+		{
+			name: "unmatched response body",
+			cfg: config.AnalysisHTTP{
+				URL:              server.URL + "/healthz",
+				ExpectedResponse: "ng",
+			},
+			expected: false,
+		},
+		{
+			name: "latency exceeded",
+			cfg: config.AnalysisHTTP{
+				URL:        server.URL + "/slow",
+				MaxLatency: config.Duration(time.Millisecond),
+			},
+			expected: false,
+		},
+		{
+			name: "invalid url",
+			cfg: config.AnalysisHTTP{
+				URL: "://invalid",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewProvider(time.Second)
+			expected, reason, err := p.Run(context.Background(), &tc.cfg)
+			require.Equal(t, tc.wantErr, err != nil, err)
+			assert.Equal(t, tc.expected, expected, reason)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log"
+)
+
+const (
+	ProviderType          = "Elasticsearch"
+	defaultTimeout        = 30 * time.Second
+	defaultTimestampField = "@timestamp"
+	// The service name used to sign the requests to Amazon OpenSearch Service.
+	awsSigningService = "es"
+)
+
+// Provider works as an HTTP client for Elasticsearch.
+type Provider struct {
+	client  *http.Client
+	address string
+	index   string
+
+	timestampField string
+	username       string
+	password       string
+	apiKey         string
+	awsCredentials aws.CredentialsProvider
+	awsRegion      string
+	timeout        time.Duration
+	logger         *zap.Logger
+}
+
+func NewProvider(address, index string, opts ...Option) (*Provider, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	if index == "" {
+		return nil, fmt.Errorf("index is required")
+	}
+
+	p := &Provider{
+		client:         &http.Client{},
+		address:        strings.TrimSuffix(address, "/"),
+		index:          index,
+		timestampField: defaultTimestampField,
+		timeout:        defaultTimeout,
+		logger:         zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+type Option func(*Provider)
+
+// WithTimestampField sets the field used to filter documents by the query range.
+func WithTimestampField(field string) Option {
+	return func(p *Provider) {
+		if field != "" {
+			p.timestampField = field
+		}
+	}
+}
+
+func WithBasicAuth(username, password string) Option {
+	return func(p *Provider) {
+		p.username = username
+		p.password = password
+	}
+}
+
+func WithAPIKey(apiKey string) Option {
+	return func(p *Provider) {
+		p.apiKey = apiKey
+	}
+}
+
+// WithAWSSigV4 signs the requests with AWS Signature Version 4
+// to access the domains of Amazon OpenSearch Service.
+func WithAWSSigV4(credentials aws.CredentialsProvider, region string) Option {
+	return func(p *Provider) {
+		p.awsCredentials = credentials
+		p.awsRegion = region
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("elasticsearch-provider")
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Evaluate counts the documents matching the given query string query in the given range,
+// then checks if the count does not exceed the threshold.
+// See more: https://www.elastic.co/guide/en/elasticsearch/reference/current/search-count.html
+func (p *Provider) Evaluate(ctx context.Context, query string, queryRange log.QueryRange, threshold int) (bool, string, error) {
+	if err := queryRange.Validate(); err != nil {
+		return false, "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	count, err := p.count(ctx, query, queryRange)
+	if err != nil {
+		return false, "", err
+	}
+	expected, reason := log.Evaluate(count, threshold)
+	return expected, reason, nil
+}
+
+type countResponse struct {
+	Count int `json:"count"`
+}
+
+func (p *Provider) buildRequestBody(query string, queryRange log.QueryRange) ([]byte, error) {
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []interface{}{
+					map[string]interface{}{
+						"query_string": map[string]interface{}{
+							"query": query,
+						},
+					},
+				},
+				"filter": []interface{}{
+					map[string]interface{}{
+						"range": map[string]interface{}{
+							p.timestampField: map[string]interface{}{
+								"gte":    queryRange.From.UTC().Format(time.RFC3339Nano),
+								"lte":    queryRange.To.UTC().Format(time.RFC3339Nano),
+								"format": "strict_date_optional_time",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return json.Marshal(body)
+}
+
+func (p *Provider) count(ctx context.Context, query string, queryRange log.QueryRange) (int, error) {
+	payload, err := p.buildRequestBody(query, queryRange)
+	if err != nil {
+		return 0, err
+	}
+
+	u := fmt.Sprintf("%s/%s/_count", p.address, url.PathEscape(p.index))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case p.awsCredentials != nil:
+		if err := p.signAWSRequest(ctx, req, payload); err != nil {
+			return 0, err
+		}
+	case p.apiKey != "":
+		req.Header.Set("Authorization", "ApiKey "+p.apiKey)
+	case p.username != "" || p.password != "":
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to run query for elasticsearch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response from elasticsearch: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected HTTP status code from elasticsearch: %d, body: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out countResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return 0, fmt.Errorf("failed to decode response from elasticsearch: %w", err)
+	}
+	p.logger.Info("elasticsearch query result", zap.String("query", query), zap.Int("count", out.Count))
+	return out.Count, nil
+}
+
+func (p *Provider) signAWSRequest(ctx context.Context, req *http.Request, payload []byte) error {
+	credentials, err := p.awsCredentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve aws credentials: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	signer := v4.NewSigner()
+	if err := signer.SignHTTP(ctx, credentials, req, hex.EncodeToString(hash[:]), awsSigningService, p.awsRegion, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign the request: %w", err)
+	}
+	return nil
+}
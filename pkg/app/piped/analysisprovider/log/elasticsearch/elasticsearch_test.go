@@ -0,0 +1,138 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log"
+)
+
+func TestEvaluate(t *testing.T) {
+	to := time.Date(2021, 1, 1, 0, 1, 0, 0, time.UTC)
+	queryRange := log.QueryRange{
+		From: to.Add(-time.Minute),
+		To:   to,
+	}
+
+	testcases := []struct {
+		name      string
+		response  string
+		status    int
+		threshold int
+		expected  bool
+		wantErr   bool
+	}{
+		{
+			name:     "no matched document",
+			response: `{"count":0,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0}}`,
+			status:   http.StatusOK,
+			expected: true,
+		},
+		{
+			name:      "within the threshold",
+			response:  `{"count":2}`,
+			status:    http.StatusOK,
+			threshold: 2,
+			expected:  true,
+		},
+		{
+			name:      "exceeded the threshold",
+			response:  `{"count":3}`,
+			status:    http.StatusOK,
+			threshold: 2,
+			expected:  false,
+		},
+		{
+			name:     "index not found",
+			response: `{"error":{"type":"index_not_found_exception"},"status":404}`,
+			status:   http.StatusNotFound,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/logs-canary/_count", r.URL.Path)
+				assert.Equal(t, "ApiKey key", r.Header.Get("Authorization"))
+
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				var req map[string]interface{}
+				require.NoError(t, json.Unmarshal(body, &req))
+				filter := req["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]interface{})
+				timeRange := filter[0].(map[string]interface{})["range"].(map[string]interface{})["timestamp"].(map[string]interface{})
+				assert.Equal(t, "2021-01-01T00:00:00Z", timeRange["gte"])
+				assert.Equal(t, "2021-01-01T00:01:00Z", timeRange["lte"])
+
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(server.URL, "logs-canary", WithAPIKey("key"), WithTimestampField("timestamp"))
+			require.NoError(t, err)
+
+			got, _, err := p.Evaluate(context.Background(), `level:error`, queryRange, tc.threshold)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestEvaluateWithAWSSigV4(t *testing.T) {
+	to := time.Now()
+	queryRange := log.QueryRange{
+		From: to.Add(-time.Minute),
+		To:   to,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access-key/"), auth)
+		assert.Contains(t, auth, "/us-west-2/es/aws4_request")
+		assert.Equal(t, "session-token", r.Header.Get("X-Amz-Security-Token"))
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Date"))
+
+		w.Write([]byte(`{"count":1}`))
+	}))
+	defer server.Close()
+
+	credentials := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{
+			AccessKeyID:     "access-key",
+			SecretAccessKey: "secret-key",
+			SessionToken:    "session-token",
+		}, nil
+	})
+	p, err := NewProvider(server.URL, "logs-*", WithAWSSigV4(credentials, "us-west-2"))
+	require.NoError(t, err)
+
+	got, _, err := p.Evaluate(context.Background(), `level:error`, queryRange, 1)
+	require.NoError(t, err)
+	assert.True(t, got)
+}
@@ -15,19 +15,24 @@
 package factory
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log"
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log/elasticsearch"
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log/loki"
 	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log/stackdriver"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/awsconfig"
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 // NewProvider generates an appropriate provider according to analysis provider config.
-func NewProvider(providerCfg *config.PipedAnalysisProvider, logger *zap.Logger) (provider log.Provider, err error) {
+func NewProvider(analysisCfg *config.AnalysisLog, providerCfg *config.PipedAnalysisProvider, logger *zap.Logger) (provider log.Provider, err error) {
 	switch providerCfg.Type {
 	case model.AnalysisProviderStackdriver:
 		cfg := providerCfg.StackdriverConfig
@@ -40,8 +45,82 @@ func NewProvider(providerCfg *config.PipedAnalysisProvider, logger *zap.Logger)
 			return nil, err
 		}
 
+	case model.AnalysisProviderLoki:
+		cfg := providerCfg.LokiConfig
+		options := []loki.Option{
+			loki.WithLogger(logger),
+			loki.WithTenantID(cfg.TenantID),
+		}
+		if analysisCfg.Timeout > 0 {
+			options = append(options, loki.WithTimeout(analysisCfg.Timeout.Duration()))
+		}
+		if cfg.UsernameFile != "" && cfg.PasswordFile != "" {
+			username, password, err := readBasicAuth(cfg.UsernameFile, cfg.PasswordFile)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, loki.WithBasicAuth(username, password))
+		}
+		provider, err = loki.NewProvider(cfg.Address, options...)
+		if err != nil {
+			return nil, err
+		}
+
+	case model.AnalysisProviderElasticsearch:
+		cfg := providerCfg.ElasticsearchConfig
+		options := []elasticsearch.Option{
+			elasticsearch.WithLogger(logger),
+			elasticsearch.WithTimestampField(cfg.TimestampField),
+		}
+		if analysisCfg.Timeout > 0 {
+			options = append(options, elasticsearch.WithTimeout(analysisCfg.Timeout.Duration()))
+		}
+		if cfg.UsernameFile != "" && cfg.PasswordFile != "" {
+			username, password, err := readBasicAuth(cfg.UsernameFile, cfg.PasswordFile)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, elasticsearch.WithBasicAuth(username, password))
+		}
+		if cfg.APIKeyFile != "" {
+			apiKey, err := ioutil.ReadFile(cfg.APIKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the api-key file: %w", err)
+			}
+			options = append(options, elasticsearch.WithAPIKey(strings.TrimSpace(string(apiKey))))
+		}
+		if cfg.AWS != nil {
+			awsCfg, err := awsconfig.Load(context.Background(), awsconfig.Options{
+				Region:          cfg.AWS.Region,
+				Profile:         cfg.AWS.Profile,
+				CredentialsFile: cfg.AWS.CredentialsFile,
+				RoleARN:         cfg.AWS.RoleARN,
+				TokenFile:       cfg.AWS.TokenFile,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the aws config: %w", err)
+			}
+			options = append(options, elasticsearch.WithAWSSigV4(awsCfg.Credentials, awsCfg.Region))
+		}
+		provider, err = elasticsearch.NewProvider(cfg.Address, cfg.Index, options...)
+		if err != nil {
+			return nil, err
+		}
+
 	default:
 		return nil, fmt.Errorf("any of providers config not found")
 	}
 	return provider, nil
 }
+
+func readBasicAuth(usernameFile, passwordFile string) (string, string, error) {
+	username, err := ioutil.ReadFile(usernameFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the username file: %w", err)
+	}
+	password, err := ioutil.ReadFile(passwordFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the password file: %w", err)
+	}
+	return strings.TrimSpace(string(username)), strings.TrimSpace(string(password)), nil
+}
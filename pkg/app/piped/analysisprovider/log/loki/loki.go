@@ -0,0 +1,193 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log"
+)
+
+const (
+	ProviderType   = "Loki"
+	defaultTimeout = 30 * time.Second
+	queryPath      = "/loki/api/v1/query"
+	tenantIDHeader = "X-Scope-OrgID"
+)
+
+// Provider works as an HTTP client for Loki.
+type Provider struct {
+	client  *http.Client
+	address string
+
+	username string
+	password string
+	tenantID string
+	timeout  time.Duration
+	logger   *zap.Logger
+}
+
+func NewProvider(address string, opts ...Option) (*Provider, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+
+	p := &Provider{
+		client:  &http.Client{},
+		address: strings.TrimSuffix(address, "/"),
+		timeout: defaultTimeout,
+		logger:  zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+type Option func(*Provider)
+
+func WithBasicAuth(username, password string) Option {
+	return func(p *Provider) {
+		p.username = username
+		p.password = password
+	}
+}
+
+// WithTenantID sets the tenant ID sent via the X-Scope-OrgID header
+// for multi-tenant Loki.
+func WithTenantID(tenantID string) Option {
+	return func(p *Provider) {
+		p.tenantID = tenantID
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("loki-provider")
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Evaluate counts the log lines matching the given LogQL log query in the given range
+// by wrapping it with count_over_time, then checks if the count does not exceed the threshold.
+// See more: https://grafana.com/docs/loki/latest/api/#get-lokiapiv1query
+func (p *Provider) Evaluate(ctx context.Context, query string, queryRange log.QueryRange, threshold int) (bool, string, error) {
+	if err := queryRange.Validate(); err != nil {
+		return false, "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	count, err := p.count(ctx, query, queryRange)
+	if err != nil {
+		return false, "", err
+	}
+	expected, reason := log.Evaluate(count, threshold)
+	return expected, reason, nil
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			// A pair of the timestamp and the value in string.
+			Value [2]interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (p *Provider) count(ctx context.Context, query string, queryRange log.QueryRange) (int, error) {
+	seconds := int64(queryRange.To.Sub(queryRange.From).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	values := url.Values{}
+	values.Set("query", fmt.Sprintf("sum(count_over_time(%s [%ds]))", query, seconds))
+	values.Set("time", strconv.FormatInt(queryRange.To.UnixNano(), 10))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+queryPath+"?"+values.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	if p.username != "" || p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	if p.tenantID != "" {
+		req.Header.Set(tenantIDHeader, p.tenantID)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to run query for loki: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response from loki: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected HTTP status code from loki: %d, body: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out queryResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return 0, fmt.Errorf("failed to decode response from loki: %w", err)
+	}
+	if out.Status != "success" {
+		return 0, fmt.Errorf("failed to run query for loki: %s", out.Error)
+	}
+	if out.Data.ResultType != "vector" {
+		return 0, fmt.Errorf("unexpected result type %q, the query must be a log query", out.Data.ResultType)
+	}
+	p.logger.Info("loki query result", zap.String("query", query), zap.Int("series", len(out.Data.Result)))
+
+	// No result means that there was no matched log line in the range.
+	var count int
+	for _, r := range out.Data.Result {
+		s, ok := r.Value[1].(string)
+		if !ok {
+			return 0, fmt.Errorf("unexpected value type in the response from loki: %T", r.Value[1])
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse the value in the response from loki: %w", err)
+		}
+		count += int(v)
+	}
+	return count, nil
+}
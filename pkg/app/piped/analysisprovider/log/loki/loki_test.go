@@ -0,0 +1,103 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log"
+)
+
+func TestEvaluate(t *testing.T) {
+	to := time.Unix(1600000000, 0)
+	queryRange := log.QueryRange{
+		From: to.Add(-time.Minute),
+		To:   to,
+	}
+
+	testcases := []struct {
+		name      string
+		response  string
+		status    int
+		threshold int
+		expected  bool
+		wantErr   bool
+	}{
+		{
+			name:     "no matched log line",
+			response: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			status:   http.StatusOK,
+			expected: true,
+		},
+		{
+			name:      "within the threshold",
+			response:  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1600000000,"3"]}]}}`,
+			status:    http.StatusOK,
+			threshold: 3,
+			expected:  true,
+		},
+		{
+			name:      "exceeded the threshold",
+			response:  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1600000000,"4"]}]}}`,
+			status:    http.StatusOK,
+			threshold: 3,
+			expected:  false,
+		},
+		{
+			name:     "not a log query",
+			response: `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			status:   http.StatusOK,
+			wantErr:  true,
+		},
+		{
+			name:     "bad request",
+			response: `parse error`,
+			status:   http.StatusBadRequest,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, queryPath, r.URL.Path)
+				assert.Equal(t, `sum(count_over_time({app="canary"} |= "error" [60s]))`, r.URL.Query().Get("query"))
+				assert.Equal(t, "1600000000000000000", r.URL.Query().Get("time"))
+				assert.Equal(t, "tenant", r.Header.Get(tenantIDHeader))
+				username, password, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "user", username)
+				assert.Equal(t, "pass", password)
+
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(server.URL, WithBasicAuth("user", "pass"), WithTenantID("tenant"))
+			require.NoError(t, err)
+
+			got, _, err := p.Evaluate(context.Background(), `{app="canary"} |= "error"`, queryRange, tc.threshold)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -16,13 +16,45 @@ package log
 
 import (
 	"context"
+	"fmt"
+	"time"
 )
 
 // Provider represents a client for log provider which provides logs for analysis.
 type Provider interface {
 	Type() string
-	// Evaluate runs the given query against the log provider,
-	// and then checks if there is at least one error log.
+	// Evaluate counts the log entries matching the given query in the given range,
+	// and then checks if the count does not exceed the given threshold.
 	// Returns the result reason if non-error occurred.
-	Evaluate(ctx context.Context, query string) (result bool, reason string, err error)
+	// The first value "result" must be false if err isn't nil.
+	Evaluate(ctx context.Context, query string, queryRange QueryRange, threshold int) (result bool, reason string, err error)
+}
+
+// QueryRange represents a sliced time range.
+type QueryRange struct {
+	// Required: Start of the queried time period
+	From time.Time
+	// End of the queried time period. Defaults to the current time.
+	To time.Time
+}
+
+func (q *QueryRange) Validate() error {
+	if q.From.IsZero() {
+		return fmt.Errorf("start of the query range is required")
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if q.From.After(q.To) {
+		return fmt.Errorf("\"to\" should be after \"from\"")
+	}
+	return nil
+}
+
+// Evaluate returns whether the given count of matched log entries does not exceed the threshold.
+func Evaluate(count, threshold int) (bool, string) {
+	if count > threshold {
+		return false, fmt.Sprintf("found %d log entries matching the query while the threshold is %d", count, threshold)
+	}
+	return true, fmt.Sprintf("found %d log entries matching the query, within the threshold %d", count, threshold)
 }
@@ -17,6 +17,8 @@ package stackdriver
 import (
 	"context"
 	"time"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/log"
 )
 
 const ProviderType = "StackdriverLogging"
@@ -38,6 +40,6 @@ func (p *Provider) Type() string {
 	return ProviderType
 }
 
-func (p *Provider) Evaluate(ctx context.Context, query string) (bool, string, error) {
+func (p *Provider) Evaluate(ctx context.Context, query string, queryRange log.QueryRange, threshold int) (bool, string, error) {
 	return false, "", nil
 }
@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-api-client-go/api/v1/datadog"
@@ -32,6 +34,17 @@ const (
 	defaultTimeout = 30 * time.Second
 )
 
+var (
+	// metricQueryRegex matches a metric query like "avg:system.cpu.user{env:prod} by {host}".
+	metricQueryRegex = regexp.MustCompile(`([A-Za-z_]+):[A-Za-z][A-Za-z0-9_.]*\{[^{}]*\}`)
+	aggregators      = map[string]struct{}{
+		"avg": {},
+		"sum": {},
+		"min": {},
+		"max": {},
+	}
+)
+
 // Provider works as an HTTP client for datadog.
 type Provider struct {
 	client *datadog.APIClient
@@ -133,6 +146,52 @@ func (p *Provider) Evaluate(ctx context.Context, query string, queryRange metric
 	return evaluate(evaluator, *resp.Series)
 }
 
+// ValidateQuery checks if the given query is following the syntax of Datadog metric queries.
+// See more: https://docs.datadoghq.com/metrics/#querying-metrics
+func (p *Provider) ValidateQuery(query string) error {
+	return validateQuery(query)
+}
+
+func validateQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query must not be empty")
+	}
+	if err := checkBrackets(query); err != nil {
+		return err
+	}
+	matches := metricQueryRegex.FindAllStringSubmatch(query, -1)
+	if len(matches) == 0 {
+		return fmt.Errorf("no metric query like \"avg:metric.name{scope}\" found in %q", query)
+	}
+	for _, m := range matches {
+		if _, ok := aggregators[m[1]]; !ok {
+			return fmt.Errorf("unknown space aggregator %q in %q, must be one of avg, sum, min, max", m[1], m[0])
+		}
+	}
+	return nil
+}
+
+// checkBrackets checks if all brackets in the given query are balanced.
+func checkBrackets(query string) error {
+	pairs := map[rune]rune{')': '(', '}': '{', ']': '['}
+	stack := make([]rune, 0)
+	for _, c := range query {
+		switch c {
+		case '(', '{', '[':
+			stack = append(stack, c)
+		case ')', '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[c] {
+				return fmt.Errorf("unbalanced %q found in %q", c, query)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("unclosed %q found in %q", stack[len(stack)-1], query)
+	}
+	return nil
+}
+
 // evaluate checks if all data points for all time series are within the expected range.
 func evaluate(evaluator metrics.Evaluator, series []datadog.MetricsQueryMetadata) (bool, string, error) {
 	for _, s := range series {
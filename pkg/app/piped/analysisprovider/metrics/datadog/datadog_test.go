@@ -106,3 +106,63 @@ func TestEvaluate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateQuery(t *testing.T) {
+	testcases := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{
+			name:    "empty query",
+			query:   "",
+			wantErr: true,
+		},
+		{
+			name:    "simple query",
+			query:   "avg:system.cpu.user{env:prod}",
+			wantErr: false,
+		},
+		{
+			name:    "query with grouping and function",
+			query:   "sum:trace.http.request.errors{service:web,env:prod} by {host}.as_count()",
+			wantErr: false,
+		},
+		{
+			name:    "arithmetic between queries",
+			query:   "sum:trace.http.request.errors{*}.as_count() / sum:trace.http.request.hits{*}.as_count()",
+			wantErr: false,
+		},
+		{
+			name:    "query wrapped by function",
+			query:   "anomalies(avg:system.load.1{*}, 'basic', 2)",
+			wantErr: false,
+		},
+		{
+			name:    "missing scope",
+			query:   "avg:system.cpu.user",
+			wantErr: true,
+		},
+		{
+			name:    "unknown aggregator",
+			query:   "count:system.cpu.user{*}",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed scope",
+			query:   "avg:system.cpu.user{env:prod",
+			wantErr: true,
+		},
+		{
+			name:    "unbalanced parentheses",
+			query:   "abs(avg:system.cpu.user{*}))",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateQuery(tc.query)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -15,16 +15,21 @@
 package factory
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"strings"
 
+	promconfig "github.com/prometheus/common/config"
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
 	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics/datadog"
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics/newrelic"
 	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics/prometheus"
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics/stackdriver"
 	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
@@ -48,7 +53,33 @@ func NewProvider(analysisTempCfg *config.TemplatableAnalysisMetrics, providerCfg
 			}
 			options = append(options, prometheus.WithBasicAuth(strings.TrimSpace(string(username)), strings.TrimSpace(string(password))))
 		}
-		return prometheus.NewProvider(providerCfg.PrometheusConfig.Address, options...)
+		if cfg.BearerTokenFile != "" {
+			token, err := ioutil.ReadFile(cfg.BearerTokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the bearer token file: %w", err)
+			}
+			options = append(options, prometheus.WithBearerToken(strings.TrimSpace(string(token))))
+		}
+		if len(cfg.Headers) > 0 {
+			options = append(options, prometheus.WithHeaders(cfg.Headers))
+		}
+		if cfg.TLS != nil {
+			tlsConfig, err := promconfig.NewTLSConfig(&promconfig.TLSConfig{
+				CAFile:             cfg.TLS.CAFile,
+				CertFile:           cfg.TLS.CertFile,
+				KeyFile:            cfg.TLS.KeyFile,
+				ServerName:         cfg.TLS.ServerName,
+				InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the tls config: %w", err)
+			}
+			options = append(options, prometheus.WithTLSConfig(tlsConfig))
+		}
+		if len(cfg.FailoverAddresses) > 0 {
+			options = append(options, prometheus.WithFailoverAddresses(cfg.FailoverAddresses...))
+		}
+		return prometheus.NewProvider(cfg.Address, options...)
 	case model.AnalysisProviderDatadog:
 		var apiKey, applicationKey string
 		cfg := providerCfg.DatadogConfig
@@ -74,6 +105,38 @@ func NewProvider(analysisTempCfg *config.TemplatableAnalysisMetrics, providerCfg
 			options = append(options, datadog.WithAddress(cfg.Address))
 		}
 		return datadog.NewProvider(apiKey, applicationKey, options...)
+	case model.AnalysisProviderNewRelic:
+		cfg := providerCfg.NewRelicConfig
+		a, err := ioutil.ReadFile(cfg.APIKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the api-key file: %w", err)
+		}
+		options := []newrelic.Option{
+			newrelic.WithLogger(logger),
+			newrelic.WithTimeout(analysisTempCfg.Timeout.Duration()),
+		}
+		if cfg.Address != "" {
+			options = append(options, newrelic.WithAddress(cfg.Address))
+		}
+		return newrelic.NewProvider(cfg.AccountID, strings.TrimSpace(string(a)), options...)
+	case model.AnalysisProviderStackdriver:
+		cfg := providerCfg.StackdriverConfig
+		ctx := context.Background()
+		ts, err := gcpcredentials.TokenSource(ctx, gcpcredentials.Options{
+			CredentialsFile:           cfg.ServiceAccountFile,
+			ImpersonateServiceAccount: cfg.ImpersonateServiceAccount,
+		}, stackdriver.MonitoringReadScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the credentials: %w", err)
+		}
+		options := []stackdriver.Option{
+			stackdriver.WithLogger(logger),
+			stackdriver.WithTimeout(analysisTempCfg.Timeout.Duration()),
+		}
+		if cfg.QueryLanguage == config.StackdriverQueryLanguagePromQL {
+			options = append(options, stackdriver.WithPromQL())
+		}
+		return stackdriver.NewProvider(ctx, cfg.Project, ts, options...)
 	default:
 		return nil, fmt.Errorf("any of providers config not found")
 	}
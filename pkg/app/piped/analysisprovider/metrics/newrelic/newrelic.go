@@ -0,0 +1,273 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package newrelic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
+)
+
+const (
+	ProviderType   = "NewRelic"
+	defaultAddress = "https://api.newrelic.com/graphql"
+	defaultTimeout = 30 * time.Second
+	apiKeyHeader   = "API-Key"
+
+	nrqlQuery = `query($accountId: Int!, $nrql: Nrql!) {
+  actor {
+    account(id: $accountId) {
+      nrql(query: $nrql) {
+        results
+      }
+    }
+  }
+}`
+)
+
+var (
+	// timeRangeRegex matches the clauses specifying the time range of a NRQL query.
+	timeRangeRegex = regexp.MustCompile(`(?i)\b(SINCE|UNTIL)\b`)
+	// The keys of the result holding the metadata instead of the queried values.
+	metadataKeys = map[string]struct{}{
+		"beginTimeSeconds": {},
+		"endTimeSeconds":   {},
+		"timestamp":        {},
+		"facet":            {},
+	}
+)
+
+// Provider works as an HTTP client for the NerdGraph API of New Relic.
+type Provider struct {
+	client    *http.Client
+	address   string
+	accountID int64
+	apiKey    string
+
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+func NewProvider(accountID int64, apiKey string, opts ...Option) (*Provider, error) {
+	if accountID <= 0 {
+		return nil, fmt.Errorf("account-id is required")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("api-key is required")
+	}
+
+	p := &Provider{
+		client:    &http.Client{},
+		address:   defaultAddress,
+		accountID: accountID,
+		apiKey:    apiKey,
+		timeout:   defaultTimeout,
+		logger:    zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+type Option func(*Provider)
+
+func WithAddress(address string) Option {
+	return func(p *Provider) {
+		p.address = address
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("newrelic-provider")
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// ValidateQuery checks if the given NRQL query can be run by this provider.
+// The time range is always decided by the analysis so it must not be specified in the query.
+func (p *Provider) ValidateQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query must not be empty")
+	}
+	if m := timeRangeRegex.FindString(query); m != "" {
+		return fmt.Errorf("%s clause must not be specified in the query since the range is decided by the analysis interval", strings.ToUpper(m))
+	}
+	return nil
+}
+
+// Evaluate runs the given NRQL query in the given range through the NerdGraph API,
+// then checks if all the returned values are within the expected range.
+// See more: https://docs.newrelic.com/docs/apis/nerdgraph/examples/nerdgraph-nrql-tutorial/
+func (p *Provider) Evaluate(ctx context.Context, query string, queryRange metrics.QueryRange, evaluator metrics.Evaluator) (bool, string, error) {
+	if err := queryRange.Validate(); err != nil {
+		return false, "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	nrql := fmt.Sprintf("%s SINCE %d UNTIL %d", query, queryRange.From.UnixNano()/int64(time.Millisecond), queryRange.To.UnixNano()/int64(time.Millisecond))
+	results, err := p.query(ctx, nrql)
+	if err != nil {
+		return false, "", err
+	}
+	p.logger.Info("newrelic query result", zap.String("query", nrql), zap.Int("results", len(results)))
+	return evaluate(evaluator, results)
+}
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphqlResponse struct {
+	Data struct {
+		Actor struct {
+			Account struct {
+				NRQL *struct {
+					Results []map[string]interface{} `json:"results"`
+				} `json:"nrql"`
+			} `json:"account"`
+		} `json:"actor"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (p *Provider) query(ctx context.Context, nrql string) ([]map[string]interface{}, error) {
+	body, err := json.Marshal(graphqlRequest{
+		Query: nrqlQuery,
+		Variables: map[string]interface{}{
+			"accountId": p.accountID,
+			"nrql":      nrql,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.address, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apiKeyHeader, p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query for newrelic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from newrelic: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status code from newrelic: %d, body: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var out graphqlResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode response from newrelic: %w", err)
+	}
+	if len(out.Errors) > 0 {
+		msgs := make([]string, 0, len(out.Errors))
+		for _, e := range out.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return nil, fmt.Errorf("failed to run query for newrelic: %s", strings.Join(msgs, ", "))
+	}
+	if out.Data.Actor.Account.NRQL == nil {
+		return nil, fmt.Errorf("no query result found: %w", metrics.ErrNoDataFound)
+	}
+	return out.Data.Actor.Account.NRQL.Results, nil
+}
+
+// evaluate checks if all values of all results are within the expected range.
+// Each result is a row of the NRQL query such as a bucket of TIMESERIES or a FACET.
+func evaluate(evaluator metrics.Evaluator, results []map[string]interface{}) (bool, string, error) {
+	var found bool
+	for _, r := range results {
+		keys := make([]string, 0, len(r))
+		for k := range r {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if _, ok := metadataKeys[k]; ok {
+				continue
+			}
+			for _, value := range numbers(r[k]) {
+				found = true
+				if !evaluator.InRange(value) {
+					reason := fmt.Sprintf("found a value of %s (%g) that is out of the expected range (%s)", k, value, evaluator)
+					return false, reason, nil
+				}
+			}
+		}
+	}
+	if !found {
+		return false, "", fmt.Errorf("invalid response: no values found within the queried range: %w", metrics.ErrNoDataFound)
+	}
+	reason := fmt.Sprintf("all values are within the expected range (%s)", evaluator)
+	return true, reason, nil
+}
+
+// numbers returns all numbers held by the given value of a result.
+// The functions like percentile return the values as an object.
+func numbers(v interface{}) []float64 {
+	switch v := v.(type) {
+	case float64:
+		return []float64{v}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var out []float64
+		for _, k := range keys {
+			out = append(out, numbers(v[k])...)
+		}
+		return out
+	default:
+		// The string values such as the name of facet and null are ignored.
+		return nil
+	}
+}
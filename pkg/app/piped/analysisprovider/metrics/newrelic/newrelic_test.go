@@ -0,0 +1,156 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package newrelic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
+)
+
+type fakeEvaluator struct {
+	max float64
+}
+
+func (f *fakeEvaluator) InRange(value float64) bool {
+	return value <= f.max
+}
+
+func (f *fakeEvaluator) String() string {
+	return ""
+}
+
+func TestValidateQuery(t *testing.T) {
+	testcases := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{
+			name:    "empty query",
+			query:   " ",
+			wantErr: true,
+		},
+		{
+			name:    "valid query",
+			query:   "SELECT percentage(count(*), WHERE error IS true) FROM Transaction WHERE appName = 'web' TIMESERIES",
+			wantErr: false,
+		},
+		{
+			name:    "time range is specified",
+			query:   "SELECT count(*) FROM Transaction since 30 minutes ago",
+			wantErr: true,
+		},
+		{
+			name:    "attribute including the keyword",
+			query:   "SELECT average(sinceStart) FROM Transaction",
+			wantErr: false,
+		},
+	}
+	p := &Provider{}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := p.ValidateQuery(tc.query)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	to := time.Unix(1600000000, 0)
+	queryRange := metrics.QueryRange{
+		From: to.Add(-time.Minute),
+		To:   to,
+	}
+
+	testcases := []struct {
+		name      string
+		response  string
+		status    int
+		expected  bool
+		wantErr   bool
+		errNoData bool
+	}{
+		{
+			name:     "within the range",
+			response: `{"data":{"actor":{"account":{"nrql":{"results":[{"beginTimeSeconds":1599999940,"endTimeSeconds":1599999970,"count":3},{"beginTimeSeconds":1599999970,"endTimeSeconds":1600000000,"count":5}]}}}}}`,
+			status:   http.StatusOK,
+			expected: true,
+		},
+		{
+			name:     "out of the range",
+			response: `{"data":{"actor":{"account":{"nrql":{"results":[{"facet":"web","appName":"web","count":11}]}}}}}`,
+			status:   http.StatusOK,
+			expected: false,
+		},
+		{
+			name:     "nested values",
+			response: `{"data":{"actor":{"account":{"nrql":{"results":[{"percentile.duration":{"95":12.5}}]}}}}}`,
+			status:   http.StatusOK,
+			expected: false,
+		},
+		{
+			name:      "no data",
+			response:  `{"data":{"actor":{"account":{"nrql":{"results":[{"average.duration":null}]}}}}}`,
+			status:    http.StatusOK,
+			wantErr:   true,
+			errNoData: true,
+		},
+		{
+			name:     "graphql error",
+			response: `{"data":{"actor":{"account":{"nrql":null}}},"errors":[{"message":"NRQL Syntax Error"}]}`,
+			status:   http.StatusOK,
+			wantErr:  true,
+		},
+		{
+			name:     "unauthorized",
+			response: `{"error":"invalid api key"}`,
+			status:   http.StatusUnauthorized,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "api-key", r.Header.Get(apiKeyHeader))
+				var req graphqlRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, float64(12345), req.Variables["accountId"])
+				assert.Equal(t, "SELECT count(*) FROM Transaction SINCE 1599999940000 UNTIL 1600000000000", req.Variables["nrql"])
+
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(12345, "api-key", WithAddress(server.URL))
+			require.NoError(t, err)
+
+			expected, _, err := p.Evaluate(context.Background(), "SELECT count(*) FROM Transaction", queryRange, &fakeEvaluator{max: 10})
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.errNoData, errors.Is(err, metrics.ErrNoDataFound))
+			assert.Equal(t, tc.expected, expected)
+		})
+	}
+}
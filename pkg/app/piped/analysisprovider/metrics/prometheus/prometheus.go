@@ -16,8 +16,11 @@ package prometheus
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
@@ -36,14 +39,25 @@ const (
 
 // Provider is a client for prometheus.
 type Provider struct {
-	api      v1.API
-	username string
-	password string
+	endpoints []endpoint
+
+	failoverAddresses []string
+	username          string
+	password          string
+	bearerToken       string
+	headers           map[string]string
+	tlsConfig         *tls.Config
+	baseRoundTripper  http.RoundTripper
 
 	timeout time.Duration
 	logger  *zap.Logger
 }
 
+type endpoint struct {
+	address string
+	api     v1.API
+}
+
 func NewProvider(address string, opts ...Option) (*Provider, error) {
 	if address == "" {
 		return nil, fmt.Errorf("address is required")
@@ -57,18 +71,48 @@ func NewProvider(address string, opts ...Option) (*Provider, error) {
 		opt(p)
 	}
 
-	cfg := api.Config{
-		Address: address,
+	rt := p.roundTripper()
+	addresses := append([]string{address}, p.failoverAddresses...)
+	p.endpoints = make([]endpoint, 0, len(addresses))
+	for _, addr := range addresses {
+		client, err := api.NewClient(api.Config{
+			Address:      addr,
+			RoundTripper: rt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.endpoints = append(p.endpoints, endpoint{
+			address: addr,
+			api:     v1.NewAPI(client),
+		})
 	}
-	if p.username != "" && p.password != "" {
-		cfg.RoundTripper = config.NewBasicAuthRoundTripper(p.username, config.Secret(p.password), "", api.DefaultRoundTripper)
+	return p, nil
+}
+
+// roundTripper returns the RoundTripper shared by all endpoints.
+func (p *Provider) roundTripper() http.RoundTripper {
+	rt := api.DefaultRoundTripper
+	if p.baseRoundTripper != nil {
+		rt = p.baseRoundTripper
 	}
-	client, err := api.NewClient(cfg)
-	if err != nil {
-		return nil, err
+	if p.tlsConfig != nil {
+		if t, ok := rt.(*http.Transport); ok {
+			t = t.Clone()
+			t.TLSClientConfig = p.tlsConfig
+			rt = t
+		}
 	}
-	p.api = v1.NewAPI(client)
-	return p, nil
+	if len(p.headers) > 0 {
+		rt = &headersRoundTripper{headers: p.headers, rt: rt}
+	}
+	switch {
+	case p.bearerToken != "":
+		rt = config.NewBearerAuthRoundTripper(config.Secret(p.bearerToken), rt)
+	case p.username != "" && p.password != "":
+		rt = config.NewBasicAuthRoundTripper(p.username, config.Secret(p.password), "", rt)
+	}
+	return rt
 }
 
 type Option func(*Provider)
@@ -92,6 +136,40 @@ func WithBasicAuth(username, password string) Option {
 	}
 }
 
+func WithBearerToken(token string) Option {
+	return func(p *Provider) {
+		p.bearerToken = token
+	}
+}
+
+func WithHeaders(headers map[string]string) Option {
+	return func(p *Provider) {
+		p.headers = headers
+	}
+}
+
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(p *Provider) {
+		p.tlsConfig = cfg
+	}
+}
+
+// WithRoundTripper specifies the RoundTripper used to send requests
+// before adding the headers and the credentials configured by other options.
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(p *Provider) {
+		p.baseRoundTripper = rt
+	}
+}
+
+// WithFailoverAddresses specifies the addresses tried in order
+// when the previous one was unavailable.
+func WithFailoverAddresses(addresses ...string) Option {
+	return func(p *Provider) {
+		p.failoverAddresses = addresses
+	}
+}
+
 func (p *Provider) Type() string {
 	return ProviderType
 }
@@ -99,32 +177,102 @@ func (p *Provider) Type() string {
 // Evaluate queries the range query endpoint and checks if values in all data points are within the expected range.
 // For the range query endpoint, see: https://prometheus.io/docs/prometheus/latest/querying/api/#range-queries
 func (p *Provider) Evaluate(ctx context.Context, query string, queryRange metrics.QueryRange, evaluator metrics.Evaluator) (bool, string, error) {
-	if err := queryRange.Validate(); err != nil {
+	response, err := p.query(ctx, query, queryRange)
+	if err != nil {
 		return false, "", err
 	}
+	return evaluate(evaluator, response)
+}
 
-	ctx, cancel := context.WithTimeout(ctx, p.timeout)
-	defer cancel()
+// QueryPoints queries the range query endpoint and returns the values of all data points.
+// The data points of all returned time series are merged into a single list.
+func (p *Provider) QueryPoints(ctx context.Context, query string, queryRange metrics.QueryRange) ([]metrics.DataPoint, error) {
+	response, err := p.query(ctx, query, queryRange)
+	if err != nil {
+		return nil, err
+	}
+	return convertToDataPoints(response)
+}
+
+// query runs the given query against the endpoints in order until one of them succeeds.
+func (p *Provider) query(ctx context.Context, query string, queryRange metrics.QueryRange) (model.Value, error) {
+	if err := queryRange.Validate(); err != nil {
+		return nil, err
+	}
 
 	// NOTE: Use 1m as a step but make sure the "step" is smaller than the query range.
 	step := time.Minute
 	if diff := queryRange.To.Sub(queryRange.From); diff < step {
 		step = diff
 	}
-
-	p.logger.Info("run query", zap.String("query", query))
-	response, warnings, err := p.api.QueryRange(ctx, query, v1.Range{
+	r := v1.Range{
 		Start: queryRange.From,
 		End:   queryRange.To,
 		Step:  step,
-	})
+	}
+
+	var lastErr error
+	for i, e := range p.endpoints {
+		response, err := p.queryRange(ctx, e, query, r)
+		if err == nil {
+			return response, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !shouldFailover(err) {
+			break
+		}
+		if i < len(p.endpoints)-1 {
+			p.logger.Warn("failed to query, trying the next endpoint",
+				zap.String("address", e.address),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil, lastErr
+}
+
+func (p *Provider) queryRange(ctx context.Context, e endpoint, query string, r v1.Range) (model.Value, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	p.logger.Info("run query", zap.String("query", query), zap.String("address", e.address))
+	response, warnings, err := e.api.QueryRange(ctx, query, r)
 	if err != nil {
-		return false, "", err
+		return nil, err
 	}
 	for _, w := range warnings {
 		p.logger.Warn("non critical error occurred", zap.String("warning", w))
 	}
-	return evaluate(evaluator, response)
+	return response, nil
+}
+
+// shouldFailover reports whether the query should be retried with the next endpoint.
+// The errors caused by the query itself are not retried because they would happen again.
+func shouldFailover(err error) bool {
+	var apiErr *v1.Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch apiErr.Type {
+	case v1.ErrBadData, v1.ErrExec, v1.ErrCanceled:
+		return false
+	default:
+		return true
+	}
+}
+
+// headersRoundTripper adds the given headers to every request.
+type headersRoundTripper struct {
+	headers map[string]string
+	rt      http.RoundTripper
+}
+
+func (h *headersRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return h.rt.RoundTrip(req)
 }
 
 func evaluate(evaluator metrics.Evaluator, response model.Value) (bool, string, error) {
@@ -191,3 +339,41 @@ func evaluate(evaluator metrics.Evaluator, response model.Value) (bool, string,
 	reason := fmt.Sprintf("all values are within the expected range (%s)", evaluator)
 	return true, reason, nil
 }
+
+func convertToDataPoints(response model.Value) ([]metrics.DataPoint, error) {
+	var points []metrics.DataPoint
+	add := func(ts model.Time, value model.SampleValue) {
+		if math.IsNaN(float64(value)) {
+			return
+		}
+		points = append(points, metrics.DataPoint{
+			Timestamp: ts.Unix(),
+			Value:     float64(value),
+		})
+	}
+
+	switch res := response.(type) {
+	case *model.Scalar:
+		add(res.Timestamp, res.Value)
+	case model.Vector:
+		for _, s := range res {
+			if s == nil {
+				continue
+			}
+			add(s.Timestamp, s.Value)
+		}
+	case model.Matrix:
+		for _, r := range res {
+			for _, value := range r.Values {
+				add(value.Timestamp, value.Value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected data type returned")
+	}
+
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no data points returned: %w", metrics.ErrNoDataFound)
+	}
+	return points, nil
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
@@ -33,32 +37,153 @@ func TestType(t *testing.T) {
 }
 
 func TestProviderEvaluate(t *testing.T) {
+	value := model.Matrix([]*model.SampleStream{
+		{
+			Values: []model.SamplePair{
+				{
+					Value: 1,
+				},
+			},
+		},
+	})
 	cases := []struct {
-		name       string
-		queryError error
-		wantErr    bool
+		name    string
+		apis    []fakeAPI
+		want    bool
+		wantErr bool
 	}{
 		{
-			name:       "query error occurred",
-			queryError: fmt.Errorf("error"),
-			wantErr:    true,
+			name: "query error occurred",
+			apis: []fakeAPI{
+				{err: fmt.Errorf("error")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failed over to the next endpoint",
+			apis: []fakeAPI{
+				{err: &v1.Error{Type: v1.ErrServer}},
+				{value: value},
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "all endpoints are unavailable",
+			apis: []fakeAPI{
+				{err: &v1.Error{Type: v1.ErrServer}},
+				{err: fmt.Errorf("connection refused")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad query is not failed over",
+			apis: []fakeAPI{
+				{err: &v1.Error{Type: v1.ErrBadData}},
+				{value: value},
+			},
+			wantErr: true,
 		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			p := Provider{
-				api: fakeAPI{
-					err: tc.queryError,
+				timeout: defaultTimeout,
+				logger:  zap.NewNop(),
+			}
+			for i, api := range tc.apis {
+				p.endpoints = append(p.endpoints, endpoint{
+					address: fmt.Sprintf("http://prometheus-%d", i),
+					api:     api,
+				})
+			}
+			got, _, err := p.Evaluate(context.Background(), "query", metrics.QueryRange{From: time.Now()}, &fakeEvaluator{expected: true})
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestProviderQueryPoints(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   model.Value
+		want    []metrics.DataPoint
+		wantErr bool
+	}{
+		{
+			name: "multiple time series are merged",
+			value: model.Matrix([]*model.SampleStream{
+				{
+					Values: []model.SamplePair{
+						{Timestamp: 1000, Value: 1},
+						{Timestamp: 61000, Value: 2},
+					},
+				},
+				{
+					Values: []model.SamplePair{
+						{Timestamp: 1000, Value: 3},
+						{Timestamp: 61000, Value: model.SampleValue(math.NaN())},
+					},
 				},
+			}),
+			want: []metrics.DataPoint{
+				{Timestamp: 1, Value: 1},
+				{Timestamp: 61, Value: 2},
+				{Timestamp: 1, Value: 3},
+			},
+		},
+		{
+			name:    "no data points",
+			value:   model.Matrix([]*model.SampleStream{}),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := Provider{
 				timeout: defaultTimeout,
 				logger:  zap.NewNop(),
+				endpoints: []endpoint{
+					{address: "http://prometheus", api: fakeAPI{value: tc.value}},
+				},
 			}
-			_, _, err := p.Evaluate(context.Background(), "query", metrics.QueryRange{From: time.Now()}, &fakeEvaluator{expected: true})
+			got, err := p.QueryPoints(context.Background(), "query", metrics.QueryRange{From: time.Now()})
 			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
 		})
 	}
+}
+
+func TestProviderRequestHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1,"1"]]}]}}`))
+	}))
+	defer server.Close()
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	p, err := NewProvider(unavailable.URL,
+		WithFailoverAddresses(server.URL),
+		WithBearerToken("token"),
+		WithHeaders(map[string]string{"X-Scope-OrgID": "tenant"}),
+	)
+	require.NoError(t, err)
 
+	now := time.Now()
+	ok, _, err := p.Evaluate(context.Background(), "query", metrics.QueryRange{From: now.Add(-time.Minute), To: now}, &fakeEvaluator{expected: true})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Bearer token", got.Get("Authorization"))
+	assert.Equal(t, "tenant", got.Get("X-Scope-OrgID"))
 }
 
 func TestEvaluate(t *testing.T) {
@@ -35,6 +35,28 @@ type Provider interface {
 	Evaluate(ctx context.Context, query string, queryRange QueryRange, evaluator Evaluator) (expected bool, reason string, err error)
 }
 
+// QueryValidator is implemented by the providers being able to check
+// the syntax of a query before running it.
+type QueryValidator interface {
+	// ValidateQuery returns an error if the given query is malformed.
+	ValidateQuery(query string) error
+}
+
+// DataPointsQueryer is implemented by the providers being able to return
+// the raw data points of a query. It is required for analyses comparing
+// the metrics of two variants instead of checking a static range.
+type DataPointsQueryer interface {
+	// QueryPoints runs the given query and returns all data points within the given range.
+	QueryPoints(ctx context.Context, query string, queryRange QueryRange) ([]DataPoint, error)
+}
+
+// DataPoint represents a single value of a metric at a given time.
+type DataPoint struct {
+	// Unix timestamp in seconds.
+	Timestamp int64
+	Value     float64
+}
+
 // Evaluator evaluates the response from the metrics provider.
 type Evaluator interface {
 	// InRange checks if the value is expected one.
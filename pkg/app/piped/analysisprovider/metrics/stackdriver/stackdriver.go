@@ -0,0 +1,239 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stackdriver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	monitoring "google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics/prometheus"
+)
+
+const (
+	ProviderType = "Stackdriver"
+	// MonitoringReadScope is the OAuth scope required to query the metrics.
+	MonitoringReadScope = monitoring.MonitoringReadScope
+
+	defaultEndpoint = "https://monitoring.googleapis.com"
+	defaultTimeout  = 30 * time.Second
+	// The format of date literals in MQL.
+	mqlDateFormat = "2006/01/02 15:04:05"
+)
+
+// Provider is a client for Google Cloud Monitoring (formerly Stackdriver).
+// The queries are written in either MQL or PromQL.
+type Provider struct {
+	project  string
+	promQL   bool
+	endpoint string
+
+	mql        *monitoring.Service
+	prometheus *prometheus.Provider
+
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+// NewProvider returns a new provider querying the metrics of the given project
+// with the access tokens issued by the given token source.
+func NewProvider(ctx context.Context, project string, ts oauth2.TokenSource, opts ...Option) (*Provider, error) {
+	if project == "" {
+		return nil, fmt.Errorf("project is required")
+	}
+
+	p := &Provider{
+		project:  project,
+		endpoint: defaultEndpoint,
+		timeout:  defaultTimeout,
+		logger:   zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var rt http.RoundTripper = http.DefaultTransport
+	if ts != nil {
+		rt = &oauth2.Transport{Source: ts, Base: rt}
+	}
+
+	if p.promQL {
+		// Cloud Monitoring serves the Prometheus HTTP API for PromQL queries.
+		// See: https://cloud.google.com/stackdriver/docs/managed-prometheus/query
+		address := fmt.Sprintf("%s/v1/projects/%s/location/global/prometheus", p.endpoint, project)
+		prom, err := prometheus.NewProvider(address,
+			prometheus.WithRoundTripper(rt),
+			prometheus.WithTimeout(p.timeout),
+			prometheus.WithLogger(p.logger),
+		)
+		if err != nil {
+			return nil, err
+		}
+		p.prometheus = prom
+		return p, nil
+	}
+
+	service, err := monitoring.NewService(ctx,
+		option.WithHTTPClient(&http.Client{Transport: rt}),
+		option.WithEndpoint(p.endpoint+"/"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create monitoring service: %w", err)
+	}
+	p.mql = service
+	return p, nil
+}
+
+type Option func(*Provider)
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("stackdriver-provider")
+	}
+}
+
+// WithPromQL makes the provider send the queries to the PromQL endpoint instead of MQL one.
+func WithPromQL() Option {
+	return func(p *Provider) {
+		p.promQL = true
+	}
+}
+
+// WithEndpoint specifies the address of Cloud Monitoring API.
+func WithEndpoint(endpoint string) Option {
+	return func(p *Provider) {
+		p.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// Evaluate runs the given query and checks if values in all data points are within the expected range.
+// For MQL, see: https://cloud.google.com/monitoring/api/ref_v3/rest/v3/projects.timeSeries/query
+func (p *Provider) Evaluate(ctx context.Context, query string, queryRange metrics.QueryRange, evaluator metrics.Evaluator) (bool, string, error) {
+	if p.promQL {
+		return p.prometheus.Evaluate(ctx, query, queryRange, evaluator)
+	}
+	if err := queryRange.Validate(); err != nil {
+		return false, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	query = withinQueryRange(query, queryRange)
+	p.logger.Info("run query", zap.String("query", query))
+
+	var (
+		name   = fmt.Sprintf("projects/%s", p.project)
+		series []*monitoring.TimeSeriesData
+		token  string
+	)
+	for {
+		resp, err := p.mql.Projects.TimeSeries.Query(name, &monitoring.QueryTimeSeriesRequest{
+			Query:     query,
+			PageToken: token,
+		}).Context(ctx).Do()
+		if err != nil {
+			return false, "", fmt.Errorf("failed to query time series: %w", err)
+		}
+		for _, e := range resp.PartialErrors {
+			p.logger.Warn("non critical error occurred", zap.String("warning", e.Message))
+		}
+		series = append(series, resp.TimeSeriesData...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		token = resp.NextPageToken
+	}
+	return evaluate(evaluator, series)
+}
+
+// withinQueryRange restricts the given MQL query to the given range
+// unless the query is already specifying its range.
+func withinQueryRange(query string, queryRange metrics.QueryRange) string {
+	if strings.Contains(query, "within") {
+		return query
+	}
+	return fmt.Sprintf("%s | within d'%s', d'%s'",
+		strings.TrimSpace(query),
+		queryRange.From.UTC().Format(mqlDateFormat),
+		queryRange.To.UTC().Format(mqlDateFormat),
+	)
+}
+
+// evaluate checks if all values of all time series are within the expected range.
+func evaluate(evaluator metrics.Evaluator, series []*monitoring.TimeSeriesData) (bool, string, error) {
+	if len(series) == 0 {
+		return false, "", fmt.Errorf("no time series found: %w", metrics.ErrNoDataFound)
+	}
+	for _, s := range series {
+		if len(s.PointData) == 0 {
+			return false, "", fmt.Errorf("no data points found in a time series: %w", metrics.ErrNoDataFound)
+		}
+		for _, point := range s.PointData {
+			for _, v := range point.Values {
+				value, err := numericValue(v)
+				if err != nil {
+					return false, "", err
+				}
+				if !evaluator.InRange(value) {
+					reason := fmt.Sprintf("found a value (%g) that is out of the expected range (%s)", value, evaluator)
+					return false, reason, nil
+				}
+			}
+		}
+	}
+	reason := fmt.Sprintf("all values are within the expected range (%s)", evaluator)
+	return true, reason, nil
+}
+
+func numericValue(v *monitoring.TypedValue) (float64, error) {
+	switch {
+	case v == nil:
+		return 0, fmt.Errorf("the value is empty: %w", metrics.ErrNoDataFound)
+	case v.DoubleValue != nil:
+		if math.IsNaN(*v.DoubleValue) {
+			return 0, fmt.Errorf("the value is not a number: %w", metrics.ErrNoDataFound)
+		}
+		return *v.DoubleValue, nil
+	case v.Int64Value != nil:
+		return float64(*v.Int64Value), nil
+	case v.BoolValue != nil:
+		if *v.BoolValue {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unsupported value type, only numeric and boolean values can be evaluated")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stackdriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	monitoring "google.golang.org/api/monitoring/v3"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
+)
+
+type fakeEvaluator struct {
+	expected bool
+}
+
+func (f *fakeEvaluator) InRange(_ float64) bool {
+	return f.expected
+}
+
+func (f *fakeEvaluator) String() string {
+	return ""
+}
+
+func TestProviderEvaluateMQL(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v3/projects/project/timeSeries:query", r.URL.Path)
+		var req monitoring.QueryTimeSeriesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		queries = append(queries, req.Query)
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.PageToken == "" {
+			w.Write([]byte(`{"timeSeriesData":[{"pointData":[{"values":[{"doubleValue":0.5}]}]}],"nextPageToken":"next"}`))
+			return
+		}
+		w.Write([]byte(`{"timeSeriesData":[{"pointData":[{"values":[{"int64Value":"1"}]}]}]}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(context.Background(), "project", nil, WithEndpoint(server.URL))
+	require.NoError(t, err)
+
+	from := time.Date(2021, 3, 2, 14, 0, 0, 0, time.UTC)
+	ok, _, err := p.Evaluate(context.Background(), "fetch k8s_container", metrics.QueryRange{From: from, To: from.Add(time.Hour)}, &fakeEvaluator{expected: true})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	expected := "fetch k8s_container | within d'2021/03/02 14:00:00', d'2021/03/02 15:00:00'"
+	assert.Equal(t, []string{expected, expected}, queries)
+}
+
+func TestProviderEvaluatePromQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/projects/project/location/global/prometheus/api/v1/query_range", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1,"1"]]}]}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(context.Background(), "project", nil, WithEndpoint(server.URL), WithPromQL())
+	require.NoError(t, err)
+
+	now := time.Now()
+	ok, _, err := p.Evaluate(context.Background(), "up", metrics.QueryRange{From: now.Add(-time.Minute), To: now}, &fakeEvaluator{expected: true})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestEvaluate(t *testing.T) {
+	double := func(v float64) *monitoring.TypedValue {
+		return &monitoring.TypedValue{DoubleValue: &v}
+	}
+	series := func(values ...*monitoring.TypedValue) []*monitoring.TimeSeriesData {
+		return []*monitoring.TimeSeriesData{
+			{
+				PointData: []*monitoring.PointData{
+					{Values: values},
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name      string
+		evaluator metrics.Evaluator
+		series    []*monitoring.TimeSeriesData
+		want      bool
+		wantErr   bool
+		errNoData bool
+	}{
+		{
+			name:      "no time series",
+			evaluator: &fakeEvaluator{},
+			wantErr:   true,
+			errNoData: true,
+		},
+		{
+			name:      "no data points in a time series",
+			evaluator: &fakeEvaluator{},
+			series:    []*monitoring.TimeSeriesData{{}},
+			wantErr:   true,
+			errNoData: true,
+		},
+		{
+			name:      "NaN found",
+			evaluator: &fakeEvaluator{expected: true},
+			series:    series(double(math.NaN())),
+			wantErr:   true,
+			errNoData: true,
+		},
+		{
+			name:      "value is out of range",
+			evaluator: &fakeEvaluator{expected: false},
+			series:    series(double(1)),
+			want:      false,
+		},
+		{
+			name:      "value is within the expected range",
+			evaluator: &fakeEvaluator{expected: true},
+			series:    series(double(1)),
+			want:      true,
+		},
+		{
+			name:      "unsupported value type",
+			evaluator: &fakeEvaluator{expected: true},
+			series:    series(&monitoring.TypedValue{DistributionValue: &monitoring.Distribution{}}),
+			wantErr:   true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _, err := evaluate(tc.evaluator, tc.series)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.errNoData, errors.Is(err, metrics.ErrNoDataFound))
+		})
+	}
+}
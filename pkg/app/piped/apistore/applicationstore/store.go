@@ -16,6 +16,7 @@ package applicationstore
 
 import (
 	"context"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -56,20 +57,33 @@ type store struct {
 	syncInterval    time.Duration
 	gracePeriod     time.Duration
 	logger          *zap.Logger
+
+	// The time reported by the control-plane at the last sync.
+	syncedAt int64
+	// The time when the full list of applications was fetched at last.
+	lastFullSyncTime time.Time
+	fullSyncInterval time.Duration
+	nowFunc          func() time.Time
 }
 
 var (
-	defaultSyncInterval = time.Minute
+	defaultSyncInterval     = time.Minute
+	defaultFullSyncInterval = 30 * time.Minute
+	// deltaSyncMargin is subtracted from the last synced time while fetching the changes
+	// to not miss the applications updated by a slow write or a skewed clock.
+	deltaSyncMargin = 30 * time.Second
 )
 
 // NewStore creates a new application store instance.
 // This syncs with the control plane to keep the list of applications for this runner up-to-date.
 func NewStore(apiClient apiClient, gracePeriod time.Duration, logger *zap.Logger) Store {
 	return &store{
-		apiClient:    apiClient,
-		syncInterval: defaultSyncInterval,
-		gracePeriod:  gracePeriod,
-		logger:       logger.Named("application-store"),
+		apiClient:        apiClient,
+		syncInterval:     defaultSyncInterval,
+		gracePeriod:      gracePeriod,
+		logger:           logger.Named("application-store"),
+		fullSyncInterval: defaultFullSyncInterval,
+		nowFunc:          time.Now,
 	}
 }
 
@@ -100,20 +114,63 @@ func (s *store) Lister() Lister {
 	return s
 }
 
+// sync fetches only the applications changed since the last sync
+// and merges them into the current snapshot.
+// The full list is fetched at the first time and periodically after that
+// to recover from any change missed by the delta syncs.
 func (s *store) sync(ctx context.Context) error {
-	resp, err := s.apiClient.ListApplications(ctx, &pipedservice.ListApplicationsRequest{})
+	now := s.nowFunc()
+	full := s.syncedAt == 0 || now.Sub(s.lastFullSyncTime) >= s.fullSyncInterval
+
+	req := &pipedservice.ListApplicationsRequest{}
+	if !full {
+		req.UpdatedAfter = s.syncedAt - int64(deltaSyncMargin.Seconds())
+	}
+	resp, err := s.apiClient.ListApplications(ctx, req)
 	if err != nil {
 		s.logger.Error("failed to list unhandled application", zap.Error(err))
 		return err
 	}
 
-	applicationMap := make(map[string]*model.Application, len(resp.Applications))
+	// The control-plane not supporting the delta sync always returns the full list.
+	if resp.SyncedAt == 0 {
+		full = true
+	}
+
+	var applicationMap map[string]*model.Application
+	if full {
+		applicationMap = make(map[string]*model.Application, len(resp.Applications))
+		s.lastFullSyncTime = now
+	} else {
+		current, _ := s.applicationMap.Load().(map[string]*model.Application)
+		applicationMap = make(map[string]*model.Application, len(current)+len(resp.Applications))
+		for id, app := range current {
+			applicationMap[id] = app
+		}
+	}
 	for _, app := range resp.Applications {
+		// The deleted applications are disabled as well.
+		if app.Disabled {
+			delete(applicationMap, app.Id)
+			continue
+		}
 		applicationMap[app.Id] = app
 	}
+	for _, id := range resp.UnmanagedApplicationIds {
+		delete(applicationMap, id)
+	}
+
+	applicationList := make([]*model.Application, 0, len(applicationMap))
+	for _, app := range applicationMap {
+		applicationList = append(applicationList, app)
+	}
+	sort.Slice(applicationList, func(i, j int) bool {
+		return applicationList[i].Id < applicationList[j].Id
+	})
 
 	s.applicationMap.Store(applicationMap)
-	s.applicationList.Store(resp.Applications)
+	s.applicationList.Store(applicationList)
+	s.syncedAt = resp.SyncedAt
 	return nil
 }
 
@@ -13,3 +13,112 @@
 // limitations under the License.
 
 package applicationstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeAPIClient struct {
+	requests  []*pipedservice.ListApplicationsRequest
+	responses []*pipedservice.ListApplicationsResponse
+}
+
+func (c *fakeAPIClient) ListApplications(_ context.Context, req *pipedservice.ListApplicationsRequest, _ ...grpc.CallOption) (*pipedservice.ListApplicationsResponse, error) {
+	c.requests = append(c.requests, req)
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	return resp, nil
+}
+
+func TestSync(t *testing.T) {
+	client := &fakeAPIClient{
+		responses: []*pipedservice.ListApplicationsResponse{
+			{
+				Applications: []*model.Application{
+					{Id: "app-2"},
+					{Id: "app-1"},
+				},
+				SyncedAt: 1000,
+			},
+			{
+				Applications: []*model.Application{
+					{Id: "app-1", Name: "updated"},
+					{Id: "app-2", Disabled: true},
+					{Id: "app-3"},
+				},
+				SyncedAt: 1060,
+			},
+			{
+				UnmanagedApplicationIds: []string{"app-1", "app-4"},
+				SyncedAt:                1120,
+			},
+			{
+				Applications: []*model.Application{
+					{Id: "app-3"},
+				},
+				SyncedAt: 3000,
+			},
+		},
+	}
+	now := time.Unix(1000, 0)
+	s := NewStore(client, time.Second, zap.NewNop()).(*store)
+	s.nowFunc = func() time.Time { return now }
+
+	// The first sync fetches the full list.
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, int64(0), client.requests[0].UpdatedAfter)
+	assert.Equal(t, []*model.Application{{Id: "app-1"}, {Id: "app-2"}}, s.List())
+
+	// The next one fetches only the changes.
+	now = now.Add(time.Minute)
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, int64(970), client.requests[1].UpdatedAfter)
+	assert.Equal(t, []*model.Application{{Id: "app-1", Name: "updated"}, {Id: "app-3"}}, s.List())
+	_, ok := s.Get("app-2")
+	assert.False(t, ok)
+
+	// The applications moved to another piped are removed.
+	now = now.Add(time.Minute)
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, int64(1030), client.requests[2].UpdatedAfter)
+	assert.Equal(t, []*model.Application{{Id: "app-3"}}, s.List())
+
+	// The full list is fetched again after the full sync interval.
+	now = now.Add(defaultFullSyncInterval)
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, int64(0), client.requests[3].UpdatedAfter)
+	assert.Equal(t, []*model.Application{{Id: "app-3"}}, s.List())
+}
+
+func TestSyncWithoutDeltaSupport(t *testing.T) {
+	client := &fakeAPIClient{
+		responses: []*pipedservice.ListApplicationsResponse{
+			{
+				Applications: []*model.Application{
+					{Id: "app-1"},
+				},
+			},
+			{
+				Applications: []*model.Application{
+					{Id: "app-2"},
+				},
+			},
+		},
+	}
+	s := NewStore(client, time.Second, zap.NewNop()).(*store)
+
+	require.NoError(t, s.sync(context.Background()))
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, int64(0), client.requests[1].UpdatedAfter)
+	assert.Equal(t, []*model.Application{{Id: "app-2"}}, s.List())
+}
@@ -19,8 +19,11 @@ import (
 	"sync"
 	"time"
 
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	"github.com/pipe-cd/pipe/pkg/model"
@@ -28,7 +31,9 @@ import (
 
 type apiClient interface {
 	ListUnhandledCommands(ctx context.Context, in *pipedservice.ListUnhandledCommandsRequest, opts ...grpc.CallOption) (*pipedservice.ListUnhandledCommandsResponse, error)
+	WatchUnhandledCommands(ctx context.Context, opts ...grpc.CallOption) (pipedservice.PipedService_WatchUnhandledCommandsClient, error)
 	ReportCommandHandled(ctx context.Context, in *pipedservice.ReportCommandHandledRequest, opts ...grpc.CallOption) (*pipedservice.ReportCommandHandledResponse, error)
+	ReportCommandOutputChunks(ctx context.Context, opts ...grpc.CallOption) (pipedservice.PipedService_ReportCommandOutputChunksClient, error)
 }
 
 type Store interface {
@@ -42,25 +47,49 @@ type Lister interface {
 	ListApplicationCommands() []model.ReportableCommand
 	ListDeploymentCommands() []model.ReportableCommand
 	ListStageCommands(deploymentID, stageID string) []model.ReportableCommand
+	// ListPipedCommands returns the commands those should be handled
+	// by piped itself instead of a specific application or deployment.
+	ListPipedCommands() []model.ReportableCommand
+	// ListBuildPlanPreviewCommands returns the commands requesting
+	// to build the plan preview of the applications.
+	ListBuildPlanPreviewCommands() []model.ReportableCommand
+	// Updated returns a channel which is closed when new commands arrived.
+	// Since a new channel is used after that, this should be called
+	// again every time before waiting for the next arrival.
+	Updated() <-chan struct{}
 }
 
 type store struct {
-	apiClient    apiClient
-	syncInterval time.Duration
+	apiClient       apiClient
+	syncInterval    time.Duration
+	rewatchInterval time.Duration
+	// Whether the commands are being pushed from the control plane.
+	// They are periodically synced only while this is false.
+	watching atomic.Bool
 	// TODO: Using atomic for storing a map of all commands
 	// instead of some separate lists + mutex as the current.
 	applicationCommands []model.ReportableCommand
 	deploymentCommands  []model.ReportableCommand
 	stageCommands       []model.ReportableCommand
+	pipedCommands       []model.ReportableCommand
+	planPreviewCommands []model.ReportableCommand
 	handledCommands     map[string]time.Time
-	mu                  sync.RWMutex
-	gracePeriod         time.Duration
-	logger              *zap.Logger
+	// The IDs of the commands received at the last update.
+	receivedCommands map[string]struct{}
+	// Closed and replaced by a new one when new commands arrived.
+	updatedCh   chan struct{}
+	mu          sync.RWMutex
+	gracePeriod time.Duration
+	logger      *zap.Logger
 }
 
 var (
-	defaultSyncInterval = 5 * time.Second
-	staleCommandPeriod  = 10 * time.Minute
+	defaultSyncInterval    = 5 * time.Second
+	defaultRewatchInterval = 10 * time.Second
+	staleCommandPeriod     = 10 * time.Minute
+	// The output of a command larger than this is sent
+	// by splitting into multiple chunks of this size.
+	maxOutputChunkSize = 1024 * 1024
 )
 
 // NewStore creates a new command store instance.
@@ -70,7 +99,9 @@ func NewStore(apiClient apiClient, gracePeriod time.Duration, logger *zap.Logger
 	return &store{
 		apiClient:       apiClient,
 		syncInterval:    defaultSyncInterval,
+		rewatchInterval: defaultRewatchInterval,
 		handledCommands: make(map[string]time.Time),
+		updatedCh:       make(chan struct{}),
 		gracePeriod:     gracePeriod,
 		logger:          logger.Named("command-store"),
 	}
@@ -86,10 +117,14 @@ func (s *store) Run(ctx context.Context) error {
 	cleanHandledCommandTicker := time.NewTicker(10 * time.Minute)
 	defer cleanHandledCommandTicker.Stop()
 
+	go s.watch(ctx)
+
 	for {
 		select {
 		case <-syncTicker.C:
-			s.sync(ctx)
+			if !s.watching.Load() {
+				s.sync(ctx)
+			}
 
 		case now := <-cleanHandledCommandTicker.C:
 			s.cleanHandledCommands(now)
@@ -105,36 +140,119 @@ func (s *store) Lister() Lister {
 	return s
 }
 
+// watch receives the unhandled commands pushed from the control plane
+// and reconnects when the stream was closed until the given context is done.
+// The commands are periodically synced instead while it is not watching.
+func (s *store) watch(ctx context.Context) {
+	for {
+		err := s.watchOnce(ctx)
+		s.watching.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if status.Code(err) == codes.Unimplemented {
+			s.logger.Info("control plane does not support watching commands, they will be synced periodically")
+			return
+		}
+		s.logger.Warn("failed to watch unhandled commands, they will be synced periodically until reconnected", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.rewatchInterval):
+		}
+	}
+}
+
+func (s *store) watchOnce(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.apiClient.WatchUnhandledCommands(ctx)
+	if err != nil {
+		return err
+	}
+	// Request to send the current unhandled commands.
+	if err := stream.Send(&pipedservice.WatchUnhandledCommandsRequest{}); err != nil {
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if !s.watching.Swap(true) {
+			s.logger.Info("started watching unhandled commands")
+		}
+		s.update(resp.Commands)
+	}
+}
+
 func (s *store) sync(ctx context.Context) error {
 	resp, err := s.apiClient.ListUnhandledCommands(ctx, &pipedservice.ListUnhandledCommandsRequest{})
 	if err != nil {
 		s.logger.Error("failed to list unhandled commands", zap.Error(err))
 		return err
 	}
+	s.update(resp.Commands)
+	return nil
+}
 
+func (s *store) update(commands []*model.Command) {
 	var (
 		applicationCommands = make([]model.ReportableCommand, 0)
 		deploymentCommands  = make([]model.ReportableCommand, 0)
 		stageCommands       = make([]model.ReportableCommand, 0)
+		pipedCommands       = make([]model.ReportableCommand, 0)
+		planPreviewCommands = make([]model.ReportableCommand, 0)
 	)
-	for _, cmd := range resp.Commands {
+	for _, cmd := range commands {
 		switch cmd.Type {
 		case model.Command_SYNC_APPLICATION, model.Command_UPDATE_APPLICATION_CONFIG:
 			applicationCommands = append(applicationCommands, s.makeReportableCommand(cmd))
 		case model.Command_CANCEL_DEPLOYMENT:
 			deploymentCommands = append(deploymentCommands, s.makeReportableCommand(cmd))
-		case model.Command_APPROVE_STAGE:
+		case model.Command_APPROVE_STAGE, model.Command_REJECT_STAGE, model.Command_SKIP_STAGE:
 			stageCommands = append(stageCommands, s.makeReportableCommand(cmd))
+		case model.Command_ENABLE_DEBUG_LOGGING, model.Command_EXECUTE_RESOURCE_ACTION:
+			pipedCommands = append(pipedCommands, s.makeReportableCommand(cmd))
+		case model.Command_BUILD_PLAN_PREVIEW:
+			planPreviewCommands = append(planPreviewCommands, s.makeReportableCommand(cmd))
 		}
 	}
 
 	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.applicationCommands = applicationCommands
 	s.deploymentCommands = deploymentCommands
 	s.stageCommands = stageCommands
-	s.mu.Unlock()
+	s.pipedCommands = pipedCommands
+	s.planPreviewCommands = planPreviewCommands
+
+	// Notify the consumers to let them handle the new commands
+	// without waiting for their next periodic check.
+	received := make(map[string]struct{}, len(commands))
+	arrived := false
+	for _, cmd := range commands {
+		received[cmd.Id] = struct{}{}
+		if _, ok := s.receivedCommands[cmd.Id]; !ok {
+			arrived = true
+		}
+	}
+	s.receivedCommands = received
+	if arrived {
+		close(s.updatedCh)
+		s.updatedCh = make(chan struct{})
+	}
+}
 
-	return nil
+func (s *store) Updated() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.updatedCh
 }
 
 func (s *store) cleanHandledCommands(now time.Time) {
@@ -199,27 +317,89 @@ func (s *store) ListStageCommands(deploymentID, stageID string) []model.Reportab
 	return commands
 }
 
+func (s *store) ListPipedCommands() []model.ReportableCommand {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	commands := make([]model.ReportableCommand, 0, len(s.pipedCommands))
+	for _, cmd := range s.pipedCommands {
+		if _, ok := s.handledCommands[cmd.Id]; ok {
+			continue
+		}
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+func (s *store) ListBuildPlanPreviewCommands() []model.ReportableCommand {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	commands := make([]model.ReportableCommand, 0, len(s.planPreviewCommands))
+	for _, cmd := range s.planPreviewCommands {
+		if _, ok := s.handledCommands[cmd.Id]; ok {
+			continue
+		}
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
 func (s *store) makeReportableCommand(c *model.Command) model.ReportableCommand {
 	return model.ReportableCommand{
 		Command: c,
-		Report: func(ctx context.Context, status model.CommandStatus, metadata map[string]string) error {
-			return s.reportCommandHandled(ctx, c, status, metadata)
+		Report: func(ctx context.Context, status model.CommandStatus, metadata map[string]string, output []byte) error {
+			return s.reportCommandHandled(ctx, c, status, metadata, output)
 		},
 	}
 }
 
-func (s *store) reportCommandHandled(ctx context.Context, c *model.Command, status model.CommandStatus, metadata map[string]string) error {
+func (s *store) reportCommandHandled(ctx context.Context, c *model.Command, status model.CommandStatus, metadata map[string]string, output []byte) error {
 	now := time.Now()
 
 	s.mu.Lock()
 	s.handledCommands[c.Id] = now
 	s.mu.Unlock()
 
+	if len(output) > maxOutputChunkSize {
+		if err := s.reportCommandOutputChunks(ctx, c.Id, output); err != nil {
+			return err
+		}
+		output = nil
+	}
+
 	_, err := s.apiClient.ReportCommandHandled(ctx, &pipedservice.ReportCommandHandledRequest{
 		CommandId: c.Id,
 		Status:    status,
 		Metadata:  metadata,
 		HandledAt: now.Unix(),
+		Output:    output,
 	})
 	return err
 }
+
+// reportCommandOutputChunks sends the given output of a command
+// to the control plane by splitting into multiple chunks.
+func (s *store) reportCommandOutputChunks(ctx context.Context, commandID string, output []byte) error {
+	stream, err := s.apiClient.ReportCommandOutputChunks(ctx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(output); i += maxOutputChunkSize {
+		end := i + maxOutputChunkSize
+		if end > len(output) {
+			end = len(output)
+		}
+		chunk := &pipedservice.ReportCommandOutputChunk{
+			Data: output[i:end],
+		}
+		if i == 0 {
+			chunk.CommandId = commandID
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
@@ -13,3 +13,108 @@
 // limitations under the License.
 
 package commandstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestUpdateNotifiesNewCommands(t *testing.T) {
+	s := NewStore(nil, time.Second, zap.NewNop()).(*store)
+
+	ch := s.Updated()
+	assert.False(t, isClosed(ch))
+
+	s.update([]*model.Command{
+		{Id: "cmd-1", Type: model.Command_APPROVE_STAGE},
+	})
+	assert.True(t, isClosed(ch))
+
+	// No notification when the same commands were received again.
+	ch = s.Updated()
+	s.update([]*model.Command{
+		{Id: "cmd-1", Type: model.Command_APPROVE_STAGE},
+	})
+	assert.False(t, isClosed(ch))
+
+	// No notification when some commands were removed.
+	s.update(nil)
+	assert.False(t, isClosed(ch))
+
+	s.update([]*model.Command{
+		{Id: "cmd-2", Type: model.Command_CANCEL_DEPLOYMENT},
+	})
+	assert.True(t, isClosed(ch))
+	assert.False(t, isClosed(s.Updated()))
+}
+
+type fakeAPIClient struct {
+	apiClient
+	handled []*pipedservice.ReportCommandHandledRequest
+	chunks  []*pipedservice.ReportCommandOutputChunk
+}
+
+func (c *fakeAPIClient) ReportCommandHandled(_ context.Context, req *pipedservice.ReportCommandHandledRequest, _ ...grpc.CallOption) (*pipedservice.ReportCommandHandledResponse, error) {
+	c.handled = append(c.handled, req)
+	return &pipedservice.ReportCommandHandledResponse{}, nil
+}
+
+func (c *fakeAPIClient) ReportCommandOutputChunks(_ context.Context, _ ...grpc.CallOption) (pipedservice.PipedService_ReportCommandOutputChunksClient, error) {
+	return &fakeOutputChunksClient{client: c}, nil
+}
+
+type fakeOutputChunksClient struct {
+	grpc.ClientStream
+	client *fakeAPIClient
+}
+
+func (c *fakeOutputChunksClient) Send(chunk *pipedservice.ReportCommandOutputChunk) error {
+	c.client.chunks = append(c.client.chunks, chunk)
+	return nil
+}
+
+func (c *fakeOutputChunksClient) CloseAndRecv() (*pipedservice.ReportCommandOutputChunksResponse, error) {
+	return &pipedservice.ReportCommandOutputChunksResponse{}, nil
+}
+
+func TestReportCommandHandledWithLargeOutput(t *testing.T) {
+	cmd := &model.Command{Id: "cmd-1", Type: model.Command_BUILD_PLAN_PREVIEW}
+
+	c := &fakeAPIClient{}
+	s := NewStore(c, time.Second, zap.NewNop()).(*store)
+	err := s.reportCommandHandled(context.Background(), cmd, model.CommandStatus_COMMAND_SUCCEEDED, nil, []byte("small"))
+	require.NoError(t, err)
+	assert.Empty(t, c.chunks)
+	require.Equal(t, 1, len(c.handled))
+	assert.Equal(t, []byte("small"), c.handled[0].Output)
+
+	output := make([]byte, maxOutputChunkSize*2+1)
+	c = &fakeAPIClient{}
+	s = NewStore(c, time.Second, zap.NewNop()).(*store)
+	err = s.reportCommandHandled(context.Background(), cmd, model.CommandStatus_COMMAND_SUCCEEDED, nil, output)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(c.chunks))
+	assert.Equal(t, "cmd-1", c.chunks[0].CommandId)
+	assert.Empty(t, c.chunks[1].CommandId)
+	assert.Equal(t, 1, len(c.chunks[2].Data))
+	require.Equal(t, 1, len(c.handled))
+	assert.Empty(t, c.handled[0].Output)
+}
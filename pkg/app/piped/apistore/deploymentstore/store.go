@@ -16,6 +16,7 @@ package deploymentstore
 
 import (
 	"context"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -61,20 +62,35 @@ type store struct {
 	syncInterval       time.Duration
 	gracePeriod        time.Duration
 	logger             *zap.Logger
+
+	// The not completed deployments at the last sync.
+	deployments map[string]*model.Deployment
+	// The time reported by the control-plane at the last sync.
+	syncedAt int64
+	// The time when the full list of deployments was fetched at last.
+	lastFullSyncTime time.Time
+	fullSyncInterval time.Duration
+	nowFunc          func() time.Time
 }
 
 var (
-	defaultSyncInterval = 10 * time.Second
+	defaultSyncInterval     = 10 * time.Second
+	defaultFullSyncInterval = 10 * time.Minute
+	// deltaSyncMargin is subtracted from the last synced time while fetching the changes
+	// to not miss the deployments updated by a slow write or a skewed clock.
+	deltaSyncMargin = 30 * time.Second
 )
 
 // NewStore creates a new deployment store instance.
 // This syncs with the control plane to keep the list of deployments for this runner up-to-date.
 func NewStore(apiClient apiClient, gracePeriod time.Duration, logger *zap.Logger) Store {
 	return &store{
-		apiClient:    apiClient,
-		syncInterval: defaultSyncInterval,
-		gracePeriod:  gracePeriod,
-		logger:       logger.Named("deployment-store"),
+		apiClient:        apiClient,
+		syncInterval:     defaultSyncInterval,
+		gracePeriod:      gracePeriod,
+		logger:           logger.Named("deployment-store"),
+		fullSyncInterval: defaultFullSyncInterval,
+		nowFunc:          time.Now,
 	}
 }
 
@@ -102,16 +118,61 @@ func (s *store) Lister() Lister {
 	return s
 }
 
+// sync fetches only the deployments changed since the last sync
+// and merges them into the current snapshot.
+// The full list is fetched at the first time and periodically after that
+// to recover from any change missed by the delta syncs.
 func (s *store) sync(ctx context.Context) error {
-	resp, err := s.apiClient.ListNotCompletedDeployments(ctx, &pipedservice.ListNotCompletedDeploymentsRequest{})
+	now := s.nowFunc()
+	full := s.syncedAt == 0 || now.Sub(s.lastFullSyncTime) >= s.fullSyncInterval
+
+	req := &pipedservice.ListNotCompletedDeploymentsRequest{}
+	if !full {
+		req.UpdatedAfter = s.syncedAt - int64(deltaSyncMargin.Seconds())
+	}
+	resp, err := s.apiClient.ListNotCompletedDeployments(ctx, req)
 	if err != nil {
 		s.logger.Error("failed to list unhandled deployment", zap.Error(err))
 		return err
 	}
 
+	// The control-plane not supporting the delta sync always returns the full list.
+	if resp.SyncedAt == 0 {
+		full = true
+	}
+
 	// TODO: Call ListNotCompletedDeployments itervally until all required deployments are fetched.
-	var pendings, planneds, runnings []*model.Deployment
+	var deployments map[string]*model.Deployment
+	if full {
+		deployments = make(map[string]*model.Deployment, len(resp.Deployments))
+		s.lastFullSyncTime = now
+	} else {
+		deployments = make(map[string]*model.Deployment, len(s.deployments)+len(resp.Deployments))
+		for id, d := range s.deployments {
+			deployments[id] = d
+		}
+	}
 	for _, d := range resp.Deployments {
+		if model.IsCompletedDeployment(d.Status) {
+			delete(deployments, d.Id)
+			continue
+		}
+		deployments[d.Id] = d
+	}
+
+	list := make([]*model.Deployment, 0, len(deployments))
+	for _, d := range deployments {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt != list[j].CreatedAt {
+			return list[i].CreatedAt < list[j].CreatedAt
+		}
+		return list[i].Id < list[j].Id
+	})
+
+	var pendings, planneds, runnings []*model.Deployment
+	for _, d := range list {
 		switch d.Status {
 		case model.DeploymentStatus_DEPLOYMENT_PENDING:
 			pendings = append(pendings, d)
@@ -137,6 +198,8 @@ func (s *store) sync(ctx context.Context) error {
 	s.runningDeployments.Store(runnings)
 	s.pendingDeployments.Store(pendings)
 	s.headDeployments.Store(headDeployments)
+	s.deployments = deployments
+	s.syncedAt = resp.SyncedAt
 
 	return nil
 }
@@ -13,3 +13,88 @@
 // limitations under the License.
 
 package deploymentstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeAPIClient struct {
+	requests  []*pipedservice.ListNotCompletedDeploymentsRequest
+	responses []*pipedservice.ListNotCompletedDeploymentsResponse
+}
+
+func (c *fakeAPIClient) ListNotCompletedDeployments(_ context.Context, req *pipedservice.ListNotCompletedDeploymentsRequest, _ ...grpc.CallOption) (*pipedservice.ListNotCompletedDeploymentsResponse, error) {
+	c.requests = append(c.requests, req)
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	return resp, nil
+}
+
+func TestSync(t *testing.T) {
+	var (
+		pending = &model.Deployment{
+			Id:            "deployment-1",
+			ApplicationId: "app-1",
+			Status:        model.DeploymentStatus_DEPLOYMENT_PENDING,
+			CreatedAt:     100,
+		}
+		running = &model.Deployment{
+			Id:            "deployment-2",
+			ApplicationId: "app-2",
+			Status:        model.DeploymentStatus_DEPLOYMENT_RUNNING,
+			CreatedAt:     200,
+		}
+		planned = &model.Deployment{
+			Id:            "deployment-1",
+			ApplicationId: "app-1",
+			Status:        model.DeploymentStatus_DEPLOYMENT_PLANNED,
+			CreatedAt:     100,
+		}
+		completed = &model.Deployment{
+			Id:            "deployment-2",
+			ApplicationId: "app-2",
+			Status:        model.DeploymentStatus_DEPLOYMENT_SUCCESS,
+			CreatedAt:     200,
+		}
+	)
+	client := &fakeAPIClient{
+		responses: []*pipedservice.ListNotCompletedDeploymentsResponse{
+			{
+				Deployments: []*model.Deployment{running, pending},
+				SyncedAt:    1000,
+			},
+			{
+				Deployments: []*model.Deployment{planned, completed},
+				SyncedAt:    1010,
+			},
+		},
+	}
+	now := time.Unix(1000, 0)
+	s := NewStore(client, time.Second, zap.NewNop()).(*store)
+	s.nowFunc = func() time.Time { return now }
+
+	// The first sync fetches the full list.
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, int64(0), client.requests[0].UpdatedAfter)
+	assert.Equal(t, []*model.Deployment{pending}, s.ListPendings())
+	assert.Equal(t, []*model.Deployment{running}, s.ListRunnings())
+
+	// The next one fetches only the changes.
+	now = now.Add(10 * time.Second)
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, int64(970), client.requests[1].UpdatedAfter)
+	assert.Empty(t, s.ListPendings())
+	assert.Equal(t, []*model.Deployment{planned}, s.ListPlanneds())
+	assert.Empty(t, s.ListRunnings())
+	assert.Equal(t, map[string]*model.Deployment{"app-1": planned}, s.ListAppHeadDeployments())
+}
@@ -62,9 +62,7 @@ func (s *Store) Get(id string) (*model.Environment, bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.apiTimeout)
 	defer cancel()
 
-	resp, err := s.apiClient.GetEnvironment(ctx, &pipedservice.GetEnvironmentRequest{
-		Id: id,
-	})
+	e, err := s.GetLatest(ctx, id)
 	if err != nil {
 		s.logger.Warn("unable to get environment from control plane",
 			zap.String("env", id),
@@ -72,9 +70,24 @@ func (s *Store) Get(id string) (*model.Environment, bool) {
 		)
 		return nil, false
 	}
+	return e, true
+}
+
+// GetLatest retrieves the Environment for the given id from the control plane
+// without using the cached one. The cache is also updated with the retrieved one.
+func (s *Store) GetLatest(ctx context.Context, id string) (*model.Environment, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.apiTimeout)
+	defer cancel()
+
+	resp, err := s.apiClient.GetEnvironment(ctx, &pipedservice.GetEnvironmentRequest{
+		Id: id,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	if err := s.cache.Put(id, resp.Environment); err != nil {
 		s.logger.Warn("unable to put environment to cache", zap.Error(err))
 	}
-	return resp.Environment, true
+	return resp.Environment, nil
 }
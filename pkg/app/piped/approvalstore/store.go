@@ -0,0 +1,130 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package approvalstore provides a piped component
+// that keeps the decisions for WAIT_APPROVAL stages
+// made outside the web UI such as by the buttons of Slack notifications.
+package approvalstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Decision represents an approval or a rejection for a stage.
+type Decision struct {
+	// Whether the stage was approved or rejected.
+	Approved bool
+	// The PipeCD username who made this decision.
+	Commander string
+	// When this decision was made.
+	DecidedAt time.Time
+}
+
+type Store interface {
+	Run(ctx context.Context) error
+	// Put stores the decision for the given stage.
+	// The previous decision of that stage is overwritten.
+	Put(deploymentID, stageID string, d Decision)
+	Taker() Taker
+}
+
+// Taker helps take the decisions.
+type Taker interface {
+	// Take returns the decision for the given stage and removes it from the store.
+	Take(deploymentID, stageID string) (Decision, bool)
+}
+
+type store struct {
+	decisions map[string]Decision
+	mu        sync.Mutex
+	logger    *zap.Logger
+}
+
+var (
+	cleanInterval       = 10 * time.Minute
+	staleDecisionPeriod = time.Hour
+)
+
+// NewStore creates a new approval store instance.
+func NewStore(logger *zap.Logger) Store {
+	return &store{
+		decisions: make(map[string]Decision),
+		logger:    logger.Named("approval-store"),
+	}
+}
+
+// Run starts removing the decisions those were not taken for a long time,
+// e.g. the ones for already completed stages.
+func (s *store) Run(ctx context.Context) error {
+	s.logger.Info("start running approval store")
+
+	ticker := time.NewTicker(cleanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("approval store has been stopped")
+			return nil
+
+		case now := <-ticker.C:
+			s.removeStaleDecisions(now)
+		}
+	}
+}
+
+func (s *store) Put(deploymentID, stageID string, d Decision) {
+	if d.DecidedAt.IsZero() {
+		d.DecidedAt = time.Now()
+	}
+	s.mu.Lock()
+	s.decisions[decisionKey(deploymentID, stageID)] = d
+	s.mu.Unlock()
+}
+
+func (s *store) Taker() Taker {
+	return s
+}
+
+func (s *store) Take(deploymentID, stageID string) (Decision, bool) {
+	key := decisionKey(deploymentID, stageID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.decisions[key]
+	if ok {
+		delete(s.decisions, key)
+	}
+	return d, ok
+}
+
+func (s *store) removeStaleDecisions(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, d := range s.decisions {
+		if now.Sub(d.DecidedAt) > staleDecisionPeriod {
+			delete(s.decisions, k)
+		}
+	}
+}
+
+func decisionKey(deploymentID, stageID string) string {
+	return deploymentID + "/" + stageID
+}
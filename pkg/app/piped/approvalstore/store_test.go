@@ -0,0 +1,50 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approvalstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore(zap.NewNop()).(*store)
+	taker := s.Taker()
+
+	_, ok := taker.Take("deployment-1", "stage-1")
+	assert.False(t, ok)
+
+	s.Put("deployment-1", "stage-1", Decision{Approved: false, Commander: "alice"})
+	s.Put("deployment-1", "stage-1", Decision{Approved: true, Commander: "bob"})
+	s.Put("deployment-2", "stage-1", Decision{Approved: true, Commander: "carol"})
+
+	// The latest decision is returned and removed.
+	d, ok := taker.Take("deployment-1", "stage-1")
+	assert.True(t, ok)
+	assert.True(t, d.Approved)
+	assert.Equal(t, "bob", d.Commander)
+	assert.False(t, d.DecidedAt.IsZero())
+
+	_, ok = taker.Take("deployment-1", "stage-1")
+	assert.False(t, ok)
+
+	// Stale decisions are removed.
+	s.removeStaleDecisions(time.Now().Add(2 * staleDecisionPeriod))
+	_, ok = taker.Take("deployment-2", "stage-1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package factory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/changemanagement"
+	"github.com/pipe-cd/pipe/pkg/app/piped/changemanagement/servicenow"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// NewProvider generates an appropriate provider according to change management provider config.
+func NewProvider(providerCfg *config.PipedChangeManagementProvider, logger *zap.Logger) (changemanagement.Provider, error) {
+	switch providerCfg.Type {
+	case model.ChangeManagementProviderServiceNow:
+		cfg := providerCfg.ServiceNowConfig
+		username, err := ioutil.ReadFile(cfg.UsernameFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the username file: %w", err)
+		}
+		password, err := ioutil.ReadFile(cfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the password file: %w", err)
+		}
+		return servicenow.NewProvider(
+			cfg.Address,
+			strings.TrimSpace(string(username)),
+			strings.TrimSpace(string(password)),
+			servicenow.WithLogger(logger),
+		)
+	default:
+		return nil, fmt.Errorf("any of providers config not found")
+	}
+}
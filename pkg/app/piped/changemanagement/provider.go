@@ -0,0 +1,90 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package changemanagement provides a way to gate deployments
+// by the change requests managed in the external change management systems.
+package changemanagement
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Provider represents a client for the change management system.
+type Provider interface {
+	Type() string
+	// CreateChangeRequest creates a new change request for a deployment.
+	CreateChangeRequest(ctx context.Context, req CreateRequest) (*ChangeRequest, error)
+	// GetChangeRequest returns the change request with the given number.
+	GetChangeRequest(ctx context.Context, number string) (*ChangeRequest, error)
+}
+
+// CreateRequest contains the values used to create a change request.
+type CreateRequest struct {
+	ShortDescription string
+	Description      string
+	// The provider specific fields.
+	Fields map[string]string
+}
+
+// ApprovalStatus represents the normalized approval status of a change request.
+type ApprovalStatus int
+
+const (
+	ApprovalPending ApprovalStatus = iota
+	ApprovalApproved
+	ApprovalRejected
+)
+
+func (s ApprovalStatus) String() string {
+	switch s {
+	case ApprovalApproved:
+		return "approved"
+	case ApprovalRejected:
+		return "rejected"
+	default:
+		return "pending"
+	}
+}
+
+// ChangeRequest represents a change request in the change management system.
+type ChangeRequest struct {
+	Number string
+	// The link to show the change request.
+	URL    string
+	Status ApprovalStatus
+	// The planned window of the change.
+	// Zero means not specified.
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// Check reports whether the deployment can proceed at the given time.
+// A non-nil error is returned when the deployment can never proceed with this change request.
+func (c *ChangeRequest) Check(now time.Time) (bool, string, error) {
+	switch c.Status {
+	case ApprovalRejected:
+		return false, "", fmt.Errorf("change request %s was rejected", c.Number)
+	case ApprovalPending:
+		return false, fmt.Sprintf("change request %s is waiting for an approval", c.Number), nil
+	}
+	if !c.EndAt.IsZero() && !now.Before(c.EndAt) {
+		return false, "", fmt.Errorf("the window of change request %s was ended at %s", c.Number, c.EndAt.Format(time.RFC3339))
+	}
+	if !c.StartAt.IsZero() && now.Before(c.StartAt) {
+		return false, fmt.Sprintf("change request %s was approved but its window starts at %s", c.Number, c.StartAt.Format(time.RFC3339)), nil
+	}
+	return true, fmt.Sprintf("change request %s was approved and is within its window", c.Number), nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changemanagement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeRequestCheck(t *testing.T) {
+	now := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		name     string
+		cr       ChangeRequest
+		expected bool
+		wantErr  bool
+	}{
+		{
+			name:     "pending",
+			cr:       ChangeRequest{Number: "CHG1", Status: ApprovalPending},
+			expected: false,
+		},
+		{
+			name:    "rejected",
+			cr:      ChangeRequest{Number: "CHG1", Status: ApprovalRejected},
+			wantErr: true,
+		},
+		{
+			name:     "approved without window",
+			cr:       ChangeRequest{Number: "CHG1", Status: ApprovalApproved},
+			expected: true,
+		},
+		{
+			name: "approved within window",
+			cr: ChangeRequest{
+				Number:  "CHG1",
+				Status:  ApprovalApproved,
+				StartAt: now.Add(-time.Hour),
+				EndAt:   now.Add(time.Hour),
+			},
+			expected: true,
+		},
+		{
+			name: "approved before window",
+			cr: ChangeRequest{
+				Number:  "CHG1",
+				Status:  ApprovalApproved,
+				StartAt: now.Add(time.Hour),
+				EndAt:   now.Add(2 * time.Hour),
+			},
+			expected: false,
+		},
+		{
+			name: "approved after window",
+			cr: ChangeRequest{
+				Number:  "CHG1",
+				Status:  ApprovalApproved,
+				StartAt: now.Add(-2 * time.Hour),
+				EndAt:   now,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason, err := tc.cr.Check(now)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, got)
+			if err == nil {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
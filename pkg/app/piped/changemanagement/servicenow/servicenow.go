@@ -0,0 +1,225 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicenow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/changemanagement"
+)
+
+const (
+	ProviderType       = "ServiceNow"
+	defaultTimeout     = 30 * time.Second
+	changeRequestPath  = "/api/now/table/change_request"
+	changeRequestField = "number,sys_id,approval,state,start_date,end_date"
+	// The format of date-time fields returned by the Table API.
+	// They are in UTC since the display values are not requested.
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// The values of "state" field meaning the change request will never be implemented.
+var terminatedStates = map[string]struct{}{
+	"3": {}, // Closed
+	"4": {}, // Canceled
+}
+
+// Provider works as an HTTP client for ServiceNow Table API.
+type Provider struct {
+	client   *http.Client
+	address  string
+	username string
+	password string
+	timeout  time.Duration
+	logger   *zap.Logger
+}
+
+func NewProvider(address, username, password string, opts ...Option) (*Provider, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+
+	p := &Provider{
+		client:   &http.Client{},
+		address:  strings.TrimSuffix(address, "/"),
+		username: username,
+		password: password,
+		timeout:  defaultTimeout,
+		logger:   zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+type Option func(*Provider)
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("servicenow-provider")
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+type changeRequestRecord struct {
+	Number    string `json:"number"`
+	SysID     string `json:"sys_id"`
+	Approval  string `json:"approval"`
+	State     string `json:"state"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// CreateChangeRequest creates a new change request by the Table API.
+// See more: https://docs.servicenow.com/bundle/quebec-application-development/page/integrate/inbound-rest/concept/c_TableAPI.html
+func (p *Provider) CreateChangeRequest(ctx context.Context, req changemanagement.CreateRequest) (*changemanagement.ChangeRequest, error) {
+	fields := make(map[string]string, len(req.Fields)+2)
+	for k, v := range req.Fields {
+		fields[k] = v
+	}
+	fields["short_description"] = req.ShortDescription
+	fields["description"] = req.Description
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("sysparm_fields", changeRequestField)
+
+	var out struct {
+		Result changeRequestRecord `json:"result"`
+	}
+	if err := p.do(ctx, http.MethodPost, changeRequestPath+"?"+values.Encode(), body, &out); err != nil {
+		return nil, fmt.Errorf("failed to create change request in servicenow: %w", err)
+	}
+	p.logger.Info("created a change request", zap.String("number", out.Result.Number))
+	return p.toChangeRequest(out.Result)
+}
+
+// GetChangeRequest finds the change request with the given number by the Table API.
+func (p *Provider) GetChangeRequest(ctx context.Context, number string) (*changemanagement.ChangeRequest, error) {
+	values := url.Values{}
+	values.Set("sysparm_query", "number="+number)
+	values.Set("sysparm_fields", changeRequestField)
+	values.Set("sysparm_limit", "1")
+
+	var out struct {
+		Result []changeRequestRecord `json:"result"`
+	}
+	if err := p.do(ctx, http.MethodGet, changeRequestPath+"?"+values.Encode(), nil, &out); err != nil {
+		return nil, fmt.Errorf("failed to get change request from servicenow: %w", err)
+	}
+	if len(out.Result) == 0 {
+		return nil, fmt.Errorf("change request %s was not found in servicenow", number)
+	}
+	return p.toChangeRequest(out.Result[0])
+}
+
+func (p *Provider) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.address+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.username, p.password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected HTTP status code: %d, body: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (p *Provider) toChangeRequest(r changeRequestRecord) (*changemanagement.ChangeRequest, error) {
+	cr := &changemanagement.ChangeRequest{
+		Number: r.Number,
+		URL:    fmt.Sprintf("%s/nav_to.do?uri=change_request.do?sys_id=%s", p.address, r.SysID),
+	}
+	switch {
+	case r.Approval == "rejected":
+		cr.Status = changemanagement.ApprovalRejected
+	case isTerminated(r.State):
+		cr.Status = changemanagement.ApprovalRejected
+	case r.Approval == "approved":
+		cr.Status = changemanagement.ApprovalApproved
+	default:
+		cr.Status = changemanagement.ApprovalPending
+	}
+
+	var err error
+	if cr.StartAt, err = parseDateTime(r.StartDate); err != nil {
+		return nil, fmt.Errorf("invalid start_date of change request %s: %w", r.Number, err)
+	}
+	if cr.EndAt, err = parseDateTime(r.EndDate); err != nil {
+		return nil, fmt.Errorf("invalid end_date of change request %s: %w", r.Number, err)
+	}
+	return cr, nil
+}
+
+func isTerminated(state string) bool {
+	_, ok := terminatedStates[state]
+	return ok
+}
+
+func parseDateTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(dateTimeLayout, s, time.UTC)
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicenow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/changemanagement"
+)
+
+func TestGetChangeRequest(t *testing.T) {
+	testcases := []struct {
+		name     string
+		response string
+		status   int
+		expected *changemanagement.ChangeRequest
+		wantErr  bool
+	}{
+		{
+			name:     "approved",
+			response: `{"result":[{"number":"CHG0000001","sys_id":"abc","approval":"approved","state":"-1","start_date":"2021-03-01 10:00:00","end_date":"2021-03-01 12:00:00"}]}`,
+			status:   http.StatusOK,
+			expected: &changemanagement.ChangeRequest{
+				Number:  "CHG0000001",
+				URL:     "ADDRESS/nav_to.do?uri=change_request.do?sys_id=abc",
+				Status:  changemanagement.ApprovalApproved,
+				StartAt: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+				EndAt:   time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "requested without window",
+			response: `{"result":[{"number":"CHG0000001","sys_id":"abc","approval":"requested","state":"-4","start_date":"","end_date":""}]}`,
+			status:   http.StatusOK,
+			expected: &changemanagement.ChangeRequest{
+				Number: "CHG0000001",
+				URL:    "ADDRESS/nav_to.do?uri=change_request.do?sys_id=abc",
+				Status: changemanagement.ApprovalPending,
+			},
+		},
+		{
+			name:     "canceled",
+			response: `{"result":[{"number":"CHG0000001","sys_id":"abc","approval":"approved","state":"4"}]}`,
+			status:   http.StatusOK,
+			expected: &changemanagement.ChangeRequest{
+				Number: "CHG0000001",
+				URL:    "ADDRESS/nav_to.do?uri=change_request.do?sys_id=abc",
+				Status: changemanagement.ApprovalRejected,
+			},
+		},
+		{
+			name:     "not found",
+			response: `{"result":[]}`,
+			status:   http.StatusOK,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid date",
+			response: `{"result":[{"number":"CHG0000001","sys_id":"abc","approval":"approved","start_date":"tomorrow"}]}`,
+			status:   http.StatusOK,
+			wantErr:  true,
+		},
+		{
+			name:     "unauthorized",
+			response: `{"error":{"message":"User Not Authenticated"}}`,
+			status:   http.StatusUnauthorized,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, changeRequestPath, r.URL.Path)
+				assert.Equal(t, "number=CHG0000001", r.URL.Query().Get("sysparm_query"))
+				username, password, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "user", username)
+				assert.Equal(t, "pass", password)
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(server.URL, "user", "pass")
+			require.NoError(t, err)
+
+			got, err := p.GetChangeRequest(context.Background(), "CHG0000001")
+			assert.Equal(t, tc.wantErr, err != nil)
+			if tc.expected != nil {
+				tc.expected.URL = server.URL + tc.expected.URL[len("ADDRESS"):]
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestCreateChangeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, changeRequestPath, r.URL.Path)
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{
+			"short_description": "Deploy app to prod",
+			"description":       "desc",
+			"assignment_group":  "sre",
+		}, body)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"result":{"number":"CHG0000002","sys_id":"def","approval":"not requested","state":"-5"}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(server.URL+"/", "user", "pass")
+	require.NoError(t, err)
+
+	got, err := p.CreateChangeRequest(context.Background(), changemanagement.CreateRequest{
+		ShortDescription: "Deploy app to prod",
+		Description:      "desc",
+		Fields: map[string]string{
+			"assignment_group": "sre",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &changemanagement.ChangeRequest{
+		Number: "CHG0000002",
+		URL:    server.URL + "/nav_to.do?uri=change_request.do?sys_id=def",
+		Status: changemanagement.ApprovalPending,
+	}, got)
+}
@@ -18,15 +18,31 @@ package chartrepo
 import (
 	"context"
 	"fmt"
-	"os/exec"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
 	"github.com/pipe-cd/pipe/pkg/config"
 )
 
-var updateGroup = &singleflight.Group{}
+// OCIScheme is the prefix of the chart references stored in OCI registries.
+const OCIScheme = "oci://"
+
+var (
+	updateGroup = &singleflight.Group{}
+	loginGroup  = &singleflight.Group{}
+
+	// The registries given to Login. They are kept to log in again
+	// when the credentials were expired.
+	loginRegistries   []config.HelmChartRegistry
+	loginRegistriesMu sync.RWMutex
+)
 
 type registry interface {
 	Helm(ctx context.Context, version string) (string, bool, error)
@@ -47,7 +63,7 @@ func Add(ctx context.Context, repos []config.HelmChartRepository, reg registry,
 		if repo.Username != "" || repo.Password != "" {
 			args = append(args, "--username", repo.Username, "--password", repo.Password)
 		}
-		cmd := exec.CommandContext(ctx, helm, args...)
+		cmd := executil.CommandContext(ctx, helm, args...)
 		out, err := cmd.CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("failed to add chart repository %s: %s (%w)", repo.Name, string(out), err)
@@ -57,6 +73,96 @@ func Add(ctx context.Context, repos []config.HelmChartRepository, reg registry,
 	return nil
 }
 
+// Login logs in to all specified Helm chart registries.
+// The registries are remembered to be logged in again by Relogin.
+// https://helm.sh/docs/topics/registries/
+// helm registry login asia-northeast1-docker.pkg.dev --username my-username --password-stdin
+func Login(ctx context.Context, registries []config.HelmChartRegistry, reg registry, logger *zap.Logger) error {
+	loginRegistriesMu.Lock()
+	loginRegistries = registries
+	loginRegistriesMu.Unlock()
+
+	return login(ctx, registries, reg, logger)
+}
+
+// Relogin logs in again to the registries given to Login by using their latest credentials.
+// This should be called when the credentials may be expired,
+// e.g. the password was a short-lived token read from passwordFile.
+func Relogin(ctx context.Context, reg registry, logger *zap.Logger) error {
+	_, err, _ := loginGroup.Do("login", func() (interface{}, error) {
+		loginRegistriesMu.RLock()
+		registries := loginRegistries
+		loginRegistriesMu.RUnlock()
+
+		return nil, login(ctx, registries, reg, logger)
+	})
+	return err
+}
+
+func login(ctx context.Context, registries []config.HelmChartRegistry, reg registry, logger *zap.Logger) error {
+	if len(registries) == 0 {
+		return nil
+	}
+
+	helm, _, err := reg.Helm(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to find helm to login to registries (%w)", err)
+	}
+
+	for _, r := range registries {
+		if r.Type != config.OCIHelmChartRegistry {
+			return fmt.Errorf("unsupported chart registry type %s", r.Type)
+		}
+		password := r.Password
+		if r.PasswordFile != "" {
+			data, err := ioutil.ReadFile(r.PasswordFile)
+			if err != nil {
+				return fmt.Errorf("failed to read password file of chart registry %s (%w)", r.Address, err)
+			}
+			password = strings.TrimSpace(string(data))
+		}
+
+		args := []string{"registry", "login", r.Address, "--username", r.Username, "--password-stdin"}
+		cmd := executil.CommandContext(ctx, helm, args...)
+		cmd.Env = append(os.Environ(), OCIEnv()...)
+		cmd.Stdin = strings.NewReader(password)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to login to chart registry %s: %s (%w)", r.Address, string(out), err)
+		}
+		logger.Info(fmt.Sprintf("successfully logged in to chart registry: %s", r.Address))
+	}
+	return nil
+}
+
+// IsAuthError reports whether the given error of helm command
+// was caused by the rejected credentials of a registry.
+func IsAuthError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unauthorized") ||
+		strings.Contains(msg, "authentication required") ||
+		strings.Contains(msg, "denied")
+}
+
+// OCIEnv returns the environment variables required by helm commands
+// to handle the charts stored in OCI registries.
+// The location of the registry credentials is fixed since the default one
+// differs between helm versions, so that the charts can be loaded
+// by any helm version after logging in by the default one.
+func OCIEnv() []string {
+	env := []string{"HELM_EXPERIMENTAL_OCI=1"}
+	if home, err := os.UserHomeDir(); err == nil {
+		path := filepath.Join(home, ".config", "helm", "registry", "config.json")
+		env = append(env, "HELM_REGISTRY_CONFIG="+path)
+	}
+	return env
+}
+
+// IsOCI reports whether the given chart repository is an OCI registry reference.
+func IsOCI(repository string) bool {
+	return strings.HasPrefix(repository, OCIScheme)
+}
+
 func Update(ctx context.Context, reg registry, logger *zap.Logger) error {
 	_, err, _ := updateGroup.Do("update", func() (interface{}, error) {
 		return nil, update(ctx, reg, logger)
@@ -73,7 +179,7 @@ func update(ctx context.Context, reg registry, logger *zap.Logger) error {
 	}
 
 	args := []string{"repo", "update"}
-	cmd := exec.CommandContext(ctx, helm, args...)
+	cmd := executil.CommandContext(ctx, helm, args...)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to update Helm chart repositories: %s (%w)", string(out), err)
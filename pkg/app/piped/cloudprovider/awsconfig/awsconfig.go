@@ -0,0 +1,201 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package awsconfig provides a way to load the AWS config
+// shared by the AWS based cloud providers such as Lambda and ECS.
+package awsconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"golang.org/x/time/rate"
+)
+
+// ErrNotAllowed is returned when the target account or region is not allowed
+// by the policy configured for the cloud provider.
+var ErrNotAllowed = errors.New("not allowed by cloud provider policy")
+
+// Options specifies how to find the credentials for accessing AWS.
+type Options struct {
+	// The region to send requests to. This is required.
+	Region string
+	// AWS Profile to extract credentials from the shared credentials file.
+	Profile string
+	// Path to the shared credentials file.
+	CredentialsFile string
+	// The IAM role arn to use when assuming a role with the WebIdentity token.
+	RoleARN string
+	// Path to the WebIdentity token the SDK should use to assume a role with.
+	TokenFile string
+	// The IAM role arn to assume by using the credentials loaded from the above options.
+	// This enables role chaining, e.g. for deploying to another account.
+	AssumeRoleARN string
+	// The external ID to use when assuming the role specified by AssumeRoleARN.
+	ExternalID string
+	// The session tags to pass when assuming the role specified by AssumeRoleARN.
+	SessionTags map[string]string
+	// The limiter to wait for before sending each request.
+	// Nil means no limit.
+	RateLimiter *rate.Limiter
+	// List of account IDs those are allowed to be accessed.
+	// The account of the loaded credentials is verified when not empty.
+	AllowedAccounts []string
+	// List of regions those are allowed to be accessed.
+	// Empty means all regions are allowed.
+	AllowedRegions []string
+}
+
+// Load loads the AWS config based on the given options.
+func Load(ctx context.Context, opts Options) (aws.Config, error) {
+	if opts.Region == "" {
+		return aws.Config{}, fmt.Errorf("region is required field")
+	}
+	if len(opts.AllowedRegions) > 0 && !contains(opts.AllowedRegions, opts.Region) {
+		return aws.Config{}, fmt.Errorf("%w: region %q is not in the allowed regions [%s]", ErrNotAllowed, opts.Region, strings.Join(opts.AllowedRegions, ", "))
+	}
+
+	optFns := []func(*config.LoadOptions) error{config.WithRegion(opts.Region)}
+	if opts.CredentialsFile != "" {
+		optFns = append(optFns, config.WithSharedCredentialsFiles([]string{opts.CredentialsFile}))
+	}
+	if opts.Profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(opts.Profile))
+	}
+	if opts.TokenFile != "" && opts.RoleARN != "" {
+		optFns = append(optFns, config.WithWebIdentityRoleCredentialOptions(func(v *stscreds.WebIdentityRoleOptions) {
+			v.RoleARN = opts.RoleARN
+			v.TokenRetriever = stscreds.IdentityTokenFile(opts.TokenFile)
+		}))
+	}
+
+	// When you initialize an aws.Config instance using config.LoadDefaultConfig, the SDK uses its default credential chain to find AWS credentials.
+	// This default credential chain looks for credentials in the following order:
+	//
+	// 1. Environment variables.
+	//   1. Static Credentials (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN)
+	//   2. Web Identity Token (AWS_WEB_IDENTITY_TOKEN_FILE)
+	// 2. Shared configuration files.
+	//   1. SDK defaults to credentials file under .aws folder that is placed in the home folder on your computer.
+	//   2. SDK defaults to config file under .aws folder that is placed in the home folder on your computer.
+	// 3. If your application uses an ECS task definition or RunTask API operation, IAM role for tasks.
+	// 4. If your application is running on an Amazon EC2 instance, IAM role for Amazon EC2.
+	// ref: https://aws.github.io/aws-sdk-go-v2/docs/configuring-sdk/#specifying-credentials
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if opts.RateLimiter != nil {
+		cfg.HTTPClient = &rateLimitedHTTPClient{
+			HTTPClient: cfg.HTTPClient,
+			limiter:    opts.RateLimiter,
+		}
+	}
+	if opts.AssumeRoleARN != "" {
+		client := &sessionTagsClient{
+			AssumeRoleAPIClient: sts.NewFromConfig(cfg),
+			tags:                makeSessionTags(opts.SessionTags),
+		}
+		provider := stscreds.NewAssumeRoleProvider(client, opts.AssumeRoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if opts.ExternalID != "" {
+				o.ExternalID = aws.String(opts.ExternalID)
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	if len(opts.AllowedAccounts) > 0 {
+		if err := verifyAccount(ctx, sts.NewFromConfig(cfg), opts.AllowedAccounts); err != nil {
+			return aws.Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+type callerIdentityClient interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// verifyAccount checks whether the account of the loaded credentials is one of the allowed accounts.
+func verifyAccount(ctx context.Context, client callerIdentityClient, allowed []string) error {
+	out, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return fmt.Errorf("failed to get the account of the credentials: %w", err)
+	}
+	account := aws.ToString(out.Account)
+	if !contains(allowed, account) {
+		return fmt.Errorf("%w: account %q is not in the allowed accounts [%s]", ErrNotAllowed, account, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type rateLimitedHTTPClient struct {
+	aws.HTTPClient
+	limiter *rate.Limiter
+}
+
+func (c *rateLimitedHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// sessionTagsClient adds the session tags to every AssumeRole request
+// because stscreds.AssumeRoleOptions does not support them.
+type sessionTagsClient struct {
+	stscreds.AssumeRoleAPIClient
+	tags []types.Tag
+}
+
+func (c *sessionTagsClient) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	if len(c.tags) > 0 {
+		params.Tags = c.tags
+	}
+	return c.AssumeRoleAPIClient.AssumeRole(ctx, params, optFns...)
+}
+
+func makeSessionTags(tags map[string]string) []types.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		out = append(out, types.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return *out[i].Key < *out[j].Key
+	})
+	return out
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAssumeRoleClient struct {
+	input *sts.AssumeRoleInput
+}
+
+func (c *fakeAssumeRoleClient) AssumeRole(_ context.Context, params *sts.AssumeRoleInput, _ ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	c.input = params
+	return &sts.AssumeRoleOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("access-key-id"),
+			SecretAccessKey: aws.String("secret-access-key"),
+			SessionToken:    aws.String("session-token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	}, nil
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load(context.Background(), Options{})
+	assert.Error(t, err)
+
+	cfg, err := Load(context.Background(), Options{Region: "us-west-2"})
+	require.NoError(t, err)
+	assert.Equal(t, "us-west-2", cfg.Region)
+}
+
+func TestLoadNotAllowedRegion(t *testing.T) {
+	_, err := Load(context.Background(), Options{
+		Region:         "us-west-2",
+		AllowedRegions: []string{"ap-northeast-1"},
+	})
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+}
+
+type fakeCallerIdentityClient struct {
+	account string
+}
+
+func (c *fakeCallerIdentityClient) GetCallerIdentity(_ context.Context, _ *sts.GetCallerIdentityInput, _ ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(c.account),
+	}, nil
+}
+
+func TestVerifyAccount(t *testing.T) {
+	testcases := []struct {
+		name    string
+		account string
+		wantErr bool
+	}{
+		{
+			name:    "allowed account",
+			account: "123456789012",
+			wantErr: false,
+		},
+		{
+			name:    "not allowed account",
+			account: "210987654321",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyAccount(context.Background(), &fakeCallerIdentityClient{account: tc.account}, []string{"123456789012"})
+			assert.Equal(t, tc.wantErr, err != nil)
+			if tc.wantErr {
+				assert.True(t, errors.Is(err, ErrNotAllowed))
+			}
+		})
+	}
+}
+
+func TestSessionTagsClient(t *testing.T) {
+	fake := &fakeAssumeRoleClient{}
+	client := &sessionTagsClient{
+		AssumeRoleAPIClient: fake,
+		tags: makeSessionTags(map[string]string{
+			"team":    "pipecd",
+			"project": "demo",
+		}),
+	}
+	provider := stscreds.NewAssumeRoleProvider(client, "arn:aws:iam::123456789012:role/deployer", func(o *stscreds.AssumeRoleOptions) {
+		o.ExternalID = aws.String("external-id")
+	})
+
+	creds, err := provider.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-key-id", creds.AccessKeyID)
+
+	require.NotNil(t, fake.input)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/deployer", *fake.input.RoleArn)
+	assert.Equal(t, "external-id", *fake.input.ExternalId)
+	expected := []types.Tag{
+		{Key: aws.String("project"), Value: aws.String("demo")},
+		{Key: aws.String("team"), Value: aws.String("pipecd")},
+	}
+	assert.Equal(t, expected, fake.input.Tags)
+}
@@ -17,14 +17,18 @@ package cloudrun
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	"google.golang.org/api/run/v1"
+	htransport "google.golang.org/api/transport/http"
 	"sigs.k8s.io/yaml"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/ratelimiter"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
 )
 
 type client struct {
@@ -34,20 +38,27 @@ type client struct {
 	logger    *zap.Logger
 }
 
-func newClient(ctx context.Context, projectID, region, credentialsFile string, logger *zap.Logger) (*client, error) {
+func newClient(ctx context.Context, projectID, region string, creds gcpcredentials.Options, limiter *rate.Limiter, logger *zap.Logger) (*client, error) {
 	c := &client{
 		projectID: projectID,
 		region:    region,
 		logger:    logger.Named("cloudrun"),
 	}
 
-	var options []option.ClientOption
-	if len(credentialsFile) > 0 {
-		data, err := ioutil.ReadFile(credentialsFile)
+	options, err := gcpcredentials.ClientOptions(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	if limiter != nil {
+		options = append(options, option.WithScopes(run.CloudPlatformScope))
+		base := ratelimiter.NewTransport(http.DefaultTransport, limiter)
+		transport, err := htransport.NewTransport(ctx, base, options...)
 		if err != nil {
-			return nil, fmt.Errorf("unable to read credentials file (%w)", err)
+			return nil, err
+		}
+		options = []option.ClientOption{
+			option.WithHTTPClient(&http.Client{Transport: transport}),
 		}
-		options = append(options, option.WithCredentialsJSON(data))
 	}
 	options = append(options,
 		option.WithEndpoint(fmt.Sprintf("https://%s-run.googleapis.com/", region)),
@@ -17,14 +17,18 @@ package cloudrun
 import (
 	"context"
 	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 	"google.golang.org/api/run/v1"
 
+	"github.com/pipe-cd/pipe/pkg/app/piped/ratelimiter"
 	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
 )
 
 const (
@@ -33,6 +37,9 @@ const (
 
 var (
 	ErrServiceNotFound = errors.New("not found")
+	// ErrNotAllowed is returned when the target project is not allowed
+	// by the policy configured for the cloud provider.
+	ErrNotAllowed = errors.New("not allowed by cloud provider policy")
 )
 
 type Service run.Service
@@ -77,8 +84,15 @@ func (r *registry) Client(ctx context.Context, name string, cfg *config.CloudPro
 		return client, nil
 	}
 
+	if err := checkAllowedProject(cfg); err != nil {
+		return nil, err
+	}
+
 	c, err, _ := r.newGroup.Do(name, func() (interface{}, error) {
-		return newClient(ctx, cfg.Project, cfg.Region, cfg.CredentialsFile, logger)
+		return newClient(ctx, cfg.Project, cfg.Region, gcpcredentials.Options{
+			CredentialsFile:           cfg.CredentialsFile,
+			ImpersonateServiceAccount: cfg.ImpersonateServiceAccount,
+		}, ratelimiter.DefaultRegistry().Limiter(name), logger)
 	})
 	if err != nil {
 		return nil, err
@@ -91,3 +105,16 @@ func (r *registry) Client(ctx context.Context, name string, cfg *config.CloudPro
 
 	return client, nil
 }
+
+// checkAllowedProject checks whether the configured project is allowed to be deployed to.
+func checkAllowedProject(cfg *config.CloudProviderCloudRunConfig) error {
+	if len(cfg.AllowedProjects) == 0 {
+		return nil
+	}
+	for _, p := range cfg.AllowedProjects {
+		if p == cfg.Project {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: project %q is not in the allowed projects [%s]", ErrNotAllowed, cfg.Project, strings.Join(cfg.AllowedProjects, ", "))
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudrun
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestCheckAllowedProject(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     config.CloudProviderCloudRunConfig
+		wantErr bool
+	}{
+		{
+			name:    "no allowlist",
+			cfg:     config.CloudProviderCloudRunConfig{Project: "project"},
+			wantErr: false,
+		},
+		{
+			name: "allowed project",
+			cfg: config.CloudProviderCloudRunConfig{
+				Project:         "project",
+				AllowedProjects: []string{"other", "project"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "not allowed project",
+			cfg: config.CloudProviderCloudRunConfig{
+				Project:         "project",
+				AllowedProjects: []string{"other"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkAllowedProject(&tc.cfg)
+			assert.Equal(t, tc.wantErr, err != nil)
+			if tc.wantErr {
+				assert.True(t, errors.Is(err, ErrNotAllowed))
+			}
+		})
+	}
+}
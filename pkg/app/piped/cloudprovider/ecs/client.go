@@ -20,11 +20,11 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/awsconfig"
 )
 
 type client struct {
@@ -33,30 +33,12 @@ type client struct {
 	logger *zap.Logger
 }
 
-func newClient(region, profile, credentialsFile, roleARN, tokenPath string, logger *zap.Logger) (Client, error) {
-	if region == "" {
-		return nil, fmt.Errorf("region is required field")
-	}
-
+func newClient(opts awsconfig.Options, logger *zap.Logger) (Client, error) {
 	c := &client{
 		logger: logger.Named("ecs"),
 	}
 
-	optFns := []func(*config.LoadOptions) error{config.WithRegion(region)}
-	if credentialsFile != "" {
-		optFns = append(optFns, config.WithSharedCredentialsFiles([]string{credentialsFile}))
-	}
-	if profile != "" {
-		optFns = append(optFns, config.WithSharedConfigProfile(profile))
-	}
-	if tokenPath != "" && roleARN != "" {
-		optFns = append(optFns, config.WithWebIdentityRoleCredentialOptions(func(v *stscreds.WebIdentityRoleOptions) {
-			v.RoleARN = roleARN
-			v.TokenRetriever = stscreds.IdentityTokenFile(tokenPath)
-		}))
-	}
-
-	cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+	cfg, err := awsconfig.Load(context.Background(), opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config to create ecs client: %w", err)
 	}
@@ -0,0 +1,115 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotAllowed is returned when the target is not allowed by the policy
+// configured for the cloud provider.
+var ErrNotAllowed = errors.New("not allowed by cloud provider policy")
+
+// clusterScopedKinds is the list of well-known kinds those are not namespaced.
+var clusterScopedKinds = map[string]struct{}{
+	KindClusterRole:                  {},
+	KindClusterRoleBinding:           {},
+	KindPersistentVolume:             {},
+	KindCustomResourceDefinition:     {},
+	"StorageClass":                   {},
+	"PriorityClass":                  {},
+	"IngressClass":                   {},
+	"RuntimeClass":                   {},
+	"APIService":                     {},
+	"MutatingWebhookConfiguration":   {},
+	"ValidatingWebhookConfiguration": {},
+}
+
+// WithAllowedNamespaces returns a Provider that refuses to apply or delete
+// the resources outside the given namespaces.
+// The namespace is used for all resources instead of their own one when it is not empty.
+// Well-known cluster-scoped resources are also refused unless allowClusterScoped is true,
+// since they could affect all namespaces.
+// The given provider is returned as is when no namespace was given.
+func WithAllowedNamespaces(p Provider, namespace string, allowed []string, allowClusterScoped bool) Provider {
+	if len(allowed) == 0 {
+		return p
+	}
+	m := make(map[string]struct{}, len(allowed))
+	for _, ns := range allowed {
+		m[ns] = struct{}{}
+	}
+	return &namespaceRestrictedProvider{
+		Provider:           p,
+		namespace:          namespace,
+		allowed:            m,
+		allowClusterScoped: allowClusterScoped,
+		list:               strings.Join(allowed, ", "),
+	}
+}
+
+type namespaceRestrictedProvider struct {
+	Provider
+	namespace          string
+	allowed            map[string]struct{}
+	allowClusterScoped bool
+	list               string
+}
+
+func (p *namespaceRestrictedProvider) Apply(ctx context.Context, manifests []Manifest) (string, error) {
+	for _, m := range manifests {
+		if err := p.check(m.Key); err != nil {
+			return "", err
+		}
+	}
+	return p.Provider.Apply(ctx, manifests)
+}
+
+func (p *namespaceRestrictedProvider) ApplyManifest(ctx context.Context, manifest Manifest) error {
+	if err := p.check(manifest.Key); err != nil {
+		return err
+	}
+	return p.Provider.ApplyManifest(ctx, manifest)
+}
+
+func (p *namespaceRestrictedProvider) Delete(ctx context.Context, key ResourceKey) error {
+	if err := p.check(key); err != nil {
+		return err
+	}
+	return p.Provider.Delete(ctx, key)
+}
+
+func (p *namespaceRestrictedProvider) check(key ResourceKey) error {
+	if _, ok := clusterScopedKinds[key.Kind]; ok {
+		if p.allowClusterScoped {
+			return nil
+		}
+		return fmt.Errorf("%w: cluster-scoped resource %s is not allowed while the namespaces are restricted to [%s]", ErrNotAllowed, key.ReadableString(), p.list)
+	}
+	// Creating or deleting a namespace is treated as touching that namespace.
+	ns := key.Namespace
+	if key.Kind == KindNamespace {
+		ns = key.Name
+	} else if p.namespace != "" {
+		ns = p.namespace
+	}
+	if _, ok := p.allowed[ns]; ok {
+		return nil
+	}
+	return fmt.Errorf("%w: namespace %q of resource %s is not in the allowed namespaces [%s]", ErrNotAllowed, ns, key.ReadableString(), p.list)
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNamespacedApplier struct {
+	Provider
+	applied []ResourceKey
+}
+
+func (p *fakeNamespacedApplier) ApplyManifest(_ context.Context, m Manifest) error {
+	p.applied = append(p.applied, m.Key)
+	return nil
+}
+
+func (p *fakeNamespacedApplier) Apply(_ context.Context, ms []Manifest) (string, error) {
+	for _, m := range ms {
+		p.applied = append(p.applied, m.Key)
+	}
+	return "", nil
+}
+
+func TestWithAllowedNamespaces(t *testing.T) {
+	p := &fakeNamespacedApplier{}
+	assert.Equal(t, Provider(p), WithAllowedNamespaces(p, "", nil, false))
+
+	testcases := []struct {
+		name               string
+		namespace          string
+		allowClusterScoped bool
+		key                ResourceKey
+		wantErr            bool
+	}{
+		{
+			name:    "allowed namespace",
+			key:     ResourceKey{Kind: KindDeployment, Namespace: "team-a", Name: "app"},
+			wantErr: false,
+		},
+		{
+			name:    "not allowed namespace",
+			key:     ResourceKey{Kind: KindDeployment, Namespace: "kube-system", Name: "app"},
+			wantErr: true,
+		},
+		{
+			name:      "namespace is overridden by input",
+			namespace: "team-b",
+			key:       ResourceKey{Kind: KindDeployment, Namespace: "kube-system", Name: "app"},
+			wantErr:   false,
+		},
+		{
+			name:    "allowed namespace resource",
+			key:     ResourceKey{Kind: KindNamespace, Namespace: DefaultNamespace, Name: "team-a"},
+			wantErr: false,
+		},
+		{
+			name:    "not allowed namespace resource",
+			key:     ResourceKey{Kind: KindNamespace, Namespace: DefaultNamespace, Name: "kube-system"},
+			wantErr: true,
+		},
+		{
+			name:    "cluster scoped resource",
+			key:     ResourceKey{Kind: KindClusterRole, Namespace: DefaultNamespace, Name: "role"},
+			wantErr: true,
+		},
+		{
+			name:    "cluster scoped webhook",
+			key:     ResourceKey{Kind: "MutatingWebhookConfiguration", Namespace: DefaultNamespace, Name: "webhook"},
+			wantErr: true,
+		},
+		{
+			name:               "allowed cluster scoped resource",
+			allowClusterScoped: true,
+			key:                ResourceKey{Kind: KindClusterRoleBinding, Namespace: DefaultNamespace, Name: "binding"},
+			wantErr:            false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			restricted := WithAllowedNamespaces(p, tc.namespace, []string{"team-a", "team-b"}, tc.allowClusterScoped)
+			err := restricted.ApplyManifest(context.Background(), Manifest{Key: tc.key})
+			assert.Equal(t, tc.wantErr, err != nil)
+			if tc.wantErr {
+				assert.True(t, errors.Is(err, ErrNotAllowed))
+			}
+		})
+	}
+}
+
+func TestWithAllowedNamespacesApply(t *testing.T) {
+	p := &fakeNamespacedApplier{}
+	restricted := WithAllowedNamespaces(p, "", []string{"team-a"}, false)
+
+	_, err := restricted.Apply(context.Background(), []Manifest{
+		{Key: ResourceKey{Kind: KindDeployment, Namespace: "team-a", Name: "app"}},
+		{Key: ResourceKey{Kind: KindService, Namespace: "kube-system", Name: "app"}},
+	})
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	assert.Empty(t, p.applied)
+
+	_, err = restricted.Apply(context.Background(), []Manifest{
+		{Key: ResourceKey{Kind: KindDeployment, Namespace: "team-a", Name: "app"}},
+		{Key: ResourceKey{Kind: KindService, Namespace: "team-a", Name: "app"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(p.applied))
+}
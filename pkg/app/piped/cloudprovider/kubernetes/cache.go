@@ -23,6 +23,30 @@ import (
 	"github.com/pipe-cd/pipe/pkg/cache"
 )
 
+// cachedManifests holds the manifests with their size
+// to let the cache bounded by bytes know how large they are.
+type cachedManifests struct {
+	manifests []Manifest
+	size      int64
+}
+
+func newCachedManifests(manifests []Manifest) *cachedManifests {
+	var size int64
+	for _, m := range manifests {
+		if data, err := m.MarshalJSON(); err == nil {
+			size += int64(len(data))
+		}
+	}
+	return &cachedManifests{
+		manifests: manifests,
+		size:      size,
+	}
+}
+
+func (c *cachedManifests) Size() int64 {
+	return c.size
+}
+
 type AppManifestsCache struct {
 	AppID  string
 	Cache  cache.Cache
@@ -33,7 +57,7 @@ func (c AppManifestsCache) Get(commit string) ([]Manifest, bool) {
 	key := appManifestsCacheKey(c.AppID, commit)
 	item, err := c.Cache.Get(key)
 	if err == nil {
-		return item.([]Manifest), true
+		return item.(*cachedManifests).manifests, true
 	}
 
 	if errors.Is(err, cache.ErrNotFound) {
@@ -54,7 +78,7 @@ func (c AppManifestsCache) Get(commit string) ([]Manifest, bool) {
 
 func (c AppManifestsCache) Put(commit string, manifests []Manifest) {
 	key := appManifestsCacheKey(c.AppID, commit)
-	if err := c.Cache.Put(key, manifests); err != nil {
+	if err := c.Cache.Put(key, newCachedManifests(manifests)); err != nil {
 		c.Logger.Error("failed while putting app manifests from cache",
 			zap.String("app-id", c.AppID),
 			zap.String("commit-hash", commit),
@@ -63,6 +87,40 @@ func (c AppManifestsCache) Put(commit string, manifests []Manifest) {
 	}
 }
 
+// GetOrLoad returns the cached manifests at the given commit or loads them by the given function.
+// When the underlying cache supports, the concurrent loads of the same manifests are de-duplicated.
+func (c AppManifestsCache) GetOrLoad(commit string, load func() ([]Manifest, error)) ([]Manifest, error) {
+	loader, ok := c.Cache.(cache.Loader)
+	if !ok {
+		if manifests, ok := c.Get(commit); ok {
+			return manifests, nil
+		}
+		manifests, err := load()
+		if err != nil {
+			return nil, err
+		}
+		c.Put(commit, manifests)
+		return manifests, nil
+	}
+
+	key := appManifestsCacheKey(c.AppID, commit)
+	item, err := loader.GetOrLoad(key, func() (interface{}, error) {
+		c.Logger.Info("app manifests were not found in cache",
+			zap.String("app-id", c.AppID),
+			zap.String("commit-hash", commit),
+		)
+		manifests, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return newCachedManifests(manifests), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item.(*cachedManifests).manifests, nil
+}
+
 func appManifestsCacheKey(appID, commit string) string {
 	return fmt.Sprintf("%s/%s", appID, commit)
 }
@@ -0,0 +1,81 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
+)
+
+const awsIAMAuthenticatorPlugin = "aws-iam-authenticator"
+
+// EnsureCredentialPlugins makes sure that all exec credential plugins
+// (e.g. aws-iam-authenticator, gke-gcloud-auth-plugin) used by the given kubeconfig are executable.
+// The plugins which can be managed by the tool registry will be installed into its binary directory,
+// otherwise they must be pre-installed into that directory or the PATH.
+// An empty kubeConfigPath means the default loading rules of kubectl are used.
+//
+// Both kubectl and client-go execute the plugin again to refresh the credentials
+// when the cached one was expired or rejected, so no additional handling
+// is needed for the token refresh during long deployments.
+func EnsureCredentialPlugins(ctx context.Context, kubeConfigPath string, reg toolregistry.Registry, logger *zap.Logger) error {
+	cfg, err := loadKubeConfig(kubeConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	for user, info := range cfg.AuthInfos {
+		if info.Exec == nil {
+			continue
+		}
+		if err := ensureCredentialPlugin(ctx, info.Exec.Command, reg); err != nil {
+			return fmt.Errorf("credential plugin of user %s is not available: %w", user, err)
+		}
+		logger.Info("credential plugin is available",
+			zap.String("user", user),
+			zap.String("command", info.Exec.Command),
+		)
+	}
+	return nil
+}
+
+func loadKubeConfig(path string) (*clientcmdapi.Config, error) {
+	if path != "" {
+		return clientcmd.LoadFromFile(path)
+	}
+	return clientcmd.NewDefaultClientConfigLoadingRules().Load()
+}
+
+func ensureCredentialPlugin(ctx context.Context, command string, reg toolregistry.Registry) error {
+	if _, err := exec.LookPath(command); err == nil {
+		return nil
+	}
+
+	switch filepath.Base(command) {
+	case awsIAMAuthenticatorPlugin:
+		_, _, err := reg.AWSIAMAuthenticator(ctx, "")
+		return err
+	default:
+		return fmt.Errorf("%s was not found, please pre-install it into the tools directory", command)
+	}
+}
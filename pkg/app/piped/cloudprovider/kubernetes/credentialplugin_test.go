@@ -0,0 +1,71 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
+)
+
+type fakeRegistry struct {
+	toolregistry.Registry
+	installed []string
+}
+
+func (r *fakeRegistry) AWSIAMAuthenticator(_ context.Context, _ string) (string, bool, error) {
+	r.installed = append(r.installed, awsIAMAuthenticatorPlugin)
+	return awsIAMAuthenticatorPlugin, true, nil
+}
+
+func TestEnsureCredentialPlugins(t *testing.T) {
+	testcases := []struct {
+		name          string
+		kubeConfig    string
+		expectedTools []string
+		expectedErr   string
+	}{
+		{
+			name:       "no exec plugin",
+			kubeConfig: "testdata/kubeconfig/token.yaml",
+		},
+		{
+			name:          "installable exec plugin",
+			kubeConfig:    "testdata/kubeconfig/aws-iam-authenticator.yaml",
+			expectedTools: []string{awsIAMAuthenticatorPlugin},
+		},
+		{
+			name:        "unknown exec plugin",
+			kubeConfig:  "testdata/kubeconfig/unknown-plugin.yaml",
+			expectedErr: "credential plugin of user unknown is not available: unknown-auth-plugin was not found, please pre-install it into the tools directory",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := &fakeRegistry{}
+			err := EnsureCredentialPlugins(context.Background(), tc.kubeConfig, reg, zap.NewNop())
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedTools, reg.installed)
+		})
+	}
+}
@@ -20,15 +20,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/chartrepo"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
 	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
 	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/git"
 )
 
 type Helm struct {
@@ -62,17 +64,10 @@ func (c *Helm) TemplateLocalChart(ctx context.Context, appName, appDir, namespac
 		args = append(args, fmt.Sprintf("--namespace=%s", namespace))
 	}
 
-	if opts != nil {
-		for _, v := range opts.ValueFiles {
-			args = append(args, "-f", v)
-		}
-		for k, v := range opts.SetFiles {
-			args = append(args, "--set-file", fmt.Sprintf("%s=%s", k, v))
-		}
-	}
+	args = append(args, makeHelmOptionArgs(appDir, opts)...)
 
 	var stdout, stderr bytes.Buffer
-	cmd := exec.CommandContext(ctx, c.execPath, args...)
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
 	cmd.Dir = appDir
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -133,7 +128,7 @@ func (c *Helm) TemplateRemoteChart(ctx context.Context, appName, appDir, namespa
 		"template",
 		"--no-hooks",
 		releaseName,
-		fmt.Sprintf("%s/%s", chart.Repository, chart.Name),
+		fmt.Sprintf("%s/%s", strings.TrimSuffix(chart.Repository, "/"), chart.Name),
 		fmt.Sprintf("--version=%s", chart.Version),
 	}
 
@@ -141,23 +136,21 @@ func (c *Helm) TemplateRemoteChart(ctx context.Context, appName, appDir, namespa
 		args = append(args, fmt.Sprintf("--namespace=%s", namespace))
 	}
 
-	if opts != nil {
-		for _, v := range opts.ValueFiles {
-			args = append(args, "-f", v)
-		}
-		for k, v := range opts.SetFiles {
-			args = append(args, "--set-file", fmt.Sprintf("%s=%s", k, v))
-		}
-	}
+	args = append(args, makeHelmOptionArgs(appDir, opts)...)
 
+	isOCI := chartrepo.IsOCI(chart.Repository)
 	c.logger.Info(fmt.Sprintf("start templating a chart from Helm repository for application %s", appName),
 		zap.Any("args", args),
+		zap.Bool("oci", isOCI),
 	)
 
 	executor := func() (string, error) {
 		var stdout, stderr bytes.Buffer
-		cmd := exec.CommandContext(ctx, c.execPath, args...)
+		cmd := executil.CommandContext(ctx, c.execPath, args...)
 		cmd.Dir = appDir
+		if isOCI {
+			cmd.Env = append(os.Environ(), chartrepo.OCIEnv()...)
+		}
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
 
@@ -172,6 +165,21 @@ func (c *Helm) TemplateRemoteChart(ctx context.Context, appName, appDir, namespa
 		return out, nil
 	}
 
+	// The charts stored in OCI registries are pulled directly
+	// so there is no repository index to be updated.
+	// Instead, the credentials such as short-lived tokens may be expired,
+	// so we log in again with the latest ones and try again.
+	if isOCI {
+		if !chartrepo.IsAuthError(err) {
+			return "", err
+		}
+		if e := chartrepo.Relogin(ctx, toolregistry.DefaultRegistry(), c.logger); e != nil {
+			c.logger.Error("failed to log in to Helm chart registries again", zap.Error(e))
+			return "", err
+		}
+		return executor()
+	}
+
 	if !strings.Contains(err.Error(), "helm repo update") {
 		return "", err
 	}
@@ -183,3 +191,119 @@ func (c *Helm) TemplateRemoteChart(ctx context.Context, appName, appDir, namespa
 	}
 	return executor()
 }
+
+// makeHelmOptionArgs returns the arguments of helm template command for the given options.
+func makeHelmOptionArgs(appDir string, opts *config.InputHelmOptions) []string {
+	if opts == nil {
+		return nil
+	}
+	var args []string
+	for _, v := range opts.ValueFiles {
+		args = append(args, "-f", v)
+	}
+	keys := make([]string, 0, len(opts.SetFiles))
+	for k := range opts.SetFiles {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--set-file", fmt.Sprintf("%s=%s", k, opts.SetFiles[k]))
+	}
+	if r := opts.PostRenderer; r != nil {
+		// The executable is always resolved under the application directory
+		// so that no executable outside of it can be specified.
+		args = append(args, "--post-renderer", filepath.Join(appDir, r.Path))
+		for _, a := range r.Args {
+			args = append(args, "--post-renderer-args", a)
+		}
+	}
+	return args
+}
+
+// prepareExternalValueFiles returns the local paths of the given external value files.
+// The ones placing in remote git repositories are downloaded into a temporary directory
+// which should be removed by calling the returned cleanup function.
+// Those remote repositories must be registered to the piped.
+func prepareExternalValueFiles(ctx context.Context, repoDir string, files []config.InputHelmValueFile, repos []config.PipedRepository, gitClient gitClient) (paths []string, cleanup func(), err error) {
+	var dirs []string
+	cleanup = func() {
+		for _, d := range dirs {
+			os.RemoveAll(d)
+		}
+	}
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	// The directories of cloned remote repositories keyed by remote and ref.
+	clonedDirs := make(map[string]string)
+	paths = make([]string, 0, len(files))
+
+	for _, f := range files {
+		dir := repoDir
+		if f.GitRemote != "" {
+			r, ok := findRepositoryByRemote(repos, f.GitRemote)
+			if !ok {
+				err = fmt.Errorf("git remote %s of value file %s is not registered in the piped configuration", f.GitRemote, f.Path)
+				return
+			}
+			key := f.GitRemote + "@" + f.Ref
+			if d, ok := clonedDirs[key]; ok {
+				dir = d
+			} else {
+				if dir, err = ioutil.TempDir("", "helm-remote-values"); err != nil {
+					err = fmt.Errorf("unable to create temporary directory for storing remote value file: %w", err)
+					return
+				}
+				dirs = append(dirs, dir)
+
+				var repo git.Repo
+				if repo, err = gitClient.Clone(ctx, r.RepoID, r.Remote, r.Branch, dir); err != nil {
+					err = fmt.Errorf("unable to clone git repository containing remote value file: %w", err)
+					return
+				}
+				if f.Ref != "" {
+					if err = repo.Checkout(ctx, f.Ref); err != nil {
+						err = fmt.Errorf("unable to checkout to specified ref %s: %w", f.Ref, err)
+						return
+					}
+				}
+				clonedDirs[key] = dir
+			}
+		}
+
+		path := filepath.Join(dir, f.Path)
+		if rel, e := filepath.Rel(dir, path); e != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			err = fmt.Errorf("value file %s must be placed inside its repository", f.Path)
+			return
+		}
+		paths = append(paths, path)
+	}
+	return
+}
+
+// findRepositoryByRemote finds a repository with the given remote address from the given list.
+func findRepositoryByRemote(repos []config.PipedRepository, remote string) (config.PipedRepository, bool) {
+	for _, r := range repos {
+		if r.Remote == remote {
+			return r, true
+		}
+	}
+	return config.PipedRepository{}, false
+}
+
+// withValueFiles returns a copy of the given options
+// with the given value files prepended to its value files.
+func withValueFiles(opts *config.InputHelmOptions, files []string) *config.InputHelmOptions {
+	if len(files) == 0 {
+		return opts
+	}
+	var out config.InputHelmOptions
+	if opts != nil {
+		out = *opts
+	}
+	out.ValueFiles = append(append([]string{}, files...), out.ValueFiles...)
+	return &out
+}
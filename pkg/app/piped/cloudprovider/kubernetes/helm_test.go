@@ -24,6 +24,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
+	"github.com/pipe-cd/pipe/pkg/config"
 )
 
 func TestTemplateLocalChart(t *testing.T) {
@@ -73,3 +74,98 @@ func TestTemplateLocalChart_WithNamespace(t *testing.T) {
 		require.Equal(t, namespace, metadata["namespace"])
 	}
 }
+
+func TestMakeHelmOptionArgs(t *testing.T) {
+	testcases := []struct {
+		name     string
+		opts     *config.InputHelmOptions
+		expected []string
+	}{
+		{
+			name: "nil options",
+		},
+		{
+			name: "value files and set files",
+			opts: &config.InputHelmOptions{
+				ValueFiles: []string{"values.yaml", "secrets/values.yaml"},
+				SetFiles: map[string]string{
+					"tls.key": "secrets/tls.key",
+					"config":  "config.yaml",
+				},
+			},
+			expected: []string{
+				"-f", "values.yaml",
+				"-f", "secrets/values.yaml",
+				"--set-file", "config=config.yaml",
+				"--set-file", "tls.key=secrets/tls.key",
+			},
+		},
+		{
+			name: "post renderer in application directory",
+			opts: &config.InputHelmOptions{
+				PostRenderer: &config.InputHelmPostRenderer{
+					Path: "./hooks/kustomize.sh",
+					Args: []string{"overlays/prod", "--enable-helm"},
+				},
+			},
+			expected: []string{
+				"--post-renderer", "/app/hooks/kustomize.sh",
+				"--post-renderer-args", "overlays/prod",
+				"--post-renderer-args", "--enable-helm",
+			},
+		},
+		{
+			name: "post renderer specified by name",
+			opts: &config.InputHelmOptions{
+				PostRenderer: &config.InputHelmPostRenderer{
+					Path: "my-renderer",
+				},
+			},
+			expected: []string{"--post-renderer", "/app/my-renderer"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := makeHelmOptionArgs("/app", tc.opts)
+			assert.Equal(t, tc.expected, args)
+		})
+	}
+}
+
+func TestPrepareExternalValueFiles(t *testing.T) {
+	ctx := context.Background()
+	files := []config.InputHelmValueFile{
+		{Path: "envs/prod/values.yaml"},
+		{Path: "values.yaml"},
+	}
+	paths, cleanup, err := prepareExternalValueFiles(ctx, "/repo", files, nil, nil)
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, []string{"/repo/envs/prod/values.yaml", "/repo/values.yaml"}, paths)
+
+	_, _, err = prepareExternalValueFiles(ctx, "/repo", []config.InputHelmValueFile{{Path: "../other/values.yaml"}}, nil, nil)
+	assert.Error(t, err)
+
+	// The remote repositories not registered to the piped are rejected before cloning.
+	repos := []config.PipedRepository{{RepoID: "values", Remote: "git@github.com:org/values.git"}}
+	remote := []config.InputHelmValueFile{{GitRemote: "git@github.com:org/other.git", Path: "values.yaml"}}
+	_, _, err = prepareExternalValueFiles(ctx, "/repo", remote, repos, nil)
+	assert.Error(t, err)
+}
+
+func TestWithValueFiles(t *testing.T) {
+	opts := &config.InputHelmOptions{
+		ReleaseName: "foo",
+		ValueFiles:  []string{"values.yaml"},
+	}
+	got := withValueFiles(opts, []string{"/repo/envs/prod/values.yaml"})
+	assert.Equal(t, "foo", got.ReleaseName)
+	assert.Equal(t, []string{"/repo/envs/prod/values.yaml", "values.yaml"}, got.ValueFiles)
+	// The given options must not be modified.
+	assert.Equal(t, []string{"values.yaml"}, opts.ValueFiles)
+
+	got = withValueFiles(nil, []string{"/repo/values.yaml"})
+	assert.Equal(t, []string{"/repo/values.yaml"}, got.ValueFiles)
+
+	assert.Equal(t, opts, withValueFiles(opts, nil))
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+type Helmfile struct {
+	version  string
+	execPath string
+	// The path to helm binary executed by helmfile.
+	helmPath string
+	logger   *zap.Logger
+}
+
+func NewHelmfile(version, path, helmPath string, logger *zap.Logger) *Helmfile {
+	return &Helmfile{
+		version:  version,
+		execPath: path,
+		helmPath: helmPath,
+		logger:   logger,
+	}
+}
+
+func (c *Helmfile) Template(ctx context.Context, appName, appDir, namespace string, opts *config.InputHelmfileOptions) (string, error) {
+	args := []string{
+		"--helm-binary", c.helmPath,
+	}
+	if namespace != "" {
+		args = append(args, fmt.Sprintf("--namespace=%s", namespace))
+	}
+	if opts != nil {
+		if opts.File != "" {
+			args = append(args, "--file", opts.File)
+		}
+		if opts.Environment != "" {
+			args = append(args, "--environment", opts.Environment)
+		}
+		for _, s := range opts.Selectors {
+			args = append(args, "--selector", s)
+		}
+	}
+	// Same as helm templating, the hooks are not rendered
+	// because they can not be handled by the kubectl apply.
+	args = append(args, "template", "--args=--no-hooks")
+
+	var stdout, stderr bytes.Buffer
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	cmd.Dir = appDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	c.logger.Info(fmt.Sprintf("start templating a helmfile application %s", appName),
+		zap.Any("args", args),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
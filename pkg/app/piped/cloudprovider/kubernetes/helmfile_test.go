@@ -0,0 +1,72 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestHelmfileTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "helmfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A fake helmfile that prints the given arguments as a manifest.
+	execPath := filepath.Join(dir, "helmfile")
+	script := "#!/bin/sh\necho 'apiVersion: v1'\necho 'kind: ConfigMap'\necho \"args: $*\"\n"
+	require.NoError(t, ioutil.WriteFile(execPath, []byte(script), 0755))
+
+	testcases := []struct {
+		name     string
+		opts     *config.InputHelmfileOptions
+		expected string
+	}{
+		{
+			name:     "no option",
+			expected: "--helm-binary /bin/helm --namespace=ns template --args=--no-hooks",
+		},
+		{
+			name: "with options",
+			opts: &config.InputHelmfileOptions{
+				File:        "helmfile.d",
+				Environment: "production",
+				Selectors:   []string{"name=app", "tier=frontend"},
+			},
+			expected: "--helm-binary /bin/helm --namespace=ns --file helmfile.d --environment production --selector name=app --selector tier=frontend template --args=--no-hooks",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			helmfile := NewHelmfile("", execPath, "/bin/helm", zap.NewNop())
+			out, err := helmfile.Template(context.Background(), "testapp", dir, "ns", tc.opts)
+			require.NoError(t, err)
+
+			manifests, err := ParseManifests(out)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+			assert.Equal(t, tc.expected, manifests[0].u.Object["args"])
+		})
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+// DiffIgnorer removes the fields those should be ignored
+// while calculating the diff of manifests.
+type DiffIgnorer struct {
+	rules []ignoreDiffRule
+}
+
+type ignoreDiffRule struct {
+	selector map[string]string
+	paths    [][]pathSegment
+	managers map[string]struct{}
+}
+
+// NewDiffIgnorer creates a new DiffIgnorer for the given rules.
+func NewDiffIgnorer(rules ...config.KubernetesIgnoreDiffRule) (*DiffIgnorer, error) {
+	d := &DiffIgnorer{
+		rules: make([]ignoreDiffRule, 0, len(rules)),
+	}
+	for _, r := range rules {
+		rule := ignoreDiffRule{
+			selector: r.Selector,
+			paths:    make([][]pathSegment, 0, len(r.Paths)),
+			managers: make(map[string]struct{}, len(r.Managers)),
+		}
+		for _, p := range r.Paths {
+			segments, err := parseFieldPath(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ignoring path %q: %w", p, err)
+			}
+			rule.paths = append(rule.paths, segments)
+		}
+		for _, m := range r.Managers {
+			rule.managers[m] = struct{}{}
+		}
+		d.rules = append(d.rules, rule)
+	}
+	return d, nil
+}
+
+// Apply returns the copies of the given manifests without the ignored fields.
+// The managed fields of both manifests are used to find the fields owned by
+// the ignoring managers, so the live manifest is expected to be passed as
+// one of them when comparing with the live state.
+func (d *DiffIgnorer) Apply(x, y Manifest) (Manifest, Manifest) {
+	if d == nil || len(d.rules) == 0 {
+		return x, y
+	}
+	x, y = x.Duplicate(x.Key.Name), y.Duplicate(y.Key.Name)
+
+	for _, r := range d.rules {
+		if !r.matches(x) && !r.matches(y) {
+			continue
+		}
+		for _, p := range r.paths {
+			removeFieldPath(x.u.Object, p)
+			removeFieldPath(y.u.Object, p)
+		}
+		if len(r.managers) == 0 {
+			continue
+		}
+		for _, fields := range managedFieldSets(x.u, r.managers) {
+			removeManagedFields(x.u.Object, fields)
+			removeManagedFields(y.u.Object, fields)
+		}
+		for _, fields := range managedFieldSets(y.u, r.managers) {
+			removeManagedFields(x.u.Object, fields)
+			removeManagedFields(y.u.Object, fields)
+		}
+	}
+	return x, y
+}
+
+// ApplyAll returns the copies of the given manifests without the ignored fields.
+func (d *DiffIgnorer) ApplyAll(manifests []Manifest) []Manifest {
+	if d == nil || len(d.rules) == 0 {
+		return manifests
+	}
+	out := make([]Manifest, 0, len(manifests))
+	for _, m := range manifests {
+		m, _ = d.Apply(m, m)
+		out = append(out, m)
+	}
+	return out
+}
+
+func (r ignoreDiffRule) matches(m Manifest) bool {
+	labels := m.u.GetLabels()
+	for k, v := range r.selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// pathSegment represents one step of an ignoring path.
+type pathSegment struct {
+	// The key of map. Empty when this is a list step.
+	key string
+	// Whether this matches all elements of list.
+	all bool
+	// The index of list element. Negative means unspecified.
+	index int
+	// The field and its value used to select list elements.
+	field, value string
+}
+
+// parseFieldPath parses the path such as
+//   metadata.annotations['sidecar.istio.io/status']
+//   spec.template.spec.containers[name=istio-proxy]
+//   spec.template.spec.containers[*].resources
+//   spec.template.spec.containers[0].env
+func parseFieldPath(path string) ([]pathSegment, error) {
+	var (
+		segments []pathSegment
+		rest     = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	)
+	for rest != "" {
+		if rest[0] == '[' {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing closing bracket")
+			}
+			s, err := parseBracketSegment(rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, s)
+			rest = strings.TrimPrefix(rest[end+1:], ".")
+			continue
+		}
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("empty field name")
+		}
+		segments = append(segments, pathSegment{key: rest[:end], index: -1})
+		rest = strings.TrimPrefix(rest[end:], ".")
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return segments, nil
+}
+
+func parseBracketSegment(s string) (pathSegment, error) {
+	switch {
+	case s == "*":
+		return pathSegment{all: true, index: -1}, nil
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return pathSegment{key: s[1 : len(s)-1], index: -1}, nil
+	case strings.Contains(s, "="):
+		parts := strings.SplitN(s, "=", 2)
+		return pathSegment{field: parts[0], value: parts[1], index: -1}, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 {
+		return pathSegment{}, fmt.Errorf("invalid list selector [%s]", s)
+	}
+	return pathSegment{index: index}, nil
+}
+
+func (s pathSegment) matchesElement(i int, e interface{}) bool {
+	if s.all {
+		return true
+	}
+	if s.index >= 0 {
+		return s.index == i
+	}
+	m, ok := e.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	return fmt.Sprint(m[s.field]) == s.value
+}
+
+// removeFieldPath removes all fields matching the given path from the given object.
+func removeFieldPath(obj interface{}, path []pathSegment) interface{} {
+	if len(path) == 0 {
+		return obj
+	}
+	s := path[0]
+
+	if s.key != "" {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return obj
+		}
+		child, ok := m[s.key]
+		if !ok {
+			return obj
+		}
+		if len(path) == 1 {
+			delete(m, s.key)
+			return obj
+		}
+		m[s.key] = removeFieldPath(child, path[1:])
+		return obj
+	}
+
+	list, ok := obj.([]interface{})
+	if !ok {
+		return obj
+	}
+	out := make([]interface{}, 0, len(list))
+	for i, e := range list {
+		if !s.matchesElement(i, e) {
+			out = append(out, e)
+			continue
+		}
+		if len(path) == 1 {
+			continue
+		}
+		out = append(out, removeFieldPath(e, path[1:]))
+	}
+	return out
+}
+
+// managedFieldSets returns the fieldsV1 sets owned by the given managers.
+func managedFieldSets(u *unstructured.Unstructured, managers map[string]struct{}) []map[string]interface{} {
+	entries, _, _ := unstructured.NestedSlice(u.Object, "metadata", "managedFields")
+	sets := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		manager, _ := entry["manager"].(string)
+		if _, ok := managers[manager]; !ok {
+			continue
+		}
+		if fields, ok := entry["fieldsV1"].(map[string]interface{}); ok {
+			sets = append(sets, fields)
+		}
+	}
+	return sets
+}
+
+// removeManagedFields removes the fields specified by the given fieldsV1 set from the given object.
+// See https://kubernetes.io/docs/reference/using-api/server-side-apply/#field-management
+// for the format of the set.
+func removeManagedFields(obj interface{}, fields map[string]interface{}) interface{} {
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		for k, sub := range fields {
+			if !strings.HasPrefix(k, "f:") {
+				continue
+			}
+			name := strings.TrimPrefix(k, "f:")
+			child, ok := o[name]
+			if !ok {
+				continue
+			}
+			subFields, _ := sub.(map[string]interface{})
+			if ownsWhole(subFields) {
+				delete(o, name)
+				continue
+			}
+			o[name] = removeManagedFields(child, subFields)
+		}
+		return o
+
+	case []interface{}:
+		removes := make(map[int]struct{})
+		for k, sub := range fields {
+			subFields, _ := sub.(map[string]interface{})
+			for i, e := range o {
+				if !matchesListKey(k, e) {
+					continue
+				}
+				if ownsWhole(subFields) {
+					removes[i] = struct{}{}
+					continue
+				}
+				o[i] = removeManagedFields(e, subFields)
+			}
+		}
+		if len(removes) == 0 {
+			return o
+		}
+		out := make([]interface{}, 0, len(o)-len(removes))
+		for i, e := range o {
+			if _, ok := removes[i]; !ok {
+				out = append(out, e)
+			}
+		}
+		return out
+
+	default:
+		return obj
+	}
+}
+
+// ownsWhole reports whether the given set is owning the whole field
+// instead of only some of its children.
+func ownsWhole(fields map[string]interface{}) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	_, ok := fields["."]
+	return ok
+}
+
+// matchesListKey reports whether the given list element is specified by the given key
+// which is either k:{"name":"foo"} for associative lists or v:"foo" for set lists.
+func matchesListKey(key string, e interface{}) bool {
+	switch {
+	case strings.HasPrefix(key, "k:"):
+		var keys map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(key, "k:")), &keys); err != nil {
+			return false
+		}
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range keys {
+			if fmt.Sprint(m[k]) != fmt.Sprint(v) {
+				return false
+			}
+		}
+		return true
+
+	case strings.HasPrefix(key, "v:"):
+		var v interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(key, "v:")), &v); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(normalizeJSONNumber(v), normalizeJSONNumber(e))
+
+	default:
+		return false
+	}
+}
+
+func normalizeJSONNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+const (
+	ignoreDiffHead = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+  labels:
+    app: simple
+spec:
+  template:
+    metadata:
+      annotations:
+        foo: bar
+    spec:
+      containers:
+      - name: helloworld
+        image: gcr.io/pipecd/helloworld:v1.0.0
+`
+	ignoreDiffLive = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+  labels:
+    app: simple
+  managedFields:
+  - manager: kubectl
+    fieldsV1:
+      f:spec:
+        f:template:
+          f:spec:
+            f:containers:
+              k:{"name":"helloworld"}:
+                .: {}
+                f:image: {}
+                f:name: {}
+  - manager: vault-injector
+    fieldsV1:
+      f:spec:
+        f:template:
+          f:metadata:
+            f:annotations:
+              f:vault.hashicorp.com/agent-inject-status: {}
+          f:spec:
+            f:containers:
+              k:{"name":"vault-agent"}:
+                .: {}
+                f:image: {}
+                f:name: {}
+spec:
+  template:
+    metadata:
+      annotations:
+        foo: bar
+        sidecar.istio.io/status: injected
+        vault.hashicorp.com/agent-inject-status: injected
+    spec:
+      containers:
+      - name: istio-proxy
+        image: istio/proxyv2:1.9.0
+      - name: helloworld
+        image: gcr.io/pipecd/helloworld:v1.0.0
+      - name: vault-agent
+        image: vault:1.7.0
+`
+)
+
+func TestDiffIgnorer(t *testing.T) {
+	heads, err := ParseManifests(ignoreDiffHead)
+	require.NoError(t, err)
+	lives, err := ParseManifests(ignoreDiffLive)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name     string
+		rules    []config.KubernetesIgnoreDiffRule
+		expected []string
+	}{
+		{
+			name: "no rule",
+			expected: []string{
+				"metadata.managedFields",
+				"spec.template.metadata.annotations.sidecar.istio.io/status",
+				"spec.template.metadata.annotations.vault.hashicorp.com/agent-inject-status",
+				"spec.template.spec.containers.0",
+				"spec.template.spec.containers.2",
+			},
+		},
+		{
+			name: "ignore by paths",
+			rules: []config.KubernetesIgnoreDiffRule{
+				{
+					Paths: []string{
+						"spec.template.metadata.annotations['sidecar.istio.io/status']",
+						"spec.template.spec.containers[name=istio-proxy]",
+					},
+				},
+			},
+			expected: []string{
+				"metadata.managedFields",
+				"spec.template.metadata.annotations.vault.hashicorp.com/agent-inject-status",
+				"spec.template.spec.containers.1",
+			},
+		},
+		{
+			name: "ignore by managers",
+			rules: []config.KubernetesIgnoreDiffRule{
+				{
+					Managers: []string{"vault-injector"},
+				},
+			},
+			expected: []string{
+				"metadata.managedFields",
+				"spec.template.metadata.annotations.sidecar.istio.io/status",
+				"spec.template.spec.containers.0",
+			},
+		},
+		{
+			name: "not selected resource",
+			rules: []config.KubernetesIgnoreDiffRule{
+				{
+					Selector: map[string]string{"app": "other"},
+					Managers: []string{"vault-injector"},
+					Paths:    []string{"spec.template"},
+				},
+			},
+			expected: []string{
+				"metadata.managedFields",
+				"spec.template.metadata.annotations.sidecar.istio.io/status",
+				"spec.template.metadata.annotations.vault.hashicorp.com/agent-inject-status",
+				"spec.template.spec.containers.0",
+				"spec.template.spec.containers.2",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ignorer, err := NewDiffIgnorer(tc.rules...)
+			require.NoError(t, err)
+
+			head, live := ignorer.Apply(heads[0], lives[0])
+			result, err := Diff(head, live)
+			require.NoError(t, err)
+
+			paths := make([]string, 0, result.NumNodes())
+			for _, n := range result.Nodes() {
+				paths = append(paths, n.PathString)
+			}
+			assert.ElementsMatch(t, tc.expected, paths)
+
+			// The given manifests must not be modified.
+			containers, _, _ := unstructured.NestedSlice(lives[0].u.Object, "spec", "template", "spec", "containers")
+			assert.Equal(t, 3, len(containers))
+		})
+	}
+}
+
+func TestParseFieldPath(t *testing.T) {
+	testcases := []struct {
+		path     string
+		expected []pathSegment
+		wantErr  bool
+	}{
+		{
+			path: "metadata.annotations['sidecar.istio.io/status']",
+			expected: []pathSegment{
+				{key: "metadata", index: -1},
+				{key: "annotations", index: -1},
+				{key: "sidecar.istio.io/status", index: -1},
+			},
+		},
+		{
+			path: "$.spec.containers[*].resources",
+			expected: []pathSegment{
+				{key: "spec", index: -1},
+				{key: "containers", index: -1},
+				{all: true, index: -1},
+				{key: "resources", index: -1},
+			},
+		},
+		{
+			path: "spec.containers[name=istio-proxy]",
+			expected: []pathSegment{
+				{key: "spec", index: -1},
+				{key: "containers", index: -1},
+				{field: "name", value: "istio-proxy", index: -1},
+			},
+		},
+		{
+			path: "spec.containers[1].env",
+			expected: []pathSegment{
+				{key: "spec", index: -1},
+				{key: "containers", index: -1},
+				{index: 1},
+				{key: "env", index: -1},
+			},
+		},
+		{
+			path:    "spec.containers[foo",
+			wantErr: true,
+		},
+		{
+			path:    "spec..containers",
+			wantErr: true,
+		},
+		{
+			path:    "",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := parseFieldPath(tc.path)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
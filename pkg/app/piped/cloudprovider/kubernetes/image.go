@@ -0,0 +1,122 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// The key of the deployment metadata containing the digests of the images
+	// resolved at planning time. The value is a JSON map from the image written
+	// in the manifests to the one pinned to its digest.
+	ImageDigestsMetadataKey = "ImageDigests"
+	// The key of the deployment metadata containing the images those were running
+	// before the deployment. The value is a JSON map from kind/name/container to the image.
+	RunningImagesMetadataKey = "RunningImages"
+)
+
+// ContainerImage represents the image used by a container of a workload.
+type ContainerImage struct {
+	// The name of the container.
+	Container string
+	Image     string
+}
+
+var containerFields = []string{"initContainers", "containers"}
+
+// podSpecFields returns the fields to the pod spec of the given kind of workloads.
+func podSpecFields(kind string) ([]string, bool) {
+	switch kind {
+	case KindDeployment, KindStatefulSet, KindDaemonSet, KindReplicaSet, KindJob:
+		return []string{"spec", "template", "spec"}, true
+	case KindCronJob:
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}, true
+	case KindPod:
+		return []string{"spec"}, true
+	default:
+		return nil, false
+	}
+}
+
+// GetContainerImages returns the images of all init containers and containers
+// of the workload. Nil is returned when the manifest is not a workload.
+func (m Manifest) GetContainerImages() []ContainerImage {
+	fields, ok := podSpecFields(m.Key.Kind)
+	if !ok {
+		return nil
+	}
+	var images []ContainerImage
+	for _, f := range containerFields {
+		path := append(append([]string{}, fields...), f)
+		containers, _, _ := unstructured.NestedSlice(m.u.Object, path...)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			if image == "" {
+				continue
+			}
+			images = append(images, ContainerImage{
+				Container: name,
+				Image:     image,
+			})
+		}
+	}
+	return images
+}
+
+// ReplaceContainerImages replaces the image of every init container and container
+// of the workload with the one returned by the given function.
+func (m Manifest) ReplaceContainerImages(replace func(ContainerImage) string) error {
+	fields, ok := podSpecFields(m.Key.Kind)
+	if !ok {
+		return nil
+	}
+	for _, f := range containerFields {
+		path := append(append([]string{}, fields...), f)
+		containers, found, err := unstructured.NestedSlice(m.u.Object, path...)
+		if err != nil {
+			return fmt.Errorf("invalid %s of %s: %w", f, m.Key.ReadableString(), err)
+		}
+		if !found {
+			continue
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			if image == "" {
+				continue
+			}
+			container["image"] = replace(ContainerImage{
+				Container: name,
+				Image:     image,
+			})
+		}
+		if err := unstructured.SetNestedSlice(m.u.Object, containers, path...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestContainerImages(t *testing.T) {
+	manifests, err := ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: gcr.io/pipecd/init:v1
+      containers:
+      - name: web
+        image: gcr.io/pipecd/web:v1
+      - name: sidecar
+        image: envoyproxy/envoy:v1.16.0
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: gcr.io/pipecd/cleanup:v1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: gcr.io/pipecd/web:v1
+`)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(manifests))
+
+	assert.Equal(t, []ContainerImage{
+		{Container: "init", Image: "gcr.io/pipecd/init:v1"},
+		{Container: "web", Image: "gcr.io/pipecd/web:v1"},
+		{Container: "sidecar", Image: "envoyproxy/envoy:v1.16.0"},
+	}, manifests[0].GetContainerImages())
+	assert.Equal(t, []ContainerImage{
+		{Container: "cleanup", Image: "gcr.io/pipecd/cleanup:v1"},
+	}, manifests[1].GetContainerImages())
+	assert.Nil(t, manifests[2].GetContainerImages())
+
+	for _, m := range manifests {
+		err := m.ReplaceContainerImages(func(c ContainerImage) string {
+			if c.Container == "sidecar" {
+				return c.Image
+			}
+			return c.Image + "@sha256:abc"
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []ContainerImage{
+		{Container: "init", Image: "gcr.io/pipecd/init:v1@sha256:abc"},
+		{Container: "web", Image: "gcr.io/pipecd/web:v1@sha256:abc"},
+		{Container: "sidecar", Image: "envoyproxy/envoy:v1.16.0"},
+	}, manifests[0].GetContainerImages())
+	assert.Equal(t, []ContainerImage{
+		{Container: "cleanup", Image: "gcr.io/pipecd/cleanup:v1@sha256:abc"},
+	}, manifests[1].GetContainerImages())
+	image, _, _ := unstructured.NestedString(manifests[2].u.Object, "data", "image")
+	assert.Equal(t, "gcr.io/pipecd/web:v1", image)
+}
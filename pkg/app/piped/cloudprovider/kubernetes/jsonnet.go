@@ -0,0 +1,240 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+const (
+	jsonnetMainFileName   = "main.jsonnet"
+	jsonnetfileFileName   = "jsonnetfile.json"
+	jsonnetVendorDirName  = "vendor"
+	jsonnetLibraryDirName = "lib"
+)
+
+type Jsonnet struct {
+	version  string
+	execPath string
+	// The path to jsonnet-bundler binary used to install the vendored libraries.
+	jbPath string
+	logger *zap.Logger
+}
+
+func NewJsonnet(version, path, jbPath string, logger *zap.Logger) *Jsonnet {
+	return &Jsonnet{
+		version:  version,
+		execPath: path,
+		jbPath:   jbPath,
+		logger:   logger,
+	}
+}
+
+// Template evaluates the jsonnet file of the given application and
+// returns all Kubernetes objects found in the output.
+// Same as Tanka, the directory containing jsonnetfile.json is used as the root directory
+// and its lib and vendor directories are added to the library search paths.
+func (c *Jsonnet) Template(ctx context.Context, appName, appDir, repoDir string, opts *config.InputJsonnetOptions) ([]Manifest, error) {
+	if opts == nil {
+		opts = &config.InputJsonnetOptions{}
+	}
+	file := opts.File
+	if file == "" {
+		file = jsonnetMainFileName
+	}
+
+	rootDir := findJsonnetRootDir(appDir, repoDir)
+	if err := c.installDependencies(ctx, rootDir); err != nil {
+		return nil, fmt.Errorf("unable to install jsonnet dependencies: %w", err)
+	}
+
+	args := make([]string, 0)
+	for _, p := range makeJsonnetJPaths(appDir, rootDir, opts.JPaths) {
+		args = append(args, "--jpath", p)
+	}
+	for _, k := range sortedStringMapKeys(opts.ExtVars) {
+		args = append(args, "--ext-str", fmt.Sprintf("%s=%s", k, opts.ExtVars[k]))
+	}
+	for _, k := range sortedStringMapKeys(opts.TLAs) {
+		args = append(args, "--tla-str", fmt.Sprintf("%s=%s", k, opts.TLAs[k]))
+	}
+	args = append(args, file)
+
+	var stdout, stderr bytes.Buffer
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	cmd.Dir = appDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	c.logger.Info(fmt.Sprintf("start evaluating jsonnet for application %s", appName),
+		zap.Any("args", args),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return parseJsonnetOutput(stdout.Bytes())
+}
+
+// installDependencies runs jb install when the vendored libraries
+// are declared in jsonnetfile.json but not committed.
+func (c *Jsonnet) installDependencies(ctx context.Context, rootDir string) error {
+	if rootDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, jsonnetVendorDirName)); err == nil {
+		return nil
+	}
+	if c.jbPath == "" {
+		return fmt.Errorf("jsonnet-bundler is required to install the libraries in %s", jsonnetfileFileName)
+	}
+
+	var stderr bytes.Buffer
+	cmd := executil.CommandContext(ctx, c.jbPath, "install")
+	cmd.Dir = rootDir
+	cmd.Stderr = &stderr
+
+	c.logger.Info("start installing jsonnet dependencies", zap.String("dir", rootDir))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// findJsonnetRootDir returns the nearest directory containing jsonnetfile.json
+// from appDir up to repoDir. Empty is returned when no one was found.
+func findJsonnetRootDir(appDir, repoDir string) string {
+	dir := filepath.Clean(appDir)
+	repoDir = filepath.Clean(repoDir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, jsonnetfileFileName)); err == nil {
+			return dir
+		}
+		if dir == repoDir || !strings.HasPrefix(dir, repoDir) {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// makeJsonnetJPaths returns the library search paths in ascending order of precedence
+// since the right-most one wins in jsonnet.
+func makeJsonnetJPaths(appDir, rootDir string, jpaths []string) []string {
+	paths := make([]string, 0, len(jpaths)+4)
+	if rootDir != "" {
+		paths = append(paths,
+			filepath.Join(rootDir, jsonnetVendorDirName),
+			filepath.Join(rootDir, jsonnetLibraryDirName),
+		)
+	}
+	paths = append(paths, appDir)
+	for _, p := range jpaths {
+		paths = append(paths, filepath.Join(appDir, p))
+	}
+	return paths
+}
+
+// parseJsonnetOutput extracts all Kubernetes objects from the evaluated JSON.
+// The objects can be nested in any objects or arrays, and the List kinds are expanded.
+func parseJsonnetOutput(data []byte) ([]Manifest, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("unable to parse jsonnet output: %w", err)
+	}
+	manifests := make([]Manifest, 0)
+	if err := extractJsonnetObjects(v, "", &manifests); err != nil {
+		return nil, err
+	}
+	return manifests, nil
+}
+
+func extractJsonnetObjects(v interface{}, path string, manifests *[]Manifest) error {
+	switch t := v.(type) {
+	case []interface{}:
+		for i, e := range t {
+			if err := extractJsonnetObjects(e, fmt.Sprintf("%s[%d]", path, i), manifests); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case map[string]interface{}:
+		if isKubernetesObject(t) {
+			if kind := t["kind"].(string); strings.HasSuffix(kind, "List") {
+				if items, ok := t["items"].([]interface{}); ok {
+					return extractJsonnetObjects(items, path+".items", manifests)
+				}
+			}
+			obj := &unstructured.Unstructured{Object: t}
+			*manifests = append(*manifests, Manifest{
+				Key: MakeResourceKey(obj),
+				u:   obj,
+			})
+			return nil
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := extractJsonnetObjects(t[k], path+"."+k, manifests); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case nil:
+		return nil
+
+	default:
+		return fmt.Errorf("found a value which is not a Kubernetes object at %q in jsonnet output", path)
+	}
+}
+
+func isKubernetesObject(m map[string]interface{}) bool {
+	apiVersion, ok := m["apiVersion"].(string)
+	if !ok || apiVersion == "" {
+		return false
+	}
+	kind, ok := m["kind"].(string)
+	return ok && kind != ""
+}
+
+func sortedStringMapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
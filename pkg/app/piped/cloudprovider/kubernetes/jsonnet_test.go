@@ -0,0 +1,142 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestParseJsonnetOutput(t *testing.T) {
+	testcases := []struct {
+		name     string
+		data     string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "single object",
+			data:     `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`,
+			expected: []string{"ConfigMap/a"},
+		},
+		{
+			name:     "array",
+			data:     `[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}},{"apiVersion":"v1","kind":"Secret","metadata":{"name":"b"}}]`,
+			expected: []string{"ConfigMap/a", "Secret/b"},
+		},
+		{
+			name: "nested objects in key order",
+			data: `{
+				"web": {
+					"service": {"apiVersion":"v1","kind":"Service","metadata":{"name":"web"}},
+					"deployment": {"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"}}
+				},
+				"config": {"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config"}},
+				"disabled": null
+			}`,
+			expected: []string{"ConfigMap/config", "Deployment/web", "Service/web"},
+		},
+		{
+			name:     "list kind",
+			data:     `{"apiVersion":"v1","kind":"List","items":[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}]}`,
+			expected: []string{"ConfigMap/a"},
+		},
+		{
+			name:    "non-object value",
+			data:    `{"config": {"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}, "replicas": 3}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			data:    `{`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := parseJsonnetOutput([]byte(tc.data))
+			assert.Equal(t, tc.wantErr, err != nil)
+			if err != nil {
+				return
+			}
+			got := make([]string, 0, len(manifests))
+			for _, m := range manifests {
+				got = append(got, m.Key.Kind+"/"+m.Key.Name)
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestJsonnetTemplate(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "jsonnet")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	// repo/jsonnetfile.json, repo/vendor and repo/environments/prod as the application directory.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, jsonnetfileFileName), []byte("{}"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, jsonnetVendorDirName), 0755))
+	appDir := filepath.Join(repoDir, "environments", "prod")
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+
+	// A fake jsonnet that outputs the given arguments as a ConfigMap.
+	execPath := filepath.Join(repoDir, "jsonnet")
+	script := "#!/bin/sh\necho \"{\\\"apiVersion\\\":\\\"v1\\\",\\\"kind\\\":\\\"ConfigMap\\\",\\\"metadata\\\":{\\\"name\\\":\\\"args\\\"},\\\"data\\\":{\\\"args\\\":\\\"$*\\\"}}\"\n"
+	require.NoError(t, ioutil.WriteFile(execPath, []byte(script), 0755))
+
+	jsonnet := NewJsonnet("", execPath, "", zap.NewNop())
+	manifests, err := jsonnet.Template(context.Background(), "testapp", appDir, repoDir, &config.InputJsonnetOptions{
+		JPaths:  []string{"lib"},
+		ExtVars: map[string]string{"env": "prod", "cluster": "c1"},
+		TLAs:    map[string]string{"image": "app:v1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(manifests))
+
+	data, err := manifests[0].GetNestedStringMap("data")
+	require.NoError(t, err)
+	expected := "--jpath " + filepath.Join(repoDir, "vendor") +
+		" --jpath " + filepath.Join(repoDir, "lib") +
+		" --jpath " + appDir +
+		" --jpath " + filepath.Join(appDir, "lib") +
+		" --ext-str cluster=c1 --ext-str env=prod --tla-str image=app:v1 main.jsonnet"
+	assert.Equal(t, expected, data["args"])
+}
+
+func TestFindJsonnetRootDir(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "jsonnet")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	appDir := filepath.Join(repoDir, "environments", "prod")
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+
+	assert.Equal(t, "", findJsonnetRootDir(appDir, repoDir))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, jsonnetfileFileName), []byte("{}"), 0644))
+	assert.Equal(t, repoDir, findJsonnetRootDir(appDir, repoDir))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(appDir, jsonnetfileFileName), []byte("{}"), 0644))
+	assert.Equal(t, appDir, findJsonnetRootDir(appDir, repoDir))
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+const defaultKappWaitTimeout = 15 * time.Minute
+
+type Kapp struct {
+	version  string
+	execPath string
+	logger   *zap.Logger
+}
+
+func NewKapp(version, path string, logger *zap.Logger) *Kapp {
+	return &Kapp{
+		version:  version,
+		execPath: path,
+		logger:   logger,
+	}
+}
+
+// Deploy applies all given manifests as a kapp application.
+// The resources those are no longer included in the given manifests are deleted by kapp
+// and it waits until all changed resources become ready.
+// The returned output contains the diff of the changes.
+func (c *Kapp) Deploy(ctx context.Context, appName, appDir, namespace string, manifests []Manifest, opts config.InputKappOptions) (string, error) {
+	var data bytes.Buffer
+	for i, m := range manifests {
+		b, err := m.YamlBytes()
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			data.WriteString("---\n")
+		}
+		data.Write(b)
+	}
+
+	app := appName
+	if opts.App != "" {
+		app = opts.App
+	}
+	appNamespace := namespace
+	if opts.AppNamespace != "" {
+		appNamespace = opts.AppNamespace
+	}
+	waitTimeout := defaultKappWaitTimeout
+	if opts.WaitTimeout > 0 {
+		waitTimeout = opts.WaitTimeout.Duration()
+	}
+
+	args := []string{"deploy", "--app", app}
+	if appNamespace != "" {
+		args = append(args, "--namespace", appNamespace)
+	}
+	if namespace != "" {
+		args = append(args, "--into-ns", namespace)
+	}
+	args = append(args, "--file", "-")
+	for _, f := range opts.ConfigFiles {
+		args = append(args, "--file", f)
+	}
+	args = append(args,
+		"--wait-timeout", waitTimeout.String(),
+		"--diff-changes",
+		"--color=false",
+		"--yes",
+	)
+
+	var out bytes.Buffer
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	cmd.Dir = appDir
+	cmd.Stdin = &data
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	c.logger.Info(fmt.Sprintf("start deploying a kapp application %s", app),
+		zap.Any("args", args),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return out.String(), fmt.Errorf("failed to deploy: %s (%v)", out.String(), err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestKappDeploy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kapp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A fake kapp that prints the given arguments and the received manifests.
+	execPath := filepath.Join(dir, "kapp")
+	script := "#!/bin/sh\necho \"args: $*\"\ncat\n"
+	require.NoError(t, ioutil.WriteFile(execPath, []byte(script), 0755))
+
+	manifests, err := ParseManifests(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+`)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name      string
+		namespace string
+		opts      config.InputKappOptions
+		expected  string
+	}{
+		{
+			name:     "no option",
+			expected: "args: deploy --app testapp --file - --wait-timeout 15m0s --diff-changes --color=false --yes\n",
+		},
+		{
+			name:      "with options",
+			namespace: "ns",
+			opts: config.InputKappOptions{
+				App:          "helloworld",
+				AppNamespace: "kapp-apps",
+				ConfigFiles:  []string{"kapp-config.yaml"},
+				WaitTimeout:  config.Duration(time.Minute),
+			},
+			expected: "args: deploy --app helloworld --namespace kapp-apps --into-ns ns --file - --file kapp-config.yaml --wait-timeout 1m0s --diff-changes --color=false --yes\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			kapp := NewKapp("", execPath, zap.NewNop())
+			out, err := kapp.Deploy(context.Background(), "testapp", dir, tc.namespace, manifests, tc.opts)
+			require.NoError(t, err)
+
+			expected := tc.expected + `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+`
+			assert.Equal(t, expected, out)
+		})
+	}
+}
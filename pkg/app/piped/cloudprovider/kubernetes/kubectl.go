@@ -18,12 +18,17 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/rest"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
 )
 
+// kubectlFieldManager is the name of the manager of the fields applied by server-side apply.
+const kubectlFieldManager = "piped"
+
 type Kubectl struct {
 	version  string
 	execPath string
@@ -37,7 +42,7 @@ func NewKubectl(version, path string) *Kubectl {
 	}
 }
 
-func (c *Kubectl) Apply(ctx context.Context, namespace string, manifest Manifest) (err error) {
+func (c *Kubectl) Apply(ctx context.Context, namespace string, manifest Manifest, serverSide bool) (err error) {
 	defer func() {
 		metricsKubectlCalled(c.version, "apply", err == nil)
 	}()
@@ -47,23 +52,154 @@ func (c *Kubectl) Apply(ctx context.Context, namespace string, manifest Manifest
 		return err
 	}
 
-	args := make([]string, 0, 5)
-	if namespace != "" {
-		args = append(args, "-n", namespace)
-	}
-	args = append(args, "apply", "-f", "-")
-
-	cmd := exec.CommandContext(ctx, c.execPath, args...)
+	args := makeKubectlApplyArgs(namespace, serverSide)
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
 	r := bytes.NewReader(data)
 	cmd.Stdin = r
 
 	out, err := cmd.CombinedOutput()
 	if err != nil {
+		if isNoMatchesForKindOutput(string(out)) {
+			return fmt.Errorf("failed to apply: %s (%w), %v", string(out), ErrNoMatchesForKind, err)
+		}
 		return fmt.Errorf("failed to apply: %s (%v)", string(out), err)
 	}
 	return nil
 }
 
+func makeKubectlApplyArgs(namespace string, serverSide bool) []string {
+	args := make([]string, 0, 8)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args, "apply")
+	if serverSide {
+		// Git is the source of truth of the fields defined in the manifests,
+		// so piped takes over them from other managers, e.g. the one recorded
+		// by the client-side apply used before enabling server-side apply.
+		args = append(args, "--server-side", "--force-conflicts", "--field-manager="+kubectlFieldManager)
+	}
+	return append(args, "-f", "-")
+}
+
+// isNoMatchesForKindOutput reports whether the given kubectl output is telling
+// that the kind of the resource is not registered in the cluster.
+func isNoMatchesForKindOutput(out string) bool {
+	return strings.Contains(out, "no matches for kind") || strings.Contains(out, "ensure CRDs are installed first")
+}
+
+func (c *Kubectl) Get(ctx context.Context, namespace string, r ResourceKey) (m Manifest, err error) {
+	defer func() {
+		metricsKubectlCalled(c.version, "get", err == nil)
+	}()
+
+	args := make([]string, 0, 6)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args, "get", kubectlResourceType(r), r.Name, "-o", "json")
+
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "(NotFound)") {
+			return Manifest{}, fmt.Errorf("failed to get: %s, (%w), %v", stderr.String(), ErrNotFound, err)
+		}
+		return Manifest{}, fmt.Errorf("failed to get: %s, %v", stderr.String(), err)
+	}
+
+	ms, err := ParseManifests(stdout.String())
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to parse the returned manifest: %w", err)
+	}
+	if len(ms) != 1 {
+		return Manifest{}, fmt.Errorf("expected one manifest but got %d", len(ms))
+	}
+	return ms[0], nil
+}
+
+// List returns the live manifests of the given resource type
+// those are matching the given label and field selectors.
+func (c *Kubectl) List(ctx context.Context, namespace, resourceType, labelSelector, fieldSelector string) (ms []Manifest, err error) {
+	defer func() {
+		metricsKubectlCalled(c.version, "list", err == nil)
+	}()
+
+	args := make([]string, 0, 10)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args, "get", resourceType, "-o", "json")
+	if labelSelector != "" {
+		args = append(args, "-l", labelSelector)
+	}
+	if fieldSelector != "" {
+		args = append(args, "--field-selector", fieldSelector)
+	}
+
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list: %s, %v", stderr.String(), err)
+	}
+	return parseManifestList(stdout.Bytes())
+}
+
+// Logs returns the last lines of the logs of all containers in the given pod.
+func (c *Kubectl) Logs(ctx context.Context, namespace, pod string, tailLines int) (logs string, err error) {
+	defer func() {
+		metricsKubectlCalled(c.version, "logs", err == nil)
+	}()
+
+	args := make([]string, 0, 7)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args, "logs", pod, "--all-containers=true", fmt.Sprintf("--tail=%d", tailLines))
+
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to get logs: %s, %v", stderr.String(), err)
+	}
+	return stdout.String(), nil
+}
+
+// parseManifestList parses the given JSON-encoded list such as PodList.
+func parseManifestList(data []byte) ([]Manifest, error) {
+	var list unstructured.UnstructuredList
+	if err := list.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to parse the returned list: %w", err)
+	}
+	ms := make([]Manifest, 0, len(list.Items))
+	for i := range list.Items {
+		u := &list.Items[i]
+		ms = append(ms, MakeManifest(MakeResourceKey(u), u))
+	}
+	return ms, nil
+}
+
+// kubectlResourceType returns the fully qualified resource type
+// for using in kubectl commands. e.g. Deployment.v1.apps
+// This prevents kubectl from choosing a wrong resource
+// when there are multiple kinds of the same name in different groups.
+func kubectlResourceType(r ResourceKey) string {
+	parts := strings.SplitN(r.APIVersion, "/", 2)
+	if len(parts) != 2 {
+		return r.Kind
+	}
+	return fmt.Sprintf("%s.%s.%s", r.Kind, parts[1], parts[0])
+}
+
 func (c *Kubectl) Delete(ctx context.Context, namespace string, r ResourceKey) (err error) {
 	defer func() {
 		metricsKubectlCalled(c.version, "delete", err == nil)
@@ -75,7 +211,7 @@ func (c *Kubectl) Delete(ctx context.Context, namespace string, r ResourceKey) (
 	}
 	args = append(args, "delete", r.Kind, r.Name)
 
-	cmd := exec.CommandContext(ctx, c.execPath, args...)
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
 	out, err := cmd.CombinedOutput()
 
 	if strings.Contains(string(out), "(NotFound)") {
@@ -86,3 +222,49 @@ func (c *Kubectl) Delete(ctx context.Context, namespace string, r ResourceKey) (
 	}
 	return nil
 }
+
+func (c *Kubectl) RolloutRestart(ctx context.Context, namespace string, r ResourceKey) (err error) {
+	defer func() {
+		metricsKubectlCalled(c.version, "rollout-restart", err == nil)
+	}()
+
+	args := make([]string, 0, 6)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args, "rollout", "restart", r.Kind, r.Name)
+
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	out, err := cmd.CombinedOutput()
+
+	if strings.Contains(string(out), "(NotFound)") {
+		return fmt.Errorf("failed to restart: %s, (%w), %v", string(out), ErrNotFound, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restart: %s, %v", string(out), err)
+	}
+	return nil
+}
+
+func (c *Kubectl) Scale(ctx context.Context, namespace string, r ResourceKey, replicas int) (err error) {
+	defer func() {
+		metricsKubectlCalled(c.version, "scale", err == nil)
+	}()
+
+	args := make([]string, 0, 6)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args, "scale", r.Kind, r.Name, fmt.Sprintf("--replicas=%d", replicas))
+
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	out, err := cmd.CombinedOutput()
+
+	if strings.Contains(string(out), "(NotFound)") {
+		return fmt.Errorf("failed to scale: %s, (%w), %v", string(out), ErrNotFound, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scale: %s, %v", string(out), err)
+	}
+	return nil
+}
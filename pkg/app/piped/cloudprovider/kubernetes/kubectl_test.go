@@ -0,0 +1,52 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeKubectlApplyArgs(t *testing.T) {
+	testcases := []struct {
+		name       string
+		namespace  string
+		serverSide bool
+		expected   []string
+	}{
+		{
+			name:     "client-side apply",
+			expected: []string{"apply", "-f", "-"},
+		},
+		{
+			name:      "client-side apply with namespace",
+			namespace: "dev",
+			expected:  []string{"-n", "dev", "apply", "-f", "-"},
+		},
+		{
+			name:       "server-side apply",
+			namespace:  "dev",
+			serverSide: true,
+			expected:   []string{"-n", "dev", "apply", "--server-side", "--force-conflicts", "--field-manager=piped", "-f", "-"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := makeKubectlApplyArgs(tc.namespace, tc.serverSide)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"go.uber.org/zap"
@@ -31,6 +33,9 @@ import (
 
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrNoMatchesForKind is returned when the kind of the applying resource
+	// is not known by the cluster, e.g. its CRD is not established yet.
+	ErrNoMatchesForKind = errors.New("no matches for kind")
 )
 
 const (
@@ -45,13 +50,43 @@ const (
 	IgnoreDriftDetectionTrue  = "true"
 
 	kustomizationFileName = "kustomization.yaml"
+	helmfileFileName      = "helmfile.yaml"
 )
 
+// ManagedAnnotations is the set of annotation keys piped adds
+// to the resources it manages in order to track them.
+type ManagedAnnotations struct {
+	ManagedBy          string
+	Piped              string
+	Application        string
+	CommitHash         string
+	ResourceKey        string
+	OriginalAPIVersion string
+}
+
+// DefaultManagedAnnotations is the set of annotation keys used when no prefix was configured.
+var DefaultManagedAnnotations = NewManagedAnnotations(config.DefaultKubernetesAnnotationPrefix)
+
+// NewManagedAnnotations returns the managed annotation keys under the given prefix.
+func NewManagedAnnotations(prefix string) ManagedAnnotations {
+	return ManagedAnnotations{
+		ManagedBy:          prefix + "/managed-by",
+		Piped:              prefix + "/piped",
+		Application:        prefix + "/application",
+		CommitHash:         prefix + "/commit-hash",
+		ResourceKey:        prefix + "/resource-key",
+		OriginalAPIVersion: prefix + "/original-api-version",
+	}
+}
+
 type TemplatingMethod string
 
 const (
 	TemplatingMethodHelm      TemplatingMethod = "helm"
 	TemplatingMethodKustomize TemplatingMethod = "kustomize"
+	TemplatingMethodHelmfile  TemplatingMethod = "helmfile"
+	TemplatingMethodJsonnet   TemplatingMethod = "jsonnet"
+	TemplatingMethodYtt       TemplatingMethod = "ytt"
 	TemplatingMethodNone      TemplatingMethod = "none"
 )
 
@@ -66,12 +101,21 @@ type ManifestLoader interface {
 }
 
 type Applier interface {
-	// Apply does applying application manifests by using the tool specified in Input.
-	Apply(ctx context.Context) error
+	// Apply applies all the given manifests at once by using the apply engine specified in Input
+	// and returns the output of that engine such as the diff of the changes.
+	Apply(ctx context.Context, manifests []Manifest) (string, error)
 	// ApplyManifest does applying the given manifest.
 	ApplyManifest(ctx context.Context, manifest Manifest) error
 	// Delete deletes the given resource from Kubernetes cluster.
 	Delete(ctx context.Context, key ResourceKey) error
+	// GetManifest returns the live manifest of the given resource.
+	GetManifest(ctx context.Context, key ResourceKey) (Manifest, error)
+	// ListPods returns the live pods those are matching the given labels.
+	ListPods(ctx context.Context, namespace string, labels map[string]string) ([]Manifest, error)
+	// ListEvents returns the live events those are related to the given resource.
+	ListEvents(ctx context.Context, key ResourceKey) ([]Manifest, error)
+	// GetPodLogs returns the last lines of the logs of all containers in the given pod.
+	GetPodLogs(ctx context.Context, key ResourceKey, tailLines int) (string, error)
 }
 
 type gitClient interface {
@@ -95,9 +139,34 @@ type provider struct {
 	kubectl          *Kubectl
 	kustomize        *Kustomize
 	helm             *Helm
+	helmfile         *Helmfile
+	jsonnet          *Jsonnet
+	ytt              *Ytt
+	kapp             *Kapp
 	templatingMethod TemplatingMethod
 	initOnce         sync.Once
 	initErr          error
+
+	allowHelmPostRenderer bool
+	gitRepositories       []config.PipedRepository
+}
+
+type Option func(*provider)
+
+// WithHelmPostRenderer sets whether the Helm post-renderer specified
+// in the input is allowed to be executed while loading manifests.
+func WithHelmPostRenderer(allowed bool) Option {
+	return func(p *provider) {
+		p.allowHelmPostRenderer = allowed
+	}
+}
+
+// WithGitRepositories sets the git repositories registered to the piped.
+// The external value files can be loaded only from these repositories.
+func WithGitRepositories(repos []config.PipedRepository) Option {
+	return func(p *provider) {
+		p.gitRepositories = repos
+	}
 }
 
 func init() {
@@ -112,8 +181,8 @@ func initSharedGitClient(logger *zap.Logger) error {
 	return err
 }
 
-func NewProvider(appName, appDir, repoDir, configFileName string, input config.KubernetesDeploymentInput, logger *zap.Logger) Provider {
-	return &provider{
+func NewProvider(appName, appDir, repoDir, configFileName string, input config.KubernetesDeploymentInput, logger *zap.Logger, opts ...Option) Provider {
+	p := &provider{
 		appName:        appName,
 		appDir:         appDir,
 		repoDir:        repoDir,
@@ -121,10 +190,14 @@ func NewProvider(appName, appDir, repoDir, configFileName string, input config.K
 		input:          input,
 		logger:         logger.Named("kubernetes-provider"),
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-func NewManifestLoader(appName, appDir, repoDir, configFileName string, input config.KubernetesDeploymentInput, logger *zap.Logger) ManifestLoader {
-	return NewProvider(appName, appDir, repoDir, configFileName, input, logger)
+func NewManifestLoader(appName, appDir, repoDir, configFileName string, input config.KubernetesDeploymentInput, logger *zap.Logger, opts ...Option) ManifestLoader {
+	return NewProvider(appName, appDir, repoDir, configFileName, input, logger, opts...)
 }
 
 func (p *provider) init(ctx context.Context) {
@@ -141,12 +214,31 @@ func (p *provider) init(ctx context.Context) {
 		return
 	}
 
+	if p.input.ApplyEngine == config.KubernetesApplyEngineKapp {
+		p.kapp, p.initErr = p.findKapp(ctx, p.input.KappVersion)
+		if p.initErr != nil {
+			return
+		}
+	}
+
 	switch p.templatingMethod {
 	case TemplatingMethodHelm:
 		p.helm, p.initErr = p.findHelm(ctx, p.input.HelmVersion)
 
 	case TemplatingMethodKustomize:
 		p.kustomize, p.initErr = p.findKustomize(ctx, p.input.KustomizeVersion)
+		if p.initErr == nil && kustomizeNeedsHelm(p.input.KustomizeOptions) {
+			p.helm, p.initErr = p.findHelm(ctx, p.input.HelmVersion)
+		}
+
+	case TemplatingMethodHelmfile:
+		p.helmfile, p.initErr = p.findHelmfile(ctx, p.input.HelmfileVersion, p.input.HelmVersion)
+
+	case TemplatingMethodJsonnet:
+		p.jsonnet, p.initErr = p.findJsonnet(ctx, p.input.JsonnetVersion, p.input.JsonnetBundlerVersion)
+
+	case TemplatingMethodYtt:
+		p.ytt, p.initErr = p.findYtt(ctx, p.input.YttVersion)
 	}
 }
 
@@ -159,7 +251,26 @@ func (p *provider) LoadManifests(ctx context.Context) (manifests []Manifest, err
 
 	switch p.templatingMethod {
 	case TemplatingMethodHelm:
-		var data string
+		var (
+			data    string
+			opts    = p.input.HelmOptions
+			cleanup func()
+		)
+		if opts != nil && opts.PostRenderer != nil && !p.allowHelmPostRenderer {
+			err = fmt.Errorf("helm post-renderer is not allowed by this piped, allowHelmPostRenderer must be enabled in the piped configuration")
+			return
+		}
+		if opts != nil && len(opts.ExternalValueFiles) > 0 {
+			var files []string
+			files, cleanup, err = prepareExternalValueFiles(ctx, p.repoDir, opts.ExternalValueFiles, p.gitRepositories, sharedGitClient)
+			if err != nil {
+				err = fmt.Errorf("unable to prepare external value files: %w", err)
+				return
+			}
+			defer cleanup()
+			opts = withValueFiles(opts, files)
+		}
+
 		switch {
 		case p.input.HelmChart.GitRemote != "":
 			chart := helmRemoteGitChart{
@@ -173,7 +284,7 @@ func (p *provider) LoadManifests(ctx context.Context) (manifests []Manifest, err
 				p.input.Namespace,
 				chart,
 				sharedGitClient,
-				p.input.HelmOptions)
+				opts)
 
 		case p.input.HelmChart.Repository != "":
 			chart := helmRemoteChart{
@@ -186,7 +297,7 @@ func (p *provider) LoadManifests(ctx context.Context) (manifests []Manifest, err
 				p.appDir,
 				p.input.Namespace,
 				chart,
-				p.input.HelmOptions)
+				opts)
 
 		default:
 			data, err = p.helm.TemplateLocalChart(ctx,
@@ -194,7 +305,7 @@ func (p *provider) LoadManifests(ctx context.Context) (manifests []Manifest, err
 				p.appDir,
 				p.input.Namespace,
 				p.input.HelmChart.Path,
-				p.input.HelmOptions)
+				opts)
 		}
 
 		if err != nil {
@@ -205,13 +316,42 @@ func (p *provider) LoadManifests(ctx context.Context) (manifests []Manifest, err
 
 	case TemplatingMethodKustomize:
 		var data string
-		data, err = p.kustomize.Template(ctx, p.appName, p.appDir, p.input.KustomizeOptions)
+		var helmPath string
+		if p.helm != nil {
+			helmPath = p.helm.execPath
+		}
+		data, err = p.kustomize.Template(ctx, p.appName, p.appDir, p.input.KustomizeOptions, helmPath)
 		if err != nil {
 			err = fmt.Errorf("unable to run kustomize template: %w", err)
 			return
 		}
 		manifests, err = ParseManifests(data)
 
+	case TemplatingMethodHelmfile:
+		var data string
+		data, err = p.helmfile.Template(ctx, p.appName, p.appDir, p.input.Namespace, p.input.HelmfileOptions)
+		if err != nil {
+			err = fmt.Errorf("unable to run helmfile template: %w", err)
+			return
+		}
+		manifests, err = ParseManifests(data)
+
+	case TemplatingMethodJsonnet:
+		manifests, err = p.jsonnet.Template(ctx, p.appName, p.appDir, p.repoDir, p.input.JsonnetOptions)
+		if err != nil {
+			err = fmt.Errorf("unable to run jsonnet template: %w", err)
+			return
+		}
+
+	case TemplatingMethodYtt:
+		var data string
+		data, err = p.ytt.Template(ctx, p.appName, p.appDir, p.input.YttOptions)
+		if err != nil {
+			err = fmt.Errorf("unable to run ytt template: %w", err)
+			return
+		}
+		manifests, err = ParseManifests(data)
+
 	case TemplatingMethodNone:
 		manifests, err = LoadPlainYAMLManifests(p.appDir, p.input.Manifests, p.configFileName)
 
@@ -222,9 +362,23 @@ func (p *provider) LoadManifests(ctx context.Context) (manifests []Manifest, err
 	return
 }
 
-// Apply does applying application manifests by using the tool specified in Input.
-func (p *provider) Apply(ctx context.Context) error {
-	return nil
+// Apply applies all the given manifests at once by using the apply engine specified in Input
+// and returns the output of that engine such as the diff of the changes.
+func (p *provider) Apply(ctx context.Context, manifests []Manifest) (string, error) {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return "", p.initErr
+	}
+
+	if p.kapp != nil {
+		return p.kapp.Deploy(ctx, p.appName, p.appDir, p.input.Namespace, manifests, p.input.KappOptions)
+	}
+	for _, m := range manifests {
+		if err := p.kubectl.Apply(ctx, p.getNamespaceToRun(m.Key), m, p.input.ServerSideApply); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
 }
 
 // ApplyManifest does applying the given manifest.
@@ -234,7 +388,7 @@ func (p *provider) ApplyManifest(ctx context.Context, manifest Manifest) error {
 		return p.initErr
 	}
 
-	return p.kubectl.Apply(ctx, p.getNamespaceToRun(manifest.Key), manifest)
+	return p.kubectl.Apply(ctx, p.getNamespaceToRun(manifest.Key), manifest, p.input.ServerSideApply)
 }
 
 // Delete deletes the given resource from Kubernetes cluster.
@@ -247,6 +401,52 @@ func (p *provider) Delete(ctx context.Context, k ResourceKey) (err error) {
 	return p.kubectl.Delete(ctx, p.getNamespaceToRun(k), k)
 }
 
+// GetManifest returns the live manifest of the given resource.
+func (p *provider) GetManifest(ctx context.Context, k ResourceKey) (Manifest, error) {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return Manifest{}, p.initErr
+	}
+
+	return p.kubectl.Get(ctx, p.getNamespaceToRun(k), k)
+}
+
+// ListPods returns the live pods those are matching the given labels.
+func (p *provider) ListPods(ctx context.Context, namespace string, labels map[string]string) ([]Manifest, error) {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return nil, p.initErr
+	}
+
+	selectors := make([]string, 0, len(labels))
+	for k, v := range labels {
+		selectors = append(selectors, k+"="+v)
+	}
+	sort.Strings(selectors)
+	return p.kubectl.List(ctx, p.getNamespaceToRun(ResourceKey{Namespace: namespace}), "pods", strings.Join(selectors, ","), "")
+}
+
+// ListEvents returns the live events those are related to the given resource.
+func (p *provider) ListEvents(ctx context.Context, k ResourceKey) ([]Manifest, error) {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return nil, p.initErr
+	}
+
+	selector := fmt.Sprintf("involvedObject.kind=%s,involvedObject.name=%s", k.Kind, k.Name)
+	return p.kubectl.List(ctx, p.getNamespaceToRun(k), "events", "", selector)
+}
+
+// GetPodLogs returns the last lines of the logs of all containers in the given pod.
+func (p *provider) GetPodLogs(ctx context.Context, k ResourceKey, tailLines int) (string, error) {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return "", p.initErr
+	}
+
+	return p.kubectl.Logs(ctx, p.getNamespaceToRun(k), k.Name, tailLines)
+}
+
 // getNamespaceToRun returns namespace used on kubectl apply/delete commands.
 // priority: config.KubernetesDeploymentInput > kubernetes.ResourceKey
 func (p *provider) getNamespaceToRun(k ResourceKey) string {
@@ -289,10 +489,87 @@ func (p *provider) findHelm(ctx context.Context, version string) (*Helm, error)
 	return NewHelm(version, path, p.logger), nil
 }
 
+func (p *provider) findHelmfile(ctx context.Context, version, helmVersion string) (*Helmfile, error) {
+	helm, err := p.findHelm(ctx, helmVersion)
+	if err != nil {
+		return nil, err
+	}
+	path, installed, err := toolregistry.DefaultRegistry().Helmfile(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("no helmfile %s (%v)", version, err)
+	}
+	if installed {
+		p.logger.Info(fmt.Sprintf("helmfile %s has just been installed because of no pre-installed binary for that version", version))
+	}
+	return NewHelmfile(version, path, helm.execPath, p.logger), nil
+}
+
+func (p *provider) findJsonnet(ctx context.Context, version, jbVersion string) (*Jsonnet, error) {
+	path, installed, err := toolregistry.DefaultRegistry().Jsonnet(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("no jsonnet %s (%v)", version, err)
+	}
+	if installed {
+		p.logger.Info(fmt.Sprintf("jsonnet %s has just been installed because of no pre-installed binary for that version", version))
+	}
+
+	// jsonnet-bundler is needed only when the vendored libraries were not committed.
+	var jbPath string
+	if root := findJsonnetRootDir(p.appDir, p.repoDir); root != "" {
+		if _, err := os.Stat(filepath.Join(root, jsonnetVendorDirName)); err != nil {
+			jbPath, installed, err = toolregistry.DefaultRegistry().JsonnetBundler(ctx, jbVersion)
+			if err != nil {
+				return nil, fmt.Errorf("no jsonnet-bundler %s (%v)", jbVersion, err)
+			}
+			if installed {
+				p.logger.Info(fmt.Sprintf("jsonnet-bundler %s has just been installed because of no pre-installed binary for that version", jbVersion))
+			}
+		}
+	}
+	return NewJsonnet(version, path, jbPath, p.logger), nil
+}
+
+func (p *provider) findYtt(ctx context.Context, version string) (*Ytt, error) {
+	path, installed, err := toolregistry.DefaultRegistry().Ytt(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("no ytt %s (%v)", version, err)
+	}
+	if installed {
+		p.logger.Info(fmt.Sprintf("ytt %s has just been installed because of no pre-installed binary for that version", version))
+	}
+	return NewYtt(version, path, p.logger), nil
+}
+
+func (p *provider) findKapp(ctx context.Context, version string) (*Kapp, error) {
+	path, installed, err := toolregistry.DefaultRegistry().Kapp(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("no kapp %s (%v)", version, err)
+	}
+	if installed {
+		p.logger.Info(fmt.Sprintf("kapp %s has just been installed because of no pre-installed binary for that version", version))
+	}
+	return NewKapp(version, path, p.logger), nil
+}
+
 func determineTemplatingMethod(input config.KubernetesDeploymentInput, appDirPath string) TemplatingMethod {
 	if input.HelmChart != nil {
 		return TemplatingMethodHelm
 	}
+	if input.HelmfileOptions != nil {
+		return TemplatingMethodHelmfile
+	}
+	if input.JsonnetOptions != nil {
+		return TemplatingMethodJsonnet
+	}
+	if input.YttOptions != nil {
+		return TemplatingMethodYtt
+	}
+	if _, err := os.Stat(filepath.Join(appDirPath, helmfileFileName)); err == nil {
+		return TemplatingMethodHelmfile
+	}
+	if _, err := os.Stat(filepath.Join(appDirPath, jsonnetMainFileName)); err == nil {
+		return TemplatingMethodJsonnet
+	}
 	if _, err := os.Stat(filepath.Join(appDirPath, kustomizationFileName)); err == nil {
 		return TemplatingMethodKustomize
 	}
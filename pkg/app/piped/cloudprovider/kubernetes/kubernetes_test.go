@@ -15,13 +15,18 @@
 package kubernetes
 
 import (
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
+	"github.com/pipe-cd/pipe/pkg/config"
 )
 
 func TestMain(m *testing.M) {
@@ -32,3 +37,83 @@ func TestMain(m *testing.M) {
 	}
 	os.Exit(m.Run())
 }
+
+func TestDetermineTemplatingMethod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templating")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, helmfileFileName), []byte("releases: []"), 0644))
+
+	testcases := []struct {
+		name     string
+		input    config.KubernetesDeploymentInput
+		appDir   string
+		expected TemplatingMethod
+	}{
+		{
+			name:     "helm chart",
+			input:    config.KubernetesDeploymentInput{HelmChart: &config.InputHelmChart{Path: "chart"}},
+			appDir:   dir,
+			expected: TemplatingMethodHelm,
+		},
+		{
+			name:     "helmfile options",
+			input:    config.KubernetesDeploymentInput{HelmfileOptions: &config.InputHelmfileOptions{}},
+			appDir:   "testdata/testkustomize",
+			expected: TemplatingMethodHelmfile,
+		},
+		{
+			name:     "helmfile.yaml",
+			appDir:   dir,
+			expected: TemplatingMethodHelmfile,
+		},
+		{
+			name:     "jsonnet options",
+			input:    config.KubernetesDeploymentInput{JsonnetOptions: &config.InputJsonnetOptions{}},
+			appDir:   "testdata/testkustomize",
+			expected: TemplatingMethodJsonnet,
+		},
+		{
+			name:     "ytt options",
+			input:    config.KubernetesDeploymentInput{YttOptions: &config.InputYttOptions{}},
+			appDir:   "testdata/testkustomize",
+			expected: TemplatingMethodYtt,
+		},
+		{
+			name:     "kustomization.yaml",
+			appDir:   "testdata/testkustomize",
+			expected: TemplatingMethodKustomize,
+		},
+		{
+			name:     "plain manifests",
+			appDir:   "testdata",
+			expected: TemplatingMethodNone,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := determineTemplatingMethod(tc.input, tc.appDir)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestNewManagedAnnotations(t *testing.T) {
+	assert.Equal(t, ManagedAnnotations{
+		ManagedBy:          LabelManagedBy,
+		Piped:              LabelPiped,
+		Application:        LabelApplication,
+		CommitHash:         LabelCommitHash,
+		ResourceKey:        LabelResourceKey,
+		OriginalAPIVersion: LabelOriginalAPIVersion,
+	}, DefaultManagedAnnotations)
+
+	assert.Equal(t, ManagedAnnotations{
+		ManagedBy:          "example.com/managed-by",
+		Piped:              "example.com/piped",
+		Application:        "example.com/application",
+		CommitHash:         "example.com/commit-hash",
+		ResourceKey:        "example.com/resource-key",
+		OriginalAPIVersion: "example.com/original-api-version",
+	}, NewManagedAnnotations("example.com"))
+}
@@ -18,9 +18,17 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"os/exec"
+	"sort"
 
 	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
+)
+
+const (
+	// The flags of kustomize v4 to inflate the helm charts by using the helm binary.
+	kustomizeEnableHelmFlag  = "enable-helm"
+	kustomizeHelmCommandFlag = "helm-command"
 )
 
 type Kustomize struct {
@@ -37,21 +45,14 @@ func NewKustomize(version, path string, logger *zap.Logger) *Kustomize {
 	}
 }
 
-func (c *Kustomize) Template(ctx context.Context, appName, appDir string, opts map[string]string) (string, error) {
-	args := []string{
-		"build",
-		".",
-	}
-
-	for k, v := range opts {
-		args = append(args, fmt.Sprintf("--%s", k))
-		if v != "" {
-			args = append(args, v)
-		}
-	}
+// Template renders the kustomization in the given application directory
+// with the given options such as "load-restrictor" or "enable-helm".
+// The helm binary at helmPath is used to inflate the helm charts when it is not empty.
+func (c *Kustomize) Template(ctx context.Context, appName, appDir string, opts map[string]string, helmPath string) (string, error) {
+	args := makeKustomizeBuildArgs(opts, helmPath)
 
 	var stdout, stderr bytes.Buffer
-	cmd := exec.CommandContext(ctx, c.execPath, args...)
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
 	cmd.Dir = appDir
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -65,3 +66,34 @@ func (c *Kustomize) Template(ctx context.Context, appName, appDir string, opts m
 	}
 	return stdout.String(), nil
 }
+
+// makeKustomizeBuildArgs returns the arguments for "kustomize build" in a stable order.
+func makeKustomizeBuildArgs(opts map[string]string, helmPath string) []string {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := []string{
+		"build",
+		".",
+	}
+	for _, k := range keys {
+		args = append(args, fmt.Sprintf("--%s", k))
+		if v := opts[k]; v != "" {
+			args = append(args, v)
+		}
+	}
+
+	if _, ok := opts[kustomizeHelmCommandFlag]; !ok && helmPath != "" {
+		args = append(args, "--"+kustomizeHelmCommandFlag, helmPath)
+	}
+	return args
+}
+
+// kustomizeNeedsHelm reports whether the given options enable the helm chart inflation.
+func kustomizeNeedsHelm(opts map[string]string) bool {
+	_, ok := opts[kustomizeEnableHelmFlag]
+	return ok
+}
@@ -38,7 +38,52 @@ func TestKustomizeTemplate(t *testing.T) {
 	kustomize := NewKustomize("", kustomizePath, zap.NewNop())
 	out, err := kustomize.Template(ctx, appName, appDir, map[string]string{
 		"load_restrictor": "LoadRestrictionsNone",
-	})
+	}, "")
 	require.NoError(t, err)
 	assert.True(t, len(out) > 0)
 }
+
+func TestMakeKustomizeBuildArgs(t *testing.T) {
+	testcases := []struct {
+		name     string
+		opts     map[string]string
+		helmPath string
+		expected []string
+	}{
+		{
+			name:     "no option",
+			expected: []string{"build", "."},
+		},
+		{
+			name: "options are sorted",
+			opts: map[string]string{
+				"load-restrictor":      "LoadRestrictionsNone",
+				"enable-alpha-plugins": "",
+			},
+			expected: []string{"build", ".", "--enable-alpha-plugins", "--load-restrictor", "LoadRestrictionsNone"},
+		},
+		{
+			name: "helm is enabled",
+			opts: map[string]string{
+				"enable-helm": "",
+			},
+			helmPath: "/bin/helm-3.5.2",
+			expected: []string{"build", ".", "--enable-helm", "--helm-command", "/bin/helm-3.5.2"},
+		},
+		{
+			name: "helm command was specified",
+			opts: map[string]string{
+				"enable-helm":  "",
+				"helm-command": "/usr/local/bin/helm",
+			},
+			helmPath: "/bin/helm-3.5.2",
+			expected: []string{"build", ".", "--enable-helm", "--helm-command", "/usr/local/bin/helm"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := makeKustomizeBuildArgs(tc.opts, tc.helmPath)
+			assert.Equal(t, tc.expected, args)
+		})
+	}
+}
@@ -151,7 +151,15 @@ func (m Manifest) ConvertToStructuredObject(o interface{}) error {
 	return json.Unmarshal(data, o)
 }
 
+// Diff calculates the diff between two manifests.
+// The equivalent resource quantities such as "1000m" and 1 are considered as equal
+// and the elements of the lists having strategic merge keys, e.g. containers, env,
+// are matched by their keys so reordering them is not considered as a diff.
 func Diff(first, second Manifest, opts ...diff.Option) (*diff.Result, error) {
+	opts = append([]diff.Option{
+		diff.WithNormalizeQuantities(),
+		diff.WithMatchByMergeKeys(),
+	}, opts...)
 	return diff.DiffUnstructureds(*first.u, *second.u, opts...)
 }
 
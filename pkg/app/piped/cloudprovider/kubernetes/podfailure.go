@@ -0,0 +1,102 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// podFailureWaitingReasons contains the reasons of waiting containers
+// those are not expected to be recovered without changing the manifests.
+var podFailureWaitingReasons = map[string]struct{}{
+	"CrashLoopBackOff":           {},
+	"ImagePullBackOff":           {},
+	"ErrImagePull":               {},
+	"InvalidImageName":           {},
+	"CreateContainerConfigError": {},
+}
+
+// podFailureTerminatedReasons contains the reasons of terminated containers
+// those are considered as failures.
+var podFailureTerminatedReasons = map[string]struct{}{
+	"OOMKilled": {},
+}
+
+// FindPodFailure reports whether any container of the given live pod is failing
+// because of the reasons such as CrashLoopBackOff, ImagePullBackOff or OOMKilled.
+// The returned string describes all found failures.
+func FindPodFailure(m Manifest) (string, bool) {
+	if m.Key.Kind != KindPod {
+		return "", false
+	}
+	p := &corev1.Pod{}
+	if err := scheme.Scheme.Convert(m.u, p, nil); err != nil {
+		return "", false
+	}
+
+	statuses := make([]corev1.ContainerStatus, 0, len(p.Status.InitContainerStatuses)+len(p.Status.ContainerStatuses))
+	statuses = append(statuses, p.Status.InitContainerStatuses...)
+	statuses = append(statuses, p.Status.ContainerStatuses...)
+
+	var failures []string
+	for _, s := range statuses {
+		if w := s.State.Waiting; w != nil {
+			if _, ok := podFailureWaitingReasons[w.Reason]; ok {
+				failures = append(failures, formatContainerFailure(s.Name, w.Reason, w.Message))
+				continue
+			}
+		}
+		for _, t := range []*corev1.ContainerStateTerminated{s.State.Terminated, s.LastTerminationState.Terminated} {
+			if t == nil {
+				continue
+			}
+			if _, ok := podFailureTerminatedReasons[t.Reason]; ok {
+				failures = append(failures, formatContainerFailure(s.Name, t.Reason, t.Message))
+				break
+			}
+		}
+	}
+	if len(failures) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("pod %s is failing: %s", m.Key.Name, strings.Join(failures, ", ")), true
+}
+
+func formatContainerFailure(container, reason, message string) string {
+	if message == "" {
+		return fmt.Sprintf("container %s is in %s", container, reason)
+	}
+	return fmt.Sprintf("container %s is in %s (%s)", container, reason, message)
+}
+
+// IsWarningEvent reports whether the given live event is a Warning one.
+func IsWarningEvent(m Manifest) bool {
+	eventType, _, _ := unstructured.NestedString(m.u.Object, "type")
+	return eventType == "Warning"
+}
+
+// DescribeEvent returns a one-line description of the given live event.
+// e.g. Warning BackOff: Back-off restarting failed container
+func DescribeEvent(m Manifest) string {
+	eventType, _, _ := unstructured.NestedString(m.u.Object, "type")
+	reason, _, _ := unstructured.NestedString(m.u.Object, "reason")
+	message, _, _ := unstructured.NestedString(m.u.Object, "message")
+	return fmt.Sprintf("%s %s: %s", eventType, reason, message)
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPodFailure(t *testing.T) {
+	testcases := []struct {
+		name         string
+		manifest     string
+		expected     string
+		expectedFail bool
+	}{
+		{
+			name: "running pod",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+status:
+  phase: Running
+  containerStatuses:
+  - name: app
+    state:
+      running: {}
+`,
+			expectedFail: false,
+		},
+		{
+			name: "crash looping container",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+status:
+  phase: Running
+  containerStatuses:
+  - name: app
+    state:
+      waiting:
+        reason: CrashLoopBackOff
+        message: back-off 10s restarting failed container
+`,
+			expected:     "pod app is failing: container app is in CrashLoopBackOff (back-off 10s restarting failed container)",
+			expectedFail: true,
+		},
+		{
+			name: "image pull failure in init container",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+status:
+  phase: Pending
+  initContainerStatuses:
+  - name: init
+    state:
+      waiting:
+        reason: ImagePullBackOff
+`,
+			expected:     "pod app is failing: container init is in ImagePullBackOff",
+			expectedFail: true,
+		},
+		{
+			name: "oom killed container",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+status:
+  phase: Running
+  containerStatuses:
+  - name: app
+    state:
+      running: {}
+    lastState:
+      terminated:
+        reason: OOMKilled
+        exitCode: 137
+`,
+			expected:     "pod app is failing: container app is in OOMKilled",
+			expectedFail: true,
+		},
+		{
+			name: "not a pod",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`,
+			expectedFail: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			desc, failed := FindPodFailure(manifests[0])
+			assert.Equal(t, tc.expectedFail, failed)
+			assert.Equal(t, tc.expected, desc)
+		})
+	}
+}
+
+func TestParseManifestList(t *testing.T) {
+	ms, err := parseManifestList([]byte(`{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Event",
+      "metadata": {"name": "app.1", "namespace": "default"},
+      "type": "Warning",
+      "reason": "BackOff",
+      "message": "Back-off restarting failed container"
+    }
+  ]
+}`))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(ms))
+	assert.Equal(t, ResourceKey{APIVersion: "v1", Kind: "Event", Namespace: "default", Name: "app.1"}, ms[0].Key)
+	assert.Equal(t, "Warning BackOff: Back-off restarting failed container", DescribeEvent(ms[0]))
+}
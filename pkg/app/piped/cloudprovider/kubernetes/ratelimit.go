@@ -0,0 +1,88 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimiter returns a Provider that waits for the given limiter
+// before sending requests to the cluster.
+// The given provider is returned as is when the limiter is nil.
+func WithRateLimiter(p Provider, limiter *rate.Limiter) Provider {
+	if limiter == nil {
+		return p
+	}
+	return &rateLimitedProvider{
+		Provider: p,
+		limiter:  limiter,
+	}
+}
+
+type rateLimitedProvider struct {
+	Provider
+	limiter *rate.Limiter
+}
+
+func (p *rateLimitedProvider) Apply(ctx context.Context, manifests []Manifest) (string, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return p.Provider.Apply(ctx, manifests)
+}
+
+func (p *rateLimitedProvider) ApplyManifest(ctx context.Context, manifest Manifest) error {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return p.Provider.ApplyManifest(ctx, manifest)
+}
+
+func (p *rateLimitedProvider) Delete(ctx context.Context, key ResourceKey) error {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return p.Provider.Delete(ctx, key)
+}
+
+func (p *rateLimitedProvider) GetManifest(ctx context.Context, key ResourceKey) (Manifest, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return Manifest{}, err
+	}
+	return p.Provider.GetManifest(ctx, key)
+}
+
+func (p *rateLimitedProvider) ListPods(ctx context.Context, namespace string, labels map[string]string) ([]Manifest, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.Provider.ListPods(ctx, namespace, labels)
+}
+
+func (p *rateLimitedProvider) ListEvents(ctx context.Context, key ResourceKey) ([]Manifest, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.Provider.ListEvents(ctx, key)
+}
+
+func (p *rateLimitedProvider) GetPodLogs(ctx context.Context, key ResourceKey, tailLines int) (string, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return p.Provider.GetPodLogs(ctx, key, tailLines)
+}
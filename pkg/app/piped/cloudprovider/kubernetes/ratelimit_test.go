@@ -0,0 +1,52 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+type fakeApplier struct {
+	Provider
+	applied int
+}
+
+func (p *fakeApplier) Apply(_ context.Context, _ []Manifest) (string, error) {
+	p.applied++
+	return "", nil
+}
+
+func TestWithRateLimiter(t *testing.T) {
+	p := &fakeApplier{}
+	assert.Equal(t, Provider(p), WithRateLimiter(p, nil))
+
+	limited := WithRateLimiter(p, rate.NewLimiter(rate.Limit(0.001), 1))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The first call uses the burst.
+	_, err := limited.Apply(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.applied)
+
+	// The second call has to wait so it must be stopped by the cancelled context.
+	cancel()
+	_, err = limited.Apply(ctx, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, p.applied)
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// ResourceActionExecutor executes the actions requested against
+// the live resources of Kubernetes applications.
+type ResourceActionExecutor struct {
+	logger *zap.Logger
+}
+
+func NewResourceActionExecutor(logger *zap.Logger) *ResourceActionExecutor {
+	return &ResourceActionExecutor{
+		logger: logger.Named("kubernetes-resource-action"),
+	}
+}
+
+// Execute executes the given action by using the default version of kubectl
+// and returns the output of the action such as the logs of the pod.
+func (e *ResourceActionExecutor) Execute(ctx context.Context, action *model.Command_ExecuteResourceAction) ([]byte, error) {
+	if !IsKubernetesBuiltInResource(action.ResourceApiVersion) {
+		return nil, fmt.Errorf("resource action is not available for %s", action.ResourceApiVersion)
+	}
+	if !action.Action.IsAvailableFor(action.ResourceKind) {
+		return nil, fmt.Errorf("%s action is not available for %s", action.Action, action.ResourceKind)
+	}
+
+	path, installed, err := toolregistry.DefaultRegistry().Kubectl(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("no kubectl (%v)", err)
+	}
+	if installed {
+		e.logger.Info("kubectl has just been installed because of no pre-installed binary")
+	}
+	kubectl := NewKubectl("", path)
+
+	key := ResourceKey{
+		APIVersion: action.ResourceApiVersion,
+		Kind:       action.ResourceKind,
+		Namespace:  action.ResourceNamespace,
+		Name:       action.ResourceName,
+	}
+	switch action.Action {
+	case model.Command_ExecuteResourceAction_RESTART_WORKLOAD:
+		return nil, kubectl.RolloutRestart(ctx, key.Namespace, key)
+	case model.Command_ExecuteResourceAction_SCALE_WORKLOAD:
+		return nil, kubectl.Scale(ctx, key.Namespace, key, int(action.Replicas))
+	case model.Command_ExecuteResourceAction_DELETE_POD:
+		return nil, kubectl.Delete(ctx, key.Namespace, key)
+	case model.Command_ExecuteResourceAction_GET_POD_LOGS:
+		logs, err := kubectl.Logs(ctx, key.Namespace, key.Name, int(action.TailLines))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(logs), nil
+	default:
+		return nil, fmt.Errorf("unsupported resource action: %s", action.Action)
+	}
+}
@@ -76,6 +76,9 @@ const (
 	KindRoleBinding           = "RoleBinding"
 	KindClusterRole           = "ClusterRole"
 	KindClusterRoleBinding    = "ClusterRoleBinding"
+	KindNamespace             = "Namespace"
+
+	KindCustomResourceDefinition = "CustomResourceDefinition"
 
 	DefaultNamespace = "default"
 )
@@ -0,0 +1,87 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// The reason of Progressing condition added to a Deployment when its newest replica set
+// fails to show any progress within the given deadline (progressDeadlineSeconds).
+const progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+// CheckRolloutStatus reports whether the rollout of the workload of the given live manifest
+// has been completed as `kubectl rollout status` does.
+// The description of the current progress is returned while the rollout is in progress,
+// and an error is returned when the rollout was considered failed,
+// e.g. the Deployment exceeded its progress deadline or the Job failed.
+func CheckRolloutStatus(m Manifest) (bool, string, error) {
+	if m.Key.Kind == KindJob {
+		done, err := CheckJobStatus(m)
+		if !done && err == nil {
+			return false, "Job is in progress", nil
+		}
+		return done, "", err
+	}
+	if m.Key.Kind == KindDeployment && isProgressDeadlineExceeded(m.u) {
+		return false, "", fmt.Errorf("%s exceeded its progress deadline", m.Key.ReadableString())
+	}
+	status, desc := determineResourceHealth(m.Key, m.u)
+	if status == model.KubernetesResourceState_OTHER {
+		return false, desc, nil
+	}
+	return true, "", nil
+}
+
+// CheckJobStatus reports whether the Job of the given live manifest has been completed.
+// An error is returned when the Job was failed.
+func CheckJobStatus(m Manifest) (bool, error) {
+	conditions, _, _ := unstructured.NestedSlice(m.u.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["status"] != "True" {
+			continue
+		}
+		switch cond["type"] {
+		case "Failed":
+			return false, fmt.Errorf("%s failed: %v", m.Key.ReadableString(), cond["message"])
+		case "Complete":
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isProgressDeadlineExceeded(u *unstructured.Unstructured) bool {
+	// The condition may be the one of the previous rollout
+	// until the controller observes the latest generation.
+	observed, _, _ := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+	if observed < u.GetGeneration() {
+		return false
+	}
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Progressing" {
+			continue
+		}
+		return cond["reason"] == progressDeadlineExceededReason
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRolloutStatus(t *testing.T) {
+	testcases := []struct {
+		name     string
+		manifest string
+		done     bool
+		wantErr  bool
+	}{
+		{
+			name: "completed deployment",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  generation: 2
+spec:
+  replicas: 2
+status:
+  observedGeneration: 2
+  replicas: 2
+  updatedReplicas: 2
+  availableReplicas: 2
+  conditions:
+  - type: Progressing
+    status: "True"
+    reason: NewReplicaSetAvailable
+`,
+			done: true,
+		},
+		{
+			name: "deployment in progress",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  generation: 2
+spec:
+  replicas: 2
+status:
+  observedGeneration: 2
+  replicas: 3
+  updatedReplicas: 1
+  availableReplicas: 2
+  conditions:
+  - type: Progressing
+    status: "True"
+    reason: ReplicaSetUpdated
+`,
+			done: false,
+		},
+		{
+			name: "deployment exceeded its progress deadline",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  generation: 2
+spec:
+  replicas: 2
+status:
+  observedGeneration: 2
+  replicas: 3
+  updatedReplicas: 1
+  availableReplicas: 2
+  conditions:
+  - type: Progressing
+    status: "False"
+    reason: ProgressDeadlineExceeded
+`,
+			wantErr: true,
+		},
+		{
+			name: "deadline exceeded condition of the previous generation",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  generation: 3
+spec:
+  replicas: 2
+status:
+  observedGeneration: 2
+  replicas: 3
+  updatedReplicas: 1
+  availableReplicas: 2
+  conditions:
+  - type: Progressing
+    status: "False"
+    reason: ProgressDeadlineExceeded
+`,
+			done: false,
+		},
+		{
+			name: "statefulset in progress",
+			manifest: `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: app
+  generation: 1
+spec:
+  replicas: 2
+status:
+  observedGeneration: 1
+  readyReplicas: 1
+`,
+			done: false,
+		},
+		{
+			name: "job in progress",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  active: 1
+`,
+			done: false,
+		},
+		{
+			name: "failed job",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  conditions:
+  - type: Failed
+    status: "True"
+    message: Job has reached the specified backoff limit
+`,
+			done:    false,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			done, _, err := CheckRolloutStatus(manifests[0])
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.done, done)
+		})
+	}
+}
+
+func TestCheckJobStatus(t *testing.T) {
+	testcases := []struct {
+		name     string
+		manifest string
+		done     bool
+		wantErr  bool
+	}{
+		{
+			name: "running job",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  active: 1
+`,
+			done: false,
+		},
+		{
+			name: "completed job",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  succeeded: 1
+  conditions:
+  - type: Complete
+    status: "True"
+`,
+			done: true,
+		},
+		{
+			name: "failed job",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  failed: 6
+  conditions:
+  - type: Failed
+    status: "True"
+    reason: BackoffLimitExceeded
+    message: Job has reached the specified backoff limit
+`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			done, err := CheckJobStatus(manifests[0])
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.done, done)
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/pipe-cd/pipe/pkg/model"
@@ -55,6 +56,7 @@ func MakeKubernetesResourceState(uid string, key ResourceKey, obj *unstructured.
 
 		HealthStatus:      status,
 		HealthDescription: desc,
+		References:        findReferences(key, obj),
 
 		CreatedAt: creationTime.Unix(),
 		UpdatedAt: now.Unix(),
@@ -63,6 +65,114 @@ func MakeKubernetesResourceState(uid string, key ResourceKey, obj *unstructured.
 	return state
 }
 
+// findReferences returns the sorted list of ConfigMaps, Secrets and PersistentVolumeClaims
+// referenced by the pod template of the given workload.
+func findReferences(key ResourceKey, obj *unstructured.Unstructured) []*model.KubernetesResourceReference {
+	if !IsKubernetesBuiltInResource(key.APIVersion) {
+		return nil
+	}
+
+	var fields []string
+	switch key.Kind {
+	case KindPod:
+		fields = []string{"spec"}
+	case KindDeployment, KindStatefulSet, KindDaemonSet, KindReplicaSet, KindJob:
+		fields = []string{"spec", "template", "spec"}
+	case KindCronJob:
+		fields = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+
+	m, ok, err := unstructured.NestedMap(obj.Object, fields...)
+	if err != nil || !ok {
+		return nil
+	}
+	spec := &corev1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, spec); err != nil {
+		return nil
+	}
+
+	type reference struct {
+		kind string
+		name string
+	}
+	var (
+		refs = make(map[reference]struct{})
+		add  = func(kind, name string) {
+			if name != "" {
+				refs[reference{kind: kind, name: name}] = struct{}{}
+			}
+		}
+	)
+
+	for _, v := range spec.Volumes {
+		switch {
+		case v.ConfigMap != nil:
+			add(KindConfigMap, v.ConfigMap.Name)
+		case v.Secret != nil:
+			add(KindSecret, v.Secret.SecretName)
+		case v.PersistentVolumeClaim != nil:
+			add(KindPersistentVolumeClaim, v.PersistentVolumeClaim.ClaimName)
+		case v.Projected != nil:
+			for _, s := range v.Projected.Sources {
+				if s.ConfigMap != nil {
+					add(KindConfigMap, s.ConfigMap.Name)
+				}
+				if s.Secret != nil {
+					add(KindSecret, s.Secret.Name)
+				}
+			}
+		}
+	}
+
+	containers := append(spec.InitContainers, spec.Containers...)
+	for _, c := range containers {
+		for _, e := range c.EnvFrom {
+			if e.ConfigMapRef != nil {
+				add(KindConfigMap, e.ConfigMapRef.Name)
+			}
+			if e.SecretRef != nil {
+				add(KindSecret, e.SecretRef.Name)
+			}
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if e.ValueFrom.ConfigMapKeyRef != nil {
+				add(KindConfigMap, e.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if e.ValueFrom.SecretKeyRef != nil {
+				add(KindSecret, e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+
+	for _, s := range spec.ImagePullSecrets {
+		add(KindSecret, s.Name)
+	}
+
+	if len(refs) == 0 {
+		return nil
+	}
+	out := make([]*model.KubernetesResourceReference, 0, len(refs))
+	for r := range refs {
+		out = append(out, &model.KubernetesResourceReference{
+			Kind:      r.kind,
+			Name:      r.name,
+			Namespace: obj.GetNamespace(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
 func determineResourceHealth(key ResourceKey, obj *unstructured.Unstructured) (status model.KubernetesResourceState_HealthStatus, desc string) {
 	if !IsKubernetesBuiltInResource(key.APIVersion) {
 		desc = fmt.Sprintf("Unreadable resource kind %s/%s", key.APIVersion, key.Kind)
@@ -0,0 +1,128 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestFindReferences(t *testing.T) {
+	testcases := []struct {
+		name     string
+		manifest string
+		expected []*model.KubernetesResourceReference
+	}{
+		{
+			name: "deployment",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+  namespace: default
+spec:
+  template:
+    spec:
+      imagePullSecrets:
+      - name: registry
+      initContainers:
+      - name: init
+        envFrom:
+        - configMapRef:
+            name: init-config
+      containers:
+      - name: helloworld
+        env:
+        - name: PASSWORD
+          valueFrom:
+            secretKeyRef:
+              name: credentials
+              key: password
+        - name: MODE
+          valueFrom:
+            configMapKeyRef:
+              name: config
+              key: mode
+      volumes:
+      - name: config
+        configMap:
+          name: config
+      - name: data
+        persistentVolumeClaim:
+          claimName: data
+      - name: projected
+        projected:
+          sources:
+          - secret:
+              name: tls
+`,
+			expected: []*model.KubernetesResourceReference{
+				{Kind: "ConfigMap", Name: "config", Namespace: "default"},
+				{Kind: "ConfigMap", Name: "init-config", Namespace: "default"},
+				{Kind: "PersistentVolumeClaim", Name: "data", Namespace: "default"},
+				{Kind: "Secret", Name: "credentials", Namespace: "default"},
+				{Kind: "Secret", Name: "registry", Namespace: "default"},
+				{Kind: "Secret", Name: "tls", Namespace: "default"},
+			},
+		},
+		{
+			name: "cronjob",
+			manifest: `
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: simple
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          volumes:
+          - name: secret
+            secret:
+              secretName: credentials
+`,
+			expected: []*model.KubernetesResourceReference{
+				{Kind: "Secret", Name: "credentials"},
+			},
+		},
+		{
+			name: "not workload",
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  mode: debug
+`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			got := findReferences(manifests[0].Key, manifests[0].u)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
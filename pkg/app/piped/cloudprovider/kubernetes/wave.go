@@ -0,0 +1,141 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// AnnotationApplyWave is the annotation used to specify the wave of a manifest.
+// The manifests in a lower wave are applied before the ones in a higher wave.
+const AnnotationApplyWave = "pipecd.dev/apply-wave"
+
+// defaultKindWaves contains the waves of the well-known kinds
+// those are commonly depended by other resources.
+var defaultKindWaves = map[string]int{
+	KindCustomResourceDefinition: -2,
+	KindNamespace:                -2,
+	"Issuer":                     -1,
+	"ClusterIssuer":              -1,
+}
+
+// ManifestWave is a group of manifests those can be applied at the same time.
+type ManifestWave struct {
+	Wave      int
+	Manifests []Manifest
+}
+
+// GroupManifestsByWave groups the given manifests into waves sorted in applying order.
+// The wave of each manifest is decided by the following priority:
+// - the value of its AnnotationApplyWave annotation
+// - the wave configured for its kind in the given kindWaves
+// - the default wave of its kind
+// - 0
+// The order of manifests inside a wave is preserved.
+func GroupManifestsByWave(manifests []Manifest, kindWaves map[string]int) ([]ManifestWave, error) {
+	waves := make(map[int][]Manifest)
+	for _, m := range manifests {
+		wave, err := determineWave(m, kindWaves)
+		if err != nil {
+			return nil, err
+		}
+		waves[wave] = append(waves[wave], m)
+	}
+
+	out := make([]ManifestWave, 0, len(waves))
+	for wave, ms := range waves {
+		out = append(out, ManifestWave{
+			Wave:      wave,
+			Manifests: ms,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Wave < out[j].Wave
+	})
+	return out, nil
+}
+
+func determineWave(m Manifest, kindWaves map[string]int) (int, error) {
+	if v, ok := m.GetAnnotations()[AnnotationApplyWave]; ok {
+		wave, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s annotation %q of %s: %w", AnnotationApplyWave, v, m.Key.ReadableString(), err)
+		}
+		return wave, nil
+	}
+	if wave, ok := kindWaves[m.Key.Kind]; ok {
+		return wave, nil
+	}
+	return defaultKindWaves[m.Key.Kind], nil
+}
+
+// IsManifestReady reports whether the resource of the given live manifest
+// is ready to be used by the resources in the next waves.
+// The resources whose readiness can not be determined are considered as ready.
+func IsManifestReady(m Manifest) (bool, string) {
+	switch {
+	case m.Key.Kind == KindCustomResourceDefinition:
+		if isConditionTrue(m.u, "Established") {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s is not established yet", m.Key.ReadableString())
+
+	case m.Key.Kind == KindNamespace:
+		phase, _, _ := unstructured.NestedString(m.u.Object, "status", "phase")
+		if phase == "" || phase == "Active" {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s is in %s phase", m.Key.ReadableString(), phase)
+
+	case IsKubernetesBuiltInResource(m.Key.APIVersion):
+		status, desc := determineResourceHealth(m.Key, m.u)
+		if status == model.KubernetesResourceState_OTHER {
+			return false, desc
+		}
+		return true, ""
+
+	default:
+		// Most of the custom resources report their readiness by the Ready condition.
+		if ok, found := findCondition(m.u, "Ready"); found && !ok {
+			return false, fmt.Sprintf("%s is not ready yet", m.Key.ReadableString())
+		}
+		return true, ""
+	}
+}
+
+func isConditionTrue(u *unstructured.Unstructured, conditionType string) bool {
+	ok, found := findCondition(u, conditionType)
+	return found && ok
+}
+
+// findCondition returns whether the status of the given condition type is True
+// and whether that condition was found.
+func findCondition(u *unstructured.Unstructured, conditionType string) (bool, bool) {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != conditionType {
+			continue
+		}
+		return cond["status"] == "True", true
+	}
+	return false, false
+}
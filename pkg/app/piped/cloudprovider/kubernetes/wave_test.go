@@ -0,0 +1,212 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupManifestsByWave(t *testing.T) {
+	manifests, err := ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: cert
+---
+apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: issuer
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    pipecd.dev/apply-wave: "-3"
+`)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name      string
+		kindWaves map[string]int
+		expected  map[int][]string
+	}{
+		{
+			name: "default waves",
+			expected: map[int][]string{
+				-3: {"config"},
+				-2: {"certificates.cert-manager.io"},
+				-1: {"issuer"},
+				0:  {"app", "cert"},
+			},
+		},
+		{
+			name: "configured kind waves",
+			kindWaves: map[string]int{
+				"Certificate": 1,
+				"Issuer":      0,
+			},
+			expected: map[int][]string{
+				-3: {"config"},
+				-2: {"certificates.cert-manager.io"},
+				0:  {"app", "issuer"},
+				1:  {"cert"},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			waves, err := GroupManifestsByWave(manifests, tc.kindWaves)
+			require.NoError(t, err)
+
+			got := make(map[int][]string, len(waves))
+			for i, w := range waves {
+				if i > 0 {
+					assert.Less(t, waves[i-1].Wave, w.Wave)
+				}
+				for _, m := range w.Manifests {
+					got[w.Wave] = append(got[w.Wave], m.Key.Name)
+				}
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestGroupManifestsByWaveInvalidAnnotation(t *testing.T) {
+	manifests, err := ParseManifests(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    pipecd.dev/apply-wave: first
+`)
+	require.NoError(t, err)
+
+	_, err = GroupManifestsByWave(manifests, nil)
+	assert.Error(t, err)
+}
+
+func TestIsManifestReady(t *testing.T) {
+	testcases := []struct {
+		name     string
+		manifest string
+		expected bool
+	}{
+		{
+			name: "established crd",
+			manifest: `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+status:
+  conditions:
+  - type: NamesAccepted
+    status: "True"
+  - type: Established
+    status: "True"
+`,
+			expected: true,
+		},
+		{
+			name: "not yet established crd",
+			manifest: `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+status:
+  conditions:
+  - type: Established
+    status: "False"
+`,
+			expected: false,
+		},
+		{
+			name: "active namespace",
+			manifest: `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: foo
+status:
+  phase: Active
+`,
+			expected: true,
+		},
+		{
+			name: "terminating namespace",
+			manifest: `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: foo
+status:
+  phase: Terminating
+`,
+			expected: false,
+		},
+		{
+			name: "not ready custom resource",
+			manifest: `
+apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: issuer
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+`,
+			expected: false,
+		},
+		{
+			name: "custom resource without conditions",
+			manifest: `
+apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: issuer
+`,
+			expected: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			ready, _ := IsManifestReady(manifests[0])
+			assert.Equal(t, tc.expected, ready)
+		})
+	}
+}
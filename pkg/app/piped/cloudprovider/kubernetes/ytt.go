@@ -0,0 +1,80 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+type Ytt struct {
+	version  string
+	execPath string
+	logger   *zap.Logger
+}
+
+func NewYtt(version, path string, logger *zap.Logger) *Ytt {
+	return &Ytt{
+		version:  version,
+		execPath: path,
+		logger:   logger,
+	}
+}
+
+func (c *Ytt) Template(ctx context.Context, appName, appDir string, opts *config.InputYttOptions) (string, error) {
+	args := make([]string, 0)
+	files := []string{"."}
+	if opts != nil && len(opts.Files) > 0 {
+		files = opts.Files
+	}
+	for _, f := range files {
+		args = append(args, "--file", f)
+	}
+	if opts != nil {
+		for _, f := range opts.DataValuesFiles {
+			args = append(args, "--data-values-file", f)
+		}
+		keys := make([]string, 0, len(opts.DataValues))
+		for k := range opts.DataValues {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			args = append(args, "--data-value", fmt.Sprintf("%s=%s", k, opts.DataValues[k]))
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := executil.CommandContext(ctx, c.execPath, args...)
+	cmd.Dir = appDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	c.logger.Info(fmt.Sprintf("start templating a ytt application %s", appName),
+		zap.Any("args", args),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
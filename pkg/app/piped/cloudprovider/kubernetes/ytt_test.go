@@ -0,0 +1,75 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestYttTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ytt")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A fake ytt that prints the given arguments as a manifest.
+	execPath := filepath.Join(dir, "ytt")
+	script := "#!/bin/sh\necho 'apiVersion: v1'\necho 'kind: ConfigMap'\necho \"args: $*\"\n"
+	require.NoError(t, ioutil.WriteFile(execPath, []byte(script), 0755))
+
+	testcases := []struct {
+		name     string
+		opts     *config.InputYttOptions
+		expected string
+	}{
+		{
+			name:     "no option",
+			expected: "--file .",
+		},
+		{
+			name: "with options",
+			opts: &config.InputYttOptions{
+				Files:           []string{"config", "overlays/production.yaml"},
+				DataValuesFiles: []string{"values.yaml"},
+				DataValues: map[string]string{
+					"replicas": "3",
+					"image":    "helloworld:v1",
+				},
+			},
+			expected: "--file config --file overlays/production.yaml --data-values-file values.yaml --data-value image=helloworld:v1 --data-value replicas=3",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ytt := NewYtt("", execPath, zap.NewNop())
+			out, err := ytt.Template(context.Background(), "testapp", dir, tc.opts)
+			require.NoError(t, err)
+
+			manifests, err := ParseManifests(out)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+			assert.Equal(t, tc.expected, manifests[0].u.Object["args"])
+		})
+	}
+}
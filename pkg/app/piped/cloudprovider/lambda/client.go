@@ -22,12 +22,11 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"go.uber.org/zap"
 
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/awsconfig"
 	"github.com/pipe-cd/pipe/pkg/backoff"
 )
 
@@ -42,47 +41,47 @@ const (
 // ErrNotFound lambda resource occurred.
 var ErrNotFound = errors.New("lambda resource not found")
 
+// NewRetry returns a new backoff.Retry for calling AWS Lambda APIs.
+// It does not retry the errors caused by the request itself.
+func NewRetry() backoff.Retry {
+	return backoff.NewRetry(RequestRetryTime, backoff.NewConstant(RetryIntervalDuration),
+		backoff.WithRetryable(Retriable),
+	)
+}
+
+// Retriable checks whether the caller should retry the api call for the given error.
+func Retriable(err error) bool {
+	var (
+		invalidParameter *types.InvalidParameterValueException
+		invalidRequest   *types.InvalidRequestContentException
+		notFound         *types.ResourceNotFoundException
+		codeStorage      *types.CodeStorageExceededException
+	)
+	switch {
+	case errors.As(err, &invalidParameter):
+		return false
+	case errors.As(err, &invalidRequest):
+		return false
+	case errors.As(err, &notFound):
+		return false
+	case errors.As(err, &codeStorage):
+		return false
+	default:
+		return true
+	}
+}
+
 type client struct {
 	client *lambda.Client
 	logger *zap.Logger
 }
 
-func newClient(region, profile, credentialsFile, roleARN, tokenPath string, logger *zap.Logger) (*client, error) {
-	if region == "" {
-		return nil, fmt.Errorf("region is required field")
-	}
-
+func newClient(opts awsconfig.Options, logger *zap.Logger) (*client, error) {
 	c := &client{
 		logger: logger.Named("lambda"),
 	}
 
-	optFns := []func(*config.LoadOptions) error{config.WithRegion(region)}
-	if credentialsFile != "" {
-		optFns = append(optFns, config.WithSharedCredentialsFiles([]string{credentialsFile}))
-	}
-	if profile != "" {
-		optFns = append(optFns, config.WithSharedConfigProfile(profile))
-	}
-	if tokenPath != "" && roleARN != "" {
-		optFns = append(optFns, config.WithWebIdentityRoleCredentialOptions(func(v *stscreds.WebIdentityRoleOptions) {
-			v.RoleARN = roleARN
-			v.TokenRetriever = stscreds.IdentityTokenFile(tokenPath)
-		}))
-	}
-
-	// When you initialize an aws.Config instance using config.LoadDefaultConfig, the SDK uses its default credential chain to find AWS credentials.
-	// This default credential chain looks for credentials in the following order:
-	//
-	// 1. Environment variables.
-	//   1. Static Credentials (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN)
-	//   2. Web Identity Token (AWS_WEB_IDENTITY_TOKEN_FILE)
-	// 2. Shared configuration files.
-	//   1. SDK defaults to credentials file under .aws folder that is placed in the home folder on your computer.
-	//   2. SDK defaults to config file under .aws folder that is placed in the home folder on your computer.
-	// 3. If your application uses an ECS task definition or RunTask API operation, IAM role for tasks.
-	// 4. If your application is running on an Amazon EC2 instance, IAM role for Amazon EC2.
-	// ref: https://aws.github.io/aws-sdk-go-v2/docs/configuring-sdk/#specifying-credentials
-	cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+	cfg, err := awsconfig.Load(context.Background(), opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config to create lambda client: %w", err)
 	}
@@ -139,9 +138,8 @@ func (c *client) UpdateFunction(ctx context.Context, fm FunctionManifest) error
 	}
 
 	// Update function configuration.
-	retry := backoff.NewRetry(RequestRetryTime, backoff.NewConstant(RetryIntervalDuration))
-	updateFunctionConfigurationSucceed := false
-	for retry.WaitNext(ctx) {
+	retry := NewRetry()
+	err = retry.Do(ctx, func(ctx context.Context) error {
 		configInput := &lambda.UpdateFunctionConfigurationInput{
 			FunctionName: aws.String(fm.Spec.Name),
 			MemorySize:   aws.Int32(fm.Spec.Memory),
@@ -150,15 +148,13 @@ func (c *client) UpdateFunction(ctx context.Context, fm FunctionManifest) error
 				Variables: fm.Spec.Environments,
 			},
 		}
-		_, err = c.client.UpdateFunctionConfiguration(ctx, configInput)
+		_, err := c.client.UpdateFunctionConfiguration(ctx, configInput)
 		if err != nil {
 			c.logger.Error("Failed to update function configuration")
-		} else {
-			updateFunctionConfigurationSucceed = true
-			break
 		}
-	}
-	if !updateFunctionConfigurationSucceed {
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update configuration for Lambda function %s: %w", fm.Spec.Name, err)
 	}
 
@@ -22,6 +22,8 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/awsconfig"
+	"github.com/pipe-cd/pipe/pkg/app/piped/ratelimiter"
 	"github.com/pipe-cd/pipe/pkg/config"
 )
 
@@ -67,7 +69,19 @@ func (r *registry) Client(name string, cfg *config.CloudProviderLambdaConfig, lo
 	}
 
 	c, err, _ := r.newGroup.Do(name, func() (interface{}, error) {
-		return newClient(cfg.Region, cfg.Profile, cfg.CredentialsFile, cfg.RoleARN, cfg.TokenFile, logger)
+		return newClient(awsconfig.Options{
+			Region:          cfg.Region,
+			Profile:         cfg.Profile,
+			CredentialsFile: cfg.CredentialsFile,
+			RoleARN:         cfg.RoleARN,
+			TokenFile:       cfg.TokenFile,
+			AssumeRoleARN:   cfg.AssumeRoleARN,
+			ExternalID:      cfg.ExternalID,
+			SessionTags:     cfg.SessionTags,
+			RateLimiter:     ratelimiter.DefaultRegistry().Limiter(name),
+			AllowedAccounts: cfg.AllowedAccounts,
+			AllowedRegions:  cfg.AllowedRegions,
+		}, logger)
 	})
 	if err != nil {
 		return nil, err
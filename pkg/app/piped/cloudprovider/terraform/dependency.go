@@ -0,0 +1,233 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const defaultRegistryHost = "registry.terraform.io"
+
+type DependencyKind string
+
+const (
+	DependencyProvider DependencyKind = "provider"
+	DependencyModule   DependencyKind = "module"
+)
+
+// Dependency represents a provider or a registry module required by a Terraform configuration.
+type Dependency struct {
+	Kind DependencyKind
+	// The local name of the provider or the name of the module block.
+	Name string
+	// The registry address without the hostname part.
+	// e.g. "hashicorp/aws" for providers, "terraform-aws-modules/vpc/aws" for modules.
+	Source string
+	// The version constraint specified in the configuration.
+	Constraint string
+	// The name of the file where the dependency was declared.
+	File string
+}
+
+var (
+	requiredProvidersRegex = regexp.MustCompile(`required_providers\s*\{`)
+	moduleBlockRegex       = regexp.MustCompile(`(?m)^\s*module\s+"([^"]+)"\s*\{`)
+	providerObjectRegex    = regexp.MustCompile(`([A-Za-z0-9_-]+)\s*=\s*\{([^}]*)\}`)
+	providerStringRegex    = regexp.MustCompile(`(?m)^\s*([A-Za-z0-9_-]+)\s*=\s*"([^"]*)"`)
+	sourceAttrRegex        = regexp.MustCompile(`(?m)(?:^|[\s,{])source\s*=\s*"([^"]*)"`)
+	versionAttrRegex       = regexp.MustCompile(`(?m)(?:^|[\s,{])version\s*=\s*"([^"]*)"`)
+	blockCommentRegex      = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	lineCommentRegex       = regexp.MustCompile(`(?m)^\s*(#|//).*$`)
+)
+
+// ParseDependencies parses all .tf files placed in the given directory
+// to find the required providers and the registry modules with their version constraints.
+// Dependencies without a version constraint or not hosted on the public registry are ignored.
+func ParseDependencies(dir string) ([]Dependency, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	deps := make([]Dependency, 0)
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", f, err)
+		}
+		deps = append(deps, parseDependencies(filepath.Base(f), string(data))...)
+	}
+	return deps, nil
+}
+
+func parseDependencies(file, content string) []Dependency {
+	content = blockCommentRegex.ReplaceAllString(content, "")
+	content = lineCommentRegex.ReplaceAllString(content, "")
+
+	var deps []Dependency
+	for _, loc := range requiredProvidersRegex.FindAllStringIndex(content, -1) {
+		body, ok := extractBlock(content, loc[1]-1)
+		if !ok {
+			continue
+		}
+		deps = append(deps, parseRequiredProviders(file, body)...)
+	}
+
+	for _, loc := range moduleBlockRegex.FindAllStringSubmatchIndex(content, -1) {
+		body, ok := extractBlock(content, loc[1]-1)
+		if !ok {
+			continue
+		}
+		source := findAttribute(sourceAttrRegex, body)
+		constraint := findAttribute(versionAttrRegex, body)
+		addr, ok := registryModuleAddress(source)
+		if !ok || constraint == "" {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Kind:       DependencyModule,
+			Name:       content[loc[2]:loc[3]],
+			Source:     addr,
+			Constraint: constraint,
+			File:       file,
+		})
+	}
+	return deps
+}
+
+func parseRequiredProviders(file, body string) []Dependency {
+	var deps []Dependency
+	for _, m := range providerObjectRegex.FindAllStringSubmatch(body, -1) {
+		constraint := findAttribute(versionAttrRegex, m[2])
+		addr, ok := registryProviderAddress(m[1], findAttribute(sourceAttrRegex, m[2]))
+		if !ok || constraint == "" {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Kind:       DependencyProvider,
+			Name:       m[1],
+			Source:     addr,
+			Constraint: constraint,
+			File:       file,
+		})
+	}
+	// The legacy syntax which specifies only the version constraint.
+	body = providerObjectRegex.ReplaceAllString(body, "")
+	for _, m := range providerStringRegex.FindAllStringSubmatch(body, -1) {
+		addr, _ := registryProviderAddress(m[1], "")
+		if m[2] == "" {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Kind:       DependencyProvider,
+			Name:       m[1],
+			Source:     addr,
+			Constraint: m[2],
+			File:       file,
+		})
+	}
+	return deps
+}
+
+// extractBlock returns the content between the brace at the given position
+// and its matching closing brace.
+func extractBlock(content string, open int) (string, bool) {
+	var (
+		depth    int
+		inString bool
+	)
+	for i := open; i < len(content); i++ {
+		switch c := content[i]; {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return content[open+1 : i], true
+			}
+		}
+	}
+	return "", false
+}
+
+func findAttribute(r *regexp.Regexp, body string) string {
+	m := r.FindStringSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1])
+}
+
+// registryProviderAddress returns the "namespace/type" address of the given provider.
+// A provider without source belongs to the "hashicorp" namespace.
+func registryProviderAddress(name, source string) (string, bool) {
+	if source == "" {
+		return "hashicorp/" + name, true
+	}
+	parts := strings.Split(strings.ToLower(source), "/")
+	switch len(parts) {
+	case 2:
+		return strings.Join(parts, "/"), true
+	case 3:
+		if parts[0] != defaultRegistryHost {
+			return "", false
+		}
+		return strings.Join(parts[1:], "/"), true
+	default:
+		return "", false
+	}
+}
+
+// registryModuleAddress returns the "namespace/name/provider" address of the given module source.
+// Local paths, VCS or archive sources are not registry modules.
+func registryModuleAddress(source string) (string, bool) {
+	if source == "" || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.Contains(source, "::") {
+		return "", false
+	}
+	// Remove the sub-directory part.
+	if i := strings.Index(source, "//"); i >= 0 {
+		source = source[:i]
+	}
+	parts := strings.Split(source, "/")
+	switch len(parts) {
+	case 3:
+		// Sources like "github.com/owner/repo" are not registry modules.
+		if strings.Contains(parts[0], ".") {
+			return "", false
+		}
+		return source, true
+	case 4:
+		if strings.ToLower(parts[0]) != defaultRegistryHost {
+			return "", false
+		}
+		return strings.Join(parts[1:], "/"), true
+	default:
+		return "", false
+	}
+}
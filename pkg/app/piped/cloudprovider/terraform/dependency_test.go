@@ -0,0 +1,102 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDependencies(t *testing.T) {
+	testcases := []struct {
+		name     string
+		content  string
+		expected []Dependency
+	}{
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name: "required providers",
+			content: `
+terraform {
+  required_version = ">= 0.13"
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 3.0"
+    }
+    random = { source = "registry.terraform.io/hashicorp/random", version = ">= 2.0, < 3.0" }
+    # google = { version = "~> 3.5" }
+    private = {
+      source  = "example.com/org/private"
+      version = "1.0.0"
+    }
+    null = {
+      source = "hashicorp/null"
+    }
+    template = "~> 2.1"
+  }
+}`,
+			expected: []Dependency{
+				{Kind: DependencyProvider, Name: "aws", Source: "hashicorp/aws", Constraint: "~> 3.0", File: "main.tf"},
+				{Kind: DependencyProvider, Name: "random", Source: "hashicorp/random", Constraint: ">= 2.0, < 3.0", File: "main.tf"},
+				{Kind: DependencyProvider, Name: "template", Source: "hashicorp/template", Constraint: "~> 2.1", File: "main.tf"},
+			},
+		},
+		{
+			name: "modules",
+			content: `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "2.44.0"
+
+  tags = {
+    "version" = "ignored"
+  }
+}
+
+module "iam" {
+  source  = "registry.terraform.io/terraform-aws-modules/iam/aws//modules/iam-user"
+  version = "~> 3.0"
+}
+
+module "local" {
+  source = "./modules/local"
+}
+
+module "github" {
+  source  = "github.com/hashicorp/example"
+  version = "1.0.0"
+}
+
+module "git" {
+  source = "git::https://example.com/vpc.git?ref=v1.2.0"
+}`,
+			expected: []Dependency{
+				{Kind: DependencyModule, Name: "vpc", Source: "terraform-aws-modules/vpc/aws", Constraint: "2.44.0", File: "main.tf"},
+				{Kind: DependencyModule, Name: "iam", Source: "terraform-aws-modules/iam/aws", Constraint: "~> 3.0", File: "main.tf"},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			deps := parseDependencies("main.tf", tc.content)
+			assert.Equal(t, tc.expected, deps)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultRegistryAddress = "https://" + defaultRegistryHost
+	registryRequestTimeout = 30 * time.Second
+)
+
+// Registry works as an HTTP client for the Terraform registry to find the available versions.
+// See: https://www.terraform.io/docs/internals/provider-registry-protocol.html
+type Registry struct {
+	client  *http.Client
+	address string
+}
+
+func NewRegistry(address string) *Registry {
+	if address == "" {
+		address = DefaultRegistryAddress
+	}
+	return &Registry{
+		client:  &http.Client{Timeout: registryRequestTimeout},
+		address: strings.TrimSuffix(address, "/"),
+	}
+}
+
+type registryVersion struct {
+	Version string `json:"version"`
+}
+
+// LatestVersion returns the latest stable version of the given dependency published on the registry.
+func (r *Registry) LatestVersion(ctx context.Context, dep Dependency) (string, error) {
+	var versions []registryVersion
+	switch dep.Kind {
+	case DependencyProvider:
+		var out struct {
+			Versions []registryVersion `json:"versions"`
+		}
+		if err := r.get(ctx, "/v1/providers/"+dep.Source+"/versions", &out); err != nil {
+			return "", err
+		}
+		versions = out.Versions
+	case DependencyModule:
+		var out struct {
+			Modules []struct {
+				Versions []registryVersion `json:"versions"`
+			} `json:"modules"`
+		}
+		if err := r.get(ctx, "/v1/modules/"+dep.Source+"/versions", &out); err != nil {
+			return "", err
+		}
+		for _, m := range out.Modules {
+			versions = append(versions, m.Versions...)
+		}
+	default:
+		return "", fmt.Errorf("unknown dependency kind %q", dep.Kind)
+	}
+
+	var latest *version
+	for _, rv := range versions {
+		v, err := parseVersion(rv.Version)
+		if err != nil || v.prerelease != "" {
+			continue
+		}
+		if latest == nil || v.compare(*latest) > 0 {
+			latest = &v
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no stable version of %s %s was found", dep.Kind, dep.Source)
+	}
+	return latest.String(), nil
+}
+
+func (r *Registry) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.address+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, req.URL)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
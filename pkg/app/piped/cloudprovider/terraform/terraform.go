@@ -19,12 +19,16 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
 )
 
+const applyKillGracePeriod = time.Minute
+
 type Terraform struct {
 	execPath string
 	dir      string
@@ -43,7 +47,7 @@ func NewTerraform(execPath, dir string, vars, varFiles []string) *Terraform {
 
 func (t *Terraform) Version(ctx context.Context) (string, error) {
 	args := []string{"version"}
-	cmd := exec.CommandContext(ctx, t.execPath, args...)
+	cmd := executil.CommandContext(ctx, t.execPath, args...)
 	cmd.Dir = t.dir
 
 	out, err := cmd.CombinedOutput()
@@ -66,7 +70,7 @@ func (t *Terraform) Init(ctx context.Context, w io.Writer) error {
 	}
 	args = append(args, "-lock=false", ".")
 
-	cmd := exec.CommandContext(ctx, t.execPath, args...)
+	cmd := executil.CommandContext(ctx, t.execPath, args...)
 	cmd.Dir = t.dir
 	cmd.Stdout = w
 	cmd.Stderr = w
@@ -82,7 +86,7 @@ func (t *Terraform) SelectWorkspace(ctx context.Context, workspace string) error
 		workspace,
 		".",
 	}
-	cmd := exec.CommandContext(ctx, t.execPath, args...)
+	cmd := executil.CommandContext(ctx, t.execPath, args...)
 	cmd.Dir = t.dir
 
 	out, err := cmd.CombinedOutput()
@@ -120,7 +124,7 @@ func (t *Terraform) Plan(ctx context.Context, w io.Writer) (PlanResult, error) {
 	var buf bytes.Buffer
 	stdout := io.MultiWriter(w, &buf)
 
-	cmd := exec.CommandContext(ctx, t.execPath, args...)
+	cmd := executil.CommandContext(ctx, t.execPath, args...)
 	cmd.Dir = t.dir
 	cmd.Stdout = stdout
 	cmd.Stderr = stdout
@@ -187,10 +191,13 @@ func (t *Terraform) Apply(ctx context.Context, w io.Writer) error {
 	}
 	args = append(args, ".")
 
-	cmd := exec.CommandContext(ctx, t.execPath, args...)
+	cmd := executil.CommandContext(ctx, t.execPath, args...)
 	cmd.Dir = t.dir
 	cmd.Stdout = w
 	cmd.Stderr = w
+	// Give terraform enough time to stop the running operations
+	// and release the state lock after being interrupted.
+	cmd.KillGracePeriod = applyKillGracePeriod
 
 	io.WriteString(w, fmt.Sprintf("terraform %s", strings.Join(args, " ")))
 	return cmd.Run()
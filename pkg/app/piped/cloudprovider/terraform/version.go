@@ -0,0 +1,170 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a parsed semantic version used by Terraform registry.
+type version struct {
+	segments   []int
+	prerelease string
+}
+
+func parseVersion(s string) (version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	var v version
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.prerelease = s[i+1:]
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return version{}, fmt.Errorf("invalid version %q", s)
+	}
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, fmt.Errorf("invalid version %q", s)
+		}
+		v.segments = append(v.segments, n)
+	}
+	return v, nil
+}
+
+func (v version) segment(i int) int {
+	if i < len(v.segments) {
+		return v.segments[i]
+	}
+	return 0
+}
+
+func (v version) compare(o version) int {
+	for i := 0; i < 3; i++ {
+		if a, b := v.segment(i), o.segment(i); a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.prerelease == o.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	case v.prerelease < o.prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.segment(0), v.segment(1), v.segment(2))
+	if v.prerelease != "" {
+		s += "-" + v.prerelease
+	}
+	return s
+}
+
+// versionConstraint is one of the comma-separated conditions of a Terraform version constraint.
+// See: https://www.terraform.io/docs/language/expressions/version-constraints.html
+type versionConstraint struct {
+	op     string
+	target version
+}
+
+func parseConstraint(constraint string) ([]versionConstraint, error) {
+	var cs []versionConstraint
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		op := "="
+		for _, o := range []string{"~>", ">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(c, o) {
+				op = o
+				c = strings.TrimSpace(c[len(o):])
+				break
+			}
+		}
+		target, err := parseVersion(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %w", constraint, err)
+		}
+		cs = append(cs, versionConstraint{op: op, target: target})
+	}
+	return cs, nil
+}
+
+func (c versionConstraint) allows(v version) bool {
+	cmp := v.compare(c.target)
+	switch c.op {
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		// Only the right-most specified segment is allowed to increase.
+		for i := 0; i < len(c.target.segments)-1; i++ {
+			if v.segment(i) != c.target.segments[i] {
+				return false
+			}
+		}
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// IsOutdated reports whether the given latest version is newer than
+// every version allowed by the given constraint, e.g. "4.1.0" for "~> 3.0".
+func IsOutdated(constraint, latest string) (bool, error) {
+	v, err := parseVersion(latest)
+	if err != nil {
+		return false, err
+	}
+	cs, err := parseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range cs {
+		// The latest version being refused by a lower bound or an exclusion
+		// does not mean that the constraint is out of date.
+		if c.op == ">" || c.op == "!=" {
+			continue
+		}
+		if !c.allows(v) && v.compare(c.target) >= 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
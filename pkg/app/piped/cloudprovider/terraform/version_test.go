@@ -0,0 +1,57 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOutdated(t *testing.T) {
+	testcases := []struct {
+		constraint  string
+		latest      string
+		expected    bool
+		expectedErr bool
+	}{
+		{constraint: "~> 3.0", latest: "3.45.0", expected: false},
+		{constraint: "~> 3.0", latest: "4.1.0", expected: true},
+		{constraint: "~> 3.0.1", latest: "3.0.9", expected: false},
+		{constraint: "~> 3.0.1", latest: "3.1.0", expected: true},
+		{constraint: "~> 3", latest: "4.0.0", expected: false},
+		{constraint: "2.44.0", latest: "2.44.0", expected: false},
+		{constraint: "= 2.44.0", latest: "2.70.0", expected: true},
+		{constraint: ">= 2.0, < 3.0", latest: "2.3.2", expected: false},
+		{constraint: ">= 2.0, < 3.0", latest: "3.0.0", expected: true},
+		{constraint: ">= 5.0", latest: "4.0.0", expected: false},
+		{constraint: "!= 4.0.0", latest: "4.0.0", expected: false},
+		{constraint: "<= v1.2", latest: "v1.3.0", expected: true},
+		{constraint: "~> latest", latest: "1.0.0", expectedErr: true},
+		{constraint: "~> 1.0", latest: "main", expectedErr: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.constraint+"/"+tc.latest, func(t *testing.T) {
+			outdated, err := IsOutdated(tc.constraint, tc.latest)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, outdated)
+		})
+	}
+}
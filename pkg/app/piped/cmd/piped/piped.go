@@ -18,16 +18,17 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"os/exec"
-	"path"
 	"path/filepath"
-	"strings"
+	"runtime"
 	"time"
 
+	gcpkmsapi "cloud.google.com/go/kms/apiv1"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -41,13 +42,20 @@ import (
 	"github.com/pipe-cd/pipe/pkg/app/piped/apistore/deploymentstore"
 	"github.com/pipe-cd/pipe/pkg/app/piped/apistore/environmentstore"
 	"github.com/pipe-cd/pipe/pkg/app/piped/apistore/eventstore"
+	"github.com/pipe-cd/pipe/pkg/app/piped/approvalstore"
 	"github.com/pipe-cd/pipe/pkg/app/piped/chartrepo"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/awsconfig"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/commandhandler"
 	"github.com/pipe-cd/pipe/pkg/app/piped/controller"
 	"github.com/pipe-cd/pipe/pkg/app/piped/driftdetector"
 	"github.com/pipe-cd/pipe/pkg/app/piped/eventwatcher"
+	"github.com/pipe-cd/pipe/pkg/app/piped/healthchecker"
 	"github.com/pipe-cd/pipe/pkg/app/piped/livestatereporter"
 	"github.com/pipe-cd/pipe/pkg/app/piped/livestatestore"
 	"github.com/pipe-cd/pipe/pkg/app/piped/notifier"
+	"github.com/pipe-cd/pipe/pkg/app/piped/planpreview"
+	"github.com/pipe-cd/pipe/pkg/app/piped/ratelimiter"
 	"github.com/pipe-cd/pipe/pkg/app/piped/statsreporter"
 	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
 	"github.com/pipe-cd/pipe/pkg/app/piped/trigger"
@@ -55,6 +63,10 @@ import (
 	"github.com/pipe-cd/pipe/pkg/cli"
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/crypto"
+	"github.com/pipe-cd/pipe/pkg/crypto/awskms"
+	"github.com/pipe-cd/pipe/pkg/crypto/gcpkms"
+	"github.com/pipe-cd/pipe/pkg/crypto/vault"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
 	"github.com/pipe-cd/pipe/pkg/git"
 	"github.com/pipe-cd/pipe/pkg/model"
 	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
@@ -77,6 +89,8 @@ type piped struct {
 	useFakeAPIClient                     bool
 	gracePeriod                          time.Duration
 	addLoginUserToPasswd                 bool
+	appManifestsCacheCount               int
+	appManifestsCacheSizeMB              int
 }
 
 func NewCommand() *cobra.Command {
@@ -85,9 +99,11 @@ func NewCommand() *cobra.Command {
 		panic(fmt.Sprintf("failed to detect the current user's home directory: %v", err))
 	}
 	p := &piped{
-		adminPort:   9085,
-		toolsDir:    path.Join(home, ".piped", "tools"),
-		gracePeriod: 30 * time.Second,
+		adminPort:               9085,
+		toolsDir:                filepath.Join(home, ".piped", "tools"),
+		gracePeriod:             30 * time.Second,
+		appManifestsCacheCount:  1000,
+		appManifestsCacheSizeMB: 256,
 	}
 	cmd := &cobra.Command{
 		Use:   "piped",
@@ -106,6 +122,8 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&p.enableDefaultKubernetesCloudProvider, "enable-default-kubernetes-cloud-provider", p.enableDefaultKubernetesCloudProvider, "Whether the default kubernetes provider is enabled or not.")
 	cmd.Flags().BoolVar(&p.addLoginUserToPasswd, "add-login-user-to-passwd", p.addLoginUserToPasswd, "Whether to add login user to $HOME/passwd. This is typically for applications running as a random user ID.")
 	cmd.Flags().DurationVar(&p.gracePeriod, "grace-period", p.gracePeriod, "How long to wait for graceful shutdown.")
+	cmd.Flags().IntVar(&p.appManifestsCacheCount, "app-manifests-cache-count", p.appManifestsCacheCount, "The maximum number of application manifests kept in the memory cache.")
+	cmd.Flags().IntVar(&p.appManifestsCacheSizeMB, "app-manifests-cache-size-mb", p.appManifestsCacheSizeMB, "The maximum total size in megabytes of application manifests kept in the memory cache. Zero means unlimited.")
 
 	cmd.MarkFlagRequired("config-file")
 
@@ -115,7 +133,7 @@ func NewCommand() *cobra.Command {
 func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 	group, ctx := errgroup.WithContext(ctx)
 	if p.addLoginUserToPasswd {
-		if err := p.insertLoginUserToPasswd(ctx); err != nil {
+		if err := p.insertLoginUserToPasswd(); err != nil {
 			return fmt.Errorf("failed to insert logged-in user to passwd: %w", err)
 		}
 	}
@@ -127,16 +145,40 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 		return err
 	}
 
-	// Initialize notifier and add piped events.
-	notifier, err := notifier.NewNotifier(cfg, t.Logger)
-	if err != nil {
-		t.Logger.Error("failed to initialize notifier", zap.Error(err))
+	// Prepare the workspace directory for storing the working data of deployments.
+	// The data left by the previous run (e.g. when piped crashed)
+	// are removed at this point since they may contain decrypted secrets.
+	workspaceDir := cfg.GetWorkspaceDir()
+	if err := os.RemoveAll(workspaceDir); err != nil {
+		t.Logger.Error("failed to clean up workspace directory", zap.String("dir", workspaceDir), zap.Error(err))
 		return err
 	}
+	if err := os.MkdirAll(workspaceDir, 0700); err != nil {
+		t.Logger.Error("failed to create workspace directory", zap.String("dir", workspaceDir), zap.Error(err))
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(workspaceDir); err != nil {
+			t.Logger.Error("failed to clean up workspace directory", zap.String("dir", workspaceDir), zap.Error(err))
+		}
+	}()
+
+	// Start running approval store to keep the decisions made from notifications.
+	approvalStore := approvalstore.NewStore(t.Logger)
 	group.Go(func() error {
-		return notifier.Run(ctx)
+		return approvalStore.Run(ctx)
 	})
 
+	// Initialize the handler for the interactions from Slack notifications.
+	var slackInteractionHandler *notifier.SlackInteractionHandler
+	if si := cfg.Notifications.SlackInteraction; si != nil {
+		slackInteractionHandler, err = notifier.NewSlackInteractionHandler(*si, approvalStore, t.Logger)
+		if err != nil {
+			t.Logger.Error("failed to initialize slack interaction handler", zap.Error(err))
+			return err
+		}
+	}
+
 	// Configure SSH config if needed.
 	if cfg.Git.ShouldConfigureSSHConfig() {
 		if err := git.AddSSHConfig(cfg.Git); err != nil {
@@ -146,12 +188,37 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 		t.Logger.Info("successfully configured ssh-config")
 	}
 
+	// Initialize the rate limiters shared by all clients of each cloud provider.
+	ratelimiter.InitDefaultRegistry(cfg.CloudProviders)
+
 	// Initialize default tool registry.
 	if err := toolregistry.InitDefaultRegistry(p.toolsDir, t.Logger); err != nil {
 		t.Logger.Error("failed to initialize default tool registry", zap.Error(err))
 		return err
 	}
 
+	// Make sure the credential plugins used by kubeconfigs are available.
+	for _, cp := range cfg.CloudProviders {
+		if cp.Type != model.CloudProviderKubernetes {
+			continue
+		}
+		if err := kubernetes.EnsureCredentialPlugins(ctx, cp.KubernetesConfig.KubeConfigPath, toolregistry.DefaultRegistry(), t.Logger); err != nil {
+			t.Logger.Error("failed to prepare credential plugins for kubernetes cloud provider",
+				zap.String("cloud-provider", cp.Name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// Login to configured Helm chart registries.
+	if len(cfg.ChartRegistries) > 0 {
+		reg := toolregistry.DefaultRegistry()
+		if err := chartrepo.Login(ctx, cfg.ChartRegistries, reg, t.Logger); err != nil {
+			t.Logger.Error("failed to login to configured chart registries", zap.Error(err))
+			return err
+		}
+	}
+
 	// Add configured Helm chart repositories.
 	if len(cfg.ChartRepositories) > 0 {
 		reg := toolregistry.DefaultRegistry()
@@ -174,8 +241,25 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 		return err
 	}
 
+	// Initialize notifier and add piped events.
+	notifier, err := notifier.NewNotifier(cfg, apiClient, t.Logger)
+	if err != nil {
+		t.Logger.Error("failed to initialize notifier", zap.Error(err))
+		return err
+	}
+	group.Go(func() error {
+		return notifier.Run(ctx)
+	})
+
+	// Check the health of piped components before reporting them as a part of piped meta.
+	healthChecker := healthchecker.NewChecker(cfg, toolregistry.DefaultRegistry(), t.Logger)
+	healthChecker.Check(ctx)
+	group.Go(func() error {
+		return healthChecker.Run(ctx)
+	})
+
 	// Send the newest piped meta to the control-plane.
-	if err := p.sendPipedMeta(ctx, apiClient, cfg, t.Logger); err != nil {
+	if err := p.sendPipedMeta(ctx, apiClient, cfg, healthChecker.ListComponentStatuses(), t.Logger); err != nil {
 		t.Logger.Error("failed to report piped meta to control-plane", zap.Error(err))
 		return err
 	}
@@ -194,16 +278,24 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 			w.Write([]byte("ok"))
 		})
 		admin.Handle("/metrics", t.PrometheusMetricsHandler())
+		admin.Handle("/loglevel", t.LogLevels)
 
 		group.Go(func() error {
 			return admin.Run(ctx)
 		})
 	}
 
+	// Start running the server receiving the interactions from Slack notifications.
+	if slackInteractionHandler != nil {
+		group.Go(func() error {
+			return slackInteractionHandler.Run(ctx, p.gracePeriod)
+		})
+	}
+
 	// Start running stats reporter.
 	{
 		url := fmt.Sprintf("http://localhost:%d/metrics", p.adminPort)
-		r := statsreporter.NewReporter(url, apiClient, t.Logger)
+		r := statsreporter.NewReporter(url, apiClient, healthChecker, notifier, cfg.PipedID, t.Logger)
 		group.Go(func() error {
 			return r.Run(ctx)
 		})
@@ -260,6 +352,20 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 		commandLister = store.Lister()
 	}
 
+	// Start running command handler.
+	{
+		h := commandhandler.NewHandler(
+			commandLister,
+			t.LogLevels,
+			cfg.ResourceActions,
+			kubernetes.NewResourceActionExecutor(t.Logger),
+			t.Logger,
+		)
+		group.Go(func() error {
+			return h.Run(ctx)
+		})
+	}
+
 	// Start running event store.
 	var eventGetter eventstore.Getter
 	{
@@ -271,7 +377,15 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 	}
 
 	// Create memory caches.
-	appManifestsCache := memorycache.NewTTLCache(ctx, time.Hour, time.Minute)
+	appManifestsCache, err := memorycache.NewSizedLRUCache(
+		"app-manifests",
+		memorycache.WithMaxEntries(p.appManifestsCacheCount),
+		memorycache.WithMaxBytes(int64(p.appManifestsCacheSizeMB)<<20),
+	)
+	if err != nil {
+		t.Logger.Error("failed to create the cache for application manifests", zap.Error(err))
+		return err
+	}
 
 	var liveStateGetter livestatestore.Getter
 	// Start running application live state store.
@@ -291,7 +405,7 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 		})
 	}
 
-	decrypter, err := p.initializeSealedSecretDecrypter(cfg)
+	decrypter, err := p.initializeSealedSecretDecrypter(ctx, cfg)
 	if err != nil {
 		t.Logger.Error("failed to initialize sealed secret decrypter", zap.Error(err))
 		return err
@@ -304,6 +418,8 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 			gitClient,
 			liveStateGetter,
 			apiClient,
+			environmentStore,
+			notifier,
 			appManifestsCache,
 			cfg,
 			decrypter,
@@ -314,6 +430,23 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 		})
 	}
 
+	// Start running plan preview handler.
+	{
+		b := planpreview.NewBuilder(
+			gitClient,
+			applicationLister,
+			cfg,
+			decrypter,
+			appManifestsCache,
+			filepath.Join(workspaceDir, "planpreview"),
+			t.Logger,
+		)
+		h := planpreview.NewHandler(b, commandLister, t.Logger)
+		group.Go(func() error {
+			return h.Run(ctx)
+		})
+	}
+
 	// Start running deployment controller.
 	{
 		c := controller.NewController(
@@ -325,6 +458,7 @@ func (p *piped) run(ctx context.Context, t cli.Telemetry) (runErr error) {
 			environmentStore,
 			livestatestore.LiveResourceLister{Getter: liveStateGetter},
 			notifier,
+			approvalStore.Taker(),
 			decrypter,
 			cfg,
 			appManifestsCache,
@@ -436,7 +570,7 @@ func (p *piped) loadConfig() (*config.PipedSpec, error) {
 	return cfg.PipedSpec, nil
 }
 
-func (p *piped) initializeSealedSecretDecrypter(cfg *config.PipedSpec) (crypto.Decrypter, error) {
+func (p *piped) initializeSealedSecretDecrypter(ctx context.Context, cfg *config.PipedSpec) (crypto.Decrypter, error) {
 	ssm := cfg.SealedSecretManagement
 	if ssm == nil {
 		return nil, nil
@@ -450,40 +584,91 @@ func (p *piped) initializeSealedSecretDecrypter(cfg *config.PipedSpec) (crypto.D
 		if ssm.SealingKeyConfig.PrivateKeyFile == "" {
 			return nil, fmt.Errorf("sealedSecretManagement.privateKeyFile must be set")
 		}
-		decrypter, err := crypto.NewHybridDecrypter(ssm.SealingKeyConfig.PrivateKeyFile)
+		decrypter, err := crypto.NewHybridDecrypter(ssm.SealingKeyConfig.PrivateKeyFile, ssm.SealingKeyConfig.PreviousPrivateKeyFiles...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize decrypter (%w)", err)
 		}
 		return decrypter, nil
 	case model.SealedSecretManagementGCPKMS:
-		return nil, fmt.Errorf("type %q is not implemented yet", ssm.Type.String())
+		kmsCfg := ssm.GCPKMSConfig
+		options, err := gcpcredentials.ClientOptions(ctx, gcpcredentials.Options{
+			CredentialsFile:           kmsCfg.DecryptServiceAccountFile,
+			ImpersonateServiceAccount: kmsCfg.DecryptImpersonateServiceAccount,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find gcp credentials for sealed secret management (%w)", err)
+		}
+		client, err := gcpkmsapi.NewKeyManagementClient(ctx, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gcp kms client (%w)", err)
+		}
+		decrypter, err := gcpkms.NewDecrypter(client, kmsCfg.KeyName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize decrypter (%w)", err)
+		}
+		return decrypter, nil
 
 	case model.SealedSecretManagementAWSKMS:
-		return nil, fmt.Errorf("type %q is not implemented yet", ssm.Type.String())
+		kmsCfg := ssm.AWSKMSConfig
+		awsCfg, err := awsconfig.Load(ctx, awsconfig.Options{
+			Region:          kmsCfg.Region,
+			Profile:         kmsCfg.Profile,
+			CredentialsFile: kmsCfg.CredentialsFile,
+			RoleARN:         kmsCfg.RoleARN,
+			TokenFile:       kmsCfg.TokenFile,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load aws config for sealed secret management (%w)", err)
+		}
+		decrypter, err := awskms.NewDecrypter(kms.NewFromConfig(awsCfg), kmsCfg.KeyARN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize decrypter (%w)", err)
+		}
+		return decrypter, nil
+
+	case model.SealedSecretManagementVault:
+		vaultCfg := ssm.VaultConfig
+		options := []vault.Option{
+			vault.WithTransitMount(vaultCfg.TransitMount),
+			vault.WithNamespace(vaultCfg.Namespace),
+		}
+		if vaultCfg.CAFile != "" {
+			client, err := vault.NewHTTPClient(vaultCfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create http client for vault (%w)", err)
+			}
+			options = append(options, vault.WithHTTPClient(client))
+		}
+		decrypter, err := vault.NewDecrypter(vaultCfg.Address, vaultCfg.KeyName, vaultCfg.TokenFile, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize decrypter (%w)", err)
+		}
+		return decrypter, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported sealed secret management type: %s", ssm.Type.String())
 	}
 }
 
-func (p *piped) sendPipedMeta(ctx context.Context, client pipedservice.Client, cfg *config.PipedSpec, logger *zap.Logger) error {
+func (p *piped) sendPipedMeta(ctx context.Context, client pipedservice.Client, cfg *config.PipedSpec, componentStatuses []*model.Piped_ComponentStatus, logger *zap.Logger) error {
 	repos := make([]*model.ApplicationGitRepository, 0, len(cfg.Repositories))
 	for _, r := range cfg.Repositories {
 		repos = append(repos, &model.ApplicationGitRepository{
-			Id:     r.RepoID,
-			Remote: r.Remote,
-			Branch: r.Branch,
+			Id:              r.RepoID,
+			Remote:          r.Remote,
+			Branch:          r.Branch,
+			ConfigFilenames: r.GetConfigFilenameMap(),
 		})
 	}
 
 	var (
 		req = &pipedservice.ReportPipedMetaRequest{
-			Version:        version.Get().Version,
-			Repositories:   repos,
-			CloudProviders: make([]*model.Piped_CloudProvider, 0, len(cfg.CloudProviders)),
+			Version:           version.Get().Version,
+			Repositories:      repos,
+			CloudProviders:    make([]*model.Piped_CloudProvider, 0, len(cfg.CloudProviders)),
+			ComponentStatuses: componentStatuses,
 		}
 		retry = pipedservice.NewRetry(5)
-		err   error
 	)
 
 	// Configure the list of specified cloud providers.
@@ -502,9 +687,18 @@ func (p *piped) sendPipedMeta(ctx context.Context, client pipedservice.Client, c
 			if err != nil {
 				return fmt.Errorf("failed to read public key for sealed secret management (%w)", err)
 			}
+			key, err := crypto.ParseRSAPublicKeyFromPem(bytes.TrimSpace(publicKey))
+			if err != nil {
+				return fmt.Errorf("failed to parse public key for sealed secret management (%w)", err)
+			}
+			fingerprint, err := crypto.RSAPublicKeyFingerprint(key)
+			if err != nil {
+				return fmt.Errorf("failed to calculate fingerprint of public key for sealed secret management (%w)", err)
+			}
 			req.SealedSecretEncryption = &model.Piped_SealedSecretEncryption{
-				Type:      sm.Type.String(),
-				PublicKey: string(publicKey),
+				Type:                 sm.Type.String(),
+				PublicKey:            string(publicKey),
+				PublicKeyFingerprint: fingerprint,
 			}
 		}
 	}
@@ -514,17 +708,16 @@ func (p *piped) sendPipedMeta(ctx context.Context, client pipedservice.Client, c
 		}
 	}
 
-	for retry.WaitNext(ctx) {
-		if _, err = client.ReportPipedMeta(ctx, req); err == nil {
-			return nil
+	return retry.Do(ctx, func(ctx context.Context) error {
+		_, err := client.ReportPipedMeta(ctx, req)
+		if err != nil {
+			logger.Warn("failed to report piped meta to control-plane, wait to the next retry",
+				zap.Int("calls", retry.Calls()),
+				zap.Error(err),
+			)
 		}
-		logger.Warn("failed to report piped meta to control-plane, wait to the next retry",
-			zap.Int("calls", retry.Calls()),
-			zap.Error(err),
-		)
-	}
-
-	return err
+		return err
+	})
 }
 
 // insertLoginUserToPasswd adds the logged-in user to /etc/passwd.
@@ -533,28 +726,18 @@ func (p *piped) sendPipedMeta(ctx context.Context, client pipedservice.Client, c
 //
 // This is a workaround to deal with OpenShift less than 4.2
 // See more: https://github.com/pipe-cd/pipe/issues/1905
-func (p *piped) insertLoginUserToPasswd(ctx context.Context) error {
-	var stdout, stderr bytes.Buffer
-
-	// Use the id command so that it gets proper ids even in pure Go.
-	cmd := exec.CommandContext(ctx, "id", "-u")
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to get uid: %s", &stderr)
+func (p *piped) insertLoginUserToPasswd() error {
+	// There is no passwd database on Windows.
+	if runtime.GOOS == "windows" {
+		return errors.New("adding login user to passwd is not supported on windows")
 	}
-	uid := strings.TrimSpace(stdout.String())
-
-	stdout.Reset()
-	stderr.Reset()
 
-	cmd = exec.CommandContext(ctx, "id", "-g")
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to get gid: %s", &stderr)
-	}
-	gid := strings.TrimSpace(stdout.String())
+	// Use the system calls instead of the id command
+	// so that it works even if the image does not contain that command.
+	var (
+		uid = os.Getuid()
+		gid = os.Getgid()
+	)
 
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -562,7 +745,7 @@ func (p *piped) insertLoginUserToPasswd(ctx context.Context) error {
 	}
 
 	// echo "default:x:${USER_ID}:${GROUP_ID}:Dynamically created user:${HOME}:/sbin/nologin" >> "$HOME/passwd"
-	entry := fmt.Sprintf("\ndefault:x:%s:%s:Dynamically created user:%s:/sbin/nologin", uid, gid, home)
+	entry := fmt.Sprintf("\ndefault:x:%d:%d:Dynamically created user:%s:/sbin/nologin", uid, gid, home)
 	nssPasswdPath := filepath.Join(home, "passwd")
 	f, err := os.OpenFile(nssPasswdPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0664)
 	if err != nil {
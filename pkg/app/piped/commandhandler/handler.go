@@ -0,0 +1,188 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commandhandler provides a piped component
+// that handles the commands targeting piped itself
+// or the live resources of applications
+// instead of a specific deployment.
+package commandhandler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/log"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type commandLister interface {
+	ListPipedCommands() []model.ReportableCommand
+}
+
+type logLevels interface {
+	EnableDebug(scope log.DebugScope, ttl time.Duration)
+}
+
+type resourceActionExecutor interface {
+	Execute(ctx context.Context, action *model.Command_ExecuteResourceAction) ([]byte, error)
+}
+
+const (
+	// The number of lines fetched when the tail lines of pod logs was not specified.
+	defaultPodLogsTailLines = 100
+	// The maximum number of lines of pod logs can be fetched by one command.
+	maxPodLogsTailLines = 5000
+)
+
+type Handler struct {
+	commandLister   commandLister
+	logLevels       logLevels
+	resourceActions config.PipedResourceActions
+	actionExecutor  resourceActionExecutor
+	interval        time.Duration
+	logger          *zap.Logger
+}
+
+func NewHandler(cl commandLister, levels logLevels, actions config.PipedResourceActions, executor resourceActionExecutor, logger *zap.Logger) *Handler {
+	return &Handler{
+		commandLister:   cl,
+		logLevels:       levels,
+		resourceActions: actions,
+		actionExecutor:  executor,
+		interval:        10 * time.Second,
+		logger:          logger.Named("command-handler"),
+	}
+}
+
+func (h *Handler) Run(ctx context.Context) error {
+	h.logger.Info("start running command handler")
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("command handler has been stopped")
+			return nil
+
+		case <-ticker.C:
+			for _, cmd := range h.commandLister.ListPipedCommands() {
+				h.handleCommand(ctx, cmd)
+			}
+		}
+	}
+}
+
+func (h *Handler) handleCommand(ctx context.Context, cmd model.ReportableCommand) {
+	var (
+		output []byte
+		err    error
+	)
+	switch cmd.Type {
+	case model.Command_ENABLE_DEBUG_LOGGING:
+		err = h.enableDebugLogging(cmd.EnableDebugLogging)
+	case model.Command_EXECUTE_RESOURCE_ACTION:
+		output, err = h.executeResourceAction(ctx, cmd.Commander, cmd.ExecuteResourceAction)
+	default:
+		err = fmt.Errorf("unsupported command type: %s", cmd.Type)
+	}
+
+	status := model.CommandStatus_COMMAND_SUCCEEDED
+	if err != nil {
+		h.logger.Error("failed to handle command",
+			zap.String("command-id", cmd.Id),
+			zap.Error(err),
+		)
+		status = model.CommandStatus_COMMAND_FAILED
+	}
+	if err := cmd.Report(ctx, status, nil, output); err != nil {
+		h.logger.Error("failed to report command status",
+			zap.String("command-id", cmd.Id),
+			zap.Error(err),
+		)
+	}
+}
+
+func (h *Handler) enableDebugLogging(c *model.Command_EnableDebugLogging) error {
+	if c == nil {
+		return fmt.Errorf("malformed command")
+	}
+
+	var scope log.DebugScope
+	switch {
+	case c.DeploymentId != "":
+		scope = log.DebugScope{Key: log.DeploymentIDKey, Value: c.DeploymentId}
+	case c.ApplicationId != "":
+		scope = log.DebugScope{Key: log.ApplicationIDKey, Value: c.ApplicationId}
+	default:
+		return fmt.Errorf("either application or deployment must be specified")
+	}
+
+	ttl := time.Duration(c.Ttl) * time.Second
+	h.logLevels.EnableDebug(scope, ttl)
+	h.logger.Info(fmt.Sprintf("enabled debug logging for %s %s", scope.Key, scope.Value),
+		zap.Duration("ttl", ttl),
+	)
+	return nil
+}
+
+func (h *Handler) executeResourceAction(ctx context.Context, commander string, c *model.Command_ExecuteResourceAction) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("malformed command")
+	}
+	if !h.resourceActions.Enabled {
+		return nil, fmt.Errorf("resource actions are not enabled in this piped")
+	}
+	switch c.Action {
+	case model.Command_ExecuteResourceAction_SCALE_WORKLOAD:
+		if c.Replicas < 0 {
+			return nil, fmt.Errorf("replicas must not be negative")
+		}
+		if max := h.resourceActions.MaxReplicas; max > 0 && int(c.Replicas) > max {
+			return nil, fmt.Errorf("replicas %d exceeds the maximum number %d", c.Replicas, max)
+		}
+	case model.Command_ExecuteResourceAction_GET_POD_LOGS:
+		if !h.resourceActions.PodDebugging {
+			return nil, fmt.Errorf("pod debugging is not enabled in this piped")
+		}
+		if c.TailLines <= 0 {
+			c.TailLines = defaultPodLogsTailLines
+		}
+		if c.TailLines > maxPodLogsTailLines {
+			c.TailLines = maxPodLogsTailLines
+		}
+	}
+
+	logger := h.logger.With(
+		zap.String("commander", commander),
+		zap.String("application-id", c.ApplicationId),
+		zap.String("action", c.Action.String()),
+		zap.String("resource", fmt.Sprintf("%s/%s/%s", c.ResourceKind, c.ResourceNamespace, c.ResourceName)),
+	)
+	output, err := h.actionExecutor.Execute(ctx, c)
+	if err != nil {
+		logger.Error("failed to execute resource action", zap.Error(err))
+		return nil, err
+	}
+	logger.Info("successfully executed resource action",
+		zap.Int32("replicas", c.Replicas),
+		zap.Int32("tail-lines", c.TailLines),
+	)
+	return output, nil
+}
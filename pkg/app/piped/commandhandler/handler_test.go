@@ -0,0 +1,230 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandhandler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/log"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeLogLevels struct {
+	scopes map[log.DebugScope]time.Duration
+}
+
+func (f *fakeLogLevels) EnableDebug(scope log.DebugScope, ttl time.Duration) {
+	f.scopes[scope] = ttl
+}
+
+func TestHandleCommand(t *testing.T) {
+	testcases := []struct {
+		name           string
+		cmd            *model.Command
+		expectedStatus model.CommandStatus
+		expectedScopes map[log.DebugScope]time.Duration
+	}{
+		{
+			name: "enable debug logging for deployment",
+			cmd: &model.Command{
+				Id:   "cmd-1",
+				Type: model.Command_ENABLE_DEBUG_LOGGING,
+				EnableDebugLogging: &model.Command_EnableDebugLogging{
+					ApplicationId: "app-1",
+					DeploymentId:  "deployment-1",
+					Ttl:           60,
+				},
+			},
+			expectedStatus: model.CommandStatus_COMMAND_SUCCEEDED,
+			expectedScopes: map[log.DebugScope]time.Duration{
+				{Key: log.DeploymentIDKey, Value: "deployment-1"}: time.Minute,
+			},
+		},
+		{
+			name: "enable debug logging for application",
+			cmd: &model.Command{
+				Id:   "cmd-2",
+				Type: model.Command_ENABLE_DEBUG_LOGGING,
+				EnableDebugLogging: &model.Command_EnableDebugLogging{
+					ApplicationId: "app-1",
+					Ttl:           3600,
+				},
+			},
+			expectedStatus: model.CommandStatus_COMMAND_SUCCEEDED,
+			expectedScopes: map[log.DebugScope]time.Duration{
+				{Key: log.ApplicationIDKey, Value: "app-1"}: time.Hour,
+			},
+		},
+		{
+			name: "missing target",
+			cmd: &model.Command{
+				Id:                 "cmd-3",
+				Type:               model.Command_ENABLE_DEBUG_LOGGING,
+				EnableDebugLogging: &model.Command_EnableDebugLogging{Ttl: 60},
+			},
+			expectedStatus: model.CommandStatus_COMMAND_FAILED,
+			expectedScopes: map[log.DebugScope]time.Duration{},
+		},
+		{
+			name: "unsupported command",
+			cmd: &model.Command{
+				Id:   "cmd-4",
+				Type: model.Command_SYNC_APPLICATION,
+			},
+			expectedStatus: model.CommandStatus_COMMAND_FAILED,
+			expectedScopes: map[log.DebugScope]time.Duration{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			levels := &fakeLogLevels{scopes: make(map[log.DebugScope]time.Duration)}
+			h := NewHandler(nil, levels, config.PipedResourceActions{}, nil, zap.NewNop())
+
+			var status model.CommandStatus
+			cmd := model.ReportableCommand{
+				Command: tc.cmd,
+				Report: func(_ context.Context, s model.CommandStatus, _ map[string]string, _ []byte) error {
+					status = s
+					return nil
+				},
+			}
+			h.handleCommand(context.Background(), cmd)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedScopes, levels.scopes)
+		})
+	}
+}
+
+type fakeResourceActionExecutor struct {
+	executed []*model.Command_ExecuteResourceAction
+}
+
+func (f *fakeResourceActionExecutor) Execute(_ context.Context, action *model.Command_ExecuteResourceAction) ([]byte, error) {
+	f.executed = append(f.executed, action)
+	if action.Action == model.Command_ExecuteResourceAction_GET_POD_LOGS {
+		return []byte("logs"), nil
+	}
+	return nil, nil
+}
+
+func TestHandleResourceActionCommand(t *testing.T) {
+	restart := &model.Command_ExecuteResourceAction{
+		ApplicationId:      "app-1",
+		Action:             model.Command_ExecuteResourceAction_RESTART_WORKLOAD,
+		ResourceApiVersion: "apps/v1",
+		ResourceKind:       "Deployment",
+		ResourceNamespace:  "default",
+		ResourceName:       "simple",
+	}
+	scale := &model.Command_ExecuteResourceAction{
+		ApplicationId:      "app-1",
+		Action:             model.Command_ExecuteResourceAction_SCALE_WORKLOAD,
+		ResourceApiVersion: "apps/v1",
+		ResourceKind:       "Deployment",
+		ResourceNamespace:  "default",
+		ResourceName:       "simple",
+		Replicas:           10,
+	}
+	logs := &model.Command_ExecuteResourceAction{
+		ApplicationId:      "app-1",
+		Action:             model.Command_ExecuteResourceAction_GET_POD_LOGS,
+		ResourceApiVersion: "v1",
+		ResourceKind:       "Pod",
+		ResourceNamespace:  "default",
+		ResourceName:       "simple-6d8b9c7f5d-abcde",
+	}
+	testcases := []struct {
+		name             string
+		actions          config.PipedResourceActions
+		action           *model.Command_ExecuteResourceAction
+		expectedStatus   model.CommandStatus
+		expectedExecuted []*model.Command_ExecuteResourceAction
+		expectedOutput   []byte
+	}{
+		{
+			name:           "disabled",
+			action:         restart,
+			expectedStatus: model.CommandStatus_COMMAND_FAILED,
+		},
+		{
+			name:             "restart workload",
+			actions:          config.PipedResourceActions{Enabled: true},
+			action:           restart,
+			expectedStatus:   model.CommandStatus_COMMAND_SUCCEEDED,
+			expectedExecuted: []*model.Command_ExecuteResourceAction{restart},
+		},
+		{
+			name:             "scale workload",
+			actions:          config.PipedResourceActions{Enabled: true, MaxReplicas: 10},
+			action:           scale,
+			expectedStatus:   model.CommandStatus_COMMAND_SUCCEEDED,
+			expectedExecuted: []*model.Command_ExecuteResourceAction{scale},
+		},
+		{
+			name:           "scale workload over the maximum replicas",
+			actions:        config.PipedResourceActions{Enabled: true, MaxReplicas: 5},
+			action:         scale,
+			expectedStatus: model.CommandStatus_COMMAND_FAILED,
+		},
+		{
+			name:           "get pod logs without pod debugging",
+			actions:        config.PipedResourceActions{Enabled: true},
+			action:         logs,
+			expectedStatus: model.CommandStatus_COMMAND_FAILED,
+		},
+		{
+			name:             "get pod logs",
+			actions:          config.PipedResourceActions{Enabled: true, PodDebugging: true},
+			action:           logs,
+			expectedStatus:   model.CommandStatus_COMMAND_SUCCEEDED,
+			expectedExecuted: []*model.Command_ExecuteResourceAction{logs},
+			expectedOutput:   []byte("logs"),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &fakeResourceActionExecutor{}
+			h := NewHandler(nil, nil, tc.actions, executor, zap.NewNop())
+
+			var (
+				status model.CommandStatus
+				output []byte
+			)
+			cmd := model.ReportableCommand{
+				Command: &model.Command{
+					Id:                    "cmd-1",
+					Type:                  model.Command_EXECUTE_RESOURCE_ACTION,
+					Commander:             "user-1",
+					ExecuteResourceAction: tc.action,
+				},
+				Report: func(_ context.Context, s model.CommandStatus, _ map[string]string, o []byte) error {
+					status = s
+					output = o
+					return nil
+				},
+			}
+			h.handleCommand(context.Background(), cmd)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedExecuted, executor.executed)
+			assert.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
@@ -35,6 +35,7 @@ import (
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
 	"github.com/pipe-cd/pipe/pkg/app/piped/logpersister"
 	"github.com/pipe-cd/pipe/pkg/cache"
 	"github.com/pipe-cd/pipe/pkg/config"
@@ -70,6 +71,7 @@ type deploymentLister interface {
 type commandLister interface {
 	ListDeploymentCommands() []model.ReportableCommand
 	ListStageCommands(deploymentID, stageID string) []model.ReportableCommand
+	Updated() <-chan struct{}
 }
 
 type applicationLister interface {
@@ -78,6 +80,7 @@ type applicationLister interface {
 
 type environmentLister interface {
 	Get(id string) (*model.Environment, bool)
+	GetLatest(ctx context.Context, id string) (*model.Environment, error)
 }
 
 type liveResourceLister interface {
@@ -99,8 +102,17 @@ type DeploymentController interface {
 var (
 	plannerStaleDuration   = time.Hour
 	schedulerStaleDuration = time.Hour
+	// The maximum duration to wait for a planned deployment
+	// to be picked by a scheduler before planning another deployment
+	// of the same application.
+	plannedWaitDuration = time.Minute
 )
 
+type waitingScheduler struct {
+	deploymentID string
+	plannedAt    time.Time
+}
+
 type controller struct {
 	apiClient             apiClient
 	gitClient             gitClient
@@ -110,6 +122,7 @@ type controller struct {
 	environmentLister     environmentLister
 	liveResourceLister    liveResourceLister
 	notifier              notifier
+	approvalTaker         executor.ApprovalTaker
 	sealedSecretDecrypter sealedSecretDecrypter
 	pipedConfig           *config.PipedSpec
 	appManifestsCache     cache.Cache
@@ -123,6 +136,11 @@ type controller struct {
 	// Because when the deployment lister returns not fresh data
 	// we use this to ignore the ones that have been handled previously.
 	donePlanners map[string]time.Time
+	// Map from application ID to the completion time of the planner
+	// whose deployment has been planned but not yet picked by any scheduler.
+	// Because the deployment lister may return not fresh data
+	// we use this to avoid planning another deployment of the same application meanwhile.
+	waitingSchedulers map[string]waitingScheduler
 	// Map from application ID to the scheduler
 	// of a running deployment of that application.
 	schedulers map[string]*scheduler
@@ -150,6 +168,7 @@ func NewController(
 	environmentLister environmentLister,
 	liveResourceLister liveResourceLister,
 	notifier notifier,
+	approvalTaker executor.ApprovalTaker,
 	ssd sealedSecretDecrypter,
 	pipedConfig *config.PipedSpec,
 	appManifestsCache cache.Cache,
@@ -170,6 +189,7 @@ func NewController(
 		environmentLister:     environmentLister,
 		liveResourceLister:    liveResourceLister,
 		notifier:              notifier,
+		approvalTaker:         approvalTaker,
 		sealedSecretDecrypter: ssd,
 		appManifestsCache:     appManifestsCache,
 		pipedConfig:           pipedConfig,
@@ -177,6 +197,7 @@ func NewController(
 
 		planners:                      make(map[string]*planner),
 		donePlanners:                  make(map[string]time.Time),
+		waitingSchedulers:             make(map[string]waitingScheduler),
 		schedulers:                    make(map[string]*scheduler),
 		doneSchedulers:                make(map[string]time.Time),
 		mostRecentlySuccessfulCommits: make(map[string]string),
@@ -194,7 +215,7 @@ func (c *controller) Run(ctx context.Context) error {
 
 	// Make sure the existence of the workspace directory.
 	// Each planner/scheduler will have a working directory inside this workspace.
-	dir, err := ioutil.TempDir("", "workspace")
+	dir, err := ioutil.TempDir(c.pipedConfig.GetWorkspaceDir(), "workspace")
 	if err != nil {
 		c.logger.Error("failed to create workspace directory", zap.Error(err))
 		return err
@@ -230,6 +251,9 @@ L:
 			c.syncSchedulers(ctx)
 			c.syncPlanners(ctx)
 			c.checkCommands()
+
+		case <-c.commandLister.Updated():
+			c.checkCommands()
 		}
 	}
 
@@ -303,6 +327,13 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 		c.donePlanners[p.ID()] = p.DoneTimestamp()
 		delete(c.planners, id)
 
+		if p.DoneDeploymentStatus() == model.DeploymentStatus_DEPLOYMENT_PLANNED {
+			c.waitingSchedulers[id] = waitingScheduler{
+				deploymentID: p.ID(),
+				plannedAt:    p.DoneTimestamp(),
+			}
+		}
+
 		// Application will be marked as NOT deploying when planner's deployment was completed.
 		if model.IsCompletedDeployment(p.DoneDeploymentStatus()) {
 			if err := reportApplicationDeployingStatus(ctx, c.apiClient, id, false); err != nil {
@@ -315,9 +346,22 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 		}
 	}
 
+	// Stop waiting for the planned deployments those were not picked for a long time
+	// (e.g. they were cancelled before being scheduled).
+	for id, w := range c.waitingSchedulers {
+		if time.Since(w.plannedAt) >= plannedWaitDuration {
+			delete(c.waitingSchedulers, id)
+		}
+	}
+
 	// Add missing planners.
 	pendings := c.deploymentLister.ListPendings()
+	metricsDeploymentsObserved(model.DeploymentStatus_DEPLOYMENT_PENDING.String(), len(pendings))
+	defer func() {
+		metricsPlanners.Set(float64(len(c.planners)))
+	}()
 	if len(pendings) == 0 {
+		metricsQueuedDeployments.Set(0)
 		return nil
 	}
 
@@ -325,7 +369,12 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 		zap.Int("count", len(c.planners)),
 	)
 
-	pendingByApp := make(map[string]*model.Deployment, len(pendings))
+	var (
+		pendingByApp = make(map[string]*model.Deployment, len(pendings))
+		// Pending deployments of the applications those are deploying.
+		waitingByApp = make(map[string][]*model.Deployment)
+		queued       int
+	)
 	for _, d := range pendings {
 		appID := d.ApplicationId
 		// Ignore already processed one.
@@ -333,20 +382,39 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 			continue
 		}
 		// For each application, only one deployment can be planned at the same time.
-		if _, ok := c.planners[appID]; ok {
+		if p, ok := c.planners[appID]; ok {
+			if p.ID() != d.Id {
+				queued++
+			}
 			continue
 		}
 		// If this application is deploying, no other deployments can be added to plan.
 		if _, ok := c.schedulers[appID]; ok {
+			queued++
+			waitingByApp[appID] = append(waitingByApp[appID], d)
 			continue
 		}
-		// Choose the oldest PENDING deployment of the application to plan.
-		if pre, ok := pendingByApp[appID]; ok && !d.TriggerBefore(pre) {
+		// If the planned deployment of this application is going to be scheduled,
+		// no other deployments can be added to plan.
+		if _, ok := c.waitingSchedulers[appID]; ok {
+			queued++
 			continue
 		}
+		// Choose the oldest PENDING deployment of the application to plan.
+		if pre, ok := pendingByApp[appID]; ok {
+			queued++
+			if !d.TriggerBefore(pre) {
+				continue
+			}
+		}
 		pendingByApp[appID] = d
 	}
 
+	for appID, ds := range waitingByApp {
+		queued -= c.supersedeIfNeeded(ctx, c.schedulers[appID], ds)
+	}
+	metricsQueuedDeployments.Set(float64(queued))
+
 	for appID, d := range pendingByApp {
 		planner, err := c.startNewPlanner(ctx, d)
 		if err != nil {
@@ -358,6 +426,7 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 			continue
 		}
 		c.planners[appID] = planner
+		metricsDeploymentDequeued(d.Kind.String(), metricsValuePlanning, time.Unix(d.CreatedAt, 0))
 
 		// Application will be marked as DEPLOYING after its planner was successfully created.
 		if err := reportApplicationDeployingStatus(ctx, c.apiClient, d.ApplicationId, true); err != nil {
@@ -372,6 +441,116 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 	return nil
 }
 
+// supersedeIfNeeded handles the pending deployments of an application
+// which is deploying by the given scheduler.
+// When the application is configured to cancel the older deployments,
+// only the most recently triggered one will be kept while all others,
+// including the running one, will be cancelled.
+// The running one is not cancelled after starting any irreversible stage.
+// It returns the number of cancelled pending deployments.
+func (c *controller) supersedeIfNeeded(ctx context.Context, s *scheduler, pendings []*model.Deployment) int {
+	if s.ConcurrencyPolicy() != config.DeploymentConcurrencyPolicyCancelOlder || len(pendings) == 0 {
+		return 0
+	}
+
+	latest := pendings[0]
+	for _, d := range pendings[1:] {
+		if latest.TriggerBefore(d) {
+			latest = d
+		}
+	}
+
+	var cancelled int
+	for _, d := range pendings {
+		if d.Id == latest.Id {
+			continue
+		}
+		c.logger.Info("cancel the pending deployment because a newer one was triggered",
+			zap.String("app-id", d.ApplicationId),
+			zap.String("deployment-id", d.Id),
+			zap.String("newer-deployment-id", latest.Id),
+		)
+		if err := c.cancelPendingDeployment(ctx, d, latest.Id); err != nil {
+			c.logger.Error("failed to cancel the superseded pending deployment",
+				zap.String("app-id", d.ApplicationId),
+				zap.String("deployment-id", d.Id),
+				zap.Error(err),
+			)
+			continue
+		}
+		cancelled++
+	}
+
+	if s.ID() == latest.Id || latest.TriggerBefore(s.deployment) {
+		return cancelled
+	}
+	if s.Supersede(latest.Id) {
+		c.logger.Info("cancel the running deployment because a newer one was triggered",
+			zap.String("app-id", latest.ApplicationId),
+			zap.String("deployment-id", s.ID()),
+			zap.String("newer-deployment-id", latest.Id),
+		)
+	}
+	return cancelled
+}
+
+// cancelPendingDeployment marks the given pending deployment as cancelled
+// because it was superseded by the specified newer deployment.
+func (c *controller) cancelPendingDeployment(ctx context.Context, d *model.Deployment, newerDeploymentID string) error {
+	var (
+		err       error
+		commander = fmt.Sprintf("newer deployment %s", newerDeploymentID)
+		req       = &pipedservice.ReportDeploymentCompletedRequest{
+			DeploymentId: d.Id,
+			Status:       model.DeploymentStatus_DEPLOYMENT_CANCELLED,
+			StatusReason: fmt.Sprintf("Deployment was superseded by %s before planning", commander),
+			CompletedAt:  time.Now().Unix(),
+		}
+		retry = pipedservice.NewRetry(3)
+	)
+
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := c.apiClient.ReportDeploymentCompleted(ctx, req)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to report deployment status to control-plane: %v", err)
+	}
+
+	// Mark as processed to ignore it even if the deployment lister returns not fresh data.
+	c.donePlanners[d.Id] = time.Now()
+
+	var envName string
+	if env, ok := c.environmentLister.Get(d.EnvId); ok {
+		envName = env.Name
+	}
+	c.notifier.Notify(model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_CANCELLED,
+		Metadata: &model.NotificationEventDeploymentCancelled{
+			Deployment: d,
+			EnvName:    envName,
+			Commander:  commander,
+		},
+	})
+	return nil
+}
+
+// getEnvironmentProtection returns the name of the given environment and whether it is protected.
+// The protection flag is read from the control plane every time instead of the cached one,
+// and the environment is treated as protected when it could not be read.
+func (c *controller) getEnvironmentProtection(ctx context.Context, envID string, logger *zap.Logger) (string, bool) {
+	env, err := c.environmentLister.GetLatest(ctx, envID)
+	if err == nil {
+		return env.Name, env.Protected
+	}
+
+	logger.Warn("failed to get the latest environment, the deployment is planned as it is protected", zap.Error(err))
+	if env, ok := c.environmentLister.Get(envID); ok {
+		return env.Name, true
+	}
+	return "", true
+}
+
 func (c *controller) startNewPlanner(ctx context.Context, d *model.Deployment) (*planner, error) {
 	logger := c.logger.With(
 		zap.String("deployment-id", d.Id),
@@ -405,14 +584,12 @@ func (c *controller) startNewPlanner(ctx context.Context, d *model.Deployment) (
 		}
 	}
 
-	var envName string
-	if env, ok := c.environmentLister.Get(d.EnvId); ok {
-		envName = env.Name
-	}
+	envName, envProtected := c.getEnvironmentProtection(ctx, d.EnvId, logger)
 
 	planner := newPlanner(
 		d,
 		envName,
+		envProtected,
 		commit,
 		workingDir,
 		c.apiClient,
@@ -494,6 +671,12 @@ func (c *controller) syncSchedulers(ctx context.Context) error {
 	runnings := c.deploymentLister.ListRunnings()
 	targets := append(runnings, planneds...)
 
+	metricsDeploymentsObserved(model.DeploymentStatus_DEPLOYMENT_PLANNED.String(), len(planneds))
+	metricsDeploymentsObserved(model.DeploymentStatus_DEPLOYMENT_RUNNING.String(), len(runnings))
+	defer func() {
+		metricsSchedulers.Set(float64(len(c.schedulers)))
+	}()
+
 	if len(targets) == 0 {
 		return nil
 	}
@@ -514,6 +697,8 @@ func (c *controller) syncSchedulers(ctx context.Context) error {
 					zap.String("handling-deployment-id", s.ID()),
 					zap.String("deployment-id", d.Id),
 				)
+			} else {
+				s.UpdateNotes(d.Notes)
 			}
 			continue
 		}
@@ -522,6 +707,12 @@ func (c *controller) syncSchedulers(ctx context.Context) error {
 			continue
 		}
 		c.schedulers[d.ApplicationId] = s
+		if w, ok := c.waitingSchedulers[d.ApplicationId]; ok && w.deploymentID == d.Id {
+			delete(c.waitingSchedulers, d.ApplicationId)
+		}
+		if d.Status == model.DeploymentStatus_DEPLOYMENT_PLANNED {
+			metricsDeploymentDequeued(d.Kind.String(), metricsValueScheduling, time.Unix(d.UpdatedAt, 0))
+		}
 		c.logger.Info("added a new scheduler",
 			zap.String("deployment-id", d.Id),
 			zap.String("app-id", d.ApplicationId),
@@ -568,6 +759,7 @@ func (c *controller) startNewScheduler(ctx context.Context, d *model.Deployment)
 		c.liveResourceLister,
 		c.logPersister,
 		c.notifier,
+		c.approvalTaker,
 		c.sealedSecretDecrypter,
 		c.pipedConfig,
 		c.appManifestsCache,
@@ -610,15 +802,15 @@ func (c *controller) getMostRecentlySuccessfulDeployment(ctx context.Context, ap
 		}
 	)
 
-	for retry.WaitNext(ctx) {
-		if resp, err = c.apiClient.GetApplicationMostRecentDeployment(ctx, req); err == nil {
-			return resp.Deployment, nil
-		}
-		if !pipedservice.Retriable(err) {
-			return nil, err
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.apiClient.GetApplicationMostRecentDeployment(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, err
+	return resp.Deployment, nil
 }
 
 type appLiveResourceLister struct {
@@ -641,10 +833,11 @@ func reportApplicationDeployingStatus(ctx context.Context, c apiClient, appID st
 		}
 	)
 
-	for retry.WaitNext(ctx) {
-		if _, err = c.ReportApplicationDeployingStatus(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := c.ReportApplicationDeployingStatus(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report application deploying status to control-plane: %w", err)
 	}
 	return err
@@ -13,3 +13,206 @@
 // limitations under the License.
 
 package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeCompletionAPIClient struct {
+	apiClient
+	completed map[string]model.DeploymentStatus
+}
+
+func (c *fakeCompletionAPIClient) ReportDeploymentCompleted(_ context.Context, req *pipedservice.ReportDeploymentCompletedRequest, _ ...grpc.CallOption) (*pipedservice.ReportDeploymentCompletedResponse, error) {
+	c.completed[req.DeploymentId] = req.Status
+	return &pipedservice.ReportDeploymentCompletedResponse{}, nil
+}
+
+type fakeEnvironmentLister struct {
+	cached *model.Environment
+	latest *model.Environment
+}
+
+func (l fakeEnvironmentLister) Get(string) (*model.Environment, bool) {
+	return l.cached, l.cached != nil
+}
+
+func (l fakeEnvironmentLister) GetLatest(context.Context, string) (*model.Environment, error) {
+	if l.latest == nil {
+		return nil, errors.New("unavailable")
+	}
+	return l.latest, nil
+}
+
+func TestGetEnvironmentProtection(t *testing.T) {
+	testcases := []struct {
+		name          string
+		lister        fakeEnvironmentLister
+		wantName      string
+		wantProtected bool
+	}{
+		{
+			name: "protected in the latest one",
+			lister: fakeEnvironmentLister{
+				cached: &model.Environment{Name: "prod"},
+				latest: &model.Environment{Name: "prod", Protected: true},
+			},
+			wantName:      "prod",
+			wantProtected: true,
+		},
+		{
+			name: "unprotected in the latest one",
+			lister: fakeEnvironmentLister{
+				cached: &model.Environment{Name: "prod", Protected: true},
+				latest: &model.Environment{Name: "prod"},
+			},
+			wantName:      "prod",
+			wantProtected: false,
+		},
+		{
+			name: "failed to get the latest one",
+			lister: fakeEnvironmentLister{
+				cached: &model.Environment{Name: "prod"},
+			},
+			wantName:      "prod",
+			wantProtected: true,
+		},
+		{
+			name:          "unknown environment",
+			lister:        fakeEnvironmentLister{},
+			wantProtected: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &controller{environmentLister: tc.lister}
+			name, protected := c.getEnvironmentProtection(context.Background(), "env", zap.NewNop())
+			assert.Equal(t, tc.wantName, name)
+			assert.Equal(t, tc.wantProtected, protected)
+		})
+	}
+}
+
+type fakeNotifier struct {
+	events []model.NotificationEvent
+}
+
+func (n *fakeNotifier) Notify(event model.NotificationEvent) {
+	n.events = append(n.events, event)
+}
+
+func TestSupersedeIfNeeded(t *testing.T) {
+	newDeployment := func(id string, commitCreatedAt int64) *model.Deployment {
+		return &model.Deployment{
+			Id:            id,
+			ApplicationId: "app-id",
+			Trigger: &model.DeploymentTrigger{
+				Commit: &model.Commit{CreatedAt: commitCreatedAt},
+			},
+		}
+	}
+	testcases := []struct {
+		name                     string
+		policy                   config.DeploymentConcurrencyPolicy
+		irreversibleStageStarted bool
+		running                  *model.Deployment
+		pendings                 []*model.Deployment
+		wantRunningCancelled     bool
+		wantPendingsCancelled    []string
+	}{
+		{
+			name:     "queue policy",
+			policy:   config.DeploymentConcurrencyPolicyQueue,
+			running:  newDeployment("running", 1),
+			pendings: []*model.Deployment{newDeployment("pending-1", 2), newDeployment("pending-2", 3)},
+		},
+		{
+			name:     "policy was not loaded yet",
+			running:  newDeployment("running", 1),
+			pendings: []*model.Deployment{newDeployment("pending-1", 2)},
+		},
+		{
+			name:                  "cancel older policy with newer deployments",
+			policy:                config.DeploymentConcurrencyPolicyCancelOlder,
+			running:               newDeployment("running", 1),
+			pendings:              []*model.Deployment{newDeployment("pending-2", 3), newDeployment("pending-1", 2)},
+			wantRunningCancelled:  true,
+			wantPendingsCancelled: []string{"pending-1"},
+		},
+		{
+			name:                     "cancel older policy after starting an irreversible stage",
+			policy:                   config.DeploymentConcurrencyPolicyCancelOlder,
+			irreversibleStageStarted: true,
+			running:                  newDeployment("running", 1),
+			pendings:                 []*model.Deployment{newDeployment("pending-1", 2), newDeployment("pending-2", 3)},
+			wantPendingsCancelled:    []string{"pending-1"},
+		},
+		{
+			name:     "cancel older policy with an older deployment",
+			policy:   config.DeploymentConcurrencyPolicyCancelOlder,
+			running:  newDeployment("running", 2),
+			pendings: []*model.Deployment{newDeployment("pending-1", 1)},
+		},
+		{
+			name:     "cancel older policy with the same deployment",
+			policy:   config.DeploymentConcurrencyPolicyCancelOlder,
+			running:  newDeployment("running", 1),
+			pendings: []*model.Deployment{newDeployment("running", 1)},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeCompletionAPIClient{completed: make(map[string]model.DeploymentStatus)}
+			c := &controller{
+				apiClient:         client,
+				environmentLister: fakeEnvironmentLister{},
+				notifier:          &fakeNotifier{},
+				donePlanners:      make(map[string]time.Time),
+				logger:            zap.NewNop(),
+			}
+			s := &scheduler{
+				deployment:               tc.running,
+				cancelledCh:              make(chan *model.ReportableCommand, 1),
+				irreversibleStageStarted: tc.irreversibleStageStarted,
+			}
+			s.concurrencyPolicy.Store(string(tc.policy))
+
+			cancelled := c.supersedeIfNeeded(context.Background(), s, tc.pendings)
+			assert.Equal(t, len(tc.wantPendingsCancelled), cancelled)
+			for _, id := range tc.wantPendingsCancelled {
+				assert.Equal(t, model.DeploymentStatus_DEPLOYMENT_CANCELLED, client.completed[id])
+				assert.Contains(t, c.donePlanners, id)
+			}
+			assert.Equal(t, len(tc.wantPendingsCancelled), len(client.completed))
+
+			assert.Equal(t, tc.wantRunningCancelled, s.cancelled)
+			if tc.wantRunningCancelled {
+				cmd := <-s.cancelledCh
+				assert.Equal(t, "newer deployment pending-2", cmd.Commander)
+			}
+		})
+	}
+}
+
+func TestSchedulerSupersede(t *testing.T) {
+	s := &scheduler{cancelledCh: make(chan *model.ReportableCommand, 1)}
+	s.markStageStarted(model.StageWait)
+	assert.True(t, s.Supersede("newer"))
+	assert.False(t, s.Supersede("newer"))
+
+	s = &scheduler{cancelledCh: make(chan *model.ReportableCommand, 1)}
+	s.markStageStarted(model.StageTerraformApply)
+	assert.False(t, s.Supersede("newer"))
+	assert.False(t, s.cancelled)
+}
@@ -27,6 +27,8 @@ type metadataStore struct {
 	deployment    *model.Deployment
 	metadata      sync.Map // map[key-string]string
 	stageMetadata sync.Map // map[stage-id-string]map[string]string
+	// Guards the read-modify-write of stage metadata.
+	stageMetadataMu sync.Mutex
 }
 
 func NewMetadataStore(apiClient apiClient, d *model.Deployment) *metadataStore {
@@ -85,6 +87,24 @@ func (s *metadataStore) SetStageMetadata(ctx context.Context, stageID string, me
 	return err
 }
 
+// PutStageMetadata adds the given key/value pairs into the metadata of the specified stage
+// while keeping the other existing ones, then persists the result via the control plane.
+func (s *metadataStore) PutStageMetadata(ctx context.Context, stageID string, metadata map[string]string) error {
+	s.stageMetadataMu.Lock()
+	defer s.stageMetadataMu.Unlock()
+
+	merged := make(map[string]string, len(metadata))
+	if ori, ok := s.stageMetadata.Load(stageID); ok {
+		for k, v := range ori.(map[string]string) {
+			merged[k] = v
+		}
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	return s.SetStageMetadata(ctx, stageID, merged)
+}
+
 func (s *metadataStore) GetStageMetadata(stageID string) (map[string]string, bool) {
 	if metadata, ok := s.stageMetadata.Load(stageID); ok {
 		return metadata.(map[string]string), true
@@ -0,0 +1,70 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeStageMetadataAPIClient struct {
+	apiClient
+	saved map[string]map[string]string
+}
+
+func (c *fakeStageMetadataAPIClient) SaveStageMetadata(_ context.Context, req *pipedservice.SaveStageMetadataRequest, _ ...grpc.CallOption) (*pipedservice.SaveStageMetadataResponse, error) {
+	c.saved[req.StageId] = req.Metadata
+	return &pipedservice.SaveStageMetadataResponse{}, nil
+}
+
+func TestMetadataStorePutStageMetadata(t *testing.T) {
+	client := &fakeStageMetadataAPIClient{
+		saved: make(map[string]map[string]string),
+	}
+	d := &model.Deployment{
+		Id: "deployment-id",
+		Stages: []*model.PipelineStage{
+			{
+				Id:       "stage-1",
+				Metadata: map[string]string{"Approvers": "foo", "key": "old"},
+			},
+			{
+				Id: "stage-2",
+			},
+		},
+	}
+	s := NewMetadataStore(client, d)
+	ctx := context.Background()
+
+	require.NoError(t, s.PutStageMetadata(ctx, "stage-1", map[string]string{"key": "new", "Approved-By": "bar"}))
+	expected := map[string]string{"Approvers": "foo", "key": "new", "Approved-By": "bar"}
+	assert.Equal(t, expected, client.saved["stage-1"])
+	got, ok := s.GetStageMetadata("stage-1")
+	assert.True(t, ok)
+	assert.Equal(t, expected, got)
+
+	require.NoError(t, s.PutStageMetadata(ctx, "stage-2", map[string]string{"key": "value"}))
+	assert.Equal(t, map[string]string{"key": "value"}, client.saved["stage-2"])
+
+	// The initial metadata of deployment must not be modified.
+	assert.Equal(t, map[string]string{"Approvers": "foo", "key": "old"}, d.Stages[0].Metadata)
+}
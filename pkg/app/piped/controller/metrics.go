@@ -0,0 +1,121 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsLabelStatus = "status"
+	metricsLabelKind   = "kind"
+	metricsLabelPhase  = "phase"
+	metricsLabelStage  = "stage"
+
+	metricsValuePlanning   = "planning"
+	metricsValueScheduling = "scheduling"
+)
+
+var (
+	metricsDeployments = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_deployments",
+			Help: "Number of not completed deployments handled by this piped in each status.",
+		},
+		[]string{
+			metricsLabelStatus,
+		},
+	)
+	metricsQueuedDeployments = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "controller_queued_deployments",
+			Help: "Number of pending deployments waiting for a planner because their applications are busy.",
+		},
+	)
+	metricsPlanners = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "controller_planners",
+			Help: "Number of running planners.",
+		},
+	)
+	metricsSchedulers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "controller_schedulers",
+			Help: "Number of running schedulers.",
+		},
+	)
+	metricsRunningExecutors = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_running_executors",
+			Help: "Number of stage executors being executed at the moment.",
+		},
+		[]string{
+			metricsLabelStage,
+		},
+	)
+	metricsDeploymentQueueDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "controller_deployment_queue_duration_seconds",
+			Help:    "How long a deployment had been waiting before it was handled by a planner or a scheduler.",
+			Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+		},
+		[]string{
+			metricsLabelKind,
+			metricsLabelPhase,
+		},
+	)
+)
+
+func init() {
+	registerMetrics()
+}
+
+func registerMetrics() {
+	prometheus.MustRegister(
+		metricsDeployments,
+		metricsQueuedDeployments,
+		metricsPlanners,
+		metricsSchedulers,
+		metricsRunningExecutors,
+		metricsDeploymentQueueDuration,
+	)
+}
+
+func metricsDeploymentsObserved(status string, num int) {
+	metricsDeployments.With(prometheus.Labels{
+		metricsLabelStatus: status,
+	}).Set(float64(num))
+}
+
+func metricsDeploymentDequeued(kind, phase string, since time.Time) {
+	metricsDeploymentQueueDuration.With(prometheus.Labels{
+		metricsLabelKind:  kind,
+		metricsLabelPhase: phase,
+	}).Observe(time.Since(since).Seconds())
+}
+
+func metricsExecutorStarted(stage string) {
+	metricsRunningExecutors.With(prometheus.Labels{
+		metricsLabelStage: stage,
+	}).Inc()
+}
+
+func metricsExecutorFinished(stage string) {
+	metricsRunningExecutors.With(prometheus.Labels{
+		metricsLabelStage: stage,
+	}).Dec()
+}
@@ -43,6 +43,7 @@ type planner struct {
 	// Readonly deployment model.
 	deployment               *model.Deployment
 	envName                  string
+	envProtected             bool
 	lastSuccessfulCommitHash string
 	workingDir               string
 	apiClient                apiClient
@@ -66,6 +67,7 @@ type planner struct {
 func newPlanner(
 	d *model.Deployment,
 	envName string,
+	envProtected bool,
 	lastSuccessfulCommitHash string,
 	workingDir string,
 	apiClient apiClient,
@@ -89,6 +91,7 @@ func newPlanner(
 	p := &planner{
 		deployment:               d,
 		envName:                  envName,
+		envProtected:             envProtected,
 		lastSuccessfulCommitHash: lastSuccessfulCommitHash,
 		workingDir:               workingDir,
 		apiClient:                apiClient,
@@ -163,6 +166,7 @@ func (p *planner) Run(ctx context.Context) error {
 
 	in := pln.Input{
 		Deployment:                     p.deployment,
+		PipedConfig:                    p.pipedConfig,
 		MostRecentSuccessfulCommitHash: p.lastSuccessfulCommitHash,
 		AppManifestsCache:              p.appManifestsCache,
 		RegexPool:                      regexpool.DefaultPool(),
@@ -200,7 +204,7 @@ func (p *planner) Run(ctx context.Context) error {
 			p.doneDeploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
 			desc := fmt.Sprintf("Deployment was cancelled by %s while planning", cmd.Commander)
 			p.reportDeploymentCancelled(ctx, cmd.Commander, desc)
-			return cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil)
+			return cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil)
 		}
 	default:
 	}
@@ -210,10 +214,56 @@ func (p *planner) Run(ctx context.Context) error {
 		return p.reportDeploymentFailed(ctx, fmt.Sprintf("Unable to plan the deployment (%v)", err))
 	}
 
+	// Every deployment targeting a protected environment must be approved
+	// regardless of what was configured in the deployment configuration.
+	if p.envProtected {
+		var added bool
+		if out.Stages, added = pln.EnsureWaitApprovalStage(out.Stages, p.nowFunc()); added {
+			out.Summary = fmt.Sprintf("%s after getting an approval since %s environment is protected", out.Summary, p.envName)
+			p.logger.Info("added WAIT_APPROVAL stage since the environment is protected")
+		}
+	}
+
+	if len(out.Metadata) > 0 {
+		if err := p.saveDeploymentMetadata(ctx, out.Metadata); err != nil {
+			p.doneDeploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+			return p.reportDeploymentFailed(ctx, fmt.Sprintf("Unable to save the metadata of the deployment (%v)", err))
+		}
+	}
+
 	p.doneDeploymentStatus = model.DeploymentStatus_DEPLOYMENT_PLANNED
 	return p.reportDeploymentPlanned(ctx, p.lastSuccessfulCommitHash, out)
 }
 
+// saveDeploymentMetadata adds the given key/value pairs into the metadata of the deployment
+// while keeping the existing ones.
+func (p *planner) saveDeploymentMetadata(ctx context.Context, metadata map[string]string) error {
+	var (
+		err    error
+		retry  = pipedservice.NewRetry(10)
+		merged = make(map[string]string, len(p.deployment.Metadata)+len(metadata))
+	)
+	for k, v := range p.deployment.Metadata {
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	req := &pipedservice.SaveDeploymentMetadataRequest{
+		DeploymentId: p.deployment.Id,
+		Metadata:     merged,
+	}
+
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := p.apiClient.SaveDeploymentMetadata(ctx, req)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to save deployment metadata to control-plane: %v", err)
+	}
+	return err
+}
+
 func (p *planner) reportDeploymentPlanned(ctx context.Context, runningCommitHash string, out pln.Output) error {
 	var (
 		err   error
@@ -239,10 +289,11 @@ func (p *planner) reportDeploymentPlanned(ctx context.Context, runningCommitHash
 		})
 	}()
 
-	for retry.WaitNext(ctx) {
-		if _, err = p.apiClient.ReportDeploymentPlanned(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := p.apiClient.ReportDeploymentPlanned(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report deployment status to control-plane: %v", err)
 	}
 
@@ -277,10 +328,11 @@ func (p *planner) reportDeploymentFailed(ctx context.Context, reason string) err
 		})
 	}()
 
-	for retry.WaitNext(ctx) {
-		if _, err = p.apiClient.ReportDeploymentCompleted(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := p.apiClient.ReportDeploymentCompleted(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report deployment status to control-plane: %v", err)
 	}
 
@@ -315,10 +367,11 @@ func (p *planner) reportDeploymentCancelled(ctx context.Context, commander, reas
 		})
 	}()
 
-	for retry.WaitNext(ctx) {
-		if _, err = p.apiClient.ReportDeploymentCompleted(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := p.apiClient.ReportDeploymentCompleted(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report deployment status to control-plane: %v", err)
 	}
 
@@ -19,10 +19,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	"github.com/pipe-cd/pipe/pkg/app/piped/deploysource"
@@ -35,6 +38,14 @@ import (
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
+// The key of the deployment metadata storing the time when
+// this deployment was started to be handled by piped.
+const startedAtMetadataKey = "started-at"
+
+// The key of the stage metadata storing the time when
+// the stage was started to be executed.
+const stageStartedAtMetadataKey = "stage-started-at"
+
 // scheduler is a dedicated object for a specific deployment of a single application.
 type scheduler struct {
 	// Readonly deployment model.
@@ -50,6 +61,7 @@ type scheduler struct {
 	logPersister          logpersister.Persister
 	metadataStore         *metadataStore
 	notifier              notifier
+	approvalTaker         executor.ApprovalTaker
 	sealedSecretDecrypter sealedSecretDecrypter
 	pipedConfig           *config.PipedSpec
 	appManifestsCache     cache.Cache
@@ -69,8 +81,15 @@ type scheduler struct {
 	done                 atomic.Bool
 	doneTimestamp        time.Time
 	doneDeploymentStatus model.DeploymentStatus
-	cancelled            bool
-	cancelledCh          chan *model.ReportableCommand
+	// Protects cancelled, irreversibleStageStarted and notes.
+	mu                       sync.Mutex
+	cancelled                bool
+	cancelledCh              chan *model.ReportableCommand
+	irreversibleStageStarted bool
+	// The latest notes attached to the deployment by users.
+	notes []*model.DeploymentNote
+	// The concurrency policy loaded from the deployment configuration.
+	concurrencyPolicy atomic.String
 
 	nowFunc func() time.Time
 }
@@ -86,6 +105,7 @@ func newScheduler(
 	liveResourceLister liveResourceLister,
 	lp logpersister.Persister,
 	notifier notifier,
+	approvalTaker executor.ApprovalTaker,
 	ssd sealedSecretDecrypter,
 	pipedConfig *config.PipedSpec,
 	appManifestsCache cache.Cache,
@@ -114,6 +134,7 @@ func newScheduler(
 		logPersister:          lp,
 		metadataStore:         NewMetadataStore(apiClient, d),
 		notifier:              notifier,
+		approvalTaker:         approvalTaker,
 		sealedSecretDecrypter: ssd,
 		pipedConfig:           pipedConfig,
 		appManifestsCache:     appManifestsCache,
@@ -127,6 +148,10 @@ func newScheduler(
 	s.stageStatuses = make(map[string]model.StageStatus, len(d.Stages))
 	for _, stage := range d.Stages {
 		s.stageStatuses[stage.Id] = stage.Status
+		// The irreversible stage may have been started before restarting piped.
+		if stage.Status != model.StageStatus_STAGE_NOT_STARTED_YET && model.Stage(stage.Name).IsIrreversible() {
+			s.irreversibleStageStarted = true
+		}
 	}
 
 	return s
@@ -168,6 +193,12 @@ func (s *scheduler) DoneDeploymentStatus() model.DeploymentStatus {
 }
 
 func (s *scheduler) Cancel(cmd model.ReportableCommand) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel(cmd)
+}
+
+func (s *scheduler) cancel(cmd model.ReportableCommand) {
 	if s.cancelled {
 		return
 	}
@@ -176,6 +207,74 @@ func (s *scheduler) Cancel(cmd model.ReportableCommand) {
 	close(s.cancelledCh)
 }
 
+// Supersede cancels this deployment because the given newer deployment
+// of the same application should be handled instead.
+// Nothing will be done when this deployment has already started any irreversible stage.
+// It returns true when this deployment was cancelled.
+func (s *scheduler) Supersede(deploymentID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancelled || s.irreversibleStageStarted {
+		return false
+	}
+	s.cancel(model.ReportableCommand{
+		Command: &model.Command{
+			Commander: fmt.Sprintf("newer deployment %s", deploymentID),
+		},
+		// There is no command to be reported.
+		Report: func(context.Context, model.CommandStatus, map[string]string, []byte) error {
+			return nil
+		},
+	})
+	return true
+}
+
+// UpdateNotes updates the notes attached to the deployment by users
+// to show the latest ones in the notifications.
+func (s *scheduler) UpdateNotes(notes []*model.DeploymentNote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notes = notes
+}
+
+// notifiedDeployment returns the deployment model to be sent in the notifications.
+// Since the deployment model is readonly, a copy of it is returned
+// when any note was newly attached after this scheduler was created.
+func (s *scheduler) notifiedDeployment() *model.Deployment {
+	s.mu.Lock()
+	notes := s.notes
+	s.mu.Unlock()
+
+	// Notes can only be appended.
+	if len(notes) <= len(s.deployment.Notes) {
+		return s.deployment
+	}
+	d := proto.Clone(s.deployment).(*model.Deployment)
+	d.Notes = notes
+	return d
+}
+
+// markStageStarted must be called before starting to execute the given stage
+// to prevent the deployment from being superseded after starting an irreversible stage.
+func (s *scheduler) markStageStarted(stage model.Stage) {
+	if !stage.IsIrreversible() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.irreversibleStageStarted = true
+}
+
+// ConcurrencyPolicy returns the concurrency policy configured for this deployment.
+// The queue policy is returned until the deployment configuration has been loaded.
+func (s *scheduler) ConcurrencyPolicy() config.DeploymentConcurrencyPolicy {
+	if p := s.concurrencyPolicy.Load(); p != "" {
+		return config.DeploymentConcurrencyPolicy(p)
+	}
+	return config.DeploymentConcurrencyPolicyQueue
+}
+
 // Run starts running the scheduler.
 // It determines what stage should be executed next by which executor.
 // The returning error does not mean that the pipeline was failed,
@@ -208,6 +307,7 @@ func (s *scheduler) Run(ctx context.Context) error {
 		cancelCommand   *model.ReportableCommand
 		cancelCommander string
 		lastStage       *model.PipelineStage
+		skipRollback    bool
 		repoID          = s.deployment.GitPath.Repo.Id
 		statusReason    = "The deployment was completed successfully"
 	)
@@ -264,15 +364,23 @@ func (s *scheduler) Run(ctx context.Context) error {
 		return err
 	}
 	s.genericDeploymentConfig = ds.GenericDeploymentConfig
+	s.concurrencyPolicy.Store(string(s.genericDeploymentConfig.ConcurrencyPolicy))
 
-	timer := time.NewTimer(s.genericDeploymentConfig.Timeout.Duration())
+	// The timeout is counted from the time this deployment was started
+	// to ensure that restarting piped does not extend its deadline.
+	var (
+		timeout  = s.genericDeploymentConfig.Timeout.Duration()
+		deadline = s.startedAt(ctx).Add(timeout)
+		timedOut bool
+	)
+	timer := time.NewTimer(deadline.Sub(s.nowFunc()))
 	defer timer.Stop()
 
 	// Iterate all the stages and execute the uncompleted ones.
 	for i, ps := range s.deployment.Stages {
 		lastStage = s.deployment.Stages[i]
 
-		if ps.Status == model.StageStatus_STAGE_SUCCESS {
+		if ps.Status == model.StageStatus_STAGE_SUCCESS || ps.Status == model.StageStatus_STAGE_SKIPPED {
 			continue
 		}
 		if !ps.Visible || ps.Name == model.StageRollback.String() {
@@ -291,12 +399,49 @@ func (s *scheduler) Run(ctx context.Context) error {
 			break
 		}
 
+		// Do not start the next stage when the deadline was already exceeded.
+		if !timedOut && !s.nowFunc().Before(deadline) {
+			timedOut = true
+		}
+		if timedOut {
+			s.logger.Info("deployment was timed out before starting a stage", zap.String("stage-id", ps.Id))
+			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+			statusReason = fmt.Sprintf("Timed out after %v before executing stage %s", timeout, ps.Id)
+			break
+		}
+
+		// The stage timeout is also counted from the time the stage was started
+		// to ensure that restarting piped does not extend its deadline.
+		var (
+			stageTimeout  time.Duration
+			stageTimer    *time.Timer
+			stageTimerCh  <-chan time.Time
+			stageTimedOut bool
+		)
+		if stageConfig, ok := s.getStageConfig(ps); ok && stageConfig.Timeout > 0 {
+			stageTimeout = stageConfig.Timeout.Duration()
+			stageDeadline := s.stageStartedAt(ctx, ps.Id).Add(stageTimeout)
+			if !s.nowFunc().Before(stageDeadline) {
+				s.logger.Info("stage was timed out before being executed", zap.String("stage-id", ps.Id))
+				reason := fmt.Sprintf("Timed out after %v", stageTimeout)
+				if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, reason, ps.Requires); err != nil {
+					s.logger.Error("failed to report stage status", zap.Error(err))
+				}
+				deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+				statusReason = fmt.Sprintf("Stage %s was timed out after %v", ps.Id, stageTimeout)
+				break
+			}
+			stageTimer = time.NewTimer(stageDeadline.Sub(s.nowFunc()))
+			stageTimerCh = stageTimer.C
+		}
+
 		var (
 			result       model.StageStatus
 			sig, handler = executor.NewStopSignal()
 			doneCh       = make(chan struct{})
 		)
 
+		s.markStageStarted(model.Stage(ps.Name))
 		go func() {
 			result = s.executeStage(sig, *ps, func(in executor.Input) (executor.Executor, bool) {
 				return s.executorRegistry.Executor(model.Stage(ps.Name), in)
@@ -310,6 +455,14 @@ func (s *scheduler) Run(ctx context.Context) error {
 			<-doneCh
 
 		case <-timer.C:
+			s.logger.Info("deployment was timed out, stopping the running stage", zap.String("stage-id", ps.Id))
+			timedOut = true
+			handler.Timeout()
+			<-doneCh
+
+		case <-stageTimerCh:
+			s.logger.Info("stage was timed out, stopping it", zap.String("stage-id", ps.Id))
+			stageTimedOut = true
 			handler.Timeout()
 			<-doneCh
 
@@ -324,13 +477,43 @@ func (s *scheduler) Run(ctx context.Context) error {
 		case <-doneCh:
 			break
 		}
+		if stageTimer != nil {
+			stageTimer.Stop()
+		}
 
 		// If all operations of the stage were completed successfully
-		// handle the next stage.
-		if result == model.StageStatus_STAGE_SUCCESS {
+		// or the stage was skipped by a user, handle the next stage.
+		if result == model.StageStatus_STAGE_SUCCESS || result == model.StageStatus_STAGE_SKIPPED {
 			continue
 		}
 
+		// The stage was stopped because of timing out.
+		if stageTimedOut {
+			// The executor context was already cancelled, so the reason is reported here.
+			reason := fmt.Sprintf("Timed out after %v", stageTimeout)
+			if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, reason, ps.Requires); err != nil {
+				s.logger.Error("failed to report stage status", zap.Error(err))
+			}
+			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+			statusReason = fmt.Sprintf("Stage %s was timed out after %v", ps.Id, stageTimeout)
+			break
+		}
+		if sig.Signal() == executor.StopSignalTimeout {
+			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+			statusReason = fmt.Sprintf("Timed out after %v while executing stage %s", timeout, ps.Id)
+			break
+		}
+
+		// The deployment was cancelled by the stage itself
+		// such as WAIT_APPROVAL stage timed out with the cancel action.
+		// No rollback is executed in this case.
+		if result == model.StageStatus_STAGE_CANCELLED && cancelCommand == nil {
+			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
+			statusReason = fmt.Sprintf("Cancelled by stage %s", ps.Id)
+			skipRollback = true
+			break
+		}
+
 		// The deployment was cancelled by a web user.
 		if result == model.StageStatus_STAGE_CANCELLED {
 			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
@@ -340,12 +523,7 @@ func (s *scheduler) Run(ctx context.Context) error {
 
 		if result == model.StageStatus_STAGE_FAILURE {
 			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
-			// The stage was failed because of timing out.
-			if sig.Signal() == executor.StopSignalTimeout {
-				statusReason = fmt.Sprintf("Timed out while executing stage %s", ps.Id)
-			} else {
-				statusReason = fmt.Sprintf("Failed while executing stage %s", ps.Id)
-			}
+			statusReason = fmt.Sprintf("Failed while executing stage %s", ps.Id)
 			break
 		}
 
@@ -360,15 +538,37 @@ func (s *scheduler) Run(ctx context.Context) error {
 		return nil
 	}
 
+	// Follow the onFailure policy of the pipeline to decide whether to roll back the failed deployment.
+	// The cancelled deployments are always rolled back since it is expected while cancelling.
+	if !skipRollback && deploymentStatus == model.DeploymentStatus_DEPLOYMENT_FAILURE {
+		if stage, ok := s.deployment.FindRollbackStage(); ok {
+			rollback, reason, terminated := s.decideRollback(ctx, stage, lastStage.Id)
+			if terminated {
+				return nil
+			}
+			if !rollback {
+				skipRollback = true
+				statusReason = fmt.Sprintf("%s (%s)", statusReason, reason)
+			}
+		}
+	}
+
 	// When the deployment has completed but not successful,
 	// we start rollback stage if the auto-rollback option is true.
-	if deploymentStatus == model.DeploymentStatus_DEPLOYMENT_CANCELLED ||
-		deploymentStatus == model.DeploymentStatus_DEPLOYMENT_FAILURE {
+	if !skipRollback && (deploymentStatus == model.DeploymentStatus_DEPLOYMENT_CANCELLED ||
+		deploymentStatus == model.DeploymentStatus_DEPLOYMENT_FAILURE) {
 		if stage, ok := s.deployment.FindRollbackStage(); ok {
 			// Update to change deployment status to ROLLING_BACK.
 			if err := s.reportDeploymentStatusChanged(ctx, model.DeploymentStatus_DEPLOYMENT_ROLLING_BACK, statusReason); err != nil {
 				return err
 			}
+			s.notifier.Notify(model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPLOYMENT_ROLLING_BACK,
+				Metadata: &model.NotificationEventDeploymentRollingBack{
+					Deployment: s.notifiedDeployment(),
+					EnvName:    s.envName,
+				},
+			})
 
 			// Start running rollback stage.
 			var (
@@ -404,7 +604,7 @@ func (s *scheduler) Run(ctx context.Context) error {
 	}
 
 	if cancelCommand != nil {
-		if err := cancelCommand.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil); err != nil {
+		if err := cancelCommand.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
 			s.logger.Error("failed to report command status", zap.Error(err))
 		}
 	}
@@ -412,6 +612,193 @@ func (s *scheduler) Run(ctx context.Context) error {
 	return nil
 }
 
+// decideRollback decides whether to roll back the failed deployment by following the onFailure policy of the pipeline.
+// The reason is returned when the rollback should not be executed,
+// and the last value is true when piped was terminated while waiting for an approval.
+func (s *scheduler) decideRollback(ctx context.Context, stage *model.PipelineStage, lastStageID string) (bool, string, bool) {
+	var policy *config.DeploymentFailurePolicy
+	if s.genericDeploymentConfig.Pipeline != nil {
+		policy = s.genericDeploymentConfig.Pipeline.OnFailure
+	}
+	if policy == nil {
+		return true, "", false
+	}
+
+	switch policy.Action {
+	case config.DeploymentFailureActionNone:
+		reason := "rollback was skipped by the onFailure policy"
+		lp := s.logPersister.StageLogPersister(s.deployment.Id, stage.Id)
+		s.skipRollbackStage(ctx, stage, lastStageID, lp, reason)
+		return false, reason, false
+
+	case config.DeploymentFailureActionWaitApproval:
+		return s.waitRollbackApproval(ctx, stage, lastStageID, policy)
+
+	default:
+		return true, "", false
+	}
+}
+
+// waitRollbackApproval waits until the rollback was approved, rejected or timed out.
+// The rollback is started automatically after timing out.
+func (s *scheduler) waitRollbackApproval(ctx context.Context, stage *model.PipelineStage, lastStageID string, policy *config.DeploymentFailurePolicy) (bool, string, bool) {
+	var (
+		requires  = []string{lastStageID}
+		lp        = s.logPersister.StageLogPersister(s.deployment.Id, stage.Id)
+		timeout   = policy.Timeout.Duration()
+		timeoutAt = s.stageStartedAt(ctx, stage.Id).Add(timeout)
+	)
+
+	// Mark the rollback stage as running to be able to approve it.
+	if model.CanUpdateStageStatus(stage.Status, model.StageStatus_STAGE_RUNNING) {
+		if err := s.reportStageStatus(ctx, stage.Id, model.StageStatus_STAGE_RUNNING, "Waiting for an approval to roll back", requires); err != nil {
+			s.logger.Error("failed to report stage status", zap.Error(err))
+		}
+	}
+	lp.Infof("Waiting for an approval to roll back the failed deployment until %s...", timeoutAt.UTC().Format(time.RFC1123))
+	s.notifier.Notify(model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL,
+		Metadata: &model.NotificationEventDeploymentWaitApproval{
+			Deployment: s.notifiedDeployment(),
+			EnvName:    s.envName,
+			Approvers:  policy.Approvers,
+			TimeoutAt:  timeoutAt.Unix(),
+			StageId:    stage.Id,
+		},
+	})
+
+	timer := time.NewTimer(timeoutAt.Sub(s.nowFunc()))
+	defer timer.Stop()
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, "", true
+
+		case <-timer.C:
+			lp.Infof("Timed out %v, the rollback is started automatically", timeout)
+			lp.Complete(time.Minute)
+			return true, "", false
+
+		case cmd := <-s.cancelledCh:
+			if cmd == nil {
+				continue
+			}
+			reason := fmt.Sprintf("rollback was cancelled by %s", cmd.Commander)
+			s.skipRollbackStage(ctx, stage, lastStageID, lp, reason)
+			if err := cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+				s.logger.Error("failed to report command status", zap.Error(err))
+			}
+			return false, reason, false
+
+		case <-ticker.C:
+			approved, commander, ok := s.takeRollbackDecision(ctx, stage.Id, policy.Approvers)
+			if !ok {
+				continue
+			}
+			if approved {
+				lp.Infof("Got an approval from %s", commander)
+				lp.Complete(time.Minute)
+				return true, "", false
+			}
+			reason := fmt.Sprintf("rollback was rejected by %s", commander)
+			s.skipRollbackStage(ctx, stage, lastStageID, lp, reason)
+			return false, reason, false
+		}
+	}
+}
+
+// takeRollbackDecision returns the decision on the rollback stage
+// made from the web console or the notifications.
+func (s *scheduler) takeRollbackDecision(ctx context.Context, stageID string, approvers []string) (approved bool, commander string, ok bool) {
+	for _, cmd := range s.commandLister.ListStageCommands(s.deployment.Id, stageID) {
+		if cmd.GetApproveStage() == nil && cmd.GetRejectStage() == nil {
+			continue
+		}
+		if err := cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+			s.logger.Error("failed to report handled command", zap.Error(err))
+		}
+		return cmd.GetApproveStage() != nil, cmd.Commander, true
+	}
+
+	if s.approvalTaker == nil {
+		return false, "", false
+	}
+	d, ok := s.approvalTaker.Take(s.deployment.Id, stageID)
+	if !ok {
+		return false, "", false
+	}
+	if len(approvers) > 0 && !containsString(approvers, d.Commander) {
+		s.logger.Info(fmt.Sprintf("ignored the decision from %s since the user is not one of the approvers", d.Commander))
+		return false, "", false
+	}
+	return d.Approved, d.Commander, true
+}
+
+// skipRollbackStage marks the rollback stage as skipped with the given reason.
+func (s *scheduler) skipRollbackStage(ctx context.Context, stage *model.PipelineStage, lastStageID string, lp logpersister.StageLogPersister, reason string) {
+	lp.Infof("The deployment is left as-is since %s", reason)
+	lp.Complete(time.Minute)
+
+	if err := s.reportStageStatus(ctx, stage.Id, model.StageStatus_STAGE_SKIPPED, reason, []string{lastStageID}); err != nil {
+		s.logger.Error("failed to report stage status", zap.Error(err))
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// startedAt returns the time when this deployment was started to be handled by piped.
+// It is saved into the deployment metadata at the first run
+// so that the same value can be used after restarting piped.
+func (s *scheduler) startedAt(ctx context.Context) time.Time {
+	if v, ok := s.metadataStore.Get(startedAtMetadataKey); ok {
+		if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(sec, 0)
+		}
+	}
+
+	now := s.nowFunc()
+	if err := s.metadataStore.Set(ctx, startedAtMetadataKey, strconv.FormatInt(now.Unix(), 10)); err != nil {
+		s.logger.Error("failed to save the started time of deployment", zap.Error(err))
+	}
+	return now
+}
+
+// stageStartedAt returns the time when the given stage was started to be executed.
+// It is saved into the stage metadata at the first run
+// so that the same value can be used after restarting piped.
+func (s *scheduler) stageStartedAt(ctx context.Context, stageID string) time.Time {
+	metadata := executor.NewStageMetadata(s.metadataStore, stageID)
+	if v, ok := metadata.Get(stageStartedAtMetadataKey); ok {
+		if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(sec, 0)
+		}
+	}
+
+	now := s.nowFunc()
+	if err := metadata.Put(ctx, stageStartedAtMetadataKey, strconv.FormatInt(now.Unix(), 10)); err != nil {
+		s.logger.Error("failed to save the started time of stage", zap.String("stage-id", stageID), zap.Error(err))
+	}
+	return now
+}
+
+// getStageConfig returns the configuration of the given stage.
+func (s *scheduler) getStageConfig(ps *model.PipelineStage) (config.PipelineStage, bool) {
+	if ps.Predefined {
+		return pln.GetPredefinedStage(ps.Id)
+	}
+	return s.genericDeploymentConfig.GetStage(ps.Index)
+}
+
 // executeStage finds the executor for the given stage and execute.
 func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage, executorFactory func(executor.Input) (executor.Executor, bool)) (finalStatus model.StageStatus) {
 	var (
@@ -430,7 +817,7 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 
 	// Update stage status to RUNNING if needed.
 	if model.CanUpdateStageStatus(ps.Status, model.StageStatus_STAGE_RUNNING) {
-		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_RUNNING, ps.Requires); err != nil {
+		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_RUNNING, "", ps.Requires); err != nil {
 			return model.StageStatus_STAGE_FAILURE
 		}
 		originalStatus = model.StageStatus_STAGE_RUNNING
@@ -439,24 +826,17 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 	// Check the existence of the specified cloud provider.
 	if !s.pipedConfig.HasCloudProvider(s.deployment.CloudProvider, s.deployment.CloudProviderType()) {
 		lp.Errorf("This piped is not having the specified cloud provider in this deployment: %v", s.deployment.CloudProvider)
-		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires); err != nil {
+		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, "", ps.Requires); err != nil {
 			s.logger.Error("failed to report stage status", zap.Error(err))
 		}
 		return model.StageStatus_STAGE_FAILURE
 	}
 
 	// Load the stage configuration.
-	var stageConfig config.PipelineStage
-	var stageConfigFound bool
-	if ps.Predefined {
-		stageConfig, stageConfigFound = pln.GetPredefinedStage(ps.Id)
-	} else {
-		stageConfig, stageConfigFound = s.genericDeploymentConfig.GetStage(ps.Index)
-	}
-
+	stageConfig, stageConfigFound := s.getStageConfig(&ps)
 	if !stageConfigFound {
 		lp.Error("Unable to find the stage configuration")
-		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires); err != nil {
+		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, "", ps.Requires); err != nil {
 			s.logger.Error("failed to report stage status", zap.Error(err))
 		}
 		return model.StageStatus_STAGE_FAILURE
@@ -465,7 +845,7 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 	app, ok := s.applicationLister.Get(s.deployment.ApplicationId)
 	if !ok {
 		lp.Errorf("Application %s for this deployment was not found (Maybe it was disabled).", s.deployment.ApplicationId)
-		s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires)
+		s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, "", ps.Requires)
 		return model.StageStatus_STAGE_FAILURE
 	}
 
@@ -484,6 +864,7 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 		StageConfig:           stageConfig,
 		Deployment:            s.deployment,
 		Application:           app,
+		EnvName:               s.envName,
 		PipedConfig:           s.pipedConfig,
 		TargetDSP:             s.targetDSP,
 		RunningDSP:            s.runningDSP,
@@ -492,7 +873,12 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 		MetadataStore:         s.metadataStore,
 		AppManifestsCache:     s.appManifestsCache,
 		AppLiveResourceLister: alrLister,
-		Logger:                s.logger,
+		Notifier:              s.notifier,
+		ApprovalTaker:         s.approvalTaker,
+		Logger: s.logger.Named("executor").With(
+			zap.String("stage-id", ps.Id),
+			zap.String("stage-name", ps.Name),
+		),
 	}
 
 	// Find the executor for this stage.
@@ -500,22 +886,26 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 	if !ok {
 		err := fmt.Errorf("no registered executor for stage %s", ps.Name)
 		lp.Error(err.Error())
-		s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires)
+		s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, "", ps.Requires)
 		return model.StageStatus_STAGE_FAILURE
 	}
 
 	// Start running executor.
+	metricsExecutorStarted(ps.Name)
 	status := ex.Execute(sig)
+	metricsExecutorFinished(ps.Name)
 
 	// Commit deployment state status in the following cases:
 	// - Apply state successfully.
 	// - State was canceled while running (cancel via Controlpane).
+	// - State was skipped while running (skip via Controlpane).
 	// - Apply state failed but not because of terminating piped process.
 	if status == model.StageStatus_STAGE_SUCCESS ||
 		status == model.StageStatus_STAGE_CANCELLED ||
+		status == model.StageStatus_STAGE_SKIPPED ||
 		(status == model.StageStatus_STAGE_FAILURE && !sig.Terminated()) {
 
-		s.reportStageStatus(ctx, ps.Id, status, ps.Requires)
+		s.reportStageStatus(ctx, ps.Id, status, "", ps.Requires)
 		return status
 	}
 
@@ -524,7 +914,7 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 	return originalStatus
 }
 
-func (s *scheduler) reportStageStatus(ctx context.Context, stageID string, status model.StageStatus, requires []string) error {
+func (s *scheduler) reportStageStatus(ctx context.Context, stageID string, status model.StageStatus, reason string, requires []string) error {
 	var (
 		err error
 		now = s.nowFunc()
@@ -532,6 +922,7 @@ func (s *scheduler) reportStageStatus(ctx context.Context, stageID string, statu
 			DeploymentId: s.deployment.Id,
 			StageId:      stageID,
 			Status:       status,
+			StatusReason: reason,
 			Requires:     requires,
 			Visible:      true,
 			CompletedAt:  now.Unix(),
@@ -543,11 +934,11 @@ func (s *scheduler) reportStageStatus(ctx context.Context, stageID string, statu
 	s.stageStatuses[stageID] = status
 
 	// Update stage status on the remote.
-	for retry.WaitNext(ctx) {
-		_, err = s.apiClient.ReportStageStatusChanged(ctx, req)
-		if err == nil {
-			break
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := s.apiClient.ReportStageStatusChanged(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report stage status to control-plane: %v", err)
 	}
 
@@ -566,10 +957,11 @@ func (s *scheduler) reportDeploymentStatusChanged(ctx context.Context, status mo
 	)
 
 	// Update deployment status on remote.
-	for retry.WaitNext(ctx) {
-		if _, err = s.apiClient.ReportDeploymentStatusChanged(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := s.apiClient.ReportDeploymentStatusChanged(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report deployment status to control-plane: %v", err)
 	}
 	return err
@@ -590,12 +982,13 @@ func (s *scheduler) reportDeploymentCompleted(ctx context.Context, status model.
 	)
 
 	defer func() {
+		d := s.notifiedDeployment()
 		switch status {
 		case model.DeploymentStatus_DEPLOYMENT_SUCCESS:
 			s.notifier.Notify(model.NotificationEvent{
 				Type: model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED,
 				Metadata: &model.NotificationEventDeploymentSucceeded{
-					Deployment: s.deployment,
+					Deployment: d,
 					EnvName:    s.envName,
 				},
 			})
@@ -604,7 +997,7 @@ func (s *scheduler) reportDeploymentCompleted(ctx context.Context, status model.
 			s.notifier.Notify(model.NotificationEvent{
 				Type: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
 				Metadata: &model.NotificationEventDeploymentFailed{
-					Deployment: s.deployment,
+					Deployment: d,
 					EnvName:    s.envName,
 					Reason:     desc,
 				},
@@ -614,7 +1007,7 @@ func (s *scheduler) reportDeploymentCompleted(ctx context.Context, status model.
 			s.notifier.Notify(model.NotificationEvent{
 				Type: model.NotificationEventType_EVENT_DEPLOYMENT_CANCELLED,
 				Metadata: &model.NotificationEventDeploymentCancelled{
-					Deployment: s.deployment,
+					Deployment: d,
 					EnvName:    s.envName,
 					Commander:  cancelCommander,
 				},
@@ -623,10 +1016,11 @@ func (s *scheduler) reportDeploymentCompleted(ctx context.Context, status model.
 	}()
 
 	// Update deployment status on remote.
-	for retry.WaitNext(ctx) {
-		if _, err = s.apiClient.ReportDeploymentCompleted(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := s.apiClient.ReportDeploymentCompleted(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report deployment status to control-plane: %w", err)
 	}
 
@@ -651,10 +1045,11 @@ func (s *scheduler) reportMostRecentlySuccessfulDeployment(ctx context.Context)
 		retry = pipedservice.NewRetry(10)
 	)
 
-	for retry.WaitNext(ctx) {
-		if _, err = s.apiClient.ReportApplicationMostRecentDeployment(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := s.apiClient.ReportApplicationMostRecentDeployment(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report most recent successful deployment: %w", err)
 	}
 	return err
@@ -669,3 +1064,7 @@ type stageCommandLister struct {
 func (s stageCommandLister) ListCommands() []model.ReportableCommand {
 	return s.lister.ListStageCommands(s.deploymentID, s.stageID)
 }
+
+func (s stageCommandLister) Updated() <-chan struct{} {
+	return s.lister.Updated()
+}
@@ -0,0 +1,326 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/app/piped/approvalstore"
+	"github.com/pipe-cd/pipe/pkg/app/piped/logpersister"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeMetadataAPIClient struct {
+	apiClient
+	saved map[string]string
+}
+
+func (c *fakeMetadataAPIClient) SaveDeploymentMetadata(_ context.Context, req *pipedservice.SaveDeploymentMetadataRequest, _ ...grpc.CallOption) (*pipedservice.SaveDeploymentMetadataResponse, error) {
+	c.saved = req.Metadata
+	return &pipedservice.SaveDeploymentMetadataResponse{}, nil
+}
+
+func TestSchedulerStartedAt(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	testcases := []struct {
+		name          string
+		metadata      map[string]string
+		expected      time.Time
+		expectedSaved map[string]string
+	}{
+		{
+			name:          "first run",
+			expected:      now,
+			expectedSaved: map[string]string{startedAtMetadataKey: "1600000000"},
+		},
+		{
+			name:     "restarted run",
+			metadata: map[string]string{startedAtMetadataKey: "1599999000"},
+			expected: time.Unix(1599999000, 0),
+		},
+		{
+			name:          "malformed value",
+			metadata:      map[string]string{startedAtMetadataKey: "foo"},
+			expected:      now,
+			expectedSaved: map[string]string{startedAtMetadataKey: "1600000000"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeMetadataAPIClient{}
+			d := &model.Deployment{Id: "deployment-id", Metadata: tc.metadata}
+			s := &scheduler{
+				deployment:    d,
+				metadataStore: NewMetadataStore(client, d),
+				logger:        zap.NewNop(),
+				nowFunc:       func() time.Time { return now },
+			}
+			assert.Equal(t, tc.expected, s.startedAt(context.Background()))
+			assert.Equal(t, tc.expectedSaved, client.saved)
+		})
+	}
+}
+
+func TestSchedulerStageStartedAt(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	testcases := []struct {
+		name          string
+		metadata      map[string]string
+		expected      time.Time
+		expectedSaved map[string]string
+	}{
+		{
+			name:          "first run",
+			metadata:      map[string]string{"Approvers": "foo"},
+			expected:      now,
+			expectedSaved: map[string]string{"Approvers": "foo", stageStartedAtMetadataKey: "1600000000"},
+		},
+		{
+			name:     "restarted run",
+			metadata: map[string]string{stageStartedAtMetadataKey: "1599999000"},
+			expected: time.Unix(1599999000, 0),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeStageMetadataAPIClient{
+				saved: make(map[string]map[string]string),
+			}
+			d := &model.Deployment{
+				Id: "deployment-id",
+				Stages: []*model.PipelineStage{
+					{Id: "stage-id", Metadata: tc.metadata},
+				},
+			}
+			s := &scheduler{
+				deployment:    d,
+				metadataStore: NewMetadataStore(client, d),
+				logger:        zap.NewNop(),
+				nowFunc:       func() time.Time { return now },
+			}
+			assert.Equal(t, tc.expected, s.stageStartedAt(context.Background(), "stage-id"))
+			assert.Equal(t, tc.expectedSaved, client.saved["stage-id"])
+		})
+	}
+}
+
+func TestSchedulerNotifiedDeployment(t *testing.T) {
+	d := &model.Deployment{
+		Id:    "deployment-id",
+		Notes: []*model.DeploymentNote{{Text: "INC-1"}},
+	}
+	s := &scheduler{deployment: d}
+
+	// The original deployment is used when there is no new note.
+	s.UpdateNotes(d.Notes)
+	assert.Same(t, d, s.notifiedDeployment())
+
+	// A copy with the latest notes is used when a note was attached.
+	notes := []*model.DeploymentNote{{Text: "INC-1"}, {Text: "CHG-2", Url: "https://example.com/CHG-2"}}
+	s.UpdateNotes(notes)
+	got := s.notifiedDeployment()
+	assert.NotSame(t, d, got)
+	assert.Equal(t, "deployment-id", got.Id)
+	assert.Equal(t, notes, got.Notes)
+	assert.Equal(t, 1, len(d.Notes))
+}
+
+type fakeLogPersister struct {
+	logpersister.Persister
+}
+
+func (fakeLogPersister) StageLogPersister(_, _ string) logpersister.StageLogPersister {
+	return fakeStageLogPersister{}
+}
+
+type fakeStageLogPersister struct {
+	logpersister.StageLogPersister
+}
+
+func (fakeStageLogPersister) Infof(_ string, _ ...interface{}) {}
+func (fakeStageLogPersister) Complete(_ time.Duration) error   { return nil }
+
+type fakeStageStatusAPIClient struct {
+	apiClient
+	statuses map[string]model.StageStatus
+}
+
+func (c *fakeStageStatusAPIClient) ReportStageStatusChanged(_ context.Context, req *pipedservice.ReportStageStatusChangedRequest, _ ...grpc.CallOption) (*pipedservice.ReportStageStatusChangedResponse, error) {
+	c.statuses[req.StageId] = req.Status
+	return &pipedservice.ReportStageStatusChangedResponse{}, nil
+}
+
+func TestSchedulerDecideRollback(t *testing.T) {
+	testcases := []struct {
+		name             string
+		pipeline         *config.DeploymentPipeline
+		expectedRollback bool
+		expectedStatuses map[string]model.StageStatus
+	}{
+		{
+			name:             "no pipeline",
+			expectedRollback: true,
+			expectedStatuses: map[string]model.StageStatus{},
+		},
+		{
+			name:             "no policy",
+			pipeline:         &config.DeploymentPipeline{},
+			expectedRollback: true,
+			expectedStatuses: map[string]model.StageStatus{},
+		},
+		{
+			name: "rollback",
+			pipeline: &config.DeploymentPipeline{
+				OnFailure: &config.DeploymentFailurePolicy{Action: config.DeploymentFailureActionRollback},
+			},
+			expectedRollback: true,
+			expectedStatuses: map[string]model.StageStatus{},
+		},
+		{
+			name: "none",
+			pipeline: &config.DeploymentPipeline{
+				OnFailure: &config.DeploymentFailurePolicy{Action: config.DeploymentFailureActionNone},
+			},
+			expectedRollback: false,
+			expectedStatuses: map[string]model.StageStatus{
+				"rollback": model.StageStatus_STAGE_SKIPPED,
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeStageStatusAPIClient{
+				statuses: make(map[string]model.StageStatus),
+			}
+			s := &scheduler{
+				deployment:    &model.Deployment{Id: "deployment-id"},
+				apiClient:     client,
+				logPersister:  fakeLogPersister{},
+				stageStatuses: make(map[string]model.StageStatus),
+				genericDeploymentConfig: config.GenericDeploymentSpec{
+					Pipeline: tc.pipeline,
+				},
+				logger:  zap.NewNop(),
+				nowFunc: time.Now,
+			}
+			stage := &model.PipelineStage{Id: "rollback", Name: model.StageRollback.String()}
+			rollback, reason, terminated := s.decideRollback(context.Background(), stage, "stage-id")
+			assert.Equal(t, tc.expectedRollback, rollback)
+			assert.Equal(t, tc.expectedRollback, reason == "")
+			assert.False(t, terminated)
+			assert.Equal(t, tc.expectedStatuses, client.statuses)
+		})
+	}
+}
+
+type fakeCommandLister struct {
+	commandLister
+	commands []model.ReportableCommand
+}
+
+func (l fakeCommandLister) ListStageCommands(_, _ string) []model.ReportableCommand {
+	return l.commands
+}
+
+type fakeApprovalTaker struct {
+	decision *approvalstore.Decision
+}
+
+func (t fakeApprovalTaker) Take(_, _ string) (approvalstore.Decision, bool) {
+	if t.decision == nil {
+		return approvalstore.Decision{}, false
+	}
+	return *t.decision, true
+}
+
+func TestSchedulerTakeRollbackDecision(t *testing.T) {
+	report := func(context.Context, model.CommandStatus, map[string]string, []byte) error {
+		return nil
+	}
+	testcases := []struct {
+		name             string
+		commands         []model.ReportableCommand
+		decision         *approvalstore.Decision
+		approvers        []string
+		expectedApproved bool
+		expectedCmd      string
+		expectedOK       bool
+	}{
+		{
+			name: "no decision",
+		},
+		{
+			name: "approved from web",
+			commands: []model.ReportableCommand{
+				{
+					Command: &model.Command{Commander: "foo", ApproveStage: &model.Command_ApproveStage{}},
+					Report:  report,
+				},
+			},
+			expectedApproved: true,
+			expectedCmd:      "foo",
+			expectedOK:       true,
+		},
+		{
+			name: "rejected from web",
+			commands: []model.ReportableCommand{
+				{
+					Command: &model.Command{Commander: "foo", RejectStage: &model.Command_RejectStage{}},
+					Report:  report,
+				},
+			},
+			expectedApproved: false,
+			expectedCmd:      "foo",
+			expectedOK:       true,
+		},
+		{
+			name:             "approved from notification",
+			decision:         &approvalstore.Decision{Approved: true, Commander: "bar"},
+			approvers:        []string{"bar"},
+			expectedApproved: true,
+			expectedCmd:      "bar",
+			expectedOK:       true,
+		},
+		{
+			name:      "ignored decision from non-approver",
+			decision:  &approvalstore.Decision{Approved: true, Commander: "bar"},
+			approvers: []string{"foo"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &scheduler{
+				deployment:    &model.Deployment{Id: "deployment-id"},
+				commandLister: fakeCommandLister{commands: tc.commands},
+				approvalTaker: fakeApprovalTaker{decision: tc.decision},
+				logger:        zap.NewNop(),
+			}
+			approved, commander, ok := s.takeRollbackDecision(context.Background(), "rollback", tc.approvers)
+			assert.Equal(t, tc.expectedApproved, approved)
+			assert.Equal(t, tc.expectedCmd, commander)
+			assert.Equal(t, tc.expectedOK, ok)
+		})
+	}
+}
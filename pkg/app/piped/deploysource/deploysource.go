@@ -15,7 +15,9 @@
 package deploysource
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,6 +25,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sync"
+	"text/template"
 
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/git"
@@ -36,6 +39,10 @@ type DeploySource struct {
 	Revision                string
 	DeploymentConfig        *config.Config
 	GenericDeploymentConfig config.GenericDeploymentSpec
+	// The analysis templates shared across the repository.
+	// This is empty when the pipeline does not contain any ANALYSIS stage
+	// or no template was defined in the .pipe directory.
+	AnalysisTemplateSpec *config.AnalysisTemplateSpec
 }
 
 type Provider interface {
@@ -59,6 +66,7 @@ type provider struct {
 	gitClient             gitClient
 	appGitPath            *model.ApplicationGitPath
 	sealedSecretDecrypter sealedSecretDecrypter
+	sopsDecrypter         sopsDecrypter
 
 	done    bool
 	source  *DeploySource
@@ -84,6 +92,7 @@ func NewProvider(
 		gitClient:             gitClient,
 		appGitPath:            appGitPath,
 		sealedSecretDecrypter: ssd,
+		sopsDecrypter:         sopsCLI{},
 	}
 }
 
@@ -175,6 +184,22 @@ func (p *provider) prepare(ctx context.Context, lw io.Writer) (*DeploySource, er
 	}
 	writeLog(lw, "Successfully loaded the deployment configuration file")
 
+	// Load the analysis templates shared across the repository.
+	analysisTemplateSpec := &config.AnalysisTemplateSpec{}
+	if gdc.HasStage(model.StageAnalysis) {
+		spec, err := config.LoadAnalysisTemplate(repoDir)
+		switch {
+		case err == nil:
+			analysisTemplateSpec = spec
+			writeLog(lw, "Successfully loaded the analysis templates from %s directory", config.SharedConfigurationDirName)
+		case errors.Is(err, config.ErrNotFound):
+			writeLog(lw, "No analysis template was found in %s directory", config.SharedConfigurationDirName)
+		default:
+			writeLog(lw, "Unable to load the analysis templates (%v)", err)
+			return nil, err
+		}
+	}
+
 	// Decrypt the sealed secrets if needed.
 	if len(gdc.SealedSecrets) > 0 && p.sealedSecretDecrypter != nil {
 		for _, s := range gdc.SealedSecrets {
@@ -186,6 +211,28 @@ func (p *provider) prepare(ctx context.Context, lw io.Writer) (*DeploySource, er
 		writeLog(lw, "Successfully decrypted %d sealed secrets", len(gdc.SealedSecrets))
 	}
 
+	// Expand the encrypted secrets referenced in the manifests.
+	if gdc.Encryption != nil {
+		if p.sealedSecretDecrypter == nil {
+			writeLog(lw, "Unable to decrypt the encrypted secrets since no sealed secret management was configured in piped")
+			return nil, fmt.Errorf("no sealed secret management was configured to decrypt the encrypted secrets")
+		}
+		if err := decryptSecrets(appDir, *gdc.Encryption, p.sealedSecretDecrypter); err != nil {
+			writeLog(lw, "Unable to decrypt the encrypted secrets (%v)", err)
+			return nil, err
+		}
+		writeLog(lw, "Successfully decrypted %d encrypted secrets into %d files", len(gdc.Encryption.EncryptedSecrets), len(gdc.Encryption.DecryptionTargets))
+	}
+
+	// Decrypt the files encrypted by SOPS.
+	if gdc.Sops != nil {
+		if err := decryptSopsFiles(ctx, appDir, *gdc.Sops, p.sopsDecrypter); err != nil {
+			writeLog(lw, "Unable to decrypt the files encrypted by SOPS (%v)", err)
+			return nil, err
+		}
+		writeLog(lw, "Successfully decrypted %d files encrypted by SOPS", len(gdc.Sops.Files))
+	}
+
 	return &DeploySource{
 		RepoDir:                 repoDir,
 		AppDir:                  appDir,
@@ -193,6 +240,7 @@ func (p *provider) prepare(ctx context.Context, lw io.Writer) (*DeploySource, er
 		Revision:                p.revision,
 		DeploymentConfig:        cfg,
 		GenericDeploymentConfig: gdc,
+		AnalysisTemplateSpec:    analysisTemplateSpec,
 	}, nil
 }
 
@@ -214,6 +262,7 @@ func (p *provider) copy(lw io.Writer) (*DeploySource, error) {
 		Revision:                p.revision,
 		DeploymentConfig:        p.source.DeploymentConfig,
 		GenericDeploymentConfig: p.source.GenericDeploymentConfig,
+		AnalysisTemplateSpec:    p.source.AnalysisTemplateSpec,
 	}, nil
 }
 
@@ -247,12 +296,57 @@ func decryptSealedSecret(appDir string, secret config.SealedSecretMapping, dcr s
 	}
 	outPath := filepath.Join(appDir, outDir, outFile)
 
-	if err := ioutil.WriteFile(outPath, content, 0644); err != nil {
+	// The existing file must be removed first since its permission is not changed by WriteFile
+	// while the decrypted content should only be readable by the owner.
+	if err := os.Remove(outPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to remove the existing file of sealed secret file %s (%w)", secret.Path, err)
+	}
+	if err := ioutil.WriteFile(outPath, content, 0600); err != nil {
 		return fmt.Errorf("unable to write decrypted content of sealed secret file %s (%w)", secret.Path, err)
 	}
 	return nil
 }
 
+func decryptSecrets(appDir string, enc config.SecretEncryption, dcr sealedSecretDecrypter) error {
+	secrets := make(map[string]string, len(enc.EncryptedSecrets))
+	for k, v := range enc.EncryptedSecrets {
+		text, err := dcr.Decrypt(v)
+		if err != nil {
+			return fmt.Errorf("unable to decrypt %s secret (%w)", k, err)
+		}
+		secrets[k] = text
+	}
+	data := map[string]interface{}{
+		"encryptedSecrets": secrets,
+	}
+
+	for _, target := range enc.DecryptionTargets {
+		targetPath := filepath.Join(appDir, target)
+		content, err := ioutil.ReadFile(targetPath)
+		if err != nil {
+			return fmt.Errorf("unable to read decryption target %s (%w)", target, err)
+		}
+		tmpl, err := template.New(filepath.Base(target)).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("unable to parse decryption target %s as a template (%w)", target, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return fmt.Errorf("unable to render decryption target %s (%w)", target, err)
+		}
+
+		// Same as the sealed secrets, the file containing the decrypted secrets
+		// should only be readable by the owner.
+		if err := os.Remove(targetPath); err != nil {
+			return fmt.Errorf("unable to remove the original file of decryption target %s (%w)", target, err)
+		}
+		if err := ioutil.WriteFile(targetPath, out.Bytes(), 0600); err != nil {
+			return fmt.Errorf("unable to write decrypted content of decryption target %s (%w)", target, err)
+		}
+	}
+	return nil
+}
+
 func writeLog(w io.Writer, format string, a ...interface{}) {
 	io.WriteString(w, fmt.Sprintf(format, a...))
 }
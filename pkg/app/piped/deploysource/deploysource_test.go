@@ -94,6 +94,13 @@ spec:
 		require.NoError(t, err)
 	}
 
+	// The decrypted files must be readable by only the owner.
+	for _, f := range []string{"replacing.yaml", "new-copy.yaml", ".credentials/copy.yaml"} {
+		info, err := os.Stat(filepath.Join(dir, f))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), f)
+	}
+
 	data, err := ioutil.ReadFile(filepath.Join(dir, "replacing.yaml"))
 	require.NoError(t, err)
 	assert.Equal(t,
@@ -123,3 +130,88 @@ data:
 		string(data),
 	)
 }
+
+func TestDecryptSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-decrypting-secrets")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "manifests"), 0755))
+	err = ioutil.WriteFile(filepath.Join(dir, "manifests", "deployment.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: DB_PASSWORD
+          value: {{ .encryptedSecrets.dbPassword }}
+`),
+		0644,
+	)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "secret.yaml"), []byte(`apiVersion: v1
+kind: Secret
+stringData:
+  password: {{ .encryptedSecrets.dbPassword }}
+  token: {{ .encryptedSecrets.token }}
+`),
+		0644,
+	)
+	require.NoError(t, err)
+
+	dcr := testSealedSecretDecrypter{
+		prefix: "decrypted-",
+	}
+	enc := config.SecretEncryption{
+		EncryptedSecrets: map[string]string{
+			"dbPassword": "encrypted-password",
+			"token":      "encrypted-token",
+		},
+		DecryptionTargets: []string{
+			"manifests/deployment.yaml",
+			"secret.yaml",
+		},
+	}
+	require.NoError(t, decryptSecrets(dir, enc, dcr))
+
+	for _, f := range enc.DecryptionTargets {
+		info, err := os.Stat(filepath.Join(dir, f))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), f)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "manifests", "deployment.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: DB_PASSWORD
+          value: decrypted-encrypted-password
+`,
+		string(data),
+	)
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "secret.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+stringData:
+  password: decrypted-encrypted-password
+  token: decrypted-encrypted-token
+`,
+		string(data),
+	)
+
+	// Referencing an unknown secret must be an error.
+	err = ioutil.WriteFile(filepath.Join(dir, "unknown.yaml"), []byte(`value: {{ .encryptedSecrets.unknown }}`), 0644)
+	require.NoError(t, err)
+	enc.DecryptionTargets = []string{"unknown.yaml"}
+	assert.Error(t, decryptSecrets(dir, enc, dcr))
+}
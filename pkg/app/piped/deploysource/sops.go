@@ -0,0 +1,73 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploysource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/executil"
+	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+type sopsDecrypter interface {
+	Decrypt(ctx context.Context, version, path string) ([]byte, error)
+}
+
+// sopsCLI decrypts the files by running the sops binary.
+// The keys used for decrypting such as KMS credentials or age key file
+// are given by the environment of piped, e.g. SOPS_AGE_KEY_FILE.
+type sopsCLI struct{}
+
+func (sopsCLI) Decrypt(ctx context.Context, version, path string) ([]byte, error) {
+	execPath, _, err := toolregistry.DefaultRegistry().Sops(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("no sops %s (%w)", version, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := executil.CommandContext(ctx, execPath, "--decrypt", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func decryptSopsFiles(ctx context.Context, appDir string, sops config.SopsDecryption, dcr sopsDecrypter) error {
+	for _, f := range sops.Files {
+		path := filepath.Join(appDir, f)
+		content, err := dcr.Decrypt(ctx, sops.Version, path)
+		if err != nil {
+			return fmt.Errorf("unable to decrypt sops encrypted file %s (%w)", f, err)
+		}
+
+		// Same as the sealed secrets, the decrypted file
+		// should only be readable by the owner.
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("unable to remove the original file of sops encrypted file %s (%w)", f, err)
+		}
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			return fmt.Errorf("unable to write decrypted content of sops encrypted file %s (%w)", f, err)
+		}
+	}
+	return nil
+}
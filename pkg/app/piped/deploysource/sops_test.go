@@ -0,0 +1,82 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploysource
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+type testSopsDecrypter struct {
+	versions []string
+}
+
+func (d *testSopsDecrypter) Decrypt(_ context.Context, version, path string) ([]byte, error) {
+	d.versions = append(d.versions, version)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(string(data), "ENC:") {
+		return nil, fmt.Errorf("not encrypted")
+	}
+	return []byte(strings.TrimPrefix(string(data), "ENC:")), nil
+}
+
+func TestDecryptSopsFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-decrypting-sops-files")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "config"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret.enc.yaml"), []byte("ENC:password: foo\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config", "credentials.json"), []byte(`ENC:{"token": "bar"}`), 0644))
+
+	dcr := &testSopsDecrypter{}
+	sops := config.SopsDecryption{
+		Version: "3.7.1",
+		Files:   []string{"secret.enc.yaml", "config/credentials.json"},
+	}
+	require.NoError(t, decryptSopsFiles(context.Background(), dir, sops, dcr))
+	assert.Equal(t, []string{"3.7.1", "3.7.1"}, dcr.versions)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "secret.enc.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "password: foo\n", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "config", "credentials.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"token": "bar"}`, string(data))
+
+	for _, f := range sops.Files {
+		info, err := os.Stat(filepath.Join(dir, f))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), f)
+	}
+
+	// The already decrypted files can not be decrypted again.
+	assert.Error(t, decryptSopsFiles(context.Background(), dir, sops, dcr))
+}
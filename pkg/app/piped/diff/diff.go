@@ -26,6 +26,8 @@ type differ struct {
 	ignoreAddingMapKeys           bool
 	equateEmpty                   bool
 	compareNumberAndNumericString bool
+	normalizeQuantities           bool
+	matchByMergeKeys              bool
 
 	result *Result
 }
@@ -56,6 +58,24 @@ func WithCompareNumberAndNumericString() Option {
 	}
 }
 
+// WithNormalizeQuantities configures differ to compare the Kubernetes resource quantities
+// such as container resources limits/requests by their values instead of their representations.
+// e.g. "1000m" == 1, "1Gi" == "1024Mi"
+func WithNormalizeQuantities() Option {
+	return func(d *differ) {
+		d.normalizeQuantities = true
+	}
+}
+
+// WithMatchByMergeKeys configures differ to match the elements of the well-known Kubernetes lists
+// such as containers, env and volumes by their strategic merge keys instead of their indexes.
+// So reordering those elements is not considered as a diff.
+func WithMatchByMergeKeys() Option {
+	return func(d *differ) {
+		d.matchByMergeKeys = true
+	}
+}
+
 // DiffUnstructureds calculates the diff between two unstructured objects.
 func DiffUnstructureds(x, y unstructured.Unstructured, opts ...Option) (*Result, error) {
 	var (
@@ -95,6 +115,12 @@ func (d *differ) diff(path []PathStep, vx, vy reflect.Value) error {
 		return nil
 	}
 
+	if d.normalizeQuantities && isQuantityPath(path) {
+		if done := d.diffQuantity(path, vx, vy); done {
+			return nil
+		}
+	}
+
 	if isNumberValue(vx) && isNumberValue(vy) {
 		return d.diffNumber(path, vx, vy)
 	}
@@ -145,6 +171,12 @@ func (d *differ) diffSlice(path []PathStep, vx, vy reflect.Value) error {
 		return nil
 	}
 
+	if d.matchByMergeKeys {
+		if key, ok := findMergeKey(path, vx, vy); ok {
+			return d.diffSliceByMergeKey(path, key, vx, vy)
+		}
+	}
+
 	minLen := vx.Len()
 	if minLen > vy.Len() {
 		minLen = vy.Len()
@@ -0,0 +1,190 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// quantityParentKeys contains the keys of the maps whose values are resource quantities.
+// e.g. spec.containers[].resources.limits, spec.hard of ResourceQuota
+var quantityParentKeys = map[string]struct{}{
+	"limits":   {},
+	"requests": {},
+	"hard":     {},
+}
+
+// mergeKeys contains the well-known lists along with their candidate strategic merge keys.
+// The first key that exists in all elements will be used.
+var mergeKeys = map[string][]string{
+	"containers":          {"name"},
+	"initContainers":      {"name"},
+	"ephemeralContainers": {"name"},
+	"env":                 {"name"},
+	"volumes":             {"name"},
+	"volumeMounts":        {"mountPath"},
+	"volumeDevices":       {"devicePath"},
+	"imagePullSecrets":    {"name"},
+	"hostAliases":         {"ip"},
+	"ports":               {"containerPort", "port"},
+}
+
+func isQuantityPath(path []PathStep) bool {
+	if len(path) < 2 {
+		return false
+	}
+	parent := path[len(path)-2]
+	if parent.Type != MapIndexPathStep {
+		return false
+	}
+	_, ok := quantityParentKeys[parent.MapIndex]
+	return ok
+}
+
+// diffQuantity compares the given values as resource quantities.
+// It returns false when they could not be parsed as quantities.
+func (d *differ) diffQuantity(path []PathStep, vx, vy reflect.Value) bool {
+	qx, ok := parseQuantity(vx)
+	if !ok {
+		return false
+	}
+	qy, ok := parseQuantity(vy)
+	if !ok {
+		return false
+	}
+	if qx.Cmp(qy) != 0 {
+		d.result.addNode(path, vx.Type(), vy.Type(), vx, vy)
+	}
+	return true
+}
+
+func parseQuantity(v reflect.Value) (resource.Quantity, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		s = strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	return q, true
+}
+
+// findMergeKey returns the merge key that can be used to match
+// the elements of the given lists.
+func findMergeKey(path []PathStep, vx, vy reflect.Value) (string, bool) {
+	if len(path) == 0 {
+		return "", false
+	}
+	last := path[len(path)-1]
+	if last.Type != MapIndexPathStep {
+		return "", false
+	}
+	for _, key := range mergeKeys[last.MapIndex] {
+		if _, ok := mergeKeyIndexes(key, vx); !ok {
+			continue
+		}
+		if _, ok := mergeKeyIndexes(key, vy); !ok {
+			continue
+		}
+		return key, true
+	}
+	return "", false
+}
+
+// mergeKeyIndexes returns a map from the merge key value to the index of
+// the element having that value. It returns false when any element
+// is missing the key or there are duplicate values.
+func mergeKeyIndexes(key string, v reflect.Value) (map[string]int, bool) {
+	indexes := make(map[string]int, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		e := v.Index(i)
+		if e.Kind() == reflect.Interface {
+			e = e.Elem()
+		}
+		if e.Kind() != reflect.Map {
+			return nil, false
+		}
+		kv := e.MapIndex(reflect.ValueOf(key))
+		if !kv.IsValid() {
+			return nil, false
+		}
+		if kv.Kind() == reflect.Interface {
+			kv = kv.Elem()
+		}
+		id := fmt.Sprint(kv.Interface())
+		if _, ok := indexes[id]; ok {
+			return nil, false
+		}
+		indexes[id] = i
+	}
+	return indexes, true
+}
+
+// diffSliceByMergeKey compares the elements having the same merge key value.
+// The elements of the first list are reported with their indexes in the first list
+// while the added ones are reported with their indexes in the second list.
+func (d *differ) diffSliceByMergeKey(path []PathStep, key string, vx, vy reflect.Value) error {
+	ix, _ := mergeKeyIndexes(key, vx)
+	iy, _ := mergeKeyIndexes(key, vy)
+
+	for i := 0; i < vx.Len(); i++ {
+		nextPath := newSlicePath(path, i)
+		nextValueX := vx.Index(i)
+		j, ok := iy[mergeKeyValue(key, nextValueX)]
+		if !ok {
+			d.result.addNode(nextPath, nextValueX.Type(), nextValueX.Type(), nextValueX, reflect.Value{})
+			continue
+		}
+		if err := d.diff(nextPath, nextValueX, vy.Index(j)); err != nil {
+			return err
+		}
+	}
+
+	for j := 0; j < vy.Len(); j++ {
+		nextValueY := vy.Index(j)
+		if _, ok := ix[mergeKeyValue(key, nextValueY)]; ok {
+			continue
+		}
+		nextPath := newSlicePath(path, j)
+		d.result.addNode(nextPath, nextValueY.Type(), nextValueY.Type(), reflect.Value{}, nextValueY)
+	}
+	return nil
+}
+
+func mergeKeyValue(key string, v reflect.Value) string {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	kv := v.MapIndex(reflect.ValueOf(key))
+	if kv.Kind() == reflect.Interface {
+		kv = kv.Elem()
+	}
+	return fmt.Sprint(kv.Interface())
+}
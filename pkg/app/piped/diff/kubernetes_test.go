@@ -0,0 +1,120 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiffKubernetesNormalization(t *testing.T) {
+	objs, err := loadUnstructureds("testdata/kubernetes_normalization.yaml")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(objs))
+
+	testcases := []struct {
+		name    string
+		options []Option
+		diffNum int
+	}{
+		{
+			name:    "without normalization",
+			diffNum: 10,
+		},
+		{
+			name: "normalize quantities",
+			options: []Option{
+				WithNormalizeQuantities(),
+			},
+			diffNum: 10,
+		},
+		{
+			name: "match by merge keys",
+			options: []Option{
+				WithMatchByMergeKeys(),
+			},
+			diffNum: 3,
+		},
+		{
+			name: "normalize all",
+			options: []Option{
+				WithNormalizeQuantities(),
+				WithMatchByMergeKeys(),
+			},
+			diffNum: 0,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := DiffUnstructureds(objs[0], objs[1], tc.options...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.diffNum, result.NumNodes())
+		})
+	}
+}
+
+func TestDiffMatchByMergeKeys(t *testing.T) {
+	x := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "foo", "image": "foo:v1"},
+				map[string]interface{}{"name": "bar", "image": "bar:v1"},
+			},
+		},
+	}}
+	y := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "baz", "image": "baz:v1"},
+				map[string]interface{}{"name": "foo", "image": "foo:v2"},
+			},
+		},
+	}}
+
+	result, err := DiffUnstructureds(x, y, WithMatchByMergeKeys())
+	require.NoError(t, err)
+
+	paths := make([]string, 0, result.NumNodes())
+	for _, n := range result.Nodes() {
+		paths = append(paths, n.PathString)
+	}
+	// The changed foo and removed bar are reported with their indexes in x
+	// while the added baz is reported with its index in y.
+	assert.ElementsMatch(t, []string{
+		"spec.containers.0.image",
+		"spec.containers.1",
+		"spec.containers.0",
+	}, paths)
+}
+
+func TestDiffNormalizeQuantitiesOnlyResources(t *testing.T) {
+	x := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"version": "1.0",
+		},
+	}}
+	y := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"version": "1",
+		},
+	}}
+
+	result, err := DiffUnstructureds(x, y, WithNormalizeQuantities())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.NumNodes())
+}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	"github.com/pipe-cd/pipe/pkg/app/piped/driftdetector/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/driftdetector/terraform"
 	"github.com/pipe-cd/pipe/pkg/app/piped/livestatestore"
 	"github.com/pipe-cd/pipe/pkg/cache"
 	"github.com/pipe-cd/pipe/pkg/config"
@@ -56,12 +57,22 @@ type sealedSecretDecrypter interface {
 	Decrypt(string) (string, error)
 }
 
+type environmentLister interface {
+	Get(id string) (*model.Environment, bool)
+}
+
+type notifier interface {
+	Notify(event model.NotificationEvent)
+}
+
 type Detector interface {
 	Run(ctx context.Context) error
 }
 
 type detector struct {
 	apiClient  apiClient
+	envLister  environmentLister
+	notifier   notifier
 	detectors  []providerDetector
 	syncStates map[string]model.ApplicationSyncState
 	mu         sync.RWMutex
@@ -78,6 +89,8 @@ func NewDetector(
 	gitClient gitClient,
 	stateGetter livestatestore.Getter,
 	apiClient apiClient,
+	envLister environmentLister,
+	notifier notifier,
 	appManifestsCache cache.Cache,
 	cfg *config.PipedSpec,
 	ssd sealedSecretDecrypter,
@@ -86,6 +99,8 @@ func NewDetector(
 
 	d := &detector{
 		apiClient:  apiClient,
+		envLister:  envLister,
+		notifier:   notifier,
 		detectors:  make([]providerDetector, 0, len(cfg.CloudProviders)),
 		syncStates: make(map[string]model.ApplicationSyncState),
 		logger:     logger.Named("drift-detector"),
@@ -111,6 +126,21 @@ func NewDetector(
 				logger,
 			))
 
+		case model.CloudProviderTerraform:
+			// Terraform applications are only checked for their outdated dependencies.
+			if cp.TerraformConfig == nil || cp.TerraformConfig.DependencyCheck == nil || !cp.TerraformConfig.DependencyCheck.Enabled {
+				continue
+			}
+			d.detectors = append(d.detectors, terraform.NewDetector(
+				cp,
+				appLister,
+				gitClient,
+				envLister,
+				notifier,
+				cfg,
+				logger,
+			))
+
 		default:
 		}
 	}
@@ -142,9 +172,9 @@ func (d *detector) Run(ctx context.Context) error {
 	return nil
 }
 
-func (d *detector) ReportApplicationSyncState(ctx context.Context, appID string, state model.ApplicationSyncState) error {
+func (d *detector) ReportApplicationSyncState(ctx context.Context, app *model.Application, state model.ApplicationSyncState) error {
 	d.mu.RLock()
-	curState, ok := d.syncStates[appID]
+	curState, ok := d.syncStates[app.Id]
 	d.mu.RUnlock()
 
 	if ok && !curState.HasChanged(state) {
@@ -152,12 +182,12 @@ func (d *detector) ReportApplicationSyncState(ctx context.Context, appID string,
 	}
 
 	_, err := d.apiClient.ReportApplicationSyncState(ctx, &pipedservice.ReportApplicationSyncStateRequest{
-		ApplicationId: appID,
+		ApplicationId: app.Id,
 		State:         &state,
 	})
 	if err != nil {
 		d.logger.Error("failed to report application sync state",
-			zap.String("application-id", appID),
+			zap.String("application-id", app.Id),
 			zap.Any("state", state),
 			zap.Error(err),
 		)
@@ -165,8 +195,50 @@ func (d *detector) ReportApplicationSyncState(ctx context.Context, appID string,
 	}
 
 	d.mu.Lock()
-	d.syncStates[appID] = state
+	d.syncStates[app.Id] = state
 	d.mu.Unlock()
 
+	// Use the state stored in the control plane before the first report
+	// to avoid missing the changes happened while piped was not running.
+	prevStatus := app.GetSyncState().GetStatus()
+	if ok {
+		prevStatus = curState.Status
+	}
+	d.notifySyncStatusChange(app, prevStatus, &state)
+
 	return nil
 }
+
+// notifySyncStatusChange sends a notification when the application became OUT_OF_SYNC
+// or came back to SYNCED from OUT_OF_SYNC.
+func (d *detector) notifySyncStatusChange(app *model.Application, prevStatus model.ApplicationSyncStatus, state *model.ApplicationSyncState) {
+	if prevStatus == state.Status {
+		return
+	}
+
+	var envName string
+	if env, ok := d.envLister.Get(app.EnvId); ok {
+		envName = env.Name
+	}
+
+	switch {
+	case state.Status == model.ApplicationSyncStatus_OUT_OF_SYNC:
+		d.notifier.Notify(model.NotificationEvent{
+			Type: model.NotificationEventType_EVENT_APPLICATION_OUT_OF_SYNC,
+			Metadata: &model.NotificationEventApplicationOutOfSync{
+				Application: app,
+				EnvName:     envName,
+				State:       state,
+			},
+		})
+	case state.Status == model.ApplicationSyncStatus_SYNCED && prevStatus == model.ApplicationSyncStatus_OUT_OF_SYNC:
+		d.notifier.Notify(model.NotificationEvent{
+			Type: model.NotificationEventType_EVENT_APPLICATION_SYNCED,
+			Metadata: &model.NotificationEventApplicationSynced{
+				Application: app,
+				EnvName:     envName,
+				State:       state,
+			},
+		})
+	}
+}
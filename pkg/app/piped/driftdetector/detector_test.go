@@ -0,0 +1,88 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driftdetector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeEnvironmentLister struct{}
+
+func (fakeEnvironmentLister) Get(id string) (*model.Environment, bool) {
+	return &model.Environment{Id: id, Name: "prod"}, true
+}
+
+type fakeNotifier struct {
+	events []model.NotificationEvent
+}
+
+func (n *fakeNotifier) Notify(event model.NotificationEvent) {
+	n.events = append(n.events, event)
+}
+
+func TestNotifySyncStatusChange(t *testing.T) {
+	testcases := []struct {
+		name       string
+		prevStatus model.ApplicationSyncStatus
+		status     model.ApplicationSyncStatus
+		expected   []model.NotificationEventType
+	}{
+		{
+			name:       "unchanged",
+			prevStatus: model.ApplicationSyncStatus_OUT_OF_SYNC,
+			status:     model.ApplicationSyncStatus_OUT_OF_SYNC,
+		},
+		{
+			name:       "became out of sync",
+			prevStatus: model.ApplicationSyncStatus_SYNCED,
+			status:     model.ApplicationSyncStatus_OUT_OF_SYNC,
+			expected:   []model.NotificationEventType{model.NotificationEventType_EVENT_APPLICATION_OUT_OF_SYNC},
+		},
+		{
+			name:       "back to synced",
+			prevStatus: model.ApplicationSyncStatus_OUT_OF_SYNC,
+			status:     model.ApplicationSyncStatus_SYNCED,
+			expected:   []model.NotificationEventType{model.NotificationEventType_EVENT_APPLICATION_SYNCED},
+		},
+		{
+			name:       "synced after deploying",
+			prevStatus: model.ApplicationSyncStatus_DEPLOYING,
+			status:     model.ApplicationSyncStatus_SYNCED,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := &fakeNotifier{}
+			d := &detector{
+				envLister: fakeEnvironmentLister{},
+				notifier:  n,
+				logger:    zap.NewNop(),
+			}
+			app := &model.Application{Id: "app-id", Name: "app", EnvId: "env-id"}
+			d.notifySyncStatusChange(app, tc.prevStatus, &model.ApplicationSyncState{Status: tc.status})
+
+			var types []model.NotificationEventType
+			for _, e := range n.events {
+				types = append(types, e.Type)
+			}
+			assert.Equal(t, tc.expected, types)
+		})
+	}
+}
@@ -48,7 +48,7 @@ type sealedSecretDecrypter interface {
 }
 
 type reporter interface {
-	ReportApplicationSyncState(ctx context.Context, appID string, state model.ApplicationSyncState) error
+	ReportApplicationSyncState(ctx context.Context, app *model.Application, state model.ApplicationSyncState) error
 }
 
 type detector struct {
@@ -166,7 +166,10 @@ func (d *detector) check(ctx context.Context) error {
 		// Start checking all applications in this repository.
 		for _, app := range apps {
 			if err := d.checkApplication(ctx, app, gitRepo, headCommit); err != nil {
-				d.logger.Error(fmt.Sprintf("failed to check application: %s", app.Id), zap.Error(err))
+				d.logger.Error(fmt.Sprintf("failed to check application: %s", app.Id),
+					zap.String("app-id", app.Id),
+					zap.Error(err),
+				)
 			}
 		}
 	}
@@ -175,17 +178,38 @@ func (d *detector) check(ctx context.Context) error {
 }
 
 func (d *detector) checkApplication(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit) error {
+	cfg, err := d.loadDeploymentConfiguration(repo.GetPath(), app)
+	if err != nil {
+		return fmt.Errorf("failed to load deployment configuration: %w", err)
+	}
+	if cfg.KubernetesDeploymentSpec == nil {
+		return fmt.Errorf("unsupport application kind %s", cfg.Kind)
+	}
+
+	ignoreDiffs := append([]config.KubernetesIgnoreDiffRule{}, cfg.KubernetesDeploymentSpec.Input.IgnoreDiffs...)
+	if d.provider.KubernetesConfig != nil {
+		ignoreDiffs = append(ignoreDiffs, d.provider.KubernetesConfig.IgnoreDiffs...)
+	}
+	ignorer, err := provider.NewDiffIgnorer(ignoreDiffs...)
+	if err != nil {
+		return err
+	}
+
 	watchingResourceKinds := d.stateGetter.GetWatchingResourceKinds()
-	headManifests, err := d.loadHeadManifests(ctx, app, repo, headCommit, watchingResourceKinds)
+	headManifests, err := d.loadHeadManifests(ctx, app, repo, headCommit, cfg, watchingResourceKinds)
 	if err != nil {
 		return err
 	}
 	headManifests = filterIgnoringManifests(headManifests)
-	d.logger.Info(fmt.Sprintf("application %s has %d manifests at commit %s", app.Id, len(headManifests), headCommit.Hash))
+	d.logger.Info(fmt.Sprintf("application %s has %d manifests at commit %s", app.Id, len(headManifests), headCommit.Hash),
+		zap.String("app-id", app.Id),
+	)
 
 	liveManifests := d.stateGetter.GetAppLiveManifests(app.Id)
 	liveManifests = filterIgnoringManifests(liveManifests)
-	d.logger.Info(fmt.Sprintf("application %s has %d live manifests", app.Id, len(liveManifests)))
+	d.logger.Info(fmt.Sprintf("application %s has %d live manifests", app.Id, len(liveManifests)),
+		zap.String("app-id", app.Id),
+	)
 
 	// Divide manifests into separate groups.
 	adds, deletes, headInters, liveInters := groupManifests(headManifests, liveManifests)
@@ -193,13 +217,17 @@ func (d *detector) checkApplication(ctx context.Context, app *model.Application,
 	// Now we will go to check the diff intersection group.
 	changes := make(map[provider.Manifest]*diff.Result)
 	for i := 0; i < len(headInters); i++ {
-		result, err := provider.Diff(headInters[i], liveInters[i],
+		head, live := ignorer.Apply(headInters[i], liveInters[i])
+		result, err := provider.Diff(head, live,
 			diff.WithEquateEmpty(),
 			diff.WithIgnoreAddingMapKeys(),
 			diff.WithCompareNumberAndNumericString(),
 		)
 		if err != nil {
-			d.logger.Error("failed to calculate the diff of manifests", zap.Error(err))
+			d.logger.Error("failed to calculate the diff of manifests",
+				zap.String("app-id", app.Id),
+				zap.Error(err),
+			)
 			return err
 		}
 		if !result.HasDiff() {
@@ -211,14 +239,14 @@ func (d *detector) checkApplication(ctx context.Context, app *model.Application,
 	// No diffs means this application is in SYNCED state.
 	if len(adds) == 0 && len(deletes) == 0 && len(changes) == 0 {
 		state := makeSyncedState()
-		return d.reporter.ReportApplicationSyncState(ctx, app.Id, state)
+		return d.reporter.ReportApplicationSyncState(ctx, app, state)
 	}
 
 	state := makeOutOfSyncState(adds, deletes, changes, headCommit.Hash)
-	return d.reporter.ReportApplicationSyncState(ctx, app.Id, state)
+	return d.reporter.ReportApplicationSyncState(ctx, app, state)
 }
 
-func (d *detector) loadHeadManifests(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit, watchingResourceKinds []provider.APIVersionKind) ([]provider.Manifest, error) {
+func (d *detector) loadHeadManifests(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit, cfg *config.Config, watchingResourceKinds []provider.APIVersionKind) ([]provider.Manifest, error) {
 	var (
 		manifestCache = provider.AppManifestsCache{
 			AppID:  app.Id,
@@ -232,11 +260,6 @@ func (d *detector) loadHeadManifests(ctx context.Context, app *model.Application
 	manifests, ok := manifestCache.Get(headCommit.Hash)
 	if !ok {
 		// When the manifests were not in the cache we have to load them.
-		cfg, err := d.loadDeploymentConfiguration(repoDir, app)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load deployment configuration: %w", err)
-		}
-
 		gds, ok := cfg.GetGenericDeployment()
 		if !ok {
 			return nil, fmt.Errorf("unsupport application kind %s", cfg.Kind)
@@ -245,7 +268,7 @@ func (d *detector) loadHeadManifests(ctx context.Context, app *model.Application
 		if d.sealedSecretDecrypter != nil && len(gds.SealedSecrets) > 0 {
 			// We have to copy repository into another directory because
 			// decrypting the sealed secrets might change the git repository.
-			dir, err := ioutil.TempDir("", "detector-git-decrypt")
+			dir, err := ioutil.TempDir(d.config.GetWorkspaceDir(), "detector-git-decrypt")
 			if err != nil {
 				return nil, fmt.Errorf("failed to prepare a temporary directory for git repository (%w)", err)
 			}
@@ -263,7 +286,8 @@ func (d *detector) loadHeadManifests(ctx context.Context, app *model.Application
 			}
 		}
 
-		loader := provider.NewManifestLoader(app.Name, appDir, repoDir, app.GitPath.ConfigFilename, cfg.KubernetesDeploymentSpec.Input, d.logger)
+		loader := provider.NewManifestLoader(app.Name, appDir, repoDir, app.GitPath.ConfigFilename, cfg.KubernetesDeploymentSpec.Input, d.logger, provider.WithHelmPostRenderer(d.config.AllowHelmPostRenderer), provider.WithGitRepositories(d.config.Repositories))
+		var err error
 		manifests, err = loader.LoadManifests(ctx)
 		if err != nil {
 			err = fmt.Errorf("failed to load new manifests: %w", err)
@@ -322,7 +346,12 @@ func decryptSealedSecrets(appDir string, secrets []config.SealedSecretMapping, d
 		}
 		outPath := filepath.Join(appDir, outDir, outFile)
 
-		if err := ioutil.WriteFile(outPath, content, 0644); err != nil {
+		// The existing file must be removed first since its permission is not changed by WriteFile
+		// while the decrypted content should only be readable by the owner.
+		if err := os.Remove(outPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to remove the existing file of sealed secret file %s (%w)", s.Path, err)
+		}
+		if err := ioutil.WriteFile(outPath, content, 0600); err != nil {
 			return fmt.Errorf("unable to write decrypted content of sealed secret file %s (%w)", s.Path, err)
 		}
 	}
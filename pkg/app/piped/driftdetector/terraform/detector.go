@@ -12,4 +12,252 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package terraform provides a detector which periodically checks
+// whether the providers and modules required by Terraform applications are outdated.
 package terraform
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/terraform"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/git"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const defaultCheckInterval = 24 * time.Hour
+
+type applicationLister interface {
+	ListByCloudProvider(name string) []*model.Application
+}
+
+type gitClient interface {
+	Clone(ctx context.Context, repoID, remote, branch, destination string) (git.Repo, error)
+}
+
+type environmentLister interface {
+	Get(id string) (*model.Environment, bool)
+}
+
+type notifier interface {
+	Notify(event model.NotificationEvent)
+}
+
+type registry interface {
+	LatestVersion(ctx context.Context, dep provider.Dependency) (string, error)
+}
+
+type detector struct {
+	provider  config.PipedCloudProvider
+	appLister applicationLister
+	gitClient gitClient
+	envLister environmentLister
+	notifier  notifier
+	registry  registry
+	interval  time.Duration
+	config    *config.PipedSpec
+	logger    *zap.Logger
+
+	gitRepos map[string]git.Repo
+	// The outdated dependencies last notified for each application.
+	notified map[string]string
+}
+
+func NewDetector(
+	cp config.PipedCloudProvider,
+	appLister applicationLister,
+	gitClient gitClient,
+	envLister environmentLister,
+	notifier notifier,
+	cfg *config.PipedSpec,
+	logger *zap.Logger,
+) *detector {
+
+	logger = logger.Named("terraform-detector").With(
+		zap.String("cloud-provider", cp.Name),
+	)
+	var (
+		checkCfg = cp.TerraformConfig.DependencyCheck
+		interval = checkCfg.Interval.Duration()
+	)
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	return &detector{
+		provider:  cp,
+		appLister: appLister,
+		gitClient: gitClient,
+		envLister: envLister,
+		notifier:  notifier,
+		registry:  provider.NewRegistry(checkCfg.RegistryAddress),
+		interval:  interval,
+		config:    cfg,
+		gitRepos:  make(map[string]git.Repo),
+		notified:  make(map[string]string),
+		logger:    logger,
+	}
+}
+
+func (d *detector) Run(ctx context.Context) error {
+	d.logger.Info("start running dependency checker for terraform applications")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	d.check(ctx)
+L:
+	for {
+		select {
+		case <-ticker.C:
+			d.check(ctx)
+
+		case <-ctx.Done():
+			break L
+		}
+	}
+
+	d.logger.Info("dependency checker for terraform applications has been stopped")
+	return nil
+}
+
+func (d *detector) check(ctx context.Context) {
+	// The latest versions are cached during each check
+	// to avoid sending the same request to the registry many times.
+	latests := make(map[string]string)
+
+	for repoID, apps := range d.listGroupedApplication() {
+		gitRepo, ok := d.gitRepos[repoID]
+		if !ok {
+			// Clone repository for the first time.
+			repoCfg, ok := d.config.GetRepository(repoID)
+			if !ok {
+				d.logger.Error(fmt.Sprintf("repository %s was not found in piped configuration", repoID))
+				continue
+			}
+			gr, err := d.gitClient.Clone(ctx, repoID, repoCfg.Remote, repoCfg.Branch, "")
+			if err != nil {
+				d.logger.Error("failed to clone repository",
+					zap.String("repo-id", repoID),
+					zap.Error(err),
+				)
+				continue
+			}
+			gitRepo = gr
+			d.gitRepos[repoID] = gitRepo
+		}
+
+		if err := gitRepo.Pull(ctx, gitRepo.GetClonedBranch()); err != nil {
+			d.logger.Error("failed to update repository branch",
+				zap.String("repo-id", repoID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		for _, app := range apps {
+			if err := d.checkApplication(ctx, app, gitRepo.GetPath(), latests); err != nil {
+				d.logger.Error(fmt.Sprintf("failed to check dependencies of application: %s", app.Id),
+					zap.String("app-id", app.Id),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+func (d *detector) checkApplication(ctx context.Context, app *model.Application, repoPath string, latests map[string]string) error {
+	deps, err := provider.ParseDependencies(filepath.Join(repoPath, app.GitPath.Path))
+	if err != nil {
+		return err
+	}
+
+	outdated := make([]*model.NotificationEventDependencyOutdated_Dependency, 0)
+	for _, dep := range deps {
+		key := fmt.Sprintf("%s:%s", dep.Kind, dep.Source)
+		latest, ok := latests[key]
+		if !ok {
+			latest, err = d.registry.LatestVersion(ctx, dep)
+			if err != nil {
+				d.logger.Warn(fmt.Sprintf("failed to get the latest version of %s %s", dep.Kind, dep.Source), zap.Error(err))
+				continue
+			}
+			latests[key] = latest
+		}
+
+		isOutdated, err := provider.IsOutdated(dep.Constraint, latest)
+		if err != nil {
+			d.logger.Warn(fmt.Sprintf("failed to check the version constraint of %s %s", dep.Kind, dep.Source), zap.Error(err))
+			continue
+		}
+		if !isOutdated {
+			continue
+		}
+		outdated = append(outdated, &model.NotificationEventDependencyOutdated_Dependency{
+			Kind:          string(dep.Kind),
+			Name:          dep.Name,
+			Source:        dep.Source,
+			Constraint:    dep.Constraint,
+			LatestVersion: latest,
+			File:          dep.File,
+		})
+	}
+	d.logger.Info(fmt.Sprintf("application %s has %d outdated dependencies out of %d", app.Id, len(outdated), len(deps)),
+		zap.String("app-id", app.Id),
+	)
+
+	// Notify only when the outdated dependencies have changed since the last notification.
+	summary := summarizeDependencies(outdated)
+	if summary == d.notified[app.Id] {
+		return nil
+	}
+	d.notified[app.Id] = summary
+	if len(outdated) == 0 {
+		return nil
+	}
+
+	var envName string
+	if env, ok := d.envLister.Get(app.EnvId); ok {
+		envName = env.Name
+	}
+	d.notifier.Notify(model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPENDENCY_OUTDATED,
+		Metadata: &model.NotificationEventDependencyOutdated{
+			Application:  app,
+			EnvName:      envName,
+			Dependencies: outdated,
+		},
+	})
+	return nil
+}
+
+func summarizeDependencies(deps []*model.NotificationEventDependencyOutdated_Dependency) string {
+	parts := make([]string, 0, len(deps))
+	for _, d := range deps {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s:%s", d.Kind, d.Source, d.Constraint, d.LatestVersion))
+	}
+	return strings.Join(parts, ",")
+}
+
+// listGroupedApplication retrieves all applications those should be handled by this detector
+// and then groups them by repoID.
+func (d *detector) listGroupedApplication() map[string][]*model.Application {
+	var (
+		apps = d.appLister.ListByCloudProvider(d.provider.Name)
+		m    = make(map[string][]*model.Application)
+	)
+	for _, app := range apps {
+		repoID := app.GitPath.Repo.Id
+		m[repoID] = append(m[repoID], app)
+	}
+	return m
+}
+
+func (d *detector) ProviderName() string {
+	return d.provider.Name
+}
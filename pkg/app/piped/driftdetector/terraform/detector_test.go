@@ -0,0 +1,133 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/terraform"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeEnvironmentLister struct{}
+
+func (fakeEnvironmentLister) Get(id string) (*model.Environment, bool) {
+	return &model.Environment{Id: id, Name: "prod"}, true
+}
+
+type fakeNotifier struct {
+	events []model.NotificationEvent
+}
+
+func (n *fakeNotifier) Notify(event model.NotificationEvent) {
+	n.events = append(n.events, event)
+}
+
+type fakeRegistry struct {
+	versions map[string]string
+	calls    int
+}
+
+func (r *fakeRegistry) LatestVersion(_ context.Context, dep provider.Dependency) (string, error) {
+	r.calls++
+	return r.versions[dep.Source], nil
+}
+
+func TestCheckApplication(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "terraform-detector")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	appDir := filepath.Join(repoDir, "app")
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+	err = ioutil.WriteFile(filepath.Join(appDir, "main.tf"), []byte(`
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 3.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.0"
+    }
+  }
+}
+`), 0644)
+	require.NoError(t, err)
+
+	var (
+		n = &fakeNotifier{}
+		r = &fakeRegistry{
+			versions: map[string]string{
+				"hashicorp/aws":    "4.1.0",
+				"hashicorp/random": "3.1.0",
+			},
+		}
+		d = &detector{
+			envLister: fakeEnvironmentLister{},
+			notifier:  n,
+			registry:  r,
+			notified:  make(map[string]string),
+			logger:    zap.NewNop(),
+		}
+		app = &model.Application{
+			Id:      "app-id",
+			Name:    "app",
+			EnvId:   "env-id",
+			GitPath: &model.ApplicationGitPath{Path: "app"},
+		}
+		ctx = context.Background()
+	)
+
+	err = d.checkApplication(ctx, app, repoDir, make(map[string]string))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(n.events))
+	assert.Equal(t, model.NotificationEventType_EVENT_DEPENDENCY_OUTDATED, n.events[0].Type)
+	assert.Equal(t, &model.NotificationEventDependencyOutdated{
+		Application: app,
+		EnvName:     "prod",
+		Dependencies: []*model.NotificationEventDependencyOutdated_Dependency{
+			{
+				Kind:          "provider",
+				Name:          "aws",
+				Source:        "hashicorp/aws",
+				Constraint:    "~> 3.0",
+				LatestVersion: "4.1.0",
+				File:          "main.tf",
+			},
+		},
+	}, n.events[0].Metadata)
+	assert.Equal(t, 2, r.calls)
+
+	// The same outdated dependencies should not be notified again.
+	err = d.checkApplication(ctx, app, repoDir, make(map[string]string))
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(n.events))
+
+	// A newer version should be notified.
+	r.versions["hashicorp/aws"] = "4.2.0"
+	err = d.checkApplication(ctx, app, repoDir, make(map[string]string))
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(n.events))
+}
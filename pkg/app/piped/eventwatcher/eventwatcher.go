@@ -24,7 +24,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -248,10 +250,31 @@ func (w *watcher) modifyFiles(latestEvent *model.Event, eventCfg *config.EventWa
 	}
 	return &commit{
 		changes: changes,
-		message: commitMsg,
+		message: appendContexts(commitMsg, latestEvent.Contexts),
 	}, nil
 }
 
+// appendContexts appends the given event contexts to the commit message
+// as trailer lines sorted by their keys, e.g. "Source-Commit: abc123".
+func appendContexts(msg string, contexts map[string]string) string {
+	if len(contexts) == 0 {
+		return msg
+	}
+	keys := make([]string, 0, len(contexts))
+	for k := range contexts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(msg)
+	b.WriteString("\n")
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf("\n%s: %s", k, contexts[k]))
+	}
+	return b.String()
+}
+
 // modifyYAML returns a new YAML content as a first returned value if the value of given
 // field was outdated. True as a second returned value means it's already up-to-date.
 func modifyYAML(path, field, newValue string) ([]byte, bool, error) {
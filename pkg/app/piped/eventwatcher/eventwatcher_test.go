@@ -117,3 +117,33 @@ func TestModifyYAML(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendContexts(t *testing.T) {
+	testcases := []struct {
+		name     string
+		msg      string
+		contexts map[string]string
+		want     string
+	}{
+		{
+			name: "no context",
+			msg:  "Update image",
+			want: "Update image",
+		},
+		{
+			name: "multiple contexts",
+			msg:  "Update image",
+			contexts: map[string]string{
+				"Source-Commit": "abc123",
+				"Build-Url":     "https://ci.example.com/builds/1",
+			},
+			want: "Update image\n\nBuild-Url: https://ci.example.com/builds/1\nSource-Commit: abc123",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := appendContexts(tc.msg, tc.contexts)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package executil provides a way to run external tools (kubectl, helm, terraform...)
+// so that all of their child processes are also stopped when the given context is done.
+// exec.CommandContext kills only the started process, so the processes spawned by
+// that process (e.g. terraform providers) would be left running after cancelling a stage.
+package executil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// DefaultKillGracePeriod is the duration to wait after sending SIGTERM
+// to the process group before killing it forcibly.
+const DefaultKillGracePeriod = 10 * time.Second
+
+// Cmd wraps exec.Cmd to run the command in its own process group.
+type Cmd struct {
+	*exec.Cmd
+	// The duration to wait after sending SIGTERM before sending SIGKILL
+	// to the process group. Default is DefaultKillGracePeriod.
+	KillGracePeriod time.Duration
+
+	ctx    context.Context
+	doneCh chan struct{}
+}
+
+// CommandContext is like exec.CommandContext but the whole process group
+// of the command will be stopped when the given context is done.
+// The processes are asked to exit gracefully by SIGTERM at first
+// and then killed by SIGKILL after KillGracePeriod.
+func CommandContext(ctx context.Context, name string, args ...string) *Cmd {
+	if ctx == nil {
+		panic("nil Context")
+	}
+	cmd := exec.Command(name, args...)
+	setProcessGroup(cmd)
+	return &Cmd{
+		Cmd:             cmd,
+		KillGracePeriod: DefaultKillGracePeriod,
+		ctx:             ctx,
+	}
+}
+
+// Start starts the command and watches the context to stop its process group.
+func (c *Cmd) Start() error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.Cmd.Start(); err != nil {
+		return err
+	}
+
+	c.doneCh = make(chan struct{})
+	go c.watch(c.Cmd.Process.Pid)
+	return nil
+}
+
+func (c *Cmd) watch(pgid int) {
+	select {
+	case <-c.doneCh:
+		return
+	case <-c.ctx.Done():
+	}
+
+	terminateProcessGroup(pgid)
+
+	timer := time.NewTimer(c.KillGracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-c.doneCh:
+		// Also stop the remaining children even if the leader has exited.
+		killProcessGroup(pgid)
+	case <-timer.C:
+		killProcessGroup(pgid)
+	}
+}
+
+// Wait waits for the command to exit.
+// The context error is returned when the command was stopped because of the context.
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+	if c.doneCh != nil {
+		close(c.doneCh)
+	}
+	if err != nil && c.ctx.Err() != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return c.ctx.Err()
+		}
+	}
+	return err
+}
+
+// Run starts the command and waits for it to complete.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output and standard error.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	var b bytes.Buffer
+	c.Stdout = &b
+	c.Stderr = &b
+	err := c.Run()
+	return b.Bytes(), err
+}
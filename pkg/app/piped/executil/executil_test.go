@@ -0,0 +1,83 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombinedOutput(t *testing.T) {
+	cmd := CommandContext(context.Background(), "sh", "-c", "echo out; echo err 1>&2")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err)
+	assert.Equal(t, "out\nerr\n", string(out))
+}
+
+func TestCancelKillsProcessGroup(t *testing.T) {
+	testcases := []struct {
+		name            string
+		script          string
+		killGracePeriod time.Duration
+	}{
+		{
+			name:            "children exit by SIGTERM",
+			script:          "sleep 60 & wait",
+			killGracePeriod: time.Minute,
+		},
+		{
+			name:            "children ignore SIGTERM",
+			script:          "trap '' TERM; sh -c 'trap \"\" TERM; sleep 60' & wait",
+			killGracePeriod: 100 * time.Millisecond,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cmd := CommandContext(ctx, "sh", "-c", tc.script)
+			cmd.KillGracePeriod = tc.killGracePeriod
+
+			doneCh := make(chan error, 1)
+			go func() {
+				// The output pipe is held open by the child processes
+				// so this returns only after all of them were stopped.
+				_, err := cmd.CombinedOutput()
+				doneCh <- err
+			}()
+
+			time.Sleep(200 * time.Millisecond)
+			cancel()
+
+			select {
+			case err := <-doneCh:
+				assert.Equal(t, context.Canceled, err)
+			case <-time.After(10 * time.Second):
+				t.Fatal("child processes were not stopped")
+			}
+		})
+	}
+}
+
+func TestStartWithDoneContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := CommandContext(ctx, "true").Run()
+	assert.Equal(t, context.Canceled, err)
+}
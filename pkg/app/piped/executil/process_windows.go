@@ -0,0 +1,38 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executil
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// Windows has no signals to stop a whole process group,
+// so the process tree rooted at the command is stopped by taskkill instead.
+// Note that the children whose parent has already exited are not reachable from the tree.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// terminateProcessGroup asks all processes in the tree to exit
+// by sending WM_CLOSE messages.
+func terminateProcessGroup(pid int) {
+	exec.Command("taskkill", "/T", "/PID", strconv.Itoa(pid)).Run()
+}
+
+func killProcessGroup(pid int) {
+	exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
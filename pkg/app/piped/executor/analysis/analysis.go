@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"text/template"
 	"time"
 
@@ -39,10 +41,12 @@ import (
 type Executor struct {
 	executor.Input
 
-	repoDir             string
 	config              *config.Config
 	startTime           time.Time
 	previousElapsedTime time.Duration
+	// The prefix added to the keys of the stage metadata.
+	// It is used to separate the data of the analyses run in a same stage.
+	metadataKeyPrefix string
 }
 
 type registerer interface {
@@ -58,18 +62,42 @@ func Register(r registerer) {
 	r.Register(model.StageAnalysis, f)
 }
 
+// NewStepExecutor returns an executor running the given analysis as a step of another stage,
+// e.g. a step of progressive traffic shifting in K8S_TRAFFIC_ROUTING stage.
+// The metadata stored while running are keyed with the given prefix.
+func NewStepExecutor(in executor.Input, options *config.AnalysisStageOptions, metadataKeyPrefix string) executor.Executor {
+	in.StageConfig.AnalysisStageOptions = options
+	return &Executor{
+		Input:             in,
+		metadataKeyPrefix: metadataKeyPrefix,
+	}
+}
+
 // templateArgs allows deployment-specific data to be embedded in the analysis template.
 // NOTE: Changing its fields will force users to change the template definition.
 type templateArgs struct {
 	App struct {
+		ID   string
 		Name string
 		Env  string
 	}
+	Deployment struct {
+		ID         string
+		CommitHash string
+	}
 	K8s struct {
 		Namespace string
 	}
 	// User-defined custom args.
+	// Their values can also contain the built-in args above
+	// since they are expanded before rendering the template.
 	Args map[string]string
+	// The variant-specific data are kept as they are
+	// because they are populated while running the query.
+	Variant struct {
+		Name string
+	}
+	VariantCustomArgs map[string]string
 }
 
 // Execute spawns and runs multiple analyzer that run a query at the regular time.
@@ -83,21 +111,17 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	ds, err := e.RunningDSP.Get(ctx, e.LogPersister)
+	ds, err := e.TargetDSP.GetReadOnly(ctx, e.LogPersister)
 	if err != nil {
-		e.LogPersister.Errorf("Failed to prepare running deploy source data (%v)", err)
+		e.LogPersister.Errorf("Failed to prepare target deploy source data (%v)", err)
 		return model.StageStatus_STAGE_FAILURE
 	}
-	e.repoDir = ds.RepoDir
 	e.config = ds.DeploymentConfig
 
-	templateCfg, err := config.LoadAnalysisTemplate(e.repoDir)
-	if errors.Is(err, config.ErrNotFound) {
-		e.Logger.Info("config file for AnalysisTemplate not found")
+	// The templates shared across the repository were loaded while preparing the deploy source.
+	templateCfg := ds.AnalysisTemplateSpec
+	if templateCfg == nil {
 		templateCfg = &config.AnalysisTemplateSpec{}
-	} else if err != nil {
-		e.LogPersister.Error(err.Error())
-		return model.StageStatus_STAGE_FAILURE
 	}
 
 	timeout := time.Duration(options.Duration)
@@ -113,6 +137,18 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 
 	eg, ctx := errgroup.WithContext(ctx)
 
+	// Stop all analyses once a command to skip this stage was received.
+	var skippedBy string
+	skipCh := e.WatchSkipCommand(ctx)
+	eg.Go(func() error {
+		select {
+		case skippedBy = <-skipCh:
+			cancel()
+		case <-ctx.Done():
+		}
+		return nil
+	})
+
 	// Run analyses with metrics providers.
 	for i := range options.Metrics {
 		analyzer, err := e.newAnalyzerForMetrics(i, &options.Metrics[i], templateCfg)
@@ -150,10 +186,28 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 		})
 	}
 
+	// Notify only at the first run to avoid sending it again after a restart of piped.
+	if e.previousElapsedTime == 0 {
+		e.Notifier.Notify(model.NotificationEvent{
+			Type: model.NotificationEventType_EVENT_ANALYSIS_STARTED,
+			Metadata: &model.NotificationEventAnalysisStarted{
+				Deployment: e.Deployment,
+				EnvName:    e.EnvName,
+				StageId:    e.Stage.Id,
+				Duration:   int64(options.Duration.Duration().Seconds()),
+			},
+		})
+	}
+
 	if err := eg.Wait(); err != nil {
 		e.LogPersister.Errorf("Analysis failed: %s", err.Error())
+		e.notifyFailure(err)
 		return model.StageStatus_STAGE_FAILURE
 	}
+	if skippedBy != "" {
+		e.LogPersister.Infof("Analysis was skipped by %s", skippedBy)
+		return executor.DetermineStageStatus(sig.Signal(), e.Stage.Status, model.StageStatus_STAGE_SKIPPED)
+	}
 
 	status := executor.DetermineStageStatus(sig.Signal(), e.Stage.Status, model.StageStatus_STAGE_SUCCESS)
 	if status == model.StageStatus_STAGE_SUCCESS {
@@ -162,28 +216,45 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 	return status
 }
 
-const elapsedTimeKey = "elapsedTime"
+func (e *Executor) notifyFailure(err error) {
+	md := &model.NotificationEventAnalysisFailed{
+		Deployment: e.Deployment,
+		EnvName:    e.EnvName,
+		StageId:    e.Stage.Id,
+		Reason:     err.Error(),
+	}
+	var f *failure
+	if errors.As(err, &f) {
+		md.AnalyzerId = f.analyzerID
+		md.ProviderType = f.providerType
+		md.Query = f.query
+		md.LastResult = f.lastResult
+		md.FailureCount = int32(f.count)
+	}
+	e.Notifier.Notify(model.NotificationEvent{
+		Type:     model.NotificationEventType_EVENT_ANALYSIS_FAILED,
+		Metadata: md,
+	})
+}
+
+const (
+	elapsedTimeKey            = "elapsedTime"
+	previousSnapshotKeyPrefix = "previousSnapshot-"
+)
 
 // saveElapsedTime stores the elapsed time of analysis stage into metadata persister.
 // The analysis stage can be restarted from the middle even if it ends unexpectedly,
 // that's why count should be stored.
 func (e *Executor) saveElapsedTime(ctx context.Context) {
 	elapsedTime := time.Since(e.startTime) + e.previousElapsedTime
-	metadata := map[string]string{
-		elapsedTimeKey: elapsedTime.String(),
-	}
-	if err := e.MetadataStore.SetStageMetadata(ctx, e.Stage.Id, metadata); err != nil {
+	if err := e.StageMetadata().Put(ctx, e.metadataKeyPrefix+elapsedTimeKey, elapsedTime.String()); err != nil {
 		e.Logger.Error("failed to store metadata", zap.Error(err))
 	}
 }
 
 // retrievePreviousElapsedTime sets the elapsed time of analysis stage by decoding metadata.
 func (e *Executor) retrievePreviousElapsedTime() time.Duration {
-	metadata, ok := e.MetadataStore.GetStageMetadata(e.Stage.Id)
-	if !ok {
-		return 0
-	}
-	s, ok := metadata[elapsedTimeKey]
+	s, ok := e.StageMetadata().Get(e.metadataKeyPrefix + elapsedTimeKey)
 	if !ok {
 		return 0
 	}
@@ -204,7 +275,35 @@ func (e *Executor) newAnalyzerForMetrics(i int, templatable *config.TemplatableA
 	if err != nil {
 		return nil, err
 	}
+	switch cfg.Strategy {
+	case config.AnalysisStrategyCanaryBaseline, config.AnalysisStrategyPrevious:
+	default:
+		// The threshold strategy evaluates only one variant,
+		// so the query is rendered with it beforehand.
+		variant := e.analyzedVariant()
+		query, err := renderQuery(cfg.Query, variant, variantCustomArgs(cfg, variant))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Query = query
+	}
+	if v, ok := provider.(metrics.QueryValidator); ok {
+		if err := v.ValidateQuery(cfg.Query); err != nil {
+			return nil, fmt.Errorf("invalid query for %s provider: %w", provider.Type(), err)
+		}
+	}
 	id := fmt.Sprintf("metrics-%d", i)
+	switch cfg.Strategy {
+	case config.AnalysisStrategyCanaryBaseline, config.AnalysisStrategyPrevious:
+		cr, err := e.newComparisonRunner(id, provider, cfg)
+		if err != nil {
+			return nil, err
+		}
+		runner := func(ctx context.Context, _ string) (bool, string, error) {
+			return cr.run(ctx)
+		}
+		return newAnalyzer(id, provider.Type(), cfg.Query, runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.InconclusiveLimit, cfg.FailureWindow, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
+	}
 	runner := func(ctx context.Context, query string) (bool, string, error) {
 		now := time.Now()
 		queryRange := metrics.QueryRange{
@@ -213,7 +312,103 @@ func (e *Executor) newAnalyzerForMetrics(i int, templatable *config.TemplatableA
 		}
 		return provider.Evaluate(ctx, query, queryRange, &cfg.Expected)
 	}
-	return newAnalyzer(id, provider.Type(), cfg.Query, runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
+	return newAnalyzer(id, provider.Type(), cfg.Query, runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.InconclusiveLimit, cfg.FailureWindow, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
+}
+
+// newComparisonRunner returns a runner comparing the data points of two variants
+// decided by the strategy of the given metrics config.
+func (e *Executor) newComparisonRunner(id string, provider metrics.Provider, cfg *config.AnalysisMetrics) (*comparisonRunner, error) {
+	queryer, ok := provider.(metrics.DataPointsQueryer)
+	if !ok {
+		return nil, fmt.Errorf("%s provider does not support the %s strategy", provider.Type(), cfg.Strategy)
+	}
+	interval := cfg.Interval.Duration()
+	currentRange := func(now time.Time) metrics.QueryRange {
+		return metrics.QueryRange{
+			From: now.Add(-interval),
+			To:   now,
+		}
+	}
+	r := &comparisonRunner{
+		comparison: newComparison(cfg),
+	}
+
+	switch cfg.Strategy {
+	case config.AnalysisStrategyCanaryBaseline:
+		canaryQuery, err := renderQuery(cfg.Query, canaryVariant, cfg.CanaryArgs)
+		if err != nil {
+			return nil, err
+		}
+		baselineQuery, err := renderQuery(cfg.Query, baselineVariant, cfg.BaselineArgs)
+		if err != nil {
+			return nil, err
+		}
+		r.experimentVariant, r.controlVariant = canaryVariant, baselineVariant
+		r.experiment = queryValues(queryer, canaryQuery, currentRange)
+		r.control = queryValues(queryer, baselineQuery, currentRange)
+
+	case config.AnalysisStrategyPrevious:
+		query, err := renderQuery(cfg.Query, primaryVariant, cfg.PrimaryArgs)
+		if err != nil {
+			return nil, err
+		}
+		r.experimentVariant, r.controlVariant = primaryVariant, "previous version"
+		r.experiment = queryValues(queryer, query, currentRange)
+		r.control = e.previousValues(id, queryer, query, interval)
+	}
+	return r, nil
+}
+
+// previousValues returns a valuesFunc returning the snapshot of the values
+// the previous version reported before this deployment was started.
+// The snapshot is taken at the first call and saved into the stage metadata
+// so that the same values are used even after the stage was restarted.
+func (e *Executor) previousValues(id string, queryer metrics.DataPointsQueryer, query string, interval time.Duration) valuesFunc {
+	var (
+		key      = e.metadataKeyPrefix + previousSnapshotKeyPrefix + id
+		snapshot *metricsSnapshot
+	)
+	return func(ctx context.Context, _ time.Time) ([]float64, error) {
+		if snapshot != nil {
+			return snapshot.Values, nil
+		}
+
+		s := &metricsSnapshot{}
+		found, err := e.StageMetadata().GetJSON(key, s)
+		if err != nil {
+			e.Logger.Error("failed to load the snapshot of previous metrics", zap.Error(err))
+		}
+		if found && err == nil {
+			snapshot = s
+			return snapshot.Values, nil
+		}
+
+		// Use the period of the same length as this stage
+		// because the values vary depending on the time of day.
+		lookback := time.Duration(e.StageConfig.AnalysisStageOptions.Duration)
+		if lookback < interval {
+			lookback = interval
+		}
+		to := time.Unix(e.Deployment.CreatedAt, 0)
+		points, err := queryer.QueryPoints(ctx, query, metrics.QueryRange{
+			From: to.Add(-lookback),
+			To:   to,
+		})
+		if err != nil {
+			return nil, err
+		}
+		s = &metricsSnapshot{
+			From:   to.Add(-lookback).Unix(),
+			To:     to.Unix(),
+			Values: pointValues(points),
+		}
+		if err := e.StageMetadata().PutJSON(ctx, key, s); err != nil {
+			e.Logger.Error("failed to save the snapshot of previous metrics", zap.Error(err))
+		}
+		e.LogPersister.Infof("[%s] Recorded %d data points reported by the previous version before this deployment was started", id, len(s.Values))
+		snapshot = s
+		return snapshot.Values, nil
+	}
 }
 
 func (e *Executor) newAnalyzerForLog(i int, templatable *config.TemplatableAnalysisLog, templateCfg *config.AnalysisTemplateSpec) (*analyzer, error) {
@@ -221,15 +416,25 @@ func (e *Executor) newAnalyzerForLog(i int, templatable *config.TemplatableAnaly
 	if err != nil {
 		return nil, err
 	}
-	provider, err := e.newLogProvider(cfg.Provider)
+	provider, err := e.newLogProvider(cfg)
 	if err != nil {
 		return nil, err
 	}
+	query, err := renderQuery(cfg.Query, e.analyzedVariant(), nil)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Query = query
 	id := fmt.Sprintf("log-%d", i)
 	runner := func(ctx context.Context, query string) (bool, string, error) {
-		return provider.Evaluate(ctx, query)
+		now := time.Now()
+		queryRange := log.QueryRange{
+			From: now.Add(-cfg.Interval.Duration()),
+			To:   now,
+		}
+		return provider.Evaluate(ctx, query, queryRange, cfg.Threshold)
 	}
-	return newAnalyzer(id, provider.Type(), cfg.Query, runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
+	return newAnalyzer(id, provider.Type(), cfg.Query, runner, time.Duration(cfg.Interval), cfg.FailureLimit, 0, 0, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
 }
 
 func (e *Executor) newAnalyzerForHTTP(i int, templatable *config.TemplatableAnalysisHTTP, templateCfg *config.AnalysisTemplateSpec) (*analyzer, error) {
@@ -237,12 +442,21 @@ func (e *Executor) newAnalyzerForHTTP(i int, templatable *config.TemplatableAnal
 	if err != nil {
 		return nil, err
 	}
+	if cfg, err = renderHTTP(cfg, e.analyzedVariant()); err != nil {
+		return nil, err
+	}
 	provider := httpprovider.NewProvider(time.Duration(cfg.Timeout))
 	id := fmt.Sprintf("http-%d", i)
-	runner := func(ctx context.Context, query string) (bool, string, error) {
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	// The request is shown as the query in the logs.
+	query := fmt.Sprintf("%s %s", method, cfg.URL)
+	runner := func(ctx context.Context, _ string) (bool, string, error) {
 		return provider.Run(ctx, cfg)
 	}
-	return newAnalyzer(id, provider.Type(), "", runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
+	return newAnalyzer(id, provider.Type(), query, runner, time.Duration(cfg.Interval), cfg.FailureLimit, 0, 0, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
 }
 
 func (e *Executor) newMetricsProvider(providerName string, templatable *config.TemplatableAnalysisMetrics) (metrics.Provider, error) {
@@ -257,12 +471,12 @@ func (e *Executor) newMetricsProvider(providerName string, templatable *config.T
 	return provider, nil
 }
 
-func (e *Executor) newLogProvider(providerName string) (log.Provider, error) {
-	cfg, ok := e.PipedConfig.GetAnalysisProvider(providerName)
+func (e *Executor) newLogProvider(analysisCfg *config.AnalysisLog) (log.Provider, error) {
+	cfg, ok := e.PipedConfig.GetAnalysisProvider(analysisCfg.Provider)
 	if !ok {
-		return nil, fmt.Errorf("unknown provider name %s", providerName)
+		return nil, fmt.Errorf("unknown provider name %s", analysisCfg.Provider)
 	}
-	provider, err := logfactory.NewProvider(&cfg, e.Logger)
+	provider, err := logfactory.NewProvider(analysisCfg, &cfg, e.Logger)
 	if err != nil {
 		return nil, err
 	}
@@ -321,7 +535,11 @@ func (e *Executor) getLogConfig(templatableCfg *config.TemplatableAnalysisLog, t
 func (e *Executor) getHTTPConfig(templatableCfg *config.TemplatableAnalysisHTTP, templateCfg *config.AnalysisTemplateSpec, args map[string]string) (*config.AnalysisHTTP, error) {
 	name := templatableCfg.Template.Name
 	if name == "" {
-		return &templatableCfg.AnalysisHTTP, nil
+		cfg := &templatableCfg.AnalysisHTTP
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid http configuration: %w", err)
+		}
+		return cfg, nil
 	}
 
 	var err error
@@ -333,27 +551,124 @@ func (e *Executor) getHTTPConfig(templatableCfg *config.TemplatableAnalysisHTTP,
 	if !ok {
 		return nil, fmt.Errorf("analysis template %s not found despite template specified", name)
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid http configuration: %w", err)
+	}
 	return &cfg, nil
 }
 
 // render returns a new AnalysisTemplateSpec, where deployment-specific arguments populated.
 func (e *Executor) render(templateCfg config.AnalysisTemplateSpec, customArgs map[string]string) (*config.AnalysisTemplateSpec, error) {
-	args := templateArgs{
-		Args: customArgs,
-		App: struct {
-			Name string
-			Env  string
-			// TODO: Populate Env
-		}{Name: e.Application.Name, Env: ""},
+	args := e.builtinTemplateArgs()
+	args.VariantCustomArgs = variantArgPlaceholders(&templateCfg)
+
+	expanded, err := expandCustomArgs(customArgs, args)
+	if err != nil {
+		return nil, err
 	}
+	args.Args = expanded
+
+	return renderTemplate(templateCfg, args)
+}
+
+// analyzedVariant returns the name of the variant analyzed by the analyses
+// not comparing two variants. That is the canary variant while the one created
+// by a preceding stage is still running, otherwise the primary variant.
+func (e *Executor) analyzedVariant() string {
+	variant := primaryVariant
+	for _, s := range e.Deployment.Stages {
+		if s.Id == e.Stage.Id {
+			break
+		}
+		switch model.Stage(s.Name) {
+		case model.StageK8sCanaryRollout, model.StageLambdaCanaryRollout:
+			variant = canaryVariant
+		case model.StageK8sCanaryClean, model.StageK8sPrimaryRollout:
+			variant = primaryVariant
+		}
+	}
+	return variant
+}
+
+// variantCustomArgs returns the custom args configured for the given variant.
+func variantCustomArgs(cfg *config.AnalysisMetrics, variant string) map[string]string {
+	switch variant {
+	case canaryVariant:
+		return cfg.CanaryArgs
+	case baselineVariant:
+		return cfg.BaselineArgs
+	default:
+		return cfg.PrimaryArgs
+	}
+}
+
+// renderHTTP returns a copy of the given http config
+// where the data of the given variant populated.
+func renderHTTP(cfg *config.AnalysisHTTP, variant string) (*config.AnalysisHTTP, error) {
+	rendered := *cfg
+	var err error
+	if rendered.URL, err = renderQuery(cfg.URL, variant, nil); err != nil {
+		return nil, err
+	}
+	if rendered.Body, err = renderQuery(cfg.Body, variant, nil); err != nil {
+		return nil, err
+	}
+	rendered.Headers = make([]config.AnalysisHeader, 0, len(cfg.Headers))
+	for _, h := range cfg.Headers {
+		if h.Value, err = renderQuery(h.Value, variant, nil); err != nil {
+			return nil, err
+		}
+		rendered.Headers = append(rendered.Headers, h)
+	}
+	return &rendered, nil
+}
+
+// builtinTemplateArgs returns the args populated automatically from the current deployment.
+func (e *Executor) builtinTemplateArgs() templateArgs {
+	var args templateArgs
+	args.App.ID = e.Application.Id
+	args.App.Name = e.Application.Name
+	args.App.Env = e.EnvName
+	args.Deployment.ID = e.Deployment.Id
+	args.Deployment.CommitHash = e.Deployment.CommitHash()
+	args.Variant.Name = "{{ .Variant.Name }}"
 	if e.config.Kind == config.KindKubernetesApp {
 		namespace := "default"
 		if n := e.config.KubernetesDeploymentSpec.Input.Namespace; n != "" {
 			namespace = n
 		}
-		args.K8s = struct{ Namespace string }{Namespace: namespace}
+		args.K8s.Namespace = namespace
+	}
+	return args
+}
+
+// expandCustomArgs returns a copy of the given custom args
+// where the built-in args referenced in their values are expanded.
+func expandCustomArgs(customArgs map[string]string, builtin templateArgs) (map[string]string, error) {
+	if len(customArgs) == 0 {
+		return customArgs, nil
+	}
+	expanded := make(map[string]string, len(customArgs))
+	for k, v := range customArgs {
+		if !strings.Contains(v, "{{") {
+			expanded[k] = v
+			continue
+		}
+		t, err := template.New(k).Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the value of arg %s: %w", k, err)
+		}
+		b := new(bytes.Buffer)
+		if err := t.Execute(b, builtin); err != nil {
+			return nil, fmt.Errorf("failed to expand the value of arg %s: %w", k, err)
+		}
+		expanded[k] = b.String()
 	}
+	return expanded, nil
+}
 
+// renderTemplate renders the given analysis template with the given args.
+func renderTemplate(templateCfg config.AnalysisTemplateSpec, args templateArgs) (*config.AnalysisTemplateSpec, error) {
 	cfg, err := json.Marshal(templateCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal json: %w", err)
@@ -370,3 +685,17 @@ func (e *Executor) render(templateCfg config.AnalysisTemplateSpec, customArgs ma
 	err = json.Unmarshal(b.Bytes(), newCfg)
 	return newCfg, err
 }
+
+// variantArgPlaceholders returns the custom args whose values are the references to themselves
+// to keep them in the query while rendering the analysis template.
+func variantArgPlaceholders(templateCfg *config.AnalysisTemplateSpec) map[string]string {
+	placeholders := make(map[string]string)
+	for _, m := range templateCfg.Metrics {
+		for _, args := range []map[string]string{m.CanaryArgs, m.BaselineArgs, m.PrimaryArgs} {
+			for k := range args {
+				placeholders[k] = fmt.Sprintf("{{ .VariantCustomArgs.%s }}", k)
+			}
+		}
+	}
+	return placeholders
+}
@@ -0,0 +1,271 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeLogPersister struct{}
+
+func (l *fakeLogPersister) Write(_ []byte) (int, error)         { return 0, nil }
+func (l *fakeLogPersister) Info(_ string)                       {}
+func (l *fakeLogPersister) Infof(_ string, _ ...interface{})    {}
+func (l *fakeLogPersister) Success(_ string)                    {}
+func (l *fakeLogPersister) Successf(_ string, _ ...interface{}) {}
+func (l *fakeLogPersister) Error(_ string)                      {}
+func (l *fakeLogPersister) Errorf(_ string, _ ...interface{})   {}
+
+type fakeMetadataStore struct {
+	stages map[string]map[string]string
+}
+
+func (s *fakeMetadataStore) Get(_ string) (string, bool)              { return "", false }
+func (s *fakeMetadataStore) Set(_ context.Context, _, _ string) error { return nil }
+func (s *fakeMetadataStore) GetStageMetadata(id string) (map[string]string, bool) {
+	md, ok := s.stages[id]
+	return md, ok
+}
+func (s *fakeMetadataStore) SetStageMetadata(_ context.Context, id string, md map[string]string) error {
+	s.stages[id] = md
+	return nil
+}
+func (s *fakeMetadataStore) PutStageMetadata(_ context.Context, id string, md map[string]string) error {
+	if s.stages[id] == nil {
+		s.stages[id] = make(map[string]string)
+	}
+	for k, v := range md {
+		s.stages[id][k] = v
+	}
+	return nil
+}
+
+type fakeQueryer struct {
+	points  []metrics.DataPoint
+	queries []string
+	ranges  []metrics.QueryRange
+}
+
+func (q *fakeQueryer) QueryPoints(_ context.Context, query string, queryRange metrics.QueryRange) ([]metrics.DataPoint, error) {
+	q.queries = append(q.queries, query)
+	q.ranges = append(q.ranges, queryRange)
+	return q.points, nil
+}
+
+func TestPreviousValues(t *testing.T) {
+	var (
+		createdAt = time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		store     = &fakeMetadataStore{stages: make(map[string]map[string]string)}
+		queryer   = &fakeQueryer{
+			points: []metrics.DataPoint{
+				{Timestamp: createdAt.Add(-time.Minute).Unix(), Value: 1},
+				{Timestamp: createdAt.Unix(), Value: 2},
+			},
+		}
+		newExecutor = func() *Executor {
+			return &Executor{
+				Input: executor.Input{
+					Stage: &model.PipelineStage{Id: "stage-1"},
+					StageConfig: config.PipelineStage{
+						AnalysisStageOptions: &config.AnalysisStageOptions{
+							Duration: config.Duration(30 * time.Minute),
+						},
+					},
+					Deployment:    &model.Deployment{CreatedAt: createdAt.Unix()},
+					MetadataStore: store,
+					LogPersister:  &fakeLogPersister{},
+					Logger:        zap.NewNop(),
+				},
+			}
+		}
+	)
+
+	values, err := newExecutor().previousValues("metrics-0", queryer, "query", time.Minute)(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, values)
+	require.Len(t, queryer.ranges, 1)
+	assert.Equal(t, createdAt.Add(-30*time.Minute).Unix(), queryer.ranges[0].From.Unix())
+	assert.Equal(t, createdAt.Unix(), queryer.ranges[0].To.Unix())
+
+	// The saved snapshot is used after restarting without querying again.
+	queryer.points = nil
+	values, err = newExecutor().previousValues("metrics-0", queryer, "query", time.Minute)(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, values)
+	assert.Len(t, queryer.ranges, 1)
+}
+
+func TestExpandCustomArgs(t *testing.T) {
+	var builtin templateArgs
+	builtin.App.Name = "simple"
+	builtin.App.Env = "prod"
+	builtin.K8s.Namespace = "web"
+
+	testcases := []struct {
+		name       string
+		customArgs map[string]string
+		want       map[string]string
+		wantErr    bool
+	}{
+		{
+			name: "no args",
+		},
+		{
+			name: "plain values",
+			customArgs: map[string]string{
+				"job": "simple",
+			},
+			want: map[string]string{
+				"job": "simple",
+			},
+		},
+		{
+			name: "values referencing built-in args",
+			customArgs: map[string]string{
+				"job":     "{{ .App.Name }}-{{ .App.Env }}",
+				"service": "{{ .App.Name }}.{{ .K8s.Namespace }}.svc",
+				"code":    "5.*",
+			},
+			want: map[string]string{
+				"job":     "simple-prod",
+				"service": "simple.web.svc",
+				"code":    "5.*",
+			},
+		},
+		{
+			name: "invalid template",
+			customArgs: map[string]string{
+				"job": "{{ .App.Name ",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown built-in arg",
+			customArgs: map[string]string{
+				"job": "{{ .Unknown }}",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandCustomArgs(tc.customArgs, builtin)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	var args templateArgs
+	args.App.Name = "simple"
+	args.K8s.Namespace = "web"
+	args.Variant.Name = "{{ .Variant.Name }}"
+	args.Args = map[string]string{
+		"job": "simple-prod",
+	}
+
+	templateCfg := config.AnalysisTemplateSpec{
+		Metrics: map[string]config.AnalysisMetrics{
+			"error_rate": {
+				Query: `rate(errors{app="{{ .App.Name }}", namespace="{{ .K8s.Namespace }}", job="{{ .Args.job }}", variant="{{ .Variant.Name }}"}[1m])`,
+			},
+		},
+	}
+	got, err := renderTemplate(templateCfg, args)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`rate(errors{app="simple", namespace="web", job="simple-prod", variant="{{ .Variant.Name }}"}[1m])`,
+		got.Metrics["error_rate"].Query,
+	)
+}
+
+func TestAnalyzedVariant(t *testing.T) {
+	testcases := []struct {
+		name   string
+		stages []string
+		want   string
+	}{
+		{
+			name:   "no preceding stage",
+			stages: []string{"ANALYSIS"},
+			want:   primaryVariant,
+		},
+		{
+			name:   "after canary rollout",
+			stages: []string{"K8S_CANARY_ROLLOUT", "ANALYSIS", "K8S_PRIMARY_ROLLOUT", "K8S_CANARY_CLEAN"},
+			want:   canaryVariant,
+		},
+		{
+			name:   "after primary rollout",
+			stages: []string{"K8S_CANARY_ROLLOUT", "K8S_PRIMARY_ROLLOUT", "ANALYSIS", "K8S_CANARY_CLEAN"},
+			want:   primaryVariant,
+		},
+		{
+			name:   "after canary clean",
+			stages: []string{"K8S_CANARY_ROLLOUT", "K8S_CANARY_CLEAN", "ANALYSIS"},
+			want:   primaryVariant,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &model.Deployment{}
+			var current *model.PipelineStage
+			for i, name := range tc.stages {
+				s := &model.PipelineStage{Id: fmt.Sprintf("stage-%d", i), Name: name}
+				if name == "ANALYSIS" {
+					current = s
+				}
+				d.Stages = append(d.Stages, s)
+			}
+			e := &Executor{
+				Input: executor.Input{
+					Deployment: d,
+					Stage:      current,
+				},
+			}
+			assert.Equal(t, tc.want, e.analyzedVariant())
+		})
+	}
+}
+
+func TestRenderHTTP(t *testing.T) {
+	cfg := &config.AnalysisHTTP{
+		URL:  "http://{{ .Variant.Name }}.simple.svc/health",
+		Body: `{"variant": "{{ .Variant.Name }}"}`,
+		Headers: []config.AnalysisHeader{
+			{Key: "X-Variant", Value: "{{ .Variant.Name }}"},
+		},
+	}
+	got, err := renderHTTP(cfg, canaryVariant)
+	require.NoError(t, err)
+	assert.Equal(t, "http://canary.simple.svc/health", got.URL)
+	assert.Equal(t, `{"variant": "canary"}`, got.Body)
+	assert.Equal(t, []config.AnalysisHeader{{Key: "X-Variant", Value: "canary"}}, got.Headers)
+	// The given config is left unchanged.
+	assert.Equal(t, "{{ .Variant.Name }}", cfg.Headers[0].Value)
+}
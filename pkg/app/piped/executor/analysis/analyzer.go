@@ -21,7 +21,13 @@ type analyzer struct {
 	interval     time.Duration
 	// The analysis will fail, if this value is exceeded,
 	failureLimit int
-	skipOnNoData bool
+	// The analysis will fail, if the number of inconclusive results exceeds this value.
+	// Zero means the inconclusive results are counted as failures.
+	inconclusiveLimit int
+	// The number of latest results the limits are checked against.
+	// Zero means all results are checked.
+	failureWindow int
+	skipOnNoData  bool
 
 	logger       *zap.Logger
 	logPersister executor.LogPersister
@@ -29,6 +35,25 @@ type analyzer struct {
 
 type evaluator func(ctx context.Context, query string) (expected bool, reason string, err error)
 
+// failure is the error returned when the number of unexpected results exceeded the failure limit.
+// It keeps the details of the failed analysis to be sent with the notification.
+type failure struct {
+	analyzerID   string
+	providerType string
+	query        string
+	lastResult   string
+	count        int
+	limit        int
+	inconclusive bool
+}
+
+func (f *failure) Error() string {
+	if f.inconclusive {
+		return fmt.Sprintf("analysis '%s' failed because the number of inconclusive results exceeded the inconclusive limit (%d)", f.analyzerID, f.limit)
+	}
+	return fmt.Sprintf("anslysis '%s' failed because the failure number exceeded the failure limit (%d)", f.analyzerID, f.limit)
+}
+
 func newAnalyzer(
 	id string,
 	providerType string,
@@ -36,19 +61,23 @@ func newAnalyzer(
 	evaluate evaluator,
 	interval time.Duration,
 	failureLimit int,
+	inconclusiveLimit int,
+	failureWindow int,
 	skipOnNodata bool,
 	logger *zap.Logger,
 	logPersister executor.LogPersister,
 ) *analyzer {
 	return &analyzer{
-		id:           id,
-		providerType: providerType,
-		evaluate:     evaluate,
-		query:        query,
-		interval:     interval,
-		failureLimit: failureLimit,
-		skipOnNoData: skipOnNodata,
-		logPersister: logPersister,
+		id:                id,
+		providerType:      providerType,
+		evaluate:          evaluate,
+		query:             query,
+		interval:          interval,
+		failureLimit:      failureLimit,
+		inconclusiveLimit: inconclusiveLimit,
+		failureWindow:     failureWindow,
+		skipOnNoData:      skipOnNodata,
+		logPersister:      logPersister,
 		logger: logger.With(
 			zap.String("analyzer-id", id),
 			zap.String("provider-type", providerType),
@@ -57,40 +86,108 @@ func newAnalyzer(
 }
 
 // run starts an analysis which runs the query at the given interval, until the context is done.
-// It returns an error when the number of failures exceeds the the failureLimit.
+// It returns an error when the number of failures exceeds the the failureLimit,
+// or the number of inconclusive results exceeds the inconclusiveLimit.
 func (a *analyzer) run(ctx context.Context) error {
 	ticker := time.NewTicker(a.interval)
 	defer ticker.Stop()
 
-	failureCount := 0
+	history := &resultHistory{window: a.failureWindow}
 	for {
 		select {
 		case <-ticker.C:
 			expected, reason, err := a.evaluate(ctx, a.query)
-			// Ignore parent's context deadline exceeded error, and return immediately.
-			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == context.DeadlineExceeded {
+			// Ignore parent's context deadline exceeded or cancelled error, and return immediately.
+			// The context is cancelled when the stage was skipped.
+			if (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) && ctx.Err() != nil {
 				return nil
 			}
 			if errors.Is(err, metrics.ErrNoDataFound) && a.skipOnNoData {
 				a.logPersister.Infof("[%s] The query result evaluation was skipped because \"skipOnNoData\" is true even though no data returned. Reason: %v. Performed query: %q", a.id, err, a.query)
 				continue
 			}
+			result := resultFailure
 			if err != nil {
 				reason = fmt.Sprintf("failed to run query: %s", err.Error())
+				result = resultInconclusive
+			} else if expected {
+				result = resultSuccess
 			}
+			history.add(result)
 
-			if expected {
+			switch result {
+			case resultSuccess:
 				a.logPersister.Successf("[%s] The query result is expected one. Reason: %s. Performed query: %q", a.id, reason, a.query)
 				continue
+			case resultInconclusive:
+				a.logPersister.Errorf("[%s] The query result is inconclusive. Reason: %s. Performed query: %q", a.id, reason, a.query)
+			default:
+				a.logPersister.Errorf("[%s] The query result is unexpected. Reason: %s. Performed query: %q", a.id, reason, a.query)
 			}
 
-			a.logPersister.Errorf("[%s] The query result is unexpected. Reason: %s. Performed query: %q", a.id, reason, a.query)
-			failureCount++
-			if failureCount > a.failureLimit {
-				return fmt.Errorf("anslysis '%s' failed because the failure number exceeded the failure limit (%d)", a.id, a.failureLimit)
+			failures, inconclusives := history.count()
+			if a.inconclusiveLimit == 0 {
+				failures += inconclusives
+			} else if inconclusives > a.inconclusiveLimit {
+				return &failure{
+					analyzerID:   a.id,
+					providerType: a.providerType,
+					query:        a.query,
+					lastResult:   reason,
+					count:        inconclusives,
+					limit:        a.inconclusiveLimit,
+					inconclusive: true,
+				}
+			}
+			if failures > a.failureLimit {
+				return &failure{
+					analyzerID:   a.id,
+					providerType: a.providerType,
+					query:        a.query,
+					lastResult:   reason,
+					count:        failures,
+					limit:        a.failureLimit,
+				}
 			}
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
+
+type analysisResult int
+
+const (
+	resultSuccess analysisResult = iota
+	resultFailure
+	resultInconclusive
+)
+
+// resultHistory keeps the results of the latest queries
+// so that the limits can be checked against a sliding window.
+type resultHistory struct {
+	// The maximum number of results to be kept.
+	// Zero means all results are kept.
+	window  int
+	results []analysisResult
+}
+
+func (h *resultHistory) add(r analysisResult) {
+	h.results = append(h.results, r)
+	if h.window > 0 && len(h.results) > h.window {
+		h.results = h.results[len(h.results)-h.window:]
+	}
+}
+
+// count returns the number of failures and inconclusive results in the history.
+func (h *resultHistory) count() (failures, inconclusives int) {
+	for _, r := range h.results {
+		switch r {
+		case resultFailure:
+			failures++
+		case resultInconclusive:
+			inconclusives++
+		}
+	}
+	return
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAnalyzerRun(t *testing.T) {
+	const (
+		s = resultSuccess
+		f = resultFailure
+		i = resultInconclusive
+	)
+	testcases := []struct {
+		name              string
+		results           []analysisResult
+		failureLimit      int
+		inconclusiveLimit int
+		failureWindow     int
+		wantCount         int
+		wantInconclusive  bool
+		wantErr           bool
+	}{
+		{
+			name:    "all succeeded",
+			results: []analysisResult{s, s, s},
+		},
+		{
+			name:         "failures exceeded the limit",
+			results:      []analysisResult{f, s, f, s},
+			failureLimit: 1,
+			wantCount:    2,
+			wantErr:      true,
+		},
+		{
+			name:         "inconclusive results are counted as failures by default",
+			results:      []analysisResult{i, s, f},
+			failureLimit: 1,
+			wantCount:    2,
+			wantErr:      true,
+		},
+		{
+			name:              "inconclusive results within the limit",
+			results:           []analysisResult{i, s, f, i},
+			failureLimit:      1,
+			inconclusiveLimit: 2,
+		},
+		{
+			name:              "inconclusive results exceeded the limit",
+			results:           []analysisResult{i, i, s, i},
+			failureLimit:      1,
+			inconclusiveLimit: 2,
+			wantCount:         3,
+			wantInconclusive:  true,
+			wantErr:           true,
+		},
+		{
+			name:          "failures spread out of the window",
+			results:       []analysisResult{f, s, s, f, s, s, f},
+			failureLimit:  1,
+			failureWindow: 3,
+		},
+		{
+			name:          "consecutive failures",
+			results:       []analysisResult{f, s, f, f, f},
+			failureLimit:  2,
+			failureWindow: 3,
+			wantCount:     3,
+			wantErr:       true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			next := 0
+			evaluate := func(_ context.Context, _ string) (bool, string, error) {
+				// The ticker may fire once more before the cancellation is observed.
+				if next >= len(tc.results) {
+					return true, "ok", nil
+				}
+				r := tc.results[next]
+				next++
+				if next == len(tc.results) {
+					defer cancel()
+				}
+				switch r {
+				case resultSuccess:
+					return true, "ok", nil
+				case resultInconclusive:
+					return false, "", errors.New("unavailable")
+				default:
+					return false, "too high", nil
+				}
+			}
+			a := newAnalyzer("metrics-0", "PROMETHEUS", "query", evaluate, time.Millisecond, tc.failureLimit, tc.inconclusiveLimit, tc.failureWindow, false, zap.NewNop(), &fakeLogPersister{})
+
+			err := a.run(ctx)
+			if !tc.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var f *failure
+			if assert.True(t, errors.As(err, &f)) {
+				assert.Equal(t, tc.wantCount, f.count)
+				assert.Equal(t, tc.wantInconclusive, f.inconclusive)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/analysisprovider/metrics"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+const (
+	canaryVariant   = "canary"
+	baselineVariant = "baseline"
+	primaryVariant  = "primary"
+
+	defaultSignificance    = 0.05
+	defaultZScoreThreshold = 3.0
+)
+
+// variantArgs allows variant-specific data to be embedded in the query.
+// NOTE: Changing its fields will force users to change the query definition.
+type variantArgs struct {
+	Variant struct {
+		Name string
+	}
+	// User-defined custom args.
+	VariantCustomArgs map[string]string
+}
+
+// renderQuery returns the query where the data of the given variant populated.
+func renderQuery(query, variant string, customArgs map[string]string) (string, error) {
+	args := variantArgs{
+		VariantCustomArgs: customArgs,
+	}
+	args.Variant.Name = variant
+
+	t, err := template.New("Query").Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse query: %w", err)
+	}
+	b := new(bytes.Buffer)
+	if err := t.Execute(b, args); err != nil {
+		return "", fmt.Errorf("failed to render query: %w", err)
+	}
+	return b.String(), nil
+}
+
+// comparison holds the settings to compare the data points of two variants.
+type comparison struct {
+	deviation       config.AnalysisDeviation
+	statisticalTest config.AnalysisStatisticalTest
+	significance    float64
+	zScoreThreshold float64
+}
+
+func newComparison(cfg *config.AnalysisMetrics) *comparison {
+	c := &comparison{
+		deviation:       cfg.Deviation,
+		statisticalTest: cfg.StatisticalTest,
+		significance:    cfg.Significance,
+		zScoreThreshold: cfg.ZScoreThreshold,
+	}
+	if c.deviation == "" {
+		c.deviation = config.AnalysisDeviationEither
+	}
+	if c.statisticalTest == "" {
+		c.statisticalTest = config.AnalysisStatisticalTestMannWhitney
+	}
+	if c.significance == 0 {
+		c.significance = defaultSignificance
+	}
+	if c.zScoreThreshold == 0 {
+		c.zScoreThreshold = defaultZScoreThreshold
+	}
+	return c
+}
+
+// compare checks whether the experiment values differ from the control values
+// in the direction not allowed by the deviation.
+func (c *comparison) compare(experiment, control []float64) (expected bool, reason string, err error) {
+	if len(experiment) == 0 || len(control) == 0 {
+		return false, "", fmt.Errorf("no data points to compare: %w", metrics.ErrNoDataFound)
+	}
+
+	switch c.statisticalTest {
+	case config.AnalysisStatisticalTestZScore:
+		z := zScore(experiment, control)
+		if c.deviates(z, math.Abs(z) > c.zScoreThreshold) {
+			return false, fmt.Sprintf("the mean differs from the compared one by %.2f standard deviations (threshold: %g)", z, c.zScoreThreshold), nil
+		}
+		return true, fmt.Sprintf("the mean differs from the compared one by %.2f standard deviations (threshold: %g)", z, c.zScoreThreshold), nil
+	default:
+		z := mannWhitneyZ(experiment, control)
+		p := pValue(z, c.deviation)
+		if c.deviates(z, p < c.significance) {
+			return false, fmt.Sprintf("a significant difference was found by the Mann-Whitney U test (p-value: %.4f, significance: %g)", p, c.significance), nil
+		}
+		return true, fmt.Sprintf("no significant difference was found by the Mann-Whitney U test (p-value: %.4f, significance: %g)", p, c.significance), nil
+	}
+}
+
+// deviates reports whether the given significant difference is in the direction not allowed.
+func (c *comparison) deviates(z float64, significant bool) bool {
+	if !significant {
+		return false
+	}
+	switch c.deviation {
+	case config.AnalysisDeviationHigh:
+		return z > 0
+	case config.AnalysisDeviationLow:
+		return z < 0
+	default:
+		return true
+	}
+}
+
+// mannWhitneyZ returns the standardized U statistic of the Mann-Whitney U test.
+// It is positive when the experiment values tend to be higher than the control values.
+// The normal approximation with the tie and continuity corrections is used.
+func mannWhitneyZ(experiment, control []float64) float64 {
+	type sample struct {
+		value      float64
+		experiment bool
+	}
+	n1, n2 := float64(len(experiment)), float64(len(control))
+	samples := make([]sample, 0, len(experiment)+len(control))
+	for _, v := range experiment {
+		samples = append(samples, sample{value: v, experiment: true})
+	}
+	for _, v := range control {
+		samples = append(samples, sample{value: v})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].value < samples[j].value
+	})
+
+	// Assign the average rank to the tied values.
+	var rankSum, tieSum float64
+	for i := 0; i < len(samples); {
+		j := i
+		for j < len(samples) && samples[j].value == samples[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if samples[k].experiment {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		tieSum += t*t*t - t
+		i = j
+	}
+
+	n := n1 + n2
+	u := rankSum - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance <= 0 {
+		// All values are the same.
+		return 0
+	}
+	diff := u - mean
+	switch {
+	case diff > 0.5:
+		diff -= 0.5
+	case diff < -0.5:
+		diff += 0.5
+	default:
+		diff = 0
+	}
+	return diff / math.Sqrt(variance)
+}
+
+// pValue returns the p-value of the given standardized statistic.
+// A one-sided test is used when only one direction is considered as failure.
+func pValue(z float64, deviation config.AnalysisDeviation) float64 {
+	switch deviation {
+	case config.AnalysisDeviationHigh:
+		return 0.5 * math.Erfc(z/math.Sqrt2)
+	case config.AnalysisDeviationLow:
+		return 0.5 * math.Erfc(-z/math.Sqrt2)
+	default:
+		return math.Erfc(math.Abs(z) / math.Sqrt2)
+	}
+}
+
+// zScore returns how many standard deviations of the control values
+// the mean of the experiment values differs from the mean of the control values.
+func zScore(experiment, control []float64) float64 {
+	experimentMean := mean(experiment)
+	controlMean := mean(control)
+	var sum float64
+	for _, v := range control {
+		sum += (v - controlMean) * (v - controlMean)
+	}
+	stddev := math.Sqrt(sum / float64(len(control)))
+	diff := experimentMean - controlMean
+	if stddev == 0 {
+		switch {
+		case diff > 0:
+			return math.Inf(1)
+		case diff < 0:
+			return math.Inf(-1)
+		default:
+			return 0
+		}
+	}
+	return diff / stddev
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func pointValues(points []metrics.DataPoint) []float64 {
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		values = append(values, p.Value)
+	}
+	return values
+}
+
+// valuesFunc returns the values of a variant to be compared.
+type valuesFunc func(ctx context.Context, now time.Time) ([]float64, error)
+
+// queryValues returns a valuesFunc running the given query over the range decided by the given function.
+func queryValues(queryer metrics.DataPointsQueryer, query string, queryRange func(now time.Time) metrics.QueryRange) valuesFunc {
+	return func(ctx context.Context, now time.Time) ([]float64, error) {
+		points, err := queryer.QueryPoints(ctx, query, queryRange(now))
+		if err != nil {
+			return nil, err
+		}
+		return pointValues(points), nil
+	}
+}
+
+// metricsSnapshot represents the values of a metric recorded for comparing with them later.
+type metricsSnapshot struct {
+	// Unix timestamps in seconds of the queried range.
+	From   int64     `json:"from"`
+	To     int64     `json:"to"`
+	Values []float64 `json:"values"`
+}
+
+// comparisonRunner gets the values of two variants and compares them.
+type comparisonRunner struct {
+	comparison        *comparison
+	experiment        valuesFunc
+	control           valuesFunc
+	experimentVariant string
+	controlVariant    string
+}
+
+func (r *comparisonRunner) run(ctx context.Context) (bool, string, error) {
+	now := time.Now()
+	experiment, err := r.experiment(ctx, now)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to query %s data points: %w", r.experimentVariant, err)
+	}
+	control, err := r.control(ctx, now)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to query %s data points: %w", r.controlVariant, err)
+	}
+	expected, reason, err := r.comparison.compare(experiment, control)
+	if err != nil {
+		return false, "", err
+	}
+	return expected, fmt.Sprintf("%s compared with %s: %s", r.experimentVariant, r.controlVariant, reason), nil
+}
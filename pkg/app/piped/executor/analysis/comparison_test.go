@@ -0,0 +1,128 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestRenderQuery(t *testing.T) {
+	got, err := renderQuery(`rate(errors{variant="{{ .Variant.Name }}", pod=~"{{ .VariantCustomArgs.pod }}"}[1m])`, canaryVariant, map[string]string{"pod": "app-canary-.*"})
+	require.NoError(t, err)
+	assert.Equal(t, `rate(errors{variant="canary", pod=~"app-canary-.*"}[1m])`, got)
+
+	_, err = renderQuery("{{ .Unknown }}", canaryVariant, nil)
+	assert.Error(t, err)
+}
+
+func TestComparisonCompare(t *testing.T) {
+	baseline := []float64{10, 11, 9, 10, 12, 10, 11, 9, 10, 11}
+	higher := []float64{20, 21, 19, 22, 20, 21, 23, 20, 19, 22}
+	lower := []float64{1, 2, 1, 0, 2, 1, 1, 2, 0, 1}
+	similar := []float64{10, 10, 11, 9, 11, 10, 12, 9, 10, 10}
+
+	testcases := []struct {
+		name       string
+		cfg        config.AnalysisMetrics
+		experiment []float64
+		control    []float64
+		expected   bool
+		wantErr    bool
+	}{
+		{
+			name:       "mann-whitney: no significant difference",
+			experiment: similar,
+			control:    baseline,
+			expected:   true,
+		},
+		{
+			name:       "mann-whitney: higher values",
+			experiment: higher,
+			control:    baseline,
+			expected:   false,
+		},
+		{
+			name:       "mann-whitney: lower values are allowed by HIGH deviation",
+			cfg:        config.AnalysisMetrics{Deviation: config.AnalysisDeviationHigh},
+			experiment: lower,
+			control:    baseline,
+			expected:   true,
+		},
+		{
+			name:       "mann-whitney: lower values are not allowed by LOW deviation",
+			cfg:        config.AnalysisMetrics{Deviation: config.AnalysisDeviationLow},
+			experiment: lower,
+			control:    baseline,
+			expected:   false,
+		},
+		{
+			name:       "mann-whitney: all values are the same",
+			experiment: []float64{1, 1, 1},
+			control:    []float64{1, 1, 1},
+			expected:   true,
+		},
+		{
+			name:       "z-score: within threshold",
+			cfg:        config.AnalysisMetrics{StatisticalTest: config.AnalysisStatisticalTestZScore},
+			experiment: similar,
+			control:    baseline,
+			expected:   true,
+		},
+		{
+			name:       "z-score: higher values",
+			cfg:        config.AnalysisMetrics{StatisticalTest: config.AnalysisStatisticalTestZScore},
+			experiment: higher,
+			control:    baseline,
+			expected:   false,
+		},
+		{
+			name: "z-score: higher values are allowed by LOW deviation",
+			cfg: config.AnalysisMetrics{
+				StatisticalTest: config.AnalysisStatisticalTestZScore,
+				Deviation:       config.AnalysisDeviationLow,
+			},
+			experiment: higher,
+			control:    baseline,
+			expected:   true,
+		},
+		{
+			name:       "no data points",
+			experiment: nil,
+			control:    baseline,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newComparison(&tc.cfg)
+			expected, _, err := c.compare(tc.experiment, tc.control)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, expected)
+		})
+	}
+}
+
+func TestMannWhitneyZ(t *testing.T) {
+	// The experiment values are all higher than the control values.
+	z := mannWhitneyZ([]float64{4, 5, 6}, []float64{1, 2, 3})
+	assert.InDelta(t, 1.746, z, 0.001)
+	assert.InDelta(t, -1.746, mannWhitneyZ([]float64{1, 2, 3}, []float64{4, 5, 6}), 0.001)
+	assert.Equal(t, 0.0, mannWhitneyZ([]float64{1, 2}, []float64{1, 2}))
+}
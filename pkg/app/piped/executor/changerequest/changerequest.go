@@ -0,0 +1,193 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/changemanagement"
+	"github.com/pipe-cd/pipe/pkg/app/piped/changemanagement/factory"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const (
+	changeRequestNumberKey = "ChangeRequestNumber"
+	changeRequestURLKey    = "ChangeRequestURL"
+)
+
+type Executor struct {
+	executor.Input
+}
+
+type registerer interface {
+	Register(stage model.Stage, f executor.Factory) error
+}
+
+// Register registers this executor factory into a given registerer.
+func Register(r registerer) {
+	f := func(in executor.Input) executor.Executor {
+		return &Executor{
+			Input: in,
+		}
+	}
+	r.Register(model.StageChangeRequest, f)
+}
+
+// Execute ensures a change request for this deployment exists
+// and waits until it is approved and its window is opened.
+func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
+	options := e.StageConfig.ChangeRequestStageOptions
+	cfg, ok := e.PipedConfig.GetChangeManagementProvider(options.Provider)
+	if !ok {
+		e.LogPersister.Errorf("Change management provider %s was not found in piped config", options.Provider)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	provider, err := factory.NewProvider(&cfg, e.Logger)
+	if err != nil {
+		e.LogPersister.Errorf("Failed to create change management provider %s: %v", options.Provider, err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	return e.execute(sig, provider)
+}
+
+func (e *Executor) execute(sig executor.StopSignal, provider changemanagement.Provider) model.StageStatus {
+	var (
+		originalStatus = e.Stage.Status
+		ctx            = sig.Context()
+		options        = e.StageConfig.ChangeRequestStageOptions
+		timeout        = options.Timeout.Duration()
+	)
+
+	number, err := e.ensureChangeRequest(ctx, provider)
+	if err != nil {
+		e.LogPersister.Errorf("Failed to prepare the change request: %v", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	ticker := time.NewTicker(options.CheckInterval.Duration())
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	e.LogPersister.Infof("Waiting for change request %s to be approved...", number)
+	var lastReason string
+	check := func() (model.StageStatus, bool) {
+		cr, err := provider.GetChangeRequest(ctx, number)
+		if err != nil {
+			// The change management system might be temporarily unavailable,
+			// so just log and retry at the next check.
+			e.LogPersister.Errorf("Failed to get change request %s: %v", number, err)
+			return model.StageStatus_STAGE_RUNNING, false
+		}
+		ok, reason, err := cr.Check(time.Now())
+		if err != nil {
+			e.LogPersister.Errorf("Unable to proceed: %v", err)
+			return model.StageStatus_STAGE_FAILURE, true
+		}
+		if ok {
+			e.LogPersister.Success(reason)
+			return model.StageStatus_STAGE_SUCCESS, true
+		}
+		if reason != lastReason {
+			e.LogPersister.Info(reason)
+			lastReason = reason
+		}
+		return model.StageStatus_STAGE_RUNNING, false
+	}
+
+	if status, done := check(); done {
+		return status
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if status, done := check(); done {
+				return status
+			}
+
+		case s := <-sig.Ch():
+			switch s {
+			case executor.StopSignalCancel:
+				return model.StageStatus_STAGE_CANCELLED
+			case executor.StopSignalTerminate:
+				return originalStatus
+			default:
+				return model.StageStatus_STAGE_FAILURE
+			}
+
+		case <-timer.C:
+			e.LogPersister.Errorf("Timed out %v while waiting for change request %s", timeout, number)
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+}
+
+// ensureChangeRequest returns the number of the change request for this stage.
+// A new one is created only when no number was specified or saved by the previous run.
+func (e *Executor) ensureChangeRequest(ctx context.Context, provider changemanagement.Provider) (string, error) {
+	options := e.StageConfig.ChangeRequestStageOptions
+	if options.Number != "" {
+		e.LogPersister.Infof("Using the specified change request %s", options.Number)
+		return options.Number, nil
+	}
+	if number, ok := e.StageMetadata().Get(changeRequestNumberKey); ok && number != "" {
+		e.LogPersister.Infof("Using change request %s created by the previous run", number)
+		return number, nil
+	}
+
+	shortDesc := options.ShortDescription
+	if shortDesc == "" {
+		shortDesc = fmt.Sprintf("Deploy %s to %s", e.Deployment.ApplicationName, e.EnvName)
+	}
+	cr, err := provider.CreateChangeRequest(ctx, changemanagement.CreateRequest{
+		ShortDescription: shortDesc,
+		Description:      e.makeDescription(),
+		Fields:           options.Fields,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	metadata := map[string]string{
+		changeRequestNumberKey: cr.Number,
+		changeRequestURLKey:    cr.URL,
+	}
+	if err := e.StageMetadata().PutMulti(ctx, metadata); err != nil {
+		// Not a fatal error since the stage can still wait for the created one.
+		e.Logger.Error("failed to save change request to stage metadata", zap.Error(err))
+	}
+	e.LogPersister.Infof("Created change request %s: %s", cr.Number, cr.URL)
+	return cr.Number, nil
+}
+
+func (e *Executor) makeDescription() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Application: %s\n", e.Deployment.ApplicationName)
+	fmt.Fprintf(&b, "Deployment: %s\n", e.Deployment.Id)
+	if e.Deployment.Trigger != nil && e.Deployment.Trigger.Commit != nil {
+		c := e.Deployment.Trigger.Commit
+		fmt.Fprintf(&b, "Commit: %s %s\n", c.Hash, c.Message)
+	}
+	if addr := strings.TrimRight(e.PipedConfig.WebAddress, "/"); addr != "" {
+		fmt.Fprintf(&b, "Link: %s/deployments/%s\n", addr, e.Deployment.Id)
+	}
+	return b.String()
+}
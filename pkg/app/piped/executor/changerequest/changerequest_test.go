@@ -0,0 +1,197 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changerequest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/changemanagement"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeLogPersister struct{}
+
+func (l *fakeLogPersister) Write(_ []byte) (int, error)         { return 0, nil }
+func (l *fakeLogPersister) Info(_ string)                       {}
+func (l *fakeLogPersister) Infof(_ string, _ ...interface{})    {}
+func (l *fakeLogPersister) Success(_ string)                    {}
+func (l *fakeLogPersister) Successf(_ string, _ ...interface{}) {}
+func (l *fakeLogPersister) Error(_ string)                      {}
+func (l *fakeLogPersister) Errorf(_ string, _ ...interface{})   {}
+
+type fakeMetadataStore struct {
+	stages map[string]map[string]string
+}
+
+func (s *fakeMetadataStore) Get(_ string) (string, bool)              { return "", false }
+func (s *fakeMetadataStore) Set(_ context.Context, _, _ string) error { return nil }
+func (s *fakeMetadataStore) GetStageMetadata(id string) (map[string]string, bool) {
+	md, ok := s.stages[id]
+	return md, ok
+}
+func (s *fakeMetadataStore) SetStageMetadata(_ context.Context, id string, md map[string]string) error {
+	s.stages[id] = md
+	return nil
+}
+func (s *fakeMetadataStore) PutStageMetadata(_ context.Context, id string, md map[string]string) error {
+	if s.stages[id] == nil {
+		s.stages[id] = make(map[string]string)
+	}
+	for k, v := range md {
+		s.stages[id][k] = v
+	}
+	return nil
+}
+
+type fakeProvider struct {
+	created []changemanagement.CreateRequest
+	// The statuses returned by the sequential GetChangeRequest calls.
+	// The last one is repeated once all have been returned.
+	statuses []changemanagement.ApprovalStatus
+	startAt  time.Time
+	endAt    time.Time
+	calls    int
+}
+
+func (p *fakeProvider) Type() string { return "fake" }
+
+func (p *fakeProvider) CreateChangeRequest(_ context.Context, req changemanagement.CreateRequest) (*changemanagement.ChangeRequest, error) {
+	p.created = append(p.created, req)
+	return &changemanagement.ChangeRequest{
+		Number: fmt.Sprintf("CHG%d", len(p.created)),
+		URL:    "https://example.com",
+	}, nil
+}
+
+func (p *fakeProvider) GetChangeRequest(_ context.Context, number string) (*changemanagement.ChangeRequest, error) {
+	i := p.calls
+	if i >= len(p.statuses) {
+		i = len(p.statuses) - 1
+	}
+	p.calls++
+	return &changemanagement.ChangeRequest{
+		Number:  number,
+		Status:  p.statuses[i],
+		StartAt: p.startAt,
+		EndAt:   p.endAt,
+	}, nil
+}
+
+func TestExecute(t *testing.T) {
+	now := time.Now()
+	testcases := []struct {
+		name            string
+		number          string
+		savedNumber     string
+		provider        *fakeProvider
+		expected        model.StageStatus
+		expectedCreated int
+		expectedNumber  string
+	}{
+		{
+			name: "create and wait for an approval",
+			provider: &fakeProvider{
+				statuses: []changemanagement.ApprovalStatus{changemanagement.ApprovalPending, changemanagement.ApprovalApproved},
+			},
+			expected:        model.StageStatus_STAGE_SUCCESS,
+			expectedCreated: 1,
+			expectedNumber:  "CHG1",
+		},
+		{
+			name:   "use the specified change request",
+			number: "CHG100",
+			provider: &fakeProvider{
+				statuses: []changemanagement.ApprovalStatus{changemanagement.ApprovalApproved},
+			},
+			expected: model.StageStatus_STAGE_SUCCESS,
+		},
+		{
+			name:        "reuse the change request created by the previous run",
+			savedNumber: "CHG200",
+			provider: &fakeProvider{
+				statuses: []changemanagement.ApprovalStatus{changemanagement.ApprovalApproved},
+			},
+			expected:       model.StageStatus_STAGE_SUCCESS,
+			expectedNumber: "CHG200",
+		},
+		{
+			name: "rejected",
+			provider: &fakeProvider{
+				statuses: []changemanagement.ApprovalStatus{changemanagement.ApprovalRejected},
+			},
+			expected:        model.StageStatus_STAGE_FAILURE,
+			expectedCreated: 1,
+			expectedNumber:  "CHG1",
+		},
+		{
+			name: "window has already ended",
+			provider: &fakeProvider{
+				statuses: []changemanagement.ApprovalStatus{changemanagement.ApprovalApproved},
+				endAt:    now.Add(-time.Hour),
+			},
+			expected:        model.StageStatus_STAGE_FAILURE,
+			expectedCreated: 1,
+			expectedNumber:  "CHG1",
+		},
+		{
+			name:   "timed out before the window starts",
+			number: "CHG100",
+			provider: &fakeProvider{
+				statuses: []changemanagement.ApprovalStatus{changemanagement.ApprovalApproved},
+				startAt:  now.Add(time.Hour),
+			},
+			expected: model.StageStatus_STAGE_FAILURE,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeMetadataStore{stages: map[string]map[string]string{}}
+			if tc.savedNumber != "" {
+				store.stages["stage-id"] = map[string]string{changeRequestNumberKey: tc.savedNumber}
+			}
+			e := &Executor{
+				Input: executor.Input{
+					Stage: &model.PipelineStage{Id: "stage-id"},
+					StageConfig: config.PipelineStage{
+						ChangeRequestStageOptions: &config.ChangeRequestStageOptions{
+							Provider:      "servicenow",
+							Number:        tc.number,
+							Timeout:       config.Duration(100 * time.Millisecond),
+							CheckInterval: config.Duration(10 * time.Millisecond),
+						},
+					},
+					Deployment:    &model.Deployment{Id: "deployment-id", ApplicationName: "app"},
+					EnvName:       "prod",
+					PipedConfig:   &config.PipedSpec{},
+					MetadataStore: store,
+					LogPersister:  &fakeLogPersister{},
+					Logger:        zap.NewNop(),
+				},
+			}
+			sig, _ := executor.NewStopSignal()
+			assert.Equal(t, tc.expected, e.execute(sig, tc.provider))
+			assert.Equal(t, tc.expectedCreated, len(tc.provider.created))
+			assert.Equal(t, tc.expectedNumber, store.stages["stage-id"][changeRequestNumberKey])
+		})
+	}
+}
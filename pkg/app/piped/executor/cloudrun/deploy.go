@@ -113,10 +113,7 @@ func (e *deployExecutor) ensurePromote(ctx context.Context) model.StageStatus {
 		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
 		return model.StageStatus_STAGE_FAILURE
 	}
-	metadata := map[string]string{
-		promotePercentageMetadataKey: strconv.FormatInt(int64(options.Percent), 10),
-	}
-	if err := e.MetadataStore.SetStageMetadata(ctx, e.Stage.Id, metadata); err != nil {
+	if err := e.StageMetadata().Put(ctx, promotePercentageMetadataKey, strconv.FormatInt(int64(options.Percent), 10)); err != nil {
 		e.Logger.Error("failed to save routing percentages to metadata", zap.Error(err))
 	}
 
@@ -19,6 +19,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/pipe-cd/pipe/pkg/app/piped/approvalstore"
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipe/pkg/app/piped/deploysource"
 	"github.com/pipe-cd/pipe/pkg/cache"
@@ -50,10 +51,23 @@ type MetadataStore interface {
 
 	GetStageMetadata(stageID string) (map[string]string, bool)
 	SetStageMetadata(ctx context.Context, stageID string, metadata map[string]string) error
+	// PutStageMetadata is the same as SetStageMetadata
+	// except it keeps the existing keys those are not given.
+	PutStageMetadata(ctx context.Context, stageID string, metadata map[string]string) error
 }
 
 type CommandLister interface {
 	ListCommands() []model.ReportableCommand
+	// Updated returns a channel which is closed when new commands arrived.
+	Updated() <-chan struct{}
+}
+
+type Notifier interface {
+	Notify(event model.NotificationEvent)
+}
+
+type ApprovalTaker interface {
+	Take(deploymentID, stageID string) (approvalstore.Decision, bool)
 }
 
 type AppLiveResourceLister interface {
@@ -66,6 +80,7 @@ type Input struct {
 	// Readonly deployment model.
 	Deployment            *model.Deployment
 	Application           *model.Application
+	EnvName               string
 	PipedConfig           *config.PipedSpec
 	TargetDSP             deploysource.Provider
 	RunningDSP            deploysource.Provider
@@ -74,6 +89,8 @@ type Input struct {
 	MetadataStore         MetadataStore
 	AppManifestsCache     cache.Cache
 	AppLiveResourceLister AppLiveResourceLister
+	Notifier              Notifier
+	ApprovalTaker         ApprovalTaker
 	Logger                *zap.Logger
 }
 
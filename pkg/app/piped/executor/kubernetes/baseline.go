@@ -63,6 +63,8 @@ func (e *deployExecutor) ensureBaselineRollout(ctx context.Context) model.StageS
 	// Add builtin annotations for tracking application live state.
 	addBuiltinAnnontations(
 		baselineManifests,
+		e.managedAnnotations,
+		e.variantLabel,
 		baselineVariant,
 		runningCommit,
 		e.PipedConfig.PipedID,
@@ -83,7 +85,7 @@ func (e *deployExecutor) ensureBaselineRollout(ctx context.Context) model.StageS
 
 	// Start rolling out the resources for BASELINE variant.
 	e.LogPersister.Info("Start rolling out BASELINE variant...")
-	if err := applyManifests(ctx, e.provider, baselineManifests, e.deployCfg.Input.Namespace, e.LogPersister); err != nil {
+	if err := applyManifests(ctx, e.provider, baselineManifests, e.deployCfg.Input.Namespace, e.deployCfg.Input.ApplyWaves, e.LogPersister); err != nil {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
@@ -130,7 +132,7 @@ func (e *deployExecutor) generateBaselineManifests(manifests []provider.Manifest
 		// so we duplicate them to avoid updating the shared manifests data in cache.
 		services = duplicateManifests(services, "")
 
-		generatedServices, err := generateVariantServiceManifests(services, baselineVariant, suffix)
+		generatedServices, err := generateVariantServiceManifests(services, e.variantLabel, baselineVariant, suffix)
 		if err != nil {
 			return nil, err
 		}
@@ -146,7 +148,7 @@ func (e *deployExecutor) generateBaselineManifests(manifests []provider.Manifest
 		num := opts.Replicas.Calculate(int(*cur), 1)
 		return int32(num)
 	}
-	generatedWorkloads, err := generateVariantWorkloadManifests(workloads, nil, nil, baselineVariant, suffix, replicasCalculator)
+	generatedWorkloads, err := generateVariantWorkloadManifests(workloads, nil, nil, e.variantLabel, baselineVariant, suffix, replicasCalculator)
 	if err != nil {
 		return nil, err
 	}
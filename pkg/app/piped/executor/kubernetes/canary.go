@@ -65,9 +65,16 @@ func (e *deployExecutor) ensureCanaryRollout(ctx context.Context) model.StageSta
 		return model.StageStatus_STAGE_FAILURE
 	}
 
+	// Pin the images to the digests resolved while planning.
+	if err := e.pinImageDigests(canaryManifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	// Add builtin annotations for tracking application live state.
 	addBuiltinAnnontations(
 		canaryManifests,
+		e.managedAnnotations,
+		e.variantLabel,
 		canaryVariant,
 		e.commit,
 		e.PipedConfig.PipedID,
@@ -88,11 +95,25 @@ func (e *deployExecutor) ensureCanaryRollout(ctx context.Context) model.StageSta
 
 	// Start rolling out the resources for CANARY variant.
 	e.LogPersister.Info("Start rolling out CANARY variant...")
-	if err := applyManifests(ctx, e.provider, canaryManifests, e.deployCfg.Input.Namespace, e.LogPersister); err != nil {
+	if err := applyManifests(ctx, e.provider, canaryManifests, e.deployCfg.Input.Namespace, e.deployCfg.Input.ApplyWaves, e.LogPersister); err != nil {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
 	e.LogPersister.Success("Successfully rolled out CANARY variant")
+
+	if d := options.WatchPodFailures.Duration(); d > 0 {
+		// The CANARY workloads were renamed with the suffix
+		// so they are found by their kind instead of the configured references.
+		workloads := make([]provider.Manifest, 0, len(canaryManifests))
+		for _, m := range canaryManifests {
+			if m.Key.IsWorkload() {
+				workloads = append(workloads, m)
+			}
+		}
+		if err := watchPodFailures(ctx, e.provider, workloads, d, e.LogPersister); err != nil {
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
 	return model.StageStatus_STAGE_SUCCESS
 }
 
@@ -135,7 +156,7 @@ func (e *deployExecutor) generateCanaryManifests(manifests []provider.Manifest,
 		// so we duplicate them to avoid updating the shared manifests data in cache.
 		services = duplicateManifests(services, "")
 
-		generatedServices, err := generateVariantServiceManifests(services, canaryVariant, suffix)
+		generatedServices, err := generateVariantServiceManifests(services, e.variantLabel, canaryVariant, suffix)
 		if err != nil {
 			return nil, err
 		}
@@ -164,7 +185,7 @@ func (e *deployExecutor) generateCanaryManifests(manifests []provider.Manifest,
 	// We don't need to duplicate the workload manifests
 	// because generateVariantWorkloadManifests function is already making a duplicate while decoding.
 	// workloads = duplicateManifests(workloads, suffix)
-	generatedWorkloads, err := generateVariantWorkloadManifests(workloads, configMaps, secrets, canaryVariant, suffix, replicasCalculator)
+	generatedWorkloads, err := generateVariantWorkloadManifests(workloads, configMaps, secrets, e.variantLabel, canaryVariant, suffix, replicasCalculator)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,138 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+)
+
+const (
+	// The stage metadata key where the warning events of the applied resources are stored.
+	warningEventsMetadataKey = "kubernetes-warning-events"
+	// The maximum number of warning events attached to a stage.
+	maxWarningEvents = 20
+	// The maximum number of pods of each workload whose events are collected.
+	maxEventPodsPerWorkload = 5
+)
+
+// appliedRecordingProvider records all manifests applied through the wrapped provider
+// so that their events can be collected when the stage was failed.
+type appliedRecordingProvider struct {
+	provider.Provider
+	mu      sync.Mutex
+	applied []provider.Manifest
+}
+
+func (p *appliedRecordingProvider) Apply(ctx context.Context, manifests []provider.Manifest) (string, error) {
+	p.record(manifests...)
+	return p.Provider.Apply(ctx, manifests)
+}
+
+func (p *appliedRecordingProvider) ApplyManifest(ctx context.Context, manifest provider.Manifest) error {
+	p.record(manifest)
+	return p.Provider.ApplyManifest(ctx, manifest)
+}
+
+func (p *appliedRecordingProvider) record(manifests ...provider.Manifest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.applied = append(p.applied, manifests...)
+}
+
+func (p *appliedRecordingProvider) appliedManifests() []provider.Manifest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.applied
+}
+
+// collectWarningEvents returns the descriptions of the Warning events related to
+// the given manifests and the pods of the workloads among them.
+// e.g. Pod/simple-6d8b9c7f5d-abcde: Warning Failed: Error: ImagePullBackOff
+func collectWarningEvents(ctx context.Context, applier provider.Applier, manifests []provider.Manifest) []string {
+	var (
+		seen    = make(map[provider.ResourceKey]struct{}, len(manifests))
+		targets = make([]provider.ResourceKey, 0, len(manifests))
+	)
+	add := func(k provider.ResourceKey) {
+		if _, ok := seen[k]; ok {
+			return
+		}
+		seen[k] = struct{}{}
+		targets = append(targets, k)
+	}
+	for _, m := range manifests {
+		add(m.Key)
+		if !m.Key.IsWorkload() {
+			continue
+		}
+		selector, err := m.GetNestedStringMap("spec", "selector", "matchLabels")
+		if err != nil || len(selector) == 0 {
+			continue
+		}
+		pods, err := applier.ListPods(ctx, m.Key.Namespace, selector)
+		if err != nil {
+			continue
+		}
+		for i, p := range pods {
+			if i >= maxEventPodsPerWorkload {
+				break
+			}
+			add(p.Key)
+		}
+	}
+
+	descs := make([]string, 0)
+	for _, k := range targets {
+		events, err := applier.ListEvents(ctx, k)
+		if err != nil {
+			continue
+		}
+		for _, e := range events {
+			if !provider.IsWarningEvent(e) {
+				continue
+			}
+			descs = append(descs, k.Kind+"/"+k.Name+": "+provider.DescribeEvent(e))
+		}
+	}
+	return descs
+}
+
+// reportWarningEvents writes the Warning events of the resources applied in this stage
+// into the stage log and attaches them to the stage metadata.
+func (e *deployExecutor) reportWarningEvents(ctx context.Context, recorder *appliedRecordingProvider) {
+	manifests := recorder.appliedManifests()
+	if len(manifests) == 0 {
+		return
+	}
+	events := collectWarningEvents(ctx, e.provider, manifests)
+	if len(events) == 0 {
+		return
+	}
+	if len(events) > maxWarningEvents {
+		events = events[:maxWarningEvents]
+	}
+
+	e.LogPersister.Infof("Found %d warning events of the applied resources:", len(events))
+	for _, ev := range events {
+		e.LogPersister.Info("  " + ev)
+	}
+	if err := e.StageMetadata().Put(ctx, warningEventsMetadataKey, strings.Join(events, "\n")); err != nil {
+		e.LogPersister.Errorf("Unable to save warning events to metadata (%v)", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes/providertest"
+)
+
+func TestCollectWarningEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manifests, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: simple
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: simple
+  namespace: default
+`)
+	require.NoError(t, err)
+
+	pods, err := provider.ParseManifests(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: simple-6d8b9c7f5d-abcde
+  namespace: default
+`)
+	require.NoError(t, err)
+
+	events, err := provider.ParseManifests(`
+apiVersion: v1
+kind: Event
+metadata:
+  name: simple-6d8b9c7f5d-abcde.1
+type: Warning
+reason: Failed
+message: "Error: ImagePullBackOff"
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: simple-6d8b9c7f5d-abcde.2
+type: Normal
+reason: Scheduled
+message: Successfully assigned default/simple-6d8b9c7f5d-abcde to node-1
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: simple-6d8b9c7f5d-abcde.3
+type: Warning
+reason: FailedScheduling
+message: 0/3 nodes are available
+`)
+	require.NoError(t, err)
+
+	p := providertest.NewMockProvider(ctrl)
+	p.EXPECT().ListPods(gomock.Any(), "default", map[string]string{"app": "simple"}).Return(pods, nil)
+	p.EXPECT().ListEvents(gomock.Any(), manifests[0].Key).Return(nil, nil)
+	p.EXPECT().ListEvents(gomock.Any(), manifests[1].Key).Return(nil, nil)
+	p.EXPECT().ListEvents(gomock.Any(), pods[0].Key).Return(events, nil)
+
+	// Applying the same manifest twice should not list its events twice.
+	got := collectWarningEvents(context.Background(), p, append(manifests, manifests[1]))
+	expected := []string{
+		"Pod/simple-6d8b9c7f5d-abcde: Warning Failed: Error: ImagePullBackOff",
+		"Pod/simple-6d8b9c7f5d-abcde: Warning FailedScheduling: 0/3 nodes are available",
+	}
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+)
+
+// pinImageDigests pins the images of the given manifests to the digests
+// those were resolved at planning time when imageDigests.pinManifests was configured.
+func (e *deployExecutor) pinImageDigests(manifests []provider.Manifest) error {
+	if !e.deployCfg.ImageDigests.PinManifests {
+		return nil
+	}
+	value, ok := e.MetadataStore.Get(provider.ImageDigestsMetadataKey)
+	if !ok {
+		e.LogPersister.Error("Unable to pin the images because their digests were not resolved while planning")
+		return fmt.Errorf("missing %s in deployment metadata", provider.ImageDigestsMetadataKey)
+	}
+	digests := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &digests); err != nil {
+		e.LogPersister.Errorf("Malformed image digests in deployment metadata (%v)", err)
+		return err
+	}
+
+	pinned, err := replaceImages(manifests, func(_ provider.Manifest, c provider.ContainerImage) (string, bool) {
+		p, ok := digests[c.Image]
+		return p, ok
+	})
+	if err != nil {
+		e.LogPersister.Errorf("Unable to pin the images to their digests (%v)", err)
+		return err
+	}
+	e.LogPersister.Infof("Pinned %d images to the digests resolved while planning", pinned)
+	return nil
+}
+
+// recordRunningImages records the images of the given workloads those are running in the cluster
+// before they are updated by this deployment, so that the rollback can restore the same images.
+// Nothing is done if they were already recorded by the previous stage.
+func (e *deployExecutor) recordRunningImages(ctx context.Context, manifests []provider.Manifest) error {
+	if !e.deployCfg.ImageDigests.PinManifests {
+		return nil
+	}
+	if _, ok := e.MetadataStore.Get(provider.RunningImagesMetadataKey); ok {
+		return nil
+	}
+
+	images := make(map[string]string)
+	for _, m := range manifests {
+		if m.GetContainerImages() == nil {
+			continue
+		}
+		live, err := e.provider.GetManifest(ctx, m.Key)
+		if errors.Is(err, provider.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			e.LogPersister.Errorf("Unable to get the running %s (%v)", m.Key.ReadableString(), err)
+			return err
+		}
+		for _, c := range live.GetContainerImages() {
+			images[runningImageKey(m, c)] = c.Image
+		}
+	}
+
+	data, err := json.Marshal(images)
+	if err != nil {
+		return err
+	}
+	if err := e.MetadataStore.Set(ctx, provider.RunningImagesMetadataKey, string(data)); err != nil {
+		e.LogPersister.Errorf("Unable to save the running images to deployment metadata (%v)", err)
+		return err
+	}
+	e.LogPersister.Infof("Recorded %d running images for rollback", len(images))
+	return nil
+}
+
+// pinRunningImages pins the images of the given manifests at the running commit
+// to the ones recorded before deploying. Only the images those were pinned from
+// the same tag by the previous deployment are restored.
+func pinRunningImages(manifests []provider.Manifest, ms executor.MetadataStore, lp executor.LogPersister) error {
+	value, ok := ms.Get(provider.RunningImagesMetadataKey)
+	if !ok {
+		return nil
+	}
+	images := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &images); err != nil {
+		lp.Errorf("Malformed running images in deployment metadata (%v)", err)
+		return err
+	}
+
+	pinned, err := replaceImages(manifests, func(m provider.Manifest, c provider.ContainerImage) (string, bool) {
+		running, ok := images[runningImageKey(m, c)]
+		if !ok || !strings.HasPrefix(running, c.Image+"@") {
+			return "", false
+		}
+		return running, true
+	})
+	if err != nil {
+		lp.Errorf("Unable to pin the images to the running ones (%v)", err)
+		return err
+	}
+	lp.Infof("Pinned %d images to the ones running before the deployment", pinned)
+	return nil
+}
+
+// replaceImages replaces the container images of all workloads in the given manifests
+// with the ones returned by the given function and returns the number of replaced images.
+func replaceImages(manifests []provider.Manifest, replace func(provider.Manifest, provider.ContainerImage) (string, bool)) (int, error) {
+	var replaced int
+	for _, m := range manifests {
+		err := m.ReplaceContainerImages(func(c provider.ContainerImage) string {
+			if image, ok := replace(m, c); ok {
+				replaced++
+				return image
+			}
+			return c.Image
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return replaced, nil
+}
+
+func runningImageKey(m provider.Manifest, c provider.ContainerImage) string {
+	return m.Key.Kind + "/" + m.Key.Name + "/" + c.Container
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+)
+
+type imagesMetadataStore struct {
+	fakeMetadataStore
+	metadata map[string]string
+}
+
+func (m *imagesMetadataStore) Get(key string) (string, bool) {
+	value, ok := m.metadata[key]
+	return value, ok
+}
+
+func TestPinRunningImages(t *testing.T) {
+	const data = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: gcr.io/pipecd/web:v1
+      - name: sidecar
+        image: gcr.io/pipecd/sidecar:v1
+`
+	testcases := []struct {
+		name        string
+		metadata    map[string]string
+		expected    []provider.ContainerImage
+		expectedErr bool
+	}{
+		{
+			name: "no running images",
+			expected: []provider.ContainerImage{
+				{Container: "web", Image: "gcr.io/pipecd/web:v1"},
+				{Container: "sidecar", Image: "gcr.io/pipecd/sidecar:v1"},
+			},
+		},
+		{
+			name: "only images pinned from the same tag are restored",
+			metadata: map[string]string{
+				provider.RunningImagesMetadataKey: `{"Deployment/web/web":"gcr.io/pipecd/web:v1@sha256:aaa","Deployment/web/sidecar":"gcr.io/pipecd/sidecar:v0@sha256:bbb"}`,
+			},
+			expected: []provider.ContainerImage{
+				{Container: "web", Image: "gcr.io/pipecd/web:v1@sha256:aaa"},
+				{Container: "sidecar", Image: "gcr.io/pipecd/sidecar:v1"},
+			},
+		},
+		{
+			name: "malformed running images",
+			metadata: map[string]string{
+				provider.RunningImagesMetadataKey: "malformed",
+			},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := provider.ParseManifests(data)
+			require.NoError(t, err)
+
+			ms := &imagesMetadataStore{metadata: tc.metadata}
+			err = pinRunningImages(manifests, ms, &fakeLogPersister{})
+			assert.Equal(t, tc.expectedErr, err != nil)
+			if tc.expectedErr {
+				return
+			}
+			assert.Equal(t, tc.expected, manifests[0].GetContainerImages())
+		})
+	}
+}
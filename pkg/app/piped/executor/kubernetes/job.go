@@ -0,0 +1,159 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+)
+
+const (
+	defaultJobCompletionTimeout = 30 * time.Minute
+	jobNameSuffixLength         = 8
+	// The label added to the pods of a Job by its controller.
+	jobNameLabel = "job-name"
+)
+
+var jobCheckInterval = 5 * time.Second
+
+// waitForJobs waits for the Jobs in the given manifests to be completed
+// when jobs.waitForCompletion was configured.
+func (e *deployExecutor) waitForJobs(ctx context.Context, manifests []provider.Manifest) error {
+	cfg := e.deployCfg.Jobs
+	if !cfg.WaitForCompletion {
+		return nil
+	}
+	jobs := findJobManifests(manifests)
+	return waitForJobs(ctx, e.provider, jobs, cfg.CompletionTimeout.Duration(), cfg.LogTailLines, e.LogPersister)
+}
+
+// findJobManifests returns all Job manifests in the given list.
+func findJobManifests(manifests []provider.Manifest) []provider.Manifest {
+	var jobs []provider.Manifest
+	for _, m := range manifests {
+		if m.Key.Kind == provider.KindJob {
+			jobs = append(jobs, m)
+		}
+	}
+	return jobs
+}
+
+// generateJobNameSuffixes returns a new list of manifests in which the name of every Job
+// is suffixed with a hash of its spec. Since the pod template of a Job is immutable,
+// this makes a changed Job to be created as a new one instead of failing to be updated.
+func generateJobNameSuffixes(manifests []provider.Manifest) ([]provider.Manifest, error) {
+	out := make([]provider.Manifest, 0, len(manifests))
+	for _, m := range manifests {
+		if m.Key.Kind != provider.KindJob {
+			out = append(out, m)
+			continue
+		}
+		spec, err := m.GetSpec()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get spec of %s: %w", m.Key.ReadableString(), err)
+		}
+		data, err := json.Marshal(spec)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal spec of %s: %w", m.Key.ReadableString(), err)
+		}
+		hash := sha256.Sum256(data)
+		suffix := hex.EncodeToString(hash[:])[:jobNameSuffixLength]
+		out = append(out, m.Duplicate(makeSuffixedName(m.Key.Name, suffix)))
+	}
+	return out, nil
+}
+
+// waitForJobs waits until all given Jobs are completed.
+// It returns an error as soon as any Job was found failed
+// or when they were not completed within the given timeout.
+// The last logTailLines lines of the logs of each finished Job are written to the log.
+func waitForJobs(ctx context.Context, applier provider.Applier, jobs []provider.Manifest, timeout time.Duration, logTailLines int, lp executor.LogPersister) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = defaultJobCompletionTimeout
+	}
+	lp.Infof("Waiting for %d jobs to be completed", len(jobs))
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(jobCheckInterval)
+	defer ticker.Stop()
+
+	pendings := jobs
+	for {
+		var running []provider.Manifest
+		for _, j := range pendings {
+			live, err := applier.GetManifest(ctx, j.Key)
+			if err != nil {
+				running = append(running, j)
+				continue
+			}
+			done, err := provider.CheckJobStatus(live)
+			if err != nil {
+				lp.Errorf("Job %s failed (%v)", j.Key.ReadableString(), err)
+				showJobLogs(ctx, applier, j, logTailLines, lp)
+				return err
+			}
+			if !done {
+				running = append(running, j)
+				continue
+			}
+			lp.Successf("- job %s was completed", j.Key.ReadableString())
+			showJobLogs(ctx, applier, j, logTailLines, lp)
+		}
+		if len(running) == 0 {
+			lp.Successf("All %d jobs were completed", len(jobs))
+			return nil
+		}
+		pendings = running
+
+		select {
+		case <-ctx.Done():
+			lp.Errorf("Timed out waiting for %d jobs to be completed", len(pendings))
+			return fmt.Errorf("%d jobs were not completed within %v", len(pendings), timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// showJobLogs writes the last lines of the logs of all pods of the given Job to the log.
+func showJobLogs(ctx context.Context, applier provider.Applier, job provider.Manifest, tailLines int, lp executor.LogPersister) {
+	if tailLines <= 0 {
+		return
+	}
+	pods, err := applier.ListPods(ctx, job.Key.Namespace, map[string]string{jobNameLabel: job.Key.Name})
+	if err != nil {
+		lp.Errorf("Unable to list pods of %s (%v)", job.Key.ReadableString(), err)
+		return
+	}
+	for _, p := range pods {
+		logs, err := applier.GetPodLogs(ctx, p.Key, tailLines)
+		if err != nil {
+			lp.Errorf("Unable to get logs of %s (%v)", p.Key.ReadableString(), err)
+			continue
+		}
+		lp.Infof("Last %d lines of logs of %s:\n%s", tailLines, p.Key.ReadableString(), logs)
+	}
+}
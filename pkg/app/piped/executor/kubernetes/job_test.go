@@ -0,0 +1,174 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes/providertest"
+)
+
+func TestGenerateJobNameSuffixes(t *testing.T) {
+	manifests, err := provider.ParseManifests(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: migrate:v1
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: migrate:v2
+`)
+	require.NoError(t, err)
+
+	got, err := generateJobNameSuffixes(manifests)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(got))
+
+	assert.Equal(t, "config", got[0].Key.Name)
+	assert.Regexp(t, "^migrate-[0-9a-f]{8}$", got[1].Key.Name)
+	assert.Regexp(t, "^migrate-[0-9a-f]{8}$", got[2].Key.Name)
+	assert.NotEqual(t, got[1].Key.Name, got[2].Key.Name)
+	// The original manifests must not be changed.
+	assert.Equal(t, "migrate", manifests[1].Key.Name)
+
+	// The same spec always produces the same name.
+	again, err := generateJobNameSuffixes(manifests)
+	require.NoError(t, err)
+	assert.Equal(t, got[1].Key.Name, again[1].Key.Name)
+}
+
+func TestWaitForJobs(t *testing.T) {
+	jobs, err := provider.ParseManifests(`
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: default
+`)
+	require.NoError(t, err)
+
+	lives, err := provider.ParseManifests(`
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: default
+status:
+  active: 1
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: default
+status:
+  succeeded: 1
+  conditions:
+  - type: Complete
+    status: "True"
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: default
+status:
+  failed: 1
+  conditions:
+  - type: Failed
+    status: "True"
+    message: Job has reached the specified backoff limit
+`)
+	require.NoError(t, err)
+
+	pods, err := provider.ParseManifests(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: migrate-abcde
+  namespace: default
+`)
+	require.NoError(t, err)
+
+	interval := jobCheckInterval
+	jobCheckInterval = time.Millisecond
+	defer func() { jobCheckInterval = interval }()
+
+	t.Run("completed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		gomock.InOrder(
+			p.EXPECT().GetManifest(gomock.Any(), jobs[0].Key).Return(lives[0], nil),
+			p.EXPECT().GetManifest(gomock.Any(), jobs[0].Key).Return(lives[1], nil),
+		)
+
+		err := waitForJobs(context.Background(), p, jobs, time.Minute, 0, &fakeLogPersister{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("failed with logs", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		gomock.InOrder(
+			p.EXPECT().GetManifest(gomock.Any(), jobs[0].Key).Return(lives[2], nil),
+			p.EXPECT().ListPods(gomock.Any(), "default", map[string]string{"job-name": "migrate"}).Return(pods, nil),
+			p.EXPECT().GetPodLogs(gomock.Any(), pods[0].Key, 20).Return("error: connection refused", nil),
+		)
+
+		err := waitForJobs(context.Background(), p, jobs, time.Minute, 20, &fakeLogPersister{})
+		assert.Error(t, err)
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		p.EXPECT().GetManifest(gomock.Any(), jobs[0].Key).Return(lives[0], nil).MinTimes(1)
+
+		err := waitForJobs(context.Background(), p, jobs, 20*time.Millisecond, 0, &fakeLogPersister{})
+		assert.Error(t, err)
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -27,21 +28,34 @@ import (
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/app/piped/ratelimiter"
+	"github.com/pipe-cd/pipe/pkg/backoff"
 	"github.com/pipe-cd/pipe/pkg/cache"
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 const (
-	variantLabel = "pipecd.dev/variant" // Variant name: primary, stage, baseline
+	defaultWaveReadinessTimeout = 5 * time.Minute
+	noMatchesForKindRetryTimes  = 10
+)
+
+// These are variables to be shortened in tests.
+var (
+	// The interval between the readiness checks of the resources in a wave.
+	waveReadinessCheckInterval = 5 * time.Second
+	// The interval between the retries of applying a resource whose kind is not registered yet.
+	noMatchesForKindRetryInterval = 3 * time.Second
 )
 
 type deployExecutor struct {
 	executor.Input
 
-	commit    string
-	deployCfg *config.KubernetesDeploymentSpec
-	provider  provider.Provider
+	commit             string
+	deployCfg          *config.KubernetesDeploymentSpec
+	provider           provider.Provider
+	variantLabel       string
+	managedAnnotations provider.ManagedAnnotations
 }
 
 type registerer interface {
@@ -64,6 +78,7 @@ func Register(r registerer) {
 	r.Register(model.StageK8sBaselineRollout, f)
 	r.Register(model.StageK8sBaselineClean, f)
 	r.Register(model.StageK8sTrafficRouting, f)
+	r.Register(model.StageK8sPartitionRollout, f)
 
 	r.RegisterRollback(model.ApplicationKind_KUBERNETES, func(in executor.Input) executor.Executor {
 		return &rollbackExecutor{
@@ -88,7 +103,14 @@ func (e *deployExecutor) Execute(sig executor.StopSignal) model.StageStatus {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	e.provider = provider.NewProvider(e.Deployment.ApplicationName, ds.AppDir, ds.RepoDir, e.Deployment.GitPath.ConfigFilename, e.deployCfg.Input, e.Logger)
+	e.provider = provider.NewProvider(e.Deployment.ApplicationName, ds.AppDir, ds.RepoDir, e.Deployment.GitPath.ConfigFilename, e.deployCfg.Input, e.Logger, provider.WithHelmPostRenderer(e.PipedConfig.AllowHelmPostRenderer), provider.WithGitRepositories(e.PipedConfig.Repositories))
+	e.provider = provider.WithRateLimiter(e.provider, ratelimiter.DefaultRegistry().Limiter(e.Deployment.CloudProvider))
+	cpCfg := findKubernetesConfig(e.PipedConfig, e.Deployment.CloudProvider)
+	e.provider = provider.WithAllowedNamespaces(e.provider, e.deployCfg.Input.Namespace, cpCfg.AllowedNamespaces, cpCfg.AllowClusterScopedResources)
+	recorder := &appliedRecordingProvider{Provider: e.provider}
+	e.provider = recorder
+	e.variantLabel = cpCfg.GetVariantLabel()
+	e.managedAnnotations = provider.NewManagedAnnotations(cpCfg.GetAnnotationPrefix())
 	e.Logger.Info("start executing kubernetes stage",
 		zap.String("stage-name", e.Stage.Name),
 		zap.String("app-dir", ds.AppDir),
@@ -119,14 +141,23 @@ func (e *deployExecutor) Execute(sig executor.StopSignal) model.StageStatus {
 		status = e.ensureBaselineClean(ctx)
 
 	case model.StageK8sTrafficRouting:
-		status = e.ensureTrafficRouting(ctx)
+		status = e.ensureTrafficRouting(sig)
+
+	case model.StageK8sPartitionRollout:
+		status = e.ensurePartitionRollout(ctx)
 
 	default:
 		e.LogPersister.Errorf("Unsupported stage %s for kubernetes application", e.Stage.Name)
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	return executor.DetermineStageStatus(sig.Signal(), originalStatus, status)
+	status = executor.DetermineStageStatus(sig.Signal(), originalStatus, status)
+	// Attach the events of the applied resources to help finding the cause of the failure
+	// such as scheduling or image pulling errors.
+	if status == model.StageStatus_STAGE_FAILURE && ctx.Err() == nil {
+		e.reportWarningEvents(ctx, recorder)
+	}
+	return status
 }
 
 func (e *deployExecutor) loadRunningManifests(ctx context.Context) (manifests []provider.Manifest, err error) {
@@ -150,6 +181,8 @@ func (e *deployExecutor) loadRunningManifests(ctx context.Context) (manifests []
 				e.Deployment.GitPath.ConfigFilename,
 				e.deployCfg.Input,
 				e.Logger,
+				provider.WithHelmPostRenderer(e.PipedConfig.AllowHelmPostRenderer),
+				provider.WithGitRepositories(e.PipedConfig.Repositories),
 			)
 			return loader.LoadManifests(ctx)
 		},
@@ -172,51 +205,171 @@ func loadManifests(ctx context.Context, appID, commit string, manifestsCache cac
 		Cache:  manifestsCache,
 		Logger: logger,
 	}
-	manifests, ok := cache.Get(commit)
-	if ok {
-		return manifests, nil
-	}
-
 	// When the manifests were not in the cache we have to load them.
-	if manifests, err = loader.LoadManifests(ctx); err != nil {
-		return nil, err
-	}
-	cache.Put(commit, manifests)
+	return cache.GetOrLoad(commit, func() ([]provider.Manifest, error) {
+		return loader.LoadManifests(ctx)
+	})
+}
 
-	return manifests, nil
+// findKubernetesConfig returns the configuration of the given cloud provider.
+// An empty one is returned when it was not found.
+func findKubernetesConfig(cfg *config.PipedSpec, cloudProvider string) *config.CloudProviderKubernetesConfig {
+	cp, ok := cfg.FindCloudProvider(cloudProvider, model.CloudProviderKubernetes)
+	if !ok || cp.KubernetesConfig == nil {
+		return &config.CloudProviderKubernetesConfig{}
+	}
+	return cp.KubernetesConfig
 }
 
-func addBuiltinAnnontations(manifests []provider.Manifest, variant, hash, pipedID, appID string) {
+func addBuiltinAnnontations(manifests []provider.Manifest, keys provider.ManagedAnnotations, variantLabel, variant, hash, pipedID, appID string) {
 	for i := range manifests {
 		manifests[i].AddAnnotations(map[string]string{
-			provider.LabelManagedBy:          provider.ManagedByPiped,
-			provider.LabelPiped:              pipedID,
-			provider.LabelApplication:        appID,
-			variantLabel:                     variant,
-			provider.LabelOriginalAPIVersion: manifests[i].Key.APIVersion,
-			provider.LabelResourceKey:        manifests[i].Key.String(),
-			provider.LabelCommitHash:         hash,
+			keys.ManagedBy:          provider.ManagedByPiped,
+			keys.Piped:              pipedID,
+			keys.Application:        appID,
+			variantLabel:            variant,
+			keys.OriginalAPIVersion: manifests[i].Key.APIVersion,
+			keys.ResourceKey:        manifests[i].Key.String(),
+			keys.CommitHash:         hash,
 		})
 	}
 }
 
-func applyManifests(ctx context.Context, applier provider.Applier, manifests []provider.Manifest, namespace string, lp executor.LogPersister) error {
+func applyManifests(ctx context.Context, applier provider.Applier, manifests []provider.Manifest, namespace string, waves config.KubernetesApplyWaves, lp executor.LogPersister) error {
 	if namespace == "" {
 		lp.Infof("Start applying %d manifests", len(manifests))
 	} else {
 		lp.Infof("Start applying %d manifests to %q namespace", len(manifests), namespace)
 	}
-	for _, m := range manifests {
-		if err := applier.ApplyManifest(ctx, m); err != nil {
-			lp.Errorf("Failed to apply manifest: %s (%v)", m.Key.ReadableString(), err)
+
+	groups, err := provider.GroupManifestsByWave(manifests, waves.Kinds)
+	if err != nil {
+		lp.Errorf("Failed to determine the apply waves of manifests (%v)", err)
+		return err
+	}
+
+	for i, g := range groups {
+		if len(groups) > 1 {
+			lp.Infof("Applying %d manifests of wave %d", len(g.Manifests), g.Wave)
+		}
+		for _, m := range g.Manifests {
+			if err := applyManifest(ctx, applier, m, lp); err != nil {
+				lp.Errorf("Failed to apply manifest: %s (%v)", m.Key.ReadableString(), err)
+				return err
+			}
+			lp.Successf("- applied manifest: %s", m.Key.ReadableString())
+		}
+
+		// No need to wait for the last wave since nothing depends on it.
+		if i == len(groups)-1 {
+			continue
+		}
+		// The CRDs must always be established before applying
+		// the next waves since they may contain their custom resources.
+		targets := g.Manifests
+		if waves.SkipReadinessCheck {
+			targets = filterCustomResourceDefinitions(g.Manifests)
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		if err := waitForReady(ctx, applier, g.Wave, targets, waves.ReadinessTimeout.Duration(), lp); err != nil {
 			return err
 		}
-		lp.Successf("- applied manifest: %s", m.Key.ReadableString())
 	}
 	lp.Successf("Successfully applied %d manifests", len(manifests))
 	return nil
 }
 
+// applyManifestsByKapp applies all the given manifests at once as a kapp application.
+// kapp orders the changes by itself, waits until they become ready and
+// deletes the resources those are no longer included in the given manifests.
+func applyManifestsByKapp(ctx context.Context, applier provider.Applier, manifests []provider.Manifest, lp executor.LogPersister) error {
+	lp.Infof("Start applying %d manifests by kapp", len(manifests))
+	out, err := applier.Apply(ctx, manifests)
+	if out != "" {
+		lp.Info(out)
+	}
+	if err != nil {
+		lp.Errorf("Failed to apply manifests by kapp (%v)", err)
+		return err
+	}
+	lp.Successf("Successfully applied %d manifests by kapp", len(manifests))
+	return nil
+}
+
+// applyManifest applies the given manifest while retrying on the error
+// caused by the missing kind since its CRD may be being established.
+func applyManifest(ctx context.Context, applier provider.Applier, m provider.Manifest, lp executor.LogPersister) error {
+	retry := backoff.NewRetry(noMatchesForKindRetryTimes, backoff.NewConstant(noMatchesForKindRetryInterval))
+	for {
+		err := applier.ApplyManifest(ctx, m)
+		if !errors.Is(err, provider.ErrNoMatchesForKind) {
+			return err
+		}
+		lp.Infof("- kind %s is not registered yet, will retry applying %s", m.Key.Kind, m.Key.ReadableString())
+		if !retry.WaitNext(ctx) {
+			return err
+		}
+	}
+}
+
+func filterCustomResourceDefinitions(manifests []provider.Manifest) []provider.Manifest {
+	crds := make([]provider.Manifest, 0, len(manifests))
+	for _, m := range manifests {
+		if m.Key.Kind == provider.KindCustomResourceDefinition {
+			crds = append(crds, m)
+		}
+	}
+	return crds
+}
+
+// waitForReady waits until all resources of the given manifests become ready.
+func waitForReady(ctx context.Context, applier provider.Applier, wave int, manifests []provider.Manifest, timeout time.Duration, lp executor.LogPersister) error {
+	if timeout == 0 {
+		timeout = defaultWaveReadinessTimeout
+	}
+	lp.Infof("Waiting for %d resources of wave %d to become ready", len(manifests), wave)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(waveReadinessCheckInterval)
+	defer ticker.Stop()
+
+	pendings := manifests
+	for {
+		var (
+			notReady []provider.Manifest
+			reason   string
+		)
+		for _, m := range pendings {
+			live, err := applier.GetManifest(ctx, m.Key)
+			if err != nil {
+				notReady = append(notReady, m)
+				reason = fmt.Sprintf("unable to get %s (%v)", m.Key.ReadableString(), err)
+				continue
+			}
+			if ok, desc := provider.IsManifestReady(live); !ok {
+				notReady = append(notReady, m)
+				reason = desc
+			}
+		}
+		if len(notReady) == 0 {
+			lp.Successf("All resources of wave %d are ready", wave)
+			return nil
+		}
+		pendings = notReady
+
+		select {
+		case <-ctx.Done():
+			lp.Errorf("Timed out waiting for %d resources of wave %d to become ready: %s", len(pendings), wave, reason)
+			return fmt.Errorf("resources of wave %d did not become ready: %s", wave, reason)
+		case <-ticker.C:
+		}
+	}
+}
+
 func deleteResources(ctx context.Context, applier provider.Applier, resources []provider.ResourceKey, lp executor.LogPersister) error {
 	resourcesLen := len(resources)
 	if resourcesLen == 0 {
@@ -251,6 +404,29 @@ func deleteResources(ctx context.Context, applier provider.Applier, resources []
 	return nil
 }
 
+// filterOwnedResources returns only the resources those are currently running
+// and annotated as being managed by piped for the given application.
+func filterOwnedResources(ctx context.Context, applier provider.Applier, resources []provider.ResourceKey, keys provider.ManagedAnnotations, appID string, lp executor.LogPersister) ([]provider.ResourceKey, error) {
+	owned := make([]provider.ResourceKey, 0, len(resources))
+	for _, k := range resources {
+		m, err := applier.GetManifest(ctx, k)
+		if errors.Is(err, provider.ErrNotFound) {
+			lp.Infof("- no resource %s to delete", k.ReadableString())
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to get resource %s (%w)", k.ReadableString(), err)
+		}
+		annotations := m.GetAnnotations()
+		if annotations[keys.ManagedBy] != provider.ManagedByPiped || annotations[keys.Application] != appID {
+			lp.Infof("- skipped resource %s since it is not managed by this application", k.ReadableString())
+			continue
+		}
+		owned = append(owned, k)
+	}
+	return owned, nil
+}
+
 func findManifests(kind, name string, manifests []provider.Manifest) []provider.Manifest {
 	var out []provider.Manifest
 	for _, m := range manifests {
@@ -317,7 +493,7 @@ func duplicateManifest(m provider.Manifest, nameSuffix string) provider.Manifest
 	return m.Duplicate(name)
 }
 
-func generateVariantServiceManifests(services []provider.Manifest, variant, nameSuffix string) ([]provider.Manifest, error) {
+func generateVariantServiceManifests(services []provider.Manifest, variantLabel, variant, nameSuffix string) ([]provider.Manifest, error) {
 	manifests := make([]provider.Manifest, 0, len(services))
 	updateService := func(s *corev1.Service) {
 		s.Name = makeSuffixedName(s.Name, nameSuffix)
@@ -350,7 +526,7 @@ func generateVariantServiceManifests(services []provider.Manifest, variant, name
 	return manifests, nil
 }
 
-func generateVariantWorkloadManifests(workloads, configmaps, secrets []provider.Manifest, variant, nameSuffix string, replicasCalculator func(*int32) int32) ([]provider.Manifest, error) {
+func generateVariantWorkloadManifests(workloads, configmaps, secrets []provider.Manifest, variantLabel, variant, nameSuffix string, replicasCalculator func(*int32) int32) ([]provider.Manifest, error) {
 	manifests := make([]provider.Manifest, 0, len(workloads))
 
 	cmNames := make(map[string]struct{}, len(configmaps))
@@ -417,7 +593,7 @@ func generateVariantWorkloadManifests(workloads, configmaps, secrets []provider.
 	return manifests, nil
 }
 
-func checkVariantSelectorInWorkload(m provider.Manifest, variant string) error {
+func checkVariantSelectorInWorkload(m provider.Manifest, variantLabel, variant string) error {
 	var (
 		matchLabelsFields = []string{"spec", "selector", "matchLabels"}
 		labelsFields      = []string{"spec", "template", "metadata", "labels"}
@@ -450,7 +626,7 @@ func checkVariantSelectorInWorkload(m provider.Manifest, variant string) error {
 	return nil
 }
 
-func ensureVariantSelectorInWorkload(m provider.Manifest, variant string) error {
+func ensureVariantSelectorInWorkload(m provider.Manifest, variantLabel, variant string) error {
 	variantMap := map[string]string{
 		variantLabel: variant,
 	}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,7 @@ import (
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes/providertest"
+	"github.com/pipe-cd/pipe/pkg/config"
 )
 
 type fakeLogPersister struct{}
@@ -45,6 +47,9 @@ func (m *fakeMetadataStore) GetStageMetadata(_ string) (map[string]string, bool)
 func (m *fakeMetadataStore) SetStageMetadata(_ context.Context, _ string, _ map[string]string) error {
 	return nil
 }
+func (m *fakeMetadataStore) PutStageMetadata(_ context.Context, _ string, _ map[string]string) error {
+	return nil
+}
 
 func TestGenerateServiceManifests(t *testing.T) {
 	testcases := []struct {
@@ -62,7 +67,7 @@ func TestGenerateServiceManifests(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, 2, len(manifests))
 
-			generatedManifests, err := generateVariantServiceManifests(manifests[:1], "canary-variant", "canary")
+			generatedManifests, err := generateVariantServiceManifests(manifests[:1], config.DefaultKubernetesVariantLabel, "canary-variant", "canary")
 			require.NoError(t, err)
 			require.Equal(t, 1, len(generatedManifests))
 
@@ -105,7 +110,7 @@ func TestGenerateWorkloadManifests(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			generatedManifests, err := generateVariantWorkloadManifests(manifests[:1], configmaps, secrets, "canary-variant", "canary", func(r *int32) int32 {
+			generatedManifests, err := generateVariantWorkloadManifests(manifests[:1], configmaps, secrets, config.DefaultKubernetesVariantLabel, "canary-variant", "canary", func(r *int32) int32 {
 				return *r - 1
 			})
 			require.NoError(t, err)
@@ -246,10 +251,10 @@ spec:
 			require.NoError(t, err)
 			require.Equal(t, 1, len(manifests))
 
-			err = checkVariantSelectorInWorkload(manifests[0], primaryVariant)
+			err = checkVariantSelectorInWorkload(manifests[0], config.DefaultKubernetesVariantLabel, primaryVariant)
 			assert.Equal(t, tc.expected, err)
 
-			err = ensureVariantSelectorInWorkload(manifests[0], primaryVariant)
+			err = ensureVariantSelectorInWorkload(manifests[0], config.DefaultKubernetesVariantLabel, primaryVariant)
 			assert.NoError(t, err)
 			assert.Equal(t, generatedManifests[0], manifests[0])
 		})
@@ -323,3 +328,165 @@ func TestDeleteResources(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterOwnedResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manifests, err := provider.ParseManifests(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: owned
+  annotations:
+    pipecd.dev/managed-by: piped
+    pipecd.dev/application: app-1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other-app
+  annotations:
+    pipecd.dev/managed-by: piped
+    pipecd.dev/application: app-2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unmanaged
+`)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(manifests))
+
+	missing := provider.ResourceKey{APIVersion: "v1", Kind: "ConfigMap", Name: "missing"}
+	p := providertest.NewMockProvider(ctrl)
+	for _, m := range manifests {
+		p.EXPECT().GetManifest(gomock.Any(), m.Key).Return(m, nil)
+	}
+	p.EXPECT().GetManifest(gomock.Any(), missing).Return(provider.Manifest{}, provider.ErrNotFound)
+
+	keys := []provider.ResourceKey{manifests[0].Key, manifests[1].Key, manifests[2].Key, missing}
+	got, err := filterOwnedResources(context.Background(), p, keys, provider.DefaultManagedAnnotations, "app-1", &fakeLogPersister{})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.ResourceKey{manifests[0].Key}, got)
+
+	p.EXPECT().GetManifest(gomock.Any(), missing).Return(provider.Manifest{}, fmt.Errorf("unexpected error"))
+	_, err = filterOwnedResources(context.Background(), p, []provider.ResourceKey{missing}, provider.DefaultManagedAnnotations, "app-1", &fakeLogPersister{})
+	assert.Error(t, err)
+}
+
+func TestApplyManifestsInWaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manifests, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+`)
+	require.NoError(t, err)
+
+	established, err := provider.ParseManifests(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+status:
+  conditions:
+  - type: Established
+    status: "True"
+`)
+	require.NoError(t, err)
+
+	interval := waveReadinessCheckInterval
+	waveReadinessCheckInterval = time.Millisecond
+	defer func() { waveReadinessCheckInterval = interval }()
+
+	p := providertest.NewMockProvider(ctrl)
+	gomock.InOrder(
+		p.EXPECT().ApplyManifest(gomock.Any(), manifests[1]).Return(nil),
+		p.EXPECT().GetManifest(gomock.Any(), manifests[1].Key).Return(provider.Manifest{}, provider.ErrNotFound),
+		p.EXPECT().GetManifest(gomock.Any(), manifests[1].Key).Return(established[0], nil),
+		p.EXPECT().ApplyManifest(gomock.Any(), manifests[0]).Return(nil),
+	)
+
+	err = applyManifests(context.Background(), p, manifests, "", config.KubernetesApplyWaves{}, &fakeLogPersister{})
+	assert.NoError(t, err)
+}
+
+func TestApplyManifestsRetryNoMatchesForKind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manifests, err := provider.ParseManifests(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: cert
+`)
+	require.NoError(t, err)
+
+	established, err := provider.ParseManifests(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+status:
+  conditions:
+  - type: Established
+    status: "True"
+`)
+	require.NoError(t, err)
+
+	interval := noMatchesForKindRetryInterval
+	noMatchesForKindRetryInterval = time.Millisecond
+	defer func() { noMatchesForKindRetryInterval = interval }()
+
+	p := providertest.NewMockProvider(ctrl)
+	gomock.InOrder(
+		p.EXPECT().ApplyManifest(gomock.Any(), manifests[0]).Return(nil),
+		// The CRD must be waited even though the readiness check is skipped.
+		p.EXPECT().GetManifest(gomock.Any(), manifests[0].Key).Return(established[0], nil),
+		p.EXPECT().ApplyManifest(gomock.Any(), manifests[1]).Return(fmt.Errorf("failed to apply: (%w)", provider.ErrNoMatchesForKind)),
+		p.EXPECT().ApplyManifest(gomock.Any(), manifests[1]).Return(nil),
+	)
+
+	waves := config.KubernetesApplyWaves{
+		SkipReadinessCheck: true,
+	}
+	err = applyManifests(context.Background(), p, manifests, "", waves, &fakeLogPersister{})
+	assert.NoError(t, err)
+}
+
+func TestApplyManifestsByKapp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manifests, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`)
+	require.NoError(t, err)
+
+	p := providertest.NewMockProvider(ctrl)
+	p.EXPECT().Apply(gomock.Any(), manifests).Return("Changes\n", nil)
+	err = applyManifestsByKapp(context.Background(), p, manifests, &fakeLogPersister{})
+	assert.NoError(t, err)
+
+	p.EXPECT().Apply(gomock.Any(), manifests).Return("", fmt.Errorf("timed out waiting"))
+	err = applyManifestsByKapp(context.Background(), p, manifests, &fakeLogPersister{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func (e *deployExecutor) ensurePartitionRollout(ctx context.Context) model.StageStatus {
+	options := e.StageConfig.K8sPartitionRolloutStageOptions
+	if options == nil {
+		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	// Load the manifests at the triggered commit.
+	e.LogPersister.Infof("Loading manifests at trigered commit %s for handling", e.commit)
+	manifests, err := loadManifests(
+		ctx,
+		e.Deployment.ApplicationId,
+		e.commit,
+		e.AppManifestsCache,
+		e.provider,
+		e.Logger,
+	)
+	if err != nil {
+		e.LogPersister.Errorf("Failed while loading manifests (%v)", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	e.LogPersister.Successf("Successfully loaded %d manifests", len(manifests))
+
+	// Because the loaded manifests are read-only
+	// we duplicate them to avoid updating the shared manifests data in cache.
+	manifests = duplicateManifests(manifests, "")
+
+	if e.deployCfg.Jobs.GenerateNameSuffix {
+		if manifests, err = generateJobNameSuffixes(manifests); err != nil {
+			e.LogPersister.Errorf("Unable to generate name suffixes for jobs (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+
+	statefulSets := findStatefulSetManifests(manifests, e.deployCfg.Workloads)
+	if len(statefulSets) == 0 {
+		e.LogPersister.Error("Unable to find any StatefulSet workload to roll out")
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	e.LogPersister.Infof("Start generating manifests with partition %s", options.Partition.String())
+	if manifests, err = generatePartitionedManifests(manifests, statefulSets, options.Partition); err != nil {
+		e.LogPersister.Errorf("Unable to set partition to the StatefulSet workloads (%v)", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	statefulSets = findStatefulSetManifests(manifests, e.deployCfg.Workloads)
+
+	// Pin the images to the digests resolved while planning.
+	if err := e.pinImageDigests(manifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+	if err := e.recordRunningImages(ctx, manifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	// Add builtin annotations for tracking application live state.
+	addBuiltinAnnontations(
+		manifests,
+		e.managedAnnotations,
+		e.variantLabel,
+		primaryVariant,
+		e.commit,
+		e.PipedConfig.PipedID,
+		e.Deployment.ApplicationId,
+	)
+
+	// Start applying all manifests to add or update running resources.
+	e.LogPersister.Info("Start rolling out the partitioned StatefulSet workloads...")
+	if err := applyManifests(ctx, e.provider, manifests, e.deployCfg.Input.Namespace, e.deployCfg.Input.ApplyWaves, e.LogPersister); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	if err := waitForRollout(ctx, e.provider, statefulSets, options.RolloutTimeout.Duration(), e.LogPersister); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+	e.LogPersister.Successf("Successfully rolled out the pods with ordinal greater than or equal to partition %s", options.Partition.String())
+
+	if err := e.waitForJobs(ctx, manifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	return model.StageStatus_STAGE_SUCCESS
+}
+
+// findStatefulSetManifests returns the StatefulSet workloads.
+// All StatefulSets are returned when no StatefulSet was specified in the given references.
+func findStatefulSetManifests(manifests []provider.Manifest, refs []config.K8sResourceReference) []provider.Manifest {
+	statefulSets := make([]provider.Manifest, 0)
+	for _, ref := range refs {
+		if ref.Kind != provider.KindStatefulSet {
+			continue
+		}
+		statefulSets = append(statefulSets, findManifests(provider.KindStatefulSet, ref.Name, manifests)...)
+	}
+	if len(statefulSets) > 0 {
+		return statefulSets
+	}
+	return findManifests(provider.KindStatefulSet, "", manifests)
+}
+
+// generatePartitionedManifests returns a new list of manifests in which the given StatefulSets
+// are configured to be updated by RollingUpdate strategy with the given partition.
+func generatePartitionedManifests(manifests, statefulSets []provider.Manifest, partition config.Replicas) ([]provider.Manifest, error) {
+	partitioned := make(map[provider.ResourceKey]provider.Manifest, len(statefulSets))
+	for _, m := range statefulSets {
+		s := &appsv1.StatefulSet{}
+		if err := m.ConvertToStructuredObject(s); err != nil {
+			return nil, err
+		}
+
+		replicas := 1
+		if s.Spec.Replicas != nil {
+			replicas = int(*s.Spec.Replicas)
+		}
+		p := partition.Calculate(replicas, 0)
+		if p < 0 {
+			return nil, fmt.Errorf("partition of %s must not be negative", m.Key.ReadableString())
+		}
+		if p > replicas {
+			p = replicas
+		}
+		p32 := int32(p)
+
+		s.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+			Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+				Partition: &p32,
+			},
+		}
+		manifest, err := provider.ParseFromStructuredObject(s)
+		if err != nil {
+			return nil, err
+		}
+		partitioned[m.Key] = manifest
+	}
+
+	out := make([]provider.Manifest, 0, len(manifests))
+	for _, m := range manifests {
+		if p, ok := partitioned[m.Key]; ok {
+			out = append(out, p)
+			continue
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestFindStatefulSetManifests(t *testing.T) {
+	manifests, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: cache
+`)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name     string
+		refs     []config.K8sResourceReference
+		expected []string
+	}{
+		{
+			name:     "no reference",
+			expected: []string{"db", "cache"},
+		},
+		{
+			name: "only deployment references",
+			refs: []config.K8sResourceReference{
+				{Kind: "Deployment", Name: "web"},
+			},
+			expected: []string{"db", "cache"},
+		},
+		{
+			name: "statefulset reference",
+			refs: []config.K8sResourceReference{
+				{Kind: "Deployment", Name: "web"},
+				{Kind: "StatefulSet", Name: "cache"},
+			},
+			expected: []string{"cache"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := findStatefulSetManifests(manifests, tc.refs)
+			names := make([]string, 0, len(got))
+			for _, m := range got {
+				names = append(names, m.Key.Name)
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestGeneratePartitionedManifests(t *testing.T) {
+	manifests, err := provider.ParseManifests(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db-config
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  replicas: 5
+  serviceName: db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+      - name: db
+        image: db:v2
+`)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name      string
+		partition config.Replicas
+		expected  int64
+	}{
+		{
+			name:      "absolute ordinal",
+			partition: config.Replicas{Number: 3},
+			expected:  3,
+		},
+		{
+			name:      "percentage",
+			partition: config.Replicas{Number: 50, IsPercentage: true},
+			expected:  3,
+		},
+		{
+			name:      "greater than replicas",
+			partition: config.Replicas{Number: 10},
+			expected:  5,
+		},
+		{
+			name:     "all pods",
+			expected: 0,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := generatePartitionedManifests(manifests, manifests[1:], tc.partition)
+			require.NoError(t, err)
+			require.Equal(t, 2, len(got))
+			assert.Equal(t, manifests[0], got[0])
+
+			strategy, err := got[1].GetNestedMap("spec", "updateStrategy")
+			require.NoError(t, err)
+			assert.Equal(t, "RollingUpdate", strategy["type"])
+			assert.Equal(t, map[string]interface{}{"partition": tc.expected}, strategy["rollingUpdate"])
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+)
+
+var podFailureCheckInterval = 10 * time.Second
+
+// watchPodFailures watches the pods of the given workloads during the given duration
+// and returns an error as soon as any of them was found failing.
+// The events of the failing pod are written to the log to help finding the cause.
+func watchPodFailures(ctx context.Context, applier provider.Applier, workloads []provider.Manifest, duration time.Duration, lp executor.LogPersister) error {
+	type target struct {
+		workload  provider.Manifest
+		namespace string
+		selector  map[string]string
+	}
+	targets := make([]target, 0, len(workloads))
+	for _, w := range workloads {
+		selector, err := w.GetNestedStringMap("spec", "selector", "matchLabels")
+		if err != nil || len(selector) == 0 {
+			lp.Infof("Skipped watching pods of %s because its selector was not found", w.Key.ReadableString())
+			continue
+		}
+		targets = append(targets, target{
+			workload:  w,
+			namespace: w.Key.Namespace,
+			selector:  selector,
+		})
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	lp.Infof("Watching pods of %d workloads for failures during %v", len(targets), duration)
+
+	watchCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	ticker := time.NewTicker(podFailureCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, t := range targets {
+			pods, err := applier.ListPods(watchCtx, t.namespace, t.selector)
+			if err != nil {
+				if watchCtx.Err() != nil {
+					break
+				}
+				lp.Errorf("Unable to list pods of %s (%v)", t.workload.Key.ReadableString(), err)
+				continue
+			}
+			for _, p := range pods {
+				desc, failed := provider.FindPodFailure(p)
+				if !failed {
+					continue
+				}
+				lp.Errorf("Found a failing pod of %s: %s", t.workload.Key.ReadableString(), desc)
+				logPodEvents(ctx, applier, p, lp)
+				return fmt.Errorf("found a failing pod: %s", desc)
+			}
+		}
+
+		select {
+		case <-watchCtx.Done():
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			lp.Successf("No pod failure was found during %v", duration)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func logPodEvents(ctx context.Context, applier provider.Applier, pod provider.Manifest, lp executor.LogPersister) {
+	events, err := applier.ListEvents(ctx, pod.Key)
+	if err != nil {
+		lp.Errorf("Unable to list events of pod %s (%v)", pod.Key.Name, err)
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+	lp.Infof("Events of pod %s:", pod.Key.Name)
+	for _, e := range events {
+		lp.Info("  " + provider.DescribeEvent(e))
+	}
+}
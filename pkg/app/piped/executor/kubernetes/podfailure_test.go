@@ -0,0 +1,103 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes/providertest"
+)
+
+func TestWatchPodFailures(t *testing.T) {
+	workloads, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: app
+`)
+	require.NoError(t, err)
+
+	pods, err := provider.ParseManifests(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app-1
+  namespace: default
+status:
+  phase: Running
+  containerStatuses:
+  - name: app
+    state:
+      running: {}
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app-1
+  namespace: default
+status:
+  phase: Running
+  containerStatuses:
+  - name: app
+    state:
+      waiting:
+        reason: CrashLoopBackOff
+`)
+	require.NoError(t, err)
+
+	interval := podFailureCheckInterval
+	podFailureCheckInterval = time.Millisecond
+	defer func() { podFailureCheckInterval = interval }()
+
+	selector := map[string]string{"app": "app"}
+
+	t.Run("failing pod was found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		gomock.InOrder(
+			p.EXPECT().ListPods(gomock.Any(), "default", selector).Return(pods[:1], nil),
+			p.EXPECT().ListPods(gomock.Any(), "default", selector).Return(pods[1:], nil),
+			p.EXPECT().ListEvents(gomock.Any(), pods[1].Key).Return(nil, nil),
+		)
+
+		err := watchPodFailures(context.Background(), p, workloads, time.Minute, &fakeLogPersister{})
+		assert.Error(t, err)
+	})
+
+	t.Run("no failing pod", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		p.EXPECT().ListPods(gomock.Any(), "default", selector).Return(pods[:1], nil).MinTimes(1)
+
+		err := watchPodFailures(context.Background(), p, workloads, 20*time.Millisecond, &fakeLogPersister{})
+		assert.NoError(t, err)
+	})
+}
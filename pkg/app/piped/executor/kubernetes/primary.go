@@ -60,16 +60,12 @@ func (e *deployExecutor) ensurePrimaryRollout(ctx context.Context) model.StageSt
 	case config.KubernetesTrafficRoutingMethodPodSelector:
 		primaryManifests = manifests
 
-	// In case of routing by Istio,
-	// VirtualService manifest will be used to manipulate the traffic ratio.
+	// In case of routing by Istio or SMI,
+	// VirtualService or TrafficSplit manifest will be used to manipulate the traffic ratio.
 	// Other manifests can be used as primary manifests.
-	case config.KubernetesTrafficRoutingMethodIstio:
-		// Firstly, find the VirtualService manifests.
-		istioCfg := e.deployCfg.TrafficRouting.Istio
-		if istioCfg == nil {
-			istioCfg = &config.IstioTrafficRouting{}
-		}
-		trafficRoutingManifests, err := findIstioVirtualServiceManifests(manifests, istioCfg.VirtualService)
+	case config.KubernetesTrafficRoutingMethodIstio, config.KubernetesTrafficRoutingMethodSMI:
+		// Firstly, find the traffic routing manifests.
+		trafficRoutingManifests, err := findTrafficRoutingManifests(manifests, e.deployCfg.Service.Name, e.deployCfg.TrafficRouting)
 		if err != nil {
 			e.LogPersister.Errorf("Failed while finding traffic routing manifest: (%v)", err)
 			return model.StageStatus_STAGE_FAILURE
@@ -97,9 +93,9 @@ func (e *deployExecutor) ensurePrimaryRollout(ctx context.Context) model.StageSt
 		workloads := findWorkloadManifests(primaryManifests, e.deployCfg.Workloads)
 		var invalid bool
 		for _, m := range workloads {
-			if err := checkVariantSelectorInWorkload(m, primaryVariant); err != nil {
+			if err := checkVariantSelectorInWorkload(m, e.variantLabel, primaryVariant); err != nil {
 				invalid = true
-				e.LogPersister.Errorf("Missing %q in selector of workload %s (%v)", variantLabel+": "+primaryVariant, m.Key.ReadableString(), err)
+				e.LogPersister.Errorf("Missing %q in selector of workload %s (%v)", e.variantLabel+": "+primaryVariant, m.Key.ReadableString(), err)
 			}
 		}
 		if invalid {
@@ -113,11 +109,27 @@ func (e *deployExecutor) ensurePrimaryRollout(ctx context.Context) model.StageSt
 		e.LogPersister.Errorf("Unable to generate manifests for PRIMARY variant (%v)", err)
 		return model.StageStatus_STAGE_FAILURE
 	}
+	if e.deployCfg.Jobs.GenerateNameSuffix {
+		if primaryManifests, err = generateJobNameSuffixes(primaryManifests); err != nil {
+			e.LogPersister.Errorf("Unable to generate name suffixes for jobs (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
 	e.LogPersister.Successf("Successfully generated %d manifests for PRIMARY variant", len(primaryManifests))
 
+	// Pin the images to the digests resolved while planning.
+	if err := e.pinImageDigests(primaryManifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+	if err := e.recordRunningImages(ctx, primaryManifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	// Add builtin annotations for tracking application live state.
 	addBuiltinAnnontations(
 		primaryManifests,
+		e.managedAnnotations,
+		e.variantLabel,
 		primaryVariant,
 		e.commit,
 		e.PipedConfig.PipedID,
@@ -126,11 +138,37 @@ func (e *deployExecutor) ensurePrimaryRollout(ctx context.Context) model.StageSt
 
 	// Start applying all manifests to add or update running resources.
 	e.LogPersister.Info("Start rolling out PRIMARY variant...")
-	if err := applyManifests(ctx, e.provider, primaryManifests, e.deployCfg.Input.Namespace, e.LogPersister); err != nil {
+	if err := applyManifests(ctx, e.provider, primaryManifests, e.deployCfg.Input.Namespace, e.deployCfg.Input.ApplyWaves, e.LogPersister); err != nil {
 		return model.StageStatus_STAGE_FAILURE
 	}
 	e.LogPersister.Success("Successfully rolled out PRIMARY variant")
 
+	switch {
+	case options.WaitHealthy:
+		// The Jobs are left to be waited with their logs below
+		// when jobs.waitForCompletion is enabled.
+		targets := findHealthCheckTargets(primaryManifests, !e.deployCfg.Jobs.WaitForCompletion)
+		if err := waitForRollout(ctx, e.provider, targets, options.RolloutTimeout.Duration(), e.LogPersister); err != nil {
+			return model.StageStatus_STAGE_FAILURE
+		}
+	case options.WaitForRollout:
+		workloads := findWorkloadManifests(primaryManifests, e.deployCfg.Workloads)
+		if err := waitForRollout(ctx, e.provider, workloads, options.RolloutTimeout.Duration(), e.LogPersister); err != nil {
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+
+	if err := e.waitForJobs(ctx, primaryManifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	if d := options.WatchPodFailures.Duration(); d > 0 {
+		workloads := findWorkloadManifests(primaryManifests, e.deployCfg.Workloads)
+		if err := watchPodFailures(ctx, e.provider, workloads, d, e.LogPersister); err != nil {
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+
 	if !options.Prune {
 		e.LogPersister.Info("Resource GC was skipped because sync.prune was not configured")
 		return model.StageStatus_STAGE_SUCCESS
@@ -155,6 +193,19 @@ func (e *deployExecutor) ensurePrimaryRollout(ctx context.Context) model.StageSt
 		return model.StageStatus_STAGE_FAILURE
 	}
 
+	// Since the Jobs were applied with the suffixed names,
+	// the same names must be used to find the ones that should be removed.
+	if e.deployCfg.Jobs.GenerateNameSuffix {
+		if runningManifests, err = generateJobNameSuffixes(runningManifests); err != nil {
+			e.LogPersister.Errorf("Unable to generate name suffixes for running jobs (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+		if manifests, err = generateJobNameSuffixes(manifests); err != nil {
+			e.LogPersister.Errorf("Unable to generate name suffixes for jobs (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+
 	removeKeys := findRemoveManifests(runningManifests, manifests, e.deployCfg.Input.Namespace)
 	if len(removeKeys) == 0 {
 		e.LogPersister.Info("There are no live resources should be removed")
@@ -162,6 +213,18 @@ func (e *deployExecutor) ensurePrimaryRollout(ctx context.Context) model.StageSt
 	}
 	e.LogPersister.Infof("Found %d live resources that are no longer defined in Git", len(removeKeys))
 
+	// Ensure that only the resources managed by this application will be deleted
+	// because the running manifests were loaded from Git, not from the cluster.
+	removeKeys, err = filterOwnedResources(ctx, e.provider, removeKeys, e.managedAnnotations, e.Deployment.ApplicationId, e.LogPersister)
+	if err != nil {
+		e.LogPersister.Errorf("Failed while checking the owner of resources (%v)", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	if len(removeKeys) == 0 {
+		e.LogPersister.Info("There are no live resources should be removed")
+		return model.StageStatus_STAGE_SUCCESS
+	}
+
 	// Start deleting all running resources that are not defined in Git.
 	e.LogPersister.Infof("Start deleting %d resources", len(removeKeys))
 	if err := deleteResources(ctx, e.provider, removeKeys, e.LogPersister); err != nil {
@@ -207,8 +270,8 @@ func (e *deployExecutor) generatePrimaryManifests(manifests []provider.Manifest,
 	if opts.AddVariantLabelToSelector {
 		workloads := findWorkloadManifests(manifests, e.deployCfg.Workloads)
 		for _, m := range workloads {
-			if err := ensureVariantSelectorInWorkload(m, primaryVariant); err != nil {
-				return nil, fmt.Errorf("unable to check/set %q in selector of workload %s (%v)", variantLabel+": "+primaryVariant, m.Key.ReadableString(), err)
+			if err := ensureVariantSelectorInWorkload(m, e.variantLabel, primaryVariant); err != nil {
+				return nil, fmt.Errorf("unable to check/set %q in selector of workload %s (%v)", e.variantLabel+": "+primaryVariant, m.Key.ReadableString(), err)
 			}
 		}
 	}
@@ -222,7 +285,7 @@ func (e *deployExecutor) generatePrimaryManifests(manifests []provider.Manifest,
 		}
 		services = duplicateManifests(services, "")
 
-		generatedServices, err := generateVariantServiceManifests(services, primaryVariant, suffix)
+		generatedServices, err := generateVariantServiceManifests(services, e.variantLabel, primaryVariant, suffix)
 		if err != nil {
 			return nil, err
 		}
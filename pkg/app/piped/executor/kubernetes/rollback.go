@@ -22,6 +22,8 @@ import (
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/app/piped/ratelimiter"
+	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
@@ -67,7 +69,12 @@ func (e *rollbackExecutor) ensureRollback(ctx context.Context) model.StageStatus
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	p := provider.NewProvider(e.Deployment.ApplicationName, ds.AppDir, ds.RepoDir, e.Deployment.GitPath.ConfigFilename, deployCfg.Input, e.Logger)
+	p := provider.NewProvider(e.Deployment.ApplicationName, ds.AppDir, ds.RepoDir, e.Deployment.GitPath.ConfigFilename, deployCfg.Input, e.Logger, provider.WithHelmPostRenderer(e.PipedConfig.AllowHelmPostRenderer), provider.WithGitRepositories(e.PipedConfig.Repositories))
+	p = provider.WithRateLimiter(p, ratelimiter.DefaultRegistry().Limiter(e.Deployment.CloudProvider))
+	cpCfg := findKubernetesConfig(e.PipedConfig, e.Deployment.CloudProvider)
+	p = provider.WithAllowedNamespaces(p, deployCfg.Input.Namespace, cpCfg.AllowedNamespaces, cpCfg.AllowClusterScopedResources)
+	variantLabel := cpCfg.GetVariantLabel()
+	managedAnnotations := provider.NewManagedAnnotations(cpCfg.GetAnnotationPrefix())
 	e.Logger.Info("start executing kubernetes stage",
 		zap.String("stage-name", e.Stage.Name),
 		zap.String("app-dir", ds.AppDir),
@@ -89,12 +96,18 @@ func (e *rollbackExecutor) ensureRollback(ctx context.Context) model.StageStatus
 	// we duplicate them to avoid updating the shared manifests data in cache.
 	manifests = duplicateManifests(manifests, "")
 
+	// Restore the exact images those were running before the deployment
+	// in case their tags were re-pushed after that.
+	if err := pinRunningImages(manifests, e.MetadataStore, e.LogPersister); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	// When addVariantLabelToSelector is true, ensure that all workloads
 	// have the variant label in their selector.
 	if deployCfg.QuickSync.AddVariantLabelToSelector {
 		workloads := findWorkloadManifests(manifests, deployCfg.Workloads)
 		for _, m := range workloads {
-			if err := ensureVariantSelectorInWorkload(m, primaryVariant); err != nil {
+			if err := ensureVariantSelectorInWorkload(m, variantLabel, primaryVariant); err != nil {
 				e.LogPersister.Errorf("Unable to check/set %q in selector of workload %s (%v)", variantLabel+": "+primaryVariant, m.Key.ReadableString(), err)
 				return model.StageStatus_STAGE_FAILURE
 			}
@@ -104,6 +117,8 @@ func (e *rollbackExecutor) ensureRollback(ctx context.Context) model.StageStatus
 	// Add builtin annotations for tracking application live state.
 	addBuiltinAnnontations(
 		manifests,
+		managedAnnotations,
+		variantLabel,
 		primaryVariant,
 		e.Deployment.RunningCommitHash,
 		e.PipedConfig.PipedID,
@@ -111,7 +126,11 @@ func (e *rollbackExecutor) ensureRollback(ctx context.Context) model.StageStatus
 	)
 
 	// Start applying all manifests to add or update running resources.
-	if err := applyManifests(ctx, p, manifests, deployCfg.Input.Namespace, e.LogPersister); err != nil {
+	if deployCfg.Input.ApplyEngine == config.KubernetesApplyEngineKapp {
+		if err := applyManifestsByKapp(ctx, p, manifests, e.LogPersister); err != nil {
+			return model.StageStatus_STAGE_FAILURE
+		}
+	} else if err := applyManifests(ctx, p, manifests, deployCfg.Input.Namespace, deployCfg.Input.ApplyWaves, e.LogPersister); err != nil {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
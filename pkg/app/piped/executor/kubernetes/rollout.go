@@ -0,0 +1,107 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+)
+
+const defaultRolloutTimeout = 10 * time.Minute
+
+var rolloutCheckInterval = 5 * time.Second
+
+// waitForRollout waits until the rollouts of all given workloads are completed.
+// It returns an error as soon as any rollout was found failed
+// or when they were not completed within the given timeout.
+func waitForRollout(ctx context.Context, applier provider.Applier, workloads []provider.Manifest, timeout time.Duration, lp executor.LogPersister) error {
+	if len(workloads) == 0 {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = defaultRolloutTimeout
+	}
+	lp.Infof("Waiting for the rollouts of %d workloads to be completed", len(workloads))
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(rolloutCheckInterval)
+	defer ticker.Stop()
+
+	pendings := workloads
+	for {
+		var (
+			inProgress []provider.Manifest
+			reason     string
+		)
+		for _, w := range pendings {
+			live, err := applier.GetManifest(ctx, w.Key)
+			if err != nil {
+				inProgress = append(inProgress, w)
+				reason = fmt.Sprintf("unable to get %s (%v)", w.Key.ReadableString(), err)
+				continue
+			}
+			done, desc, err := provider.CheckRolloutStatus(live)
+			if err != nil {
+				lp.Errorf("Rollout of %s failed (%v)", w.Key.ReadableString(), err)
+				return err
+			}
+			if !done {
+				inProgress = append(inProgress, w)
+				reason = fmt.Sprintf("%s: %s", w.Key.ReadableString(), desc)
+				continue
+			}
+			lp.Successf("- rollout of %s was completed", w.Key.ReadableString())
+		}
+		if len(inProgress) == 0 {
+			lp.Successf("All rollouts of %d workloads were completed", len(workloads))
+			return nil
+		}
+		pendings = inProgress
+
+		select {
+		case <-ctx.Done():
+			lp.Errorf("Timed out waiting for the rollouts of %d workloads: %s", len(pendings), reason)
+			return fmt.Errorf("rollouts were not completed: %s", reason)
+		case <-ticker.C:
+		}
+	}
+}
+
+// findHealthCheckTargets returns all Deployments, StatefulSets, DaemonSets and Jobs
+// in the given manifests those should be waited until they become healthy.
+// Jobs are excluded when includeJobs is false.
+func findHealthCheckTargets(manifests []provider.Manifest, includeJobs bool) []provider.Manifest {
+	var out []provider.Manifest
+	for _, m := range manifests {
+		if !provider.IsKubernetesBuiltInResource(m.Key.APIVersion) {
+			continue
+		}
+		switch m.Key.Kind {
+		case provider.KindDeployment, provider.KindStatefulSet, provider.KindDaemonSet:
+			out = append(out, m)
+		case provider.KindJob:
+			if includeJobs {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes/providertest"
+)
+
+func TestWaitForRollout(t *testing.T) {
+	workloads, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  replicas: 2
+`)
+	require.NoError(t, err)
+
+	lives, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  generation: 2
+spec:
+  replicas: 2
+status:
+  observedGeneration: 2
+  replicas: 3
+  updatedReplicas: 1
+  availableReplicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  generation: 2
+spec:
+  replicas: 2
+status:
+  observedGeneration: 2
+  replicas: 2
+  updatedReplicas: 2
+  availableReplicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  generation: 2
+spec:
+  replicas: 2
+status:
+  observedGeneration: 2
+  replicas: 3
+  updatedReplicas: 1
+  availableReplicas: 2
+  conditions:
+  - type: Progressing
+    status: "False"
+    reason: ProgressDeadlineExceeded
+`)
+	require.NoError(t, err)
+
+	interval := rolloutCheckInterval
+	rolloutCheckInterval = time.Millisecond
+	defer func() { rolloutCheckInterval = interval }()
+
+	t.Run("completed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		gomock.InOrder(
+			p.EXPECT().GetManifest(gomock.Any(), workloads[0].Key).Return(lives[0], nil),
+			p.EXPECT().GetManifest(gomock.Any(), workloads[0].Key).Return(lives[1], nil),
+		)
+
+		err := waitForRollout(context.Background(), p, workloads, time.Minute, &fakeLogPersister{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("progress deadline exceeded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		gomock.InOrder(
+			p.EXPECT().GetManifest(gomock.Any(), workloads[0].Key).Return(lives[0], nil),
+			p.EXPECT().GetManifest(gomock.Any(), workloads[0].Key).Return(lives[2], nil),
+		)
+
+		err := waitForRollout(context.Background(), p, workloads, time.Minute, &fakeLogPersister{})
+		assert.Error(t, err)
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		p := providertest.NewMockProvider(ctrl)
+		p.EXPECT().GetManifest(gomock.Any(), workloads[0].Key).Return(lives[0], nil).MinTimes(1)
+
+		err := waitForRollout(context.Background(), p, workloads, 20*time.Millisecond, &fakeLogPersister{})
+		assert.Error(t, err)
+	})
+}
+
+func TestFindHealthCheckTargets(t *testing.T) {
+	manifests, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Deployment
+metadata:
+  name: custom
+`)
+	require.NoError(t, err)
+
+	got := findHealthCheckTargets(manifests, true)
+	assert.Equal(t, manifests[:3], got)
+
+	got = findHealthCheckTargets(manifests, false)
+	assert.Equal(t, manifests[:2], got)
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
@@ -43,13 +44,28 @@ func (e *deployExecutor) ensureSync(ctx context.Context) model.StageStatus {
 	// we duplicate them to avoid updating the shared manifests data in cache.
 	manifests = duplicateManifests(manifests, "")
 
+	if e.deployCfg.Jobs.GenerateNameSuffix {
+		if manifests, err = generateJobNameSuffixes(manifests); err != nil {
+			e.LogPersister.Errorf("Unable to generate name suffixes for jobs (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+
+	// Pin the images to the digests resolved while planning.
+	if err := e.pinImageDigests(manifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+	if err := e.recordRunningImages(ctx, manifests); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	// When addVariantLabelToSelector is true, ensure that all workloads
 	// have the variant label in their selector.
 	if e.deployCfg.QuickSync.AddVariantLabelToSelector {
 		workloads := findWorkloadManifests(manifests, e.deployCfg.Workloads)
 		for _, m := range workloads {
-			if err := ensureVariantSelectorInWorkload(m, primaryVariant); err != nil {
-				e.LogPersister.Errorf("Unable to check/set %q in selector of workload %s (%v)", variantLabel+": "+primaryVariant, m.Key.ReadableString(), err)
+			if err := ensureVariantSelectorInWorkload(m, e.variantLabel, primaryVariant); err != nil {
+				e.LogPersister.Errorf("Unable to check/set %q in selector of workload %s (%v)", e.variantLabel+": "+primaryVariant, m.Key.ReadableString(), err)
 				return model.StageStatus_STAGE_FAILURE
 			}
 		}
@@ -58,14 +74,32 @@ func (e *deployExecutor) ensureSync(ctx context.Context) model.StageStatus {
 	// Add builtin annotations for tracking application live state.
 	addBuiltinAnnontations(
 		manifests,
+		e.managedAnnotations,
+		e.variantLabel,
 		primaryVariant,
 		e.commit,
 		e.PipedConfig.PipedID,
 		e.Deployment.ApplicationId,
 	)
 
+	// The resources no longer defined in Git are deleted by kapp itself
+	// since it tracks all resources of the application.
+	if e.deployCfg.Input.ApplyEngine == config.KubernetesApplyEngineKapp {
+		if err := applyManifestsByKapp(ctx, e.provider, manifests, e.LogPersister); err != nil {
+			return model.StageStatus_STAGE_FAILURE
+		}
+		if err := e.waitForJobs(ctx, manifests); err != nil {
+			return model.StageStatus_STAGE_FAILURE
+		}
+		return model.StageStatus_STAGE_SUCCESS
+	}
+
 	// Start applying all manifests to add or update running resources.
-	if err := applyManifests(ctx, e.provider, manifests, e.deployCfg.Input.Namespace, e.LogPersister); err != nil {
+	if err := applyManifests(ctx, e.provider, manifests, e.deployCfg.Input.Namespace, e.deployCfg.Input.ApplyWaves, e.LogPersister); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	if err := e.waitForJobs(ctx, manifests); err != nil {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
@@ -20,12 +20,16 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	istiov1alpha3 "istio.io/api/networking/v1alpha3"
 	istiov1beta1 "istio.io/api/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor/analysis"
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
@@ -34,10 +38,12 @@ const (
 	primaryMetadataKey  = "primary-percentage"
 	canaryMetadataKey   = "canary-percentage"
 	baselineMetadataKey = "baseline-percentage"
+	stepMetadataKey     = "traffic-routing-step"
 )
 
-func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageStatus {
+func (e *deployExecutor) ensureTrafficRouting(sig executor.StopSignal) model.StageStatus {
 	var (
+		ctx        = sig.Context()
 		commitHash = e.Deployment.Trigger.Commit.Hash
 		options    = e.StageConfig.K8sTrafficRoutingStageOptions
 	)
@@ -68,10 +74,6 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	// Decide traffic routing percentage for all variants.
-	primaryPercent, canaryPercent, baselinePercent := options.Percentages()
-	e.saveTrafficRoutingMetadata(ctx, primaryPercent, canaryPercent, baselinePercent)
-
 	// Find traffic routing manifests.
 	trafficRoutingManifests, err := findTrafficRoutingManifests(manifests, e.deployCfg.Service.Name, e.deployCfg.TrafficRouting)
 	if err != nil {
@@ -94,11 +96,11 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 	}
 	trafficRoutingManifest := trafficRoutingManifests[0]
 
-	// In case we are routing by PodSelector, the service manifest must contain variantLabel inside its selector.
+	// In case we are routing by PodSelector, the service manifest must contain the variant label inside its selector.
 	if method == config.KubernetesTrafficRoutingMethodPodSelector {
-		if err := checkVariantSelectorInService(trafficRoutingManifest, primaryVariant); err != nil {
+		if err := checkVariantSelectorInService(trafficRoutingManifest, e.variantLabel, primaryVariant); err != nil {
 			e.LogPersister.Errorf("Traffic routing by PodSelector requires %q inside the selector of Service manifest but it was unable to check that field in manifest %s (%v)",
-				variantLabel+": "+primaryVariant,
+				e.variantLabel+": "+primaryVariant,
 				trafficRoutingManifest.Key.ReadableString(),
 				err,
 			)
@@ -106,7 +108,89 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 		}
 	}
 
-	trafficRoutingManifest, err = e.generateTrafficRoutingManifest(
+	if len(options.Steps) > 0 {
+		return e.shiftTrafficProgressively(sig, manifests, trafficRoutingManifest, options.Steps)
+	}
+
+	// Decide traffic routing percentage for all variants.
+	primaryPercent, canaryPercent, baselinePercent := options.Percentages()
+	e.saveTrafficRoutingMetadata(ctx, primaryPercent, canaryPercent, baselinePercent)
+
+	if !e.updateTrafficRouting(ctx, manifests, trafficRoutingManifest, primaryPercent, canaryPercent, baselinePercent) {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	e.LogPersister.Success("Successfully updated traffic routing")
+	return model.StageStatus_STAGE_SUCCESS
+}
+
+// shiftTrafficProgressively updates the traffic routing step by step.
+// Each step waits and runs its analysis after updating the traffic before moving to the next one.
+// The index of the running step is stored into the stage metadata
+// so that the stage can be resumed from that step after a restart of piped.
+func (e *deployExecutor) shiftTrafficProgressively(sig executor.StopSignal, manifests []provider.Manifest, manifest provider.Manifest, steps []config.K8sTrafficRoutingStep) model.StageStatus {
+	ctx := sig.Context()
+	start := e.retrieveTrafficRoutingStep()
+	if start >= len(steps) {
+		start = 0
+	}
+	if start > 0 {
+		e.LogPersister.Infof("Resuming traffic shifting from step %d/%d", start+1, len(steps))
+	}
+
+	for i := start; i < len(steps); i++ {
+		step := steps[i]
+		e.saveTrafficRoutingStep(ctx, i)
+
+		primaryPercent, canaryPercent, baselinePercent := step.Percentages()
+		e.LogPersister.Infof("[step %d/%d] Shifting traffic", i+1, len(steps))
+		e.saveTrafficRoutingMetadata(ctx, primaryPercent, canaryPercent, baselinePercent)
+		if !e.updateTrafficRouting(ctx, manifests, manifest, primaryPercent, canaryPercent, baselinePercent) {
+			return model.StageStatus_STAGE_FAILURE
+		}
+
+		if step.Wait > 0 {
+			e.LogPersister.Infof("[step %d/%d] Waiting for %v before moving to the next step", i+1, len(steps), step.Wait.Duration())
+			timer := time.NewTimer(step.Wait.Duration())
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return model.StageStatus_STAGE_FAILURE
+			}
+		}
+
+		if step.Analysis != nil {
+			e.LogPersister.Infof("[step %d/%d] Start analyzing the traffic for %v", i+1, len(steps), step.Analysis.Duration.Duration())
+			prefix := fmt.Sprintf("step-%d-", i)
+			status := analysis.NewStepExecutor(e.Input, step.Analysis, prefix).Execute(sig)
+			switch status {
+			case model.StageStatus_STAGE_SUCCESS, model.StageStatus_STAGE_SKIPPED:
+			default:
+				e.LogPersister.Errorf("[step %d/%d] Stopped traffic shifting since the analysis was not successful", i+1, len(steps))
+				return status
+			}
+		}
+		e.LogPersister.Successf("[step %d/%d] Successfully completed", i+1, len(steps))
+	}
+
+	e.LogPersister.Success("Successfully shifted traffic through all steps")
+	return model.StageStatus_STAGE_SUCCESS
+}
+
+// updateTrafficRouting applies the traffic routing manifest updated to route the given percentages of traffic.
+func (e *deployExecutor) updateTrafficRouting(ctx context.Context, manifests []provider.Manifest, trafficRoutingManifest provider.Manifest, primaryPercent, canaryPercent, baselinePercent int) bool {
+	// In case of routing a part of traffic by PodSelector,
+	// CANARY variant must be scaled before the Service starts selecting its pods.
+	method := config.DetermineKubernetesTrafficRoutingMethod(e.deployCfg.TrafficRouting)
+	if method == config.KubernetesTrafficRoutingMethodPodSelector && canaryPercent > 0 && canaryPercent < 100 {
+		if err := e.scaleCanaryForTrafficRouting(ctx, manifests, primaryPercent, canaryPercent); err != nil {
+			e.LogPersister.Errorf("Unable to scale CANARY variant for traffic routing: (%v)", err)
+			return false
+		}
+	}
+
+	trafficRoutingManifest, err := e.generateTrafficRoutingManifest(
 		trafficRoutingManifest,
 		primaryPercent,
 		canaryPercent,
@@ -115,14 +199,16 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 	)
 	if err != nil {
 		e.LogPersister.Errorf("Unable generate traffic routing manifest: (%v)", err)
-		return model.StageStatus_STAGE_FAILURE
+		return false
 	}
 
 	// Add builtin annotations for tracking application live state.
 	addBuiltinAnnontations(
 		[]provider.Manifest{trafficRoutingManifest},
+		e.managedAnnotations,
+		e.variantLabel,
 		primaryVariant,
-		commitHash,
+		e.commit,
 		e.PipedConfig.PipedID,
 		e.Deployment.ApplicationId,
 	)
@@ -132,12 +218,10 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 		canaryPercent,
 		baselinePercent,
 	)
-	if err := applyManifests(ctx, e.provider, []provider.Manifest{trafficRoutingManifest}, e.deployCfg.Input.Namespace, e.LogPersister); err != nil {
-		return model.StageStatus_STAGE_FAILURE
+	if err := applyManifests(ctx, e.provider, []provider.Manifest{trafficRoutingManifest}, e.deployCfg.Input.Namespace, e.deployCfg.Input.ApplyWaves, e.LogPersister); err != nil {
+		return false
 	}
-
-	e.LogPersister.Success("Successfully updated traffic routing")
-	return model.StageStatus_STAGE_SUCCESS
+	return true
 }
 
 func findTrafficRoutingManifests(manifests []provider.Manifest, serviceName string, cfg *config.KubernetesTrafficRouting) ([]provider.Manifest, error) {
@@ -154,6 +238,13 @@ func findTrafficRoutingManifests(manifests []provider.Manifest, serviceName stri
 		}
 		return findIstioVirtualServiceManifests(manifests, istioConfig.VirtualService)
 
+	case config.KubernetesTrafficRoutingMethodSMI:
+		smiConfig := cfg.SMI
+		if smiConfig == nil {
+			smiConfig = &config.SMITrafficRouting{}
+		}
+		return findSMITrafficSplitManifests(manifests, smiConfig.TrafficSplit)
+
 	default:
 		return nil, fmt.Errorf("unsupport traffic routing method %v", method)
 	}
@@ -183,6 +274,10 @@ func (e *deployExecutor) generateTrafficRoutingManifest(manifest provider.Manife
 		return generateVirtualServiceManifest(manifest, istioConfig.Host, istioConfig.EditableRoutes, int32(canaryPercent), int32(baselinePercent))
 	}
 
+	if cfg != nil && cfg.Method == config.KubernetesTrafficRoutingMethodSMI {
+		return generateTrafficSplitManifest(manifest, primaryPercent, canaryPercent, baselinePercent)
+	}
+
 	// Determine which variant will receive 100% percent of traffic.
 	var variant string
 	switch {
@@ -190,28 +285,118 @@ func (e *deployExecutor) generateTrafficRoutingManifest(manifest provider.Manife
 		variant = primaryVariant
 	case canaryPercent == 100:
 		variant = canaryVariant
+	case baselinePercent == 0 && canaryPercent > 0:
+		// The traffic is spread over the pods of both PRIMARY and CANARY variants
+		// in proportion to their number of pods by removing the variant from selector.
+		return removeVariantSelectorFromService(manifest, e.variantLabel)
 	default:
-		return manifest, fmt.Errorf("traffic routing by pod requires either PRIMARY or CANARY must be 100 (primary=%d, canary=%d)", primaryPercent, canaryPercent)
+		return manifest, fmt.Errorf("traffic routing by pod requires either PRIMARY or CANARY must be 100 or no traffic is routed to BASELINE (primary=%d, canary=%d, baseline=%d)", primaryPercent, canaryPercent, baselinePercent)
 	}
 
-	if err := manifest.AddStringMapValues(map[string]string{variantLabel: variant}, "spec", "selector"); err != nil {
+	if err := manifest.AddStringMapValues(map[string]string{e.variantLabel: variant}, "spec", "selector"); err != nil {
 		return manifest, fmt.Errorf("unable to update selector for service %q because of: %v", manifest.Key.Name, err)
 	}
 
 	return manifest, nil
 }
 
+func removeVariantSelectorFromService(m provider.Manifest, variantLabel string) (provider.Manifest, error) {
+	s := &corev1.Service{}
+	if err := m.ConvertToStructuredObject(s); err != nil {
+		return m, err
+	}
+	delete(s.Spec.Selector, variantLabel)
+
+	manifest, err := provider.ParseFromStructuredObject(s)
+	if err != nil {
+		return m, fmt.Errorf("failed to parse Service object to Manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// scaleCanaryForTrafficRouting rolls out the workloads of CANARY variant again
+// with the number of replicas making their ratio to the PRIMARY's close to the given percentages.
+// The number of PRIMARY replicas is not changed so it is based on the one defined in Git.
+func (e *deployExecutor) scaleCanaryForTrafficRouting(ctx context.Context, manifests []provider.Manifest, primaryPercent, canaryPercent int) error {
+	if primaryPercent <= 0 {
+		return fmt.Errorf("PRIMARY must receive a part of traffic (primary=%d, canary=%d)", primaryPercent, canaryPercent)
+	}
+
+	var options *config.K8sCanaryRolloutStageOptions
+	if e.deployCfg.Pipeline != nil {
+		for _, s := range e.deployCfg.Pipeline.Stages {
+			if s.Name == model.StageK8sCanaryRollout && s.K8sCanaryRolloutStageOptions != nil {
+				options = s.K8sCanaryRolloutStageOptions
+				break
+			}
+		}
+	}
+	if options == nil {
+		return fmt.Errorf("missing %s stage in the pipeline", model.StageK8sCanaryRollout)
+	}
+
+	opts := *options
+	opts.Replicas = config.Replicas{
+		Number:       canaryPercent * 100 / primaryPercent,
+		IsPercentage: true,
+	}
+	canaryManifests, err := e.generateCanaryManifests(manifests, opts)
+	if err != nil {
+		return err
+	}
+	if err := e.pinImageDigests(canaryManifests); err != nil {
+		return err
+	}
+	addBuiltinAnnontations(
+		canaryManifests,
+		e.managedAnnotations,
+		e.variantLabel,
+		canaryVariant,
+		e.commit,
+		e.PipedConfig.PipedID,
+		e.Deployment.ApplicationId,
+	)
+
+	workloads := make([]provider.Manifest, 0, len(canaryManifests))
+	for _, m := range canaryManifests {
+		if m.Key.IsWorkload() {
+			workloads = append(workloads, m)
+		}
+	}
+	e.LogPersister.Infof("Start scaling CANARY variant to %s of PRIMARY replicas", opts.Replicas)
+	return applyManifests(ctx, e.provider, workloads, e.deployCfg.Input.Namespace, e.deployCfg.Input.ApplyWaves, e.LogPersister)
+}
+
 func (e *deployExecutor) saveTrafficRoutingMetadata(ctx context.Context, primary, canary, baseline int) {
 	metadata := map[string]string{
 		primaryMetadataKey:  strconv.FormatInt(int64(primary), 10),
 		canaryMetadataKey:   strconv.FormatInt(int64(canary), 10),
 		baselineMetadataKey: strconv.FormatInt(int64(baseline), 10),
 	}
-	if err := e.MetadataStore.SetStageMetadata(ctx, e.Stage.Id, metadata); err != nil {
+	if err := e.StageMetadata().PutMulti(ctx, metadata); err != nil {
 		e.Logger.Error("failed to save traffic routing percentages to metadata", zap.Error(err))
 	}
 }
 
+func (e *deployExecutor) saveTrafficRoutingStep(ctx context.Context, index int) {
+	if err := e.StageMetadata().Put(ctx, stepMetadataKey, strconv.Itoa(index)); err != nil {
+		e.Logger.Error("failed to save traffic routing step to metadata", zap.Error(err))
+	}
+}
+
+func (e *deployExecutor) retrieveTrafficRoutingStep() int {
+	s, ok := e.StageMetadata().Get(stepMetadataKey)
+	if !ok {
+		return 0
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 {
+		e.Logger.Error("unexpected traffic routing step is stored", zap.String("stored-value", s))
+		return 0
+	}
+	return index
+}
+
 func findIstioVirtualServiceManifests(manifests []provider.Manifest, ref config.K8sResourceReference) ([]provider.Manifest, error) {
 	const (
 		istioNetworkingAPIVersionPrefix = "networking.istio.io/"
@@ -411,7 +596,79 @@ func generateVirtualServiceManifestV1Alpha3(m provider.Manifest, host string, ed
 	return m, nil
 }
 
-func checkVariantSelectorInService(m provider.Manifest, variant string) error {
+func findSMITrafficSplitManifests(manifests []provider.Manifest, ref config.K8sResourceReference) ([]provider.Manifest, error) {
+	const (
+		smiSplitAPIVersionPrefix = "split.smi-spec.io/"
+		smiTrafficSplitKind      = "TrafficSplit"
+	)
+
+	if ref.Kind != "" && ref.Kind != smiTrafficSplitKind {
+		return nil, fmt.Errorf("support only %q kind for TrafficSplit reference", smiTrafficSplitKind)
+	}
+
+	var out []provider.Manifest
+	for _, m := range manifests {
+		if !strings.HasPrefix(m.Key.APIVersion, smiSplitAPIVersionPrefix) {
+			continue
+		}
+		if m.Key.Kind != smiTrafficSplitKind {
+			continue
+		}
+		if ref.Name != "" && m.Key.Name != ref.Name {
+			continue
+		}
+		out = append(out, m)
+	}
+
+	return out, nil
+}
+
+// generateTrafficSplitManifest updates the backends of the given TrafficSplit manifest
+// to route the traffic to the services of all variants.
+// The service of each variant is named by suffixing the root service with the variant name,
+// the same as the services created by the rollout stages.
+// This works for both v1alpha2 and v1alpha3 of TrafficSplit since they share the same backends format.
+func generateTrafficSplitManifest(m provider.Manifest, primaryPercent, canaryPercent, baselinePercent int) (provider.Manifest, error) {
+	// Because the loaded manifests are read-only
+	// so we duplicate them to avoid updating the shared manifests data in cache.
+	m = duplicateManifest(m, "")
+
+	spec, err := m.GetNestedMap("spec")
+	if err != nil {
+		return m, err
+	}
+	rootService, _ := spec["service"].(string)
+	if rootService == "" {
+		return m, fmt.Errorf("missing spec.service in TrafficSplit %s", m.Key.Name)
+	}
+
+	backends := make([]interface{}, 0, 3)
+	backends = append(backends, map[string]interface{}{
+		"service": makeSuffixedName(rootService, primaryVariant),
+		"weight":  int64(primaryPercent),
+	})
+	if canaryPercent > 0 {
+		backends = append(backends, map[string]interface{}{
+			"service": makeSuffixedName(rootService, canaryVariant),
+			"weight":  int64(canaryPercent),
+		})
+	}
+	if baselinePercent > 0 {
+		backends = append(backends, map[string]interface{}{
+			"service": makeSuffixedName(rootService, baselineVariant),
+			"weight":  int64(baselinePercent),
+		})
+	}
+	spec["backends"] = backends
+
+	if err := m.SetStructuredSpec(spec); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+func checkVariantSelectorInService(m provider.Manifest, variantLabel, variant string) error {
 	selector, err := m.GetNestedStringMap("spec", "selector")
 	if err != nil {
 		return err
@@ -15,13 +15,21 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes/providertest"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 func TestGenerateVirtualServiceManifest(t *testing.T) {
@@ -66,6 +74,189 @@ func TestGenerateVirtualServiceManifest(t *testing.T) {
 	}
 }
 
+func TestGenerateTrafficSplitManifest(t *testing.T) {
+	testcases := []struct {
+		name         string
+		primary      int
+		canary       int
+		baseline     int
+		expectedFile string
+	}{
+		{
+			name:         "all variants",
+			primary:      50,
+			canary:       30,
+			baseline:     20,
+			expectedFile: "testdata/generated-traffic-split.yaml",
+		},
+		{
+			name:         "no baseline",
+			primary:      80,
+			canary:       20,
+			expectedFile: "testdata/generated-traffic-split-without-baseline.yaml",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := provider.LoadManifestsFromYAMLFile("testdata/traffic-split.yaml")
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			generatedManifest, err := generateTrafficSplitManifest(manifests[0], tc.primary, tc.canary, tc.baseline)
+			assert.NoError(t, err)
+
+			expectedManifests, err := provider.LoadManifestsFromYAMLFile(tc.expectedFile)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(expectedManifests))
+
+			expected, err := expectedManifests[0].YamlBytes()
+			require.NoError(t, err)
+			got, err := generatedManifest.YamlBytes()
+			require.NoError(t, err)
+
+			assert.EqualValues(t, string(expected), string(got))
+		})
+	}
+}
+
+func TestFindSMITrafficSplitManifests(t *testing.T) {
+	manifests, err := provider.LoadManifestsFromYAMLFile("testdata/traffic-split.yaml")
+	require.NoError(t, err)
+	services, err := provider.LoadManifestsFromYAMLFile("testdata/services.yaml")
+	require.NoError(t, err)
+	manifests = append(manifests, services...)
+
+	found, err := findSMITrafficSplitManifests(manifests, config.K8sResourceReference{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(found))
+	assert.Equal(t, "helloworld", found[0].Key.Name)
+
+	found, err = findSMITrafficSplitManifests(manifests, config.K8sResourceReference{Name: "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(found))
+
+	_, err = findSMITrafficSplitManifests(manifests, config.K8sResourceReference{Kind: "VirtualService"})
+	assert.Error(t, err)
+}
+
+func TestGenerateTrafficRoutingManifestByPodSelector(t *testing.T) {
+	const serviceManifest = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: simple
+spec:
+  selector:
+    app: simple
+    pipecd.dev/variant: primary
+`
+	testcases := []struct {
+		name             string
+		primary          int
+		canary           int
+		baseline         int
+		expectedSelector map[string]string
+		wantErr          bool
+	}{
+		{
+			name:             "all to primary",
+			primary:          100,
+			expectedSelector: map[string]string{"app": "simple", "pipecd.dev/variant": "primary"},
+		},
+		{
+			name:             "all to canary",
+			canary:           100,
+			expectedSelector: map[string]string{"app": "simple", "pipecd.dev/variant": "canary"},
+		},
+		{
+			name:             "split between primary and canary",
+			primary:          80,
+			canary:           20,
+			expectedSelector: map[string]string{"app": "simple"},
+		},
+		{
+			name:     "route to baseline",
+			primary:  60,
+			canary:   20,
+			baseline: 20,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := provider.ParseManifests(serviceManifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			e := &deployExecutor{variantLabel: "pipecd.dev/variant"}
+			generated, err := e.generateTrafficRoutingManifest(manifests[0], tc.primary, tc.canary, tc.baseline, nil)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			selector, err := generated.GetNestedStringMap("spec", "selector")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSelector, selector)
+		})
+	}
+}
+
+func TestScaleCanaryForTrafficRouting(t *testing.T) {
+	manifests, err := provider.LoadManifestsFromYAMLFile("testdata/deployments.yaml")
+	require.NoError(t, err)
+	// Use only the original one since the second one is a generated CANARY workload.
+	manifests = manifests[:1]
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var applied []provider.Manifest
+	p := providertest.NewMockProvider(ctrl)
+	p.EXPECT().ApplyManifest(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m provider.Manifest) error {
+		applied = append(applied, m)
+		return nil
+	}).AnyTimes()
+
+	e := &deployExecutor{
+		Input: executor.Input{
+			Deployment:   &model.Deployment{ApplicationId: "app-id"},
+			LogPersister: &fakeLogPersister{},
+			PipedConfig:  &config.PipedSpec{},
+			Logger:       zap.NewNop(),
+		},
+		provider:     p,
+		variantLabel: "pipecd.dev/variant",
+		deployCfg: &config.KubernetesDeploymentSpec{
+			GenericDeploymentSpec: config.GenericDeploymentSpec{
+				Pipeline: &config.DeploymentPipeline{
+					Stages: []config.PipelineStage{
+						{
+							Name:                         model.StageK8sCanaryRollout,
+							K8sCanaryRolloutStageOptions: &config.K8sCanaryRolloutStageOptions{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// The number of PRIMARY replicas is 10 so 25% of it is used for CANARY.
+	err = e.scaleCanaryForTrafficRouting(context.Background(), manifests, 80, 20)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(applied))
+	assert.Equal(t, "simple-canary", applied[0].Key.Name)
+
+	spec, err := applied[0].GetNestedMap("spec")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), spec["replicas"])
+
+	// PRIMARY must receive a part of traffic.
+	err = e.scaleCanaryForTrafficRouting(context.Background(), manifests, 0, 50)
+	assert.Error(t, err)
+}
+
 func TestCheckVariantSelectorInService(t *testing.T) {
 	testcases := []struct {
 		name     string
@@ -120,8 +311,133 @@ spec:
 			require.NoError(t, err)
 			require.Equal(t, 1, len(manifests))
 
-			err = checkVariantSelectorInService(manifests[0], primaryVariant)
+			err = checkVariantSelectorInService(manifests[0], config.DefaultKubernetesVariantLabel, primaryVariant)
 			assert.Equal(t, tc.expected, err)
 		})
 	}
 }
+
+type memoryMetadataStore struct {
+	fakeMetadataStore
+	stages map[string]map[string]string
+}
+
+func (m *memoryMetadataStore) GetStageMetadata(stageID string) (map[string]string, bool) {
+	md, ok := m.stages[stageID]
+	return md, ok
+}
+
+func (m *memoryMetadataStore) PutStageMetadata(_ context.Context, stageID string, metadata map[string]string) error {
+	if m.stages[stageID] == nil {
+		m.stages[stageID] = make(map[string]string, len(metadata))
+	}
+	for k, v := range metadata {
+		m.stages[stageID][k] = v
+	}
+	return nil
+}
+
+func TestShiftTrafficProgressively(t *testing.T) {
+	manifests, err := provider.LoadManifestsFromYAMLFile("testdata/virtual-service.yaml")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(manifests))
+
+	newSteps := func(wait time.Duration) []config.K8sTrafficRoutingStep {
+		return []config.K8sTrafficRoutingStep{
+			{Canary: 10},
+			{Canary: 30, Baseline: 30, Wait: config.Duration(wait)},
+			{Canary: 100},
+		}
+	}
+
+	testcases := []struct {
+		name             string
+		steps            []config.K8sTrafficRoutingStep
+		savedStep        string
+		cancelled        bool
+		expectedApplies  int
+		expectedStatus   model.StageStatus
+		expectedMetadata map[string]string
+	}{
+		{
+			name:            "shift through all steps",
+			steps:           newSteps(time.Millisecond),
+			expectedApplies: 3,
+			expectedStatus:  model.StageStatus_STAGE_SUCCESS,
+			expectedMetadata: map[string]string{
+				stepMetadataKey:     "2",
+				primaryMetadataKey:  "0",
+				canaryMetadataKey:   "100",
+				baselineMetadataKey: "0",
+			},
+		},
+		{
+			name:            "resume from the saved step",
+			steps:           newSteps(time.Millisecond),
+			savedStep:       "1",
+			expectedApplies: 2,
+			expectedStatus:  model.StageStatus_STAGE_SUCCESS,
+			expectedMetadata: map[string]string{
+				stepMetadataKey:     "2",
+				primaryMetadataKey:  "0",
+				canaryMetadataKey:   "100",
+				baselineMetadataKey: "0",
+			},
+		},
+		{
+			name:            "stop while waiting",
+			steps:           newSteps(time.Hour),
+			savedStep:       "1",
+			cancelled:       true,
+			expectedApplies: 1,
+			expectedStatus:  model.StageStatus_STAGE_FAILURE,
+			expectedMetadata: map[string]string{
+				stepMetadataKey:     "1",
+				primaryMetadataKey:  "40",
+				canaryMetadataKey:   "30",
+				baselineMetadataKey: "30",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			p := providertest.NewMockProvider(ctrl)
+			p.EXPECT().ApplyManifest(gomock.Any(), gomock.Any()).Return(nil).Times(tc.expectedApplies)
+
+			store := &memoryMetadataStore{stages: map[string]map[string]string{}}
+			if tc.savedStep != "" {
+				store.stages["stage-id"] = map[string]string{stepMetadataKey: tc.savedStep}
+			}
+			e := &deployExecutor{
+				Input: executor.Input{
+					Deployment:    &model.Deployment{ApplicationId: "app-id"},
+					Stage:         &model.PipelineStage{Id: "stage-id"},
+					LogPersister:  &fakeLogPersister{},
+					MetadataStore: store,
+					PipedConfig:   &config.PipedSpec{},
+					Logger:        zap.NewNop(),
+				},
+				provider: p,
+				deployCfg: &config.KubernetesDeploymentSpec{
+					TrafficRouting: &config.KubernetesTrafficRouting{
+						Method: config.KubernetesTrafficRoutingMethodIstio,
+						Istio: &config.IstioTrafficRouting{
+							Host: "helloworld",
+						},
+					},
+				},
+			}
+
+			sig, handler := executor.NewStopSignal()
+			if tc.cancelled {
+				handler.Cancel()
+			}
+			status := e.shiftTrafficProgressively(sig, manifests, manifests[0], tc.steps)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedMetadata, store.stages["stage-id"])
+		})
+	}
+}
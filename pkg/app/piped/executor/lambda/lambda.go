@@ -23,7 +23,6 @@ import (
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/lambda"
 	"github.com/pipe-cd/pipe/pkg/app/piped/deploysource"
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
-	"github.com/pipe-cd/pipe/pkg/backoff"
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
@@ -294,23 +293,21 @@ func build(ctx context.Context, in *executor.Input, client provider.Client, fm p
 	}
 
 	in.LogPersister.Info("Waiting to update lambda function in progress...")
-	retry := backoff.NewRetry(provider.RequestRetryTime, backoff.NewConstant(provider.RetryIntervalDuration))
-	publishFunctionSucceed := false
+	retry := provider.NewRetry()
 	startWaitingStamp := time.Now()
-	for retry.WaitNext(ctx) {
+	err = retry.Do(ctx, func(ctx context.Context) error {
 		// Commit version for applied Lambda function.
 		// Note: via the current docs of [Lambda.PublishVersion](https://docs.aws.amazon.com/sdk-for-go/api/service/lambda/#Lambda.PublishVersion)
 		// AWS Lambda doesn't publish a version if the function's configuration and code haven't changed since the last version.
 		// But currently, unchanged revision is able to make publish (versionId++) as usual.
+		var err error
 		version, err = client.PublishFunction(ctx, fm)
 		if err != nil {
 			in.Logger.Error("Failed publish new version for Lambda function")
-		} else {
-			publishFunctionSucceed = true
-			break
 		}
-	}
-	if !publishFunctionSucceed {
+		return err
+	})
+	if err != nil {
 		in.LogPersister.Errorf("Failed to commit new version for Lambda function %s: %v", fm.Spec.Name, err)
 		return
 	}
@@ -0,0 +1,101 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// StageMetadata is a key/value store of the metadata of a stage.
+// The stored values are persisted via the control plane, so they can be used
+// to resume the stage after piped was restarted, to show some information
+// such as links or traffic percentages on the web UI,
+// and to pass some data from a stage to its rollback counterpart.
+type StageMetadata struct {
+	store   MetadataStore
+	stageID string
+}
+
+// NewStageMetadata returns the metadata of the specified stage.
+func NewStageMetadata(store MetadataStore, stageID string) StageMetadata {
+	return StageMetadata{
+		store:   store,
+		stageID: stageID,
+	}
+}
+
+// StageMetadata returns the metadata of the running stage.
+func (in Input) StageMetadata() StageMetadata {
+	return NewStageMetadata(in.MetadataStore, in.Stage.Id)
+}
+
+// StageMetadataByName returns the metadata of the last stage with the given name
+// in the pipeline of the running deployment. This is useful to read the data
+// saved by a stage from its rollback counterpart.
+func (in Input) StageMetadataByName(name model.Stage) (StageMetadata, bool) {
+	stages := in.Deployment.Stages
+	for i := len(stages) - 1; i >= 0; i-- {
+		if stages[i].Name == name.String() {
+			return NewStageMetadata(in.MetadataStore, stages[i].Id), true
+		}
+	}
+	return StageMetadata{}, false
+}
+
+// Get returns the value of the given key.
+func (m StageMetadata) Get(key string) (string, bool) {
+	metadata, ok := m.store.GetStageMetadata(m.stageID)
+	if !ok {
+		return "", false
+	}
+	v, ok := metadata[key]
+	return v, ok
+}
+
+// GetJSON decodes the JSON value of the given key into v.
+// The returned boolean is false if the key was not found.
+func (m StageMetadata) GetJSON(key string, v interface{}) (bool, error) {
+	value, ok := m.Get(key)
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return true, fmt.Errorf("failed to decode the stage metadata %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Put saves the given value for the given key while keeping the others.
+func (m StageMetadata) Put(ctx context.Context, key, value string) error {
+	return m.PutMulti(ctx, map[string]string{key: value})
+}
+
+// PutMulti saves all given key/value pairs while keeping the others.
+func (m StageMetadata) PutMulti(ctx context.Context, metadata map[string]string) error {
+	return m.store.PutStageMetadata(ctx, m.stageID, metadata)
+}
+
+// PutJSON saves the JSON encoded value of v for the given key while keeping the others.
+func (m StageMetadata) PutJSON(ctx context.Context, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode the stage metadata %s: %w", key, err)
+	}
+	return m.Put(ctx, key, string(data))
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeMetadataStore struct {
+	MetadataStore
+	stages map[string]map[string]string
+}
+
+func (s *fakeMetadataStore) GetStageMetadata(stageID string) (map[string]string, bool) {
+	m, ok := s.stages[stageID]
+	return m, ok
+}
+
+func (s *fakeMetadataStore) PutStageMetadata(_ context.Context, stageID string, metadata map[string]string) error {
+	if s.stages[stageID] == nil {
+		s.stages[stageID] = make(map[string]string)
+	}
+	for k, v := range metadata {
+		s.stages[stageID][k] = v
+	}
+	return nil
+}
+
+func TestStageMetadata(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeMetadataStore{
+		stages: map[string]map[string]string{
+			"stage-1": {"Approvers": "foo"},
+		},
+	}
+	m := NewStageMetadata(store, "stage-1")
+
+	v, ok := m.Get("Approvers")
+	assert.True(t, ok)
+	assert.Equal(t, "foo", v)
+
+	_, ok = m.Get("not-found")
+	assert.False(t, ok)
+
+	require.NoError(t, m.Put(ctx, "Approved-By", "bar"))
+	require.NoError(t, m.PutJSON(ctx, "resources", []string{"a", "b"}))
+	assert.Equal(t, map[string]string{
+		"Approvers":   "foo",
+		"Approved-By": "bar",
+		"resources":   `["a","b"]`,
+	}, store.stages["stage-1"])
+
+	var resources []string
+	ok, err := m.GetJSON("resources", &resources)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, resources)
+
+	ok, err = m.GetJSON("Approvers", &resources)
+	assert.Error(t, err)
+	assert.True(t, ok)
+
+	ok, err = NewStageMetadata(store, "stage-2").GetJSON("resources", &resources)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestStageMetadataByName(t *testing.T) {
+	store := &fakeMetadataStore{
+		stages: map[string]map[string]string{
+			"canary-1": {"key": "first"},
+			"canary-2": {"key": "second"},
+		},
+	}
+	in := Input{
+		Deployment: &model.Deployment{
+			Stages: []*model.PipelineStage{
+				{Id: "canary-1", Name: model.StageK8sCanaryRollout.String()},
+				{Id: "primary", Name: model.StageK8sPrimaryRollout.String()},
+				{Id: "canary-2", Name: model.StageK8sCanaryRollout.String()},
+			},
+		},
+		MetadataStore: store,
+	}
+
+	m, ok := in.StageMetadataByName(model.StageK8sCanaryRollout)
+	require.True(t, ok)
+	v, _ := m.Get("key")
+	assert.Equal(t, "second", v)
+
+	_, ok = in.StageMetadataByName(model.StageK8sBaselineRollout)
+	assert.False(t, ok)
+}
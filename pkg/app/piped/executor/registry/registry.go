@@ -20,6 +20,7 @@ import (
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor/analysis"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor/changerequest"
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor/cloudrun"
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor/ecs"
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor/kubernetes"
@@ -104,4 +105,5 @@ func init() {
 	ecs.Register(defaultRegistry)
 	wait.Register(defaultRegistry)
 	waitapproval.Register(defaultRegistry)
+	changerequest.Register(defaultRegistry)
 }
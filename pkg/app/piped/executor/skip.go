@@ -0,0 +1,84 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const (
+	skippedByKey = "SkippedBy"
+	// The interval to check whether a command to skip the stage was received.
+	skipCommandCheckInterval = 5 * time.Second
+)
+
+// CheckSkipped checks whether a command to skip the running stage was received.
+// When received, the commander is saved into the stage metadata
+// and the command is reported as handled.
+func (in Input) CheckSkipped(ctx context.Context) (commander string, skipped bool) {
+	if in.CommandLister == nil {
+		return "", false
+	}
+
+	var skipCmd *model.ReportableCommand
+	commands := in.CommandLister.ListCommands()
+	for i, cmd := range commands {
+		if cmd.GetSkipStage() != nil {
+			skipCmd = &commands[i]
+			break
+		}
+	}
+	if skipCmd == nil {
+		return "", false
+	}
+
+	if err := in.StageMetadata().Put(ctx, skippedByKey, skipCmd.Commander); err != nil {
+		in.LogPersister.Errorf("Unable to save the skipper information to deployment, %v", err)
+		return "", false
+	}
+	if err := skipCmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+		in.Logger.Error("failed to report handled command", zap.Error(err))
+	}
+	return skipCmd.Commander, true
+}
+
+// WatchSkipCommand returns a channel that receives the commander once a command
+// to skip the running stage was received.
+// It stops watching when the given context is done.
+func (in Input) WatchSkipCommand(ctx context.Context) <-chan string {
+	ch := make(chan string, 1)
+	go func() {
+		ticker := time.NewTicker(skipCommandCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if commander, ok := in.CheckSkipped(ctx); ok {
+					ch <- commander
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeCommandLister struct {
+	commands []model.ReportableCommand
+}
+
+func (l *fakeCommandLister) ListCommands() []model.ReportableCommand {
+	return l.commands
+}
+
+func (l *fakeCommandLister) Updated() <-chan struct{} {
+	return nil
+}
+
+func TestCheckSkipped(t *testing.T) {
+	var reported model.CommandStatus
+	report := func(_ context.Context, status model.CommandStatus, _ map[string]string, _ []byte) error {
+		reported = status
+		return nil
+	}
+	testcases := []struct {
+		name              string
+		commands          []model.ReportableCommand
+		expectedCommander string
+		expectedSkipped   bool
+	}{
+		{
+			name: "no command",
+		},
+		{
+			name: "approve command only",
+			commands: []model.ReportableCommand{
+				{
+					Command: &model.Command{
+						Commander:    "user-1",
+						ApproveStage: &model.Command_ApproveStage{},
+					},
+					Report: report,
+				},
+			},
+		},
+		{
+			name: "skip command",
+			commands: []model.ReportableCommand{
+				{
+					Command: &model.Command{
+						Commander:    "user-1",
+						ApproveStage: &model.Command_ApproveStage{},
+					},
+					Report: report,
+				},
+				{
+					Command: &model.Command{
+						Commander: "user-2",
+						SkipStage: &model.Command_SkipStage{},
+					},
+					Report: report,
+				},
+			},
+			expectedCommander: "user-2",
+			expectedSkipped:   true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			reported = model.CommandStatus_COMMAND_NOT_HANDLED_YET
+			store := &fakeMetadataStore{
+				stages: map[string]map[string]string{},
+			}
+			in := Input{
+				Stage:         &model.PipelineStage{Id: "stage-1"},
+				CommandLister: &fakeCommandLister{commands: tc.commands},
+				MetadataStore: store,
+				Logger:        zap.NewNop(),
+			}
+			commander, skipped := in.CheckSkipped(context.Background())
+			assert.Equal(t, tc.expectedCommander, commander)
+			assert.Equal(t, tc.expectedSkipped, skipped)
+			if tc.expectedSkipped {
+				assert.Equal(t, model.CommandStatus_COMMAND_SUCCEEDED, reported)
+				assert.Equal(t, tc.expectedCommander, store.stages["stage-1"][skippedByKey])
+			}
+		})
+	}
+}
@@ -82,6 +82,10 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 	ticker := time.NewTicker(logInterval)
 	defer ticker.Stop()
 
+	ctx, cancel := context.WithCancel(sig.Context())
+	defer cancel()
+	skipCh := e.WatchSkipCommand(ctx)
+
 	e.LogPersister.Infof("Waiting for %v...", duration)
 	for {
 		select {
@@ -89,6 +93,10 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 			e.LogPersister.Infof("Waited for %v", totalDuration)
 			return model.StageStatus_STAGE_SUCCESS
 
+		case commander := <-skipCh:
+			e.LogPersister.Infof("Skipped by %s after waiting for %v", commander, time.Since(startTime))
+			return model.StageStatus_STAGE_SKIPPED
+
 		case <-ticker.C:
 			e.LogPersister.Infof("%v elapsed...", time.Since(startTime))
 
@@ -106,11 +114,7 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 }
 
 func (e *Executor) retrieveStartTime() (t time.Time) {
-	metadata, ok := e.MetadataStore.GetStageMetadata(e.Stage.Id)
-	if !ok {
-		return
-	}
-	s, ok := metadata[startTimeKey]
+	s, ok := e.StageMetadata().Get(startTimeKey)
 	if !ok {
 		return
 	}
@@ -122,10 +126,7 @@ func (e *Executor) retrieveStartTime() (t time.Time) {
 }
 
 func (e *Executor) saveStartTime(ctx context.Context, t time.Time) {
-	metadata := map[string]string{
-		startTimeKey: strconv.FormatInt(t.Unix(), 10),
-	}
-	if err := e.MetadataStore.SetStageMetadata(ctx, e.Stage.Id, metadata); err != nil {
+	if err := e.StageMetadata().Put(ctx, startTimeKey, strconv.FormatInt(t.Unix(), 10)); err != nil {
 		e.Logger.Error("failed to store metadata", zap.Error(err))
 	}
 }
@@ -16,16 +16,19 @@ package waitapproval
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 const (
 	approvedByKey = "ApprovedBy"
+	rejectedByKey = "RejectedBy"
 )
 
 type Executor struct {
@@ -52,20 +55,45 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 		originalStatus = e.Stage.Status
 		ctx            = sig.Context()
 		ticker         = time.NewTicker(5 * time.Second)
+		options        = e.StageConfig.WaitApprovalStageOptions
 	)
 	defer ticker.Stop()
-	timeout := e.StageConfig.WaitApprovalStageOptions.Timeout.Duration()
+	timeout := options.Timeout.Duration()
 	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var reminderCh <-chan time.Time
+	if interval := options.ReminderInterval.Duration(); interval > 0 {
+		reminder := time.NewTicker(interval)
+		defer reminder.Stop()
+		reminderCh = reminder.C
+	}
+	timeoutAt := time.Now().Add(timeout)
+
+	// The control plane issues the approval links only for these users.
+	if len(options.Approvers) > 0 {
+		if err := e.StageMetadata().Put(ctx, model.MetadataKeyStageApprovers, strings.Join(options.Approvers, ",")); err != nil {
+			e.Logger.Error("failed to save approvers to stage metadata", zap.Error(err))
+		}
+	}
 
 	e.LogPersister.Info("Waiting for an approval...")
+	e.notify(timeoutAt)
 	for {
 		select {
 		case <-ticker.C:
-			if commander, ok := e.checkApproval(ctx); ok {
-				e.LogPersister.Infof("Got an approval from %s", commander)
-				return model.StageStatus_STAGE_SUCCESS
+			if status, ok := e.checkDecision(ctx); ok {
+				return status
+			}
+
+		case <-e.CommandLister.Updated():
+			if status, ok := e.checkDecision(ctx); ok {
+				return status
 			}
 
+		case <-reminderCh:
+			e.notify(timeoutAt)
+
 		case s := <-sig.Ch():
 			switch s {
 			case executor.StopSignalCancel:
@@ -76,12 +104,55 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 				return model.StageStatus_STAGE_FAILURE
 			}
 		case <-timer.C:
-			e.LogPersister.Errorf("Timed out %v", timeout)
-			return model.StageStatus_STAGE_FAILURE
+			return e.handleTimeout(timeout, options.TimeoutAction)
 		}
 	}
 }
 
+// checkDecision returns the status of the stage
+// if an approval or a rejection was made.
+func (e *Executor) checkDecision(ctx context.Context) (model.StageStatus, bool) {
+	if commander, ok := e.checkApproval(ctx); ok {
+		e.LogPersister.Infof("Got an approval from %s", commander)
+		return model.StageStatus_STAGE_SUCCESS, true
+	}
+	if commander, ok := e.checkRejection(ctx); ok {
+		e.LogPersister.Errorf("Got a rejection from %s", commander)
+		return model.StageStatus_STAGE_FAILURE, true
+	}
+	return e.checkNotificationDecision(ctx)
+}
+
+func (e *Executor) handleTimeout(timeout time.Duration, action config.WaitApprovalTimeoutAction) model.StageStatus {
+	switch action {
+	case config.WaitApprovalTimeoutActionApprove:
+		e.LogPersister.Infof("Timed out %v, the stage is automatically approved", timeout)
+		return model.StageStatus_STAGE_SUCCESS
+	case config.WaitApprovalTimeoutActionCancel:
+		e.LogPersister.Infof("Timed out %v, the deployment is cancelled without rollback", timeout)
+		return model.StageStatus_STAGE_CANCELLED
+	default:
+		e.LogPersister.Errorf("Timed out %v", timeout)
+		return model.StageStatus_STAGE_FAILURE
+	}
+}
+
+func (e *Executor) notify(timeoutAt time.Time) {
+	if e.Notifier == nil {
+		return
+	}
+	e.Notifier.Notify(model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL,
+		Metadata: &model.NotificationEventDeploymentWaitApproval{
+			Deployment: e.Deployment,
+			EnvName:    e.EnvName,
+			Approvers:  e.StageConfig.WaitApprovalStageOptions.Approvers,
+			TimeoutAt:  timeoutAt.Unix(),
+			StageId:    e.Stage.Id,
+		},
+	})
+}
+
 func (e *Executor) checkApproval(ctx context.Context) (string, bool) {
 	var approveCmd *model.ReportableCommand
 	commands := e.CommandLister.ListCommands()
@@ -96,21 +167,80 @@ func (e *Executor) checkApproval(ctx context.Context) (string, bool) {
 		return "", false
 	}
 
-	metadata := map[string]string{
-		approvedByKey: approveCmd.Commander,
+	if err := e.StageMetadata().Put(ctx, approvedByKey, approveCmd.Commander); err != nil {
+		e.LogPersister.Errorf("Unabled to save approver information to deployment, %v", err)
+		return "", false
+	}
+
+	if err := approveCmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+		e.Logger.Error("failed to report handled command", zap.Error(err))
 	}
-	if ori, ok := e.MetadataStore.GetStageMetadata(e.Stage.Id); ok {
-		for k, v := range ori {
-			metadata[k] = v
+	return approveCmd.Commander, true
+}
+
+// checkRejection checks whether the stage was rejected by a command
+// such as the one sent through the rejection link of email notifications.
+func (e *Executor) checkRejection(ctx context.Context) (string, bool) {
+	var rejectCmd *model.ReportableCommand
+	commands := e.CommandLister.ListCommands()
+
+	for i, cmd := range commands {
+		if cmd.GetRejectStage() != nil {
+			rejectCmd = &commands[i]
+			break
 		}
 	}
-	if err := e.MetadataStore.SetStageMetadata(ctx, e.Stage.Id, metadata); err != nil {
-		e.LogPersister.Errorf("Unabled to save approver information to deployment, %v", err)
+	if rejectCmd == nil {
 		return "", false
 	}
 
-	if err := approveCmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil); err != nil {
+	if err := e.StageMetadata().Put(ctx, rejectedByKey, rejectCmd.Commander); err != nil {
+		e.LogPersister.Errorf("Unabled to save rejecter information to deployment, %v", err)
+	}
+
+	if err := rejectCmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
 		e.Logger.Error("failed to report handled command", zap.Error(err))
 	}
-	return approveCmd.Commander, true
+	return rejectCmd.Commander, true
+}
+
+// checkNotificationDecision checks whether the stage was approved or rejected
+// from the notifications such as by the buttons of Slack messages.
+func (e *Executor) checkNotificationDecision(ctx context.Context) (model.StageStatus, bool) {
+	if e.ApprovalTaker == nil {
+		return model.StageStatus_STAGE_NOT_STARTED_YET, false
+	}
+	d, ok := e.ApprovalTaker.Take(e.Deployment.Id, e.Stage.Id)
+	if !ok {
+		return model.StageStatus_STAGE_NOT_STARTED_YET, false
+	}
+
+	if approvers := e.StageConfig.WaitApprovalStageOptions.Approvers; len(approvers) > 0 && !contains(approvers, d.Commander) {
+		e.LogPersister.Infof("Ignored the decision from %s since the user is not one of the approvers", d.Commander)
+		return model.StageStatus_STAGE_NOT_STARTED_YET, false
+	}
+
+	if !d.Approved {
+		if err := e.StageMetadata().Put(ctx, rejectedByKey, d.Commander); err != nil {
+			e.LogPersister.Errorf("Unabled to save rejecter information to deployment, %v", err)
+		}
+		e.LogPersister.Errorf("Got a rejection from %s", d.Commander)
+		return model.StageStatus_STAGE_FAILURE, true
+	}
+
+	if err := e.StageMetadata().Put(ctx, approvedByKey, d.Commander); err != nil {
+		e.LogPersister.Errorf("Unabled to save approver information to deployment, %v", err)
+		return model.StageStatus_STAGE_NOT_STARTED_YET, false
+	}
+	e.LogPersister.Infof("Got an approval from %s", d.Commander)
+	return model.StageStatus_STAGE_SUCCESS, true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,301 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitapproval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/approvalstore"
+	"github.com/pipe-cd/pipe/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeLogPersister struct{}
+
+func (l *fakeLogPersister) Write(_ []byte) (int, error)         { return 0, nil }
+func (l *fakeLogPersister) Info(_ string)                       {}
+func (l *fakeLogPersister) Infof(_ string, _ ...interface{})    {}
+func (l *fakeLogPersister) Success(_ string)                    {}
+func (l *fakeLogPersister) Successf(_ string, _ ...interface{}) {}
+func (l *fakeLogPersister) Error(_ string)                      {}
+func (l *fakeLogPersister) Errorf(_ string, _ ...interface{})   {}
+
+type fakeCommandLister struct {
+	commands []model.ReportableCommand
+}
+
+func (l *fakeCommandLister) ListCommands() []model.ReportableCommand { return l.commands }
+func (l *fakeCommandLister) Updated() <-chan struct{}                { return nil }
+
+type fakeNotifier struct {
+	events []model.NotificationEvent
+}
+
+func (n *fakeNotifier) Notify(event model.NotificationEvent) {
+	n.events = append(n.events, event)
+}
+
+type fakeApprovalTaker struct {
+	decision *approvalstore.Decision
+}
+
+func (t *fakeApprovalTaker) Take(_, _ string) (approvalstore.Decision, bool) {
+	if t.decision == nil {
+		return approvalstore.Decision{}, false
+	}
+	return *t.decision, true
+}
+
+type fakeMetadataStore struct {
+	executor.MetadataStore
+	metadata map[string]string
+}
+
+func (s *fakeMetadataStore) PutStageMetadata(_ context.Context, _ string, md map[string]string) error {
+	for k, v := range md {
+		s.metadata[k] = v
+	}
+	return nil
+}
+
+func TestExecuteTimeoutAction(t *testing.T) {
+	testcases := []struct {
+		name     string
+		action   config.WaitApprovalTimeoutAction
+		expected model.StageStatus
+	}{
+		{
+			name:     "default action",
+			expected: model.StageStatus_STAGE_FAILURE,
+		},
+		{
+			name:     "fail",
+			action:   config.WaitApprovalTimeoutActionFail,
+			expected: model.StageStatus_STAGE_FAILURE,
+		},
+		{
+			name:     "approve",
+			action:   config.WaitApprovalTimeoutActionApprove,
+			expected: model.StageStatus_STAGE_SUCCESS,
+		},
+		{
+			name:     "cancel",
+			action:   config.WaitApprovalTimeoutActionCancel,
+			expected: model.StageStatus_STAGE_CANCELLED,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Executor{
+				Input: executor.Input{
+					Stage: &model.PipelineStage{},
+					StageConfig: config.PipelineStage{
+						WaitApprovalStageOptions: &config.WaitApprovalStageOptions{
+							Timeout:       config.Duration(10 * time.Millisecond),
+							TimeoutAction: tc.action,
+						},
+					},
+					CommandLister: &fakeCommandLister{},
+					LogPersister:  &fakeLogPersister{},
+					Logger:        zap.NewNop(),
+				},
+			}
+			sig, _ := executor.NewStopSignal()
+			assert.Equal(t, tc.expected, e.Execute(sig))
+		})
+	}
+}
+
+func TestExecuteReminder(t *testing.T) {
+	notifier := &fakeNotifier{}
+	store := &fakeMetadataStore{metadata: map[string]string{}}
+	e := &Executor{
+		Input: executor.Input{
+			Stage: &model.PipelineStage{},
+			StageConfig: config.PipelineStage{
+				WaitApprovalStageOptions: &config.WaitApprovalStageOptions{
+					Timeout:          config.Duration(100 * time.Millisecond),
+					Approvers:        []string{"foo"},
+					ReminderInterval: config.Duration(30 * time.Millisecond),
+				},
+			},
+			Deployment:    &model.Deployment{ApplicationName: "app"},
+			EnvName:       "dev",
+			CommandLister: &fakeCommandLister{},
+			LogPersister:  &fakeLogPersister{},
+			Notifier:      notifier,
+			MetadataStore: store,
+			Logger:        zap.NewNop(),
+		},
+	}
+	sig, _ := executor.NewStopSignal()
+	assert.Equal(t, model.StageStatus_STAGE_FAILURE, e.Execute(sig))
+	assert.Equal(t, "foo", store.metadata[model.MetadataKeyStageApprovers])
+
+	if assert.NotEmpty(t, notifier.events) {
+		event := notifier.events[0]
+		assert.Equal(t, model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL, event.Type)
+		md := event.Metadata.(*model.NotificationEventDeploymentWaitApproval)
+		assert.Equal(t, "app", md.GetAppName())
+		assert.Equal(t, "dev", md.EnvName)
+		assert.Equal(t, []string{"foo"}, md.Approvers)
+	}
+}
+
+func TestCheckNotificationDecision(t *testing.T) {
+	testcases := []struct {
+		name             string
+		approvers        []string
+		decision         *approvalstore.Decision
+		expectedStatus   model.StageStatus
+		expectedDecided  bool
+		expectedMetadata map[string]string
+	}{
+		{
+			name:             "no decision",
+			expectedStatus:   model.StageStatus_STAGE_NOT_STARTED_YET,
+			expectedMetadata: map[string]string{},
+		},
+		{
+			name:             "approved",
+			decision:         &approvalstore.Decision{Approved: true, Commander: "foo"},
+			expectedStatus:   model.StageStatus_STAGE_SUCCESS,
+			expectedDecided:  true,
+			expectedMetadata: map[string]string{approvedByKey: "foo"},
+		},
+		{
+			name:             "rejected",
+			decision:         &approvalstore.Decision{Approved: false, Commander: "foo"},
+			expectedStatus:   model.StageStatus_STAGE_FAILURE,
+			expectedDecided:  true,
+			expectedMetadata: map[string]string{rejectedByKey: "foo"},
+		},
+		{
+			name:             "approved by one of the approvers",
+			approvers:        []string{"foo", "bar"},
+			decision:         &approvalstore.Decision{Approved: true, Commander: "bar"},
+			expectedStatus:   model.StageStatus_STAGE_SUCCESS,
+			expectedDecided:  true,
+			expectedMetadata: map[string]string{approvedByKey: "bar"},
+		},
+		{
+			name:             "ignored since not an approver",
+			approvers:        []string{"foo"},
+			decision:         &approvalstore.Decision{Approved: true, Commander: "bar"},
+			expectedStatus:   model.StageStatus_STAGE_NOT_STARTED_YET,
+			expectedMetadata: map[string]string{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeMetadataStore{metadata: map[string]string{}}
+			e := &Executor{
+				Input: executor.Input{
+					Stage:      &model.PipelineStage{Id: "stage-id"},
+					Deployment: &model.Deployment{Id: "deployment-id"},
+					StageConfig: config.PipelineStage{
+						WaitApprovalStageOptions: &config.WaitApprovalStageOptions{
+							Approvers: tc.approvers,
+						},
+					},
+					MetadataStore: store,
+					ApprovalTaker: &fakeApprovalTaker{decision: tc.decision},
+					LogPersister:  &fakeLogPersister{},
+					Logger:        zap.NewNop(),
+				},
+			}
+			status, decided := e.checkNotificationDecision(context.Background())
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedDecided, decided)
+			assert.Equal(t, tc.expectedMetadata, store.metadata)
+		})
+	}
+}
+
+func TestCheckRejection(t *testing.T) {
+	var reported model.CommandStatus
+	report := func(_ context.Context, status model.CommandStatus, _ map[string]string, _ []byte) error {
+		reported = status
+		return nil
+	}
+	testcases := []struct {
+		name             string
+		commands         []model.ReportableCommand
+		expectedRejecter string
+		expectedRejected bool
+		expectedMetadata map[string]string
+	}{
+		{
+			name:             "no command",
+			expectedMetadata: map[string]string{},
+		},
+		{
+			name: "no rejection",
+			commands: []model.ReportableCommand{
+				{
+					Command: &model.Command{
+						Commander:    "foo",
+						ApproveStage: &model.Command_ApproveStage{},
+					},
+					Report: report,
+				},
+			},
+			expectedMetadata: map[string]string{},
+		},
+		{
+			name: "rejected",
+			commands: []model.ReportableCommand{
+				{
+					Command: &model.Command{
+						Commander:   "foo",
+						RejectStage: &model.Command_RejectStage{},
+					},
+					Report: report,
+				},
+			},
+			expectedRejecter: "foo",
+			expectedRejected: true,
+			expectedMetadata: map[string]string{rejectedByKey: "foo"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			reported = model.CommandStatus_COMMAND_NOT_HANDLED_YET
+			store := &fakeMetadataStore{metadata: map[string]string{}}
+			e := &Executor{
+				Input: executor.Input{
+					Stage:         &model.PipelineStage{Id: "stage-id"},
+					CommandLister: &fakeCommandLister{commands: tc.commands},
+					MetadataStore: store,
+					LogPersister:  &fakeLogPersister{},
+					Logger:        zap.NewNop(),
+				},
+			}
+			rejecter, rejected := e.checkRejection(context.Background())
+			assert.Equal(t, tc.expectedRejecter, rejecter)
+			assert.Equal(t, tc.expectedRejected, rejected)
+			assert.Equal(t, tc.expectedMetadata, store.metadata)
+			if tc.expectedRejected {
+				assert.Equal(t, model.CommandStatus_COMMAND_SUCCEEDED, reported)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package healthchecker provides a piped component
+// that periodically checks the health of the other components
+// such as git repositories, cloud providers and the needed tools.
+// The checked statuses are reported to control-plane to let users
+// know why a piped is not working well.
+package healthchecker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/awsconfig"
+	"github.com/pipe-cd/pipe/pkg/app/piped/toolregistry"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const (
+	checkInterval = 5 * time.Minute
+	checkTimeout  = 30 * time.Second
+)
+
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+type Checker struct {
+	checks   []check
+	interval time.Duration
+	timeout  time.Duration
+	nowFunc  func() time.Time
+	logger   *zap.Logger
+
+	mu       sync.RWMutex
+	statuses []*model.Piped_ComponentStatus
+}
+
+// NewChecker creates a new Checker that checks
+// the repositories, cloud providers and tools configured in the given piped config.
+func NewChecker(cfg *config.PipedSpec, toolRegistry toolregistry.Registry, logger *zap.Logger) *Checker {
+	return newChecker(buildChecks(cfg, toolRegistry), logger)
+}
+
+func newChecker(checks []check, logger *zap.Logger) *Checker {
+	return &Checker{
+		checks:   checks,
+		interval: checkInterval,
+		timeout:  checkTimeout,
+		nowFunc:  time.Now,
+		logger:   logger.Named("health-checker"),
+	}
+}
+
+// Run periodically checks all components until the given context is done.
+func (c *Checker) Run(ctx context.Context) error {
+	c.logger.Info("start running health checker")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("health checker has been stopped")
+			return nil
+
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Check checks all components at once and stores their statuses.
+func (c *Checker) Check(ctx context.Context) {
+	var (
+		statuses = make([]*model.Piped_ComponentStatus, len(c.checks))
+		wg       sync.WaitGroup
+	)
+	for i := range c.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i] = c.runCheck(ctx, c.checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	c.mu.Lock()
+	c.statuses = statuses
+	c.mu.Unlock()
+}
+
+func (c *Checker) runCheck(ctx context.Context, ch check) *model.Piped_ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	status := &model.Piped_ComponentStatus{
+		Name:    ch.name,
+		Healthy: true,
+	}
+	if err := ch.run(ctx); err != nil {
+		c.logger.Error(fmt.Sprintf("component %s is unhealthy", ch.name), zap.Error(err))
+		status.Healthy = false
+		status.Reason = err.Error()
+	}
+	status.CheckedAt = c.nowFunc().Unix()
+	return status
+}
+
+// ListComponentStatuses returns the latest checked statuses of all components.
+func (c *Checker) ListComponentStatuses() []*model.Piped_ComponentStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.statuses
+}
+
+func buildChecks(cfg *config.PipedSpec, reg toolregistry.Registry) []check {
+	checks := make([]check, 0, len(cfg.Repositories)+len(cfg.CloudProviders))
+
+	for _, r := range cfg.Repositories {
+		r := r
+		checks = append(checks, check{
+			name: fmt.Sprintf("git/%s", r.RepoID),
+			run: func(ctx context.Context) error {
+				return checkGitRepository(ctx, r.Remote, r.Branch)
+			},
+		})
+	}
+
+	var (
+		needKubernetesTools bool
+		needTerraform       bool
+	)
+	for _, cp := range cfg.CloudProviders {
+		switch cp.Type {
+		case model.CloudProviderKubernetes:
+			needKubernetesTools = true
+			kc := cp.KubernetesConfig
+			checks = append(checks, check{
+				name: fmt.Sprintf("cloudprovider/%s", cp.Name),
+				run: func(ctx context.Context) error {
+					return checkKubernetes(kc)
+				},
+			})
+		case model.CloudProviderTerraform:
+			needTerraform = true
+		case model.CloudProviderCloudRun:
+			cc := cp.CloudRunConfig
+			checks = append(checks, check{
+				name: fmt.Sprintf("cloudprovider/%s", cp.Name),
+				run: func(ctx context.Context) error {
+					return checkGCPCredentials(ctx, gcpcredentials.Options{
+						CredentialsFile:           cc.CredentialsFile,
+						ImpersonateServiceAccount: cc.ImpersonateServiceAccount,
+					})
+				},
+			})
+		case model.CloudProviderLambda:
+			lc := cp.LambdaConfig
+			checks = append(checks, check{
+				name: fmt.Sprintf("cloudprovider/%s", cp.Name),
+				run: func(ctx context.Context) error {
+					return checkAWSCredentials(ctx, awsconfig.Options{
+						Region:          lc.Region,
+						Profile:         lc.Profile,
+						CredentialsFile: lc.CredentialsFile,
+						RoleARN:         lc.RoleARN,
+						TokenFile:       lc.TokenFile,
+						AssumeRoleARN:   lc.AssumeRoleARN,
+						ExternalID:      lc.ExternalID,
+						SessionTags:     lc.SessionTags,
+					})
+				},
+			})
+		case model.CloudProviderECS:
+			ec := cp.ECSConfig
+			checks = append(checks, check{
+				name: fmt.Sprintf("cloudprovider/%s", cp.Name),
+				run: func(ctx context.Context) error {
+					return checkAWSCredentials(ctx, awsconfig.Options{
+						Region:          ec.Region,
+						Profile:         ec.Profile,
+						CredentialsFile: ec.CredentialsFile,
+						RoleARN:         ec.RoleARN,
+						TokenFile:       ec.TokenFile,
+						AssumeRoleARN:   ec.AssumeRoleARN,
+						ExternalID:      ec.ExternalID,
+						SessionTags:     ec.SessionTags,
+					})
+				},
+			})
+		}
+	}
+
+	if needKubernetesTools {
+		checks = append(checks,
+			check{name: "tool/kubectl", run: toolCheck(reg.Kubectl)},
+			check{name: "tool/kustomize", run: toolCheck(reg.Kustomize)},
+			check{name: "tool/helm", run: toolCheck(reg.Helm)},
+		)
+	}
+	if needTerraform {
+		checks = append(checks, check{name: "tool/terraform", run: toolCheck(reg.Terraform)})
+	}
+
+	return checks
+}
+
+func checkGitRepository(ctx context.Context, remote, branch string) error {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--exit-code", remote, "refs/heads/"+branch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("unable to access branch %s of %s: %v (%s)", branch, remote, err, string(out))
+	}
+	return nil
+}
+
+func toolCheck(get func(ctx context.Context, version string) (string, bool, error)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		// Ensure the default version is installed.
+		if _, _, err := get(ctx, ""); err != nil {
+			return fmt.Errorf("the default version is not installed: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package healthchecker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestCheck(t *testing.T) {
+	checks := []check{
+		{
+			name: "tool/kubectl",
+			run: func(_ context.Context) error {
+				return nil
+			},
+		},
+		{
+			name: "git/repo-1",
+			run: func(_ context.Context) error {
+				return errors.New("permission denied")
+			},
+		},
+	}
+	c := newChecker(checks, zap.NewNop())
+	c.nowFunc = func() time.Time { return time.Unix(100, 0) }
+
+	assert.Equal(t, 0, len(c.ListComponentStatuses()))
+
+	c.Check(context.Background())
+	expected := []*model.Piped_ComponentStatus{
+		{
+			Name:      "git/repo-1",
+			Healthy:   false,
+			Reason:    "permission denied",
+			CheckedAt: 100,
+		},
+		{
+			Name:      "tool/kubectl",
+			Healthy:   true,
+			CheckedAt: 100,
+		},
+	}
+	assert.Equal(t, expected, c.ListComponentStatuses())
+}
+
+type fakeRegistry struct{}
+
+func (fakeRegistry) Kubectl(_ context.Context, _ string) (string, bool, error) {
+	return "kubectl", false, nil
+}
+
+func (fakeRegistry) Kustomize(_ context.Context, _ string) (string, bool, error) {
+	return "kustomize", false, nil
+}
+
+func (fakeRegistry) Helm(_ context.Context, _ string) (string, bool, error) {
+	return "helm", false, nil
+}
+
+func (fakeRegistry) Helmfile(_ context.Context, _ string) (string, bool, error) {
+	return "helmfile", false, nil
+}
+
+func (fakeRegistry) Jsonnet(_ context.Context, _ string) (string, bool, error) {
+	return "jsonnet", false, nil
+}
+
+func (fakeRegistry) JsonnetBundler(_ context.Context, _ string) (string, bool, error) {
+	return "jb", false, nil
+}
+
+func (fakeRegistry) Ytt(_ context.Context, _ string) (string, bool, error) {
+	return "ytt", false, nil
+}
+
+func (fakeRegistry) Kapp(_ context.Context, _ string) (string, bool, error) {
+	return "kapp", false, nil
+}
+
+func (fakeRegistry) Sops(_ context.Context, _ string) (string, bool, error) {
+	return "sops", false, nil
+}
+
+func (fakeRegistry) Terraform(_ context.Context, _ string) (string, bool, error) {
+	return "", false, errors.New("failed to download")
+}
+
+func (fakeRegistry) AWSIAMAuthenticator(_ context.Context, _ string) (string, bool, error) {
+	return "aws-iam-authenticator", false, nil
+}
+
+func TestBuildChecks(t *testing.T) {
+	cfg := &config.PipedSpec{
+		Repositories: []config.PipedRepository{
+			{RepoID: "repo-1"},
+		},
+		CloudProviders: []config.PipedCloudProvider{
+			{
+				Name:             "kubernetes-default",
+				Type:             model.CloudProviderKubernetes,
+				KubernetesConfig: &config.CloudProviderKubernetesConfig{},
+			},
+			{
+				Name:            "terraform-default",
+				Type:            model.CloudProviderTerraform,
+				TerraformConfig: &config.CloudProviderTerraformConfig{},
+			},
+			{
+				Name:         "lambda-default",
+				Type:         model.CloudProviderLambda,
+				LambdaConfig: &config.CloudProviderLambdaConfig{},
+			},
+		},
+	}
+
+	checks := buildChecks(cfg, fakeRegistry{})
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		names = append(names, c.name)
+	}
+	expected := []string{
+		"git/repo-1",
+		"cloudprovider/kubernetes-default",
+		"cloudprovider/lambda-default",
+		"tool/kubectl",
+		"tool/kustomize",
+		"tool/helm",
+		"tool/terraform",
+	}
+	assert.Equal(t, expected, names)
+
+	assert.Error(t, checks[2].run(context.Background()), "region is required")
+	assert.NoError(t, checks[3].run(context.Background()))
+	assert.Error(t, checks[6].run(context.Background()))
+}
+
+func TestCheckGCPCredentials(t *testing.T) {
+	err := checkGCPCredentials(context.Background(), gcpcredentials.Options{CredentialsFile: "not-found.json"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "credentialsFile")
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package healthchecker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/awsconfig"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
+)
+
+// checkKubernetes ensures that the cluster is reachable
+// by using the configured kubeconfig.
+func checkKubernetes(cfg *config.CloudProviderKubernetesConfig) error {
+	restConfig, err := clientcmd.BuildConfigFromFlags(cfg.MasterURL, cfg.KubeConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to build kube config, please check masterURL and kubeConfigPath of the cloud provider: %w", err)
+	}
+	restConfig.Timeout = checkTimeout
+
+	client, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	if _, err := client.ServerVersion(); err != nil {
+		return fmt.Errorf("unable to reach the cluster, please check its network and the credentials in the kubeconfig: %w", err)
+	}
+	return nil
+}
+
+// checkAWSCredentials ensures that the configured credentials
+// are valid by calling STS GetCallerIdentity.
+func checkAWSCredentials(ctx context.Context, opts awsconfig.Options) error {
+	cfg, err := awsconfig.Load(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config, please check region, credentialsFile and profile of the cloud provider: %w", err)
+	}
+	if _, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		return fmt.Errorf("unable to get caller identity from AWS STS, please check the credentials, roleARN, tokenFile and assumeRoleARN of the cloud provider: %w", err)
+	}
+	return nil
+}
+
+// checkGCPCredentials ensures that an access token can be issued
+// from the configured service account or the default credentials.
+func checkGCPCredentials(ctx context.Context, opts gcpcredentials.Options) error {
+	ts, err := gcpcredentials.TokenSource(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to find the credentials, please check credentialsFile of the cloud provider: %w", err)
+	}
+	if _, err := ts.Token(); err != nil {
+		return fmt.Errorf("unable to issue an access token, please check credentialsFile and impersonateServiceAccount of the cloud provider: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagedigest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The key used by docker to store the credentials of Docker Hub.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+type credential struct {
+	username string
+	password string
+}
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+// loadDockerCredentials loads the registry credentials stored in the docker config file
+// at $DOCKER_CONFIG/config.json or ~/.docker/config.json.
+// An empty map is returned when the file does not exist.
+func loadDockerCredentials() (map[string]credential, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return map[string]credential{}, nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return map[string]credential{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseDockerCredentials(data)
+}
+
+func parseDockerCredentials(data []byte) (map[string]credential, error) {
+	var cfg dockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	creds := make(map[string]credential, len(cfg.Auths))
+	for key, a := range cfg.Auths {
+		if a.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode auth of %s: %w", key, err)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed auth of %s", key)
+		}
+		domain := key
+		if domain == dockerHubAuthKey {
+			domain = dockerHubDomain
+		}
+		domain = strings.TrimPrefix(strings.TrimPrefix(domain, "https://"), "http://")
+		domain = strings.TrimSuffix(domain, "/")
+		creds[domain] = credential{
+			username: parts[0],
+			password: parts[1],
+		}
+	}
+	return creds, nil
+}
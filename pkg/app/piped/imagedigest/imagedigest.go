@@ -0,0 +1,264 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imagedigest provides a way to resolve the mutable tags of container images
+// to their immutable digests by using the Docker Registry HTTP API V2.
+package imagedigest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// The media types of manifests those can be resolved.
+// The manifest list (index) is preferred so that the digest is not platform specific.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// Resolver resolves the image tags to digests.
+// The resolved digests are cached while the resolver is alive.
+type Resolver struct {
+	httpClient  *http.Client
+	credentials map[string]credential
+	cache       sync.Map // map[image-string]digest-string
+	logger      *zap.Logger
+}
+
+// NewResolver creates a new Resolver using the registry credentials
+// stored in the docker config file if exists.
+func NewResolver(logger *zap.Logger) (*Resolver, error) {
+	creds, err := loadDockerCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load docker credentials: %w", err)
+	}
+	return &Resolver{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		credentials: creds,
+		logger:      logger.Named("image-digest-resolver"),
+	}, nil
+}
+
+// Resolve returns the given image reference pinned to the digest its tag points to,
+// e.g. gcr.io/pipecd/helloworld:v0.1.0@sha256:abc...
+// The reference is returned as is when it already contains a digest.
+func (r *Resolver) Resolve(ctx context.Context, image string) (string, error) {
+	ref, err := parseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.digest != "" {
+		return image, nil
+	}
+	if v, ok := r.cache.Load(image); ok {
+		return pin(image, v.(string)), nil
+	}
+
+	digest, err := r.fetchDigest(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest of %s: %w", image, err)
+	}
+	r.cache.Store(image, digest)
+	r.logger.Info("resolved image digest",
+		zap.String("image", image),
+		zap.String("digest", digest),
+	)
+	return pin(image, digest), nil
+}
+
+func (r *Resolver) fetchDigest(ctx context.Context, ref reference) (string, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.registryHost(), ref.path, ref.tag)
+
+	resp, err := r.doWithAuth(ctx, http.MethodHead, u, ref)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Some registries do not return the digest header for HEAD requests
+	// so the digest is calculated from the manifest content instead.
+	resp, err = r.doWithAuth(ctx, http.MethodGet, u, ref)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// doWithAuth sends the request and retries it with the credentials
+// required by the challenge when the registry responded 401.
+func (r *Resolver) doWithAuth(ctx context.Context, method, u string, ref reference) (*http.Response, error) {
+	resp, err := r.do(ctx, method, u, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		authorization, err := r.authorize(ctx, resp.Header.Get("WWW-Authenticate"), ref)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = r.do(ctx, method, u, authorization); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s from registry: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (r *Resolver) do(ctx context.Context, method, u, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return r.httpClient.Do(req)
+}
+
+// authorize returns the value of Authorization header for the given challenge.
+func (r *Resolver) authorize(ctx context.Context, challenge string, ref reference) (string, error) {
+	cred, hasCred := r.credentials[ref.domain]
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCred {
+			return "", fmt.Errorf("no credentials for %s", ref.domain)
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(cred.username, cred.password)
+		return req.Header.Get("Authorization"), nil
+
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("missing realm in the challenge %q", challenge)
+		}
+		q := url.Values{}
+		if s := params["service"]; s != "" {
+			q.Set("service", s)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull", ref.path)
+		}
+		q.Set("scope", scope)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCred {
+			req.SetBasicAuth(cred.username, cred.password)
+		}
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("%s from token endpoint %s", resp.Status, realm)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("failed to decode token: %w", err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+// parseChallenge parses the value of WWW-Authenticate header
+// such as `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+	for _, p := range splitChallengeParams(parts[1]) {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+	}
+	return parts[0], params
+}
+
+// splitChallengeParams splits the comma separated parameters
+// while ignoring the commas inside quoted values such as scopes.
+func splitChallengeParams(s string) []string {
+	var (
+		params []string
+		quoted bool
+		start  int
+	)
+	for i, c := range s {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				params = append(params, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(params, s[start:])
+}
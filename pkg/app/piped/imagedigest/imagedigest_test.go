@@ -0,0 +1,117 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagedigest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestResolve(t *testing.T) {
+	const digest = "sha256:0123456789abcdef"
+	var (
+		server   *httptest.Server
+		requests int
+	)
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "repository:pipecd/app:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token":"test-token"}`)
+
+		case "/v2/pipecd/app/manifests/v1":
+			requests++
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:pipecd/app:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, http.MethodHead, r.Method)
+			w.Header().Set("Docker-Content-Digest", digest)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	r := &Resolver{
+		httpClient: server.Client(),
+		credentials: map[string]credential{
+			host: {username: "user", password: "pass"},
+		},
+		logger: zap.NewNop(),
+	}
+	ctx := context.Background()
+
+	got, err := r.Resolve(ctx, host+"/pipecd/app:v1")
+	require.NoError(t, err)
+	assert.Equal(t, host+"/pipecd/app:v1@"+digest, got)
+	assert.Equal(t, 2, requests)
+
+	// The resolved digest is cached.
+	got, err = r.Resolve(ctx, host+"/pipecd/app:v1")
+	require.NoError(t, err)
+	assert.Equal(t, host+"/pipecd/app:v1@"+digest, got)
+	assert.Equal(t, 2, requests)
+
+	// The pinned reference is returned as is.
+	got, err = r.Resolve(ctx, host+"/pipecd/app@"+digest)
+	require.NoError(t, err)
+	assert.Equal(t, host+"/pipecd/app@"+digest, got)
+
+	_, err = r.Resolve(ctx, host+"/pipecd/unknown:v1")
+	assert.Error(t, err)
+}
+
+func TestParseDockerCredentials(t *testing.T) {
+	data := []byte(`{
+  "auths": {
+    "https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+    "gcr.io": {"auth": "X2pzb25fa2V5OnNlY3JldA=="},
+    "ghcr.io": {}
+  }
+}`)
+	creds, err := parseDockerCredentials(data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]credential{
+		"docker.io": {username: "user", password: "pass"},
+		"gcr.io":    {username: "_json_key", password: "secret"},
+	}, creds)
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull,push"`)
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/nginx:pull,push",
+	}, params)
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagedigest
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+// reference represents a parsed container image reference
+// such as gcr.io/pipecd/helloworld:v0.1.0.
+type reference struct {
+	// The domain of the registry, e.g. gcr.io, localhost:5000.
+	domain string
+	// The repository path inside the registry, e.g. pipecd/helloworld.
+	path   string
+	tag    string
+	digest string
+}
+
+// parseReference parses the given image reference.
+// The domain and tag are defaulted as docker does.
+func parseReference(image string) (reference, error) {
+	if image == "" {
+		return reference{}, fmt.Errorf("empty image reference")
+	}
+	var ref reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+		if !strings.Contains(ref.digest, ":") {
+			return reference{}, fmt.Errorf("invalid digest in image reference %q", image)
+		}
+	}
+	// The colon after the last slash separates the tag
+	// since the domain part may contain a port number.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	// The first component is treated as a domain only when it looks like a hostname.
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.domain, ref.path = parts[0], parts[1]
+	} else {
+		ref.domain, ref.path = dockerHubDomain, name
+	}
+	if ref.domain == dockerHubDomain && !strings.Contains(ref.path, "/") {
+		ref.path = "library/" + ref.path
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = defaultTag
+	}
+	return ref, nil
+}
+
+// registryHost returns the host to send the registry API requests to.
+func (r reference) registryHost() string {
+	if r.domain == dockerHubDomain {
+		return dockerHubRegistry
+	}
+	return r.domain
+}
+
+// pin returns the given image reference pinned to the given digest.
+// The tag is kept for readability since it is ignored when a digest is specified.
+func pin(image, digest string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	return image + "@" + digest
+}
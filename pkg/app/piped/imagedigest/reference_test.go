@@ -0,0 +1,70 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagedigest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	testcases := []struct {
+		image    string
+		expected reference
+		wantErr  bool
+	}{
+		{
+			image:    "nginx",
+			expected: reference{domain: "docker.io", path: "library/nginx", tag: "latest"},
+		},
+		{
+			image:    "pipecd/helloworld:v0.1.0",
+			expected: reference{domain: "docker.io", path: "pipecd/helloworld", tag: "v0.1.0"},
+		},
+		{
+			image:    "gcr.io/pipecd/helloworld:v0.1.0",
+			expected: reference{domain: "gcr.io", path: "pipecd/helloworld", tag: "v0.1.0"},
+		},
+		{
+			image:    "localhost:5000/helloworld",
+			expected: reference{domain: "localhost:5000", path: "helloworld", tag: "latest"},
+		},
+		{
+			image:    "gcr.io/pipecd/helloworld:v0.1.0@sha256:abc",
+			expected: reference{domain: "gcr.io", path: "pipecd/helloworld", tag: "v0.1.0", digest: "sha256:abc"},
+		},
+		{
+			image:   "",
+			wantErr: true,
+		},
+		{
+			image:   "gcr.io/pipecd/helloworld@abc",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.image, func(t *testing.T) {
+			ref, err := parseReference(tc.image)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, ref)
+		})
+	}
+}
+
+func TestPin(t *testing.T) {
+	assert.Equal(t, "gcr.io/pipecd/helloworld:v0.1.0@sha256:abc", pin("gcr.io/pipecd/helloworld:v0.1.0", "sha256:abc"))
+	assert.Equal(t, "gcr.io/pipecd/helloworld@sha256:def", pin("gcr.io/pipecd/helloworld@sha256:abc", "sha256:def"))
+}
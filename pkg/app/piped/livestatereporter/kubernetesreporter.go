@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	"github.com/pipe-cd/pipe/pkg/app/piped/livestatestore/kubernetes"
@@ -29,6 +30,8 @@ import (
 
 const (
 	maxNumEventsPerRequest = 1000
+	defaultMaxChunkSize    = 1024 * 1024
+	defaultMaxSnapshotSize = 32 * 1024 * 1024
 )
 
 type kubernetesReporter struct {
@@ -39,15 +42,26 @@ type kubernetesReporter struct {
 	apiClient             apiClient
 	flushInterval         time.Duration
 	snapshotFlushInterval time.Duration
+	maxChunkSize          int
+	maxSnapshotSize       int
+	maxResources          int
 	logger                *zap.Logger
 
 	snapshotVersions map[string]model.ApplicationLiveStateVersion
 }
 
-func newKubernetesReporter(cp config.PipedCloudProvider, appLister applicationLister, stateGetter kubernetes.Getter, apiClient apiClient, logger *zap.Logger) *kubernetesReporter {
+func newKubernetesReporter(cp config.PipedCloudProvider, cfg config.PipedLiveStateReporter, appLister applicationLister, stateGetter kubernetes.Getter, apiClient apiClient, logger *zap.Logger) *kubernetesReporter {
 	logger = logger.Named("kubernetes-reporter").With(
 		zap.String("cloud-provider", cp.Name),
 	)
+	maxChunkSize := cfg.MaxChunkSize
+	if maxChunkSize == 0 {
+		maxChunkSize = defaultMaxChunkSize
+	}
+	maxSnapshotSize := cfg.MaxSnapshotSize
+	if maxSnapshotSize == 0 {
+		maxSnapshotSize = defaultMaxSnapshotSize
+	}
 	return &kubernetesReporter{
 		provider:              cp,
 		appLister:             appLister,
@@ -56,6 +70,9 @@ func newKubernetesReporter(cp config.PipedCloudProvider, appLister applicationLi
 		apiClient:             apiClient,
 		flushInterval:         5 * time.Second,
 		snapshotFlushInterval: 10 * time.Minute,
+		maxChunkSize:          maxChunkSize,
+		maxSnapshotSize:       maxSnapshotSize,
+		maxResources:          cfg.MaxResources,
 		logger:                logger,
 		snapshotVersions:      make(map[string]model.ApplicationLiveStateVersion),
 	}
@@ -120,11 +137,13 @@ func (r *kubernetesReporter) flushSnapshots(ctx context.Context) error {
 			Version: &state.Version,
 		}
 		snapshot.DetermineAppHealthStatus()
-		req := &pipedservice.ReportApplicationLiveStateRequest{
-			Snapshot: snapshot,
+
+		if resources := truncateResources(state.Resources, r.maxResources, r.maxSnapshotSize, r.maxChunkSize); len(resources) < len(state.Resources) {
+			r.logger.Warn(fmt.Sprintf("the resources of application %s exceeded the limits, only %d of %d resources will be reported", app.Id, len(resources), len(state.Resources)))
+			snapshot.Kubernetes.Resources = resources
 		}
 
-		if _, err := r.apiClient.ReportApplicationLiveState(ctx, req); err != nil {
+		if err := r.reportSnapshot(ctx, snapshot); err != nil {
 			r.logger.Error("failed to report application live state",
 				zap.String("application-id", app.Id),
 				zap.Error(err),
@@ -137,6 +156,47 @@ func (r *kubernetesReporter) flushSnapshots(ctx context.Context) error {
 	return nil
 }
 
+// reportSnapshot sends the given snapshot to the control plane.
+// The snapshot larger than the maximum chunk size is sent by splitting into multiple chunks.
+func (r *kubernetesReporter) reportSnapshot(ctx context.Context, snapshot *model.ApplicationLiveStateSnapshot) error {
+	if proto.Size(snapshot) <= r.maxChunkSize {
+		req := &pipedservice.ReportApplicationLiveStateRequest{
+			Snapshot: snapshot,
+		}
+		_, err := r.apiClient.ReportApplicationLiveState(ctx, req)
+		return err
+	}
+
+	stream, err := r.apiClient.ReportApplicationLiveStateChunks(ctx)
+	if err != nil {
+		return err
+	}
+	header := &model.ApplicationLiveStateSnapshot{
+		ApplicationId: snapshot.ApplicationId,
+		EnvId:         snapshot.EnvId,
+		PipedId:       snapshot.PipedId,
+		ProjectId:     snapshot.ProjectId,
+		Kind:          snapshot.Kind,
+		HealthStatus:  snapshot.HealthStatus,
+		Kubernetes:    &model.KubernetesApplicationLiveState{},
+		Version:       snapshot.Version,
+	}
+	chunks := splitResources(snapshot.Kubernetes.Resources, r.maxChunkSize-proto.Size(header))
+	for i, resources := range chunks {
+		chunk := &pipedservice.ReportApplicationLiveStateChunk{
+			KubernetesResources: resources,
+		}
+		if i == 0 {
+			chunk.Snapshot = header
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
 func (r *kubernetesReporter) flushEvents(ctx context.Context) error {
 	events := r.eventIterator.Next(maxNumEventsPerRequest)
 	if len(events) == 0 {
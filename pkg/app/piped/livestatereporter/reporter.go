@@ -37,6 +37,7 @@ type applicationLister interface {
 
 type apiClient interface {
 	ReportApplicationLiveState(ctx context.Context, req *pipedservice.ReportApplicationLiveStateRequest, opts ...grpc.CallOption) (*pipedservice.ReportApplicationLiveStateResponse, error)
+	ReportApplicationLiveStateChunks(ctx context.Context, opts ...grpc.CallOption) (pipedservice.PipedService_ReportApplicationLiveStateChunksClient, error)
 	ReportApplicationLiveStateEvents(ctx context.Context, req *pipedservice.ReportApplicationLiveStateEventsRequest, opts ...grpc.CallOption) (*pipedservice.ReportApplicationLiveStateEventsResponse, error)
 }
 
@@ -68,7 +69,7 @@ func NewReporter(appLister applicationLister, stateGetter livestatestore.Getter,
 				r.logger.Error(fmt.Sprintf("unable to find live state getter for cloud provider: %s", cp.Name))
 				continue
 			}
-			r.reporters = append(r.reporters, newKubernetesReporter(cp, appLister, sg, apiClient, logger))
+			r.reporters = append(r.reporters, newKubernetesReporter(cp, cfg.LiveStateReporter, appLister, sg, apiClient, logger))
 
 		default:
 		}
@@ -0,0 +1,92 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package livestatereporter
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// truncateResources returns the resources from the given list those fit in the limits.
+// At most maxNum resources whose total size does not exceed maxSize bytes are kept,
+// and the resource larger than maxResourceSize bytes is always dropped since it could
+// not be sent in one chunk. Zero means no limit for each of them.
+// The resources not owned by any other resources, such as the ones defined in Git,
+// are prioritized since the others can be derived from them.
+func truncateResources(resources []*model.KubernetesResourceState, maxNum, maxSize, maxResourceSize int) []*model.KubernetesResourceState {
+	var (
+		keeps = make(map[int]struct{}, len(resources))
+		size  int
+	)
+	keep := func(i int, r *model.KubernetesResourceState) {
+		if maxNum > 0 && len(keeps) >= maxNum {
+			return
+		}
+		rs := proto.Size(r)
+		if maxResourceSize > 0 && rs > maxResourceSize {
+			return
+		}
+		if maxSize > 0 && size+rs > maxSize {
+			return
+		}
+		keeps[i] = struct{}{}
+		size += rs
+	}
+	for i, r := range resources {
+		if len(r.OwnerIds) == 0 {
+			keep(i, r)
+		}
+	}
+	for i, r := range resources {
+		if len(r.OwnerIds) > 0 {
+			keep(i, r)
+		}
+	}
+	if len(keeps) == len(resources) {
+		return resources
+	}
+
+	out := make([]*model.KubernetesResourceState, 0, len(keeps))
+	for i, r := range resources {
+		if _, ok := keeps[i]; ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// splitResources splits the given resources into the chunks whose size does not exceed maxSize.
+// The resource larger than maxSize is sent alone in a chunk.
+func splitResources(resources []*model.KubernetesResourceState, maxSize int) [][]*model.KubernetesResourceState {
+	var (
+		chunks [][]*model.KubernetesResourceState
+		chunk  []*model.KubernetesResourceState
+		size   int
+	)
+	for _, r := range resources {
+		rs := proto.Size(r)
+		if len(chunk) > 0 && size+rs > maxSize {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, 0
+		}
+		chunk = append(chunk, r)
+		size += rs
+	}
+	if len(chunk) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
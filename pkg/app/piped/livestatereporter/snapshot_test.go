@@ -0,0 +1,113 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package livestatereporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestTruncateResources(t *testing.T) {
+	resources := []*model.KubernetesResourceState{
+		{Id: "pod-1", OwnerIds: []string{"replicaset"}},
+		{Id: "deployment"},
+		{Id: "replicaset", OwnerIds: []string{"deployment"}},
+		{Id: "service"},
+		{Id: "pod-2", OwnerIds: []string{"replicaset"}},
+		{Id: "configmap", Name: strings.Repeat("x", 100)},
+	}
+	size := proto.Size(resources[1])
+	testcases := []struct {
+		name            string
+		maxNum          int
+		maxSize         int
+		maxResourceSize int
+		expected        []string
+	}{
+		{
+			name:     "no limit",
+			expected: []string{"pod-1", "deployment", "replicaset", "service", "pod-2", "configmap"},
+		},
+		{
+			name:     "not exceeded",
+			maxNum:   6,
+			expected: []string{"pod-1", "deployment", "replicaset", "service", "pod-2", "configmap"},
+		},
+		{
+			name:     "only not owned resources",
+			maxNum:   3,
+			expected: []string{"deployment", "service", "configmap"},
+		},
+		{
+			name:     "owned resources in order",
+			maxNum:   4,
+			expected: []string{"pod-1", "deployment", "service", "configmap"},
+		},
+		{
+			name:            "too large resource",
+			maxResourceSize: 50,
+			expected:        []string{"pod-1", "deployment", "replicaset", "service", "pod-2"},
+		},
+		{
+			name:     "total size exceeded",
+			maxSize:  size * 2,
+			expected: []string{"deployment", "service"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := truncateResources(resources, tc.maxNum, tc.maxSize, tc.maxResourceSize)
+			ids := make([]string, 0, len(got))
+			for _, r := range got {
+				ids = append(ids, r.Id)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestSplitResources(t *testing.T) {
+	resources := make([]*model.KubernetesResourceState, 0, 10)
+	for i := 0; i < 10; i++ {
+		resources = append(resources, &model.KubernetesResourceState{
+			Id:         "resource-id",
+			Name:       "resource-name",
+			ApiVersion: "v1",
+			Kind:       "Pod",
+		})
+	}
+	size := proto.Size(resources[0])
+
+	chunks := splitResources(resources, size*3)
+	assert.Equal(t, 4, len(chunks))
+	num := 0
+	for _, c := range chunks {
+		assert.LessOrEqual(t, len(c), 3)
+		num += len(c)
+	}
+	assert.Equal(t, len(resources), num)
+
+	chunks = splitResources(resources, size-1)
+	assert.Equal(t, len(resources), len(chunks))
+
+	chunks = splitResources(nil, size)
+	assert.Equal(t, 1, len(chunks))
+	assert.Empty(t, chunks[0])
+}
@@ -26,11 +26,12 @@ import (
 )
 
 type appNodes struct {
-	appID         string
-	managingNodes map[string]node
-	dependedNodes map[string]node
-	version       model.ApplicationLiveStateVersion
-	mu            sync.RWMutex
+	appID              string
+	managedAnnotations provider.ManagedAnnotations
+	managingNodes      map[string]node
+	dependedNodes      map[string]node
+	version            model.ApplicationLiveStateVersion
+	mu                 sync.RWMutex
 }
 
 type node struct {
@@ -49,7 +50,7 @@ func (n node) Manifest() provider.Manifest {
 func (a *appNodes) addManagingResource(uid string, key provider.ResourceKey, obj *unstructured.Unstructured, now time.Time) (model.KubernetesResourceStateEvent, bool) {
 	// Some resources in Kubernetes (e.g. Deployment) are producing multiple keys
 	// for the same uid. So we use the configured original API version to ignore them.
-	originalAPIVersion := obj.GetAnnotations()[provider.LabelOriginalAPIVersion]
+	originalAPIVersion := obj.GetAnnotations()[a.managedAnnotations.OriginalAPIVersion]
 	if originalAPIVersion != key.APIVersion {
 		return model.KubernetesResourceStateEvent{}, false
 	}
@@ -70,7 +71,7 @@ func (a *appNodes) addManagingResource(uid string, key provider.ResourceKey, obj
 	a.mu.Unlock()
 
 	// No diff compared to previous state.
-	if hasOriNode && !oriNode.state.HasDiff(n.state) {
+	if hasOriNode && !oriNode.state.HasDiff(&n.state) {
 		return model.KubernetesResourceStateEvent{}, false
 	}
 
@@ -124,7 +125,7 @@ func (a *appNodes) addDependedResource(uid string, key provider.ResourceKey, obj
 	a.mu.Unlock()
 
 	// No diff compared to previous state.
-	if hasOriNode && !oriNode.state.HasDiff(n.state) {
+	if hasOriNode && !oriNode.state.HasDiff(&n.state) {
 		return model.KubernetesResourceStateEvent{}, false
 	}
 
@@ -16,6 +16,7 @@ package kubernetes
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"go.uber.org/zap"
@@ -26,12 +27,14 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/ratelimiter"
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 type Store struct {
 	config                *config.CloudProviderKubernetesConfig
+	cloudProvider         string
 	pipedConfig           *config.PipedSpec
 	kubeConfig            *restclient.Config
 	store                 *store
@@ -73,13 +76,15 @@ func NewStore(cfg *config.CloudProviderKubernetesConfig, pipedConfig *config.Pip
 		With(zap.String("cloud-provider", cloudProvider))
 
 	return &Store{
-		config:      cfg,
-		pipedConfig: pipedConfig,
+		config:        cfg,
+		cloudProvider: cloudProvider,
+		pipedConfig:   pipedConfig,
 		store: &store{
-			pipedConfig: pipedConfig,
-			apps:        make(map[string]*appNodes),
-			resources:   make(map[string]appResource),
-			iterators:   make(map[int]int, 1),
+			pipedConfig:        pipedConfig,
+			managedAnnotations: provider.NewManagedAnnotations(cfg.GetAnnotationPrefix()),
+			apps:               make(map[string]*appNodes),
+			resources:          make(map[string]appResource),
+			iterators:          make(map[int]int, 1),
 		},
 		firstSyncedCh: make(chan error, 1),
 		logger:        logger,
@@ -96,17 +101,25 @@ func (s *Store) Run(ctx context.Context) error {
 		s.logger.Error("failed to build kube config", zap.Error(err))
 		return err
 	}
+	// Share the rate limiter of this cloud provider with the executors
+	// to avoid overloading the API server while watching resources.
+	if l := ratelimiter.DefaultRegistry().Limiter(s.cloudProvider); l != nil {
+		s.kubeConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return ratelimiter.NewTransport(rt, l)
+		})
+	}
 
 	stopCh := make(chan struct{})
 	rf := reflector{
-		config:      s.config,
-		kubeConfig:  s.kubeConfig,
-		pipedConfig: s.pipedConfig,
-		onAdd:       s.store.onAddResource,
-		onUpdate:    s.store.onUpdateResource,
-		onDelete:    s.store.onDeleteResource,
-		stopCh:      stopCh,
-		logger:      s.logger.Named("reflector"),
+		config:             s.config,
+		kubeConfig:         s.kubeConfig,
+		pipedConfig:        s.pipedConfig,
+		managedAnnotations: s.store.managedAnnotations,
+		onAdd:              s.store.onAddResource,
+		onUpdate:           s.store.onUpdateResource,
+		onDelete:           s.store.onDeleteResource,
+		stopCh:             stopCh,
+		logger:             s.logger.Named("reflector"),
 	}
 	if err := rf.start(ctx); err != nil {
 		s.firstSyncedCh <- err
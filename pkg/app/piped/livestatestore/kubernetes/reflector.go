@@ -125,6 +125,8 @@ type reflector struct {
 	config      *config.CloudProviderKubernetesConfig
 	kubeConfig  *restclient.Config
 	pipedConfig *config.PipedSpec
+	// The annotation keys added by piped to track the managed resources.
+	managedAnnotations provider.ManagedAnnotations
 
 	onAdd    func(obj *unstructured.Unstructured)
 	onUpdate func(oldObj, obj *unstructured.Unstructured)
@@ -242,7 +244,7 @@ func (r *reflector) onObjectAdd(obj interface{}) {
 	}
 
 	// Ignore all objects that are not handled by this piped.
-	pipedID := u.GetAnnotations()[provider.LabelPiped]
+	pipedID := u.GetAnnotations()[r.managedAnnotations.Piped]
 	if pipedID != "" && pipedID != r.pipedConfig.PipedID {
 		incrementResourceEventCounter("add", false)
 		return
@@ -265,7 +267,7 @@ func (r *reflector) onObjectUpdate(oldObj, obj interface{}) {
 	}
 
 	// Ignore all objects that are not handled by this piped.
-	pipedID := u.GetAnnotations()[provider.LabelPiped]
+	pipedID := u.GetAnnotations()[r.managedAnnotations.Piped]
 	if pipedID != "" && pipedID != r.pipedConfig.PipedID {
 		incrementResourceEventCounter("update", false)
 		return
@@ -287,7 +289,7 @@ func (r *reflector) onObjectDelete(obj interface{}) {
 	}
 
 	// Ignore all objects that are not handled by this piped.
-	pipedID := u.GetAnnotations()[provider.LabelPiped]
+	pipedID := u.GetAnnotations()[r.managedAnnotations.Piped]
 	if pipedID != "" && pipedID != r.pipedConfig.PipedID {
 		incrementResourceEventCounter("delete", false)
 		return
@@ -34,7 +34,9 @@ const (
 
 type store struct {
 	pipedConfig *config.PipedSpec
-	apps        map[string]*appNodes
+	// The annotation keys added by piped to track the managed resources.
+	managedAnnotations provider.ManagedAnnotations
+	apps               map[string]*appNodes
 	// The map with the key is "resource's uid" and the value is "appResource".
 	// Because the depended resource does not include the appID in its annotations
 	// so this is used to determine the application of a depended resource.
@@ -105,9 +107,10 @@ func (s *store) addResource(obj *unstructured.Unstructured, appID string) {
 		app, ok := s.apps[appID]
 		if !ok {
 			app = &appNodes{
-				appID:         appID,
-				managingNodes: make(map[string]node),
-				dependedNodes: make(map[string]node),
+				appID:              appID,
+				managedAnnotations: s.managedAnnotations,
+				managingNodes:      make(map[string]node),
+				dependedNodes:      make(map[string]node),
 				version: model.ApplicationLiveStateVersion{
 					Timestamp: now.Unix(),
 				},
@@ -154,13 +157,13 @@ func (s *store) addResource(obj *unstructured.Unstructured, appID string) {
 }
 
 func (s *store) onAddResource(obj *unstructured.Unstructured) {
-	appID := obj.GetAnnotations()[provider.LabelApplication]
+	appID := obj.GetAnnotations()[s.managedAnnotations.Application]
 	s.addResource(obj, appID)
 }
 
 func (s *store) onUpdateResource(oldObj, obj *unstructured.Unstructured) {
 	uid := string(obj.GetUID())
-	appID := obj.GetAnnotations()[provider.LabelApplication]
+	appID := obj.GetAnnotations()[s.managedAnnotations.Application]
 	// Depended nodes may not contain the app id in its annotations.
 	// In that case, preventing them from overwriting with an empty id
 	if appID == "" {
@@ -176,7 +179,7 @@ func (s *store) onUpdateResource(oldObj, obj *unstructured.Unstructured) {
 func (s *store) onDeleteResource(obj *unstructured.Unstructured) {
 	var (
 		uid    = string(obj.GetUID())
-		appID  = obj.GetAnnotations()[provider.LabelApplication]
+		appID  = obj.GetAnnotations()[s.managedAnnotations.Application]
 		key    = provider.MakeResourceKey(obj)
 		owners = obj.GetOwnerReferences()
 		now    = time.Now()
@@ -20,11 +20,13 @@ package logpersister
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
 	"github.com/pipe-cd/pipe/pkg/model"
@@ -64,7 +66,12 @@ type persister struct {
 	checkpointFlushInterval time.Duration
 	stalePeriod             time.Duration
 	gracePeriod             time.Duration
-	logger                  *zap.Logger
+	// The maximum size of log blocks sent in one request.
+	maxBatchSize int
+	// The log blocks are compressed before sending
+	// when their size exceeds this threshold.
+	compressionThreshold int
+	logger               *zap.Logger
 }
 
 // NewPersister creates a new persister instance for saving the stage logs into server's storage.
@@ -76,6 +83,8 @@ func NewPersister(apiClient apiClient, logger *zap.Logger) *persister {
 		checkpointFlushInterval: 2 * time.Minute,
 		stalePeriod:             time.Minute,
 		gracePeriod:             30 * time.Second,
+		maxBatchSize:            1024 * 1024,
+		compressionThreshold:    1024,
 		logger:                  logger.Named("log-persister"),
 	}
 }
@@ -175,8 +184,8 @@ func (p *persister) reportStageLogs(ctx context.Context, k key, blocks []*model.
 	req := &pipedservice.ReportStageLogsRequest{
 		DeploymentId: k.DeploymentID,
 		StageId:      k.StageID,
-		Blocks:       blocks,
 	}
+	req.Blocks, req.CompressedBlocks = p.encodeBlocks(blocks)
 	if _, err := p.apiClient.ReportStageLogs(ctx, req); err != nil {
 		p.logger.Error("failed to report stage logs",
 			zap.Any("key", k),
@@ -191,9 +200,9 @@ func (p *persister) reportStageLogsFromLastCheckpoint(ctx context.Context, k key
 	req := &pipedservice.ReportStageLogsFromLastCheckpointRequest{
 		DeploymentId: k.DeploymentID,
 		StageId:      k.StageID,
-		Blocks:       blocks,
 		Completed:    completed,
 	}
+	req.Blocks, req.CompressedBlocks = p.encodeBlocks(blocks)
 	if _, err := p.apiClient.ReportStageLogsFromLastCheckpoint(ctx, req); err != nil {
 		p.logger.Error("failed to report stage logs from last checkpoint",
 			zap.Any("key", k),
@@ -203,3 +212,68 @@ func (p *persister) reportStageLogsFromLastCheckpoint(ctx context.Context, k key
 	}
 	return nil
 }
+
+// encodeBlocks returns the compressed data of the given blocks when they are large enough.
+// Otherwise, or when the compression was failed, the blocks are returned as is.
+func (p *persister) encodeBlocks(blocks []*model.LogBlock) ([]*model.LogBlock, []byte) {
+	if blocksSize(blocks) < p.compressionThreshold {
+		return blocks, nil
+	}
+	data, err := pipedservice.CompressLogBlocks(blocks)
+	if err != nil {
+		p.logger.Warn("failed to compress log blocks, they will be sent without compression", zap.Error(err))
+		return blocks, nil
+	}
+	return nil, data
+}
+
+func blocksSize(blocks []*model.LogBlock) int {
+	size := 0
+	for _, b := range blocks {
+		size += proto.Size(b)
+	}
+	return size
+}
+
+const truncatedLogSuffix = "... (truncated)"
+
+// splitBlocks splits the given blocks into the batches whose size does not exceed maxSize.
+// The block larger than maxSize is sent alone in a batch after truncating its log.
+func splitBlocks(blocks []*model.LogBlock, maxSize int) [][]*model.LogBlock {
+	var (
+		batches [][]*model.LogBlock
+		batch   []*model.LogBlock
+		size    int
+	)
+	for _, b := range blocks {
+		bs := proto.Size(b)
+		if bs > maxSize {
+			b = truncateBlock(b, bs-maxSize)
+			bs = proto.Size(b)
+		}
+		if len(batch) > 0 && size+bs > maxSize {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, b)
+		size += bs
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// truncateBlock returns a copy of the given block whose log was shortened at least the given size.
+func truncateBlock(b *model.LogBlock, overSize int) *model.LogBlock {
+	n := len(b.Log) - overSize - len(truncatedLogSuffix)
+	if n < 0 {
+		n = 0
+	}
+	return &model.LogBlock{
+		Index:     b.Index,
+		Log:       strings.ToValidUTF8(b.Log[:n], "") + truncatedLogSuffix,
+		Severity:  b.Severity,
+		CreatedAt: b.CreatedAt,
+	}
+}
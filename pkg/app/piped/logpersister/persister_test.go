@@ -16,6 +16,8 @@ package logpersister
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +27,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 type fakeAPIClient struct {
@@ -87,3 +90,124 @@ func TestPersister(t *testing.T) {
 	require.Equal(t, 0, apiClient.NumberOfReportStageLogsFromLastCheckpoint())
 	assert.Equal(t, 1, num)
 }
+
+type recordingAPIClient struct {
+	mu        sync.Mutex
+	requests  []*pipedservice.ReportStageLogsFromLastCheckpointRequest
+	blocks    []*model.LogBlock
+	completed bool
+}
+
+func (c *recordingAPIClient) ReportStageLogs(ctx context.Context, in *pipedservice.ReportStageLogsRequest, opts ...grpc.CallOption) (*pipedservice.ReportStageLogsResponse, error) {
+	return &pipedservice.ReportStageLogsResponse{}, nil
+}
+
+func (c *recordingAPIClient) ReportStageLogsFromLastCheckpoint(ctx context.Context, in *pipedservice.ReportStageLogsFromLastCheckpointRequest, opts ...grpc.CallOption) (*pipedservice.ReportStageLogsFromLastCheckpointResponse, error) {
+	blocks := in.Blocks
+	if len(in.CompressedBlocks) > 0 {
+		var err error
+		if blocks, err = pipedservice.DecompressLogBlocks(in.CompressedBlocks); err != nil {
+			return nil, err
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, in)
+	c.blocks = append(c.blocks, blocks...)
+	c.completed = in.Completed
+	return &pipedservice.ReportStageLogsFromLastCheckpointResponse{}, nil
+}
+
+func TestStageLogPersisterBatching(t *testing.T) {
+	apiClient := &recordingAPIClient{}
+	p := NewPersister(apiClient, zap.NewNop())
+	p.maxBatchSize = 200
+	p.compressionThreshold = 1
+
+	sp := p.StageLogPersister("deployment-1", "stage-1").(*stageLogPersister)
+	for i := 0; i < 10; i++ {
+		sp.Info(strings.Repeat("a", 40))
+	}
+	sp.mu.Lock()
+	sp.completed = true
+	sp.mu.Unlock()
+
+	require.NoError(t, sp.flushFromLastCheckpoint(context.TODO()))
+	assert.Greater(t, len(apiClient.requests), 1)
+	assert.Equal(t, 10, len(apiClient.blocks))
+	assert.True(t, apiClient.completed)
+	for i, req := range apiClient.requests {
+		assert.NotEmpty(t, req.CompressedBlocks)
+		assert.Empty(t, req.Blocks)
+		// Only the last request reports the completion.
+		assert.Equal(t, i == len(apiClient.requests)-1, req.Completed)
+	}
+	assert.True(t, sp.done.Load())
+}
+
+func TestSplitBlocks(t *testing.T) {
+	block := func(index int64, log string) *model.LogBlock {
+		return &model.LogBlock{
+			Index:     index,
+			Log:       log,
+			Severity:  model.LogSeverity_INFO,
+			CreatedAt: 1,
+		}
+	}
+	testcases := []struct {
+		name     string
+		blocks   []*model.LogBlock
+		maxSize  int
+		expected [][]int64
+	}{
+		{
+			name:     "empty",
+			maxSize:  100,
+			expected: nil,
+		},
+		{
+			name: "all in one batch",
+			blocks: []*model.LogBlock{
+				block(1, "foo"),
+				block(2, "bar"),
+			},
+			maxSize:  100,
+			expected: [][]int64{{1, 2}},
+		},
+		{
+			name: "split into multiple batches",
+			blocks: []*model.LogBlock{
+				block(1, strings.Repeat("a", 30)),
+				block(2, strings.Repeat("b", 30)),
+				block(3, strings.Repeat("c", 30)),
+			},
+			maxSize:  80,
+			expected: [][]int64{{1, 2}, {3}},
+		},
+		{
+			name: "too large block",
+			blocks: []*model.LogBlock{
+				block(1, "foo"),
+				block(2, strings.Repeat("a", 200)),
+				block(3, "bar"),
+			},
+			maxSize:  50,
+			expected: [][]int64{{1}, {2}, {3}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			batches := splitBlocks(tc.blocks, tc.maxSize)
+			var got [][]int64
+			for _, batch := range batches {
+				assert.LessOrEqual(t, blocksSize(batch), tc.maxSize)
+				indexes := make([]int64, 0, len(batch))
+				for _, b := range batch {
+					indexes = append(indexes, b.Index)
+				}
+				got = append(got, indexes)
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
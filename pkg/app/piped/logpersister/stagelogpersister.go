@@ -168,12 +168,13 @@ func (sp *stageLogPersister) flushNewLogs(ctx context.Context) error {
 		return nil
 	}
 
-	if err := sp.persister.reportStageLogs(ctx, sp.key, blocks); err != nil {
-		return err
+	for _, batch := range splitBlocks(blocks, sp.persister.maxBatchSize) {
+		if err := sp.persister.reportStageLogs(ctx, sp.key, batch); err != nil {
+			return err
+		}
+		// Update sentIndex.
+		sp.sentIndex += len(batch)
 	}
-
-	// Update sentIndex.
-	sp.sentIndex += numBlocks
 	return nil
 }
 
@@ -195,8 +196,14 @@ func (sp *stageLogPersister) flushFromLastCheckpoint(ctx context.Context) (err e
 		return nil
 	}
 
-	if err := sp.persister.reportStageLogsFromLastCheckpoint(ctx, sp.key, blocks, completed); err != nil {
-		return err
+	// All batches must be sent successfully before removing them
+	// and the completion is reported along with the last one.
+	batches := splitBlocks(blocks, sp.persister.maxBatchSize)
+	for i, batch := range batches {
+		last := i == len(batches)-1
+		if err := sp.persister.reportStageLogsFromLastCheckpoint(ctx, sp.key, batch, completed && last); err != nil {
+			return err
+		}
 	}
 
 	// Remove all sent blocks and update checkpointSentIndex.
@@ -0,0 +1,387 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type approvalLinkCreator interface {
+	CreateApprovalLinks(ctx context.Context, req *pipedservice.CreateApprovalLinksRequest, opts ...grpc.CallOption) (*pipedservice.CreateApprovalLinksResponse, error)
+}
+
+type email struct {
+	name        string
+	config      config.NotificationReceiverEmail
+	auth        smtp.Auth
+	webURL      string
+	linkCreator approvalLinkCreator
+	sendMail    func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	eventCh     chan model.NotificationEvent
+	nowFunc     func() time.Time
+	logger      *zap.Logger
+}
+
+type emailMessage struct {
+	subject string
+	body    string
+}
+
+func newEmailSender(name string, cfg config.NotificationReceiverEmail, webURL string, lc approvalLinkCreator, logger *zap.Logger) (*email, error) {
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		password, err := ioutil.ReadFile(cfg.SMTPPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read smtp password file %s (%w)", cfg.SMTPPasswordFile, err)
+		}
+		host, _, err := net.SplitHostPort(cfg.SMTPAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smtp address %s (%w)", cfg.SMTPAddress, err)
+		}
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, strings.TrimSpace(string(password)), host)
+	}
+	return &email{
+		name:        name,
+		config:      cfg,
+		auth:        auth,
+		webURL:      strings.TrimRight(webURL, "/"),
+		linkCreator: lc,
+		sendMail:    smtp.SendMail,
+		eventCh:     make(chan model.NotificationEvent, 100),
+		nowFunc:     time.Now,
+		logger:      logger.Named("email"),
+	}, nil
+}
+
+func (e *email) Run(ctx context.Context) error {
+	for {
+		select {
+		case event, ok := <-e.eventCh:
+			if ok {
+				e.sendEvent(ctx, event)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (e *email) Notify(event model.NotificationEvent) {
+	e.eventCh <- event
+}
+
+func (e *email) Close(ctx context.Context) {
+	close(e.eventCh)
+
+	// Send all remaining events.
+	for {
+		select {
+		case event, ok := <-e.eventCh:
+			if !ok {
+				return
+			}
+			e.sendEvent(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *email) sendEvent(ctx context.Context, event model.NotificationEvent) {
+	msg, ok := e.buildEmailMessage(event)
+	if !ok {
+		e.logger.Info(fmt.Sprintf("ignore event %s", event.Type.String()))
+		return
+	}
+
+	recipients := e.recipients()
+	if md, ok := event.Metadata.(*model.NotificationEventDeploymentWaitApproval); ok && md.StageId != "" {
+		recipients = e.sendApprovalLinks(ctx, md, msg, recipients)
+	}
+	if len(recipients) == 0 {
+		return
+	}
+	if err := e.send(recipients, msg); err != nil {
+		e.logger.Error(fmt.Sprintf("unable to send email: %v", err))
+	}
+}
+
+// sendApprovalLinks sends the emails containing the approval links to the approvers
+// who are allowed to approve the stage, and returns the recipients who did not receive them.
+func (e *email) sendApprovalLinks(ctx context.Context, md *model.NotificationEventDeploymentWaitApproval, msg emailMessage, recipients []string) []string {
+	users := make(map[string]string, len(e.config.Approvers))
+	for addr, user := range e.config.Approvers {
+		if len(md.Approvers) > 0 && !contains(md.Approvers, user) {
+			continue
+		}
+		users[addr] = user
+	}
+	if len(users) == 0 {
+		return recipients
+	}
+
+	approvers := make([]string, 0, len(users))
+	for _, user := range users {
+		if !contains(approvers, user) {
+			approvers = append(approvers, user)
+		}
+	}
+	sort.Strings(approvers)
+
+	ttl := time.Unix(md.TimeoutAt, 0).Sub(e.nowFunc())
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	resp, err := e.linkCreator.CreateApprovalLinks(ctx, &pipedservice.CreateApprovalLinksRequest{
+		DeploymentId: md.Deployment.Id,
+		StageId:      md.StageId,
+		Approvers:    approvers,
+		Ttl:          int64(ttl.Seconds()),
+	})
+	if err != nil {
+		e.logger.Error("failed to create approval links, sending the emails without them", zap.Error(err))
+		return recipients
+	}
+
+	rest := make([]string, 0, len(recipients))
+	for _, addr := range recipients {
+		user, ok := users[addr]
+		if !ok {
+			rest = append(rest, addr)
+			continue
+		}
+		link, ok := resp.Links[user]
+		if !ok {
+			rest = append(rest, addr)
+			continue
+		}
+		m := emailMessage{
+			subject: msg.subject,
+			body: fmt.Sprintf("%s\nApprove or reject this stage as %s by opening the link below:\n%s\n\nThe link can be used only once and expires at %s.\n",
+				msg.body, user, link, time.Unix(md.TimeoutAt, 0).UTC().Format(time.RFC1123)),
+		}
+		if err := e.send([]string{addr}, m); err != nil {
+			e.logger.Error(fmt.Sprintf("unable to send email with approval link: %v", err))
+		}
+	}
+	return rest
+}
+
+// recipients returns all the addresses receiving the notifications.
+func (e *email) recipients() []string {
+	out := make([]string, 0, len(e.config.To)+len(e.config.Approvers))
+	out = append(out, e.config.To...)
+	addrs := make([]string, 0, len(e.config.Approvers))
+	for addr := range e.config.Approvers {
+		if !contains(out, addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return append(out, addrs...)
+}
+
+func (e *email) send(to []string, msg emailMessage) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", e.nowFunc().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.body, "\n", "\r\n"))
+	return e.sendMail(e.config.SMTPAddress, e.auth, e.config.From, to, b.Bytes())
+}
+
+func (e *email) buildEmailMessage(event model.NotificationEvent) (emailMessage, bool) {
+	var (
+		title, text string
+		lines       []string
+	)
+
+	generateDeploymentEventData := func(d *model.Deployment, envName string) {
+		lines = []string{
+			fmt.Sprintf("Env: %s", envName),
+			fmt.Sprintf("Application: %s (%s/applications/%s)", d.ApplicationName, e.webURL, d.ApplicationId),
+			fmt.Sprintf("Kind: %s", strings.ToLower(d.Kind.String())),
+			fmt.Sprintf("Deployment: %s/deployments/%s", e.webURL, d.Id),
+			fmt.Sprintf("Triggered By: %s", d.TriggeredBy()),
+			fmt.Sprintf("Started At: %s", time.Unix(d.CreatedAt, 0).UTC().Format(time.RFC1123)),
+		}
+	}
+	generateApplicationEventData := func(app *model.Application, envName string, state *model.ApplicationSyncState) {
+		lines = []string{
+			fmt.Sprintf("Env: %s", envName),
+			fmt.Sprintf("Application: %s (%s/applications/%s)", app.Name, e.webURL, app.Id),
+			fmt.Sprintf("Kind: %s", strings.ToLower(app.Kind.String())),
+			fmt.Sprintf("Detected At: %s", time.Unix(state.Timestamp, 0).UTC().Format(time.RFC1123)),
+		}
+	}
+	generatePipedEventData := func(id, version string) {
+		lines = []string{
+			fmt.Sprintf("Id: %s", id),
+			fmt.Sprintf("Version: %s", version),
+		}
+	}
+
+	switch event.Type {
+	case model.NotificationEventType_EVENT_DEPLOYMENT_TRIGGERED:
+		md := event.Metadata.(*model.NotificationEventDeploymentTriggered)
+		title = fmt.Sprintf("Triggered a new deployment for %q", md.Deployment.ApplicationName)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_PLANNED:
+		md := event.Metadata.(*model.NotificationEventDeploymentPlanned)
+		title = fmt.Sprintf("Deployment for %q was planned", md.Deployment.ApplicationName)
+		text = md.Summary
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_ROLLING_BACK:
+		md := event.Metadata.(*model.NotificationEventDeploymentRollingBack)
+		title = fmt.Sprintf("Deployment for %q is rolling back", md.Deployment.ApplicationName)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED:
+		md := event.Metadata.(*model.NotificationEventDeploymentSucceeded)
+		title = fmt.Sprintf("Deployment for %q was completed successfully", md.Deployment.ApplicationName)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_FAILED:
+		md := event.Metadata.(*model.NotificationEventDeploymentFailed)
+		title = fmt.Sprintf("Deployment for %q was failed", md.Deployment.ApplicationName)
+		text = md.Reason
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_CANCELLED:
+		md := event.Metadata.(*model.NotificationEventDeploymentCancelled)
+		title = fmt.Sprintf("Deployment for %q was cancelled", md.Deployment.ApplicationName)
+		text = fmt.Sprintf("Cancelled by %s", md.Commander)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL:
+		md := event.Metadata.(*model.NotificationEventDeploymentWaitApproval)
+		title = fmt.Sprintf("Deployment for %q is waiting for an approval", md.Deployment.ApplicationName)
+		text = fmt.Sprintf("Waiting for an approval until %s", time.Unix(md.TimeoutAt, 0).UTC().Format(time.RFC1123))
+		if len(md.Approvers) > 0 {
+			text = fmt.Sprintf("%s from %s", text, strings.Join(md.Approvers, ", "))
+		}
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_ANALYSIS_STARTED:
+		md := event.Metadata.(*model.NotificationEventAnalysisStarted)
+		title = fmt.Sprintf("Analysis for %q was started", md.Deployment.ApplicationName)
+		text = fmt.Sprintf("Analyzing for %v", time.Duration(md.Duration)*time.Second)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_ANALYSIS_FAILED:
+		md := event.Metadata.(*model.NotificationEventAnalysisFailed)
+		title = fmt.Sprintf("Analysis for %q was failed", md.Deployment.ApplicationName)
+		text = md.Reason
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+		if md.AnalyzerId != "" {
+			lines = append(lines,
+				fmt.Sprintf("Provider: %s", md.ProviderType),
+				fmt.Sprintf("Failures: %d", md.FailureCount),
+				fmt.Sprintf("Query: %s", md.Query),
+				fmt.Sprintf("Last Result: %s", md.LastResult),
+			)
+		}
+
+	case model.NotificationEventType_EVENT_DEPENDENCY_OUTDATED:
+		md := event.Metadata.(*model.NotificationEventDependencyOutdated)
+		title = fmt.Sprintf("Application %q has %d outdated dependencies", md.Application.Name, len(md.Dependencies))
+		text = describeOutdatedDependencies(md.Dependencies)
+		lines = []string{
+			fmt.Sprintf("Env: %s", md.EnvName),
+			fmt.Sprintf("Application: %s (%s/applications/%s)", md.Application.Name, e.webURL, md.Application.Id),
+			fmt.Sprintf("Kind: %s", strings.ToLower(md.Application.Kind.String())),
+		}
+
+	case model.NotificationEventType_EVENT_APPLICATION_OUT_OF_SYNC:
+		md := event.Metadata.(*model.NotificationEventApplicationOutOfSync)
+		title = fmt.Sprintf("Application %q is out of sync", md.Application.Name)
+		text = md.State.ShortReason
+		generateApplicationEventData(md.Application, md.EnvName, md.State)
+
+	case model.NotificationEventType_EVENT_APPLICATION_SYNCED:
+		md := event.Metadata.(*model.NotificationEventApplicationSynced)
+		title = fmt.Sprintf("Application %q is back in sync", md.Application.Name)
+		generateApplicationEventData(md.Application, md.EnvName, md.State)
+
+	case model.NotificationEventType_EVENT_PIPED_STARTED:
+		md := event.Metadata.(*model.NotificationEventPipedStarted)
+		title = "A piped has been started"
+		generatePipedEventData(md.Id, md.Version)
+
+	case model.NotificationEventType_EVENT_PIPED_STOPPED:
+		md := event.Metadata.(*model.NotificationEventPipedStopped)
+		title = "A piped has been stopped"
+		generatePipedEventData(md.Id, md.Version)
+
+	case model.NotificationEventType_EVENT_PIPED_OFFLINE:
+		md := event.Metadata.(*model.NotificationEventPipedOffline)
+		title = "A piped has lost its connection to the control plane"
+		text = md.Reason
+		generatePipedEventData(md.Id, md.Version)
+		lines = append(lines, fmt.Sprintf("Last Seen At: %s", time.Unix(md.LastSeenAt, 0).UTC().Format(time.RFC1123)))
+
+	case model.NotificationEventType_EVENT_PIPED_ONLINE:
+		md := event.Metadata.(*model.NotificationEventPipedOnline)
+		title = "A piped has reconnected to the control plane"
+		generatePipedEventData(md.Id, md.Version)
+		lines = append(lines, fmt.Sprintf("Offline Since: %s", time.Unix(md.OfflineSince, 0).UTC().Format(time.RFC1123)))
+
+	default:
+		return emailMessage{}, false
+	}
+
+	var b strings.Builder
+	b.WriteString(title + "\n\n")
+	if text != "" {
+		b.WriteString(text + "\n\n")
+	}
+	for _, l := range lines {
+		b.WriteString(l + "\n")
+	}
+	return emailMessage{
+		subject: "[PipeCD] " + title,
+		body:    b.String(),
+	}, true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
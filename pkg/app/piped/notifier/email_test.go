@@ -0,0 +1,304 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipe/pkg/app/api/service/pipedservice"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeApprovalLinkCreator struct {
+	req *pipedservice.CreateApprovalLinksRequest
+	err error
+}
+
+func (f *fakeApprovalLinkCreator) CreateApprovalLinks(_ context.Context, req *pipedservice.CreateApprovalLinksRequest, _ ...grpc.CallOption) (*pipedservice.CreateApprovalLinksResponse, error) {
+	f.req = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	links := make(map[string]string, len(req.Approvers))
+	for _, a := range req.Approvers {
+		links[a] = "https://pipecd.dev/approvals?token=" + a
+	}
+	return &pipedservice.CreateApprovalLinksResponse{Links: links}, nil
+}
+
+type sentMail struct {
+	to  []string
+	msg string
+}
+
+func newTestEmailSender(cfg config.NotificationReceiverEmail, lc approvalLinkCreator, sent *[]sentMail) *email {
+	e, _ := newEmailSender("email", cfg, "https://pipecd.dev/", lc, zap.NewNop())
+	e.nowFunc = func() time.Time { return time.Unix(1000, 0) }
+	e.sendMail = func(_ string, _ smtp.Auth, _ string, to []string, msg []byte) error {
+		*sent = append(*sent, sentMail{to: to, msg: string(msg)})
+		return nil
+	}
+	return e
+}
+
+func TestBuildEmailMessage(t *testing.T) {
+	d := &model.Deployment{
+		Id:              "deployment-id",
+		ApplicationId:   "app-id",
+		ApplicationName: "app",
+		Kind:            model.ApplicationKind_KUBERNETES,
+		Trigger: &model.DeploymentTrigger{
+			Commit: &model.Commit{
+				Author: "user",
+			},
+		},
+	}
+	e := &email{webURL: "https://pipecd.dev"}
+
+	testcases := []struct {
+		name     string
+		event    model.NotificationEvent
+		subject  string
+		contains []string
+		ignored  bool
+	}{
+		{
+			name: "deployment failed",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
+				Metadata: &model.NotificationEventDeploymentFailed{
+					Deployment: d,
+					EnvName:    "prod",
+					Reason:     "stage K8S_SYNC was failed",
+				},
+			},
+			subject: `[PipeCD] Deployment for "app" was failed`,
+			contains: []string{
+				"stage K8S_SYNC was failed",
+				"Env: prod",
+				"Deployment: https://pipecd.dev/deployments/deployment-id",
+			},
+		},
+		{
+			name: "waiting for an approval",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL,
+				Metadata: &model.NotificationEventDeploymentWaitApproval{
+					Deployment: d,
+					EnvName:    "prod",
+					Approvers:  []string{"foo", "bar"},
+				},
+			},
+			subject: `[PipeCD] Deployment for "app" is waiting for an approval`,
+			contains: []string{
+				"from foo, bar",
+				"Application: app (https://pipecd.dev/applications/app-id)",
+			},
+		},
+		{
+			name: "piped started",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_PIPED_STARTED,
+				Metadata: &model.NotificationEventPipedStarted{
+					Id:      "piped-id",
+					Version: "v0.1.0",
+				},
+			},
+			subject:  "[PipeCD] A piped has been started",
+			contains: []string{"Id: piped-id", "Version: v0.1.0"},
+		},
+		{
+			name: "piped offline",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_PIPED_OFFLINE,
+				Metadata: &model.NotificationEventPipedOffline{
+					Id:         "piped-id",
+					Version:    "v0.1.0",
+					LastSeenAt: 1600000000,
+					Reason:     "connection refused",
+				},
+			},
+			subject: "[PipeCD] A piped has lost its connection to the control plane",
+			contains: []string{
+				"connection refused",
+				"Id: piped-id",
+				"Last Seen At: Sun, 13 Sep 2020 12:26:40 UTC",
+			},
+		},
+		{
+			name: "application out of sync",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_APPLICATION_OUT_OF_SYNC,
+				Metadata: &model.NotificationEventApplicationOutOfSync{
+					Application: &model.Application{
+						Id:   "app-id",
+						Name: "app",
+						Kind: model.ApplicationKind_KUBERNETES,
+					},
+					EnvName: "prod",
+					State: &model.ApplicationSyncState{
+						Status:      model.ApplicationSyncStatus_OUT_OF_SYNC,
+						ShortReason: "There are 2 manifests not synced",
+					},
+				},
+			},
+			subject: `[PipeCD] Application "app" is out of sync`,
+			contains: []string{
+				"There are 2 manifests not synced",
+				"Application: app (https://pipecd.dev/applications/app-id)",
+				"Kind: kubernetes",
+			},
+		},
+		{
+			name: "analysis failed",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_ANALYSIS_FAILED,
+				Metadata: &model.NotificationEventAnalysisFailed{
+					Deployment:   d,
+					EnvName:      "prod",
+					Reason:       "analysis failed",
+					AnalyzerId:   "metrics-0",
+					ProviderType: "PROMETHEUS",
+					Query:        "sum(errors)",
+					LastResult:   "12",
+					FailureCount: 2,
+				},
+			},
+			subject: `[PipeCD] Analysis for "app" was failed`,
+			contains: []string{
+				"Provider: PROMETHEUS",
+				"Failures: 2",
+				"Query: sum(errors)",
+				"Last Result: 12",
+			},
+		},
+		{
+			name: "dependency outdated",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPENDENCY_OUTDATED,
+				Metadata: &model.NotificationEventDependencyOutdated{
+					Application: &model.Application{
+						Id:   "app-id",
+						Name: "app",
+						Kind: model.ApplicationKind_TERRAFORM,
+					},
+					EnvName: "prod",
+					Dependencies: []*model.NotificationEventDependencyOutdated_Dependency{
+						{
+							Kind:          "provider",
+							Name:          "aws",
+							Source:        "hashicorp/aws",
+							Constraint:    "~> 3.0",
+							LatestVersion: "4.1.0",
+							File:          "main.tf",
+						},
+					},
+				},
+			},
+			subject: `[PipeCD] Application "app" has 1 outdated dependencies`,
+			contains: []string{
+				"provider aws (hashicorp/aws): ~> 3.0 -> 4.1.0 in main.tf",
+				"Application: app (https://pipecd.dev/applications/app-id)",
+				"Kind: terraform",
+			},
+		},
+		{
+			name: "ignore application healthy",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_APPLICATION_HEALTHY,
+			},
+			ignored: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, ok := e.buildEmailMessage(tc.event)
+			assert.Equal(t, !tc.ignored, ok)
+			if tc.ignored {
+				return
+			}
+			assert.Equal(t, tc.subject, msg.subject)
+			for _, c := range tc.contains {
+				assert.Contains(t, msg.body, c)
+			}
+		})
+	}
+}
+
+func TestEmailSendApprovalLinks(t *testing.T) {
+	cfg := config.NotificationReceiverEmail{
+		SMTPAddress: "smtp.example.com:587",
+		From:        "pipecd@example.com",
+		To:          []string{"team@example.com"},
+		Approvers: map[string]string{
+			"foo@example.com": "foo",
+			"bar@example.com": "bar",
+		},
+	}
+	event := model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL,
+		Metadata: &model.NotificationEventDeploymentWaitApproval{
+			Deployment: &model.Deployment{
+				Id:              "deployment-id",
+				ApplicationName: "app",
+				Trigger:         &model.DeploymentTrigger{Commit: &model.Commit{}},
+			},
+			EnvName:   "prod",
+			Approvers: []string{"foo"},
+			TimeoutAt: 4600,
+			StageId:   "stage-id",
+		},
+	}
+
+	t.Run("only allowed approvers receive the links", func(t *testing.T) {
+		var sent []sentMail
+		lc := &fakeApprovalLinkCreator{}
+		e := newTestEmailSender(cfg, lc, &sent)
+		e.sendEvent(context.Background(), event)
+
+		require.NotNil(t, lc.req)
+		assert.Equal(t, []string{"foo"}, lc.req.Approvers)
+		assert.Equal(t, "stage-id", lc.req.StageId)
+		assert.Equal(t, int64(3600), lc.req.Ttl)
+
+		require.Len(t, sent, 2)
+		assert.Equal(t, []string{"foo@example.com"}, sent[0].to)
+		assert.True(t, strings.Contains(sent[0].msg, "https://pipecd.dev/approvals?token=foo"))
+		assert.Equal(t, []string{"team@example.com", "bar@example.com"}, sent[1].to)
+		assert.False(t, strings.Contains(sent[1].msg, "/approvals"))
+	})
+
+	t.Run("fall back to plain email when links are unavailable", func(t *testing.T) {
+		var sent []sentMail
+		lc := &fakeApprovalLinkCreator{err: errors.New("unavailable")}
+		e := newTestEmailSender(cfg, lc, &sent)
+		e.sendEvent(context.Background(), event)
+
+		require.Len(t, sent, 1)
+		assert.Equal(t, []string{"team@example.com", "bar@example.com", "foo@example.com"}, sent[0].to)
+		assert.False(t, strings.Contains(sent[0].msg, "/approvals"))
+	})
+}
@@ -28,6 +28,8 @@ type matcher struct {
 	ignoreApps   map[string]struct{}
 	envs         map[string]struct{}
 	ignoreEnvs   map[string]struct{}
+	labels       map[string]string
+	ignoreLabels map[string]string
 }
 
 func newMatcher(cfg config.NotificationRoute) *matcher {
@@ -40,6 +42,8 @@ func newMatcher(cfg config.NotificationRoute) *matcher {
 		ignoreApps:   makeStringMap(cfg.IgnoreApps, ""),
 		envs:         makeStringMap(cfg.Envs, ""),
 		ignoreEnvs:   makeStringMap(cfg.IgnoreEnvs, ""),
+		labels:       cfg.Labels,
+		ignoreLabels: cfg.IgnoreLabels,
 	}
 }
 
@@ -51,6 +55,10 @@ type envNameMetadata interface {
 	GetEnvName() string
 }
 
+type deploymentMetadata interface {
+	GetDeployment() *model.Deployment
+}
+
 func (m *matcher) Match(event model.NotificationEvent) bool {
 	if _, ok := m.ignoreEvents[event.Type.String()]; ok {
 		return false
@@ -75,6 +83,16 @@ func (m *matcher) Match(event model.NotificationEvent) bool {
 		return false
 	}
 
+	// Labels are only available for the events of deployments.
+	var labels map[string]string
+	md, hasLabels := event.Metadata.(deploymentMetadata)
+	if hasLabels {
+		labels = md.GetDeployment().GetLabels()
+	}
+	if hasLabels && containsAnyLabel(labels, m.ignoreLabels) {
+		return false
+	}
+
 	if len(m.events) > 0 {
 		if _, ok := m.events[event.Type.String()]; !ok {
 			return false
@@ -95,6 +113,9 @@ func (m *matcher) Match(event model.NotificationEvent) bool {
 			return false
 		}
 	}
+	if len(m.labels) > 0 && hasLabels && !containsAllLabels(labels, m.labels) {
+		return false
+	}
 
 	return true
 }
@@ -109,3 +130,21 @@ func makeStringMap(keys []string, prefix string) map[string]struct{} {
 	}
 	return m
 }
+
+func containsAllLabels(labels, expected map[string]string) bool {
+	for k, v := range expected {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAnyLabel(labels, expected map[string]string) bool {
+	for k, v := range expected {
+		if l, ok := labels[k]; ok && l == v {
+			return true
+		}
+	}
+	return false
+}
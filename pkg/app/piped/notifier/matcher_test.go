@@ -160,6 +160,64 @@ func TestMatch(t *testing.T) {
 				}: true,
 			},
 		},
+		{
+			name: "filter by labels",
+			config: config.NotificationRoute{
+				Labels: map[string]string{
+					"team": "payment",
+					"tier": "backend",
+				},
+				IgnoreLabels: map[string]string{
+					"notification": "muted",
+				},
+			},
+			matchings: map[model.NotificationEvent]bool{
+				{
+					Type: model.NotificationEventType_EVENT_DEPLOYMENT_TRIGGERED,
+					Metadata: &model.NotificationEventDeploymentTriggered{
+						Deployment: &model.Deployment{
+							Labels: map[string]string{
+								"team": "payment",
+								"tier": "backend",
+								"lang": "go",
+							},
+						},
+					},
+				}: true,
+				{
+					Type: model.NotificationEventType_EVENT_DEPLOYMENT_PLANNED,
+					Metadata: &model.NotificationEventDeploymentPlanned{
+						Deployment: &model.Deployment{
+							Labels: map[string]string{
+								"team": "payment",
+							},
+						},
+					},
+				}: false,
+				{
+					Type: model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED,
+					Metadata: &model.NotificationEventDeploymentSucceeded{
+						Deployment: &model.Deployment{
+							Labels: map[string]string{
+								"team":         "payment",
+								"tier":         "backend",
+								"notification": "muted",
+							},
+						},
+					},
+				}: false,
+				{
+					Type: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
+					Metadata: &model.NotificationEventDeploymentFailed{
+						Deployment: &model.Deployment{},
+					},
+				}: false,
+				{
+					Type:     model.NotificationEventType_EVENT_PIPED_STARTED,
+					Metadata: &model.NotificationEventPipedStarted{},
+				}: true,
+			},
+		},
 	}
 
 	for _, tc := range testcases {
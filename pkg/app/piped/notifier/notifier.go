@@ -19,6 +19,7 @@ package notifier
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/atomic"
@@ -49,7 +50,7 @@ type sender interface {
 	Close(ctx context.Context)
 }
 
-func NewNotifier(cfg *config.PipedSpec, logger *zap.Logger) (*Notifier, error) {
+func NewNotifier(cfg *config.PipedSpec, lc approvalLinkCreator, logger *zap.Logger) (*Notifier, error) {
 	logger = logger.Named("notifier")
 	receivers := make(map[string]config.NotificationReceiver, len(cfg.Notifications.Receivers))
 	for _, r := range cfg.Notifications.Receivers {
@@ -66,9 +67,21 @@ func NewNotifier(cfg *config.PipedSpec, logger *zap.Logger) (*Notifier, error) {
 		var sd sender
 		switch {
 		case receiver.Slack != nil:
-			sd = newSlackSender(receiver.Name, *receiver.Slack, cfg.WebAddress, logger)
+			sd = newSlackSender(receiver.Name, *receiver.Slack, cfg.WebAddress, cfg.Notifications.SlackInteraction != nil, logger)
 		case receiver.Webhook != nil:
 			sd = newWebhookSender(receiver.Name, *receiver.Webhook, logger)
+		case receiver.PagerDuty != nil:
+			pd, err := newPagerDutySender(receiver.Name, *receiver.PagerDuty, cfg.WebAddress, logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create pagerduty receiver %s: %w", receiver.Name, err)
+			}
+			sd = pd
+		case receiver.Email != nil:
+			em, err := newEmailSender(receiver.Name, *receiver.Email, cfg.WebAddress, lc, logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create email receiver %s: %w", receiver.Name, err)
+			}
+			sd = em
 		default:
 			continue
 		}
@@ -148,3 +161,13 @@ func (n *Notifier) Notify(event model.NotificationEvent) {
 		h.sender.Notify(event)
 	}
 }
+
+// describeOutdatedDependencies returns one line for each outdated dependency
+// such as "provider aws (hashicorp/aws): ~> 3.0 -> 4.1.0 in main.tf".
+func describeOutdatedDependencies(deps []*model.NotificationEventDependencyOutdated_Dependency) string {
+	lines := make([]string, 0, len(deps))
+	for _, d := range deps {
+		lines = append(lines, fmt.Sprintf("%s %s (%s): %s -> %s in %s", d.Kind, d.Name, d.Source, d.Constraint, d.LatestVersion, d.File))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const (
+	pagerDutyEventsURL       = "https://events.pagerduty.com/v2/enqueue"
+	pagerDutyDefaultSeverity = "error"
+	pagerDutySource          = "PipeCD"
+)
+
+type pagerDuty struct {
+	name       string
+	routingKey string
+	severity   string
+	eventsURL  string
+	webURL     string
+	httpClient *http.Client
+	eventCh    chan model.NotificationEvent
+	logger     *zap.Logger
+}
+
+func newPagerDutySender(name string, cfg config.NotificationReceiverPagerDuty, webURL string, logger *zap.Logger) (*pagerDuty, error) {
+	key, err := ioutil.ReadFile(cfg.RoutingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read routing key file %s (%w)", cfg.RoutingKeyFile, err)
+	}
+	severity := cfg.Severity
+	if severity == "" {
+		severity = pagerDutyDefaultSeverity
+	}
+	return &pagerDuty{
+		name:       name,
+		routingKey: strings.TrimSpace(string(key)),
+		severity:   severity,
+		eventsURL:  pagerDutyEventsURL,
+		webURL:     strings.TrimRight(webURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		eventCh: make(chan model.NotificationEvent, 100),
+		logger:  logger.Named("pagerduty"),
+	}, nil
+}
+
+func (p *pagerDuty) Run(ctx context.Context) error {
+	for {
+		select {
+		case event, ok := <-p.eventCh:
+			if ok {
+				p.sendEvent(ctx, event)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *pagerDuty) Notify(event model.NotificationEvent) {
+	p.eventCh <- event
+}
+
+func (p *pagerDuty) Close(ctx context.Context) {
+	close(p.eventCh)
+
+	// Send all remaining events.
+	for {
+		select {
+		case event, ok := <-p.eventCh:
+			if !ok {
+				return
+			}
+			p.sendEvent(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *pagerDuty) sendEvent(ctx context.Context, event model.NotificationEvent) {
+	e, ok := p.buildPagerDutyEvent(event)
+	if !ok {
+		p.logger.Info(fmt.Sprintf("ignore event %s", event.Type.String()))
+		return
+	}
+	if err := p.sendPagerDutyEvent(ctx, e); err != nil {
+		p.logger.Error(fmt.Sprintf("unable to send event to pagerduty: %v", err))
+	}
+}
+
+func (p *pagerDuty) sendPagerDutyEvent(ctx context.Context, e pagerDutyEvent) error {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(e); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.eventsURL, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		return fmt.Errorf("%s from PagerDuty: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// buildPagerDutyEvent returns the PagerDuty event for the given notification event.
+// All events of an application share the same dedup key so that the alert triggered
+// by a failed deployment is resolved by the next successful one.
+func (p *pagerDuty) buildPagerDutyEvent(event model.NotificationEvent) (pagerDutyEvent, bool) {
+	var (
+		action, summary string
+		d               *model.Deployment
+		envName         string
+		details         = make(map[string]string)
+	)
+
+	switch event.Type {
+	case model.NotificationEventType_EVENT_DEPLOYMENT_ROLLING_BACK:
+		md := event.Metadata.(*model.NotificationEventDeploymentRollingBack)
+		d, envName = md.Deployment, md.EnvName
+		action = "trigger"
+		summary = fmt.Sprintf("Deployment for %q is rolling back", d.ApplicationName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_FAILED:
+		md := event.Metadata.(*model.NotificationEventDeploymentFailed)
+		d, envName = md.Deployment, md.EnvName
+		action = "trigger"
+		summary = fmt.Sprintf("Deployment for %q was failed", d.ApplicationName)
+		if md.Reason != "" {
+			details["reason"] = md.Reason
+		}
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED:
+		md := event.Metadata.(*model.NotificationEventDeploymentSucceeded)
+		d, envName = md.Deployment, md.EnvName
+		action = "resolve"
+		summary = fmt.Sprintf("Deployment for %q was completed successfully", d.ApplicationName)
+
+	default:
+		return pagerDutyEvent{}, false
+	}
+
+	e := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: action,
+		DedupKey:    fmt.Sprintf("pipecd/%s/%s", d.PipedId, d.ApplicationId),
+	}
+	// The payload is only used while triggering an alert.
+	if action == "resolve" {
+		return e, true
+	}
+
+	details["deployment"] = d.Id
+	details["kind"] = strings.ToLower(d.Kind.String())
+	details["triggeredBy"] = d.TriggeredBy()
+	e.Payload = &pagerDutyPayload{
+		Summary:       summary,
+		Source:        pagerDutySource,
+		Severity:      p.severity,
+		Component:     d.ApplicationName,
+		Group:         envName,
+		CustomDetails: details,
+	}
+	if p.webURL != "" {
+		e.Links = []pagerDutyLink{{
+			Href: p.webURL + "/deployments/" + d.Id,
+			Text: "Deployment",
+		}}
+	}
+	return e, true
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestBuildPagerDutyEvent(t *testing.T) {
+	d := &model.Deployment{
+		Id:              "deployment-id",
+		ApplicationId:   "app-id",
+		ApplicationName: "app",
+		PipedId:         "piped-id",
+		Kind:            model.ApplicationKind_KUBERNETES,
+		Trigger: &model.DeploymentTrigger{
+			Commit: &model.Commit{
+				Author: "user",
+			},
+		},
+	}
+	p := &pagerDuty{
+		routingKey: "routing-key",
+		severity:   "critical",
+		webURL:     "https://pipecd.dev",
+	}
+
+	testcases := []struct {
+		name       string
+		event      model.NotificationEvent
+		expected   string
+		hasPayload bool
+		ignored    bool
+	}{
+		{
+			name: "rolling back triggers an alert",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPLOYMENT_ROLLING_BACK,
+				Metadata: &model.NotificationEventDeploymentRollingBack{
+					Deployment: d,
+					EnvName:    "prod",
+				},
+			},
+			expected:   "trigger",
+			hasPayload: true,
+		},
+		{
+			name: "failure triggers an alert",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
+				Metadata: &model.NotificationEventDeploymentFailed{
+					Deployment: d,
+					EnvName:    "prod",
+					Reason:     "stage K8S_SYNC was failed",
+				},
+			},
+			expected:   "trigger",
+			hasPayload: true,
+		},
+		{
+			name: "success resolves the alert",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED,
+				Metadata: &model.NotificationEventDeploymentSucceeded{
+					Deployment: d,
+					EnvName:    "prod",
+				},
+			},
+			expected: "resolve",
+		},
+		{
+			name: "other events are ignored",
+			event: model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_DEPLOYMENT_TRIGGERED,
+				Metadata: &model.NotificationEventDeploymentTriggered{
+					Deployment: d,
+					EnvName:    "prod",
+				},
+			},
+			ignored: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, ok := p.buildPagerDutyEvent(tc.event)
+			assert.Equal(t, !tc.ignored, ok)
+			if tc.ignored {
+				return
+			}
+			assert.Equal(t, tc.expected, e.EventAction)
+			assert.Equal(t, "routing-key", e.RoutingKey)
+			assert.Equal(t, "pipecd/piped-id/app-id", e.DedupKey)
+			assert.Equal(t, tc.hasPayload, e.Payload != nil)
+			if tc.hasPayload {
+				assert.Equal(t, "critical", e.Payload.Severity)
+				assert.Equal(t, "app", e.Payload.Component)
+				assert.Equal(t, "prod", e.Payload.Group)
+				assert.Equal(t, "https://pipecd.dev/deployments/deployment-id", e.Links[0].Href)
+			}
+		})
+	}
+}
+
+func TestSendPagerDutyEvent(t *testing.T) {
+	var got pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	p := &pagerDuty{
+		eventsURL:  server.URL,
+		httpClient: server.Client(),
+		logger:     zap.NewNop(),
+	}
+	err := p.sendPagerDutyEvent(context.Background(), pagerDutyEvent{
+		RoutingKey:  "routing-key",
+		EventAction: "resolve",
+		DedupKey:    "pipecd/piped-id/app-id",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "resolve", got.EventAction)
+	assert.Equal(t, "pipecd/piped-id/app-id", got.DedupKey)
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -46,13 +47,17 @@ type slack struct {
 	httpClient *http.Client
 	eventCh    chan model.NotificationEvent
 	logger     *zap.Logger
+
+	// Whether to add the buttons handled by SlackInteractionHandler.
+	interactive bool
 }
 
-func newSlackSender(name string, cfg config.NotificationReceiverSlack, webURL string, logger *zap.Logger) *slack {
+func newSlackSender(name string, cfg config.NotificationReceiverSlack, webURL string, interactive bool, logger *zap.Logger) *slack {
 	return &slack{
-		name:   name,
-		config: cfg,
-		webURL: strings.TrimRight(webURL, "/"),
+		name:        name,
+		config:      cfg,
+		webURL:      strings.TrimRight(webURL, "/"),
+		interactive: interactive,
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
@@ -137,6 +142,7 @@ func (s *slack) buildSlackMessage(event model.NotificationEvent, webURL string)
 		color             = slackInfoColor
 		timestamp         = time.Now().Unix()
 		fields            []slackField
+		actions           []slackAction
 	)
 
 	generateDeploymentEventData := func(d *model.Deployment, envName string) {
@@ -149,6 +155,24 @@ func (s *slack) buildSlackMessage(event model.NotificationEvent, webURL string)
 			{"Triggered By", d.TriggeredBy(), true},
 			{"Started At", makeSlackDate(d.CreatedAt), true},
 		}
+		if c := d.Trigger.GetCommit(); c.GetPullRequest() > 0 {
+			fields = append(fields, slackField{"Pull Request", makeSlackPullRequest(c), false})
+		}
+		if files := d.Trigger.GetChangedFiles(); len(files) > 0 {
+			fields = append(fields, slackField{"Changed Files", makeSlackChangedFiles(files), false})
+		}
+		if len(d.Notes) > 0 {
+			fields = append(fields, slackField{"Notes", makeSlackNotes(d.Notes), false})
+		}
+	}
+	generateApplicationEventData := func(app *model.Application, envName string, state *model.ApplicationSyncState) {
+		link = webURL + "/applications/" + app.Id
+		fields = []slackField{
+			{"Env", truncateText(envName, 8), true},
+			{"Application", makeSlackLink(app.Name, link), true},
+			{"Kind", strings.ToLower(app.Kind.String()), true},
+			{"Detected At", makeSlackDate(state.Timestamp), true},
+		}
 	}
 	generatePipedEventData := func(id, version string) {
 		link = webURL + "/settings/piped"
@@ -170,6 +194,12 @@ func (s *slack) buildSlackMessage(event model.NotificationEvent, webURL string)
 		text = md.Summary
 		generateDeploymentEventData(md.Deployment, md.EnvName)
 
+	case model.NotificationEventType_EVENT_DEPLOYMENT_ROLLING_BACK:
+		md := event.Metadata.(*model.NotificationEventDeploymentRollingBack)
+		title = fmt.Sprintf("Deployment for %q is rolling back", md.Deployment.ApplicationName)
+		color = slackWarnColor
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
 	case model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED:
 		md := event.Metadata.(*model.NotificationEventDeploymentSucceeded)
 		title = fmt.Sprintf("Deployment for %q was completed successfully", md.Deployment.ApplicationName)
@@ -190,6 +220,65 @@ func (s *slack) buildSlackMessage(event model.NotificationEvent, webURL string)
 		color = slackWarnColor
 		generateDeploymentEventData(md.Deployment, md.EnvName)
 
+	case model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL:
+		md := event.Metadata.(*model.NotificationEventDeploymentWaitApproval)
+		title = fmt.Sprintf("Deployment for %q is waiting for an approval", md.Deployment.ApplicationName)
+		text = fmt.Sprintf("Waiting for an approval until %s", time.Unix(md.TimeoutAt, 0).UTC().Format(time.RFC1123))
+		if len(md.Approvers) > 0 {
+			text = fmt.Sprintf("%s from %s", text, strings.Join(md.Approvers, ", "))
+		}
+		color = slackWarnColor
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+		if s.interactive && md.StageId != "" {
+			actions = makeSlackWaitApprovalActions(md.Deployment.Id, md.StageId)
+		}
+
+	case model.NotificationEventType_EVENT_ANALYSIS_STARTED:
+		md := event.Metadata.(*model.NotificationEventAnalysisStarted)
+		title = fmt.Sprintf("Analysis for %q was started", md.Deployment.ApplicationName)
+		text = fmt.Sprintf("Analyzing for %v", time.Duration(md.Duration)*time.Second)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_ANALYSIS_FAILED:
+		md := event.Metadata.(*model.NotificationEventAnalysisFailed)
+		title = fmt.Sprintf("Analysis for %q was failed", md.Deployment.ApplicationName)
+		text = md.Reason
+		color = slackErrorColor
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+		if md.AnalyzerId != "" {
+			fields = append(fields,
+				slackField{"Provider", md.ProviderType, true},
+				slackField{"Failures", strconv.Itoa(int(md.FailureCount)), true},
+				slackField{"Query", md.Query, false},
+				slackField{"Last Result", md.LastResult, false},
+			)
+		}
+
+	case model.NotificationEventType_EVENT_DEPENDENCY_OUTDATED:
+		md := event.Metadata.(*model.NotificationEventDependencyOutdated)
+		title = fmt.Sprintf("Application %q has %d outdated dependencies", md.Application.Name, len(md.Dependencies))
+		text = describeOutdatedDependencies(md.Dependencies)
+		color = slackWarnColor
+		link = webURL + "/applications/" + md.Application.Id
+		fields = []slackField{
+			{"Env", truncateText(md.EnvName, 8), true},
+			{"Application", makeSlackLink(md.Application.Name, link), true},
+			{"Kind", strings.ToLower(md.Application.Kind.String()), true},
+		}
+
+	case model.NotificationEventType_EVENT_APPLICATION_OUT_OF_SYNC:
+		md := event.Metadata.(*model.NotificationEventApplicationOutOfSync)
+		title = fmt.Sprintf("Application %q is out of sync", md.Application.Name)
+		text = md.State.ShortReason
+		color = slackWarnColor
+		generateApplicationEventData(md.Application, md.EnvName, md.State)
+
+	case model.NotificationEventType_EVENT_APPLICATION_SYNCED:
+		md := event.Metadata.(*model.NotificationEventApplicationSynced)
+		title = fmt.Sprintf("Application %q is back in sync", md.Application.Name)
+		color = slackSuccessColor
+		generateApplicationEventData(md.Application, md.EnvName, md.State)
+
 	case model.NotificationEventType_EVENT_PIPED_STARTED:
 		md := event.Metadata.(*model.NotificationEventPipedStarted)
 		title = "A piped has been started"
@@ -200,12 +289,64 @@ func (s *slack) buildSlackMessage(event model.NotificationEvent, webURL string)
 		title = "A piped has been stopped"
 		generatePipedEventData(md.Id, md.Version)
 
-	// TODO: Support application type of notification event.
+	case model.NotificationEventType_EVENT_PIPED_OFFLINE:
+		md := event.Metadata.(*model.NotificationEventPipedOffline)
+		title = "A piped has lost its connection to the control plane"
+		text = md.Reason
+		color = slackErrorColor
+		generatePipedEventData(md.Id, md.Version)
+		fields = append(fields, slackField{"Last Seen At", makeSlackDate(md.LastSeenAt), true})
+
+	case model.NotificationEventType_EVENT_PIPED_ONLINE:
+		md := event.Metadata.(*model.NotificationEventPipedOnline)
+		title = "A piped has reconnected to the control plane"
+		color = slackSuccessColor
+		generatePipedEventData(md.Id, md.Version)
+		fields = append(fields, slackField{"Offline Since", makeSlackDate(md.OfflineSince), true})
+
 	default:
 		return slackMessage{}, false
 	}
 
-	return makeSlackMessage(title, link, text, color, timestamp, fields...), true
+	msg := makeSlackMessage(title, link, text, color, timestamp, fields...)
+	if len(actions) > 0 {
+		msg.Attachments[0].CallbackID = slackWaitApprovalCallbackID
+		msg.Attachments[0].Actions = actions
+	}
+	return msg, true
+}
+
+func makeSlackNotes(notes []*model.DeploymentNote) string {
+	lines := make([]string, 0, len(notes))
+	for _, n := range notes {
+		if n.Url != "" {
+			lines = append(lines, makeSlackLink(n.Text, n.Url))
+			continue
+		}
+		lines = append(lines, n.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func makeSlackPullRequest(c *model.Commit) string {
+	text := fmt.Sprintf("#%d %s", c.PullRequest, c.PullRequestTitle)
+	if c.PullRequestAuthor != "" {
+		text += " by " + c.PullRequestAuthor
+	}
+	if c.PullRequestUrl != "" {
+		return makeSlackLink(text, c.PullRequestUrl)
+	}
+	return text
+}
+
+// makeSlackChangedFiles lists the first few changed files
+// to keep the message short.
+func makeSlackChangedFiles(files []string) string {
+	const max = 5
+	if len(files) <= max {
+		return strings.Join(files, "\n")
+	}
+	return fmt.Sprintf("%s\nand %d more files", strings.Join(files[:max], "\n"), len(files)-max)
 }
 
 type slackMessage struct {
@@ -221,6 +362,25 @@ type slackAttachment struct {
 	Color     string       `json:"color,omitempty"`
 	Markdown  []string     `json:"mrkdwn_in,omitempty"`
 	Timestamp int64        `json:"ts,omitempty"`
+	// The identifier sent back to SlackInteractionHandler when any action was clicked.
+	CallbackID string        `json:"callback_id,omitempty"`
+	Actions    []slackAction `json:"actions,omitempty"`
+}
+
+type slackAction struct {
+	Name    string              `json:"name"`
+	Text    string              `json:"text"`
+	Type    string              `json:"type"`
+	Value   string              `json:"value"`
+	Style   string              `json:"style,omitempty"`
+	Confirm *slackActionConfirm `json:"confirm,omitempty"`
+}
+
+type slackActionConfirm struct {
+	Title       string `json:"title"`
+	Text        string `json:"text"`
+	OkText      string `json:"ok_text"`
+	DismissText string `json:"dismiss_text"`
 }
 
 type slackField struct {
@@ -0,0 +1,79 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestMakeSlackPullRequest(t *testing.T) {
+	testcases := []struct {
+		name     string
+		commit   *model.Commit
+		expected string
+	}{
+		{
+			name: "with url",
+			commit: &model.Commit{
+				PullRequest:       12,
+				PullRequestTitle:  "Add a new feature",
+				PullRequestAuthor: "alice",
+				PullRequestUrl:    "https://github.com/org/repo/pull/12",
+			},
+			expected: "<https://github.com/org/repo/pull/12|#12 Add a new feature by alice>",
+		},
+		{
+			name: "without url and author",
+			commit: &model.Commit{
+				PullRequest:      12,
+				PullRequestTitle: "Add a new feature",
+			},
+			expected: "#12 Add a new feature",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, makeSlackPullRequest(tc.commit))
+		})
+	}
+}
+
+func TestMakeSlackChangedFiles(t *testing.T) {
+	testcases := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{
+			name:     "a few files",
+			files:    []string{"a.yaml", "b.yaml"},
+			expected: "a.yaml\nb.yaml",
+		},
+		{
+			name:     "too many files",
+			files:    []string{"a.yaml", "b.yaml", "c.yaml", "d.yaml", "e.yaml", "f.yaml", "g.yaml"},
+			expected: "a.yaml\nb.yaml\nc.yaml\nd.yaml\ne.yaml\nand 2 more files",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, makeSlackChangedFiles(tc.files))
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/approvalstore"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+const (
+	// The path where the interactions from Slack are received.
+	slackInteractionPath = "/slack/interactions"
+
+	slackWaitApprovalCallbackID = "wait_approval"
+	slackApproveActionName      = "approve"
+	slackRejectActionName       = "reject"
+
+	// The requests older than this are rejected to prevent replay attacks.
+	slackRequestMaxAge = 5 * time.Minute
+	// The maximum size of request body sent from Slack.
+	slackRequestMaxSize = 1024 * 1024
+)
+
+type approvalPutter interface {
+	Put(deploymentID, stageID string, d approvalstore.Decision)
+}
+
+// SlackInteractionHandler handles the requests sent from Slack
+// when the buttons of WAIT_APPROVAL notifications were clicked.
+// The approvals and rejections from the configured users are stored
+// to be taken by the executors of WAIT_APPROVAL stages.
+type SlackInteractionHandler struct {
+	signingSecret []byte
+	users         map[string]string
+	store         approvalPutter
+	port          int
+	nowFunc       func() time.Time
+	logger        *zap.Logger
+}
+
+func NewSlackInteractionHandler(cfg config.NotificationSlackInteraction, store approvalPutter, logger *zap.Logger) (*SlackInteractionHandler, error) {
+	secret, err := ioutil.ReadFile(cfg.SigningSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing secret file %s (%w)", cfg.SigningSecretFile, err)
+	}
+	return &SlackInteractionHandler{
+		signingSecret: []byte(strings.TrimSpace(string(secret))),
+		users:         cfg.Users,
+		store:         store,
+		port:          cfg.GetPort(),
+		nowFunc:       time.Now,
+		logger:        logger.Named("slack-interaction"),
+	}, nil
+}
+
+// Run starts an HTTP server receiving the interactions from Slack on its own port
+// and keeps it running until the given context is done.
+// The admin server is not used for this to avoid exposing it to the internet.
+func (h *SlackInteractionHandler) Run(ctx context.Context, gracePeriod time.Duration) error {
+	mux := http.NewServeMux()
+	mux.Handle(slackInteractionPath, h)
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", h.port),
+		Handler: mux,
+	}
+
+	doneCh := make(chan error, 1)
+	go func() {
+		h.logger.Info(fmt.Sprintf("slack interaction server is running on %d", h.port))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			h.logger.Error("failed to listen and serve slack interaction server", zap.Error(err))
+			doneCh <- err
+			return
+		}
+		doneCh <- nil
+	}()
+
+	select {
+	case err := <-doneCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	h.logger.Info("stopping slack interaction server")
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		h.logger.Error("failed to shutdown slack interaction server", zap.Error(err))
+		return err
+	}
+	return <-doneCh
+}
+
+type slackInteractionPayload struct {
+	CallbackID string `json:"callback_id"`
+	User       struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+	Actions []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"actions"`
+}
+
+type slackWaitApprovalTarget struct {
+	DeploymentID string `json:"deploymentId"`
+	StageID      string `json:"stageId"`
+}
+
+type slackInteractionResponse struct {
+	ResponseType    string `json:"response_type"`
+	ReplaceOriginal bool   `json:"replace_original"`
+	Text            string `json:"text"`
+}
+
+func (h *SlackInteractionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, slackRequestMaxSize))
+	if err != nil {
+		http.Error(w, "unable to read request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.verify(r.Header, body); err != nil {
+		h.logger.Warn("received an unverified request", zap.Error(err))
+		http.Error(w, "unverified request", http.StatusUnauthorized)
+		return
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "malformed request body", http.StatusBadRequest)
+		return
+	}
+	var payload slackInteractionPayload
+	if err := json.Unmarshal([]byte(values.Get("payload")), &payload); err != nil {
+		http.Error(w, "malformed payload", http.StatusBadRequest)
+		return
+	}
+	if payload.CallbackID != slackWaitApprovalCallbackID || len(payload.Actions) == 0 {
+		http.Error(w, "unsupported interaction", http.StatusBadRequest)
+		return
+	}
+
+	action := payload.Actions[0]
+	var target slackWaitApprovalTarget
+	if err := json.Unmarshal([]byte(action.Value), &target); err != nil || target.DeploymentID == "" || target.StageID == "" {
+		http.Error(w, "malformed action value", http.StatusBadRequest)
+		return
+	}
+
+	commander, ok := h.users[payload.User.ID]
+	if !ok {
+		h.logger.Info("ignored an interaction from an unknown slack user",
+			zap.String("slack-user-id", payload.User.ID),
+			zap.String("slack-user-name", payload.User.Name),
+		)
+		h.respond(w, "ephemeral", "You are not allowed to approve or reject the stages from Slack.")
+		return
+	}
+
+	var decision string
+	switch action.Name {
+	case slackApproveActionName:
+		decision = "approved"
+	case slackRejectActionName:
+		decision = "rejected"
+	default:
+		http.Error(w, "unsupported action", http.StatusBadRequest)
+		return
+	}
+
+	h.store.Put(target.DeploymentID, target.StageID, approvalstore.Decision{
+		Approved:  action.Name == slackApproveActionName,
+		Commander: commander,
+		DecidedAt: h.nowFunc(),
+	})
+	h.logger.Info(fmt.Sprintf("stage %s of deployment %s was %s by %s from slack", target.StageID, target.DeploymentID, decision, commander))
+	h.respond(w, "in_channel", fmt.Sprintf("The stage was %s by %s.", decision, commander))
+}
+
+// verify checks the signature of the request as described at
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func (h *SlackInteractionHandler) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if age := h.nowFunc().Sub(time.Unix(sec, 0)); age > slackRequestMaxAge || age < -slackRequestMaxAge {
+		return errors.New("request is too old")
+	}
+
+	mac := hmac.New(sha256.New, h.signingSecret)
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func (h *SlackInteractionHandler) respond(w http.ResponseWriter, responseType, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(slackInteractionResponse{
+		ResponseType:    responseType,
+		ReplaceOriginal: false,
+		Text:            text,
+	})
+}
+
+func makeSlackWaitApprovalActions(deploymentID, stageID string) []slackAction {
+	value, _ := json.Marshal(slackWaitApprovalTarget{
+		DeploymentID: deploymentID,
+		StageID:      stageID,
+	})
+	return []slackAction{
+		{
+			Name:  slackApproveActionName,
+			Text:  "Approve",
+			Type:  "button",
+			Value: string(value),
+			Style: "primary",
+		},
+		{
+			Name:  slackRejectActionName,
+			Text:  "Reject",
+			Type:  "button",
+			Value: string(value),
+			Style: "danger",
+			Confirm: &slackActionConfirm{
+				Title:       "Reject this deployment?",
+				Text:        "The deployment will be failed and rolled back if autoRollback is enabled.",
+				OkText:      "Reject",
+				DismissText: "Cancel",
+			},
+		},
+	}
+}
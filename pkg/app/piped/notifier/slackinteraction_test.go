@@ -0,0 +1,157 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/approvalstore"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeApprovalPutter struct {
+	decisions map[string]approvalstore.Decision
+}
+
+func (p *fakeApprovalPutter) Put(deploymentID, stageID string, d approvalstore.Decision) {
+	p.decisions[deploymentID+"/"+stageID] = d
+}
+
+func TestSlackInteractionHandler(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	secret := "test-secret"
+
+	makeRequest := func(payload string, ts time.Time, secret string) *http.Request {
+		body := url.Values{"payload": []string{payload}}.Encode()
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+
+		req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return req
+	}
+	makePayload := func(userID, action string) string {
+		return fmt.Sprintf(`{"callback_id":"wait_approval","user":{"id":%q,"name":"someone"},"actions":[{"name":%q,"value":"{\"deploymentId\":\"deployment-1\",\"stageId\":\"stage-1\"}"}]}`, userID, action)
+	}
+
+	testcases := []struct {
+		name         string
+		req          *http.Request
+		expectedCode int
+		expected     map[string]approvalstore.Decision
+	}{
+		{
+			name:         "approved by a configured user",
+			req:          makeRequest(makePayload("U1", "approve"), now, secret),
+			expectedCode: http.StatusOK,
+			expected: map[string]approvalstore.Decision{
+				"deployment-1/stage-1": {Approved: true, Commander: "alice", DecidedAt: now},
+			},
+		},
+		{
+			name:         "rejected by a configured user",
+			req:          makeRequest(makePayload("U1", "reject"), now, secret),
+			expectedCode: http.StatusOK,
+			expected: map[string]approvalstore.Decision{
+				"deployment-1/stage-1": {Approved: false, Commander: "alice", DecidedAt: now},
+			},
+		},
+		{
+			name:         "unknown user",
+			req:          makeRequest(makePayload("U2", "approve"), now, secret),
+			expectedCode: http.StatusOK,
+			expected:     map[string]approvalstore.Decision{},
+		},
+		{
+			name:         "wrong signature",
+			req:          makeRequest(makePayload("U1", "approve"), now, "wrong-secret"),
+			expectedCode: http.StatusUnauthorized,
+			expected:     map[string]approvalstore.Decision{},
+		},
+		{
+			name:         "too old request",
+			req:          makeRequest(makePayload("U1", "approve"), now.Add(-10*time.Minute), secret),
+			expectedCode: http.StatusUnauthorized,
+			expected:     map[string]approvalstore.Decision{},
+		},
+		{
+			name:         "unsupported action",
+			req:          makeRequest(makePayload("U1", "skip"), now, secret),
+			expectedCode: http.StatusBadRequest,
+			expected:     map[string]approvalstore.Decision{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeApprovalPutter{decisions: map[string]approvalstore.Decision{}}
+			h := &SlackInteractionHandler{
+				signingSecret: []byte(secret),
+				users:         map[string]string{"U1": "alice"},
+				store:         store,
+				nowFunc:       func() time.Time { return now },
+				logger:        zap.NewNop(),
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tc.req)
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expected, store.decisions)
+		})
+	}
+}
+
+func TestBuildSlackMessageWithWaitApprovalActions(t *testing.T) {
+	event := model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL,
+		Metadata: &model.NotificationEventDeploymentWaitApproval{
+			Deployment: &model.Deployment{
+				Id: "deployment-1",
+				Trigger: &model.DeploymentTrigger{
+					Commit: &model.Commit{Author: "alice"},
+				},
+			},
+			EnvName: "prod",
+			StageId: "stage-1",
+		},
+	}
+
+	s := newSlackSender("slack", config.NotificationReceiverSlack{}, "https://pipecd.dev", false, zap.NewNop())
+	msg, ok := s.buildSlackMessage(event, s.webURL)
+	assert.True(t, ok)
+	assert.Empty(t, msg.Attachments[0].Actions)
+
+	s = newSlackSender("slack", config.NotificationReceiverSlack{}, "https://pipecd.dev", true, zap.NewNop())
+	msg, ok = s.buildSlackMessage(event, s.webURL)
+	assert.True(t, ok)
+	assert.Equal(t, slackWaitApprovalCallbackID, msg.Attachments[0].CallbackID)
+	assert.Equal(t, 2, len(msg.Attachments[0].Actions))
+	assert.Equal(t, `{"deploymentId":"deployment-1","stageId":"stage-1"}`, msg.Attachments[0].Actions[0].Value)
+}
@@ -74,8 +74,13 @@ func (p *Planner) Plan(ctx context.Context, in planner.Input) (out planner.Outpu
 	// If this is the first time to deploy this application or it was unable to retrieve last successful commit,
 	// we perform the quick sync strategy.
 	if in.MostRecentSuccessfulCommitHash == "" {
-		out.Stages = buildQuickSyncPipeline(cfg.Input.AutoRollback, time.Now())
-		out.Summary = fmt.Sprintf("Quick sync to deploy image %s and configure all traffic to it (it seems this is the first deployment)", out.Version)
+		now := time.Now()
+		fallback := planner.Fallback{
+			QuickSyncStages:  buildQuickSyncPipeline(cfg.Input.AutoRollback, now),
+			QuickSyncSummary: fmt.Sprintf("Quick sync to deploy image %s and configure all traffic to it (it seems this is the first deployment)", out.Version),
+			Reason:           "it seems this is the first deployment",
+		}
+		out.Stages, out.Summary, err = fallback.Decide(cfg.Planner.Fallback, now)
 		return
 	}
 
@@ -0,0 +1,123 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+// Fallback represents the candidates of pipeline used when the planner
+// was unable to decide the strategy by comparing with the most recently deployed commit.
+type Fallback struct {
+	// The quick sync stages and its summary used by default.
+	QuickSyncStages  []*model.PipelineStage
+	QuickSyncSummary string
+	// Function to build the progressive pipeline.
+	// Nil means no pipeline was specified.
+	BuildPipeline func() []*model.PipelineStage
+	// The reason why the fallback is needed.
+	Reason string
+}
+
+// Decide returns the stages and summary for the given fallback policy.
+func (f Fallback) Decide(policy config.DeploymentPlannerFallback, now time.Time) ([]*model.PipelineStage, string, error) {
+	switch policy {
+	case config.DeploymentPlannerFallbackPipeline:
+		if f.BuildPipeline == nil {
+			break
+		}
+		return f.BuildPipeline(), fmt.Sprintf("Sync with the specified pipeline because %s", f.Reason), nil
+
+	case config.DeploymentPlannerFallbackApproval:
+		stages := PrependWaitApprovalStage(f.QuickSyncStages, now)
+		return stages, fmt.Sprintf("%s after getting an approval", f.QuickSyncSummary), nil
+
+	case config.DeploymentPlannerFallbackFail:
+		return nil, "", fmt.Errorf("unable to plan the deployment because %s", f.Reason)
+	}
+	return f.QuickSyncStages, f.QuickSyncSummary, nil
+}
+
+// PrependWaitApprovalStage adds a WAIT_APPROVAL stage
+// that must be completed before running the given stages.
+// Only the index of predefined stages is shifted, the index of non-predefined stages
+// is kept as is since it is used to find their configuration in the deployment configuration.
+func PrependWaitApprovalStage(stages []*model.PipelineStage, now time.Time) []*model.PipelineStage {
+	s, _ := GetPredefinedStage(PredefinedStageWaitApproval)
+	approval := &model.PipelineStage{
+		Id:         s.Id,
+		Name:       s.Name.String(),
+		Desc:       s.Desc,
+		Index:      0,
+		Predefined: true,
+		Visible:    true,
+		Status:     model.StageStatus_STAGE_NOT_STARTED_YET,
+		Metadata:   MakeInitialStageMetadata(s),
+		CreatedAt:  now.Unix(),
+		UpdatedAt:  now.Unix(),
+	}
+
+	out := make([]*model.PipelineStage, 0, len(stages)+1)
+	out = append(out, approval)
+	for _, stage := range stages {
+		// The hidden stages such as ROLLBACK are not a part of the main flow.
+		if !stage.Visible {
+			out = append(out, stage)
+			continue
+		}
+		if stage.Predefined {
+			stage.Index++
+		}
+		if len(stage.Requires) == 0 {
+			stage.Requires = []string{approval.Id}
+		}
+		out = append(out, stage)
+	}
+	return out
+}
+
+// nonMutatingStages is the list of stages those do not change the deployed resources.
+// The other stages, including the unknown ones, are considered as changing them.
+var nonMutatingStages = map[string]struct{}{
+	model.StageWait.String():          {},
+	model.StageWaitApproval.String():  {},
+	model.StageAnalysis.String():      {},
+	model.StageChangeRequest.String(): {},
+	model.StageTerraformPlan.String(): {},
+}
+
+// EnsureWaitApprovalStage makes sure that the given stages require an approval
+// before the first stage changing the deployed resources,
+// by prepending the predefined WAIT_APPROVAL stage when missing.
+// The returned bool reports whether the stages were changed.
+func EnsureWaitApprovalStage(stages []*model.PipelineStage, now time.Time) ([]*model.PipelineStage, bool) {
+	for _, stage := range stages {
+		// The hidden stages such as ROLLBACK are not a part of the main flow.
+		if !stage.Visible {
+			continue
+		}
+		if stage.Name == model.StageWaitApproval.String() {
+			return stages, false
+		}
+		if _, ok := nonMutatingStages[stage.Name]; !ok {
+			break
+		}
+	}
+	return PrependWaitApprovalStage(stages, now), true
+}
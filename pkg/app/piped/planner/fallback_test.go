@@ -0,0 +1,207 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestFallbackDecide(t *testing.T) {
+	quickSync := func() []*model.PipelineStage {
+		return []*model.PipelineStage{
+			{Id: "sync", Name: model.StageK8sSync.String(), Index: 0, Visible: true},
+			{Id: "rollback", Name: model.StageRollback.String(), Visible: false},
+		}
+	}
+	pipeline := func() []*model.PipelineStage {
+		return []*model.PipelineStage{
+			{Id: "canary", Name: model.StageK8sCanaryRollout.String(), Index: 0, Visible: true},
+		}
+	}
+
+	testcases := []struct {
+		name         string
+		policy       config.DeploymentPlannerFallback
+		noPipeline   bool
+		wantStageIDs []string
+		wantSummary  string
+		wantErr      bool
+	}{
+		{
+			name:         "not specified",
+			wantStageIDs: []string{"sync", "rollback"},
+			wantSummary:  "Quick sync because of the first deployment",
+		},
+		{
+			name:         "quick sync",
+			policy:       config.DeploymentPlannerFallbackQuickSync,
+			wantStageIDs: []string{"sync", "rollback"},
+			wantSummary:  "Quick sync because of the first deployment",
+		},
+		{
+			name:         "pipeline",
+			policy:       config.DeploymentPlannerFallbackPipeline,
+			wantStageIDs: []string{"canary"},
+			wantSummary:  "Sync with the specified pipeline because of the first deployment",
+		},
+		{
+			name:         "pipeline but no pipeline was specified",
+			policy:       config.DeploymentPlannerFallbackPipeline,
+			noPipeline:   true,
+			wantStageIDs: []string{"sync", "rollback"},
+			wantSummary:  "Quick sync because of the first deployment",
+		},
+		{
+			name:         "approval",
+			policy:       config.DeploymentPlannerFallbackApproval,
+			wantStageIDs: []string{PredefinedStageWaitApproval, "sync", "rollback"},
+			wantSummary:  "Quick sync because of the first deployment after getting an approval",
+		},
+		{
+			name:    "fail",
+			policy:  config.DeploymentPlannerFallbackFail,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := Fallback{
+				QuickSyncStages:  quickSync(),
+				QuickSyncSummary: "Quick sync because of the first deployment",
+				BuildPipeline:    pipeline,
+				Reason:           "of the first deployment",
+			}
+			if tc.noPipeline {
+				f.BuildPipeline = nil
+			}
+			stages, summary, err := f.Decide(tc.policy, time.Now())
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantSummary, summary)
+
+			ids := make([]string, 0, len(stages))
+			for _, s := range stages {
+				ids = append(ids, s.Id)
+			}
+			if len(tc.wantStageIDs) == 0 {
+				assert.Empty(t, ids)
+				return
+			}
+			assert.Equal(t, tc.wantStageIDs, ids)
+		})
+	}
+}
+
+func TestPrependWaitApprovalStage(t *testing.T) {
+	stages := PrependWaitApprovalStage([]*model.PipelineStage{
+		{Id: "sync", Index: 0, Predefined: true, Visible: true},
+		{Id: "rollback", Predefined: true, Visible: false},
+	}, time.Now())
+	require.Equal(t, 3, len(stages))
+
+	assert.Equal(t, model.StageWaitApproval.String(), stages[0].Name)
+	assert.Equal(t, int32(0), stages[0].Index)
+	assert.Equal(t, int32(1), stages[1].Index)
+	assert.Equal(t, []string{PredefinedStageWaitApproval}, stages[1].Requires)
+	assert.Empty(t, stages[2].Requires)
+
+	// The index of non-predefined stages points to their configuration so it must be kept.
+	stages = PrependWaitApprovalStage([]*model.PipelineStage{
+		{Id: "canary", Index: 0, Visible: true},
+		{Id: "primary", Index: 1, Visible: true, Requires: []string{"canary"}},
+	}, time.Now())
+	require.Equal(t, 3, len(stages))
+
+	assert.Equal(t, int32(0), stages[1].Index)
+	assert.Equal(t, []string{PredefinedStageWaitApproval}, stages[1].Requires)
+	assert.Equal(t, int32(1), stages[2].Index)
+	assert.Equal(t, []string{"canary"}, stages[2].Requires)
+}
+
+func TestEnsureWaitApprovalStage(t *testing.T) {
+	testcases := []struct {
+		name         string
+		stages       []*model.PipelineStage
+		wantStageIDs []string
+		wantIndexes  []int32
+		wantChanged  bool
+	}{
+		{
+			name: "quick sync",
+			stages: []*model.PipelineStage{
+				{Id: "sync", Name: model.StageK8sSync.String(), Index: 0, Predefined: true, Visible: true},
+				{Id: "rollback", Name: model.StageRollback.String(), Predefined: true, Visible: false},
+			},
+			wantStageIDs: []string{PredefinedStageWaitApproval, "sync", "rollback"},
+			wantIndexes:  []int32{0, 1, 0},
+			wantChanged:  true,
+		},
+		{
+			name: "progressive pipeline",
+			stages: []*model.PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout.String(), Index: 0, Visible: true},
+				{Id: "primary", Name: model.StageK8sPrimaryRollout.String(), Index: 1, Visible: true, Requires: []string{"canary"}},
+			},
+			wantStageIDs: []string{PredefinedStageWaitApproval, "canary", "primary"},
+			wantIndexes:  []int32{0, 0, 1},
+			wantChanged:  true,
+		},
+		{
+			name: "already has an approval stage",
+			stages: []*model.PipelineStage{
+				{Id: "analysis", Name: model.StageAnalysis.String(), Index: 0, Visible: true},
+				{Id: "approval", Name: model.StageWaitApproval.String(), Index: 1, Visible: true, Requires: []string{"analysis"}},
+				{Id: "primary", Name: model.StageK8sPrimaryRollout.String(), Index: 2, Visible: true, Requires: []string{"approval"}},
+			},
+			wantStageIDs: []string{"analysis", "approval", "primary"},
+			wantIndexes:  []int32{0, 1, 2},
+			wantChanged:  false,
+		},
+		{
+			name: "approval stage after changing resources",
+			stages: []*model.PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout.String(), Index: 0, Visible: true},
+				{Id: "approval", Name: model.StageWaitApproval.String(), Index: 1, Visible: true, Requires: []string{"canary"}},
+			},
+			wantStageIDs: []string{PredefinedStageWaitApproval, "canary", "approval"},
+			wantIndexes:  []int32{0, 0, 1},
+			wantChanged:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			stages, changed := EnsureWaitApprovalStage(tc.stages, time.Now())
+			assert.Equal(t, tc.wantChanged, changed)
+
+			ids := make([]string, 0, len(stages))
+			indexes := make([]int32, 0, len(stages))
+			for _, s := range stages {
+				ids = append(ids, s.Id)
+				indexes = append(indexes, s.Index)
+			}
+			assert.Equal(t, tc.wantStageIDs, ids)
+			assert.Equal(t, tc.wantIndexes, indexes)
+			if changed {
+				assert.Equal(t, []string{PredefinedStageWaitApproval}, stages[1].Requires)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+// checkGuard returns the reason when the changes between the given manifests
+// are exceeding any of the thresholds configured in the given guard.
+func checkGuard(guard config.KubernetesDeploymentGuard, olds, news []provider.Manifest, workloadRefs []config.K8sResourceReference) (reason string, caught bool) {
+	if max := guard.MaxDeletedResources; max > 0 {
+		newKeys := make(map[provider.ResourceKey]struct{}, len(news))
+		for _, m := range news {
+			newKeys[normalizeKey(m.Key)] = struct{}{}
+		}
+		deleted := 0
+		for _, m := range olds {
+			if _, ok := newKeys[normalizeKey(m.Key)]; !ok {
+				deleted++
+			}
+		}
+		if deleted > max {
+			return fmt.Sprintf("%d resources will be deleted while the maximum is %d", deleted, max), true
+		}
+	}
+
+	if max := guard.MaxReplicasChangePercentage; max > 0 {
+		workloads := findUpdatedWorkloads(findWorkloadManifests(olds, workloadRefs), findWorkloadManifests(news, workloadRefs))
+		for _, w := range workloads {
+			before, after := replicasOf(w.old), replicasOf(w.new)
+			if before < 0 || after < 0 || before == after {
+				continue
+			}
+			percentage := 100
+			if before > 0 {
+				delta := after - before
+				if delta < 0 {
+					delta = -delta
+				}
+				percentage = delta * 100 / before
+			}
+			if percentage > max {
+				return fmt.Sprintf("replicas of %s/%s will be changed from %d to %d (%d%%) while the maximum is %d%%", w.new.Key.Kind, w.new.Key.Name, before, after, percentage, max), true
+			}
+		}
+	}
+	return "", false
+}
+
+// replicasOf returns the number of replicas specified in the given workload.
+// Kubernetes uses 1 when it was not specified.
+// A negative value is returned when it was unable to parse.
+func replicasOf(m provider.Manifest) int {
+	spec, err := m.GetNestedMap("spec")
+	if err != nil {
+		return 1
+	}
+	v, ok := spec["replicas"]
+	if !ok || v == nil {
+		return 1
+	}
+	n, err := strconv.Atoi(fmt.Sprint(v))
+	if err != nil {
+		return -1
+	}
+	return n
+}
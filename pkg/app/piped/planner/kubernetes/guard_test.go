@@ -0,0 +1,105 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestCheckGuard(t *testing.T) {
+	olds, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 4
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: secret
+`)
+	require.NoError(t, err)
+	news, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 10
+`)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name       string
+		guard      config.KubernetesDeploymentGuard
+		wantCaught bool
+		wantReason string
+	}{
+		{
+			name:       "not configured",
+			wantCaught: false,
+		},
+		{
+			name: "deleted resources within limit",
+			guard: config.KubernetesDeploymentGuard{
+				MaxDeletedResources: 2,
+			},
+			wantCaught: false,
+		},
+		{
+			name: "deleted resources exceeded",
+			guard: config.KubernetesDeploymentGuard{
+				MaxDeletedResources: 1,
+			},
+			wantCaught: true,
+			wantReason: "2 resources will be deleted while the maximum is 1",
+		},
+		{
+			name: "replicas change within limit",
+			guard: config.KubernetesDeploymentGuard{
+				MaxReplicasChangePercentage: 150,
+			},
+			wantCaught: false,
+		},
+		{
+			name: "replicas change exceeded",
+			guard: config.KubernetesDeploymentGuard{
+				MaxReplicasChangePercentage: 100,
+			},
+			wantCaught: true,
+			wantReason: "replicas of Deployment/app will be changed from 4 to 10 (150%) while the maximum is 100%",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, caught := checkGuard(tc.guard, olds, news, nil)
+			assert.Equal(t, tc.wantCaught, caught)
+			assert.Equal(t, tc.wantReason, reason)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/imagedigest"
+)
+
+type digestResolver interface {
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+var newDigestResolver = func(logger *zap.Logger) (digestResolver, error) {
+	return imagedigest.NewResolver(logger)
+}
+
+// resolveImageDigests resolves the images of all workloads in the given manifests
+// to their digests and returns them as the deployment metadata.
+func resolveImageDigests(ctx context.Context, r digestResolver, manifests []provider.Manifest) (map[string]string, error) {
+	digests := make(map[string]string)
+	for _, m := range manifests {
+		for _, c := range m.GetContainerImages() {
+			if _, ok := digests[c.Image]; ok {
+				continue
+			}
+			pinned, err := r.Resolve(ctx, c.Image)
+			if err != nil {
+				return nil, err
+			}
+			digests[c.Image] = pinned
+		}
+	}
+	data, err := json.Marshal(digests)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		provider.ImageDigestsMetadataKey: string(data),
+	}, nil
+}
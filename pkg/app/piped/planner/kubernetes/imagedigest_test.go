@@ -0,0 +1,106 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+)
+
+type fakeDigestResolver struct {
+	digests map[string]string
+	calls   int
+}
+
+func (r *fakeDigestResolver) Resolve(_ context.Context, image string) (string, error) {
+	r.calls++
+	digest, ok := r.digests[image]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return image + "@" + digest, nil
+}
+
+func TestResolveImageDigests(t *testing.T) {
+	manifests, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: gcr.io/pipecd/web:v1
+      - name: sidecar
+        image: gcr.io/pipecd/sidecar:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    spec:
+      containers:
+      - name: api
+        image: gcr.io/pipecd/web:v1
+`)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		digests       map[string]string
+		expected      map[string]string
+		expectedCalls int
+		expectedErr   bool
+	}{
+		{
+			name: "all images are resolved",
+			digests: map[string]string{
+				"gcr.io/pipecd/web:v1":     "sha256:aaa",
+				"gcr.io/pipecd/sidecar:v1": "sha256:bbb",
+			},
+			expected: map[string]string{
+				provider.ImageDigestsMetadataKey: `{"gcr.io/pipecd/sidecar:v1":"gcr.io/pipecd/sidecar:v1@sha256:bbb","gcr.io/pipecd/web:v1":"gcr.io/pipecd/web:v1@sha256:aaa"}`,
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "unable to resolve an image",
+			digests: map[string]string{
+				"gcr.io/pipecd/web:v1": "sha256:aaa",
+			},
+			expectedCalls: 2,
+			expectedErr:   true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &fakeDigestResolver{digests: tc.digests}
+			metadata, err := resolveImageDigests(context.Background(), r, manifests)
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expected, metadata)
+			assert.Equal(t, tc.expectedCalls, r.calls)
+		})
+	}
+}
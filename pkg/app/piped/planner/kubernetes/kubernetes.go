@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strings"
 	"time"
 
@@ -70,15 +71,13 @@ func (p *Planner) Plan(ctx context.Context, in planner.Input) (out planner.Outpu
 	}
 
 	// Load previous deployed manifests and new manifests to compare.
-	newManifests, ok := manifestCache.Get(in.Deployment.Trigger.Commit.Hash)
-	if !ok {
+	newManifests, err := manifestCache.GetOrLoad(in.Deployment.Trigger.Commit.Hash, func() ([]provider.Manifest, error) {
 		// When the manifests were not in the cache we have to load them.
-		loader := provider.NewManifestLoader(in.Deployment.ApplicationName, ds.AppDir, ds.RepoDir, in.Deployment.GitPath.ConfigFilename, cfg.Input, in.Logger)
-		newManifests, err = loader.LoadManifests(ctx)
-		if err != nil {
-			return
-		}
-		manifestCache.Put(in.Deployment.Trigger.Commit.Hash, newManifests)
+		loader := provider.NewManifestLoader(in.Deployment.ApplicationName, ds.AppDir, ds.RepoDir, in.Deployment.GitPath.ConfigFilename, cfg.Input, in.Logger, provider.WithHelmPostRenderer(in.PipedConfig.AllowHelmPostRenderer), provider.WithGitRepositories(in.PipedConfig.Repositories))
+		return loader.LoadManifests(ctx)
+	})
+	if err != nil {
+		return
 	}
 
 	// Determine application version from the manifests.
@@ -89,6 +88,19 @@ func (p *Planner) Plan(ctx context.Context, in planner.Input) (out planner.Outpu
 		out.Version = version
 	}
 
+	// Resolve the image tags at this time so that the same images are deployed
+	// through all stages even if the tags were re-pushed while deploying.
+	if cfg.ImageDigests.Resolve {
+		var r digestResolver
+		if r, err = newDigestResolver(in.Logger); err != nil {
+			return
+		}
+		if out.Metadata, err = resolveImageDigests(ctx, r, newManifests); err != nil {
+			err = fmt.Errorf("failed to resolve image digests (%w)", err)
+			return
+		}
+	}
+
 	// If the deployment was triggered by forcing via web UI,
 	// we rely on the user's decision.
 	switch in.Deployment.Trigger.SyncStrategy {
@@ -148,8 +160,8 @@ func (p *Planner) Plan(ctx context.Context, in planner.Input) (out planner.Outpu
 	// or it was unable to retrieve that value.
 	// We just apply all manifests.
 	if in.MostRecentSuccessfulCommitHash == "" {
-		out.Stages = buildQuickSyncPipeline(cfg.Input.AutoRollback, time.Now())
-		out.Summary = "Quick sync by applying all manifests because it seems this is the first deployment"
+		fallback := newFallback(cfg, "it seems this is the first deployment")
+		out.Stages, out.Summary, err = fallback.Decide(cfg.Planner.Fallback, time.Now())
 		return
 	}
 
@@ -161,30 +173,132 @@ func (p *Planner) Plan(ctx context.Context, in planner.Input) (out planner.Outpu
 		runningDs, err = in.RunningDSP.Get(ctx, ioutil.Discard)
 		if err != nil {
 			err = fmt.Errorf("failed to prepare the running deploy source data (%v)", err)
+			if cfg.Planner.Fallback != "" {
+				out.Stages, out.Summary, err = newFallback(cfg, err.Error()).Decide(cfg.Planner.Fallback, time.Now())
+			}
 			return
 		}
 
-		loader := provider.NewManifestLoader(in.Deployment.ApplicationName, runningDs.AppDir, runningDs.RepoDir, in.Deployment.GitPath.ConfigFilename, cfg.Input, in.Logger)
+		loader := provider.NewManifestLoader(in.Deployment.ApplicationName, runningDs.AppDir, runningDs.RepoDir, in.Deployment.GitPath.ConfigFilename, cfg.Input, in.Logger, provider.WithHelmPostRenderer(in.PipedConfig.AllowHelmPostRenderer), provider.WithGitRepositories(in.PipedConfig.Repositories))
 		oldManifests, err = loader.LoadManifests(ctx)
 		if err != nil {
 			err = fmt.Errorf("failed to load previously deployed manifests: %w", err)
+			if cfg.Planner.Fallback != "" {
+				out.Stages, out.Summary, err = newFallback(cfg, err.Error()).Decide(cfg.Planner.Fallback, time.Now())
+			}
 			return
 		}
 		manifestCache.Put(in.MostRecentSuccessfulCommitHash, oldManifests)
 	}
 
+	ignorer, err := newDiffIgnorer(in, cfg)
+	if err != nil {
+		return
+	}
+	oldManifests, newManifests = ignorer.ApplyAll(oldManifests), ignorer.ApplyAll(newManifests)
+
 	progressive, desc := decideStrategy(oldManifests, newManifests, cfg.Workloads)
 	out.Summary = desc
 
+	// Even when the changes can be applied by quick sync,
+	// the configured rules may require the pipeline for large changes.
+	if !progressive {
+		if desc, matched := matchPlannerRules(cfg.Planner.Rules, oldManifests, newManifests, cfg.Workloads, in.Deployment.Trigger.ChangedFiles); matched {
+			progressive = true
+			out.Summary = desc
+		}
+	}
+
+	now := time.Now()
 	if progressive {
-		out.Stages = buildProgressivePipeline(cfg.Pipeline, cfg.Input.AutoRollback, time.Now())
-		return
+		out.Stages = buildProgressivePipeline(cfg.Pipeline, cfg.Input.AutoRollback, now)
+	} else {
+		out.Stages = buildQuickSyncPipeline(cfg.Input.AutoRollback, now)
 	}
 
-	out.Stages = buildQuickSyncPipeline(cfg.Input.AutoRollback, time.Now())
+	// Report as a dry-run which resources will be removed by the prune option.
+	if desc := describePrune(cfg, progressive, oldManifests, newManifests); desc != "" {
+		out.Summary = fmt.Sprintf("%s (%s)", out.Summary, desc)
+	}
+
+	// Protect from the accidental changes such as deleting many resources at once.
+	if cfg.Guard.Enabled() {
+		if reason, caught := checkGuard(cfg.Guard, oldManifests, newManifests, cfg.Workloads); caught {
+			if cfg.Guard.Action == config.KubernetesDeploymentGuardActionFail {
+				out.Stages = nil
+				err = fmt.Errorf("deployment was stopped by the guard because %s", reason)
+				return
+			}
+			out.Stages = planner.PrependWaitApprovalStage(out.Stages, now)
+			out.Summary = fmt.Sprintf("%s (approval is required because %s)", out.Summary, reason)
+		}
+	}
 	return
 }
 
+// describePrune returns the description of the resources those are no longer defined in Git
+// and will be removed because the prune option was enabled for the planned stages.
+// An empty string is returned when nothing will be removed.
+func describePrune(cfg *config.KubernetesDeploymentSpec, progressive bool, olds, news []provider.Manifest) string {
+	prune := cfg.QuickSync.Prune
+	if progressive {
+		prune = false
+		for _, s := range cfg.Pipeline.Stages {
+			if s.Name == model.StageK8sPrimaryRollout && s.K8sPrimaryRolloutStageOptions != nil && s.K8sPrimaryRolloutStageOptions.Prune {
+				prune = true
+				break
+			}
+		}
+	}
+	if !prune {
+		return ""
+	}
+
+	keys := make(map[provider.ResourceKey]struct{}, len(news))
+	for _, m := range news {
+		keys[m.Key] = struct{}{}
+	}
+	removes := make([]string, 0)
+	for _, m := range olds {
+		if _, ok := keys[m.Key]; ok {
+			continue
+		}
+		removes = append(removes, fmt.Sprintf("%s %s", m.Key.Kind, m.Key.Name))
+	}
+	if len(removes) == 0 {
+		return ""
+	}
+	sort.Strings(removes)
+	return fmt.Sprintf("%d resources will be pruned: %s", len(removes), strings.Join(removes, ", "))
+}
+
+// newFallback returns the candidates of pipeline used when it was unable
+// to compare with the most recently deployed manifests for the given reason.
+func newFallback(cfg *config.KubernetesDeploymentSpec, reason string) planner.Fallback {
+	now := time.Now()
+	return planner.Fallback{
+		QuickSyncStages:  buildQuickSyncPipeline(cfg.Input.AutoRollback, now),
+		QuickSyncSummary: fmt.Sprintf("Quick sync by applying all manifests because %s", reason),
+		BuildPipeline: func() []*model.PipelineStage {
+			return buildProgressivePipeline(cfg.Pipeline, cfg.Input.AutoRollback, now)
+		},
+		Reason: reason,
+	}
+}
+
+// newDiffIgnorer creates a DiffIgnorer for the rules configured for
+// both the application and the cloud provider of the deployment.
+func newDiffIgnorer(in planner.Input, cfg *config.KubernetesDeploymentSpec) (*provider.DiffIgnorer, error) {
+	rules := append([]config.KubernetesIgnoreDiffRule{}, cfg.Input.IgnoreDiffs...)
+	if in.PipedConfig != nil {
+		cp, ok := in.PipedConfig.FindCloudProvider(in.Deployment.CloudProvider, model.CloudProviderKubernetes)
+		if ok && cp.KubernetesConfig != nil {
+			rules = append(rules, cp.KubernetesConfig.IgnoreDiffs...)
+		}
+	}
+	return provider.NewDiffIgnorer(rules...)
+}
+
 // First up, checks to see if the workload's `spec.template` has been changed,
 // and then checks if the configmap/secret's data.
 func decideStrategy(olds, news []provider.Manifest, workloadRefs []config.K8sResourceReference) (progressive bool, desc string) {
@@ -318,20 +432,12 @@ type workloadPair struct {
 func findUpdatedWorkloads(olds, news []provider.Manifest) []workloadPair {
 	pairs := make([]workloadPair, 0)
 	oldMap := make(map[provider.ResourceKey]provider.Manifest, len(olds))
-	nomalizeKey := func(k provider.ResourceKey) provider.ResourceKey {
-		// Ignoring APIVersion because user can upgrade to the new APIVersion for the same workload.
-		k.APIVersion = ""
-		if k.Namespace == provider.DefaultNamespace {
-			k.Namespace = ""
-		}
-		return k
-	}
 	for _, m := range olds {
-		key := nomalizeKey(m.Key)
+		key := normalizeKey(m.Key)
 		oldMap[key] = m
 	}
 	for _, n := range news {
-		key := nomalizeKey(n.Key)
+		key := normalizeKey(n.Key)
 		if o, ok := oldMap[key]; ok {
 			pairs = append(pairs, workloadPair{
 				old: o,
@@ -342,6 +448,15 @@ func findUpdatedWorkloads(olds, news []provider.Manifest) []workloadPair {
 	return pairs
 }
 
+func normalizeKey(k provider.ResourceKey) provider.ResourceKey {
+	// Ignoring APIVersion because user can upgrade to the new APIVersion for the same workload.
+	k.APIVersion = ""
+	if k.Namespace == provider.DefaultNamespace {
+		k.Namespace = ""
+	}
+	return k
+}
+
 func findConfigs(manifests []provider.Manifest) map[provider.ResourceKey]provider.Manifest {
 	configs := make(map[provider.ResourceKey]provider.Manifest)
 	for _, m := range manifests {
@@ -355,8 +470,9 @@ func findConfigs(manifests []provider.Manifest) map[provider.ResourceKey]provide
 	return configs
 }
 
+const containerImageQuery = `^spec\.template\.spec\.containers\.\d+.image$`
+
 func checkImageChange(ns diff.Nodes) (string, bool) {
-	const containerImageQuery = `^spec\.template\.spec\.containers\.\d+.image$`
 	nodes, _ := ns.Find(containerImageQuery)
 	if len(nodes) == 0 {
 		return "", false
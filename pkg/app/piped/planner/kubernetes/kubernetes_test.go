@@ -8,6 +8,7 @@ import (
 
 	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 func TestDecideStrategy(t *testing.T) {
@@ -395,3 +396,80 @@ func TestDecideStrategy(t *testing.T) {
 		})
 	}
 }
+
+func TestDescribePrune(t *testing.T) {
+	olds := []provider.Manifest{
+		{Key: provider.ResourceKey{APIVersion: "apps/v1", Kind: provider.KindDeployment, Name: "simple"}},
+		{Key: provider.ResourceKey{APIVersion: "v1", Kind: provider.KindService, Name: "simple"}},
+		{Key: provider.ResourceKey{APIVersion: "v1", Kind: provider.KindConfigMap, Name: "config"}},
+	}
+	news := olds[:1]
+	pipeline := &config.DeploymentPipeline{
+		Stages: []config.PipelineStage{
+			{
+				Name:                          model.StageK8sPrimaryRollout,
+				K8sPrimaryRolloutStageOptions: &config.K8sPrimaryRolloutStageOptions{Prune: true},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		cfg         *config.KubernetesDeploymentSpec
+		progressive bool
+		news        []provider.Manifest
+		want        string
+	}{
+		{
+			name: "prune is not enabled",
+			cfg:  &config.KubernetesDeploymentSpec{},
+			news: news,
+			want: "",
+		},
+		{
+			name: "prune is enabled for quick sync",
+			cfg: &config.KubernetesDeploymentSpec{
+				QuickSync: config.K8sSyncStageOptions{Prune: true},
+			},
+			news: news,
+			want: "2 resources will be pruned: ConfigMap config, Service simple",
+		},
+		{
+			name: "prune is enabled only for quick sync but pipeline was planned",
+			cfg: &config.KubernetesDeploymentSpec{
+				GenericDeploymentSpec: config.GenericDeploymentSpec{
+					Pipeline: &config.DeploymentPipeline{},
+				},
+				QuickSync: config.K8sSyncStageOptions{Prune: true},
+			},
+			progressive: true,
+			news:        news,
+			want:        "",
+		},
+		{
+			name: "prune is enabled for primary rollout",
+			cfg: &config.KubernetesDeploymentSpec{
+				GenericDeploymentSpec: config.GenericDeploymentSpec{
+					Pipeline: pipeline,
+				},
+			},
+			progressive: true,
+			news:        news,
+			want:        "2 resources will be pruned: ConfigMap config, Service simple",
+		},
+		{
+			name: "no resource was removed",
+			cfg: &config.KubernetesDeploymentSpec{
+				QuickSync: config.K8sSyncStageOptions{Prune: true},
+			},
+			news: olds,
+			want: "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := describePrune(tc.cfg, tc.progressive, olds, tc.news)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
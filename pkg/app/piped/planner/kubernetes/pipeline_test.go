@@ -5,7 +5,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/pipe-cd/pipe/pkg/app/piped/planner"
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
@@ -65,3 +67,27 @@ func TestBuildProgressivePipeline(t *testing.T) {
 		})
 	}
 }
+
+func TestGuardApprovalKeepsStageConfigIndex(t *testing.T) {
+	pp := &config.DeploymentPipeline{
+		Stages: []config.PipelineStage{
+			{Name: model.StageK8sCanaryRollout},
+			{Name: model.StageAnalysis},
+			{Name: model.StageK8sPrimaryRollout},
+			{Name: model.StageK8sCanaryClean},
+		},
+	}
+	stages := buildProgressivePipeline(pp, true, time.Now())
+	// The same as the guard does when it requires an approval.
+	stages = planner.PrependWaitApprovalStage(stages, time.Now())
+	require.Equal(t, model.StageWaitApproval.String(), stages[0].Name)
+
+	for _, s := range stages {
+		if s.Predefined {
+			continue
+		}
+		// The scheduler finds the stage configuration by this index.
+		require.True(t, int(s.Index) < len(pp.Stages))
+		assert.Equal(t, pp.Stages[s.Index].Name.String(), s.Name)
+	}
+}
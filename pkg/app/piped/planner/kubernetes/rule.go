@@ -0,0 +1,175 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/filematcher"
+)
+
+// changeSummary represents the size of changes between two sets of manifests.
+type changeSummary struct {
+	changedResources int
+
+	// The largest replicas delta and the workload having it.
+	replicasDelta    int
+	replicasWorkload string
+
+	// The description of the first container image whose major version was bumped.
+	majorBumpedImage string
+}
+
+// matchPlannerRules checks whether the changes between the given manifests
+// and the changed files are matching any of the given rules.
+func matchPlannerRules(rules []config.DeploymentPlannerRule, olds, news []provider.Manifest, workloadRefs []config.K8sResourceReference, changedFiles []string) (desc string, matched bool) {
+	if len(rules) == 0 {
+		return "", false
+	}
+	s := summarizeChanges(olds, news, workloadRefs)
+
+	for i, r := range rules {
+		reasons := make([]string, 0, 4)
+		if r.ChangedResources > 0 {
+			if s.changedResources < r.ChangedResources {
+				continue
+			}
+			reasons = append(reasons, fmt.Sprintf("%d resources were changed", s.changedResources))
+		}
+		if r.ReplicasDelta > 0 {
+			if s.replicasDelta < r.ReplicasDelta {
+				continue
+			}
+			reasons = append(reasons, fmt.Sprintf("replicas of %s were changed by %d", s.replicasWorkload, s.replicasDelta))
+		}
+		if r.ImageMajorVersionBump {
+			if s.majorBumpedImage == "" {
+				continue
+			}
+			reasons = append(reasons, fmt.Sprintf("major version of image %s", s.majorBumpedImage))
+		}
+		if len(r.ChangedFiles) > 0 {
+			file, ok := findChangedFile(r.ChangedFiles, changedFiles)
+			if !ok {
+				continue
+			}
+			reasons = append(reasons, fmt.Sprintf("file %s was changed", file))
+		}
+		desc = fmt.Sprintf("Sync progressively because planner rule %d was matched: %s", i, strings.Join(reasons, ", "))
+		return desc, true
+	}
+	return "", false
+}
+
+// findChangedFile returns the first changed file matching any of the given patterns.
+func findChangedFile(patterns, changedFiles []string) (string, bool) {
+	matcher, err := filematcher.NewPatternMatcher(patterns)
+	if err != nil {
+		return "", false
+	}
+	for _, f := range changedFiles {
+		if matcher.Matches(f) {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+func summarizeChanges(olds, news []provider.Manifest, workloadRefs []config.K8sResourceReference) changeSummary {
+	var s changeSummary
+
+	oldMap := make(map[provider.ResourceKey]provider.Manifest, len(olds))
+	for _, m := range olds {
+		oldMap[normalizeKey(m.Key)] = m
+	}
+	newKeys := make(map[provider.ResourceKey]struct{}, len(news))
+	for _, n := range news {
+		key := normalizeKey(n.Key)
+		newKeys[key] = struct{}{}
+		o, ok := oldMap[key]
+		if !ok {
+			s.changedResources++
+			continue
+		}
+		result, err := provider.Diff(o, n)
+		if err != nil || result.HasDiff() {
+			s.changedResources++
+		}
+	}
+	for key := range oldMap {
+		if _, ok := newKeys[key]; !ok {
+			s.changedResources++
+		}
+	}
+
+	workloads := findUpdatedWorkloads(findWorkloadManifests(olds, workloadRefs), findWorkloadManifests(news, workloadRefs))
+	for _, w := range workloads {
+		result, err := provider.Diff(w.old, w.new)
+		if err != nil {
+			continue
+		}
+		nodes := result.Nodes()
+
+		if before, after, changed := checkReplicasChange(nodes); changed {
+			b, err1 := strconv.Atoi(before)
+			a, err2 := strconv.Atoi(after)
+			if err1 == nil && err2 == nil {
+				delta := a - b
+				if delta < 0 {
+					delta = -delta
+				}
+				if delta > s.replicasDelta {
+					s.replicasDelta = delta
+					s.replicasWorkload = fmt.Sprintf("%s/%s", w.new.Key.Kind, w.new.Key.Name)
+				}
+			}
+		}
+
+		if s.majorBumpedImage != "" {
+			continue
+		}
+		imageNodes, _ := nodes.Find(containerImageQuery)
+		for _, n := range imageNodes {
+			beforeName, beforeTag := parseContainerImage(n.StringX())
+			afterName, afterTag := parseContainerImage(n.StringY())
+			if beforeName != afterName {
+				continue
+			}
+			b, ok1 := parseMajorVersion(beforeTag)
+			a, ok2 := parseMajorVersion(afterTag)
+			if ok1 && ok2 && a > b {
+				s.majorBumpedImage = fmt.Sprintf("%s was bumped from %s to %s", afterName, beforeTag, afterTag)
+				break
+			}
+		}
+	}
+	return s
+}
+
+// parseMajorVersion returns the major version of the given image tag
+// such as v1.2.3 or 2.0.
+func parseMajorVersion(tag string) (int, bool) {
+	tag = strings.TrimPrefix(tag, "v")
+	major := strings.SplitN(tag, ".", 2)[0]
+	v, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestMatchPlannerRules(t *testing.T) {
+	olds, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        image: gcr.io/pipecd/app:v1.5.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  key: value
+`)
+	require.NoError(t, err)
+	news, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 10
+  template:
+    spec:
+      containers:
+      - name: app
+        image: gcr.io/pipecd/app:v2.0.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+`)
+	require.NoError(t, err)
+	changedFiles := []string{
+		"apps/app/deployment.yaml",
+		"charts/app/values.yaml",
+	}
+
+	testcases := []struct {
+		name        string
+		rules       []config.DeploymentPlannerRule
+		wantMatched bool
+		wantDesc    string
+	}{
+		{
+			name:        "no rule",
+			wantMatched: false,
+		},
+		{
+			name: "changed resources exceeded",
+			rules: []config.DeploymentPlannerRule{
+				{ChangedResources: 3},
+			},
+			wantMatched: true,
+			wantDesc:    "Sync progressively because planner rule 0 was matched: 3 resources were changed",
+		},
+		{
+			name: "changed resources not exceeded",
+			rules: []config.DeploymentPlannerRule{
+				{ChangedResources: 4},
+			},
+			wantMatched: false,
+		},
+		{
+			name: "replicas delta exceeded",
+			rules: []config.DeploymentPlannerRule{
+				{ChangedResources: 4},
+				{ReplicasDelta: 8},
+			},
+			wantMatched: true,
+			wantDesc:    "Sync progressively because planner rule 1 was matched: replicas of Deployment/app were changed by 8",
+		},
+		{
+			name: "all thresholds of a rule must be exceeded",
+			rules: []config.DeploymentPlannerRule{
+				{ReplicasDelta: 9, ImageMajorVersionBump: true},
+			},
+			wantMatched: false,
+		},
+		{
+			name: "image major version bumped",
+			rules: []config.DeploymentPlannerRule{
+				{ImageMajorVersionBump: true},
+			},
+			wantMatched: true,
+			wantDesc:    "Sync progressively because planner rule 0 was matched: major version of image app was bumped from v1.5.0 to v2.0.0",
+		},
+		{
+			name: "changed files matched",
+			rules: []config.DeploymentPlannerRule{
+				{ChangedFiles: []string{"charts/**"}},
+			},
+			wantMatched: true,
+			wantDesc:    "Sync progressively because planner rule 0 was matched: file charts/app/values.yaml was changed",
+		},
+		{
+			name: "changed files not matched",
+			rules: []config.DeploymentPlannerRule{
+				{ChangedResources: 3, ChangedFiles: []string{"apps/app/secret.yaml"}},
+			},
+			wantMatched: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			desc, matched := matchPlannerRules(tc.rules, olds, news, nil, changedFiles)
+			assert.Equal(t, tc.wantMatched, matched)
+			assert.Equal(t, tc.wantDesc, desc)
+		})
+	}
+}
+
+func TestParseMajorVersion(t *testing.T) {
+	testcases := []struct {
+		tag       string
+		wantMajor int
+		wantOK    bool
+	}{
+		{tag: "v1.2.3", wantMajor: 1, wantOK: true},
+		{tag: "2.0", wantMajor: 2, wantOK: true},
+		{tag: "10", wantMajor: 10, wantOK: true},
+		{tag: "latest", wantOK: false},
+		{tag: "", wantOK: false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.tag, func(t *testing.T) {
+			major, ok := parseMajorVersion(tc.tag)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantMajor, major)
+		})
+	}
+}
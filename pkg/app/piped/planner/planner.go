@@ -39,6 +39,7 @@ type Planner interface {
 type Input struct {
 	// Readonly deployment model.
 	Deployment                     *model.Deployment
+	PipedConfig                    *config.PipedSpec
 	MostRecentSuccessfulCommitHash string
 	TargetDSP                      deploysource.Provider
 	RunningDSP                     deploysource.Provider
@@ -51,6 +52,8 @@ type Output struct {
 	Version string
 	Stages  []*model.PipelineStage
 	Summary string
+	// The metadata to be added to the deployment before running its stages.
+	Metadata map[string]string
 }
 
 // MakeInitialStageMetadata makes the initial metadata for the given state configuration.
@@ -15,6 +15,8 @@
 package planner
 
 import (
+	"time"
+
 	"github.com/pipe-cd/pipe/pkg/config"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
@@ -26,6 +28,7 @@ const (
 	PredefinedStageLambdaSync    = "LambdaSync"
 	PredefinedStageECSSync       = "ECSSync"
 	PredefinedStageRollback      = "Rollback"
+	PredefinedStageWaitApproval  = "WaitApproval"
 )
 
 var predefinedStages = map[string]config.PipelineStage{
@@ -59,6 +62,14 @@ var predefinedStages = map[string]config.PipelineStage{
 		Name: model.StageRollback,
 		Desc: "Rollback the deployment",
 	},
+	PredefinedStageWaitApproval: {
+		Id:   PredefinedStageWaitApproval,
+		Name: model.StageWaitApproval,
+		Desc: "Wait for an approval before syncing",
+		WaitApprovalStageOptions: &config.WaitApprovalStageOptions{
+			Timeout: config.Duration(6 * time.Hour),
+		},
+	},
 }
 
 // GetPredefinedStage finds and returns the predefined stage for the given id.
@@ -0,0 +1,266 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/app/piped/deploysource"
+	"github.com/pipe-cd/pipe/pkg/app/piped/planner"
+	"github.com/pipe-cd/pipe/pkg/app/piped/planner/registry"
+	"github.com/pipe-cd/pipe/pkg/cache"
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/git"
+	"github.com/pipe-cd/pipe/pkg/model"
+	"github.com/pipe-cd/pipe/pkg/regexpool"
+)
+
+type gitClient interface {
+	Clone(ctx context.Context, repoID, remote, branch, destination string) (git.Repo, error)
+}
+
+type applicationLister interface {
+	List() []*model.Application
+}
+
+type sealedSecretDecrypter interface {
+	Decrypt(string) (string, error)
+}
+
+type builder struct {
+	gitClient         gitClient
+	applicationLister applicationLister
+	pipedCfg          *config.PipedSpec
+	secretDecrypter   sealedSecretDecrypter
+	appManifestsCache cache.Cache
+	plannerRegistry   registry.Registry
+	workingDir        string
+	logger            *zap.Logger
+}
+
+func NewBuilder(
+	gc gitClient,
+	al applicationLister,
+	cfg *config.PipedSpec,
+	sd sealedSecretDecrypter,
+	appManifestsCache cache.Cache,
+	workingDir string,
+	logger *zap.Logger,
+) Builder {
+	return &builder{
+		gitClient:         gc,
+		applicationLister: al,
+		pipedCfg:          cfg,
+		secretDecrypter:   sd,
+		appManifestsCache: appManifestsCache,
+		plannerRegistry:   registry.DefaultRegistry(),
+		workingDir:        workingDir,
+		logger:            logger.Named("plan-preview-builder"),
+	}
+}
+
+func (b *builder) Build(ctx context.Context, id string, cmd *model.Command_BuildPlanPreview) ([]*model.ApplicationPlanPreviewResult, error) {
+	logger := b.logger.With(
+		zap.String("command-id", id),
+		zap.String("repo-id", cmd.RepositoryId),
+		zap.String("head-branch", cmd.HeadBranch),
+		zap.String("head-commit", cmd.HeadCommit),
+	)
+
+	repoCfg, ok := b.pipedCfg.GetRepository(cmd.RepositoryId)
+	if !ok {
+		return nil, fmt.Errorf("repository %s was not found in piped configuration", cmd.RepositoryId)
+	}
+
+	if err := os.MkdirAll(b.workingDir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create the working directory (%w)", err)
+	}
+	dir, err := ioutil.TempDir(b.workingDir, "planpreview-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create a temporary directory (%w)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := b.gitClient.Clone(ctx, repoCfg.RepoID, repoCfg.Remote, cmd.HeadBranch, filepath.Join(dir, "repo"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to clone the branch %s of repository %s (%w)", cmd.HeadBranch, cmd.RepositoryId, err)
+	}
+	if err := repo.Checkout(ctx, cmd.HeadCommit); err != nil {
+		return nil, fmt.Errorf("unable to checkout the commit %s (%w)", cmd.HeadCommit, err)
+	}
+	headCommit, err := repo.GetLatestCommit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the head commit (%w)", err)
+	}
+
+	// The local clone keeps all branches of the remote as remote-tracking branches.
+	baseCommit, err := repo.GetCommitHashForRev(ctx, "origin/"+cmd.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the base branch %s (%w)", cmd.BaseBranch, err)
+	}
+	files, err := repo.ChangedFiles(ctx, baseCommit, headCommit.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the changed files between %s and %s (%w)", cmd.BaseBranch, cmd.HeadBranch, err)
+	}
+
+	apps := listAffectedApplications(b.applicationLister.List(), cmd.RepositoryId, files)
+	logger.Info(fmt.Sprintf("found %d applications affected by %d changed files", len(apps), len(files)))
+
+	results := make([]*model.ApplicationPlanPreviewResult, 0, len(apps))
+	for _, app := range apps {
+		r := b.buildApp(ctx, dir, app, repoCfg, cmd.HeadBranch, headCommit, logger)
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func (b *builder) buildApp(ctx context.Context, dir string, app *model.Application, repoCfg config.PipedRepository, branch string, commit git.Commit, logger *zap.Logger) *model.ApplicationPlanPreviewResult {
+	now := time.Now()
+	r := &model.ApplicationPlanPreviewResult{
+		ApplicationId:        app.Id,
+		ApplicationName:      app.Name,
+		ApplicationKind:      app.Kind,
+		ApplicationDirectory: app.GitPath.Path,
+		EnvId:                app.EnvId,
+		PipedId:              app.PipedId,
+		ProjectId:            app.ProjectId,
+		HeadBranch:           branch,
+		HeadCommit:           commit.Hash,
+		CreatedAt:            now.Unix(),
+	}
+	logger = logger.With(zap.String("app-id", app.Id))
+
+	p, ok := b.plannerRegistry.Planner(app.Kind)
+	if !ok {
+		r.Error = fmt.Sprintf("Unable to find the planner for application kind %s", app.Kind)
+		return r
+	}
+
+	workingDir := filepath.Join(dir, app.Id)
+	in := planner.Input{
+		Deployment:        makeDeployment(app, branch, commit, now),
+		PipedConfig:       b.pipedCfg,
+		AppManifestsCache: b.appManifestsCache,
+		RegexPool:         regexpool.DefaultPool(),
+		Logger:            logger,
+	}
+	in.TargetDSP = deploysource.NewProvider(
+		filepath.Join(workingDir, "target-deploysource"),
+		repoCfg,
+		"target",
+		commit.Hash,
+		b.gitClient,
+		app.GitPath,
+		b.secretDecrypter,
+	)
+	if d := app.MostRecentlySuccessfulDeployment; d != nil && d.Trigger != nil && d.Trigger.Commit != nil {
+		in.MostRecentSuccessfulCommitHash = d.Trigger.Commit.Hash
+		in.RunningDSP = deploysource.NewProvider(
+			filepath.Join(workingDir, "running-deploysource"),
+			repoCfg,
+			"running",
+			in.MostRecentSuccessfulCommitHash,
+			b.gitClient,
+			app.GitPath,
+			b.secretDecrypter,
+		)
+	}
+
+	out, err := p.Plan(ctx, in)
+	if err != nil {
+		logger.Error("failed to plan", zap.Error(err))
+		r.Error = fmt.Sprintf("Failed while planning, %v", err)
+		return r
+	}
+	r.SyncStrategy = syncStrategyOf(out.Stages)
+	r.PlanSummary = out.Summary
+
+	if app.Kind == model.ApplicationKind_KUBERNETES {
+		details, err := b.kubernetesDiff(ctx, in)
+		if err != nil {
+			logger.Error("failed to calculate the diff of manifests", zap.Error(err))
+			r.Error = fmt.Sprintf("Failed while calculating the diff of manifests, %v", err)
+			return r
+		}
+		r.PlanDetails = details
+	}
+	return r
+}
+
+// listAffectedApplications returns the applications in the given repository
+// whose directory contains at least one of the given changed files.
+func listAffectedApplications(apps []*model.Application, repoID string, changedFiles []string) []*model.Application {
+	out := make([]*model.Application, 0)
+	for _, app := range apps {
+		if app.GitPath == nil || app.GitPath.Repo == nil || app.GitPath.Repo.Id != repoID {
+			continue
+		}
+		dir := path.Clean(app.GitPath.Path)
+		for _, f := range changedFiles {
+			if dir == "." || strings.HasPrefix(f, dir+"/") {
+				out = append(out, app)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// syncStrategyOf determines the sync strategy from the planned stages.
+// Only the predefined stages are used while doing a quick sync.
+func syncStrategyOf(stages []*model.PipelineStage) model.SyncStrategy {
+	for _, s := range stages {
+		if s.Visible && !s.Predefined {
+			return model.SyncStrategy_PIPELINE
+		}
+	}
+	return model.SyncStrategy_QUICK_SYNC
+}
+
+func makeDeployment(app *model.Application, branch string, commit git.Commit, now time.Time) *model.Deployment {
+	return &model.Deployment{
+		Id:              "plan-preview-" + app.Id,
+		ApplicationId:   app.Id,
+		ApplicationName: app.Name,
+		EnvId:           app.EnvId,
+		PipedId:         app.PipedId,
+		ProjectId:       app.ProjectId,
+		Kind:            app.Kind,
+		Trigger: &model.DeploymentTrigger{
+			Commit: &model.Commit{
+				Hash:      commit.Hash,
+				Message:   commit.Message,
+				Author:    commit.Author,
+				Branch:    branch,
+				CreatedAt: int64(commit.CreatedAt),
+			},
+			Timestamp: now.Unix(),
+		},
+		GitPath:       app.GitPath,
+		CloudProvider: app.CloudProvider,
+		Status:        model.DeploymentStatus_DEPLOYMENT_PENDING,
+		CreatedAt:     now.Unix(),
+		UpdatedAt:     now.Unix(),
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestListAffectedApplications(t *testing.T) {
+	makeApp := func(id, repoID, path string) *model.Application {
+		return &model.Application{
+			Id: id,
+			GitPath: &model.ApplicationGitPath{
+				Repo: &model.ApplicationGitRepository{Id: repoID},
+				Path: path,
+			},
+		}
+	}
+	apps := []*model.Application{
+		makeApp("app-1", "repo-1", "apps/foo"),
+		makeApp("app-2", "repo-1", "apps/foo-bar/"),
+		makeApp("app-3", "repo-1", "apps/bar"),
+		makeApp("app-4", "repo-2", "apps/foo"),
+	}
+
+	testcases := []struct {
+		name         string
+		changedFiles []string
+		expected     []string
+	}{
+		{
+			name:     "no changes",
+			expected: []string{},
+		},
+		{
+			name:         "changes in one application",
+			changedFiles: []string{"apps/foo/deployment.yaml"},
+			expected:     []string{"app-1"},
+		},
+		{
+			name:         "changes in multiple applications",
+			changedFiles: []string{"apps/foo-bar/.pipe.yaml", "apps/bar/service.yaml", "README.md"},
+			expected:     []string{"app-2", "app-3"},
+		},
+		{
+			name:         "changes outside of any application",
+			changedFiles: []string{"apps/README.md"},
+			expected:     []string{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := listAffectedApplications(apps, "repo-1", tc.changedFiles)
+			ids := make([]string, 0, len(got))
+			for _, app := range got {
+				ids = append(ids, app.Id)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestSyncStrategyOf(t *testing.T) {
+	testcases := []struct {
+		name     string
+		stages   []*model.PipelineStage
+		expected model.SyncStrategy
+	}{
+		{
+			name: "quick sync",
+			stages: []*model.PipelineStage{
+				{Name: "K8S_SYNC", Predefined: true, Visible: true},
+				{Name: "K8S_ROLLBACK", Predefined: true},
+			},
+			expected: model.SyncStrategy_QUICK_SYNC,
+		},
+		{
+			name: "pipeline",
+			stages: []*model.PipelineStage{
+				{Name: "K8S_CANARY_ROLLOUT", Visible: true},
+				{Name: "K8S_PRIMARY_ROLLOUT", Visible: true},
+				{Name: "K8S_ROLLBACK", Predefined: true},
+			},
+			expected: model.SyncStrategy_PIPELINE,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, syncStrategyOf(tc.stages))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package planpreview provides a piped component
+// that builds the plan preview of the applications affected by
+// the changes of a git branch and reports the results to the control plane.
+package planpreview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const (
+	defaultWorkerNum            = 3
+	defaultCommandCheckInterval = 5 * time.Second
+	defaultCommandHandleTimeout = 5 * time.Minute
+)
+
+type commandLister interface {
+	ListBuildPlanPreviewCommands() []model.ReportableCommand
+}
+
+// Builder builds the plan preview results of all applications
+// affected by the changes of the requested branch.
+type Builder interface {
+	Build(ctx context.Context, id string, cmd *model.Command_BuildPlanPreview) ([]*model.ApplicationPlanPreviewResult, error)
+}
+
+type Handler struct {
+	builder       Builder
+	commandLister commandLister
+	commandCh     chan model.ReportableCommand
+	interval      time.Duration
+	workerNum     int
+	timeout       time.Duration
+
+	// The commands being handled currently.
+	// This is used to avoid handling the same command twice
+	// while it is still being listed by the command lister.
+	handlingCommands map[string]struct{}
+	mu               sync.Mutex
+
+	logger *zap.Logger
+}
+
+func NewHandler(b Builder, cl commandLister, logger *zap.Logger) *Handler {
+	return &Handler{
+		builder:          b,
+		commandLister:    cl,
+		commandCh:        make(chan model.ReportableCommand, defaultWorkerNum),
+		interval:         defaultCommandCheckInterval,
+		workerNum:        defaultWorkerNum,
+		timeout:          defaultCommandHandleTimeout,
+		handlingCommands: make(map[string]struct{}),
+		logger:           logger.Named("plan-preview-handler"),
+	}
+}
+
+func (h *Handler) Run(ctx context.Context) error {
+	h.logger.Info("start running plan preview handler")
+
+	var wg sync.WaitGroup
+	for i := 0; i < h.workerNum; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case cmd := <-h.commandCh:
+					h.handleCommand(ctx, cmd)
+					h.mu.Lock()
+					delete(h.handlingCommands, cmd.Id)
+					h.mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			h.logger.Info("plan preview handler has been stopped")
+			return nil
+
+		case <-ticker.C:
+			h.enqueueNewCommands(ctx)
+		}
+	}
+}
+
+func (h *Handler) enqueueNewCommands(ctx context.Context) {
+	for _, cmd := range h.commandLister.ListBuildPlanPreviewCommands() {
+		h.mu.Lock()
+		_, ok := h.handlingCommands[cmd.Id]
+		if !ok {
+			h.handlingCommands[cmd.Id] = struct{}{}
+		}
+		h.mu.Unlock()
+		if ok {
+			continue
+		}
+
+		select {
+		case h.commandCh <- cmd:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) handleCommand(ctx context.Context, cmd model.ReportableCommand) {
+	logger := h.logger.With(zap.String("command-id", cmd.Id))
+	logger.Info("start building plan preview")
+
+	result := &model.PlanPreviewCommandResult{
+		CommandId: cmd.Id,
+		PipedId:   cmd.PipedId,
+	}
+	status := model.CommandStatus_COMMAND_SUCCEEDED
+
+	if err := h.build(ctx, cmd, result); err != nil {
+		logger.Error("failed to build plan preview", zap.Error(err))
+		result.Error = err.Error()
+		status = model.CommandStatus_COMMAND_FAILED
+	}
+
+	output, err := json.Marshal(result)
+	if err != nil {
+		logger.Error("failed to marshal plan preview result", zap.Error(err))
+		status = model.CommandStatus_COMMAND_FAILED
+		output = nil
+	}
+	if err := cmd.Report(ctx, status, nil, output); err != nil {
+		logger.Error("failed to report command status", zap.Error(err))
+		return
+	}
+	logger.Info("successfully reported plan preview result", zap.Int("applications", len(result.Results)))
+}
+
+func (h *Handler) build(ctx context.Context, cmd model.ReportableCommand, result *model.PlanPreviewCommandResult) error {
+	if cmd.BuildPlanPreview == nil {
+		return fmt.Errorf("malformed command")
+	}
+
+	timeout := time.Duration(cmd.BuildPlanPreview.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = h.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results, err := h.builder.Build(ctx, cmd.Id, cmd.BuildPlanPreview)
+	result.Results = results
+	return err
+}
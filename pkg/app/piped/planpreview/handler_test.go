@@ -0,0 +1,142 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+type fakeBuilder struct {
+	results []*model.ApplicationPlanPreviewResult
+	err     error
+}
+
+func (b *fakeBuilder) Build(_ context.Context, _ string, _ *model.Command_BuildPlanPreview) ([]*model.ApplicationPlanPreviewResult, error) {
+	return b.results, b.err
+}
+
+func TestHandleCommand(t *testing.T) {
+	results := []*model.ApplicationPlanPreviewResult{
+		{
+			ApplicationId: "app-1",
+			PlanSummary:   "Quick sync by applying all manifests",
+		},
+	}
+	testcases := []struct {
+		name           string
+		cmd            *model.Command
+		builder        *fakeBuilder
+		expectedStatus model.CommandStatus
+		expected       *model.PlanPreviewCommandResult
+	}{
+		{
+			name: "successfully built",
+			cmd: &model.Command{
+				Id:      "cmd-1",
+				PipedId: "piped-1",
+				Type:    model.Command_BUILD_PLAN_PREVIEW,
+				BuildPlanPreview: &model.Command_BuildPlanPreview{
+					RepositoryId: "repo-1",
+					HeadBranch:   "feature",
+					HeadCommit:   "abc",
+					BaseBranch:   "master",
+				},
+			},
+			builder:        &fakeBuilder{results: results},
+			expectedStatus: model.CommandStatus_COMMAND_SUCCEEDED,
+			expected: &model.PlanPreviewCommandResult{
+				CommandId: "cmd-1",
+				PipedId:   "piped-1",
+				Results:   results,
+			},
+		},
+		{
+			name: "failed to build",
+			cmd: &model.Command{
+				Id:      "cmd-2",
+				PipedId: "piped-1",
+				Type:    model.Command_BUILD_PLAN_PREVIEW,
+				BuildPlanPreview: &model.Command_BuildPlanPreview{
+					RepositoryId: "repo-1",
+					HeadBranch:   "feature",
+					HeadCommit:   "abc",
+					BaseBranch:   "master",
+				},
+			},
+			builder:        &fakeBuilder{err: errors.New("repository repo-1 was not found")},
+			expectedStatus: model.CommandStatus_COMMAND_FAILED,
+			expected: &model.PlanPreviewCommandResult{
+				CommandId: "cmd-2",
+				PipedId:   "piped-1",
+				Error:     "repository repo-1 was not found",
+			},
+		},
+		{
+			name: "malformed command",
+			cmd: &model.Command{
+				Id:      "cmd-3",
+				PipedId: "piped-1",
+				Type:    model.Command_BUILD_PLAN_PREVIEW,
+			},
+			builder:        &fakeBuilder{results: results},
+			expectedStatus: model.CommandStatus_COMMAND_FAILED,
+			expected: &model.PlanPreviewCommandResult{
+				CommandId: "cmd-3",
+				PipedId:   "piped-1",
+				Error:     "malformed command",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(tc.builder, nil, zap.NewNop())
+
+			var (
+				status model.CommandStatus
+				output []byte
+			)
+			cmd := model.ReportableCommand{
+				Command: tc.cmd,
+				Report: func(_ context.Context, s model.CommandStatus, _ map[string]string, o []byte) error {
+					status = s
+					output = o
+					return nil
+				},
+			}
+			h.handleCommand(context.Background(), cmd)
+			assert.Equal(t, tc.expectedStatus, status)
+
+			var got model.PlanPreviewCommandResult
+			require.NoError(t, json.Unmarshal(output, &got))
+			assert.Equal(t, tc.expected.CommandId, got.CommandId)
+			assert.Equal(t, tc.expected.PipedId, got.PipedId)
+			assert.Equal(t, tc.expected.Error, got.Error)
+			require.Equal(t, len(tc.expected.Results), len(got.Results))
+			for i := range got.Results {
+				assert.Equal(t, tc.expected.Results[i].ApplicationId, got.Results[i].ApplicationId)
+				assert.Equal(t, tc.expected.Results[i].PlanSummary, got.Results[i].PlanSummary)
+			}
+		})
+	}
+}
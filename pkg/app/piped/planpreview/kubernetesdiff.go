@@ -0,0 +1,136 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipe/pkg/app/piped/deploysource"
+	"github.com/pipe-cd/pipe/pkg/app/piped/diff"
+	"github.com/pipe-cd/pipe/pkg/app/piped/planner"
+)
+
+// kubernetesDiff returns the rendered diff between the manifests
+// at the most recently deployed commit and the ones at the target commit.
+func (b *builder) kubernetesDiff(ctx context.Context, in planner.Input) ([]byte, error) {
+	news, err := b.loadKubernetesManifests(ctx, in, in.Deployment.Trigger.Commit.Hash, in.TargetDSP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the manifests at the target commit (%w)", err)
+	}
+
+	var olds []provider.Manifest
+	if in.RunningDSP != nil {
+		olds, err = b.loadKubernetesManifests(ctx, in, in.MostRecentSuccessfulCommitHash, in.RunningDSP)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the manifests at the running commit (%w)", err)
+		}
+	}
+
+	details, err := renderManifestsDiff(olds, news)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(details), nil
+}
+
+func (b *builder) loadKubernetesManifests(ctx context.Context, in planner.Input, commit string, dsp deploysource.Provider) ([]provider.Manifest, error) {
+	cache := provider.AppManifestsCache{
+		AppID:  in.Deployment.ApplicationId,
+		Cache:  b.appManifestsCache,
+		Logger: in.Logger,
+	}
+	return cache.GetOrLoad(commit, func() ([]provider.Manifest, error) {
+		ds, err := dsp.GetReadOnly(ctx, ioutil.Discard)
+		if err != nil {
+			return nil, err
+		}
+		cfg := ds.DeploymentConfig.KubernetesDeploymentSpec
+		if cfg == nil {
+			return nil, fmt.Errorf("malformed deployment configuration: missing KubernetesDeploymentSpec")
+		}
+
+		loader := provider.NewManifestLoader(in.Deployment.ApplicationName, ds.AppDir, ds.RepoDir, in.Deployment.GitPath.ConfigFilename, cfg.Input, in.Logger, provider.WithHelmPostRenderer(in.PipedConfig.AllowHelmPostRenderer), provider.WithGitRepositories(in.PipedConfig.Repositories))
+		return loader.LoadManifests(ctx)
+	})
+}
+
+// renderManifestsDiff renders the diff between the given two lists of manifests.
+// The data of secrets and configmaps are masked.
+func renderManifestsDiff(olds, news []provider.Manifest) (string, error) {
+	var (
+		oldMap  = make(map[provider.ResourceKey]provider.Manifest, len(olds))
+		newMap  = make(map[provider.ResourceKey]provider.Manifest, len(news))
+		keys    = make([]provider.ResourceKey, 0, len(olds)+len(news))
+		b       strings.Builder
+		adds    int
+		deletes int
+		changes int
+	)
+	for _, m := range olds {
+		oldMap[m.Key] = m
+		keys = append(keys, m.Key)
+	}
+	for _, m := range news {
+		newMap[m.Key] = m
+		if _, ok := oldMap[m.Key]; !ok {
+			keys = append(keys, m.Key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	for _, k := range keys {
+		o, inOld := oldMap[k]
+		n, inNew := newMap[k]
+		switch {
+		case !inOld:
+			adds++
+			b.WriteString(fmt.Sprintf("+ %d. %s\n\n", adds+deletes+changes, k.ReadableString()))
+		case !inNew:
+			deletes++
+			b.WriteString(fmt.Sprintf("- %d. %s\n\n", adds+deletes+changes, k.ReadableString()))
+		default:
+			result, err := provider.Diff(o, n)
+			if err != nil {
+				return "", fmt.Errorf("failed to compare manifest %s (%w)", k.ReadableString(), err)
+			}
+			if !result.HasDiff() {
+				continue
+			}
+			opts := []diff.RenderOption{
+				diff.WithLeftPadding(1),
+			}
+			if k.IsSecret() || k.IsConfigMap() {
+				opts = append(opts, diff.WithMaskPath("data"))
+			}
+			changes++
+			b.WriteString(fmt.Sprintf("* %d. %s\n\n", adds+deletes+changes, k.ReadableString()))
+			b.WriteString(diff.NewRenderer(opts...).Render(result.Nodes()))
+			b.WriteString("\n")
+		}
+	}
+
+	if adds+deletes+changes == 0 {
+		return "No changes were detected", nil
+	}
+	header := fmt.Sprintf("--- Last Deploy\n+++ Head Commit\n\n%d added, %d deleted, %d changed manifests\n\n", adds, deletes, changes)
+	return header + b.String(), nil
+}
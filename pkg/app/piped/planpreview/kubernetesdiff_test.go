@@ -0,0 +1,95 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipe/pkg/app/piped/cloudprovider/kubernetes"
+)
+
+func TestRenderManifestsDiff(t *testing.T) {
+	olds, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: simple
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-config
+data:
+  key: value
+`)
+	require.NoError(t, err)
+	news, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: simple
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new-config
+data:
+  key: value
+`)
+	require.NoError(t, err)
+
+	expected := `--- Last Deploy
++++ Head Commit
+
+1 added, 1 deleted, 1 changed manifests
+
+* 1. name="simple", kind="Deployment", namespace="default", apiVersion="apps/v1"
+
+  spec:
+    #spec.replicas
+-   replicas: 2
++   replicas: 3
+
+
++ 2. name="new-config", kind="ConfigMap", namespace="default", apiVersion="v1"
+
+- 3. name="old-config", kind="ConfigMap", namespace="default", apiVersion="v1"
+
+`
+	got, err := renderManifestsDiff(olds, news)
+	require.NoError(t, err)
+	assert.Equal(t, expected, got)
+
+	got, err = renderManifestsDiff(olds, olds)
+	require.NoError(t, err)
+	assert.Equal(t, "No changes were detected", got)
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimiter manages the rate limiters of the configured cloud providers.
+// All components sending requests to a cloud provider (executors, live state stores...)
+// share the same limiter to avoid tripping the rate limits of its API.
+package ratelimiter
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"golang.org/x/time/rate"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+// Registry holds the rate limiters of all cloud providers.
+type Registry struct {
+	limiters map[string]*rate.Limiter
+}
+
+// NewRegistry creates a registry containing a limiter
+// for each cloud provider which has the rate limit configuration.
+func NewRegistry(cps []config.PipedCloudProvider) *Registry {
+	r := &Registry{
+		limiters: make(map[string]*rate.Limiter, len(cps)),
+	}
+	for _, cp := range cps {
+		if l := newLimiter(cp.RateLimit); l != nil {
+			r.limiters[cp.Name] = l
+		}
+	}
+	return r
+}
+
+func newLimiter(cfg config.CloudProviderRateLimit) *rate.Limiter {
+	if cfg.QPS <= 0 {
+		return nil
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(cfg.QPS))
+	}
+	return rate.NewLimiter(rate.Limit(cfg.QPS), burst)
+}
+
+var defaultRegistry = &Registry{}
+
+// DefaultRegistry returns the shared registry.
+// No limit will be applied before calling InitDefaultRegistry.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// InitDefaultRegistry initializes the shared registry.
+func InitDefaultRegistry(cps []config.PipedCloudProvider) {
+	defaultRegistry = NewRegistry(cps)
+}
+
+// Limiter returns the limiter of the given cloud provider.
+// Nil means there is no limit for that cloud provider.
+func (r *Registry) Limiter(cloudProvider string) *rate.Limiter {
+	return r.limiters[cloudProvider]
+}
+
+// Wait blocks until a request to the given cloud provider is allowed to be sent.
+func (r *Registry) Wait(ctx context.Context, cloudProvider string) error {
+	l := r.Limiter(cloudProvider)
+	if l == nil {
+		return nil
+	}
+	return l.Wait(ctx)
+}
+
+// NewTransport returns a RoundTripper that waits for the given limiter
+// before sending each request through the base RoundTripper.
+// The base is returned as is when the limiter is nil.
+func NewTransport(base http.RoundTripper, l *rate.Limiter) http.RoundTripper {
+	if l == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{
+		base:    base,
+		limiter: l,
+	}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+)
+
+func TestNewRegistry(t *testing.T) {
+	r := NewRegistry([]config.PipedCloudProvider{
+		{
+			Name: "no-limit",
+		},
+		{
+			Name: "qps-only",
+			RateLimit: config.CloudProviderRateLimit{
+				QPS: 2.5,
+			},
+		},
+		{
+			Name: "qps-burst",
+			RateLimit: config.CloudProviderRateLimit{
+				QPS:   10,
+				Burst: 20,
+			},
+		},
+	})
+
+	assert.Nil(t, r.Limiter("no-limit"))
+	assert.Nil(t, r.Limiter("unknown"))
+
+	l := r.Limiter("qps-only")
+	require.NotNil(t, l)
+	assert.Equal(t, rate.Limit(2.5), l.Limit())
+	assert.Equal(t, 3, l.Burst())
+
+	l = r.Limiter("qps-burst")
+	require.NotNil(t, l)
+	assert.Equal(t, rate.Limit(10), l.Limit())
+	assert.Equal(t, 20, l.Burst())
+
+	assert.NoError(t, r.Wait(context.Background(), "no-limit"))
+	assert.NoError(t, r.Wait(context.Background(), "qps-burst"))
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	assert.Equal(t, http.DefaultTransport, NewTransport(http.DefaultTransport, nil))
+
+	// The limiter allows only one request then blocks the others forever.
+	l := rate.NewLimiter(rate.Limit(0), 1)
+	client := &http.Client{Transport: NewTransport(nil, l)}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+}
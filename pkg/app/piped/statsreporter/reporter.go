@@ -36,6 +36,18 @@ type apiClient interface {
 	Ping(ctx context.Context, req *pipedservice.PingRequest, opts ...grpc.CallOption) (*pipedservice.PingResponse, error)
 }
 
+type componentStatusLister interface {
+	ListComponentStatuses() []*model.Piped_ComponentStatus
+}
+
+type notifier interface {
+	Notify(event model.NotificationEvent)
+}
+
+// The number of consecutive failures of reporting
+// to consider that piped has lost its connection to the control plane.
+const offlineThreshold = 3
+
 type Reporter interface {
 	Run(ctx context.Context) error
 }
@@ -44,17 +56,30 @@ type reporter struct {
 	metricsURL string
 	httpClient *http.Client
 	apiClient  apiClient
-	interval   time.Duration
-	logger     *zap.Logger
+	// The optional lister of component statuses to be reported along with metrics.
+	statusLister componentStatusLister
+	notifier     notifier
+	pipedID      string
+	interval     time.Duration
+	logger       *zap.Logger
+
+	// The connection status to the control plane determined by the results of reporting.
+	failures     int
+	lastSeenAt   time.Time
+	offlineSince time.Time
 }
 
-func NewReporter(metricsURL string, apiClient apiClient, logger *zap.Logger) *reporter {
+func NewReporter(metricsURL string, apiClient apiClient, statusLister componentStatusLister, notifier notifier, pipedID string, logger *zap.Logger) *reporter {
 	return &reporter{
-		metricsURL: metricsURL,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
-		apiClient:  apiClient,
-		interval:   time.Minute,
-		logger:     logger.Named("stats-reporter"),
+		metricsURL:   metricsURL,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		apiClient:    apiClient,
+		statusLister: statusLister,
+		notifier:     notifier,
+		pipedID:      pipedID,
+		interval:     time.Minute,
+		lastSeenAt:   time.Now(),
+		logger:       logger.Named("stats-reporter"),
 	}
 }
 
@@ -75,11 +100,17 @@ L:
 			if err != nil {
 				continue
 			}
-			if len(metrics) == 0 {
+			var statuses []*model.Piped_ComponentStatus
+			if r.statusLister != nil {
+				statuses = r.statusLister.ListComponentStatuses()
+			}
+			if len(metrics) == 0 && len(statuses) == 0 {
 				r.logger.Info("there are no metrics to report")
 				continue
 			}
-			if err := r.report(ctx, metrics, now); err != nil {
+			err = r.report(ctx, metrics, statuses, now)
+			r.updateConnectionStatus(err, now)
+			if err != nil {
 				continue
 			}
 			r.logger.Info("successfully collected and reported metrics",
@@ -110,12 +141,13 @@ func (r *reporter) collect() ([]*model.PrometheusMetrics, error) {
 	return metrics, nil
 }
 
-func (r *reporter) report(ctx context.Context, metrics []*model.PrometheusMetrics, now time.Time) error {
+func (r *reporter) report(ctx context.Context, metrics []*model.PrometheusMetrics, statuses []*model.Piped_ComponentStatus, now time.Time) error {
 	req := &pipedservice.PingRequest{
 		PipedStats: &model.PipedStats{
 			Version:           version.Get().Version,
 			Timestamp:         now.Unix(),
 			PrometheusMetrics: metrics,
+			ComponentStatuses: statuses,
 		},
 	}
 	if _, err := r.apiClient.Ping(ctx, req); err != nil {
@@ -125,6 +157,44 @@ func (r *reporter) report(ctx context.Context, metrics []*model.PrometheusMetric
 	return nil
 }
 
+// updateConnectionStatus tracks whether piped is able to communicate with the control plane
+// and notifies when piped went offline or came back online.
+func (r *reporter) updateConnectionStatus(err error, now time.Time) {
+	if err == nil {
+		if !r.offlineSince.IsZero() {
+			r.logger.Info("connection to control plane has been recovered")
+			r.notifier.Notify(model.NotificationEvent{
+				Type: model.NotificationEventType_EVENT_PIPED_ONLINE,
+				Metadata: &model.NotificationEventPipedOnline{
+					Id:           r.pipedID,
+					Version:      version.Get().Version,
+					OfflineSince: r.offlineSince.Unix(),
+				},
+			})
+			r.offlineSince = time.Time{}
+		}
+		r.failures = 0
+		r.lastSeenAt = now
+		return
+	}
+
+	r.failures++
+	if r.failures != offlineThreshold {
+		return
+	}
+	r.offlineSince = now
+	r.logger.Warn("connection to control plane has been lost", zap.Int("failures", r.failures))
+	r.notifier.Notify(model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_PIPED_OFFLINE,
+		Metadata: &model.NotificationEventPipedOffline{
+			Id:         r.pipedID,
+			Version:    version.Get().Version,
+			LastSeenAt: r.lastSeenAt.Unix(),
+			Reason:     err.Error(),
+		},
+	})
+}
+
 var parser expfmt.TextParser
 
 // TODO: Add a metrics whitelist and fiter out not needed ones.
@@ -15,11 +15,16 @@
 package statsreporter
 
 import (
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 func TestParsePrometheusMetrics(t *testing.T) {
@@ -32,3 +37,48 @@ func TestParsePrometheusMetrics(t *testing.T) {
 
 	assert.Equal(t, 30, len(metrics))
 }
+
+type fakeNotifier struct {
+	events []model.NotificationEvent
+}
+
+func (n *fakeNotifier) Notify(event model.NotificationEvent) {
+	n.events = append(n.events, event)
+}
+
+func TestUpdateConnectionStatus(t *testing.T) {
+	var (
+		n   = &fakeNotifier{}
+		now = time.Unix(1600000000, 0)
+		r   = &reporter{
+			notifier:   n,
+			pipedID:    "piped-id",
+			lastSeenAt: now,
+			logger:     zap.NewNop(),
+		}
+		errUnavailable = errors.New("unavailable")
+	)
+
+	// The offline event is sent only once when the failures reach the threshold.
+	for i := 1; i <= offlineThreshold+2; i++ {
+		r.updateConnectionStatus(errUnavailable, now.Add(time.Duration(i)*time.Minute))
+	}
+	require.Equal(t, 1, len(n.events))
+	assert.Equal(t, model.NotificationEventType_EVENT_PIPED_OFFLINE, n.events[0].Type)
+	offline := n.events[0].Metadata.(*model.NotificationEventPipedOffline)
+	assert.Equal(t, "piped-id", offline.Id)
+	assert.Equal(t, now.Unix(), offline.LastSeenAt)
+	assert.Equal(t, "unavailable", offline.Reason)
+
+	// The online event is sent when the connection is recovered.
+	r.updateConnectionStatus(nil, now.Add(10*time.Minute))
+	require.Equal(t, 2, len(n.events))
+	assert.Equal(t, model.NotificationEventType_EVENT_PIPED_ONLINE, n.events[1].Type)
+	online := n.events[1].Metadata.(*model.NotificationEventPipedOnline)
+	assert.Equal(t, now.Add(offlineThreshold*time.Minute).Unix(), online.OfflineSince)
+
+	// No event for a transient failure.
+	r.updateConnectionStatus(errUnavailable, now.Add(11*time.Minute))
+	r.updateConnectionStatus(nil, now.Add(12*time.Minute))
+	assert.Equal(t, 2, len(n.events))
+}
@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"text/template"
 
 	"go.uber.org/zap"
@@ -30,14 +29,30 @@ const (
 	defaultKubectlVersion   = "1.18.2"
 	defaultKustomizeVersion = "3.8.1"
 	defaultHelmVersion      = "3.2.1"
+	defaultHelmfileVersion  = "0.138.7"
+	defaultJsonnetVersion   = "0.17.0"
+	defaultJBVersion        = "0.4.0"
+	defaultYttVersion       = "0.38.0"
+	defaultKappVersion      = "0.42.0"
+	defaultSopsVersion      = "3.7.1"
 	defaultTerraformVersion = "0.13.0"
+
+	defaultAWSIAMAuthenticatorVersion = "0.5.3"
 )
 
 var (
 	kubectlInstallScriptTmpl   = template.Must(template.New("kubectl").Parse(kubectlInstallScript))
 	kustomizeInstallScriptTmpl = template.Must(template.New("kustomize").Parse(kustomizeInstallScript))
 	helmInstallScriptTmpl      = template.Must(template.New("helm").Parse(helmInstallScript))
+	helmfileInstallScriptTmpl  = template.Must(template.New("helmfile").Parse(helmfileInstallScript))
+	jsonnetInstallScriptTmpl   = template.Must(template.New("jsonnet").Parse(jsonnetInstallScript))
+	jbInstallScriptTmpl        = template.Must(template.New("jb").Parse(jbInstallScript))
+	yttInstallScriptTmpl       = template.Must(template.New("ytt").Parse(yttInstallScript))
+	kappInstallScriptTmpl      = template.Must(template.New("kapp").Parse(kappInstallScript))
+	sopsInstallScriptTmpl      = template.Must(template.New("sops").Parse(sopsInstallScript))
 	terraformInstallScriptTmpl = template.Must(template.New("terraform").Parse(terraformInstallScript))
+
+	awsIAMAuthenticatorInstallScriptTmpl = template.Must(template.New("aws-iam-authenticator").Parse(awsIAMAuthenticatorInstallScript))
 )
 
 func (r *registry) installKubectl(ctx context.Context, version string) error {
@@ -71,7 +86,7 @@ func (r *registry) installKubectl(ctx context.Context, version string) error {
 
 	var (
 		script = buf.String()
-		cmd    = exec.CommandContext(ctx, "/bin/sh", "-c", script)
+		cmd    = installCommand(ctx, script)
 	)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		r.logger.Error("failed to install kubectl",
@@ -118,7 +133,7 @@ func (r *registry) installKustomize(ctx context.Context, version string) error {
 
 	var (
 		script = buf.String()
-		cmd    = exec.CommandContext(ctx, "/bin/sh", "-c", script)
+		cmd    = installCommand(ctx, script)
 	)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		r.logger.Error("failed to install kustomize",
@@ -165,7 +180,7 @@ func (r *registry) installHelm(ctx context.Context, version string) error {
 
 	var (
 		script = buf.String()
-		cmd    = exec.CommandContext(ctx, "/bin/sh", "-c", script)
+		cmd    = installCommand(ctx, script)
 	)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		r.logger.Error("failed to install helm",
@@ -181,6 +196,288 @@ func (r *registry) installHelm(ctx context.Context, version string) error {
 	return nil
 }
 
+func (r *registry) installHelmfile(ctx context.Context, version string) error {
+	workingDir, err := ioutil.TempDir("", "helmfile-install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	asDefault := version == ""
+	if asDefault {
+		version = defaultHelmfileVersion
+	}
+
+	var (
+		buf  bytes.Buffer
+		data = map[string]interface{}{
+			"WorkingDir": workingDir,
+			"Version":    version,
+			"BinDir":     r.binDir,
+			"AsDefault":  asDefault,
+		}
+	)
+	if err := helmfileInstallScriptTmpl.Execute(&buf, data); err != nil {
+		r.logger.Error("failed to render helmfile install script",
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install helmfile %s (%v)", version, err)
+	}
+
+	var (
+		script = buf.String()
+		cmd    = installCommand(ctx, script)
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.logger.Error("failed to install helmfile",
+			zap.String("version", version),
+			zap.String("script", script),
+			zap.String("out", string(out)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install helmfile %s (%v)", version, err)
+	}
+
+	r.logger.Info("just installed helmfile", zap.String("version", version))
+	return nil
+}
+
+func (r *registry) installJsonnet(ctx context.Context, version string) error {
+	workingDir, err := ioutil.TempDir("", "jsonnet-install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	asDefault := version == ""
+	if asDefault {
+		version = defaultJsonnetVersion
+	}
+
+	var (
+		buf  bytes.Buffer
+		data = map[string]interface{}{
+			"WorkingDir": workingDir,
+			"Version":    version,
+			"BinDir":     r.binDir,
+			"AsDefault":  asDefault,
+		}
+	)
+	if err := jsonnetInstallScriptTmpl.Execute(&buf, data); err != nil {
+		r.logger.Error("failed to render jsonnet install script",
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install jsonnet %s (%v)", version, err)
+	}
+
+	var (
+		script = buf.String()
+		cmd    = installCommand(ctx, script)
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.logger.Error("failed to install jsonnet",
+			zap.String("version", version),
+			zap.String("script", script),
+			zap.String("out", string(out)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install jsonnet %s (%v)", version, err)
+	}
+
+	r.logger.Info("just installed jsonnet", zap.String("version", version))
+	return nil
+}
+
+func (r *registry) installJsonnetBundler(ctx context.Context, version string) error {
+	workingDir, err := ioutil.TempDir("", "jb-install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	asDefault := version == ""
+	if asDefault {
+		version = defaultJBVersion
+	}
+
+	var (
+		buf  bytes.Buffer
+		data = map[string]interface{}{
+			"WorkingDir": workingDir,
+			"Version":    version,
+			"BinDir":     r.binDir,
+			"AsDefault":  asDefault,
+		}
+	)
+	if err := jbInstallScriptTmpl.Execute(&buf, data); err != nil {
+		r.logger.Error("failed to render jsonnet-bundler install script",
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install jsonnet-bundler %s (%v)", version, err)
+	}
+
+	var (
+		script = buf.String()
+		cmd    = installCommand(ctx, script)
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.logger.Error("failed to install jsonnet-bundler",
+			zap.String("version", version),
+			zap.String("script", script),
+			zap.String("out", string(out)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install jsonnet-bundler %s (%v)", version, err)
+	}
+
+	r.logger.Info("just installed jsonnet-bundler", zap.String("version", version))
+	return nil
+}
+
+func (r *registry) installYtt(ctx context.Context, version string) error {
+	workingDir, err := ioutil.TempDir("", "ytt-install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	asDefault := version == ""
+	if asDefault {
+		version = defaultYttVersion
+	}
+
+	var (
+		buf  bytes.Buffer
+		data = map[string]interface{}{
+			"WorkingDir": workingDir,
+			"Version":    version,
+			"BinDir":     r.binDir,
+			"AsDefault":  asDefault,
+		}
+	)
+	if err := yttInstallScriptTmpl.Execute(&buf, data); err != nil {
+		r.logger.Error("failed to render ytt install script",
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install ytt %s (%v)", version, err)
+	}
+
+	var (
+		script = buf.String()
+		cmd    = installCommand(ctx, script)
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.logger.Error("failed to install ytt",
+			zap.String("version", version),
+			zap.String("script", script),
+			zap.String("out", string(out)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install ytt %s (%v)", version, err)
+	}
+
+	r.logger.Info("just installed ytt", zap.String("version", version))
+	return nil
+}
+
+func (r *registry) installKapp(ctx context.Context, version string) error {
+	workingDir, err := ioutil.TempDir("", "kapp-install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	asDefault := version == ""
+	if asDefault {
+		version = defaultKappVersion
+	}
+
+	var (
+		buf  bytes.Buffer
+		data = map[string]interface{}{
+			"WorkingDir": workingDir,
+			"Version":    version,
+			"BinDir":     r.binDir,
+			"AsDefault":  asDefault,
+		}
+	)
+	if err := kappInstallScriptTmpl.Execute(&buf, data); err != nil {
+		r.logger.Error("failed to render kapp install script",
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install kapp %s (%v)", version, err)
+	}
+
+	var (
+		script = buf.String()
+		cmd    = installCommand(ctx, script)
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.logger.Error("failed to install kapp",
+			zap.String("version", version),
+			zap.String("script", script),
+			zap.String("out", string(out)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install kapp %s (%v)", version, err)
+	}
+
+	r.logger.Info("just installed kapp", zap.String("version", version))
+	return nil
+}
+
+func (r *registry) installSops(ctx context.Context, version string) error {
+	workingDir, err := ioutil.TempDir("", "sops-install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	asDefault := version == ""
+	if asDefault {
+		version = defaultSopsVersion
+	}
+
+	var (
+		buf  bytes.Buffer
+		data = map[string]interface{}{
+			"WorkingDir": workingDir,
+			"Version":    version,
+			"BinDir":     r.binDir,
+			"AsDefault":  asDefault,
+		}
+	)
+	if err := sopsInstallScriptTmpl.Execute(&buf, data); err != nil {
+		r.logger.Error("failed to render sops install script",
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install sops %s (%v)", version, err)
+	}
+
+	var (
+		script = buf.String()
+		cmd    = installCommand(ctx, script)
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.logger.Error("failed to install sops",
+			zap.String("version", version),
+			zap.String("script", script),
+			zap.String("out", string(out)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install sops %s (%v)", version, err)
+	}
+
+	r.logger.Info("just installed sops", zap.String("version", version))
+	return nil
+}
+
 func (r *registry) installTerraform(ctx context.Context, version string) error {
 	workingDir, err := ioutil.TempDir("", "terraform-install")
 	if err != nil {
@@ -212,7 +509,7 @@ func (r *registry) installTerraform(ctx context.Context, version string) error {
 
 	var (
 		script = buf.String()
-		cmd    = exec.CommandContext(ctx, "/bin/sh", "-c", script)
+		cmd    = installCommand(ctx, script)
 	)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		r.logger.Error("failed to install terraform",
@@ -227,3 +524,50 @@ func (r *registry) installTerraform(ctx context.Context, version string) error {
 	r.logger.Info("just installed terraform", zap.String("version", version))
 	return nil
 }
+
+func (r *registry) installAWSIAMAuthenticator(ctx context.Context, version string) error {
+	workingDir, err := ioutil.TempDir("", "aws-iam-authenticator-install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	asDefault := version == ""
+	if asDefault {
+		version = defaultAWSIAMAuthenticatorVersion
+	}
+
+	var (
+		buf  bytes.Buffer
+		data = map[string]interface{}{
+			"WorkingDir": workingDir,
+			"Version":    version,
+			"BinDir":     r.binDir,
+			"AsDefault":  asDefault,
+		}
+	)
+	if err := awsIAMAuthenticatorInstallScriptTmpl.Execute(&buf, data); err != nil {
+		r.logger.Error("failed to render aws-iam-authenticator install script",
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install aws-iam-authenticator %s (%w)", version, err)
+	}
+
+	var (
+		script = buf.String()
+		cmd    = installCommand(ctx, script)
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.logger.Error("failed to install aws-iam-authenticator",
+			zap.String("version", version),
+			zap.String("script", script),
+			zap.String("out", string(out)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to install aws-iam-authenticator %s, %s (%w)", version, string(out), err)
+	}
+
+	r.logger.Info("just installed aws-iam-authenticator", zap.String("version", version))
+	return nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package toolregistry
+
+import (
+	"context"
+	"os/exec"
+)
+
+// binaryExt is the file extension of the executables.
+const binaryExt = ""
+
+func installCommand(ctx context.Context, script string) *exec.Cmd {
+	return exec.CommandContext(ctx, "/bin/sh", "-c", script)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"go.uber.org/zap"
@@ -32,7 +33,14 @@ type Registry interface {
 	Kubectl(ctx context.Context, version string) (string, bool, error)
 	Kustomize(ctx context.Context, version string) (string, bool, error)
 	Helm(ctx context.Context, version string) (string, bool, error)
+	Helmfile(ctx context.Context, version string) (string, bool, error)
+	Jsonnet(ctx context.Context, version string) (string, bool, error)
+	JsonnetBundler(ctx context.Context, version string) (string, bool, error)
+	Ytt(ctx context.Context, version string) (string, bool, error)
+	Kapp(ctx context.Context, version string) (string, bool, error)
+	Sops(ctx context.Context, version string) (string, bool, error)
 	Terraform(ctx context.Context, version string) (string, bool, error)
+	AWSIAMAuthenticator(ctx context.Context, version string) (string, bool, error)
 }
 
 var defaultRegistry *registry
@@ -50,6 +58,12 @@ func InitDefaultRegistry(binDir string, logger *zap.Logger) error {
 		return err
 	}
 
+	// Make the tools in binDir executable by their name from other tools.
+	// e.g. kubectl executes the exec credential plugin specified in kubeconfig.
+	if err := addToPath(binDir); err != nil {
+		return err
+	}
+
 	tools, err := loadPreinstalledTool(binDir)
 	if err != nil {
 		return err
@@ -66,6 +80,19 @@ func InitDefaultRegistry(binDir string, logger *zap.Logger) error {
 	return nil
 }
 
+func addToPath(dir string) error {
+	path := os.Getenv("PATH")
+	for _, p := range filepath.SplitList(path) {
+		if p == dir {
+			return nil
+		}
+	}
+	if path == "" {
+		return os.Setenv("PATH", dir)
+	}
+	return os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+}
+
 func loadPreinstalledTool(binDir string) (map[string]struct{}, error) {
 	tools := make(map[string]struct{})
 	err := filepath.Walk(binDir, func(path string, info os.FileInfo, err error) error {
@@ -81,7 +108,8 @@ func loadPreinstalledTool(binDir string) (map[string]struct{}, error) {
 		if !info.Mode().IsRegular() {
 			return nil
 		}
-		name := filepath.Base(path)
+		// The versions are keyed by the name without the extension of executables.
+		name := strings.TrimSuffix(filepath.Base(path), binaryExt)
 		tools[name] = struct{}{}
 		return nil
 	})
@@ -95,7 +123,15 @@ const (
 	kubectlPrefix   = "kubectl"
 	kustomizePrefix = "kustomize"
 	helmPrefix      = "helm"
+	helmfilePrefix  = "helmfile"
+	jsonnetPrefix   = "jsonnet"
+	jbPrefix        = "jb"
+	yttPrefix       = "ytt"
+	kappPrefix      = "kapp"
+	sopsPrefix      = "sops"
 	terraformPrefix = "terraform"
+
+	awsIAMAuthenticatorPrefix = "aws-iam-authenticator"
 )
 
 type registry struct {
@@ -111,7 +147,7 @@ func (r *registry) Kubectl(ctx context.Context, version string) (string, bool, e
 	if version != "" {
 		name = fmt.Sprintf("%s-%s", kubectlPrefix, version)
 	}
-	path := filepath.Join(r.binDir, name)
+	path := filepath.Join(r.binDir, name+binaryExt)
 
 	r.mu.RLock()
 	_, ok := r.versions[name]
@@ -139,7 +175,7 @@ func (r *registry) Kustomize(ctx context.Context, version string) (string, bool,
 	if version != "" {
 		name = fmt.Sprintf("%s-%s", kustomizePrefix, version)
 	}
-	path := filepath.Join(r.binDir, name)
+	path := filepath.Join(r.binDir, name+binaryExt)
 
 	r.mu.RLock()
 	_, ok := r.versions[name]
@@ -167,7 +203,7 @@ func (r *registry) Helm(ctx context.Context, version string) (string, bool, erro
 	if version != "" {
 		name = fmt.Sprintf("%s-%s", helmPrefix, version)
 	}
-	path := filepath.Join(r.binDir, name)
+	path := filepath.Join(r.binDir, name+binaryExt)
 
 	r.mu.RLock()
 	_, ok := r.versions[name]
@@ -190,12 +226,180 @@ func (r *registry) Helm(ctx context.Context, version string) (string, bool, erro
 	return path, true, nil
 }
 
+func (r *registry) Helmfile(ctx context.Context, version string) (string, bool, error) {
+	name := helmfilePrefix
+	if version != "" {
+		name = fmt.Sprintf("%s-%s", helmfilePrefix, version)
+	}
+	path := filepath.Join(r.binDir, name+binaryExt)
+
+	r.mu.RLock()
+	_, ok := r.versions[name]
+	r.mu.RUnlock()
+	if ok {
+		return path, false, nil
+	}
+
+	_, err, _ := r.installGroup.Do(name, func() (interface{}, error) {
+		return nil, r.installHelmfile(ctx, version)
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	r.mu.Lock()
+	r.versions[name] = struct{}{}
+	r.mu.Unlock()
+
+	return path, true, nil
+}
+
+func (r *registry) Jsonnet(ctx context.Context, version string) (string, bool, error) {
+	name := jsonnetPrefix
+	if version != "" {
+		name = fmt.Sprintf("%s-%s", jsonnetPrefix, version)
+	}
+	path := filepath.Join(r.binDir, name+binaryExt)
+
+	r.mu.RLock()
+	_, ok := r.versions[name]
+	r.mu.RUnlock()
+	if ok {
+		return path, false, nil
+	}
+
+	_, err, _ := r.installGroup.Do(name, func() (interface{}, error) {
+		return nil, r.installJsonnet(ctx, version)
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	r.mu.Lock()
+	r.versions[name] = struct{}{}
+	r.mu.Unlock()
+
+	return path, true, nil
+}
+
+func (r *registry) JsonnetBundler(ctx context.Context, version string) (string, bool, error) {
+	name := jbPrefix
+	if version != "" {
+		name = fmt.Sprintf("%s-%s", jbPrefix, version)
+	}
+	path := filepath.Join(r.binDir, name+binaryExt)
+
+	r.mu.RLock()
+	_, ok := r.versions[name]
+	r.mu.RUnlock()
+	if ok {
+		return path, false, nil
+	}
+
+	_, err, _ := r.installGroup.Do(name, func() (interface{}, error) {
+		return nil, r.installJsonnetBundler(ctx, version)
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	r.mu.Lock()
+	r.versions[name] = struct{}{}
+	r.mu.Unlock()
+
+	return path, true, nil
+}
+
+func (r *registry) Ytt(ctx context.Context, version string) (string, bool, error) {
+	name := yttPrefix
+	if version != "" {
+		name = fmt.Sprintf("%s-%s", yttPrefix, version)
+	}
+	path := filepath.Join(r.binDir, name+binaryExt)
+
+	r.mu.RLock()
+	_, ok := r.versions[name]
+	r.mu.RUnlock()
+	if ok {
+		return path, false, nil
+	}
+
+	_, err, _ := r.installGroup.Do(name, func() (interface{}, error) {
+		return nil, r.installYtt(ctx, version)
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	r.mu.Lock()
+	r.versions[name] = struct{}{}
+	r.mu.Unlock()
+
+	return path, true, nil
+}
+
+func (r *registry) Kapp(ctx context.Context, version string) (string, bool, error) {
+	name := kappPrefix
+	if version != "" {
+		name = fmt.Sprintf("%s-%s", kappPrefix, version)
+	}
+	path := filepath.Join(r.binDir, name+binaryExt)
+
+	r.mu.RLock()
+	_, ok := r.versions[name]
+	r.mu.RUnlock()
+	if ok {
+		return path, false, nil
+	}
+
+	_, err, _ := r.installGroup.Do(name, func() (interface{}, error) {
+		return nil, r.installKapp(ctx, version)
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	r.mu.Lock()
+	r.versions[name] = struct{}{}
+	r.mu.Unlock()
+
+	return path, true, nil
+}
+
+func (r *registry) Sops(ctx context.Context, version string) (string, bool, error) {
+	name := sopsPrefix
+	if version != "" {
+		name = fmt.Sprintf("%s-%s", sopsPrefix, version)
+	}
+	path := filepath.Join(r.binDir, name+binaryExt)
+
+	r.mu.RLock()
+	_, ok := r.versions[name]
+	r.mu.RUnlock()
+	if ok {
+		return path, false, nil
+	}
+
+	_, err, _ := r.installGroup.Do(name, func() (interface{}, error) {
+		return nil, r.installSops(ctx, version)
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	r.mu.Lock()
+	r.versions[name] = struct{}{}
+	r.mu.Unlock()
+
+	return path, true, nil
+}
+
 func (r *registry) Terraform(ctx context.Context, version string) (string, bool, error) {
 	name := terraformPrefix
 	if version != "" {
 		name = fmt.Sprintf("%s-%s", terraformPrefix, version)
 	}
-	path := filepath.Join(r.binDir, name)
+	path := filepath.Join(r.binDir, name+binaryExt)
 
 	r.mu.RLock()
 	_, ok := r.versions[name]
@@ -217,3 +421,31 @@ func (r *registry) Terraform(ctx context.Context, version string) (string, bool,
 
 	return path, true, nil
 }
+
+func (r *registry) AWSIAMAuthenticator(ctx context.Context, version string) (string, bool, error) {
+	name := awsIAMAuthenticatorPrefix
+	if version != "" {
+		name = fmt.Sprintf("%s-%s", awsIAMAuthenticatorPrefix, version)
+	}
+	path := filepath.Join(r.binDir, name+binaryExt)
+
+	r.mu.RLock()
+	_, ok := r.versions[name]
+	r.mu.RUnlock()
+	if ok {
+		return path, false, nil
+	}
+
+	_, err, _ := r.installGroup.Do(name, func() (interface{}, error) {
+		return nil, r.installAWSIAMAuthenticator(ctx, version)
+	})
+	if err != nil {
+		return "", true, err
+	}
+
+	r.mu.Lock()
+	r.versions[name] = struct{}{}
+	r.mu.Unlock()
+
+	return path, true, nil
+}
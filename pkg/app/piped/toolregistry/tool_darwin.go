@@ -44,6 +44,66 @@ cp -f {{ .BinDir }}/helm-{{ .Version }} {{ .BinDir }}/helm
 {{ end }}
 `
 
+var helmfileInstallScript = `
+cd {{ .WorkingDir }}
+curl -L https://github.com/roboll/helmfile/releases/download/v{{ .Version }}/helmfile_darwin_amd64 -o helmfile
+mv helmfile {{ .BinDir }}/helmfile-{{ .Version }}
+chmod +x {{ .BinDir }}/helmfile-{{ .Version }}
+{{ if .AsDefault }}
+cp -f {{ .BinDir }}/helmfile-{{ .Version }} {{ .BinDir }}/helmfile
+{{ end }}
+`
+
+var jsonnetInstallScript = `
+cd {{ .WorkingDir }}
+curl -L https://github.com/google/go-jsonnet/releases/download/v{{ .Version }}/go-jsonnet_{{ .Version }}_Darwin_x86_64.tar.gz | tar xvz
+mv jsonnet {{ .BinDir }}/jsonnet-{{ .Version }}
+chmod +x {{ .BinDir }}/jsonnet-{{ .Version }}
+{{ if .AsDefault }}
+cp -f {{ .BinDir }}/jsonnet-{{ .Version }} {{ .BinDir }}/jsonnet
+{{ end }}
+`
+
+var jbInstallScript = `
+cd {{ .WorkingDir }}
+curl -L https://github.com/jsonnet-bundler/jsonnet-bundler/releases/download/v{{ .Version }}/jb-darwin-amd64 -o jb
+mv jb {{ .BinDir }}/jb-{{ .Version }}
+chmod +x {{ .BinDir }}/jb-{{ .Version }}
+{{ if .AsDefault }}
+cp -f {{ .BinDir }}/jb-{{ .Version }} {{ .BinDir }}/jb
+{{ end }}
+`
+
+var yttInstallScript = `
+cd {{ .WorkingDir }}
+curl -L https://github.com/vmware-tanzu/carvel-ytt/releases/download/v{{ .Version }}/ytt-darwin-amd64 -o ytt
+mv ytt {{ .BinDir }}/ytt-{{ .Version }}
+chmod +x {{ .BinDir }}/ytt-{{ .Version }}
+{{ if .AsDefault }}
+cp -f {{ .BinDir }}/ytt-{{ .Version }} {{ .BinDir }}/ytt
+{{ end }}
+`
+
+var kappInstallScript = `
+cd {{ .WorkingDir }}
+curl -L https://github.com/vmware-tanzu/carvel-kapp/releases/download/v{{ .Version }}/kapp-darwin-amd64 -o kapp
+mv kapp {{ .BinDir }}/kapp-{{ .Version }}
+chmod +x {{ .BinDir }}/kapp-{{ .Version }}
+{{ if .AsDefault }}
+cp -f {{ .BinDir }}/kapp-{{ .Version }} {{ .BinDir }}/kapp
+{{ end }}
+`
+
+var sopsInstallScript = `
+cd {{ .WorkingDir }}
+curl -L https://github.com/mozilla/sops/releases/download/v{{ .Version }}/sops-v{{ .Version }}.darwin -o sops
+mv sops {{ .BinDir }}/sops-{{ .Version }}
+chmod +x {{ .BinDir }}/sops-{{ .Version }}
+{{ if .AsDefault }}
+cp -f {{ .BinDir }}/sops-{{ .Version }} {{ .BinDir }}/sops
+{{ end }}
+`
+
 var terraformInstallScript = `
 cd {{ .WorkingDir }}
 curl https://releases.hashicorp.com/terraform/{{ .Version }}/terraform_{{ .Version }}_darwin_amd64.zip -o terraform_{{ .Version }}_linux_amd64.zip
@@ -53,3 +113,13 @@ mv terraform {{ .BinDir }}/terraform-{{ .Version }}
 cp -f {{ .BinDir }}/terraform-{{ .Version }} {{ .BinDir }}/terraform
 {{ end }}
 `
+
+var awsIAMAuthenticatorInstallScript = `
+cd {{ .WorkingDir }}
+curl -L https://github.com/kubernetes-sigs/aws-iam-authenticator/releases/download/v{{ .Version }}/aws-iam-authenticator_{{ .Version }}_darwin_amd64 -o aws-iam-authenticator
+mv aws-iam-authenticator {{ .BinDir }}/aws-iam-authenticator-{{ .Version }}
+chmod +x {{ .BinDir }}/aws-iam-authenticator-{{ .Version }}
+{{ if .AsDefault }}
+cp -f {{ .BinDir }}/aws-iam-authenticator-{{ .Version }} {{ .BinDir }}/aws-iam-authenticator
+{{ end }}
+`
@@ -0,0 +1,143 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolregistry
+
+// The following scripts are executed by PowerShell.
+// Windows 10 1803 or later is required since tar.exe is used to extract the tar.gz archives.
+
+var kubectlInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://storage.googleapis.com/kubernetes-release/release/v{{ .Version }}/bin/windows/amd64/kubectl.exe -OutFile kubectl.exe
+Move-Item -Force kubectl.exe "{{ .BinDir }}\kubectl-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\kubectl-{{ .Version }}.exe" "{{ .BinDir }}\kubectl.exe"
+{{ end }}
+`
+
+var kustomizeInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize/v{{ .Version }}/kustomize_v{{ .Version }}_windows_amd64.tar.gz -OutFile kustomize.tar.gz
+tar xvzf kustomize.tar.gz
+Move-Item -Force kustomize.exe "{{ .BinDir }}\kustomize-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\kustomize-{{ .Version }}.exe" "{{ .BinDir }}\kustomize.exe"
+{{ end }}
+`
+
+var helmInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://get.helm.sh/helm-v{{ .Version }}-windows-amd64.zip -OutFile helm.zip
+Expand-Archive -Force helm.zip .
+Move-Item -Force windows-amd64\helm.exe "{{ .BinDir }}\helm-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\helm-{{ .Version }}.exe" "{{ .BinDir }}\helm.exe"
+{{ end }}
+`
+
+var helmfileInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/roboll/helmfile/releases/download/v{{ .Version }}/helmfile_windows_amd64.exe -OutFile helmfile.exe
+Move-Item -Force helmfile.exe "{{ .BinDir }}\helmfile-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\helmfile-{{ .Version }}.exe" "{{ .BinDir }}\helmfile.exe"
+{{ end }}
+`
+
+var jsonnetInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/google/go-jsonnet/releases/download/v{{ .Version }}/go-jsonnet_{{ .Version }}_Windows_x86_64.tar.gz -OutFile jsonnet.tar.gz
+tar xvzf jsonnet.tar.gz
+Move-Item -Force jsonnet.exe "{{ .BinDir }}\jsonnet-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\jsonnet-{{ .Version }}.exe" "{{ .BinDir }}\jsonnet.exe"
+{{ end }}
+`
+
+var jbInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/jsonnet-bundler/jsonnet-bundler/releases/download/v{{ .Version }}/jb-windows-amd64.exe -OutFile jb.exe
+Move-Item -Force jb.exe "{{ .BinDir }}\jb-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\jb-{{ .Version }}.exe" "{{ .BinDir }}\jb.exe"
+{{ end }}
+`
+
+var yttInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/vmware-tanzu/carvel-ytt/releases/download/v{{ .Version }}/ytt-windows-amd64.exe -OutFile ytt.exe
+Move-Item -Force ytt.exe "{{ .BinDir }}\ytt-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\ytt-{{ .Version }}.exe" "{{ .BinDir }}\ytt.exe"
+{{ end }}
+`
+
+var kappInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/vmware-tanzu/carvel-kapp/releases/download/v{{ .Version }}/kapp-windows-amd64.exe -OutFile kapp.exe
+Move-Item -Force kapp.exe "{{ .BinDir }}\kapp-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\kapp-{{ .Version }}.exe" "{{ .BinDir }}\kapp.exe"
+{{ end }}
+`
+
+var sopsInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/mozilla/sops/releases/download/v{{ .Version }}/sops-v{{ .Version }}.exe -OutFile sops.exe
+Move-Item -Force sops.exe "{{ .BinDir }}\sops-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\sops-{{ .Version }}.exe" "{{ .BinDir }}\sops.exe"
+{{ end }}
+`
+
+var terraformInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://releases.hashicorp.com/terraform/{{ .Version }}/terraform_{{ .Version }}_windows_amd64.zip -OutFile terraform.zip
+Expand-Archive -Force terraform.zip .
+Move-Item -Force terraform.exe "{{ .BinDir }}\terraform-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\terraform-{{ .Version }}.exe" "{{ .BinDir }}\terraform.exe"
+{{ end }}
+`
+
+var awsIAMAuthenticatorInstallScript = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+cd "{{ .WorkingDir }}"
+Invoke-WebRequest -Uri https://github.com/kubernetes-sigs/aws-iam-authenticator/releases/download/v{{ .Version }}/aws-iam-authenticator_{{ .Version }}_windows_amd64.exe -OutFile aws-iam-authenticator.exe
+Move-Item -Force aws-iam-authenticator.exe "{{ .BinDir }}\aws-iam-authenticator-{{ .Version }}.exe"
+{{ if .AsDefault }}
+Copy-Item -Force "{{ .BinDir }}\aws-iam-authenticator-{{ .Version }}.exe" "{{ .BinDir }}\aws-iam-authenticator.exe"
+{{ end }}
+`
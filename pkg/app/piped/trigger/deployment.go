@@ -34,12 +34,27 @@ func (t *Trigger) triggerDeployment(
 	commit git.Commit,
 	commander string,
 	syncStrategy model.SyncStrategy,
+	changedFiles []string,
 ) (deployment *model.Deployment, err error) {
-	deployment, err = buildDeployment(app, branch, commit, commander, syncStrategy, time.Now())
+	deployment, err = buildDeployment(app, branch, commit, commander, syncStrategy, changedFiles, time.Now())
 	if err != nil {
 		return
 	}
 
+	// The labels are used to route the notifications of this deployment
+	// so the invalid configuration should not prevent it from being triggered.
+	if repo, ok := t.gitRepos[app.GitPath.Repo.Id]; ok {
+		if cfg, e := loadDeploymentConfiguration(repo.GetPath(), app); e == nil {
+			spec, _ := cfg.GetGenericDeployment()
+			deployment.Labels = spec.Labels
+		} else {
+			t.logger.Warn("unable to load the deployment configuration to get labels",
+				zap.String("app-id", app.Id),
+				zap.Error(e),
+			)
+		}
+	}
+
 	defer func() {
 		if err != nil {
 			return
@@ -58,13 +73,19 @@ func (t *Trigger) triggerDeployment(
 	}()
 
 	t.logger.Info(fmt.Sprintf("application %s will be triggered to sync", app.Id),
+		zap.String("app-id", app.Id),
+		zap.String("deployment-id", deployment.Id),
 		zap.String("commit-hash", commit.Hash),
 	)
 	req := &pipedservice.CreateDeploymentRequest{
 		Deployment: deployment,
 	}
 	if _, err = t.apiClient.CreateDeployment(ctx, req); err != nil {
-		t.logger.Error("failed to create deployment", zap.Error(err))
+		t.logger.Error("failed to create deployment",
+			zap.String("app-id", app.Id),
+			zap.String("deployment-id", deployment.Id),
+			zap.Error(err),
+		)
 		return
 	}
 
@@ -95,10 +116,11 @@ func (t *Trigger) reportMostRecentlyTriggeredDeployment(ctx context.Context, d *
 		retry = pipedservice.NewRetry(10)
 	)
 
-	for retry.WaitNext(ctx) {
-		if _, err = t.apiClient.ReportApplicationMostRecentDeployment(ctx, req); err == nil {
-			return nil
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		_, err := t.apiClient.ReportApplicationMostRecentDeployment(ctx, req)
+		return err
+	})
+	if err != nil {
 		err = fmt.Errorf("failed to report most recent successful deployment: %w", err)
 	}
 	return err
@@ -110,6 +132,7 @@ func buildDeployment(
 	commit git.Commit,
 	commander string,
 	syncStrategy model.SyncStrategy,
+	changedFiles []string,
 	now time.Time,
 ) (*model.Deployment, error) {
 	commitURL := ""
@@ -141,6 +164,7 @@ func buildDeployment(
 			Commander:    commander,
 			Timestamp:    now.Unix(),
 			SyncStrategy: syncStrategy,
+			ChangedFiles: changedFiles,
 		},
 		GitPath:       app.GitPath,
 		CloudProvider: app.CloudProvider,
@@ -150,5 +174,16 @@ func buildDeployment(
 		UpdatedAt:     now.Unix(),
 	}
 
+	if pr, ok := commit.PullRequest(); ok {
+		c := deployment.Trigger.Commit
+		c.PullRequest = int64(pr.Number)
+		c.PullRequestTitle = pr.Title
+		c.PullRequestAuthor = pr.Author
+		if r := app.GitPath.Repo; r != nil {
+			// The link is just an addition so the unknown remote is not an error here.
+			c.PullRequestUrl, _ = git.MakePullRequestURL(r.Remote, pr.Number)
+		}
+	}
+
 	return deployment, nil
 }
@@ -42,6 +42,8 @@ var (
 
 const (
 	triggeredDeploymentIDKey = "TriggeredDeploymentID"
+	// The maximum number of changed files recorded in a deployment.
+	maxTouchedFiles = 100
 )
 
 type apiClient interface {
@@ -61,6 +63,7 @@ type applicationLister interface {
 
 type commandLister interface {
 	ListApplicationCommands() []model.ReportableCommand
+	Updated() <-chan struct{}
 }
 
 type environmentLister interface {
@@ -80,9 +83,14 @@ type Trigger struct {
 	notifier                     notifier
 	config                       *config.PipedSpec
 	mostRecentlyTriggeredCommits map[string]string
-	gitRepos                     map[string]git.Repo
-	gracePeriod                  time.Duration
-	logger                       *zap.Logger
+	// The most recently checked commits of the repositories containing
+	// the external value files, keyed by application ID and repository ID.
+	remoteValueFileCommits map[string]string
+	// The head commits of the repositories updated in the current check.
+	checkedHeadCommits map[string]git.Commit
+	gitRepos           map[string]git.Repo
+	gracePeriod        time.Duration
+	logger             *zap.Logger
 }
 
 // NewTrigger creates a new instance for Trigger.
@@ -107,6 +115,7 @@ func NewTrigger(
 		notifier:                     notifier,
 		config:                       cfg,
 		mostRecentlyTriggeredCommits: make(map[string]string),
+		remoteValueFileCommits:       make(map[string]string),
 		gitRepos:                     make(map[string]git.Repo, len(cfg.Repositories)),
 		gracePeriod:                  gracePeriod,
 		logger:                       logger.Named("trigger"),
@@ -145,6 +154,9 @@ L:
 		case <-commandTicker.C:
 			t.checkCommand(ctx)
 
+		case <-t.commandLister.Updated():
+			t.checkCommand(ctx)
+
 		case <-commitTicker.C:
 			t.checkCommit(ctx)
 
@@ -179,7 +191,7 @@ func (t *Trigger) checkCommand(ctx context.Context) error {
 				zap.String("app-id", app.Id),
 				zap.Error(err),
 			)
-			if err := cmd.Report(ctx, model.CommandStatus_COMMAND_FAILED, nil); err != nil {
+			if err := cmd.Report(ctx, model.CommandStatus_COMMAND_FAILED, nil, nil); err != nil {
 				t.logger.Error("failed to report command status", zap.Error(err))
 			}
 			continue
@@ -188,7 +200,7 @@ func (t *Trigger) checkCommand(ctx context.Context) error {
 		metadata := map[string]string{
 			triggeredDeploymentIDKey: d.Id,
 		}
-		if err := cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, metadata); err != nil {
+		if err := cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, metadata, nil); err != nil {
 			t.logger.Error("failed to report command status", zap.Error(err))
 		}
 	}
@@ -203,9 +215,10 @@ func (t *Trigger) syncApplication(ctx context.Context, app *model.Application, c
 
 	// Build deployment model and send a request to API to create a new deployment.
 	t.logger.Info(fmt.Sprintf("application %s will be synced because of a sync command", app.Id),
+		zap.String("app-id", app.Id),
 		zap.String("head-commit", headCommit.Hash),
 	)
-	d, err := t.triggerDeployment(ctx, app, branch, headCommit, commander, syncStrategy)
+	d, err := t.triggerDeployment(ctx, app, branch, headCommit, commander, syncStrategy, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -223,6 +236,7 @@ func (t *Trigger) checkCommit(ctx context.Context) error {
 	// List all applications that should be handled by this piped
 	// and then group them by repository.
 	var applications = t.listApplications()
+	t.checkedHeadCommits = make(map[string]git.Commit)
 
 	// ENHANCEMENT: We may want to apply worker model here to run them concurrently.
 	for repoID, apps := range applications {
@@ -230,9 +244,13 @@ func (t *Trigger) checkCommit(ctx context.Context) error {
 		if err != nil {
 			continue
 		}
+		t.checkedHeadCommits[repoID] = headCommit
 		for _, app := range apps {
 			if err := t.checkApplication(ctx, app, gitRepo, branch, headCommit); err != nil {
-				t.logger.Error(fmt.Sprintf("failed to check application: %s", app.Id), zap.Error(err))
+				t.logger.Error(fmt.Sprintf("failed to check application: %s", app.Id),
+					zap.String("app-id", app.Id),
+					zap.Error(err),
+				)
 			}
 		}
 	}
@@ -267,29 +285,43 @@ func (t *Trigger) checkApplication(ctx context.Context, app *model.Application,
 		}
 	}
 
-	// Check whether the most recently applied one is the head commit or not.
-	// If so, nothing to do for this time.
-	if headCommit.Hash == preCommitHash {
-		logger.Info(fmt.Sprintf("no update to sync for application, hash: %s", headCommit.Hash))
-		return nil
+	// The external value files placing in other repositories can be changed
+	// without any new commit to the repository of this application.
+	remoteTouchedFiles, remoteHeads := t.findTouchedRemoteValueFiles(ctx, app, repo)
+	recordRemoteHeads := func() {
+		for repoID, hash := range remoteHeads {
+			t.remoteValueFileCommits[app.Id+"/"+repoID] = hash
+		}
 	}
 
-	trigger := func() error {
+	trigger := func(changedFiles []string) error {
 		// Build deployment model and send a request to API to create a new deployment.
 		logger.Info("application should be synced because of the new commit",
 			zap.String("most-recently-triggered-commit", preCommitHash),
 		)
-		if _, err := t.triggerDeployment(ctx, app, branch, headCommit, "", model.SyncStrategy_AUTO); err != nil {
+		if _, err := t.triggerDeployment(ctx, app, branch, headCommit, "", model.SyncStrategy_AUTO, changedFiles); err != nil {
 			return err
 		}
 		t.mostRecentlyTriggeredCommits[app.Id] = headCommit.Hash
+		recordRemoteHeads()
+		return nil
+	}
+
+	// Check whether the most recently applied one is the head commit or not.
+	// If so, nothing to do for this time unless any remote value file was changed.
+	if headCommit.Hash == preCommitHash {
+		if len(remoteTouchedFiles) > 0 {
+			return trigger(remoteTouchedFiles)
+		}
+		recordRemoteHeads()
+		logger.Info(fmt.Sprintf("no update to sync for application, hash: %s", headCommit.Hash))
 		return nil
 	}
 
 	// There is no previous deployment so we don't need to check anymore.
 	// Just do it.
 	if preCommitHash == "" {
-		return trigger()
+		return trigger(nil)
 	}
 
 	// List the changed files between those two commits and
@@ -304,19 +336,88 @@ func (t *Trigger) checkApplication(ctx context.Context, app *model.Application,
 		return err
 	}
 
-	touched, err := isTouchedByChangedFiles(app.GitPath.Path, deployConfig.TriggerPaths, changedFiles)
+	touchedFiles, err := findTouchedFiles(app.GitPath.Path, triggerPaths(deployConfig, t.repoRemote(app.GitPath.Repo.Id)), changedFiles)
 	if err != nil {
 		return err
 	}
-	if !touched {
+	touchedFiles = append(touchedFiles, remoteTouchedFiles...)
+	if len(touchedFiles) == 0 {
 		logger.Info("application was not touched by the new commit",
 			zap.String("most-recently-triggered-commit", preCommitHash),
 		)
 		t.mostRecentlyTriggeredCommits[app.Id] = headCommit.Hash
+		recordRemoteHeads()
 		return nil
 	}
 
-	return trigger()
+	return trigger(touchedFiles)
+}
+
+// findTouchedRemoteValueFiles returns the external value files of the given application
+// placing in other registered repositories those were changed since the last check,
+// together with the checked head commits of those repositories keyed by repository ID.
+// The changes are not detected at the first check after starting
+// because no commit was recorded yet.
+func (t *Trigger) findTouchedRemoteValueFiles(ctx context.Context, app *model.Application, repo git.Repo) ([]string, map[string]string) {
+	if app.Kind != model.ApplicationKind_KUBERNETES {
+		return nil, nil
+	}
+	// The invalid configuration is reported while handling the new commits.
+	cfg, err := loadDeploymentConfiguration(repo.GetPath(), app)
+	if err != nil {
+		return nil, nil
+	}
+	files := cfg.KubernetesDeploymentSpec.Input.HelmOptions.RemoteValueFiles(t.repoRemote(app.GitPath.Repo.Id))
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var (
+		touched []string
+		heads   = make(map[string]string, len(files))
+	)
+	for _, f := range files {
+		r, ok := t.config.GetRepositoryByRemote(f.GitRemote)
+		if !ok {
+			continue
+		}
+		head, ok := t.checkedHeadCommits[r.RepoID]
+		if !ok {
+			if _, _, head, err = t.updateRepoToLatest(ctx, r.RepoID); err != nil {
+				continue
+			}
+			t.checkedHeadCommits[r.RepoID] = head
+		}
+		heads[r.RepoID] = head.Hash
+
+		pre := t.remoteValueFileCommits[app.Id+"/"+r.RepoID]
+		if pre == "" || pre == head.Hash {
+			continue
+		}
+		changedFiles, err := t.gitRepos[r.RepoID].ChangedFiles(ctx, pre, head.Hash)
+		if err != nil {
+			t.logger.Error("failed to list changed files of repository containing remote value file",
+				zap.String("repo-id", r.RepoID),
+				zap.Error(err),
+			)
+			delete(heads, r.RepoID)
+			continue
+		}
+		path := filepath.Clean(f.Path)
+		for _, cf := range changedFiles {
+			if cf == path {
+				touched = append(touched, cf)
+				break
+			}
+		}
+	}
+	return touched, heads
+}
+
+// repoRemote returns the remote address of the registered repository with the given ID.
+func (t *Trigger) repoRemote(repoID string) string {
+	r, _ := t.config.GetRepository(repoID)
+	return r.Remote
 }
 
 func (t *Trigger) updateRepoToLatest(ctx context.Context, repoID string) (repo git.Repo, branch string, headCommit git.Commit, err error) {
@@ -387,18 +488,18 @@ func (t *Trigger) getMostRecentlyTriggeredDeployment(ctx context.Context, applic
 		}
 	)
 
-	for retry.WaitNext(ctx) {
-		if resp, err = t.apiClient.GetApplicationMostRecentDeployment(ctx, req); err == nil {
-			return resp.Deployment, nil
-		}
-		if !pipedservice.Retriable(err) {
-			return nil, err
-		}
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = t.apiClient.GetApplicationMostRecentDeployment(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, err
+	return resp.Deployment, nil
 }
 
-func loadDeploymentConfiguration(repoPath string, app *model.Application) (*config.GenericDeploymentSpec, error) {
+func loadDeploymentConfiguration(repoPath string, app *model.Application) (*config.Config, error) {
 	path := filepath.Join(repoPath, app.GitPath.GetDeploymentConfigFilePath())
 	cfg, err := config.LoadFromYAML(path)
 	if err != nil {
@@ -408,38 +509,66 @@ func loadDeploymentConfiguration(repoPath string, app *model.Application) (*conf
 		return nil, fmt.Errorf("invalid application kind in the deployment config file, got: %s, expected: %s", appKind, app.Kind)
 	}
 
-	spec, ok := cfg.GetGenericDeployment()
-	if !ok {
+	if _, ok := cfg.GetGenericDeployment(); !ok {
 		return nil, fmt.Errorf("unsupported application kind: %s", app.Kind)
 	}
 
-	return &spec, nil
+	return cfg, nil
 }
 
-func isTouchedByChangedFiles(appDir string, changes []string, changedFiles []string) (bool, error) {
-	if !strings.HasSuffix(appDir, "/") {
-		appDir += "/"
+// triggerPaths returns the paths of files outside the application directory
+// whose changes should trigger a new deployment of the application
+// placed in the repository of the given remote address.
+func triggerPaths(cfg *config.Config, repoRemote string) []string {
+	spec, _ := cfg.GetGenericDeployment()
+	paths := spec.TriggerPaths
+
+	// The external value files placing in the same repository are also
+	// considered as a part of the application.
+	if k := cfg.KubernetesDeploymentSpec; k != nil {
+		if files := k.Input.HelmOptions.ExternalValueFilePaths(repoRemote); len(files) > 0 {
+			paths = append(append([]string{}, paths...), files...)
+		}
 	}
+	return paths
+}
 
-	// If any files inside the application directory was changed
-	// this application is considered as touched.
-	for _, cf := range changedFiles {
-		if ok := strings.HasPrefix(cf, appDir); ok {
-			return true, nil
-		}
+// findTouchedFiles returns the changed files those touched the application.
+// The number of returned files is limited to maxTouchedFiles.
+func findTouchedFiles(appDir string, changes []string, changedFiles []string) ([]string, error) {
+	if !strings.HasSuffix(appDir, "/") {
+		appDir += "/"
 	}
 
-	// If any changed files matches the specified "changes"
-	// this application is consided as touched too.
+	matchers := make([]*filematcher.PatternMatcher, 0, len(changes))
 	for _, change := range changes {
 		matcher, err := filematcher.NewPatternMatcher([]string{change})
 		if err != nil {
-			return false, err
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	var touched []string
+	for _, cf := range changedFiles {
+		if len(touched) == maxTouchedFiles {
+			break
+		}
+		// If any files inside the application directory was changed
+		// this application is considered as touched.
+		if strings.HasPrefix(cf, appDir) {
+			touched = append(touched, cf)
+			continue
 		}
-		if matcher.MatchesAny(changedFiles) {
-			return true, nil
+		// If any changed files matches the specified "changes"
+		// this application is consided as touched too.
+		for _, m := range matchers {
+			if m.Matches(cf) {
+				touched = append(touched, cf)
+				break
+			}
 		}
 	}
 
-	return false, nil
+	return touched, nil
 }
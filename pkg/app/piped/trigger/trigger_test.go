@@ -15,18 +15,30 @@
 package trigger
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/config"
+	"github.com/pipe-cd/pipe/pkg/git"
+	"github.com/pipe-cd/pipe/pkg/git/gittest"
+	"github.com/pipe-cd/pipe/pkg/model"
 )
 
-func TestIsTouchedByChangedFiles(t *testing.T) {
+func TestFindTouchedFiles(t *testing.T) {
 	testcases := []struct {
 		name         string
 		appDir       string
 		changes      []string
 		changedFiles []string
-		expected     bool
+		expected     []string
 	}{
 		{
 			name:   "not touched",
@@ -35,7 +47,7 @@ func TestIsTouchedByChangedFiles(t *testing.T) {
 				"app/hello.txt",
 				"app/foo/deployment.yaml",
 			},
-			expected: false,
+			expected: nil,
 		},
 		{
 			name:   "not touched in dir whose name does not match exactly",
@@ -43,7 +55,7 @@ func TestIsTouchedByChangedFiles(t *testing.T) {
 			changedFiles: []string{
 				"app/demo-2",
 			},
-			expected: false,
+			expected: nil,
 		},
 		{
 			name:   "touched in app dir",
@@ -52,7 +64,9 @@ func TestIsTouchedByChangedFiles(t *testing.T) {
 				"app/hello.txt",
 				"app/demo/deployment.yaml",
 			},
-			expected: true,
+			expected: []string{
+				"app/demo/deployment.yaml",
+			},
 		},
 		{
 			name:   "touched in the changes",
@@ -65,15 +79,151 @@ func TestIsTouchedByChangedFiles(t *testing.T) {
 				"app/hello.txt",
 				"charts/bar/deployment.yaml",
 			},
-			expected: true,
+			expected: []string{
+				"charts/bar/deployment.yaml",
+			},
 		},
 	}
 
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := isTouchedByChangedFiles(tc.appDir, tc.changes, tc.changedFiles)
+			got, err := findTouchedFiles(tc.appDir, tc.changes, tc.changedFiles)
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expected, got)
 		})
 	}
 }
+
+func TestTriggerPaths(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cfg      *config.Config
+		expected []string
+	}{
+		{
+			name: "generic trigger paths",
+			cfg: &config.Config{
+				Kind: config.KindTerraformApp,
+				TerraformDeploymentSpec: &config.TerraformDeploymentSpec{
+					GenericDeploymentSpec: config.GenericDeploymentSpec{
+						TriggerPaths: []string{"modules/**"},
+					},
+				},
+			},
+			expected: []string{"modules/**"},
+		},
+		{
+			name: "external value files in the same repository",
+			cfg: &config.Config{
+				Kind: config.KindKubernetesApp,
+				KubernetesDeploymentSpec: &config.KubernetesDeploymentSpec{
+					GenericDeploymentSpec: config.GenericDeploymentSpec{
+						TriggerPaths: []string{"charts/**"},
+					},
+					Input: config.KubernetesDeploymentInput{
+						HelmOptions: &config.InputHelmOptions{
+							ExternalValueFiles: []config.InputHelmValueFile{
+								{Path: "envs/prod/values.yaml"},
+								{GitRemote: "git@github.com:org/values.git", Ref: "v1.0.0", Path: "values.yaml"},
+							},
+						},
+					},
+				},
+			},
+			expected: []string{"charts/**", "envs/prod/values.yaml"},
+		},
+		{
+			name: "external value files referencing the application repository",
+			cfg: &config.Config{
+				Kind: config.KindKubernetesApp,
+				KubernetesDeploymentSpec: &config.KubernetesDeploymentSpec{
+					Input: config.KubernetesDeploymentInput{
+						HelmOptions: &config.InputHelmOptions{
+							ExternalValueFiles: []config.InputHelmValueFile{
+								{GitRemote: "git@github.com:org/app.git", Path: "envs/prod/values.yaml"},
+								{GitRemote: "git@github.com:org/app.git", Ref: "v1.0.0", Path: "envs/dev/values.yaml"},
+								{GitRemote: "git@github.com:org/values.git", Path: "values.yaml"},
+							},
+						},
+					},
+				},
+			},
+			expected: []string{"envs/prod/values.yaml"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, triggerPaths(tc.cfg, "git@github.com:org/app.git"))
+		})
+	}
+}
+
+func TestFindTouchedRemoteValueFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "trigger")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "app"), 0755))
+	err = ioutil.WriteFile(filepath.Join(dir, "app", model.DefaultDeploymentConfigFileName), []byte(`
+apiVersion: pipecd.dev/v1beta1
+kind: KubernetesApp
+spec:
+  input:
+    helmChart:
+      path: chart
+    helmOptions:
+      externalValueFiles:
+        - gitRemote: git@github.com:org/values.git
+          path: prod/values.yaml
+        - gitRemote: git@github.com:org/values.git
+          ref: v1.0.0
+          path: dev/values.yaml
+`), 0644)
+	require.NoError(t, err)
+
+	appRepo := gittest.NewMockRepo(ctrl)
+	appRepo.EXPECT().GetPath().Return(dir).AnyTimes()
+
+	valuesRepo := gittest.NewMockRepo(ctrl)
+	valuesRepo.EXPECT().GetClonedBranch().Return("main").AnyTimes()
+	valuesRepo.EXPECT().Pull(gomock.Any(), "main").Return(nil).AnyTimes()
+	valuesRepo.EXPECT().GetLatestCommit(gomock.Any()).Return(git.Commit{Hash: "new"}, nil).AnyTimes()
+	valuesRepo.EXPECT().ChangedFiles(gomock.Any(), "old", "new").Return([]string{"prod/values.yaml", "README.md"}, nil)
+
+	tr := &Trigger{
+		config: &config.PipedSpec{
+			Repositories: []config.PipedRepository{
+				{RepoID: "app", Remote: "git@github.com:org/app.git", Branch: "main"},
+				{RepoID: "values", Remote: "git@github.com:org/values.git", Branch: "main"},
+			},
+		},
+		remoteValueFileCommits: make(map[string]string),
+		checkedHeadCommits:     make(map[string]git.Commit),
+		gitRepos: map[string]git.Repo{
+			"app":    appRepo,
+			"values": valuesRepo,
+		},
+		logger: zap.NewNop(),
+	}
+	app := &model.Application{
+		Id:   "app-1",
+		Kind: model.ApplicationKind_KUBERNETES,
+		GitPath: &model.ApplicationGitPath{
+			Repo: &model.ApplicationGitRepository{Id: "app"},
+			Path: "app",
+		},
+	}
+
+	// No change is detected until the commit of the remote repository is recorded.
+	touched, heads := tr.findTouchedRemoteValueFiles(context.Background(), app, appRepo)
+	assert.Empty(t, touched)
+	assert.Equal(t, map[string]string{"values": "new"}, heads)
+
+	tr.remoteValueFileCommits["app-1/values"] = "old"
+	touched, heads = tr.findTouchedRemoteValueFiles(context.Background(), app, appRepo)
+	assert.Equal(t, []string{"prod/values.yaml"}, touched)
+	assert.Equal(t, map[string]string{"values": "new"}, heads)
+}
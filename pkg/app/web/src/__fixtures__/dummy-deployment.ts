@@ -42,6 +42,7 @@ export const dummyDeployment: Deployment.AsObject = {
   },
   kind: ApplicationKind.KUBERNETES,
   metadataMap: [],
+  labelsMap: [],
 };
 
 export function createDeploymentFromObject(o: Deployment.AsObject): Deployment {
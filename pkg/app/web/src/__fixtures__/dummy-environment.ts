@@ -9,6 +9,8 @@ export const createEnvFromObject = (o: Environment.AsObject): Environment => {
   env.setProjectId(o.projectId);
   env.setUpdatedAt(o.updatedAt);
   env.setId(o.id);
+  env.setOrder(o.order);
+  env.setProtected(o.pb_protected);
   return env;
 };
 
@@ -19,6 +21,8 @@ export const dummyEnv: Environment.AsObject = {
   desc: randomWords(8),
   name: "staging",
   projectId: "project-1",
+  order: 0,
+  pb_protected: false,
   updatedAt: updatedAt.unix(),
   createdAt: createdAt.unix(),
 };
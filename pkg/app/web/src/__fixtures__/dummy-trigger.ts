@@ -17,9 +17,13 @@ export const dummyTrigger: DeploymentTrigger.AsObject = {
     hash: randomUUID().slice(0, 8),
     message: "fix",
     pullRequest: 123,
+    pullRequestTitle: "Add an awesome feature",
+    pullRequestAuthor: "pipecd-user",
+    pullRequestUrl: "",
     url: "",
   },
   syncStrategy: SyncStrategy.AUTO,
+  changedFilesList: ["apps/demo/deployment.yaml"],
 };
 
 function createCommitFromObject(o: Commit.AsObject): Commit {
@@ -30,6 +34,9 @@ function createCommitFromObject(o: Commit.AsObject): Commit {
   commit.setHash(o.hash);
   commit.setMessage(o.message);
   commit.setPullRequest(o.pullRequest);
+  commit.setPullRequestTitle(o.pullRequestTitle);
+  commit.setPullRequestAuthor(o.pullRequestAuthor);
+  commit.setPullRequestUrl(o.pullRequestUrl);
   commit.setUrl(o.url);
   return commit;
 }
@@ -41,6 +48,7 @@ export function createTriggerFromObject(
   trigger.setCommander(o.commander);
   trigger.setTimestamp(o.timestamp);
   trigger.setSyncStrategy(o.syncStrategy);
+  trigger.setChangedFilesList(o.changedFilesList);
   if (o.commit) {
     trigger.setCommit(createCommitFromObject(o.commit));
   }
@@ -6,11 +6,32 @@ export const apiClient = new WebServiceClient(apiEndpoint, null, {
   withCredentials: "true",
 });
 
+const REFRESH_TOKEN_PATH = "/auth/refresh";
+
 interface ApiCallback<Res> {
   (err: grpcWeb.Error, response: { toObject: () => Res }): void;
 }
 
-export async function apiRequest<Req, Res>(
+// Since the refresh token is rotated on every refresh,
+// all concurrent requests have to share the same refreshing result.
+let refreshing: Promise<boolean> | null = null;
+
+export function refreshToken(): Promise<boolean> {
+  if (refreshing === null) {
+    refreshing = fetch(REFRESH_TOKEN_PATH, {
+      method: "POST",
+      credentials: "same-origin",
+    })
+      .then((res) => res.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+}
+
+function call<Req, Res>(
   request: Req,
   api: {
     (request: Req, meta: grpcWeb.Metadata, callback: ApiCallback<Res>): void;
@@ -26,3 +47,24 @@ export async function apiRequest<Req, Res>(
     });
   });
 }
+
+export async function apiRequest<Req, Res>(
+  request: Req,
+  api: {
+    (request: Req, meta: grpcWeb.Metadata, callback: ApiCallback<Res>): void;
+  }
+): Promise<Res> {
+  try {
+    return await call(request, api);
+  } catch (err) {
+    // The access token may have been expired,
+    // try to refresh it once and then retry the request.
+    if (
+      err.code === grpcWeb.StatusCode.UNAUTHENTICATED &&
+      (await refreshToken())
+    ) {
+      return call(request, api);
+    }
+    throw err;
+  }
+}
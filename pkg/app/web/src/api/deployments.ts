@@ -8,6 +8,8 @@ import {
   CancelDeploymentResponse,
   ApproveStageRequest,
   ApproveStageResponse,
+  SkipStageRequest,
+  SkipStageResponse,
 } from "pipe/pkg/app/web/api_client/service_pb";
 
 export const getDeployment = ({
@@ -63,3 +65,13 @@ export const approveStage = ({
   req.setStageId(stageId);
   return apiRequest(req, apiClient.approveStage);
 };
+
+export const skipStage = ({
+  deploymentId,
+  stageId,
+}: SkipStageRequest.AsObject): Promise<SkipStageResponse.AsObject> => {
+  const req = new SkipStageRequest();
+  req.setDeploymentId(deploymentId);
+  req.setStageId(stageId);
+  return apiRequest(req, apiClient.skipStage);
+};
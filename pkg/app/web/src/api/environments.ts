@@ -6,6 +6,12 @@ import {
   AddEnvironmentResponse,
   UpdateEnvironmentDescRequest,
   UpdateEnvironmentDescResponse,
+  UpdateEnvironmentOrderRequest,
+  UpdateEnvironmentOrderResponse,
+  EnableEnvironmentProtectionRequest,
+  EnableEnvironmentProtectionResponse,
+  DisableEnvironmentProtectionRequest,
+  DisableEnvironmentProtectionResponse,
 } from "pipe/pkg/app/web/api_client/service_pb";
 
 export const getEnvironments = (): Promise<
@@ -18,18 +24,59 @@ export const getEnvironments = (): Promise<
 export const AddEnvironment = ({
   name,
   desc,
+  order,
+  pb_protected,
 }: AddEnvironmentRequest.AsObject): Promise<
   AddEnvironmentResponse.AsObject
 > => {
   const req = new AddEnvironmentRequest();
   req.setName(name);
   req.setDesc(desc);
+  req.setOrder(order);
+  req.setProtected(pb_protected);
   return apiRequest(req, apiClient.addEnvironment);
 };
 
-export const updateEnvironmentDesc = (
-  desc: string
-): Promise<UpdateEnvironmentDescResponse.AsObject> => {
+export const updateEnvironmentDesc = ({
+  environmentId,
+  desc,
+}: UpdateEnvironmentDescRequest.AsObject): Promise<
+  UpdateEnvironmentDescResponse.AsObject
+> => {
   const req = new UpdateEnvironmentDescRequest();
+  req.setEnvironmentId(environmentId);
+  req.setDesc(desc);
   return apiRequest(req, apiClient.updateEnvironmentDesc);
 };
+
+export const updateEnvironmentOrder = ({
+  environmentId,
+  order,
+}: UpdateEnvironmentOrderRequest.AsObject): Promise<
+  UpdateEnvironmentOrderResponse.AsObject
+> => {
+  const req = new UpdateEnvironmentOrderRequest();
+  req.setEnvironmentId(environmentId);
+  req.setOrder(order);
+  return apiRequest(req, apiClient.updateEnvironmentOrder);
+};
+
+export const enableEnvironmentProtection = ({
+  environmentId,
+}: EnableEnvironmentProtectionRequest.AsObject): Promise<
+  EnableEnvironmentProtectionResponse.AsObject
+> => {
+  const req = new EnableEnvironmentProtectionRequest();
+  req.setEnvironmentId(environmentId);
+  return apiRequest(req, apiClient.enableEnvironmentProtection);
+};
+
+export const disableEnvironmentProtection = ({
+  environmentId,
+}: DisableEnvironmentProtectionRequest.AsObject): Promise<
+  DisableEnvironmentProtectionResponse.AsObject
+> => {
+  const req = new DisableEnvironmentProtectionRequest();
+  req.setEnvironmentId(environmentId);
+  return apiRequest(req, apiClient.disableEnvironmentProtection);
+};
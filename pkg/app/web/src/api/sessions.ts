@@ -0,0 +1,15 @@
+import { apiClient, apiRequest } from "./client";
+import {
+  RevokeSessionsRequest,
+  RevokeSessionsResponse,
+} from "pipe/pkg/app/web/api_client/service_pb";
+
+export const revokeSessions = ({
+  username,
+}: RevokeSessionsRequest.AsObject): Promise<
+  RevokeSessionsResponse.AsObject
+> => {
+  const req = new RevokeSessionsRequest();
+  req.setUsername(username);
+  return apiRequest(req, apiClient.revokeSessions);
+};
@@ -6,6 +6,8 @@ import {
   TextField,
   Button,
   Box,
+  Checkbox,
+  FormControlLabel,
 } from "@material-ui/core";
 import { useFormik } from "formik";
 import * as Yup from "yup";
@@ -22,11 +24,18 @@ const useStyles = makeStyles((theme) => ({
 const validationSchema = Yup.object({
   name: Yup.string().required("Required"),
   desc: Yup.string().required("Required"),
+  order: Yup.number().integer(),
+  protected: Yup.boolean(),
 });
 
 export interface AddEnvFormProps {
   projectName: string;
-  onSubmit: (props: { name: string; desc: string }) => void;
+  onSubmit: (props: {
+    name: string;
+    desc: string;
+    order: number;
+    protected: boolean;
+  }) => void;
   onCancel: () => void;
 }
 
@@ -40,6 +49,8 @@ export const AddEnvForm: FC<AddEnvFormProps> = memo(function AddEnvForm({
     initialValues: {
       name: "",
       desc: "",
+      order: 0,
+      protected: false,
     },
     validationSchema,
     onSubmit: (values, actions) => {
@@ -83,6 +94,29 @@ export const AddEnvForm: FC<AddEnvFormProps> = memo(function AddEnvForm({
           fullWidth
           required
         />
+        <TextField
+          id="order"
+          name="order"
+          label="Display order"
+          type="number"
+          variant="outlined"
+          margin="dense"
+          onChange={formik.handleChange}
+          value={formik.values.order}
+          fullWidth
+        />
+        <FormControlLabel
+          control={
+            <Checkbox
+              id="protected"
+              name="protected"
+              color="primary"
+              checked={formik.values.protected}
+              onChange={formik.handleChange}
+            />
+          }
+          label="Protected (every deployment requires an approval)"
+        />
         <Button
           color="primary"
           type="submit"
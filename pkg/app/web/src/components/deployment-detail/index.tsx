@@ -77,6 +77,7 @@ const CANCEL_OPTIONS = [
   "Cancel without Rollback",
 ];
 const LOG_FETCH_INTERVAL = 2000;
+const CHANGED_FILES_LIMIT = 5;
 
 export const DeploymentDetail: FC<DeploymentDetailProps> = memo(
   function DeploymentDetail({ deploymentId }) {
@@ -199,6 +200,55 @@ export const DeploymentDetail: FC<DeploymentDetailProps> = memo(
                       }
                     />
                   )}
+                  {deployment.trigger?.commit?.pullRequest ? (
+                    <DetailTableRow
+                      label="Pull Request"
+                      value={
+                        deployment.trigger.commit.pullRequestUrl ? (
+                          <Link
+                            variant="body2"
+                            href={deployment.trigger.commit.pullRequestUrl}
+                            target="_blank"
+                            rel="noreferrer"
+                          >
+                            {`#${deployment.trigger.commit.pullRequest} ${deployment.trigger.commit.pullRequestTitle}`}
+                            <OpenInNewIcon className={classes.linkIcon} />
+                          </Link>
+                        ) : (
+                          `#${deployment.trigger.commit.pullRequest} ${deployment.trigger.commit.pullRequestTitle}`
+                        )
+                      }
+                    />
+                  ) : null}
+                  {deployment.trigger &&
+                    deployment.trigger.changedFilesList.length > 0 && (
+                      <DetailTableRow
+                        label="Changed Files"
+                        value={
+                          <Box display="flex" flexDirection="column">
+                            {deployment.trigger.changedFilesList
+                              .slice(0, CHANGED_FILES_LIMIT)
+                              .map((file) => (
+                                <Typography variant="body2" key={file}>
+                                  {file}
+                                </Typography>
+                              ))}
+                            {deployment.trigger.changedFilesList.length >
+                              CHANGED_FILES_LIMIT && (
+                              <Typography
+                                variant="body2"
+                                color="textSecondary"
+                              >
+                                {`and ${
+                                  deployment.trigger.changedFilesList.length -
+                                  CHANGED_FILES_LIMIT
+                                } more files`}
+                              </Typography>
+                            )}
+                          </Box>
+                        }
+                      />
+                    )}
                   <DetailTableRow
                     label="Triggered by"
                     value={
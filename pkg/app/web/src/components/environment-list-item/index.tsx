@@ -1,6 +1,7 @@
 import {
   Box,
   Button,
+  Chip,
   Dialog,
   DialogActions,
   DialogContent,
@@ -18,13 +19,20 @@ import { MoreVert as MoreVertIcon } from "@material-ui/icons";
 import { EntityId } from "@reduxjs/toolkit";
 import { FC, memo, useCallback, useState } from "react";
 import * as React from "react";
-import { useSelector } from "react-redux";
+import { useDispatch, useSelector } from "react-redux";
 import {
   UI_TEXT_CANCEL,
   UI_TEXT_EDIT,
   UI_TEXT_SAVE,
 } from "../../constants/ui-text";
-import { selectEnvById } from "../../modules/environments";
+import {
+  disableEnvironmentProtection,
+  enableEnvironmentProtection,
+  fetchEnvironments,
+  selectEnvById,
+  updateEnvironment,
+} from "../../modules/environments";
+import { AppDispatch } from "../../store";
 import { CopyIconButton } from "../copy-icon-button";
 
 const useStyles = makeStyles((theme) => ({
@@ -43,7 +51,10 @@ const useStyles = makeStyles((theme) => ({
 
 const ITEM_HEIGHT = 48;
 const TEXT_NO_DESCRIPTION = "No description";
-const DIALOG_TITLE = "Edit Environment description";
+const DIALOG_TITLE = "Edit Environment";
+const TEXT_PROTECTED = "Protected";
+const TEXT_ENABLE_PROTECTION = "Enable protection";
+const TEXT_DISABLE_PROTECTION = "Disable protection";
 
 export interface EnvironmentListItemProps {
   id: EntityId;
@@ -55,7 +66,9 @@ export const EnvironmentListItem: FC<EnvironmentListItemProps> = memo(
     const [anchorEl, setAnchorEl] = useState<HTMLButtonElement | null>(null);
     const [isEdit, setIsEdit] = useState(false);
     const [desc, setDesc] = useState("");
+    const [order, setOrder] = useState(0);
     const env = useSelector(selectEnvById(id));
+    const dispatch = useDispatch<AppDispatch>();
 
     // menu event handler
     const handleClickMenu = useCallback(
@@ -76,9 +89,39 @@ export const EnvironmentListItem: FC<EnvironmentListItemProps> = memo(
     const handleCloseEdit = useCallback(() => {
       setIsEdit(false);
     }, [setIsEdit]);
-    const handleSave = useCallback(() => {
-      // not implemented yet
-    }, []);
+    const handleSave = useCallback(
+      (e: React.FormEvent<HTMLFormElement>) => {
+        e.preventDefault();
+        if (!env) {
+          return;
+        }
+        dispatch(
+          updateEnvironment({
+            environmentId: env.id,
+            desc: desc !== env.desc ? desc : undefined,
+            order: order !== env.order ? order : undefined,
+          })
+        ).then(() => {
+          setIsEdit(false);
+          dispatch(fetchEnvironments());
+        });
+      },
+      [dispatch, env, desc, order, setIsEdit]
+    );
+
+    // protection event handler
+    const handleToggleProtection = useCallback(() => {
+      setAnchorEl(null);
+      if (!env) {
+        return;
+      }
+      const action = env.pb_protected
+        ? disableEnvironmentProtection({ environmentId: env.id })
+        : enableEnvironmentProtection({ environmentId: env.id });
+      dispatch(action).then(() => {
+        dispatch(fetchEnvironments());
+      });
+    }, [dispatch, env, setAnchorEl]);
 
     if (!env) {
       return null;
@@ -93,6 +136,14 @@ export const EnvironmentListItem: FC<EnvironmentListItemProps> = memo(
             <Typography variant="subtitle2" component="span">
               {env.name}
             </Typography>
+            {env.pb_protected && (
+              <Chip
+                label={TEXT_PROTECTED}
+                size="small"
+                color="secondary"
+                style={{ marginLeft: 8 }}
+              />
+            )}
           </TableCell>
           <TableCell colSpan={2}>{env.desc || TEXT_NO_DESCRIPTION}</TableCell>
           <TableCell className={classes.idCell}>
@@ -106,7 +157,6 @@ export const EnvironmentListItem: FC<EnvironmentListItemProps> = memo(
               edge="end"
               aria-label="open menu"
               onClick={handleClickMenu}
-              style={{ display: "none" }}
             >
               <MoreVertIcon />
             </IconButton>
@@ -128,12 +178,16 @@ export const EnvironmentListItem: FC<EnvironmentListItemProps> = memo(
           <MenuItem key="env-menu-edit" onClick={handleEdit}>
             {UI_TEXT_EDIT}
           </MenuItem>
+          <MenuItem key="env-menu-protection" onClick={handleToggleProtection}>
+            {env.pb_protected ? TEXT_DISABLE_PROTECTION : TEXT_ENABLE_PROTECTION}
+          </MenuItem>
         </Menu>
 
         <Dialog
           open={isEdit}
           onEnter={() => {
             setDesc(env.desc);
+            setOrder(env.order);
           }}
           onClose={handleCloseEdit}
           fullWidth
@@ -151,13 +205,24 @@ export const EnvironmentListItem: FC<EnvironmentListItemProps> = memo(
                 autoFocus
                 onChange={(e) => setDesc(e.currentTarget.value)}
               />
+              <TextField
+                value={order}
+                type="number"
+                variant="outlined"
+                margin="dense"
+                label="Display order"
+                fullWidth
+                onChange={(e) => setOrder(Number(e.currentTarget.value))}
+              />
             </DialogContent>
             <DialogActions>
               <Button onClick={handleCloseEdit}>{UI_TEXT_CANCEL}</Button>
               <Button
                 type="submit"
                 color="primary"
-                disabled={desc === "" || desc === env.desc}
+                disabled={
+                  desc === "" || (desc === env.desc && order === env.order)
+                }
               >
                 {UI_TEXT_SAVE}
               </Button>
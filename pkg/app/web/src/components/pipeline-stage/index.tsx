@@ -1,4 +1,4 @@
-import { makeStyles, Paper, Typography } from "@material-ui/core";
+import { Button, makeStyles, Paper, Typography } from "@material-ui/core";
 import { StageStatus } from "pipe/pkg/app/web/model/deployment_pb";
 import { FC, memo } from "react";
 import { StageStatusIcon } from "../stage-status-icon";
@@ -33,6 +33,11 @@ const useStyles = makeStyles((theme) => ({
     textOverflow: "ellipsis",
     overflow: "hidden",
   },
+  skipButton: {
+    marginLeft: "auto",
+    paddingTop: 0,
+    paddingBottom: 0,
+  },
   stageName: {
     fontFamily: theme.typography.fontFamilyMono,
   },
@@ -57,6 +62,7 @@ export interface PipelineStageProps {
   approver?: string;
   metadata: [string, string][];
   onClick: (stageId: string, stageName: string) => void;
+  onSkip?: (stageId: string) => void;
 }
 
 const TRAFFIC_PERCENTAGE_META_KEY = {
@@ -109,6 +115,7 @@ export const PipelineStage: FC<PipelineStageProps> = memo(
     approver,
     metadata,
     isDeploymentRunning,
+    onSkip,
   }) {
     const classes = useStyles();
     const disabled =
@@ -140,6 +147,19 @@ export const PipelineStage: FC<PipelineStageProps> = memo(
               {name}
             </span>
           </Typography>
+          {onSkip && status === StageStatus.STAGE_RUNNING ? (
+            <Button
+              size="small"
+              color="primary"
+              className={classes.skipButton}
+              onClick={(e) => {
+                e.stopPropagation();
+                onSkip(id);
+              }}
+            >
+              SKIP
+            </Button>
+          ) : null}
         </div>
         {approver !== undefined ? (
           <div className={classes.metadata}>
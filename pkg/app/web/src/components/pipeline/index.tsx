@@ -17,6 +17,7 @@ import {
   Deployment,
   Stage,
   approveStage,
+  skipStage,
   isDeploymentRunning,
 } from "../../modules/deployments";
 import { fetchStageLog } from "../../modules/stage-logs";
@@ -27,6 +28,7 @@ import { StageStatus } from "pipe/pkg/app/web/model/deployment_pb";
 import { METADATA_APPROVED_BY } from "../../constants/metadata-keys";
 
 const WAIT_APPROVAL_NAME = "WAIT_APPROVAL";
+const SKIPPABLE_STAGE_NAMES = ["ANALYSIS", "WAIT"];
 const STAGE_HEIGHT = 56;
 const APPROVED_STAGE_HEIGHT = 66;
 
@@ -149,6 +151,8 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
   const dispatch = useDispatch();
   const [approveTargetId, setApproveTargetId] = useState<string | null>(null);
   const isOpenApproveDialog = Boolean(approveTargetId);
+  const [skipTargetId, setSkipTargetId] = useState<string | null>(null);
+  const isOpenSkipDialog = Boolean(skipTargetId);
   const [isRunning, stages, defaultActiveStage] = useDeploymentStage(
     deploymentId
   );
@@ -196,6 +200,13 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
     }
   };
 
+  const handleSkip = (): void => {
+    if (skipTargetId) {
+      dispatch(skipStage({ deploymentId, stageId: skipTargetId }));
+      setSkipTargetId(null);
+    }
+  };
+
   return (
     <Box textAlign="center" overflow="scroll" className={classes.showScrollbar}>
       <Box display="inline-flex">
@@ -247,6 +258,11 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
                         active={isActive}
                         approver={approver}
                         isDeploymentRunning={isRunning}
+                        onSkip={
+                          SKIPPABLE_STAGE_NAMES.includes(stage.name)
+                            ? setSkipTargetId
+                            : undefined
+                        }
                       />
                     )}
                   </div>
@@ -275,6 +291,21 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
             </Button>
           </DialogActions>
         </Dialog>
+
+        <Dialog open={isOpenSkipDialog} onClose={() => setSkipTargetId(null)}>
+          <DialogTitle>Skip stage</DialogTitle>
+          <DialogContent>
+            <DialogContentText>
+              {`To stop this stage and continue deploying, click "SKIP".`}
+            </DialogContentText>
+          </DialogContent>
+          <DialogActions>
+            <Button onClick={() => setSkipTargetId(null)}>CANCEL</Button>
+            <Button color="primary" onClick={handleSkip}>
+              SKIP
+            </Button>
+          </DialogActions>
+        </Dialog>
       </Box>
     </Box>
   );
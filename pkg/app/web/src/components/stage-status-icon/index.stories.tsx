@@ -14,5 +14,6 @@ export const overview: React.FC = () => (
     <StageStatusIcon status={StageStatus.STAGE_NOT_STARTED_YET} />
     <StageStatusIcon status={StageStatus.STAGE_RUNNING} />
     <StageStatusIcon status={StageStatus.STAGE_SUCCESS} />
+    <StageStatusIcon status={StageStatus.STAGE_SKIPPED} />
   </>
 );
@@ -6,6 +6,7 @@ import {
   Cached,
   Stop,
   IndeterminateCheckBox,
+  SkipNext,
 } from "@material-ui/icons";
 import { StageStatus } from "pipe/pkg/app/web/model/deployment_pb";
 
@@ -26,6 +27,9 @@ const useStyles = makeStyles((theme) => ({
   [StageStatus.STAGE_NOT_STARTED_YET]: {
     color: theme.palette.grey[500],
   },
+  [StageStatus.STAGE_SKIPPED]: {
+    color: theme.palette.grey[500],
+  },
   "@keyframes running": {
     "0%": {
       transform: "rotate(0deg)",
@@ -54,5 +58,7 @@ export const StageStatusIcon: FC<StageStatusIconProps> = ({ status }) => {
       return <IndeterminateCheckBox className={classes[status]} />;
     case StageStatus.STAGE_RUNNING:
       return <Cached className={classes[status]} />;
+    case StageStatus.STAGE_SKIPPED:
+      return <SkipNext className={classes[status]} />;
   }
 };
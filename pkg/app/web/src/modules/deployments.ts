@@ -58,6 +58,7 @@ export const isStageRunning = (status: StageStatus): boolean => {
     case StageStatus.STAGE_SUCCESS:
     case StageStatus.STAGE_FAILURE:
     case StageStatus.STAGE_CANCELLED:
+    case StageStatus.STAGE_SKIPPED:
       return false;
   }
 };
@@ -155,6 +156,14 @@ export const approveStage = createAsyncThunk<
   await thunkAPI.dispatch(fetchCommand(commandId));
 });
 
+export const skipStage = createAsyncThunk<
+  void,
+  { deploymentId: string; stageId: string }
+>("deployments/skip", async (props, thunkAPI) => {
+  const { commandId } = await deploymentsApi.skipStage(props);
+  await thunkAPI.dispatch(fetchCommand(commandId));
+});
+
 export const cancelDeployment = createAsyncThunk<
   void,
   {
@@ -1,6 +1,7 @@
 import {
   ListEnvironmentsResponse,
   AddEnvironmentResponse,
+  EnableEnvironmentProtectionResponse,
 } from "pipe/pkg/app/web/api_client/service_pb";
 import { createStore } from "../../test-utils";
 import { createHandler } from "../mocks/create-handler";
@@ -13,6 +14,7 @@ import {
   environmentsSlice,
   fetchEnvironments,
   addEnvironment,
+  enableEnvironmentProtection,
 } from "./environments";
 
 beforeAll(() => {
@@ -87,7 +89,14 @@ describe("async actions", () => {
       })
     );
 
-    await store.dispatch(addEnvironment({ name: "env", desc: "description" }));
+    await store.dispatch(
+      addEnvironment({
+        name: "env",
+        desc: "description",
+        order: 0,
+        protected: false,
+      })
+    );
     expect(store.getActions()).toEqual(
       expect.arrayContaining([
         expect.objectContaining({ type: addEnvironment.pending.type }),
@@ -97,4 +106,31 @@ describe("async actions", () => {
       ])
     );
   });
+
+  test("enableEnvironmentProtection", async () => {
+    const store = createStore();
+
+    server.use(
+      createHandler<EnableEnvironmentProtectionResponse>(
+        "/EnableEnvironmentProtection",
+        () => {
+          return new EnableEnvironmentProtectionResponse();
+        }
+      )
+    );
+
+    await store.dispatch(
+      enableEnvironmentProtection({ environmentId: dummyEnv.id })
+    );
+    expect(store.getActions()).toEqual(
+      expect.arrayContaining([
+        expect.objectContaining({
+          type: enableEnvironmentProtection.pending.type,
+        }),
+        expect.objectContaining({
+          type: enableEnvironmentProtection.fulfilled.type,
+        }),
+      ])
+    );
+  });
 });
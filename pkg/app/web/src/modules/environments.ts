@@ -9,9 +9,10 @@ import { Environment } from "pipe/pkg/app/web/model/environment_pb";
 import { AppState } from ".";
 import * as envsApi from "../api/environments";
 
-export const environmentsAdapter = createEntityAdapter<Environment.AsObject>(
-  {}
-);
+export const environmentsAdapter = createEntityAdapter<Environment.AsObject>({
+  sortComparer: (a, b) =>
+    a.order !== b.order ? a.order - b.order : a.name.localeCompare(b.name),
+});
 
 const {
   selectById,
@@ -42,9 +43,40 @@ export const fetchEnvironments = createAsyncThunk<Environment.AsObject[], void>(
 
 export const addEnvironment = createAsyncThunk<
   void,
-  { name: string; desc: string }
->("environments/fetchList", async (props) => {
-  await envsApi.AddEnvironment(props);
+  { name: string; desc: string; order: number; protected: boolean }
+>("environments/add", async (props) => {
+  await envsApi.AddEnvironment({
+    name: props.name,
+    desc: props.desc,
+    order: props.order,
+    pb_protected: props.protected,
+  });
+});
+
+export const updateEnvironment = createAsyncThunk<
+  void,
+  { environmentId: string; desc?: string; order?: number }
+>("environments/update", async ({ environmentId, desc, order }) => {
+  if (desc !== undefined) {
+    await envsApi.updateEnvironmentDesc({ environmentId, desc });
+  }
+  if (order !== undefined) {
+    await envsApi.updateEnvironmentOrder({ environmentId, order });
+  }
+});
+
+export const enableEnvironmentProtection = createAsyncThunk<
+  void,
+  { environmentId: string }
+>("environments/enableProtection", async ({ environmentId }) => {
+  await envsApi.enableEnvironmentProtection({ environmentId });
+});
+
+export const disableEnvironmentProtection = createAsyncThunk<
+  void,
+  { environmentId: string }
+>("environments/disableProtection", async ({ environmentId }) => {
+  await envsApi.disableEnvironmentProtection({ environmentId });
 });
 
 export const environmentsSlice = createSlice({
@@ -53,7 +85,7 @@ export const environmentsSlice = createSlice({
   reducers: {},
   extraReducers: (builder) => {
     builder.addCase(fetchEnvironments.fulfilled, (state, action) => {
-      environmentsAdapter.addMany(state, action.payload);
+      environmentsAdapter.setAll(state, action.payload);
     });
   },
 });
@@ -37,7 +37,12 @@ export const SettingsEnvironmentPage: FC = memo(
       setIsOpenForm(false);
     };
 
-    const handleSubmit = (props: { name: string; desc: string }): void => {
+    const handleSubmit = (props: {
+      name: string;
+      desc: string;
+      order: number;
+      protected: boolean;
+    }): void => {
       dispatch(addEnvironment(props)).finally(() => {
         setIsOpenForm(false);
         dispatch(fetchEnvironments());
@@ -16,9 +16,13 @@ package backoff
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrRetryBudgetExhausted is returned by Retry.Do when no attempt could be made.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
 type Backoff interface {
 	Next() time.Duration
 	Calls() int
@@ -27,22 +31,66 @@ type Backoff interface {
 }
 
 type Retry interface {
+	// WaitNext waits until the next attempt can be made.
+	// It returns false when no more attempt should be made because the context is done,
+	// the maximum number of attempts is reached or the retry budget is exhausted.
 	WaitNext(ctx context.Context) bool
+	// Do calls the given function until it succeeds or WaitNext returns false.
+	// It returns immediately when the error is classified as non-retryable.
+	// The last error is returned when no attempt succeeded.
+	Do(ctx context.Context, f func(ctx context.Context) error) error
 	Calls() int
 }
 
-func NewRetry(max int, backoff Backoff) Retry {
-	return &retry{
+type RetryOption func(*retry)
+
+// WithMaxElapsedTime specifies the retry budget.
+// No more attempt is made once the given duration has passed since the first attempt,
+// or when waiting for the next attempt would exceed it.
+func WithMaxElapsedTime(d time.Duration) RetryOption {
+	return func(r *retry) {
+		r.maxElapsedTime = d
+	}
+}
+
+// WithAttemptTimeout specifies the timeout of each attempt made by Do.
+func WithAttemptTimeout(d time.Duration) RetryOption {
+	return func(r *retry) {
+		r.attemptTimeout = d
+	}
+}
+
+// WithRetryable specifies the function used by Do to check whether
+// the attempt should be retried for the given error.
+// All errors are considered as retryable by default.
+func WithRetryable(f func(err error) bool) RetryOption {
+	return func(r *retry) {
+		r.retryable = f
+	}
+}
+
+func NewRetry(max int, backoff Backoff, opts ...RetryOption) Retry {
+	r := &retry{
 		max:     max,
 		backoff: backoff,
+		nowFunc: time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
 type retry struct {
 	max     int
 	calls   int
-	ctx     context.Context
 	backoff Backoff
+
+	maxElapsedTime time.Duration
+	attemptTimeout time.Duration
+	retryable      func(err error) bool
+	startTime      time.Time
+	nowFunc        func() time.Time
 }
 
 func (r *retry) WaitNext(ctx context.Context) bool {
@@ -54,7 +102,22 @@ func (r *retry) WaitNext(ctx context.Context) bool {
 		return false
 	}
 
+	now := r.nowFunc()
+	if r.calls == 0 {
+		r.startTime = now
+	}
+
 	d := r.backoff.Next()
+	// Give up without waiting when the next attempt would be made
+	// after the deadline of the context or the retry budget.
+	next := now.Add(d)
+	if deadline, ok := ctx.Deadline(); ok && next.After(deadline) {
+		return false
+	}
+	if r.maxElapsedTime > 0 && next.Sub(r.startTime) > r.maxElapsedTime {
+		return false
+	}
+
 	if d == 0 {
 		select {
 		case <-ctx.Done():
@@ -65,6 +128,7 @@ func (r *retry) WaitNext(ctx context.Context) bool {
 	}
 
 	t := time.NewTimer(d)
+	defer t.Stop()
 	select {
 	case <-ctx.Done():
 		return false
@@ -73,6 +137,35 @@ func (r *retry) WaitNext(ctx context.Context) bool {
 	}
 }
 
+func (r *retry) Do(ctx context.Context, f func(ctx context.Context) error) error {
+	var err error
+	for r.WaitNext(ctx) {
+		if err = r.attempt(ctx, f); err == nil {
+			return nil
+		}
+		if r.retryable != nil && !r.retryable(err) {
+			return err
+		}
+	}
+	if err == nil {
+		// No attempt was made.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return ErrRetryBudgetExhausted
+	}
+	return err
+}
+
+func (r *retry) attempt(ctx context.Context, f func(ctx context.Context) error) error {
+	if r.attemptTimeout <= 0 {
+		return f(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.attemptTimeout)
+	defer cancel()
+	return f(ctx)
+}
+
 func (r *retry) Calls() int {
 	return r.calls
 }
@@ -16,6 +16,7 @@ package backoff
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -46,3 +47,107 @@ func TestWaitNextCancel(t *testing.T) {
 	ok := r.WaitNext(ctx)
 	assert.Equal(t, false, ok)
 }
+
+func TestWaitNextContextDeadline(t *testing.T) {
+	var (
+		bo          = NewConstant(time.Minute)
+		r           = NewRetry(10, bo)
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Second)
+	)
+	defer cancel()
+
+	// The first attempt is made immediately.
+	assert.Equal(t, true, r.WaitNext(ctx))
+	// The next attempt would be made after the deadline.
+	start := time.Now()
+	assert.Equal(t, false, r.WaitNext(ctx))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestWaitNextMaxElapsedTime(t *testing.T) {
+	var (
+		bo  = NewConstant(time.Millisecond)
+		now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		r   = NewRetry(10, bo, WithMaxElapsedTime(time.Second)).(*retry)
+	)
+	r.nowFunc = func() time.Time { return now }
+
+	assert.Equal(t, true, r.WaitNext(context.TODO()))
+	assert.Equal(t, true, r.WaitNext(context.TODO()))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, false, r.WaitNext(context.TODO()))
+}
+
+func TestDo(t *testing.T) {
+	var (
+		errRetryable = errors.New("retryable")
+		errTerminal  = errors.New("terminal")
+	)
+	testcases := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "succeeded at the first attempt",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeded after retrying",
+			errs:      []error{errRetryable, errRetryable, nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "terminal error is not retried",
+			errs:      []error{errRetryable, errTerminal, nil},
+			wantErr:   errTerminal,
+			wantCalls: 2,
+		},
+		{
+			name:      "all attempts failed",
+			errs:      []error{errRetryable, errRetryable, errRetryable, nil},
+			wantErr:   errRetryable,
+			wantCalls: 3,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRetry(3, NewConstant(time.Millisecond), WithRetryable(func(err error) bool {
+				return err != errTerminal
+			}))
+			calls := 0
+			err := r.Do(context.TODO(), func(_ context.Context) error {
+				err := tc.errs[calls]
+				calls++
+				return err
+			})
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
+
+func TestDoAttemptTimeout(t *testing.T) {
+	r := NewRetry(2, NewConstant(time.Millisecond), WithAttemptTimeout(10*time.Millisecond))
+	calls := 0
+	err := r.Do(context.TODO(), func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	r := NewRetry(3, NewConstant(time.Millisecond))
+	err := r.Do(ctx, func(_ context.Context) error {
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -37,6 +37,12 @@ type Deleter interface {
 	Delete(key interface{}) error
 }
 
+// Loader wraps a method to read from cache
+// or to load and write the value when it was not found.
+type Loader interface {
+	GetOrLoad(key interface{}, load func() (interface{}, error)) (interface{}, error)
+}
+
 // Cache groups Getter, Putter and Deleter.
 type Cache interface {
 	Getter
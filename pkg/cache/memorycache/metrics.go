@@ -0,0 +1,111 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorycache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsLabelCache = "cache"
+)
+
+var (
+	metricsHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "memorycache_hits_total",
+			Help: "Number of lookups those found the value in the memory cache.",
+		},
+		[]string{
+			metricsLabelCache,
+		},
+	)
+	metricsMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "memorycache_misses_total",
+			Help: "Number of lookups those did not find the value in the memory cache.",
+		},
+		[]string{
+			metricsLabelCache,
+		},
+	)
+	metricsEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "memorycache_evictions_total",
+			Help: "Number of entries evicted from the memory cache to keep it within its bounds.",
+		},
+		[]string{
+			metricsLabelCache,
+		},
+	)
+	metricsEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "memorycache_entries",
+			Help: "Number of entries in the memory cache.",
+		},
+		[]string{
+			metricsLabelCache,
+		},
+	)
+	metricsBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "memorycache_bytes",
+			Help: "Total size in bytes of the entries in the memory cache.",
+		},
+		[]string{
+			metricsLabelCache,
+		},
+	)
+)
+
+func init() {
+	registerMetrics()
+}
+
+func registerMetrics() {
+	prometheus.MustRegister(
+		metricsHits,
+		metricsMisses,
+		metricsEvictions,
+		metricsEntries,
+		metricsBytes,
+	)
+}
+
+func metricsHit(name string) {
+	metricsHits.With(prometheus.Labels{
+		metricsLabelCache: name,
+	}).Inc()
+}
+
+func metricsMissed(name string) {
+	metricsMisses.With(prometheus.Labels{
+		metricsLabelCache: name,
+	}).Inc()
+}
+
+func metricsEvicted(name string) {
+	metricsEvictions.With(prometheus.Labels{
+		metricsLabelCache: name,
+	}).Inc()
+}
+
+func metricsSizeObserved(name string, entries int, bytes int64) {
+	labels := prometheus.Labels{
+		metricsLabelCache: name,
+	}
+	metricsEntries.With(labels).Set(float64(entries))
+	metricsBytes.With(labels).Set(float64(bytes))
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorycache
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/pipe-cd/pipe/pkg/cache"
+)
+
+const defaultMaxEntries = 1000
+
+// Sizer is implemented by the values knowing their size in bytes.
+// The values not implementing this are counted as zero bytes.
+type Sizer interface {
+	Size() int64
+}
+
+// SizedLRUCache is a cache bounded by both the number of entries and their total size.
+// The least recently used entries are evicted when any of the bounds is exceeded.
+// Its hits, misses and evictions are exported as Prometheus metrics labeled by its name.
+type SizedLRUCache struct {
+	name       string
+	maxEntries int
+	maxBytes   int64
+
+	lru   *simplelru.LRU
+	bytes int64
+	mu    sync.Mutex
+	group singleflight.Group
+}
+
+type SizedLRUCacheOption func(*SizedLRUCache)
+
+// WithMaxEntries sets the maximum number of entries.
+// Default is 1000.
+func WithMaxEntries(n int) SizedLRUCacheOption {
+	return func(c *SizedLRUCache) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes sets the maximum total size of the values implementing Sizer.
+// Zero means unlimited.
+func WithMaxBytes(n int64) SizedLRUCacheOption {
+	return func(c *SizedLRUCache) {
+		c.maxBytes = n
+	}
+}
+
+// NewSizedLRUCache creates a new SizedLRUCache.
+// The name is used to distinguish the metrics of each cache.
+func NewSizedLRUCache(name string, opts ...SizedLRUCacheOption) (*SizedLRUCache, error) {
+	c := &SizedLRUCache{
+		name:       name,
+		maxEntries: defaultMaxEntries,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxBytes < 0 {
+		return nil, fmt.Errorf("max bytes must not be negative")
+	}
+	lru, err := simplelru.NewLRU(c.maxEntries, c.onEvicted)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = lru
+	return c, nil
+}
+
+func (c *SizedLRUCache) Get(key interface{}) (interface{}, error) {
+	c.mu.Lock()
+	value, ok := c.lru.Get(key)
+	c.mu.Unlock()
+
+	if !ok {
+		metricsMissed(c.name)
+		return nil, cache.ErrNotFound
+	}
+	metricsHit(c.name)
+	return value, nil
+}
+
+func (c *SizedLRUCache) Put(key interface{}, value interface{}) error {
+	size := sizeOf(value)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return fmt.Errorf("value of %d bytes exceeds the cache limit of %d bytes", size, c.maxBytes)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Remove the old one first to keep the total size right.
+	c.lru.Remove(key)
+	if c.lru.Add(key, value) {
+		metricsEvicted(c.name)
+	}
+	c.bytes += size
+	for c.maxBytes > 0 && c.bytes > c.maxBytes {
+		c.lru.RemoveOldest()
+		metricsEvicted(c.name)
+	}
+	c.updateMetrics()
+	return nil
+}
+
+func (c *SizedLRUCache) Delete(key interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Remove(key)
+	c.updateMetrics()
+	return nil
+}
+
+// GetOrLoad returns the cached value of the given key or loads it by the given function.
+// The concurrent calls for the same key wait for only one load to finish.
+func (c *SizedLRUCache) GetOrLoad(key interface{}, load func() (interface{}, error)) (interface{}, error) {
+	if value, err := c.Get(key); err == nil {
+		return value, nil
+	}
+	value, err, _ := c.group.Do(fmt.Sprint(key), func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		// The loaded value is still returned even if it was too large to be cached.
+		c.Put(key, value)
+		return value, nil
+	})
+	return value, err
+}
+
+// onEvicted is called while holding the lock
+// whenever an entry was removed from the underlying LRU.
+func (c *SizedLRUCache) onEvicted(_ interface{}, value interface{}) {
+	c.bytes -= sizeOf(value)
+}
+
+func (c *SizedLRUCache) updateMetrics() {
+	metricsSizeObserved(c.name, c.lru.Len(), c.bytes)
+}
+
+func sizeOf(value interface{}) int64 {
+	if s, ok := value.(Sizer); ok {
+		return s.Size()
+	}
+	return 0
+}
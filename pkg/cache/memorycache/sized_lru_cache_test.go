@@ -0,0 +1,133 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorycache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/cache"
+)
+
+type sizedValue int64
+
+func (v sizedValue) Size() int64 {
+	return int64(v)
+}
+
+func TestSizedLRUCacheMaxEntries(t *testing.T) {
+	c, err := NewSizedLRUCache("test-entries", WithMaxEntries(2))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Put("key-1", "value-1"))
+	require.NoError(t, c.Put("key-2", "value-2"))
+
+	// Make key-1 the most recently used one.
+	value, err := c.Get("key-1")
+	require.NoError(t, err)
+	assert.Equal(t, "value-1", value)
+
+	require.NoError(t, c.Put("key-3", "value-3"))
+	_, err = c.Get("key-2")
+	assert.Equal(t, cache.ErrNotFound, err)
+
+	require.NoError(t, c.Delete("key-1"))
+	_, err = c.Get("key-1")
+	assert.Equal(t, cache.ErrNotFound, err)
+
+	value, err = c.Get("key-3")
+	require.NoError(t, err)
+	assert.Equal(t, "value-3", value)
+}
+
+func TestSizedLRUCacheMaxBytes(t *testing.T) {
+	c, err := NewSizedLRUCache("test-bytes", WithMaxBytes(10))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Put("key-1", sizedValue(4)))
+	require.NoError(t, c.Put("key-2", sizedValue(4)))
+	assert.Equal(t, int64(8), c.bytes)
+
+	// Replacing a value must not count the old one.
+	require.NoError(t, c.Put("key-2", sizedValue(5)))
+	assert.Equal(t, int64(9), c.bytes)
+
+	// The least recently used one is evicted to keep the limit.
+	require.NoError(t, c.Put("key-3", sizedValue(3)))
+	assert.Equal(t, int64(8), c.bytes)
+	_, err = c.Get("key-1")
+	assert.Equal(t, cache.ErrNotFound, err)
+
+	// A value larger than the limit is never cached.
+	assert.Error(t, c.Put("key-4", sizedValue(11)))
+	assert.Equal(t, int64(8), c.bytes)
+
+	require.NoError(t, c.Delete("key-2"))
+	assert.Equal(t, int64(3), c.bytes)
+}
+
+func TestSizedLRUCacheGetOrLoad(t *testing.T) {
+	c, err := NewSizedLRUCache("test-load")
+	require.NoError(t, err)
+
+	var (
+		loads   int32
+		start   = make(chan struct{})
+		wg      sync.WaitGroup
+		results = make([]interface{}, 10)
+	)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			value, err := c.GetOrLoad("key", func() (interface{}, error) {
+				atomic.AddInt32(&loads, 1)
+				time.Sleep(100 * time.Millisecond)
+				return "value", nil
+			})
+			require.NoError(t, err)
+			results[i] = value
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads)
+	for _, r := range results {
+		assert.Equal(t, "value", r)
+	}
+
+	// The loaded value is cached.
+	value, err := c.GetOrLoad("key", func() (interface{}, error) {
+		return nil, errors.New("must not be loaded")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// The failure of loading is not cached.
+	_, err = c.GetOrLoad("failure", func() (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	assert.Error(t, err)
+	_, err = c.Get("failure")
+	assert.Equal(t, cache.ErrNotFound, err)
+}
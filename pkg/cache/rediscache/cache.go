@@ -15,6 +15,7 @@
 package rediscache
 
 import (
+	"fmt"
 	"time"
 
 	redigo "github.com/gomodule/redigo/redis"
@@ -23,28 +24,64 @@ import (
 	"github.com/pipe-cd/pipe/pkg/redis"
 )
 
+// Codec converts the cached values to and from the bytes stored in Redis.
+// It is required for caching values other than strings, bytes and numbers
+// because Redis does not keep the Go type of the stored value.
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+type Option func(*RedisCache)
+
+// WithCodec specifies the codec used to encode the values before storing
+// them into Redis and to decode them after loading.
+func WithCodec(codec Codec) Option {
+	return func(c *RedisCache) {
+		c.codec = codec
+	}
+}
+
+// WithKeyPrefix specifies a prefix prepended to all keys of this cache.
+// This helps to avoid key conflicts when multiple caches share the same Redis.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *RedisCache) {
+		c.keyPrefix = prefix
+	}
+}
+
 type RedisCache struct {
-	redis redis.Redis
-	ttl   uint
+	redis     redis.Redis
+	ttl       uint
+	codec     Codec
+	keyPrefix string
 }
 
-func NewCache(redis redis.Redis) *RedisCache {
-	return &RedisCache{
+func NewCache(redis redis.Redis, opts ...Option) *RedisCache {
+	c := &RedisCache{
 		redis: redis,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func NewTTLCache(redis redis.Redis, ttl time.Duration) *RedisCache {
-	return &RedisCache{
+func NewTTLCache(redis redis.Redis, ttl time.Duration, opts ...Option) *RedisCache {
+	c := &RedisCache{
 		redis: redis,
 		ttl:   uint(ttl.Seconds()),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *RedisCache) Get(k interface{}) (interface{}, error) {
 	conn := c.redis.Get()
 	defer conn.Close()
-	reply, err := conn.Do("GET", k)
+	reply, err := conn.Do("GET", c.key(k))
 	if err != nil {
 		if err == redigo.ErrNil {
 			return nil, cache.ErrNotFound
@@ -57,17 +94,31 @@ func (c *RedisCache) Get(k interface{}) (interface{}, error) {
 	if err, ok := reply.(redigo.Error); ok {
 		return nil, err
 	}
-	return reply, nil
+	if c.codec == nil {
+		return reply, nil
+	}
+	data, err := redigo.Bytes(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.codec.Decode(data)
 }
 
 func (c *RedisCache) Put(k interface{}, v interface{}) error {
 	conn := c.redis.Get()
 	defer conn.Close()
+	if c.codec != nil {
+		data, err := c.codec.Encode(v)
+		if err != nil {
+			return err
+		}
+		v = data
+	}
 	var err error
 	if c.ttl == 0 {
-		_, err = conn.Do("SET", k, v)
+		_, err = conn.Do("SET", c.key(k), v)
 	} else {
-		_, err = conn.Do("SETEX", k, c.ttl, v)
+		_, err = conn.Do("SETEX", c.key(k), c.ttl, v)
 	}
 	return err
 }
@@ -75,6 +126,13 @@ func (c *RedisCache) Put(k interface{}, v interface{}) error {
 func (c *RedisCache) Delete(k interface{}) error {
 	conn := c.redis.Get()
 	defer conn.Close()
-	_, err := conn.Do("DEL", k)
+	_, err := conn.Do("DEL", c.key(k))
 	return err
 }
+
+func (c *RedisCache) key(k interface{}) interface{} {
+	if c.keyPrefix == "" {
+		return k
+	}
+	return fmt.Sprintf("%s%v", c.keyPrefix, k)
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rediscache
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipe/pkg/cache"
+)
+
+type fakeRedis struct {
+	values map[string][]byte
+	ttls   map[string]int64
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		values: make(map[string][]byte),
+		ttls:   make(map[string]int64),
+	}
+}
+
+func (r *fakeRedis) Get() redigo.Conn {
+	return &fakeConn{redis: r}
+}
+
+func (r *fakeRedis) Close() error {
+	return nil
+}
+
+type fakeConn struct {
+	redigo.Conn
+	redis *fakeRedis
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	key := fmt.Sprint(args[0])
+	switch cmd {
+	case "GET":
+		v, ok := c.redis.values[key]
+		if !ok {
+			return nil, nil
+		}
+		return v, nil
+	case "SET":
+		c.redis.values[key] = toBytes(args[1])
+		return "OK", nil
+	case "SETEX":
+		c.redis.values[key] = toBytes(args[2])
+		c.redis.ttls[key] = int64(args[1].(uint))
+		return "OK", nil
+	case "DEL":
+		delete(c.redis.values, key)
+		return int64(1), nil
+	}
+	return nil, fmt.Errorf("unsupported command %s", cmd)
+}
+
+func toBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type itemCodec struct{}
+
+func (itemCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (itemCodec) Decode(data []byte) (interface{}, error) {
+	var i item
+	if err := json.Unmarshal(data, &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func TestCache(t *testing.T) {
+	rd := newFakeRedis()
+	c := NewCache(rd)
+
+	_, err := c.Get("key")
+	assert.Equal(t, cache.ErrNotFound, err)
+
+	require.NoError(t, c.Put("key", "value"))
+	v, err := c.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), v)
+
+	require.NoError(t, c.Delete("key"))
+	_, err = c.Get("key")
+	assert.Equal(t, cache.ErrNotFound, err)
+}
+
+func TestTTLCacheWithCodec(t *testing.T) {
+	rd := newFakeRedis()
+	c := NewTTLCache(rd, time.Hour, WithCodec(itemCodec{}), WithKeyPrefix("items:"))
+
+	require.NoError(t, c.Put("a", &item{Name: "a", Count: 2}))
+	assert.Equal(t, int64(3600), rd.ttls["items:a"])
+	assert.Equal(t, `{"name":"a","count":2}`, string(rd.values["items:a"]))
+
+	v, err := c.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, &item{Name: "a", Count: 2}, v)
+
+	// A value that cannot be decoded must be reported as an error.
+	rd.values["items:b"] = []byte("invalid")
+	_, err = c.Get("b")
+	assert.Error(t, err)
+
+	require.NoError(t, c.Delete("a"))
+	_, err = c.Get("a")
+	assert.Equal(t, cache.ErrNotFound, err)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"google.golang.org/api/option"
 
 	"github.com/pipe-cd/pipe/pkg/log"
@@ -34,7 +35,10 @@ import (
 
 type Telemetry struct {
 	Logger *zap.Logger
-	Flags  TelemetryFlags
+	// LogLevels can be used to change the logging level
+	// of each component while running.
+	LogLevels *log.Levels
+	Flags     TelemetryFlags
 }
 
 type Runner func(ctx context.Context, telemetry Telemetry) error
@@ -60,12 +64,13 @@ func runWithContext(cmd *cobra.Command, signalCh <-chan os.Signal, runner Runner
 	version := version.Get()
 
 	// Initialize logger.
-	logger, err := newLogger(service, version.Version, flags.LogLevel, flags.LogEncoding)
+	logger, levels, err := newLogger(service, version.Version, flags.LogLevel, flags.LogEncoding)
 	if err != nil {
 		return err
 	}
 	defer logger.Sync()
 	telemetry.Logger = logger
+	telemetry.LogLevels = levels
 
 	// Start running profiler.
 	if flags.Profile {
@@ -90,7 +95,13 @@ func runWithContext(cmd *cobra.Command, signalCh <-chan os.Signal, runner Runner
 	return runner(ctx, telemetry)
 }
 
-func newLogger(service, version, level, encoding string) (*zap.Logger, error) {
+func newLogger(service, version, level, encoding string) (*zap.Logger, *log.Levels, error) {
+	var defaultLevel zapcore.Level
+	if err := defaultLevel.Set(level); err != nil {
+		return nil, nil, err
+	}
+	levels := log.NewLevels(defaultLevel)
+
 	configs := log.DefaultConfigs
 	configs.ServiceContext = &log.ServiceContext{
 		Service: service,
@@ -98,7 +109,13 @@ func newLogger(service, version, level, encoding string) (*zap.Logger, error) {
 	}
 	configs.Level = level
 	configs.Encoding = log.EncodingType(encoding)
-	return log.NewLogger(configs)
+	configs.Levels = levels
+
+	logger, err := log.NewLogger(configs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logger, levels, nil
 }
 
 func startProfiler(service, version, credentialsFile string, debugLogging bool, logger *zap.Logger) error {
@@ -46,7 +46,8 @@ func TestRunWithContext(t *testing.T) {
 }
 
 func TestNewLogger(t *testing.T) {
-	logger, err := newLogger("service", "1.0.0", "debug", "json")
+	logger, levels, err := newLogger("service", "1.0.0", "debug", "json")
 	assert.NoError(t, err)
 	assert.NotNil(t, logger)
+	assert.NotNil(t, levels)
 }
@@ -16,6 +16,8 @@ package config
 
 import (
 	"fmt"
+	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -38,12 +40,48 @@ type AnalysisMetrics struct {
 	// the analysis will be considered a failure after 2 failures.
 	// Default is 0.
 	FailureLimit int `json:"failureLimit"`
+	// Acceptable number of inconclusive results, such as the query errors or no data.
+	// Default is 0, which means the inconclusive results are counted as failures.
+	InconclusiveLimit int `json:"inconclusiveLimit"`
+	// The number of latest query results the limits are checked against.
+	// For instance, failureLimit 2 with failureWindow 5 fails the analysis
+	// when 3 of the latest 5 results are failures.
+	// Default is 0, which means all results since the stage started are checked.
+	FailureWindow int `json:"failureWindow"`
 	// If true, it considers as a success when no data returned from the analysis provider.
 	// Default is false.
 	SkipOnNoData bool `json:"skipOnNoData"`
 	// How long after which the query times out.
 	// Default is 30s.
 	Timeout Duration `json:"timeout"`
+
+	// How to decide whether the query result is expected.
+	// Default is THRESHOLD.
+	Strategy AnalysisStrategy `json:"strategy"`
+	// Which direction of the difference between two variants is considered as failure.
+	// This is used only by the CANARY_BASELINE and PREVIOUS strategies.
+	// Default is EITHER.
+	Deviation AnalysisDeviation `json:"deviation"`
+	// The statistical test used to compare two variants.
+	// This is used only by the CANARY_BASELINE and PREVIOUS strategies.
+	// Default is MANN_WHITNEY.
+	StatisticalTest AnalysisStatisticalTest `json:"statisticalTest"`
+	// The p-value below which the difference found by the Mann-Whitney U test
+	// is considered as significant.
+	// Default is 0.05.
+	Significance float64 `json:"significance"`
+	// The number of standard deviations of the baseline values
+	// the mean of the canary values is allowed to differ by.
+	// Default is 3.
+	ZScoreThreshold float64 `json:"zScoreThreshold"`
+	// The custom arguments embedded into the query for the canary variant.
+	// They can be referenced as {{ .VariantCustomArgs.xxx }} in the query.
+	CanaryArgs map[string]string `json:"canaryArgs"`
+	// The custom arguments embedded into the query for the baseline variant.
+	BaselineArgs map[string]string `json:"baselineArgs"`
+	// The custom arguments embedded into the query for the primary variant.
+	// This is used only by the PREVIOUS strategy.
+	PrimaryArgs map[string]string `json:"primaryArgs"`
 }
 
 func (m *AnalysisMetrics) Validate() error {
@@ -56,12 +94,84 @@ func (m *AnalysisMetrics) Validate() error {
 	if m.Interval == 0 {
 		return fmt.Errorf("missing \"interval\" field")
 	}
-	if err := m.Expected.Validate(); err != nil {
-		return err
+	if m.FailureLimit < 0 || m.InconclusiveLimit < 0 || m.FailureWindow < 0 {
+		return fmt.Errorf("failureLimit, inconclusiveLimit and failureWindow must not be negative")
+	}
+	if m.FailureWindow > 0 {
+		if m.FailureLimit >= m.FailureWindow {
+			return fmt.Errorf("failureLimit must be less than failureWindow")
+		}
+		if m.InconclusiveLimit >= m.FailureWindow {
+			return fmt.Errorf("inconclusiveLimit must be less than failureWindow")
+		}
+	}
+	switch m.Strategy {
+	case "", AnalysisStrategyThreshold:
+		if err := m.Expected.Validate(); err != nil {
+			return err
+		}
+		return nil
+	case AnalysisStrategyCanaryBaseline, AnalysisStrategyPrevious:
+	default:
+		return fmt.Errorf("unsupported strategy %q", m.Strategy)
+	}
+	switch m.Deviation {
+	case "", AnalysisDeviationEither, AnalysisDeviationHigh, AnalysisDeviationLow:
+	default:
+		return fmt.Errorf("unsupported deviation %q", m.Deviation)
+	}
+	switch m.StatisticalTest {
+	case "", AnalysisStatisticalTestMannWhitney, AnalysisStatisticalTestZScore:
+	default:
+		return fmt.Errorf("unsupported statisticalTest %q", m.StatisticalTest)
+	}
+	if m.Significance < 0 || m.Significance >= 1 {
+		return fmt.Errorf("significance must be in the range [0, 1)")
+	}
+	if m.ZScoreThreshold < 0 {
+		return fmt.Errorf("zScoreThreshold must not be negative")
 	}
 	return nil
 }
 
+// AnalysisStrategy represents how to decide whether the query result is expected.
+type AnalysisStrategy string
+
+const (
+	// AnalysisStrategyThreshold checks that all values are within the expected range.
+	AnalysisStrategyThreshold AnalysisStrategy = "THRESHOLD"
+	// AnalysisStrategyCanaryBaseline compares the values of the canary variant
+	// with the ones of the baseline variant running at the same time.
+	AnalysisStrategyCanaryBaseline AnalysisStrategy = "CANARY_BASELINE"
+	// AnalysisStrategyPrevious compares the values with the snapshot of the ones
+	// reported by the previous version before the deployment was started.
+	AnalysisStrategyPrevious AnalysisStrategy = "PREVIOUS"
+)
+
+// AnalysisDeviation represents which direction of difference is considered as failure.
+type AnalysisDeviation string
+
+const (
+	// AnalysisDeviationEither fails when the values are higher or lower than the compared ones.
+	AnalysisDeviationEither AnalysisDeviation = "EITHER"
+	// AnalysisDeviationHigh fails only when the values are higher than the compared ones.
+	AnalysisDeviationHigh AnalysisDeviation = "HIGH"
+	// AnalysisDeviationLow fails only when the values are lower than the compared ones.
+	AnalysisDeviationLow AnalysisDeviation = "LOW"
+)
+
+// AnalysisStatisticalTest represents the statistical test used to compare two variants.
+type AnalysisStatisticalTest string
+
+const (
+	// AnalysisStatisticalTestMannWhitney uses the Mann-Whitney U test,
+	// which does not assume any distribution of the values.
+	AnalysisStatisticalTestMannWhitney AnalysisStatisticalTest = "MANN_WHITNEY"
+	// AnalysisStatisticalTestZScore checks how many standard deviations of the compared values
+	// the mean of the values differs by.
+	AnalysisStatisticalTestZScore AnalysisStatisticalTest = "Z_SCORE"
+)
+
 // AnalysisExpected defines the range used for metrics analysis.
 type AnalysisExpected struct {
 	Min *float64 `json:"min"`
@@ -116,19 +226,36 @@ type AnalysisLog struct {
 	// Default is false.
 	SkipOnNoData bool `json:"skipOnNoData"`
 	// How long after which the query times out.
+	// Default is 30s.
 	Timeout  Duration `json:"timeout"`
 	Provider string   `json:"provider"`
+	// The maximum number of log entries allowed to match the query in each interval.
+	// Default is 0, which means any matched entry is considered as failure.
+	Threshold int `json:"threshold"`
 }
 
 // AnalysisHTTP contains common configurable values for deployment analysis with http.
 type AnalysisHTTP struct {
-	URL    string `json:"url"`
+	URL string `json:"url"`
+	// The HTTP method of the request.
+	// Default is GET.
 	Method string `json:"method"`
 	// Custom headers to set in the request. HTTP allows repeated headers.
-	Headers          []AnalysisHeader `json:"headers"`
-	ExpectedCode     int              `json:"expectedCode"`
-	ExpectedResponse string           `json:"expectedResponse"`
-	Interval         Duration         `json:"interval"`
+	Headers []AnalysisHeader `json:"headers"`
+	// The body of the request.
+	Body string `json:"body"`
+	// The expected status code of the response.
+	// Deprecated: Use ExpectedCodes instead.
+	ExpectedCode int `json:"expectedCode"`
+	// The list of status codes considered as success.
+	// Default is 200 if neither this nor ExpectedCode is specified.
+	ExpectedCodes []int `json:"expectedCodes"`
+	// The regular expression the response body must match.
+	ExpectedResponse string `json:"expectedResponse"`
+	// The maximum latency allowed for the response.
+	// Default is 0, which means no limit.
+	MaxLatency Duration `json:"maxLatency"`
+	Interval   Duration `json:"interval"`
 	// Maximum number of failed checks before the response is considered as failure.
 	FailureLimit int `json:"failureLimit"`
 	// If true, it considers as success when no data returned from the analysis provider.
@@ -137,6 +264,42 @@ type AnalysisHTTP struct {
 	Timeout      Duration `json:"timeout"`
 }
 
+func (h *AnalysisHTTP) Validate() error {
+	if h.URL == "" {
+		return fmt.Errorf("missing \"url\" field")
+	}
+	if h.Interval == 0 {
+		return fmt.Errorf("missing \"interval\" field")
+	}
+	for _, code := range h.StatusCodes() {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid expected status code %d", code)
+		}
+	}
+	if h.ExpectedResponse != "" {
+		if _, err := regexp.Compile(h.ExpectedResponse); err != nil {
+			return fmt.Errorf("invalid \"expectedResponse\" field: %w", err)
+		}
+	}
+	if h.MaxLatency < 0 {
+		return fmt.Errorf("maxLatency must not be negative")
+	}
+	return nil
+}
+
+// StatusCodes returns the list of status codes considered as success.
+func (h *AnalysisHTTP) StatusCodes() []int {
+	codes := make([]int, 0, len(h.ExpectedCodes)+1)
+	if h.ExpectedCode != 0 {
+		codes = append(codes, h.ExpectedCode)
+	}
+	codes = append(codes, h.ExpectedCodes...)
+	if len(codes) == 0 {
+		codes = append(codes, http.StatusOK)
+	}
+	return codes
+}
+
 type AnalysisHeader struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
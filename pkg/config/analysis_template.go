@@ -27,8 +27,9 @@ type AnalysisTemplateSpec struct {
 	HTTPs   map[string]AnalysisHTTP    `json:"https"`
 }
 
-// LoadAnalysisTemplate finds the config file for the analysis template in the .pipe
-// directory first up. And returns parsed config, ErrNotFound is returned if not found.
+// LoadAnalysisTemplate gives back parsed AnalysisTemplate config after merging all
+// AnalysisTemplate files placed under the .pipe directory, so that the templates can be
+// shared by all applications in the repository. ErrNotFound is returned if not found.
 func LoadAnalysisTemplate(repoRoot string) (*AnalysisTemplateSpec, error) {
 	dir := filepath.Join(repoRoot, SharedConfigurationDirName)
 	files, err := ioutil.ReadDir(dir)
@@ -39,22 +40,81 @@ func LoadAnalysisTemplate(repoRoot string) (*AnalysisTemplateSpec, error) {
 		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
 	}
 
+	// Start merging templates defined across multiple files.
+	var spec *AnalysisTemplateSpec
 	for _, f := range files {
 		if f.IsDir() {
 			continue
 		}
+		// Other kinds of files such as README can also be placed in the directory.
+		if ext := filepath.Ext(f.Name()); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
 		path := filepath.Join(dir, f.Name())
 		cfg, err := LoadFromYAML(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
 		}
-		if cfg.Kind == KindAnalysisTemplate {
-			return cfg.AnalysisTemplateSpec, nil
+		if cfg.Kind != KindAnalysisTemplate {
+			continue
+		}
+		if spec == nil {
+			spec = &AnalysisTemplateSpec{
+				Metrics: make(map[string]AnalysisMetrics),
+				Logs:    make(map[string]AnalysisLog),
+				HTTPs:   make(map[string]AnalysisHTTP),
+			}
+		}
+		if err := spec.merge(cfg.AnalysisTemplateSpec); err != nil {
+			return nil, fmt.Errorf("failed to merge analysis template file %s: %w", path, err)
 		}
 	}
-	return nil, ErrNotFound
+
+	if spec == nil {
+		return nil, ErrNotFound
+	}
+	return spec, nil
 }
 
 func (s *AnalysisTemplateSpec) Validate() error {
+	for name := range s.Metrics {
+		if name == "" {
+			return fmt.Errorf("metrics template name must not be empty")
+		}
+	}
+	for name := range s.Logs {
+		if name == "" {
+			return fmt.Errorf("log template name must not be empty")
+		}
+	}
+	for name := range s.HTTPs {
+		if name == "" {
+			return fmt.Errorf("http template name must not be empty")
+		}
+	}
+	return nil
+}
+
+// merge adds all templates of the given spec into this one.
+// The same template name is not allowed to be defined in multiple files.
+func (s *AnalysisTemplateSpec) merge(other *AnalysisTemplateSpec) error {
+	for name, t := range other.Metrics {
+		if _, ok := s.Metrics[name]; ok {
+			return fmt.Errorf("metrics template %s was defined multiple times", name)
+		}
+		s.Metrics[name] = t
+	}
+	for name, t := range other.Logs {
+		if _, ok := s.Logs[name]; ok {
+			return fmt.Errorf("log template %s was defined multiple times", name)
+		}
+		s.Logs[name] = t
+	}
+	for name, t := range other.HTTPs {
+		if _, ok := s.HTTPs[name]; ok {
+			return fmt.Errorf("http template %s was defined multiple times", name)
+		}
+		s.HTTPs[name] = t
+	}
 	return nil
 }
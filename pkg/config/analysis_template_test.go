@@ -13,3 +13,61 @@
 // limitations under the License.
 
 package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAnalysisTemplate(t *testing.T) {
+	t.Run("merge all template files", func(t *testing.T) {
+		got, err := LoadAnalysisTemplate("testdata")
+		require.NoError(t, err)
+
+		assert.Len(t, got.Metrics, 3)
+		assert.Contains(t, got.Metrics, "app_http_error_percentage")
+		assert.Contains(t, got.Metrics, "container_cpu_usage_seconds_total")
+		assert.Contains(t, got.Metrics, "grpc_error_rate-percentage")
+		assert.Equal(t, AnalysisHTTP{
+			URL:           "http://{{ .App.Name }}.{{ .Args.namespace }}.svc.cluster.local/healthz",
+			Method:        "GET",
+			ExpectedCodes: []int{200},
+			Interval:      Duration(30 * time.Second),
+			FailureLimit:  1,
+		}, got.HTTPs["health_check"])
+	})
+
+	t.Run("no .pipe directory", func(t *testing.T) {
+		_, err := LoadAnalysisTemplate(t.TempDir())
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("duplicated template name", func(t *testing.T) {
+		root := t.TempDir()
+		dir := filepath.Join(root, SharedConfigurationDirName)
+		require.NoError(t, os.Mkdir(dir, 0700))
+
+		data := []byte(`apiVersion: pipecd.dev/v1beta1
+kind: AnalysisTemplate
+spec:
+  metrics:
+    error_rate:
+      query: error_rate
+      expected:
+        max: 0.1
+      interval: 1m
+      provider: prometheus-dev
+`)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.yaml"), data, 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.yaml"), data, 0600))
+
+		_, err := LoadAnalysisTemplate(root)
+		assert.Error(t, err)
+	})
+}
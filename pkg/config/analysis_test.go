@@ -63,3 +63,187 @@ func TestAnalysisExpectedString(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalysisMetricsValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		metrics AnalysisMetrics
+		wantErr bool
+	}{
+		{
+			name: "valid threshold strategy",
+			metrics: AnalysisMetrics{
+				Provider: "prometheus-dev",
+				Query:    "rate(errors[1m])",
+				Interval: Duration(1),
+				Expected: AnalysisExpected{Max: floatPointer(0.1)},
+			},
+		},
+		{
+			name: "threshold strategy without expected range",
+			metrics: AnalysisMetrics{
+				Provider: "prometheus-dev",
+				Query:    "rate(errors[1m])",
+				Interval: Duration(1),
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid canary baseline strategy",
+			metrics: AnalysisMetrics{
+				Provider:        "prometheus-dev",
+				Query:           "rate(errors{variant=\"{{ .Variant.Name }}\"}[1m])",
+				Interval:        Duration(1),
+				Strategy:        AnalysisStrategyCanaryBaseline,
+				Deviation:       AnalysisDeviationHigh,
+				StatisticalTest: AnalysisStatisticalTestZScore,
+				ZScoreThreshold: 2,
+			},
+		},
+		{
+			name: "valid failure window",
+			metrics: AnalysisMetrics{
+				Provider:          "prometheus-dev",
+				Query:             "rate(errors[1m])",
+				Interval:          Duration(1),
+				Expected:          AnalysisExpected{Max: floatPointer(0.1)},
+				FailureLimit:      2,
+				InconclusiveLimit: 3,
+				FailureWindow:     5,
+			},
+		},
+		{
+			name: "failure limit not less than failure window",
+			metrics: AnalysisMetrics{
+				Provider:      "prometheus-dev",
+				Query:         "rate(errors[1m])",
+				Interval:      Duration(1),
+				Expected:      AnalysisExpected{Max: floatPointer(0.1)},
+				FailureLimit:  3,
+				FailureWindow: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative inconclusive limit",
+			metrics: AnalysisMetrics{
+				Provider:          "prometheus-dev",
+				Query:             "rate(errors[1m])",
+				Interval:          Duration(1),
+				Expected:          AnalysisExpected{Max: floatPointer(0.1)},
+				InconclusiveLimit: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported strategy",
+			metrics: AnalysisMetrics{
+				Provider: "prometheus-dev",
+				Query:    "rate(errors[1m])",
+				Interval: Duration(1),
+				Strategy: "UNKNOWN",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid significance",
+			metrics: AnalysisMetrics{
+				Provider:     "prometheus-dev",
+				Query:        "rate(errors[1m])",
+				Interval:     Duration(1),
+				Strategy:     AnalysisStrategyPrevious,
+				Significance: 1.5,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.metrics.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestAnalysisHTTPValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		http    AnalysisHTTP
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			http: AnalysisHTTP{
+				URL:              "https://canary-endpoint.dev",
+				Interval:         Duration(1),
+				ExpectedCodes:    []int{200, 204},
+				ExpectedResponse: `"status":\s*"ok"`,
+				MaxLatency:       Duration(1),
+			},
+		},
+		{
+			name: "missing url",
+			http: AnalysisHTTP{
+				Interval: Duration(1),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing interval",
+			http: AnalysisHTTP{
+				URL: "https://canary-endpoint.dev",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid status code",
+			http: AnalysisHTTP{
+				URL:          "https://canary-endpoint.dev",
+				Interval:     Duration(1),
+				ExpectedCode: 1000,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid expected response",
+			http: AnalysisHTTP{
+				URL:              "https://canary-endpoint.dev",
+				Interval:         Duration(1),
+				ExpectedResponse: "(ok",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.http.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestAnalysisHTTPStatusCodes(t *testing.T) {
+	testcases := []struct {
+		name string
+		http AnalysisHTTP
+		want []int
+	}{
+		{
+			name: "default",
+			want: []int{200},
+		},
+		{
+			name: "both specified",
+			http: AnalysisHTTP{
+				ExpectedCode:  200,
+				ExpectedCodes: []int{201, 204},
+			},
+			want: []int{200, 201, 204},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.http.StatusCodes())
+		})
+	}
+}
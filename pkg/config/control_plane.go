@@ -39,6 +39,8 @@ type ControlPlaneSpec struct {
 	Cache ControlPlaneCache `json:"cache"`
 	// The configuration of insight collector.
 	InsightCollector ControlPlaneInsightCollector `json:"insightCollector"`
+	// The configuration of web console sessions.
+	Session ControlPlaneSession `json:"session"`
 	// List of debugging/quickstart projects defined in Control Plane configuration.
 	// Please note that do not use this to configure the projects running in the production.
 	Projects []ControlPlaneProject `json:"projects"`
@@ -47,6 +49,9 @@ type ControlPlaneSpec struct {
 }
 
 func (s *ControlPlaneSpec) Validate() error {
+	if err := s.Session.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -57,6 +62,9 @@ type ControlPlaneProject struct {
 	Desc string `json:"desc"`
 	// Static admin account of the project.
 	StaticAdmin ProjectStaticUser `json:"staticAdmin"`
+	// Whether the debugging of the live pods from the web console is enabled.
+	// Default is false.
+	PodDebugging bool `json:"podDebugging"`
 }
 
 type ProjectStaticUser struct {
@@ -240,6 +248,59 @@ func (c ControlPlaneCache) TTLDuration() time.Duration {
 	return c.TTL.Duration()
 }
 
+type ControlPlaneSession struct {
+	// How long an issued access token can be used before it must be refreshed.
+	// Default is 1h.
+	AccessTokenTTL Duration `json:"accessTokenTTL"`
+	// How long a session can stay inactive before it expires.
+	// A session is considered active while its access token is being refreshed.
+	// Default is 24h.
+	IdleTimeout Duration `json:"idleTimeout"`
+	// The maximum lifetime of a session since the user logged in.
+	// Default is 168h (7 days).
+	MaxLifetime Duration `json:"maxLifetime"`
+}
+
+func (s ControlPlaneSession) Validate() error {
+	if s.AccessTokenTTL < 0 || s.IdleTimeout < 0 || s.MaxLifetime < 0 {
+		return fmt.Errorf("session: durations must not be negative")
+	}
+	if s.IdleTimeoutDuration() < s.AccessTokenTTLDuration() {
+		return fmt.Errorf("session: idleTimeout must be greater than or equal to accessTokenTTL")
+	}
+	if s.MaxLifetimeDuration() < s.IdleTimeoutDuration() {
+		return fmt.Errorf("session: maxLifetime must be greater than or equal to idleTimeout")
+	}
+	return nil
+}
+
+func (s ControlPlaneSession) AccessTokenTTLDuration() time.Duration {
+	const defaultTTL = time.Hour
+
+	if s.AccessTokenTTL == 0 {
+		return defaultTTL
+	}
+	return s.AccessTokenTTL.Duration()
+}
+
+func (s ControlPlaneSession) IdleTimeoutDuration() time.Duration {
+	const defaultTimeout = 24 * time.Hour
+
+	if s.IdleTimeout == 0 {
+		return defaultTimeout
+	}
+	return s.IdleTimeout.Duration()
+}
+
+func (s ControlPlaneSession) MaxLifetimeDuration() time.Duration {
+	const defaultLifetime = 7 * 24 * time.Hour
+
+	if s.MaxLifetime == 0 {
+		return defaultLifetime
+	}
+	return s.MaxLifetime.Duration()
+}
+
 type DataStoreFireStoreConfig struct {
 	// The root path element considered as a logical namespace, e.g. `pipecd`.
 	Namespace string `json:"namespace"`
@@ -339,7 +400,10 @@ type FileStoreGCSConfig struct {
 	// The bucket name to store artifacts and logs in the piped.
 	Bucket string `json:"bucket"`
 	// The path to the credentials file for accessing GCS.
+	// Empty means the application default credentials (e.g. workload identity) are used.
 	CredentialsFile string `json:"credentialsFile"`
+	// The email of the service account to impersonate while accessing GCS.
+	ImpersonateServiceAccount string `json:"impersonateServiceAccount"`
 }
 
 type FileStoreS3Config struct {
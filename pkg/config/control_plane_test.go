@@ -80,6 +80,10 @@ func TestControlPlaneConfig(t *testing.T) {
 				Cache: ControlPlaneCache{
 					TTL: Duration(5 * time.Minute),
 				},
+				Session: ControlPlaneSession{
+					AccessTokenTTL: Duration(30 * time.Minute),
+					IdleTimeout:    Duration(12 * time.Hour),
+				},
 				InsightCollector: ControlPlaneInsightCollector{
 					DisabledMetrics: InsightCollectorDisabledMetrics{
 						DeploymentFrequency: true,
@@ -112,3 +116,54 @@ func TestControlPlaneConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestControlPlaneSessionValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		session ControlPlaneSession
+		wantErr bool
+	}{
+		{
+			name:    "default values",
+			session: ControlPlaneSession{},
+			wantErr: false,
+		},
+		{
+			name: "valid values",
+			session: ControlPlaneSession{
+				AccessTokenTTL: Duration(10 * time.Minute),
+				IdleTimeout:    Duration(time.Hour),
+				MaxLifetime:    Duration(24 * time.Hour),
+			},
+			wantErr: false,
+		},
+		{
+			name: "idle timeout is shorter than access token ttl",
+			session: ControlPlaneSession{
+				AccessTokenTTL: Duration(2 * time.Hour),
+				IdleTimeout:    Duration(time.Hour),
+			},
+			wantErr: true,
+		},
+		{
+			name: "max lifetime is shorter than idle timeout",
+			session: ControlPlaneSession{
+				MaxLifetime: Duration(time.Hour),
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative duration",
+			session: ControlPlaneSession{
+				AccessTokenTTL: Duration(-time.Minute),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.session.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -17,42 +17,115 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/pipe-cd/pipe/pkg/filematcher"
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
 const (
 	defaultWaitApprovalTimeout  = Duration(6 * time.Hour)
 	defaultAnalysisQueryTimeout = Duration(30 * time.Second)
+	// The values used by CHANGE_REQUEST stage.
+	defaultChangeRequestTimeout       = Duration(24 * time.Hour)
+	defaultChangeRequestCheckInterval = Duration(time.Minute)
 )
 
 type GenericDeploymentSpec struct {
+	// Additional attributes to identify the application,
+	// e.g. to route its notifications to the owner team.
+	Labels map[string]string `json:"labels"`
 	// Forcibly use QuickSync or Pipeline when commit message matched the specified pattern.
 	CommitMatcher DeploymentCommitMatcher `json:"commitMatcher"`
+	// Configuration for the planner to decide the deployment strategy
+	// based on the size of changes.
+	Planner DeploymentPlanner `json:"planner"`
 	// Pipeline for deploying progressively.
 	Pipeline *DeploymentPipeline `json:"pipeline"`
 	// The list of sealed secrets that should be decrypted.
 	SealedSecrets []SealedSecretMapping `json:"sealedSecrets"`
+	// The encrypted secrets those are expanded into the manifests
+	// at the referenced places such as {{ .encryptedSecrets.password }}.
+	Encryption *SecretEncryption `json:"encryption"`
+	// The files encrypted by SOPS those should be decrypted.
+	Sops *SopsDecryption `json:"sops"`
 	// List of directories or files where their changes will trigger the deployment.
 	// Regular expression can be used.
 	TriggerPaths []string `json:"triggerPaths,omitempty"`
 	// The maximum length of time to execute deployment before giving up.
+	// It is counted from the time piped started handling the deployment.
 	// Default is 6h.
 	Timeout Duration `json:"timeout,omitempty"`
+	// How to handle a newer deployment of this application
+	// while an older one is still running.
+	// Empty means queue.
+	ConcurrencyPolicy DeploymentConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
 }
 
+// DeploymentConcurrencyPolicy represents how to handle the deployments
+// of the same application those were triggered concurrently.
+// No matter which policy is used, two deployments of an application
+// are never executed at the same time.
+type DeploymentConcurrencyPolicy string
+
+const (
+	// DeploymentConcurrencyPolicyQueue makes the newer deployment
+	// wait until the running one has been completed.
+	DeploymentConcurrencyPolicyQueue DeploymentConcurrencyPolicy = "queue"
+	// DeploymentConcurrencyPolicyCancelOlder cancels the running deployment
+	// and all pending deployments except the most recently triggered one
+	// to jump straight to the latest desired state.
+	// The running deployment is not cancelled after starting any irreversible stage
+	// such as TERRAFORM_APPLY, it is waited for completion instead.
+	DeploymentConcurrencyPolicyCancelOlder DeploymentConcurrencyPolicy = "cancel-older"
+)
+
 func (s *GenericDeploymentSpec) Validate() error {
 	if s.Timeout == 0 {
 		s.Timeout = Duration(6 * time.Hour)
 	}
+	switch s.ConcurrencyPolicy {
+	case "", DeploymentConcurrencyPolicyQueue, DeploymentConcurrencyPolicyCancelOlder:
+	default:
+		return fmt.Errorf("unsupported concurrencyPolicy %q", s.ConcurrencyPolicy)
+	}
+	if err := s.Planner.Validate(); err != nil {
+		return err
+	}
+	if s.Encryption != nil {
+		if err := s.Encryption.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.Sops != nil {
+		if err := s.Sops.Validate(); err != nil {
+			return err
+		}
+	}
 	if s.Pipeline != nil {
+		if s.Pipeline.OnFailure != nil {
+			if err := s.Pipeline.OnFailure.Validate(); err != nil {
+				return err
+			}
+		}
 		for _, stage := range s.Pipeline.Stages {
 			if stage.AnalysisStageOptions != nil {
 				if err := stage.AnalysisStageOptions.Validate(); err != nil {
 					return err
 				}
 			}
+			if stage.WaitApprovalStageOptions != nil {
+				if err := stage.WaitApprovalStageOptions.Validate(); err != nil {
+					return err
+				}
+			}
+			if stage.ChangeRequestStageOptions != nil {
+				if err := stage.ChangeRequestStageOptions.Validate(); err != nil {
+					return err
+				}
+			}
 		}
 	}
 	return nil
@@ -89,14 +162,138 @@ type DeploymentCommitMatcher struct {
 	Pipeline string `json:"pipeline"`
 }
 
+// DeploymentPlanner configures how the planner decides the deployment strategy.
+type DeploymentPlanner struct {
+	// List of rules to forcibly use the pipeline instead of quick sync
+	// when the changes are larger than the configured thresholds.
+	// The pipeline is used when any of the rules is matched.
+	Rules []DeploymentPlannerRule `json:"rules"`
+	// What to do when the planner was unable to compare with
+	// the most recently deployed commit, such as for the first deployment
+	// or when failed to load the previously deployed manifests.
+	// Empty means applying all as before but failing the deployment
+	// when the previously deployed manifests could not be loaded.
+	Fallback DeploymentPlannerFallback `json:"fallback"`
+}
+
+// DeploymentPlannerFallback represents how to deploy when the planner
+// was unable to decide the strategy by comparing the changes.
+type DeploymentPlannerFallback string
+
+const (
+	// DeploymentPlannerFallbackQuickSync applies all by the quick sync.
+	DeploymentPlannerFallbackQuickSync DeploymentPlannerFallback = "quick-sync"
+	// DeploymentPlannerFallbackPipeline uses the specified pipeline.
+	// The quick sync is used when no pipeline was specified.
+	DeploymentPlannerFallbackPipeline DeploymentPlannerFallback = "pipeline"
+	// DeploymentPlannerFallbackApproval requires an approval before the quick sync.
+	DeploymentPlannerFallbackApproval DeploymentPlannerFallback = "approval"
+	// DeploymentPlannerFallbackFail fails the deployment.
+	DeploymentPlannerFallbackFail DeploymentPlannerFallback = "fail"
+)
+
+func (p DeploymentPlanner) Validate() error {
+	switch p.Fallback {
+	case "", DeploymentPlannerFallbackQuickSync, DeploymentPlannerFallbackPipeline, DeploymentPlannerFallbackApproval, DeploymentPlannerFallbackFail:
+	default:
+		return fmt.Errorf("unsupported planner.fallback %q", p.Fallback)
+	}
+	for i, r := range p.Rules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("planner.rules[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// DeploymentPlannerRule represents the thresholds of changes
+// to forcibly use the pipeline.
+// A rule is matched when all of its specified thresholds are exceeded.
+type DeploymentPlannerRule struct {
+	// The minimum number of resources added, deleted or updated.
+	// Zero means this is not checked.
+	ChangedResources int `json:"changedResources"`
+	// The minimum number of replicas increased or decreased in a workload.
+	// Zero means this is not checked.
+	ReplicasDelta int `json:"replicasDelta"`
+	// Whether the major version of a container image was bumped.
+	ImageMajorVersionBump bool `json:"imageMajorVersionBump"`
+	// List of file patterns relative to the repository root.
+	// Any of the files changed by the triggered commits must match one of them.
+	ChangedFiles []string `json:"changedFiles"`
+}
+
+func (r DeploymentPlannerRule) Validate() error {
+	if r.ChangedResources < 0 {
+		return fmt.Errorf("changedResources must not be negative")
+	}
+	if r.ReplicasDelta < 0 {
+		return fmt.Errorf("replicasDelta must not be negative")
+	}
+	if r.ChangedResources == 0 && r.ReplicasDelta == 0 && !r.ImageMajorVersionBump && len(r.ChangedFiles) == 0 {
+		return fmt.Errorf("at least one of changedResources, replicasDelta, imageMajorVersionBump or changedFiles must be specified")
+	}
+	if _, err := filematcher.NewPatternMatcher(r.ChangedFiles); err != nil {
+		return fmt.Errorf("invalid changedFiles: %w", err)
+	}
+	return nil
+}
+
 // DeploymentPipeline represents the way to deploy the application.
 // The pipeline is triggered by changes in any of the following objects:
 // - Target PodSpec (Target can be Deployment, DaemonSet, StatefulSet)
 // - ConfigMaps, Secrets that are mounted as volumes or envs in the deployment.
 type DeploymentPipeline struct {
 	Stages []PipelineStage `json:"stages"`
+	// What to do when the deployment was failed.
+	// This works only when the rollback stage was added by the autoRollback option.
+	// Empty means rolling back automatically.
+	OnFailure *DeploymentFailurePolicy `json:"onFailure"`
 }
 
+// DeploymentFailurePolicy represents what to do after a deployment was failed.
+type DeploymentFailurePolicy struct {
+	// Empty means rollback.
+	Action DeploymentFailureAction `json:"action"`
+	// List of users who can approve the rollback.
+	// Empty means anyone can approve.
+	// This is used only by the wait-approval action.
+	Approvers []string `json:"approvers"`
+	// The maximum length of time to wait for an approval.
+	// The rollback is started automatically after timing out.
+	// This is used only by the wait-approval action.
+	// Defaults to 6h.
+	Timeout Duration `json:"timeout"`
+}
+
+func (p *DeploymentFailurePolicy) Validate() error {
+	switch p.Action {
+	case "", DeploymentFailureActionRollback, DeploymentFailureActionWaitApproval, DeploymentFailureActionNone:
+	default:
+		return fmt.Errorf("unsupported onFailure action %q", p.Action)
+	}
+	if p.Timeout < 0 {
+		return fmt.Errorf("onFailure timeout must not be negative")
+	}
+	if p.Timeout == 0 {
+		p.Timeout = Duration(6 * time.Hour)
+	}
+	return nil
+}
+
+// DeploymentFailureAction represents what to do after a deployment was failed.
+type DeploymentFailureAction string
+
+const (
+	// DeploymentFailureActionRollback starts the rollback immediately.
+	DeploymentFailureActionRollback DeploymentFailureAction = "rollback"
+	// DeploymentFailureActionWaitApproval starts the rollback after being approved.
+	// The deployment is left as-is if the rollback was rejected.
+	DeploymentFailureActionWaitApproval DeploymentFailureAction = "wait-approval"
+	// DeploymentFailureActionNone leaves the deployment as-is without rollback.
+	DeploymentFailureActionNone DeploymentFailureAction = "none"
+)
+
 // PipelineStage represents a single stage of a pipeline.
 // This is used as a generic struct for all stage type.
 type PipelineStage struct {
@@ -105,16 +302,18 @@ type PipelineStage struct {
 	Desc    string
 	Timeout Duration
 
-	WaitStageOptions         *WaitStageOptions
-	WaitApprovalStageOptions *WaitApprovalStageOptions
-	AnalysisStageOptions     *AnalysisStageOptions
+	WaitStageOptions          *WaitStageOptions
+	WaitApprovalStageOptions  *WaitApprovalStageOptions
+	AnalysisStageOptions      *AnalysisStageOptions
+	ChangeRequestStageOptions *ChangeRequestStageOptions
 
-	K8sPrimaryRolloutStageOptions  *K8sPrimaryRolloutStageOptions
-	K8sCanaryRolloutStageOptions   *K8sCanaryRolloutStageOptions
-	K8sCanaryCleanStageOptions     *K8sCanaryCleanStageOptions
-	K8sBaselineRolloutStageOptions *K8sBaselineRolloutStageOptions
-	K8sBaselineCleanStageOptions   *K8sBaselineCleanStageOptions
-	K8sTrafficRoutingStageOptions  *K8sTrafficRoutingStageOptions
+	K8sPrimaryRolloutStageOptions   *K8sPrimaryRolloutStageOptions
+	K8sCanaryRolloutStageOptions    *K8sCanaryRolloutStageOptions
+	K8sCanaryCleanStageOptions      *K8sCanaryCleanStageOptions
+	K8sBaselineRolloutStageOptions  *K8sBaselineRolloutStageOptions
+	K8sBaselineCleanStageOptions    *K8sBaselineCleanStageOptions
+	K8sTrafficRoutingStageOptions   *K8sTrafficRoutingStageOptions
+	K8sPartitionRolloutStageOptions *K8sPartitionRolloutStageOptions
 
 	TerraformSyncStageOptions  *TerraformSyncStageOptions
 	TerraformPlanStageOptions  *TerraformPlanStageOptions
@@ -166,10 +365,17 @@ func (s *PipelineStage) UnmarshalJSON(data []byte) error {
 		if len(gs.With) > 0 {
 			err = json.Unmarshal(gs.With, s.AnalysisStageOptions)
 		}
-		for i := 0; i < len(s.AnalysisStageOptions.Metrics); i++ {
-			if s.AnalysisStageOptions.Metrics[i].Timeout <= 0 {
-				s.AnalysisStageOptions.Metrics[i].Timeout = defaultAnalysisQueryTimeout
-			}
+		s.AnalysisStageOptions.setDefaults()
+	case model.StageChangeRequest:
+		s.ChangeRequestStageOptions = &ChangeRequestStageOptions{}
+		if len(gs.With) > 0 {
+			err = json.Unmarshal(gs.With, s.ChangeRequestStageOptions)
+		}
+		if s.ChangeRequestStageOptions.Timeout <= 0 {
+			s.ChangeRequestStageOptions.Timeout = defaultChangeRequestTimeout
+		}
+		if s.ChangeRequestStageOptions.CheckInterval <= 0 {
+			s.ChangeRequestStageOptions.CheckInterval = defaultChangeRequestCheckInterval
 		}
 	case model.StageK8sPrimaryRollout:
 		s.K8sPrimaryRolloutStageOptions = &K8sPrimaryRolloutStageOptions{}
@@ -201,6 +407,16 @@ func (s *PipelineStage) UnmarshalJSON(data []byte) error {
 		if len(gs.With) > 0 {
 			err = json.Unmarshal(gs.With, s.K8sTrafficRoutingStageOptions)
 		}
+		for _, step := range s.K8sTrafficRoutingStageOptions.Steps {
+			if step.Analysis != nil {
+				step.Analysis.setDefaults()
+			}
+		}
+	case model.StageK8sPartitionRollout:
+		s.K8sPartitionRolloutStageOptions = &K8sPartitionRolloutStageOptions{}
+		if len(gs.With) > 0 {
+			err = json.Unmarshal(gs.With, s.K8sPartitionRolloutStageOptions)
+		}
 
 	case model.StageTerraformSync:
 		s.TerraformSyncStageOptions = &TerraformSyncStageOptions{}
@@ -262,6 +478,68 @@ type WaitApprovalStageOptions struct {
 	// Defaults to 6h.
 	Timeout   Duration `json:"timeout"`
 	Approvers []string `json:"approvers"`
+	// What to do when no approval was given until the timeout.
+	// Empty means fail.
+	TimeoutAction WaitApprovalTimeoutAction `json:"timeoutAction"`
+	// How often to send the reminder notification while waiting for an approval.
+	// Empty means no reminder is sent.
+	ReminderInterval Duration `json:"reminderInterval"`
+}
+
+func (w *WaitApprovalStageOptions) Validate() error {
+	switch w.TimeoutAction {
+	case "", WaitApprovalTimeoutActionFail, WaitApprovalTimeoutActionApprove, WaitApprovalTimeoutActionCancel:
+	default:
+		return fmt.Errorf("unsupported timeoutAction %q for WAIT_APPROVAL stage", w.TimeoutAction)
+	}
+	if w.ReminderInterval < 0 {
+		return fmt.Errorf("reminderInterval for WAIT_APPROVAL stage must not be negative")
+	}
+	return nil
+}
+
+// WaitApprovalTimeoutAction represents what to do
+// when the WAIT_APPROVAL stage was timed out.
+type WaitApprovalTimeoutAction string
+
+const (
+	// WaitApprovalTimeoutActionFail fails the deployment,
+	// then the rollback is started if it is enabled.
+	WaitApprovalTimeoutActionFail WaitApprovalTimeoutAction = "fail"
+	// WaitApprovalTimeoutActionApprove considers the stage as approved
+	// and continues the deployment.
+	WaitApprovalTimeoutActionApprove WaitApprovalTimeoutAction = "approve"
+	// WaitApprovalTimeoutActionCancel cancels the deployment without rollback.
+	WaitApprovalTimeoutActionCancel WaitApprovalTimeoutAction = "cancel"
+)
+
+// ChangeRequestStageOptions contains all configurable values for a CHANGE_REQUEST stage.
+type ChangeRequestStageOptions struct {
+	// The name of change management provider configured in the piped.
+	Provider string `json:"provider"`
+	// The number of an existing change request to be validated, e.g. CHG0030001.
+	// Empty means a new change request is created for the deployment.
+	Number string `json:"number"`
+	// The short description of the change request to be created.
+	// Empty means a description generated from the deployment is used.
+	ShortDescription string `json:"shortDescription"`
+	// The additional fields set to the change request to be created,
+	// e.g. assignment_group, category.
+	Fields map[string]string `json:"fields"`
+	// The maximum length of time to wait for the change request
+	// to be approved and its window to be started.
+	// Defaults to 24h.
+	Timeout Duration `json:"timeout"`
+	// How often to check the status of the change request.
+	// Defaults to 1m.
+	CheckInterval Duration `json:"checkInterval"`
+}
+
+func (c *ChangeRequestStageOptions) Validate() error {
+	if c.Provider == "" {
+		return fmt.Errorf("the CHANGE_REQUEST stage requires provider field")
+	}
+	return nil
 }
 
 // AnalysisStageOptions contains all configurable values for a K8S_ANALYSIS stage.
@@ -277,6 +555,19 @@ type AnalysisStageOptions struct {
 	Dynamic          AnalysisDynamic              `json:"dynamic"`
 }
 
+func (a *AnalysisStageOptions) setDefaults() {
+	for i := 0; i < len(a.Metrics); i++ {
+		if a.Metrics[i].Timeout <= 0 {
+			a.Metrics[i].Timeout = defaultAnalysisQueryTimeout
+		}
+	}
+	for i := 0; i < len(a.Logs); i++ {
+		if a.Logs[i].Timeout <= 0 {
+			a.Logs[i].Timeout = defaultAnalysisQueryTimeout
+		}
+	}
+}
+
 func (a *AnalysisStageOptions) Validate() error {
 	if a.Duration == 0 {
 		return fmt.Errorf("the ANALYSIS stage requires duration field")
@@ -307,6 +598,65 @@ type TemplatableAnalysisHTTP struct {
 	Template AnalysisTemplateRef `json:"template"`
 }
 
+type SecretEncryption struct {
+	// Map from the secret name to its encrypted value.
+	EncryptedSecrets map[string]string `json:"encryptedSecrets"`
+	// List of files where the encrypted secrets are referenced.
+	// Relative paths from the application directory.
+	// Only these files are handled as templates to avoid
+	// breaking the ones using the same syntax such as Helm templates.
+	DecryptionTargets []string `json:"decryptionTargets"`
+}
+
+func (e *SecretEncryption) Validate() error {
+	if len(e.DecryptionTargets) == 0 {
+		return fmt.Errorf("encryption.decryptionTargets must be set")
+	}
+	for _, t := range e.DecryptionTargets {
+		if t == "" {
+			return fmt.Errorf("encryption.decryptionTargets must not contain an empty path")
+		}
+		if !isInsideAppDir(t) {
+			return fmt.Errorf("decryption target %s must be inside the application directory", t)
+		}
+	}
+	return nil
+}
+
+type SopsDecryption struct {
+	// Version of sops will be used.
+	// Empty means the default version.
+	Version string `json:"version"`
+	// List of files encrypted by SOPS.
+	// Relative paths from the application directory.
+	// They are decrypted in place before planning and executing the deployment.
+	Files []string `json:"files"`
+}
+
+func (s *SopsDecryption) Validate() error {
+	if len(s.Files) == 0 {
+		return fmt.Errorf("sops.files must be set")
+	}
+	for _, f := range s.Files {
+		if f == "" {
+			return fmt.Errorf("sops.files must not contain an empty path")
+		}
+		if !isInsideAppDir(f) {
+			return fmt.Errorf("sops encrypted file %s must be inside the application directory", f)
+		}
+	}
+	return nil
+}
+
+// isInsideAppDir reports whether the given relative path
+// from the application directory points to a place inside it.
+func isInsideAppDir(path string) bool {
+	// Compare in the slash-separated form since the path may be
+	// written with either separator on Windows.
+	p := filepath.ToSlash(filepath.Clean(path))
+	return !filepath.IsAbs(path) && !strings.HasPrefix(p, "/") && p != ".." && !strings.HasPrefix(p, "../")
+}
+
 type SealedSecretMapping struct {
 	// Relative path from the application directory to sealed secret file.
 	Path string `json:"path"`
@@ -14,6 +14,14 @@
 
 package config
 
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
 // KubernetesDeploymentSpec represents a deployment configuration for Kubernetes application.
 type KubernetesDeploymentSpec struct {
 	GenericDeploymentSpec
@@ -34,6 +42,12 @@ type KubernetesDeploymentSpec struct {
 	Workloads []K8sResourceReference `json:"workloads"`
 	// Which method should be used for traffic routing.
 	TrafficRouting *KubernetesTrafficRouting `json:"trafficRouting"`
+	// Safety check to protect from accidentally deleting or scaling many resources.
+	Guard KubernetesDeploymentGuard `json:"guard"`
+	// How the Jobs of application should be handled.
+	Jobs KubernetesJobs `json:"jobs"`
+	// Configuration for resolving the image tags of workloads to digests.
+	ImageDigests KubernetesImageDigests `json:"imageDigests"`
 }
 
 // Validate returns an error if any wrong configuration value was found.
@@ -41,9 +55,197 @@ func (s *KubernetesDeploymentSpec) Validate() error {
 	if err := s.GenericDeploymentSpec.Validate(); err != nil {
 		return err
 	}
+	for _, r := range s.Input.IgnoreDiffs {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.Input.HelmOptions != nil {
+		for _, f := range s.Input.HelmOptions.ExternalValueFiles {
+			if f.Path == "" {
+				return fmt.Errorf("path must be specified for externalValueFiles")
+			}
+		}
+		if r := s.Input.HelmOptions.PostRenderer; r != nil {
+			if err := r.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	switch s.Input.ApplyEngine {
+	case "", KubernetesApplyEngineKubectl, KubernetesApplyEngineKapp:
+	default:
+		return fmt.Errorf("unsupported input.applyEngine %q", s.Input.ApplyEngine)
+	}
+	if s.Input.ApplyEngine == KubernetesApplyEngineKapp && s.Pipeline != nil {
+		// kapp deploys all manifests of the application at once
+		// so the stages applying only a part of them are not supported.
+		for _, stage := range s.Pipeline.Stages {
+			switch stage.Name {
+			case model.StageK8sPrimaryRollout,
+				model.StageK8sCanaryRollout,
+				model.StageK8sCanaryClean,
+				model.StageK8sBaselineRollout,
+				model.StageK8sBaselineClean,
+				model.StageK8sTrafficRouting,
+				model.StageK8sPartitionRollout:
+				return fmt.Errorf("stage %s is not supported when input.applyEngine is %q", stage.Name, KubernetesApplyEngineKapp)
+			}
+		}
+	}
+	if err := s.Guard.Validate(); err != nil {
+		return err
+	}
+	if s.Jobs.LogTailLines < 0 {
+		return fmt.Errorf("jobs.logTailLines must not be negative")
+	}
+	if s.ImageDigests.PinManifests && !s.ImageDigests.Resolve {
+		return fmt.Errorf("imageDigests.resolve must be enabled to use imageDigests.pinManifests")
+	}
+	if s.Pipeline != nil {
+		method := DetermineKubernetesTrafficRoutingMethod(s.TrafficRouting)
+		if method == KubernetesTrafficRoutingMethodSMI {
+			if err := s.validateForSMI(); err != nil {
+				return err
+			}
+		}
+		for _, stage := range s.Pipeline.Stages {
+			opts := stage.K8sTrafficRoutingStageOptions
+			if opts == nil {
+				continue
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			if method != KubernetesTrafficRoutingMethodPodSelector {
+				continue
+			}
+			if err := opts.validateForPodSelector(s.HasStage(model.StageK8sCanaryRollout)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateForSMI checks that the rollout stages create the services of the variants
+// because the generated TrafficSplit routes the traffic to the services named <service>-<variant>.
+func (s *KubernetesDeploymentSpec) validateForSMI() error {
+	check := func(stage model.Stage, variant, suffix string, createService bool) error {
+		if !createService {
+			return fmt.Errorf("createService of %s stage must be true to use smi traffic routing", stage)
+		}
+		if suffix != "" && suffix != variant {
+			return fmt.Errorf("suffix of %s stage must be %q to use smi traffic routing", stage, variant)
+		}
+		return nil
+	}
+	for _, stage := range s.Pipeline.Stages {
+		var err error
+		switch stage.Name {
+		case model.StageK8sPrimaryRollout:
+			opts := stage.K8sPrimaryRolloutStageOptions
+			if opts == nil {
+				opts = &K8sPrimaryRolloutStageOptions{}
+			}
+			err = check(stage.Name, "primary", opts.Suffix, opts.CreateService)
+		case model.StageK8sCanaryRollout:
+			opts := stage.K8sCanaryRolloutStageOptions
+			if opts == nil {
+				opts = &K8sCanaryRolloutStageOptions{}
+			}
+			err = check(stage.Name, "canary", opts.Suffix, opts.CreateService)
+		case model.StageK8sBaselineRollout:
+			opts := stage.K8sBaselineRolloutStageOptions
+			if opts == nil {
+				opts = &K8sBaselineRolloutStageOptions{}
+			}
+			err = check(stage.Name, "baseline", opts.Suffix, opts.CreateService)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// KubernetesImageDigests represents how the mutable image tags of workloads
+// should be resolved to their immutable digests.
+type KubernetesImageDigests struct {
+	// Whether the image tags should be resolved to digests at planning time.
+	// The resolved digests are recorded in the deployment metadata.
+	Resolve bool `json:"resolve"`
+	// Whether the images in the applied manifests should be pinned to the resolved digests.
+	// The images running before the deployment are also recorded so that the rollback
+	// restores exactly the same images even if their tags were re-pushed.
+	PinManifests bool `json:"pinManifests"`
+}
+
+// KubernetesJobs represents how the Job resources of application should be handled.
+// Since the pod template of a Job is immutable, a changed Job can not be updated
+// by a plain apply.
+type KubernetesJobs struct {
+	// Whether the name of every Job should be suffixed with a hash of its spec.
+	// A changed Job is then created as a new one and the old one is removed by pruning.
+	GenerateNameSuffix bool `json:"generateNameSuffix"`
+	// Whether the stage should wait until all Jobs were completed.
+	// The stage fails when any Job was failed.
+	WaitForCompletion bool `json:"waitForCompletion"`
+	// How long to wait for the Jobs to be completed.
+	// Default is 30m.
+	CompletionTimeout Duration `json:"completionTimeout"`
+	// The number of last log lines of the Job pods to be shown after they finished.
+	// Zero means no logs will be shown.
+	LogTailLines int `json:"logTailLines"`
+}
+
+// KubernetesDeploymentGuard represents the thresholds of changes
+// those are considered as dangerous, such as deleting many resources at once.
+// The changes are compared with the most recently deployed manifests.
+type KubernetesDeploymentGuard struct {
+	// The maximum number of resources allowed to be deleted by a deployment.
+	// Zero means this is not checked.
+	MaxDeletedResources int `json:"maxDeletedResources"`
+	// The maximum percentage of replicas allowed to be changed in a workload.
+	// Zero means this is not checked.
+	MaxReplicasChangePercentage int `json:"maxReplicasChangePercentage"`
+	// What to do when any of the thresholds was exceeded.
+	// Default is approval.
+	Action KubernetesDeploymentGuardAction `json:"action"`
+}
+
+// KubernetesDeploymentGuardAction represents what to do
+// when the deployment was caught by the guard.
+type KubernetesDeploymentGuardAction string
+
+const (
+	// KubernetesDeploymentGuardActionApproval requires an approval before deploying.
+	KubernetesDeploymentGuardActionApproval KubernetesDeploymentGuardAction = "approval"
+	// KubernetesDeploymentGuardActionFail fails the deployment.
+	KubernetesDeploymentGuardActionFail KubernetesDeploymentGuardAction = "fail"
+)
+
+// Validate returns an error if any wrong configuration value was found.
+func (g KubernetesDeploymentGuard) Validate() error {
+	if g.MaxDeletedResources < 0 {
+		return fmt.Errorf("guard.maxDeletedResources must not be negative")
+	}
+	if g.MaxReplicasChangePercentage < 0 {
+		return fmt.Errorf("guard.maxReplicasChangePercentage must not be negative")
+	}
+	switch g.Action {
+	case "", KubernetesDeploymentGuardActionApproval, KubernetesDeploymentGuardActionFail:
+	default:
+		return fmt.Errorf("unsupported guard.action %q", g.Action)
+	}
+	return nil
+}
+
+// Enabled reports whether any threshold was configured.
+func (g KubernetesDeploymentGuard) Enabled() bool {
+	return g.MaxDeletedResources > 0 || g.MaxReplicasChangePercentage > 0
+}
+
 // KubernetesDeploymentInput represents needed input for triggering a Kubernetes deployment.
 type KubernetesDeploymentInput struct {
 	// List of manifest files in the application directory used to deploy.
@@ -64,12 +266,110 @@ type KubernetesDeploymentInput struct {
 	// Configurable parameters for helm commands.
 	HelmOptions *InputHelmOptions `json:"helmOptions"`
 
+	// Version of helmfile will be used.
+	HelmfileVersion string `json:"helmfileVersion"`
+	// Configurable parameters for helmfile commands.
+	// The helmfile is used when this is specified or
+	// helmfile.yaml exists in the application directory.
+	HelmfileOptions *InputHelmfileOptions `json:"helmfileOptions"`
+
+	// Version of jsonnet will be used.
+	JsonnetVersion string `json:"jsonnetVersion"`
+	// Version of jsonnet-bundler will be used.
+	JsonnetBundlerVersion string `json:"jsonnetBundlerVersion"`
+	// Configurable parameters for jsonnet commands.
+	// The jsonnet is used when this is specified or
+	// main.jsonnet exists in the application directory.
+	JsonnetOptions *InputJsonnetOptions `json:"jsonnetOptions"`
+
+	// Version of ytt will be used.
+	YttVersion string `json:"yttVersion"`
+	// Configurable parameters for ytt commands.
+	// The ytt is used when this is specified.
+	YttOptions *InputYttOptions `json:"yttOptions"`
+
+	// The tool used to apply all manifests at once in K8S_SYNC stage and rollback.
+	// Default is kubectl.
+	ApplyEngine KubernetesApplyEngine `json:"applyEngine"`
+	// Version of kapp will be used.
+	KappVersion string `json:"kappVersion"`
+	// Configurable parameters for kapp commands.
+	KappOptions InputKappOptions `json:"kappOptions"`
+
 	// The namespace where manifests will be applied.
 	Namespace string `json:"namespace"`
+	// Whether the manifests should be applied by server-side apply of kubectl.
+	// This avoids storing the last-applied-configuration annotation into resources
+	// and lets other controllers such as HorizontalPodAutoscaler manage the fields not defined in Git.
+	// Conflicts are forced so that piped takes over the fields defined in Git from other managers.
+	// This is not used by kapp while applying all manifests at once.
+	// Default is false.
+	ServerSideApply bool `json:"serverSideApply"`
 
 	// Automatically reverts all deployment changes on failure.
 	// Default is true.
 	AutoRollback bool `json:"autoRollback"`
+
+	// Configuration for applying the manifests in multiple waves.
+	ApplyWaves KubernetesApplyWaves `json:"applyWaves"`
+
+	// List of rules to ignore the fields while calculating the diff of manifests.
+	// These are used in both planning and drift detection in addition to
+	// the ones configured for the cloud provider.
+	IgnoreDiffs []KubernetesIgnoreDiffRule `json:"ignoreDiffs"`
+}
+
+// KubernetesApplyEngine represents the tool used to apply the manifests.
+type KubernetesApplyEngine string
+
+const (
+	// KubernetesApplyEngineKubectl applies the manifests one by one with kubectl.
+	KubernetesApplyEngineKubectl KubernetesApplyEngine = "kubectl"
+	// KubernetesApplyEngineKapp applies the manifests as a kapp application.
+	KubernetesApplyEngineKapp KubernetesApplyEngine = "kapp"
+)
+
+// KubernetesIgnoreDiffRule specifies the fields that should be ignored
+// while calculating the diff of manifests, such as the ones
+// injected by mutating admission webhooks.
+type KubernetesIgnoreDiffRule struct {
+	// The labels of the resources this rule is applied to.
+	// Empty means all resources.
+	Selector map[string]string `json:"selector"`
+	// List of paths to the ignoring fields.
+	// e.g. metadata.annotations['vault.hashicorp.com/agent-inject-status']
+	//      spec.template.spec.containers[name=istio-proxy]
+	//      spec.template.spec.containers[*].resources
+	Paths []string `json:"paths"`
+	// List of field managers whose fields should be ignored.
+	// The managed fields of the live resources are used to find them.
+	Managers []string `json:"managers"`
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (r KubernetesIgnoreDiffRule) Validate() error {
+	if len(r.Paths) == 0 && len(r.Managers) == 0 {
+		return fmt.Errorf("either paths or managers must be specified for ignoreDiffs")
+	}
+	return nil
+}
+
+// KubernetesApplyWaves configures how to apply the manifests in multiple waves.
+// The wave of each manifest is decided by its "pipecd.dev/apply-wave" annotation
+// or the wave configured for its kind. CustomResourceDefinition and Namespace
+// are in wave -2, cert-manager Issuer and ClusterIssuer are in wave -1
+// and all others are in wave 0 by default.
+// The manifests in a lower wave are applied and become ready before applying the next wave.
+type KubernetesApplyWaves struct {
+	// Map from resource kind to its wave.
+	Kinds map[string]int `json:"kinds"`
+	// Whether to start applying the next wave without waiting
+	// for the resources of the previous wave to become ready.
+	// CustomResourceDefinitions are always waited to be established.
+	SkipReadinessCheck bool `json:"skipReadinessCheck"`
+	// The maximum time to wait for the resources of a wave to become ready.
+	// Empty means 5m.
+	ReadinessTimeout Duration `json:"readinessTimeout"`
 }
 
 type InputHelmChart struct {
@@ -93,8 +393,129 @@ type InputHelmOptions struct {
 	ReleaseName string `json:"releaseName"`
 	// List of value files should be loaded.
 	ValueFiles []string `json:"valueFiles"`
+	// List of value files placing outside the application directory.
+	// They are loaded before the ones specified in valueFiles.
+	ExternalValueFiles []InputHelmValueFile `json:"externalValueFiles"`
 	// List of file path for values.
 	SetFiles map[string]string
+	// The executable to post-process the rendered manifests before applying,
+	// such as a script running kustomize.
+	PostRenderer *InputHelmPostRenderer `json:"postRenderer"`
+}
+
+type InputHelmPostRenderer struct {
+	// Relative path from the application directory to the executable.
+	// It must not point outside the application directory.
+	Path string `json:"path"`
+	// List of arguments passed to the executable.
+	// This requires Helm 3.10 or later.
+	Args []string `json:"args"`
+}
+
+func (r *InputHelmPostRenderer) Validate() error {
+	if r.Path == "" {
+		return fmt.Errorf("path must be specified for postRenderer")
+	}
+	if filepath.IsAbs(r.Path) {
+		return fmt.Errorf("path of postRenderer must be relative to the application directory")
+	}
+	for _, e := range strings.Split(filepath.ToSlash(r.Path), "/") {
+		if e == ".." {
+			return fmt.Errorf("path of postRenderer must not contain \"..\"")
+		}
+	}
+	return nil
+}
+
+type InputHelmfileOptions struct {
+	// The path to the helmfile state file or directory relative to the application directory.
+	// Default is helmfile.yaml.
+	File string `json:"file"`
+	// The environment defined in the helmfile to be rendered.
+	Environment string `json:"environment"`
+	// List of label selectors to filter the releases, e.g. name=app,tier=frontend.
+	Selectors []string `json:"selectors"`
+}
+
+type InputJsonnetOptions struct {
+	// The path to the jsonnet file to be evaluated relative to the application directory.
+	// Default is main.jsonnet.
+	File string `json:"file"`
+	// List of additional library search directories relative to the application directory.
+	// They take precedence over the lib and vendor directories found automatically.
+	JPaths []string `json:"jpaths"`
+	// The external variables passed by --ext-str.
+	ExtVars map[string]string `json:"extVars"`
+	// The top-level arguments passed by --tla-str.
+	TLAs map[string]string `json:"tlas"`
+}
+
+type InputYttOptions struct {
+	// List of files or directories to be evaluated relative to the application directory.
+	// Default is the application directory itself.
+	Files []string `json:"files"`
+	// The data values passed by --data-value.
+	DataValues map[string]string `json:"dataValues"`
+	// List of data values files relative to the application directory passed by --data-values-file.
+	DataValuesFiles []string `json:"dataValuesFiles"`
+}
+
+type InputKappOptions struct {
+	// The name of kapp application grouping the resources.
+	// Default is the application name.
+	App string `json:"app"`
+	// The namespace where kapp stores the application record.
+	// Default is the namespace of the deployment.
+	AppNamespace string `json:"appNamespace"`
+	// List of kapp configuration files relative to the application directory,
+	// e.g. the ones containing the wait rules or rebase rules.
+	ConfigFiles []string `json:"configFiles"`
+	// The maximum time to wait for the resources to become ready.
+	// Empty means 15m.
+	WaitTimeout Duration `json:"waitTimeout"`
+}
+
+type InputHelmValueFile struct {
+	// Git remote address where the value file is placing.
+	// Empty means the same repository.
+	GitRemote string `json:"gitRemote"`
+	// The commit SHA or tag for remote git.
+	Ref string `json:"ref"`
+	// Relative path from the repository root directory to the value file.
+	Path string `json:"path"`
+}
+
+// ExternalValueFilePaths returns the paths of the external value files
+// those are placing in the same repository with the application
+// whose remote address is the given one.
+// The ones pinned to a ref are excluded since they are changed only by updating the ref.
+func (o *InputHelmOptions) ExternalValueFilePaths(appRepoRemote string) []string {
+	if o == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(o.ExternalValueFiles))
+	for _, f := range o.ExternalValueFiles {
+		if f.GitRemote == "" || (f.GitRemote == appRepoRemote && f.Ref == "") {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+// RemoteValueFiles returns the external value files those are placing in other repositories
+// than the application one whose remote address is the given one.
+// The ones pinned to a ref are excluded since they are changed only by updating the ref.
+func (o *InputHelmOptions) RemoteValueFiles(appRepoRemote string) []InputHelmValueFile {
+	if o == nil {
+		return nil
+	}
+	var files []InputHelmValueFile
+	for _, f := range o.ExternalValueFiles {
+		if f.GitRemote != "" && f.GitRemote != appRepoRemote && f.Ref == "" {
+			files = append(files, f)
+		}
+	}
+	return files
 }
 
 type KubernetesTrafficRoutingMethod string
@@ -108,6 +529,7 @@ const (
 type KubernetesTrafficRouting struct {
 	Method KubernetesTrafficRoutingMethod `json:"method"`
 	Istio  *IstioTrafficRouting           `json:"istio"`
+	SMI    *SMITrafficRouting             `json:"smi"`
 }
 
 // DetermineKubernetesTrafficRoutingMethod determines the routing method should be used based on the TrafficRouting config.
@@ -134,6 +556,12 @@ type IstioTrafficRouting struct {
 	VirtualService K8sResourceReference `json:"virtualService"`
 }
 
+type SMITrafficRouting struct {
+	// The reference to TrafficSplit manifest.
+	// Empty means the first TrafficSplit resource will be used.
+	TrafficSplit K8sResourceReference `json:"trafficSplit"`
+}
+
 type K8sResourceReference struct {
 	Kind string `json:"kind"`
 	Name string `json:"name"`
@@ -158,6 +586,23 @@ type K8sPrimaryRolloutStageOptions struct {
 	AddVariantLabelToSelector bool `json:"addVariantLabelToSelector"`
 	// Whether the resources that are no longer defined in Git should be removed or not.
 	Prune bool `json:"prune"`
+	// How long to watch the pods of the rolled out workloads after applying.
+	// The stage fails as soon as any pod was found failing because of
+	// CrashLoopBackOff, ImagePullBackOff or OOMKilled.
+	// Default is 0, which means no watching.
+	WatchPodFailures Duration `json:"watchPodFailures"`
+	// Whether to wait until the rollouts of all applied workloads are completed
+	// as `kubectl rollout status` does.
+	// The stage fails as soon as any Deployment exceeded its progress deadline.
+	WaitForRollout bool `json:"waitForRollout"`
+	// Whether to wait until all applied Deployments, StatefulSets and DaemonSets
+	// become available and all applied Jobs are completed.
+	// Unlike waitForRollout, this covers all of them instead of only the configured workloads.
+	// The stage fails when any of them failed or did not converge within rolloutTimeout.
+	WaitHealthy bool `json:"waitHealthy"`
+	// The maximum time to wait for the rollouts to be completed.
+	// Empty means 10m.
+	RolloutTimeout Duration `json:"rolloutTimeout"`
 }
 
 // K8sCanaryRolloutStageOptions contains all configurable values for a K8S_CANARY_ROLLOUT stage.
@@ -172,6 +617,11 @@ type K8sCanaryRolloutStageOptions struct {
 	Suffix string `json:"suffix"`
 	// Whether the CANARY service should be created.
 	CreateService bool `json:"createService"`
+	// How long to watch the pods of the rolled out workloads after applying.
+	// The stage fails as soon as any pod was found failing because of
+	// CrashLoopBackOff, ImagePullBackOff or OOMKilled.
+	// Default is 0, which means no watching.
+	WatchPodFailures Duration `json:"watchPodFailures"`
 }
 
 // K8sCanaryCleanStageOptions contains all configurable values for a K8S_CANARY_CLEAN stage.
@@ -207,6 +657,67 @@ type K8sTrafficRoutingStageOptions struct {
 	Canary int `json:"canary"`
 	// The percentage of traffic should be routed to BASELINE variant.
 	Baseline int `json:"baseline"`
+	// The ordered list of steps to shift the traffic progressively.
+	// When specified, the above percentages are ignored
+	// and the traffic is shifted step by step until the last one.
+	Steps []K8sTrafficRoutingStep `json:"steps"`
+}
+
+// K8sTrafficRoutingStep represents a step of progressive traffic shifting.
+// The PRIMARY variant receives the rest of the traffic.
+type K8sTrafficRoutingStep struct {
+	// The percentage of traffic should be routed to CANARY variant.
+	Canary int `json:"canary"`
+	// The percentage of traffic should be routed to BASELINE variant.
+	Baseline int `json:"baseline"`
+	// How long to wait after updating the traffic before moving to the next step.
+	Wait Duration `json:"wait"`
+	// The analysis to be run after waiting.
+	// The stage fails as soon as the analysis failed.
+	Analysis *AnalysisStageOptions `json:"analysis"`
+}
+
+// Percentages returns the percentages of traffic should be routed to each variant at this step.
+func (s K8sTrafficRoutingStep) Percentages() (primary, canary, baseline int) {
+	return 100 - s.Canary - s.Baseline, s.Canary, s.Baseline
+}
+
+func (opts *K8sTrafficRoutingStageOptions) Validate() error {
+	for i, s := range opts.Steps {
+		if s.Canary < 0 || s.Baseline < 0 || s.Canary+s.Baseline > 100 {
+			return fmt.Errorf("steps[%d] must have canary and baseline percentages between 0 and 100 in total", i)
+		}
+		if s.Wait < 0 {
+			return fmt.Errorf("steps[%d].wait must not be negative", i)
+		}
+		if s.Analysis != nil {
+			if err := s.Analysis.Validate(); err != nil {
+				return fmt.Errorf("steps[%d].analysis: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateForPodSelector checks whether the traffic can be routed by podselector method.
+// The traffic can not be routed to BASELINE variant, and routing a part of traffic
+// requires K8S_CANARY_ROLLOUT stage since it is done by scaling CANARY variant.
+func (opts K8sTrafficRoutingStageOptions) validateForPodSelector(hasCanaryRollout bool) error {
+	steps := opts.Steps
+	if len(steps) == 0 {
+		_, canary, baseline := opts.Percentages()
+		steps = []K8sTrafficRoutingStep{{Canary: canary, Baseline: baseline}}
+	}
+
+	for _, s := range steps {
+		if s.Baseline > 0 {
+			return fmt.Errorf("routing traffic to BASELINE variant is not available with podselector traffic routing method")
+		}
+		if s.Canary > 0 && s.Canary < 100 && !hasCanaryRollout {
+			return fmt.Errorf("routing a part of traffic by podselector traffic routing method requires K8S_CANARY_ROLLOUT stage")
+		}
+	}
+	return nil
 }
 
 func (opts K8sTrafficRoutingStageOptions) Percentages() (primary, canary, baseline int) {
@@ -223,3 +734,16 @@ func (opts K8sTrafficRoutingStageOptions) Percentages() (primary, canary, baseli
 	}
 	return opts.Primary, opts.Canary, opts.Baseline
 }
+
+// K8sPartitionRolloutStageOptions contains all configurable values for a K8S_PARTITION_ROLLOUT stage.
+type K8sPartitionRolloutStageOptions struct {
+	// The partition of the StatefulSet workloads.
+	// Only the pods whose ordinal is greater than or equal to the partition are updated.
+	// An integer value can be specified to indicate an ordinal.
+	// Or a string suffixed by "%" to indicate an percentage value compared to the number of replicas.
+	// Default is 0, meaning all pods are updated.
+	Partition Replicas `json:"partition"`
+	// How long to wait for the pods to be updated.
+	// Default is 10m.
+	RolloutTimeout Duration `json:"rolloutTimeout"`
+}
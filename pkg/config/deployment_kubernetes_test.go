@@ -80,6 +80,68 @@ func TestKubernetesDeploymentConfig(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			fileName:           "testdata/application/k8s-app-istio-traffic-steps.yaml",
+			expectedKind:       KindKubernetesApp,
+			expectedAPIVersion: "pipecd.dev/v1beta1",
+			expectedSpec: &KubernetesDeploymentSpec{
+				GenericDeploymentSpec: GenericDeploymentSpec{
+					Pipeline: &DeploymentPipeline{
+						Stages: []PipelineStage{
+							{
+								Name: model.StageK8sCanaryRollout,
+								K8sCanaryRolloutStageOptions: &K8sCanaryRolloutStageOptions{
+									Replicas: Replicas{
+										Number:       100,
+										IsPercentage: true,
+									},
+								},
+							},
+							{
+								Name: model.StageK8sTrafficRouting,
+								K8sTrafficRoutingStageOptions: &K8sTrafficRoutingStageOptions{
+									Steps: []K8sTrafficRoutingStep{
+										{
+											Canary: 10,
+											Wait:   Duration(5 * time.Minute),
+										},
+										{
+											Canary: 30,
+											Analysis: &AnalysisStageOptions{
+												Duration: Duration(10 * time.Minute),
+												Metrics: []TemplatableAnalysisMetrics{
+													{
+														AnalysisMetrics: AnalysisMetrics{
+															Provider: "prometheus-dev",
+															Query:    "grpc_error_percentage",
+															Expected: AnalysisExpected{Max: floatPointer(0.1)},
+															Interval: Duration(time.Minute),
+															Timeout:  defaultAnalysisQueryTimeout,
+														},
+													},
+												},
+											},
+										},
+										{
+											Canary: 100,
+										},
+									},
+								},
+							},
+						},
+					},
+					Timeout: Duration(6 * time.Hour),
+				},
+				Input: KubernetesDeploymentInput{AutoRollback: true},
+				TrafficRouting: &KubernetesTrafficRouting{
+					Method: KubernetesTrafficRoutingMethodIstio,
+					Istio: &IstioTrafficRouting{
+						Host: "helloworld",
+					},
+				},
+			},
+			expectedError: nil,
+		},
 		// {
 		// 	fileName:           "testdata/application/k8s-app-canary.yaml",
 		// 	expectedKind:       KindKubernetesApp,
@@ -127,3 +189,334 @@ func TestKubernetesDeploymentConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestKubernetesDeploymentGuardValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		guard   KubernetesDeploymentGuard
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			wantErr: false,
+		},
+		{
+			name: "valid",
+			guard: KubernetesDeploymentGuard{
+				MaxDeletedResources:         5,
+				MaxReplicasChangePercentage: 50,
+				Action:                      KubernetesDeploymentGuardActionFail,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative threshold",
+			guard: KubernetesDeploymentGuard{
+				MaxDeletedResources: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported action",
+			guard: KubernetesDeploymentGuard{
+				MaxDeletedResources: 5,
+				Action:              "skip",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.guard.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestKubernetesDeploymentSpecValidateApplyEngine(t *testing.T) {
+	testcases := []struct {
+		name    string
+		engine  KubernetesApplyEngine
+		stages  []PipelineStage
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			wantErr: false,
+		},
+		{
+			name:    "kubectl",
+			engine:  KubernetesApplyEngineKubectl,
+			wantErr: false,
+		},
+		{
+			name:    "kapp",
+			engine:  KubernetesApplyEngineKapp,
+			wantErr: false,
+		},
+		{
+			name:    "kapp with sync stage",
+			engine:  KubernetesApplyEngineKapp,
+			stages:  []PipelineStage{{Name: model.StageK8sSync}, {Name: model.StageWaitApproval}},
+			wantErr: false,
+		},
+		{
+			name:    "kapp with canary rollout stage",
+			engine:  KubernetesApplyEngineKapp,
+			stages:  []PipelineStage{{Name: model.StageK8sCanaryRollout}, {Name: model.StageK8sPrimaryRollout}},
+			wantErr: true,
+		},
+		{
+			name:    "kubectl with canary rollout stage",
+			engine:  KubernetesApplyEngineKubectl,
+			stages:  []PipelineStage{{Name: model.StageK8sCanaryRollout}, {Name: model.StageK8sPrimaryRollout}},
+			wantErr: false,
+		},
+		{
+			name:    "unsupported engine",
+			engine:  "helm",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := KubernetesDeploymentSpec{
+				Input: KubernetesDeploymentInput{
+					ApplyEngine: tc.engine,
+				},
+			}
+			if tc.stages != nil {
+				s.Pipeline = &DeploymentPipeline{Stages: tc.stages}
+			}
+			err := s.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestKubernetesDeploymentSpecValidatePostRenderer(t *testing.T) {
+	testcases := []struct {
+		name         string
+		postRenderer *InputHelmPostRenderer
+		wantErr      bool
+	}{
+		{
+			name:    "no post renderer",
+			wantErr: false,
+		},
+		{
+			name: "valid post renderer",
+			postRenderer: &InputHelmPostRenderer{
+				Path: "./kustomize.sh",
+				Args: []string{"overlays/prod"},
+			},
+			wantErr: false,
+		},
+		{
+			name:         "missing path",
+			postRenderer: &InputHelmPostRenderer{},
+			wantErr:      true,
+		},
+		{
+			name: "absolute path",
+			postRenderer: &InputHelmPostRenderer{
+				Path: "/bin/sh",
+			},
+			wantErr: true,
+		},
+		{
+			name: "path outside application directory",
+			postRenderer: &InputHelmPostRenderer{
+				Path: "../shared/kustomize.sh",
+			},
+			wantErr: true,
+		},
+		{
+			name: "path going back inside application directory",
+			postRenderer: &InputHelmPostRenderer{
+				Path: "hooks/../kustomize.sh",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := KubernetesDeploymentSpec{
+				Input: KubernetesDeploymentInput{
+					HelmOptions: &InputHelmOptions{
+						PostRenderer: tc.postRenderer,
+					},
+				},
+			}
+			err := s.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestKubernetesDeploymentSpecValidateTrafficRoutingSteps(t *testing.T) {
+	testcases := []struct {
+		name          string
+		method        KubernetesTrafficRoutingMethod
+		steps         []K8sTrafficRoutingStep
+		canaryRollout bool
+		wantErr       bool
+	}{
+		{
+			name:    "valid steps",
+			method:  KubernetesTrafficRoutingMethodIstio,
+			steps:   []K8sTrafficRoutingStep{{Canary: 10}, {Canary: 40, Baseline: 40}, {Canary: 100}},
+			wantErr: false,
+		},
+		{
+			name:    "exceeded percentages",
+			method:  KubernetesTrafficRoutingMethodIstio,
+			steps:   []K8sTrafficRoutingStep{{Canary: 60, Baseline: 50}},
+			wantErr: true,
+		},
+		{
+			name:    "negative percentage",
+			method:  KubernetesTrafficRoutingMethodIstio,
+			steps:   []K8sTrafficRoutingStep{{Canary: -10}},
+			wantErr: true,
+		},
+		{
+			name:    "analysis without duration",
+			method:  KubernetesTrafficRoutingMethodIstio,
+			steps:   []K8sTrafficRoutingStep{{Canary: 10, Analysis: &AnalysisStageOptions{}}},
+			wantErr: true,
+		},
+		{
+			name:    "smi",
+			method:  KubernetesTrafficRoutingMethodSMI,
+			steps:   []K8sTrafficRoutingStep{{Canary: 20}, {Canary: 100}},
+			wantErr: false,
+		},
+		{
+			name:    "pod selector with switching all traffic",
+			method:  KubernetesTrafficRoutingMethodPodSelector,
+			steps:   []K8sTrafficRoutingStep{{Canary: 100}},
+			wantErr: false,
+		},
+		{
+			name:          "pod selector with canary rollout",
+			method:        KubernetesTrafficRoutingMethodPodSelector,
+			steps:         []K8sTrafficRoutingStep{{Canary: 20}, {Canary: 100}},
+			canaryRollout: true,
+			wantErr:       false,
+		},
+		{
+			name:    "pod selector without canary rollout",
+			method:  KubernetesTrafficRoutingMethodPodSelector,
+			steps:   []K8sTrafficRoutingStep{{Canary: 20}},
+			wantErr: true,
+		},
+		{
+			name:          "pod selector with baseline",
+			method:        KubernetesTrafficRoutingMethodPodSelector,
+			steps:         []K8sTrafficRoutingStep{{Canary: 20, Baseline: 20}},
+			canaryRollout: true,
+			wantErr:       true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			stages := []PipelineStage{
+				{
+					Name: model.StageK8sTrafficRouting,
+					K8sTrafficRoutingStageOptions: &K8sTrafficRoutingStageOptions{
+						Steps: tc.steps,
+					},
+				},
+			}
+			if tc.canaryRollout {
+				stages = append([]PipelineStage{{Name: model.StageK8sCanaryRollout}}, stages...)
+			}
+			s := KubernetesDeploymentSpec{
+				GenericDeploymentSpec: GenericDeploymentSpec{
+					Pipeline: &DeploymentPipeline{
+						Stages: stages,
+					},
+				},
+				TrafficRouting: &KubernetesTrafficRouting{
+					Method: tc.method,
+				},
+			}
+			err := s.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestKubernetesDeploymentSpecValidateSMI(t *testing.T) {
+	testcases := []struct {
+		name    string
+		stages  []PipelineStage
+		wantErr bool
+	}{
+		{
+			name: "services are created",
+			stages: []PipelineStage{
+				{
+					Name:                         model.StageK8sCanaryRollout,
+					K8sCanaryRolloutStageOptions: &K8sCanaryRolloutStageOptions{CreateService: true},
+				},
+				{
+					Name:                           model.StageK8sBaselineRollout,
+					K8sBaselineRolloutStageOptions: &K8sBaselineRolloutStageOptions{CreateService: true, Suffix: "baseline"},
+				},
+				{
+					Name:                          model.StageK8sPrimaryRollout,
+					K8sPrimaryRolloutStageOptions: &K8sPrimaryRolloutStageOptions{CreateService: true},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "canary service is not created",
+			stages: []PipelineStage{
+				{
+					Name:                         model.StageK8sCanaryRollout,
+					K8sCanaryRolloutStageOptions: &K8sCanaryRolloutStageOptions{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "primary service is not created",
+			stages: []PipelineStage{
+				{
+					Name:                          model.StageK8sPrimaryRollout,
+					K8sPrimaryRolloutStageOptions: &K8sPrimaryRolloutStageOptions{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom suffix",
+			stages: []PipelineStage{
+				{
+					Name:                         model.StageK8sCanaryRollout,
+					K8sCanaryRolloutStageOptions: &K8sCanaryRolloutStageOptions{CreateService: true, Suffix: "new"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := KubernetesDeploymentSpec{
+				GenericDeploymentSpec: GenericDeploymentSpec{
+					Pipeline: &DeploymentPipeline{
+						Stages: tc.stages,
+					},
+				},
+				TrafficRouting: &KubernetesTrafficRouting{
+					Method: KubernetesTrafficRoutingMethodSMI,
+				},
+			}
+			err := s.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
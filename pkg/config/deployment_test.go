@@ -16,6 +16,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -71,3 +72,289 @@ func TestHasStage(t *testing.T) {
 		})
 	}
 }
+
+func TestGenericDeploymentSpecValidateConcurrencyPolicy(t *testing.T) {
+	testcases := []struct {
+		name    string
+		policy  DeploymentConcurrencyPolicy
+		wantErr bool
+	}{
+		{
+			name:    "not specified",
+			wantErr: false,
+		},
+		{
+			name:    "queue",
+			policy:  DeploymentConcurrencyPolicyQueue,
+			wantErr: false,
+		},
+		{
+			name:    "cancel older",
+			policy:  DeploymentConcurrencyPolicyCancelOlder,
+			wantErr: false,
+		},
+		{
+			name:    "unsupported",
+			policy:  "cancel-newer",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := GenericDeploymentSpec{ConcurrencyPolicy: tc.policy}
+			err := s.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestDeploymentPlannerValidate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		rules    []DeploymentPlannerRule
+		fallback DeploymentPlannerFallback
+		wantErr  bool
+	}{
+		{
+			name:    "no rule",
+			wantErr: false,
+		},
+		{
+			name: "valid rules",
+			rules: []DeploymentPlannerRule{
+				{ChangedResources: 10},
+				{ReplicasDelta: 5, ImageMajorVersionBump: true},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty rule",
+			rules: []DeploymentPlannerRule{
+				{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative threshold",
+			rules: []DeploymentPlannerRule{
+				{ChangedResources: 10, ReplicasDelta: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "valid fallback",
+			fallback: DeploymentPlannerFallbackApproval,
+			wantErr:  false,
+		},
+		{
+			name:     "unsupported fallback",
+			fallback: "rollback",
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := DeploymentPlanner{Rules: tc.rules, Fallback: tc.fallback}
+			err := p.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestWaitApprovalStageOptionsValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		options WaitApprovalStageOptions
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			wantErr: false,
+		},
+		{
+			name: "valid options",
+			options: WaitApprovalStageOptions{
+				TimeoutAction:    WaitApprovalTimeoutActionCancel,
+				ReminderInterval: Duration(time.Hour),
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported timeout action",
+			options: WaitApprovalStageOptions{
+				TimeoutAction: "rollback",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative reminder interval",
+			options: WaitApprovalStageOptions{
+				ReminderInterval: Duration(-time.Hour),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.options.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestDeploymentFailurePolicyValidate(t *testing.T) {
+	testcases := []struct {
+		name            string
+		policy          DeploymentFailurePolicy
+		expectedTimeout Duration
+		wantErr         bool
+	}{
+		{
+			name:            "default",
+			expectedTimeout: Duration(6 * time.Hour),
+			wantErr:         false,
+		},
+		{
+			name: "wait approval",
+			policy: DeploymentFailurePolicy{
+				Action:    DeploymentFailureActionWaitApproval,
+				Approvers: []string{"foo"},
+				Timeout:   Duration(time.Hour),
+			},
+			expectedTimeout: Duration(time.Hour),
+			wantErr:         false,
+		},
+		{
+			name: "unsupported action",
+			policy: DeploymentFailurePolicy{
+				Action: "cancel",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative timeout",
+			policy: DeploymentFailurePolicy{
+				Timeout: Duration(-time.Hour),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+			if err == nil {
+				assert.Equal(t, tc.expectedTimeout, tc.policy.Timeout)
+			}
+		})
+	}
+}
+
+func TestSecretEncryptionValidate(t *testing.T) {
+	testcases := []struct {
+		name       string
+		encryption SecretEncryption
+		wantErr    bool
+	}{
+		{
+			name: "valid",
+			encryption: SecretEncryption{
+				EncryptedSecrets: map[string]string{
+					"password": "encrypted-data",
+				},
+				DecryptionTargets: []string{"deployment.yaml", "manifests/secret.yaml"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing decryption targets",
+			encryption: SecretEncryption{
+				EncryptedSecrets: map[string]string{
+					"password": "encrypted-data",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty decryption target",
+			encryption: SecretEncryption{
+				DecryptionTargets: []string{""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "absolute decryption target",
+			encryption: SecretEncryption{
+				DecryptionTargets: []string{"/etc/passwd"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "decryption target outside the application directory",
+			encryption: SecretEncryption{
+				DecryptionTargets: []string{"manifests/../../other/deployment.yaml"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.encryption.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestSopsDecryptionValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		sops    SopsDecryption
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			sops: SopsDecryption{
+				Files: []string{"secret.enc.yaml", "config/credentials.json"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "missing files",
+			sops:    SopsDecryption{Version: "3.7.1"},
+			wantErr: true,
+		},
+		{
+			name: "file outside the application directory",
+			sops: SopsDecryption{
+				Files: []string{"../secret.enc.yaml"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.sops.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestIsInsideAppDir(t *testing.T) {
+	testcases := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "secret.yaml", expected: true},
+		{path: "config/secret.yaml", expected: true},
+		{path: "config/../secret.yaml", expected: true},
+		{path: "..", expected: false},
+		{path: "../secret.yaml", expected: false},
+		{path: "config/../../secret.yaml", expected: false},
+		{path: "/etc/passwd", expected: false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isInsideAppDir(tc.path))
+		})
+	}
+}
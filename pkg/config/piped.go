@@ -17,11 +17,27 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pipe-cd/pipe/pkg/model"
 )
 
+// DefaultKubernetesVariantLabel is the label key used to distinguish
+// the variants of kubernetes workloads when nothing was configured.
+const DefaultKubernetesVariantLabel = "pipecd.dev/variant"
+
+// DefaultKubernetesAnnotationPrefix is the prefix of the annotation keys
+// piped adds to track the kubernetes resources it manages when nothing was configured.
+const DefaultKubernetesAnnotationPrefix = "pipecd.dev"
+
+// defaultSlackInteractionPort is the port number of the server
+// receiving the interactions from Slack when nothing was configured.
+const defaultSlackInteractionPort = 9086
+
 var DefaultKubernetesCloudProvider = PipedCloudProvider{
 	Name:             "kubernetes-default",
 	Type:             model.CloudProviderKubernetes,
@@ -49,16 +65,37 @@ type PipedSpec struct {
 	Repositories []PipedRepository `json:"repositories"`
 	// List of helm chart repositories that should be added while starting up.
 	ChartRepositories []HelmChartRepository `json:"chartRepositories"`
+	// List of helm chart registries that should be logged in while starting up.
+	ChartRegistries []HelmChartRegistry `json:"chartRegistries"`
 	// List of cloud providers can be used by this piped.
 	CloudProviders []PipedCloudProvider `json:"cloudProviders"`
 	// List of analysis providers can be used by this piped.
 	AnalysisProviders []PipedAnalysisProvider `json:"analysisProviders"`
+	// List of change management providers can be used by this piped.
+	ChangeManagementProviders []PipedChangeManagementProvider `json:"changeManagementProviders"`
 	// Sending notification to Slack, Webhook…
 	Notifications Notifications `json:"notifications"`
 	// How the sealed secret should be managed.
 	SealedSecretManagement *SealedSecretManagement `json:"sealedSecretManagement"`
 	// Optional settings for event watcher.
 	EventWatcher PipedEventWatcher `json:"eventWatcher"`
+	// Optional settings for reporting the live state of applications.
+	LiveStateReporter PipedLiveStateReporter `json:"liveStateReporter"`
+	// Optional settings for the actions such as restarting a workload
+	// requested against the live resources from the web console.
+	ResourceActions PipedResourceActions `json:"resourceActions"`
+	// The directory where piped places the working data of deployments
+	// including the decrypted content of sealed secrets.
+	// A memory-backed filesystem such as /dev/shm is recommended
+	// to avoid writing the decrypted secrets to the disk.
+	// Default is the temporary directory of the OS.
+	WorkspaceDir string `json:"workspaceDir"`
+	// Whether the Helm post-renderer specified in the deployment configuration
+	// of applications is allowed to be executed by this piped.
+	// Since it runs an executable from the git repository,
+	// it should be enabled only when the repositories are trusted.
+	// Default is false.
+	AllowHelmPostRenderer bool `json:"allowHelmPostRenderer"`
 }
 
 // Validate validates configured data of all fields.
@@ -89,11 +126,65 @@ func (s *PipedSpec) Validate() error {
 	if err := s.EventWatcher.Validate(); err != nil {
 		return err
 	}
+	if err := s.LiveStateReporter.Validate(); err != nil {
+		return err
+	}
+	if err := s.ResourceActions.Validate(); err != nil {
+		return err
+	}
+	if s.WorkspaceDir != "" && !filepath.IsAbs(s.WorkspaceDir) {
+		return fmt.Errorf("workspaceDir must be an absolute path")
+	}
+	for i := range s.Repositories {
+		if err := s.Repositories[i].Validate(); err != nil {
+			return fmt.Errorf("invalid repository %s: %w", s.Repositories[i].RepoID, err)
+		}
+	}
+	for i := range s.ChartRegistries {
+		if err := s.ChartRegistries[i].Validate(); err != nil {
+			return fmt.Errorf("invalid chart registry %s: %w", s.ChartRegistries[i].Address, err)
+		}
+	}
+	for _, p := range s.CloudProviders {
+		if err := p.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("invalid rateLimit of cloud provider %s: %w", p.Name, err)
+		}
+		if p.KubernetesConfig == nil {
+			continue
+		}
+		for _, r := range p.KubernetesConfig.IgnoreDiffs {
+			if err := r.Validate(); err != nil {
+				return fmt.Errorf("invalid ignoreDiffs of cloud provider %s: %w", p.Name, err)
+			}
+		}
+	}
 	for _, p := range s.AnalysisProviders {
 		if err := p.Validate(); err != nil {
 			return err
 		}
 	}
+	for _, r := range s.Notifications.Receivers {
+		if r.PagerDuty != nil {
+			if err := r.PagerDuty.Validate(); err != nil {
+				return fmt.Errorf("invalid pagerDuty of notification receiver %s: %w", r.Name, err)
+			}
+		}
+		if r.Email != nil {
+			if err := r.Email.Validate(); err != nil {
+				return fmt.Errorf("invalid email of notification receiver %s: %w", r.Name, err)
+			}
+		}
+	}
+	if s.Notifications.SlackInteraction != nil {
+		if err := s.Notifications.SlackInteraction.Validate(); err != nil {
+			return fmt.Errorf("invalid notifications.slackInteraction: %w", err)
+		}
+	}
+	for _, p := range s.ChangeManagementProviders {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("invalid change management provider %s: %w", p.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -154,6 +245,16 @@ func (s *PipedSpec) GetRepository(id string) (PipedRepository, bool) {
 	return PipedRepository{}, false
 }
 
+// GetRepositoryByRemote finds a repository with the given remote address from the configured list.
+func (s *PipedSpec) GetRepositoryByRemote(remote string) (PipedRepository, bool) {
+	for _, repo := range s.Repositories {
+		if repo.Remote == remote {
+			return repo, true
+		}
+	}
+	return PipedRepository{}, false
+}
+
 // GetAnalysisProvider finds and returns an Analysis Provider config whose name is the given string.
 func (s *PipedSpec) GetAnalysisProvider(name string) (PipedAnalysisProvider, bool) {
 	for _, p := range s.AnalysisProviders {
@@ -164,6 +265,27 @@ func (s *PipedSpec) GetAnalysisProvider(name string) (PipedAnalysisProvider, boo
 	return PipedAnalysisProvider{}, false
 }
 
+// GetChangeManagementProvider finds and returns a Change Management Provider config whose name is the given string.
+func (s *PipedSpec) GetChangeManagementProvider(name string) (PipedChangeManagementProvider, bool) {
+	for _, p := range s.ChangeManagementProviders {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PipedChangeManagementProvider{}, false
+}
+
+// GetWorkspaceDir returns the directory dedicated for the working data of this piped.
+// Since it is owned by only this piped, all remaining data inside it
+// can be safely removed while starting up.
+func (s *PipedSpec) GetWorkspaceDir() string {
+	root := s.WorkspaceDir
+	if root == "" {
+		root = os.TempDir()
+	}
+	return filepath.Join(root, "piped-"+s.PipedID)
+}
+
 type PipedGit struct {
 	// The username that will be configured for `git` user.
 	// Default is "piped".
@@ -200,6 +322,43 @@ type PipedRepository struct {
 	Remote string `json:"remote"`
 	// The branch will be handled.
 	Branch string `json:"branch"`
+	// The default names of the deployment configuration file
+	// for the applications placed inside specific directories.
+	// They are used while registering applications without specifying the file name.
+	ConfigFilenames []PipedRepositoryConfigFilename `json:"configFilenames"`
+}
+
+func (r *PipedRepository) Validate() error {
+	for _, f := range r.ConfigFilenames {
+		if f.Filename == "" {
+			return fmt.Errorf("filename must be set for directory %q", f.Dir)
+		}
+		if strings.ContainsRune(f.Filename, '/') {
+			return fmt.Errorf("filename %q must not contain any directory", f.Filename)
+		}
+	}
+	return nil
+}
+
+// GetConfigFilenameMap returns a map from directory to the default file name
+// of the deployment configuration file.
+func (r *PipedRepository) GetConfigFilenameMap() map[string]string {
+	if len(r.ConfigFilenames) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(r.ConfigFilenames))
+	for _, f := range r.ConfigFilenames {
+		m[path.Clean(f.Dir)] = f.Filename
+	}
+	return m
+}
+
+type PipedRepositoryConfigFilename struct {
+	// The directory path relative to the repository root.
+	// Empty means the whole repository.
+	Dir string `json:"dir"`
+	// The name of the deployment configuration file.
+	Filename string `json:"filename"`
 }
 
 type HelmChartRepository struct {
@@ -213,9 +372,57 @@ type HelmChartRepository struct {
 	Password string `json:"password"`
 }
 
+type HelmChartRegistryType string
+
+// The registry types that hosts Helm charts.
+const (
+	OCIHelmChartRegistry HelmChartRegistryType = "OCI"
+)
+
+type HelmChartRegistry struct {
+	// The registry type. Currently, only OCI is supported.
+	// Default is OCI.
+	Type HelmChartRegistryType `json:"type"`
+	// The address to the registry.
+	Address string `json:"address"`
+	// The username used to log in to the registry.
+	Username string `json:"username"`
+	// The password used to log in to the registry.
+	Password string `json:"password"`
+	// The path to the file containing the password used to log in to the registry.
+	// It is read on every login, so a short-lived token refreshed by
+	// another process such as a sidecar can be used.
+	// Either password or passwordFile must be set.
+	PasswordFile string `json:"passwordFile"`
+}
+
+func (r *HelmChartRegistry) Validate() error {
+	if r.Type == "" {
+		r.Type = OCIHelmChartRegistry
+	}
+	if r.Type != OCIHelmChartRegistry {
+		return fmt.Errorf("unsupported type %s", r.Type)
+	}
+	if r.Address == "" {
+		return fmt.Errorf("address must be set")
+	}
+	if strings.Contains(r.Address, "://") {
+		return fmt.Errorf("address must not contain the scheme")
+	}
+	if r.Username == "" {
+		return fmt.Errorf("username must be set")
+	}
+	if (r.Password == "") == (r.PasswordFile == "") {
+		return fmt.Errorf("either password or passwordFile must be set")
+	}
+	return nil
+}
+
 type PipedCloudProvider struct {
 	Name string
 	Type model.CloudProviderType
+	// The limit of requests sent to the cloud provider's API.
+	RateLimit CloudProviderRateLimit
 
 	KubernetesConfig *CloudProviderKubernetesConfig
 	TerraformConfig  *CloudProviderTerraformConfig
@@ -225,9 +432,10 @@ type PipedCloudProvider struct {
 }
 
 type genericPipedCloudProvider struct {
-	Name   string                  `json:"name"`
-	Type   model.CloudProviderType `json:"type"`
-	Config json.RawMessage         `json:"config"`
+	Name      string                  `json:"name"`
+	Type      model.CloudProviderType `json:"type"`
+	RateLimit CloudProviderRateLimit  `json:"rateLimit"`
+	Config    json.RawMessage         `json:"config"`
 }
 
 func (p *PipedCloudProvider) UnmarshalJSON(data []byte) error {
@@ -238,6 +446,7 @@ func (p *PipedCloudProvider) UnmarshalJSON(data []byte) error {
 	}
 	p.Name = gp.Name
 	p.Type = gp.Type
+	p.RateLimit = gp.RateLimit
 
 	switch p.Type {
 	case model.CloudProviderKubernetes:
@@ -271,6 +480,26 @@ func (p *PipedCloudProvider) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+type CloudProviderRateLimit struct {
+	// The maximum number of requests per second
+	// sent to the cloud provider's API from this piped.
+	// Zero means no limit.
+	QPS float64 `json:"qps"`
+	// The maximum number of requests can be sent at once.
+	// Default is the ceiling of QPS.
+	Burst int `json:"burst"`
+}
+
+func (r *CloudProviderRateLimit) Validate() error {
+	if r.QPS < 0 {
+		return fmt.Errorf("qps must not be negative")
+	}
+	if r.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	return nil
+}
+
 type CloudProviderKubernetesConfig struct {
 	// The master URL of the kubernetes cluster.
 	// Empty means in-cluster.
@@ -280,6 +509,44 @@ type CloudProviderKubernetesConfig struct {
 	KubeConfigPath string `json:"kubeConfigPath"`
 	// Configuration for application resource informer.
 	AppStateInformer KubernetesAppStateInformer `json:"appStateInformer"`
+	// List of rules to ignore the fields while calculating the diff of manifests
+	// of all applications deploying to this cloud provider.
+	IgnoreDiffs []KubernetesIgnoreDiffRule `json:"ignoreDiffs"`
+	// The label key used to distinguish the variants (primary, canary, baseline)
+	// of workloads and to select them from services.
+	// This can be changed to avoid the collision with other controllers.
+	// Default is pipecd.dev/variant.
+	VariantLabel string `json:"variantLabel"`
+	// The prefix of the annotation keys added to track the managed resources,
+	// e.g. <prefix>/managed-by, <prefix>/application, <prefix>/resource-key.
+	// This can be changed to avoid the collision with other controllers.
+	// Default is pipecd.dev.
+	AnnotationPrefix string `json:"annotationPrefix"`
+	// List of namespaces those are allowed to be deployed to.
+	// Applying or deleting resources in other namespaces is refused.
+	// Empty means all namespaces are allowed.
+	AllowedNamespaces []string `json:"allowedNamespaces"`
+	// Whether the well-known cluster-scoped resources such as ClusterRole,
+	// CustomResourceDefinition or webhook configurations can be applied or deleted
+	// while allowedNamespaces is specified.
+	// Default is false.
+	AllowClusterScopedResources bool `json:"allowClusterScopedResources"`
+}
+
+// GetVariantLabel returns the configured variant label key or the default one.
+func (c *CloudProviderKubernetesConfig) GetVariantLabel() string {
+	if c.VariantLabel == "" {
+		return DefaultKubernetesVariantLabel
+	}
+	return c.VariantLabel
+}
+
+// GetAnnotationPrefix returns the configured annotation prefix or the default one.
+func (c *CloudProviderKubernetesConfig) GetAnnotationPrefix() string {
+	if c.AnnotationPrefix == "" {
+		return DefaultKubernetesAnnotationPrefix
+	}
+	return c.AnnotationPrefix
 }
 
 type KubernetesAppStateInformer struct {
@@ -307,6 +574,20 @@ type CloudProviderTerraformConfig struct {
 	// 'image_id_list=["ami-abc123","ami-def456"]'
 	// 'image_id_map={"us-east-1":"ami-abc123","us-east-2":"ami-def456"}'
 	Vars []string `json:"vars"`
+	// Configuration for checking whether newer versions of the providers and modules
+	// required by the applications are available.
+	DependencyCheck *TerraformDependencyCheck `json:"dependencyCheck"`
+}
+
+type TerraformDependencyCheck struct {
+	// Whether to periodically check the versions of the providers and registry modules
+	// and send DEPENDENCY_OUTDATED notification when the latest one is not allowed by the constraint.
+	Enabled bool `json:"enabled"`
+	// How often to check. Default is 24h.
+	Interval Duration `json:"interval"`
+	// The address of the Terraform registry.
+	// Default is https://registry.terraform.io.
+	RegistryAddress string `json:"registryAddress"`
 }
 
 type CloudProviderCloudRunConfig struct {
@@ -315,7 +596,13 @@ type CloudProviderCloudRunConfig struct {
 	// The region of running CloudRun service.
 	Region string `json:"region"`
 	// The path to the service account file for accessing CloudRun service.
+	// Empty means the application default credentials (e.g. workload identity) are used.
 	CredentialsFile string `json:"credentialsFile"`
+	// The email of the service account to impersonate while accessing CloudRun service.
+	ImpersonateServiceAccount string `json:"impersonateServiceAccount"`
+	// List of GCP projects those are allowed to be deployed to.
+	// Empty means all projects are allowed.
+	AllowedProjects []string `json:"allowedProjects"`
 }
 
 type CloudProviderLambdaConfig struct {
@@ -333,6 +620,20 @@ type CloudProviderLambdaConfig struct {
 	// If empty, the environment variable "AWS_PROFILE" is used.
 	// "default" is populated if the environment variable is also not set.
 	Profile string `json:"profile"`
+	// The IAM role arn to assume by using the credentials loaded from the above fields.
+	// This can be used to deploy to another account via role chaining.
+	AssumeRoleARN string `json:"assumeRoleARN"`
+	// The external ID to use when assuming the role specified by AssumeRoleARN.
+	ExternalID string `json:"externalID"`
+	// The session tags to pass when assuming the role specified by AssumeRoleARN.
+	SessionTags map[string]string `json:"sessionTags"`
+	// List of AWS account IDs those are allowed to be deployed to.
+	// The account of the loaded credentials is verified before deploying.
+	// Empty means all accounts are allowed.
+	AllowedAccounts []string `json:"allowedAccounts"`
+	// List of AWS regions those are allowed to be deployed to.
+	// Empty means all regions are allowed.
+	AllowedRegions []string `json:"allowedRegions"`
 }
 
 type CloudProviderECSConfig struct {
@@ -350,15 +651,32 @@ type CloudProviderECSConfig struct {
 	// If empty, the environment variable "AWS_PROFILE" is used.
 	// "default" is populated if the environment variable is also not set.
 	Profile string `json:"profile"`
+	// The IAM role arn to assume by using the credentials loaded from the above fields.
+	// This can be used to deploy to another account via role chaining.
+	AssumeRoleARN string `json:"assumeRoleARN"`
+	// The external ID to use when assuming the role specified by AssumeRoleARN.
+	ExternalID string `json:"externalID"`
+	// The session tags to pass when assuming the role specified by AssumeRoleARN.
+	SessionTags map[string]string `json:"sessionTags"`
+	// List of AWS account IDs those are allowed to be deployed to.
+	// The account of the loaded credentials is verified before deploying.
+	// Empty means all accounts are allowed.
+	AllowedAccounts []string `json:"allowedAccounts"`
+	// List of AWS regions those are allowed to be deployed to.
+	// Empty means all regions are allowed.
+	AllowedRegions []string `json:"allowedRegions"`
 }
 
 type PipedAnalysisProvider struct {
 	Name string                     `json:"name"`
 	Type model.AnalysisProviderType `json:"type"`
 
-	PrometheusConfig  *AnalysisProviderPrometheusConfig  `json:"prometheus"`
-	DatadogConfig     *AnalysisProviderDatadogConfig     `json:"datadog"`
-	StackdriverConfig *AnalysisProviderStackdriverConfig `json:"stackdriver"`
+	PrometheusConfig    *AnalysisProviderPrometheusConfig    `json:"prometheus"`
+	DatadogConfig       *AnalysisProviderDatadogConfig       `json:"datadog"`
+	NewRelicConfig      *AnalysisProviderNewRelicConfig      `json:"newrelic"`
+	StackdriverConfig   *AnalysisProviderStackdriverConfig   `json:"stackdriver"`
+	LokiConfig          *AnalysisProviderLokiConfig          `json:"loki"`
+	ElasticsearchConfig *AnalysisProviderElasticsearchConfig `json:"elasticsearch"`
 }
 
 type genericPipedAnalysisProvider struct {
@@ -387,11 +705,26 @@ func (p *PipedAnalysisProvider) UnmarshalJSON(data []byte) error {
 		if len(gp.Config) > 0 {
 			err = json.Unmarshal(gp.Config, p.DatadogConfig)
 		}
+	case model.AnalysisProviderNewRelic:
+		p.NewRelicConfig = &AnalysisProviderNewRelicConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.NewRelicConfig)
+		}
 	case model.AnalysisProviderStackdriver:
 		p.StackdriverConfig = &AnalysisProviderStackdriverConfig{}
 		if len(gp.Config) > 0 {
 			err = json.Unmarshal(gp.Config, p.StackdriverConfig)
 		}
+	case model.AnalysisProviderLoki:
+		p.LokiConfig = &AnalysisProviderLokiConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.LokiConfig)
+		}
+	case model.AnalysisProviderElasticsearch:
+		p.ElasticsearchConfig = &AnalysisProviderElasticsearchConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.ElasticsearchConfig)
+		}
 	default:
 		err = fmt.Errorf("unsupported analysis provider type: %s", p.Name)
 	}
@@ -404,8 +737,14 @@ func (p *PipedAnalysisProvider) Validate() error {
 		return p.PrometheusConfig.Validate()
 	case model.AnalysisProviderDatadog:
 		return p.DatadogConfig.Validate()
+	case model.AnalysisProviderNewRelic:
+		return p.NewRelicConfig.Validate()
 	case model.AnalysisProviderStackdriver:
 		return p.StackdriverConfig.Validate()
+	case model.AnalysisProviderLoki:
+		return p.LokiConfig.Validate()
+	case model.AnalysisProviderElasticsearch:
+		return p.ElasticsearchConfig.Validate()
 	default:
 		return fmt.Errorf("unknow provider type: %s", p.Type)
 	}
@@ -413,16 +752,59 @@ func (p *PipedAnalysisProvider) Validate() error {
 
 type AnalysisProviderPrometheusConfig struct {
 	Address string `json:"address"`
+	// The addresses tried in order when the previous one was unavailable.
+	FailoverAddresses []string `json:"failoverAddresses"`
 	// The path to the username file.
 	UsernameFile string `json:"usernameFile"`
 	// The path to the password file.
 	PasswordFile string `json:"passwordFile"`
+	// The path to the bearer token file.
+	BearerTokenFile string `json:"bearerTokenFile"`
+	// The headers added to every request, e.g. X-Scope-OrgID for Cortex.
+	Headers map[string]string `json:"headers"`
+	// The TLS configuration used to connect to the servers.
+	TLS *AnalysisProviderTLSConfig `json:"tls"`
 }
 
 func (a *AnalysisProviderPrometheusConfig) Validate() error {
 	if a.Address == "" {
 		return fmt.Errorf("prometheus analysis provider requires the address")
 	}
+	for _, addr := range a.FailoverAddresses {
+		if addr == "" {
+			return fmt.Errorf("prometheus analysis provider requires non-empty failover addresses")
+		}
+	}
+	if a.BearerTokenFile != "" && (a.UsernameFile != "" || a.PasswordFile != "") {
+		return fmt.Errorf("only one of basic auth and bearer token can be specified")
+	}
+	if a.TLS != nil {
+		if err := a.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnalysisProviderTLSConfig represents the TLS configuration
+// used to connect to the analysis provider.
+type AnalysisProviderTLSConfig struct {
+	// The path to the CA certificate file used to verify the server.
+	CAFile string `json:"caFile"`
+	// The path to the client certificate file for mutual TLS.
+	CertFile string `json:"certFile"`
+	// The path to the client key file for mutual TLS.
+	KeyFile string `json:"keyFile"`
+	// The name used to verify the hostname of the server.
+	ServerName string `json:"serverName"`
+	// Whether to skip the verification of the server certificate.
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+}
+
+func (t *AnalysisProviderTLSConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("both certFile and keyFile must be specified for mutual TLS")
+	}
 	return nil
 }
 
@@ -447,20 +829,242 @@ func (a *AnalysisProviderDatadogConfig) Validate() error {
 	return nil
 }
 
+type AnalysisProviderNewRelicConfig struct {
+	// The address of NerdGraph API endpoint.
+	// Use "https://api.eu.newrelic.com/graphql" for the accounts in the EU region.
+	// Defaults to "https://api.newrelic.com/graphql"
+	Address string `json:"address"`
+	// Required: The ID of the account whose data is queried.
+	AccountID int64 `json:"accountID"`
+	// Required: The path to the user API key file.
+	APIKeyFile string `json:"apiKeyFile"`
+}
+
+func (a *AnalysisProviderNewRelicConfig) Validate() error {
+	if a.AccountID <= 0 {
+		return fmt.Errorf("newrelic analysis provider requires the account ID")
+	}
+	if a.APIKeyFile == "" {
+		return fmt.Errorf("newrelic analysis provider requires the api key file")
+	}
+	return nil
+}
+
 type AnalysisProviderStackdriverConfig struct {
+	// The GCP project whose metrics are queried.
+	Project string `json:"project"`
+	// The language of the metrics queries.
+	// Default is MQL.
+	QueryLanguage StackdriverQueryLanguage `json:"queryLanguage"`
 	// The path to the service account file.
+	// Empty means the application default credentials (e.g. workload identity) are used.
 	ServiceAccountFile string `json:"serviceAccountFile"`
+	// The email of the service account to impersonate while querying.
+	ImpersonateServiceAccount string `json:"impersonateServiceAccount"`
 }
 
+// StackdriverQueryLanguage represents the language of Cloud Monitoring queries.
+type StackdriverQueryLanguage string
+
+const (
+	// StackdriverQueryLanguageMQL is the Monitoring Query Language.
+	StackdriverQueryLanguageMQL StackdriverQueryLanguage = "MQL"
+	// StackdriverQueryLanguagePromQL is PromQL sent to the Prometheus-compatible endpoint.
+	StackdriverQueryLanguagePromQL StackdriverQueryLanguage = "PROMQL"
+)
+
 func (a *AnalysisProviderStackdriverConfig) Validate() error {
+	switch a.QueryLanguage {
+	case "", StackdriverQueryLanguageMQL, StackdriverQueryLanguagePromQL:
+	default:
+		return fmt.Errorf("unsupported stackdriver query language %q", a.QueryLanguage)
+	}
 	return nil
 }
 
+type PipedChangeManagementProvider struct {
+	Name string                             `json:"name"`
+	Type model.ChangeManagementProviderType `json:"type"`
+
+	ServiceNowConfig *ChangeManagementProviderServiceNowConfig `json:"serviceNow"`
+}
+
+type genericPipedChangeManagementProvider struct {
+	Name   string                             `json:"name"`
+	Type   model.ChangeManagementProviderType `json:"type"`
+	Config json.RawMessage                    `json:"config"`
+}
+
+func (p *PipedChangeManagementProvider) UnmarshalJSON(data []byte) error {
+	var err error
+	gp := genericPipedChangeManagementProvider{}
+	if err = json.Unmarshal(data, &gp); err != nil {
+		return err
+	}
+	p.Name = gp.Name
+	p.Type = gp.Type
+
+	switch p.Type {
+	case model.ChangeManagementProviderServiceNow:
+		p.ServiceNowConfig = &ChangeManagementProviderServiceNowConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.ServiceNowConfig)
+		}
+	default:
+		err = fmt.Errorf("unsupported change management provider type: %s", p.Type)
+	}
+	return err
+}
+
+func (p *PipedChangeManagementProvider) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name must be set")
+	}
+	switch p.Type {
+	case model.ChangeManagementProviderServiceNow:
+		return p.ServiceNowConfig.Validate()
+	default:
+		return fmt.Errorf("unknown provider type: %s", p.Type)
+	}
+}
+
+type ChangeManagementProviderServiceNowConfig struct {
+	// The address of ServiceNow instance, e.g. https://example.service-now.com.
+	Address string `json:"address"`
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+}
+
+func (c *ChangeManagementProviderServiceNowConfig) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("servicenow change management provider requires the address")
+	}
+	if c.UsernameFile == "" || c.PasswordFile == "" {
+		return fmt.Errorf("servicenow change management provider requires both usernameFile and passwordFile")
+	}
+	return nil
+}
+
+type AnalysisProviderLokiConfig struct {
+	// The Loki server address.
+	Address string `json:"address"`
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+	// The tenant ID sent as X-Scope-OrgID header in multi-tenant mode.
+	TenantID string `json:"tenantID"`
+}
+
+func (a *AnalysisProviderLokiConfig) Validate() error {
+	if a.Address == "" {
+		return fmt.Errorf("loki analysis provider requires the address")
+	}
+	return nil
+}
+
+type AnalysisProviderElasticsearchConfig struct {
+	// The Elasticsearch server address.
+	Address string `json:"address"`
+	// The index or index pattern to be searched.
+	Index string `json:"index"`
+	// The field holding the timestamp of log entries.
+	// Default is @timestamp.
+	TimestampField string `json:"timestampField"`
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+	// The path to the API key file.
+	APIKeyFile string `json:"apiKeyFile"`
+	// Configuration for signing the requests with AWS credentials
+	// to access the domains of Amazon OpenSearch Service.
+	AWS *AnalysisProviderElasticsearchAWSConfig `json:"aws"`
+}
+
+func (a *AnalysisProviderElasticsearchConfig) Validate() error {
+	if a.Address == "" {
+		return fmt.Errorf("elasticsearch analysis provider requires the address")
+	}
+	if a.Index == "" {
+		return fmt.Errorf("elasticsearch analysis provider requires the index")
+	}
+	var auths int
+	if a.UsernameFile != "" || a.PasswordFile != "" {
+		auths++
+	}
+	if a.APIKeyFile != "" {
+		auths++
+	}
+	if a.AWS != nil {
+		auths++
+		if a.AWS.Region == "" {
+			return fmt.Errorf("elasticsearch analysis provider requires the region of aws")
+		}
+	}
+	if auths > 1 {
+		return fmt.Errorf("only one of basic auth, api key and aws can be specified")
+	}
+	return nil
+}
+
+type AnalysisProviderElasticsearchAWSConfig struct {
+	// The region where the domain is running.
+	Region string `json:"region"`
+	// The path to the shared credentials file.
+	CredentialsFile string `json:"credentialsFile"`
+	// The profile to extract credentials from the shared credentials file.
+	Profile string `json:"profile"`
+	// The IAM role arn to use when assuming a role with the WebIdentity token.
+	RoleARN string `json:"roleARN"`
+	// The path to the WebIdentity token the SDK should use to assume a role with.
+	TokenFile string `json:"tokenFile"`
+}
+
 type Notifications struct {
 	// List of notification routes.
 	Routes []NotificationRoute `json:"routes"`
 	// List of notification receivers.
 	Receivers []NotificationReceiver `json:"receivers"`
+	// Configuration for handling the interactions from Slack,
+	// e.g. approving or rejecting WAIT_APPROVAL stages by the buttons of notifications.
+	SlackInteraction *NotificationSlackInteraction `json:"slackInteraction"`
+}
+
+type NotificationSlackInteraction struct {
+	// The path to the file containing the signing secret of the Slack app.
+	// It is used to verify the requests sent from Slack.
+	SigningSecretFile string `json:"signingSecretFile"`
+	// Map from Slack user ID to PipeCD username.
+	// Only these users are allowed to approve or reject stages from Slack.
+	Users map[string]string `json:"users"`
+	// The port number used to run the HTTP server receiving the interactions from Slack.
+	// It is separated from the admin server so that only this endpoint has to be exposed.
+	// Default is 9086.
+	Port int `json:"port"`
+}
+
+func (s *NotificationSlackInteraction) Validate() error {
+	if s.SigningSecretFile == "" {
+		return fmt.Errorf("signingSecretFile must be set")
+	}
+	if len(s.Users) == 0 {
+		return fmt.Errorf("users must be set")
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("port must be between 0 and 65535")
+	}
+	return nil
+}
+
+// GetPort returns the configured port or the default one.
+func (s *NotificationSlackInteraction) GetPort() int {
+	if s.Port == 0 {
+		return defaultSlackInteractionPort
+	}
+	return s.Port
 }
 
 type NotificationRoute struct {
@@ -474,12 +1078,18 @@ type NotificationRoute struct {
 	IgnoreApps   []string `json:"ignoreApps"`
 	Envs         []string `json:"envs"`
 	IgnoreEnvs   []string `json:"ignoreEnvs"`
+	// The events of the deployments whose application has all of these labels are routed.
+	Labels map[string]string `json:"labels"`
+	// The events of the deployments whose application has any of these labels are not routed.
+	IgnoreLabels map[string]string `json:"ignoreLabels"`
 }
 
 type NotificationReceiver struct {
-	Name    string                       `json:"name"`
-	Slack   *NotificationReceiverSlack   `json:"slack"`
-	Webhook *NotificationReceiverWebhook `json:"webhook"`
+	Name      string                         `json:"name"`
+	Slack     *NotificationReceiverSlack     `json:"slack"`
+	Webhook   *NotificationReceiverWebhook   `json:"webhook"`
+	PagerDuty *NotificationReceiverPagerDuty `json:"pagerDuty"`
+	Email     *NotificationReceiverEmail     `json:"email"`
 }
 
 type NotificationReceiverSlack struct {
@@ -490,13 +1100,75 @@ type NotificationReceiverWebhook struct {
 	URL string `json:"url"`
 }
 
+// NotificationReceiverPagerDuty sends alerts to PagerDuty through the Events API v2.
+// An alert is triggered when a deployment was failed or started rolling back
+// and it is resolved by the next successful deployment of the same application.
+type NotificationReceiverPagerDuty struct {
+	// The path to the file containing the integration key
+	// of an Events API v2 integration of the PagerDuty service.
+	RoutingKeyFile string `json:"routingKeyFile"`
+	// The severity of the triggered alerts.
+	// Available values: critical, error, warning, info.
+	// Default is error.
+	Severity string `json:"severity"`
+}
+
+func (p *NotificationReceiverPagerDuty) Validate() error {
+	if p.RoutingKeyFile == "" {
+		return fmt.Errorf("routingKeyFile must be set")
+	}
+	switch p.Severity {
+	case "", "critical", "error", "warning", "info":
+	default:
+		return fmt.Errorf("unsupported severity %q", p.Severity)
+	}
+	return nil
+}
+
+// NotificationReceiverEmail sends notifications by email through an SMTP server.
+type NotificationReceiverEmail struct {
+	// The address of the SMTP server in host:port format.
+	SMTPAddress string `json:"smtpAddress"`
+	// The username used to authenticate with the SMTP server.
+	// Empty means no authentication.
+	SMTPUsername string `json:"smtpUsername"`
+	// The path to the file containing the password of the SMTP user.
+	SMTPPasswordFile string `json:"smtpPasswordFile"`
+	// The address used as the sender of the emails.
+	From string `json:"from"`
+	// The list of email addresses to receive the notifications.
+	To []string `json:"to"`
+	// Map from email address to PipeCD username.
+	// These addresses receive the notifications too, and the ones for WAIT_APPROVAL stages
+	// contain the one-time links to approve or reject the stages as the mapped users.
+	Approvers map[string]string `json:"approvers"`
+}
+
+func (e *NotificationReceiverEmail) Validate() error {
+	if e.SMTPAddress == "" {
+		return fmt.Errorf("smtpAddress must be set")
+	}
+	if e.SMTPUsername != "" && e.SMTPPasswordFile == "" {
+		return fmt.Errorf("smtpPasswordFile must be set when smtpUsername is set")
+	}
+	if e.From == "" {
+		return fmt.Errorf("from must be set")
+	}
+	if len(e.To) == 0 && len(e.Approvers) == 0 {
+		return fmt.Errorf("either to or approvers must be set")
+	}
+	return nil
+}
+
 type SealedSecretManagement struct {
 	// Which management service should be used.
-	// Available values: SEALING_KEY, GCP_KMS, AWS_KMS
+	// Available values: SEALING_KEY, GCP_KMS, AWS_KMS, VAULT
 	Type model.SealedSecretManagementType `json:"type"`
 
 	SealingKeyConfig *SealedSecretManagementSealingKey
 	GCPKMSConfig     *SealedSecretManagementGCPKMS
+	AWSKMSConfig     *SealedSecretManagementAWSKMS
+	VaultConfig      *SealedSecretManagementVault
 }
 
 func (m *SealedSecretManagement) Validate() error {
@@ -505,6 +1177,10 @@ func (m *SealedSecretManagement) Validate() error {
 		return m.SealingKeyConfig.Validate()
 	case model.SealedSecretManagementGCPKMS:
 		return m.GCPKMSConfig.Validate()
+	case model.SealedSecretManagementAWSKMS:
+		return m.AWSKMSConfig.Validate()
+	case model.SealedSecretManagementVault:
+		return m.VaultConfig.Validate()
 	default:
 		return fmt.Errorf("unsupported sealed secret management type: %s", m.Type)
 	}
@@ -516,6 +1192,9 @@ type SealedSecretManagementSealingKey struct {
 	PrivateKeyFile string `json:"privateKeyFile"`
 	// The path to the public RSA key file.
 	PublicKeyFile string `json:"publicKeyFile"`
+	// The paths to the private RSA key files those were used before rotating the key.
+	// They are tried in order when the sealed secret was not encrypted by the current key.
+	PreviousPrivateKeyFiles []string `json:"previousPrivateKeyFiles"`
 }
 
 func (m *SealedSecretManagementSealingKey) Validate() error {
@@ -533,8 +1212,13 @@ type SealedSecretManagementGCPKMS struct {
 	// The key name used for decrypting the sealed secret.
 	KeyName string `json:"keyName"`
 	// The path to the service account used to decrypt secret.
+	// Empty means the application default credentials (e.g. workload identity) are used.
 	DecryptServiceAccountFile string `json:"decryptServiceAccountFile"`
+	// The email of the service account to impersonate while decrypting secret.
+	DecryptImpersonateServiceAccount string `json:"decryptImpersonateServiceAccount"`
 	// The path to the service account used to encrypt secret.
+	// Deprecated: The secrets are encrypted locally by pipectl
+	// with the credentials of the user, so this is not used anymore.
 	EncryptServiceAccountFile string `json:"encryptServiceAccountFile"`
 }
 
@@ -542,11 +1226,66 @@ func (m *SealedSecretManagementGCPKMS) Validate() error {
 	if m.KeyName == "" {
 		return fmt.Errorf("keyName must be set")
 	}
-	if m.DecryptServiceAccountFile == "" {
-		return fmt.Errorf("decryptServiceAccountFile must be set")
+	return nil
+}
+
+type SealedSecretManagementAWSKMS struct {
+	// Configurable fields when using AWS KMS.
+	// The ARN of the key used for decrypting the sealed secret.
+	KeyARN string `json:"keyARN"`
+	// The region where the key is located.
+	Region string `json:"region"`
+	// Path to the shared credentials file.
+	// Empty means the default credential chain (e.g. IAM role for service accounts) is used.
+	CredentialsFile string `json:"credentialsFile"`
+	// AWS Profile to extract credentials from the shared credentials file.
+	// If empty, the environment variable "AWS_PROFILE" is used.
+	// "default" is populated if the environment variable is also not set.
+	Profile string `json:"profile"`
+	// The IAM role arn to use when assuming a role with the WebIdentity token.
+	RoleARN string `json:"roleARN"`
+	// Path to the WebIdentity token the SDK should use to assume a role with.
+	TokenFile string `json:"tokenFile"`
+}
+
+func (m *SealedSecretManagementAWSKMS) Validate() error {
+	if m.KeyARN == "" {
+		return fmt.Errorf("keyARN must be set")
+	}
+	if m.Region == "" {
+		return fmt.Errorf("region must be set")
 	}
-	if m.EncryptServiceAccountFile == "" {
-		return fmt.Errorf("encryptServiceAccountFile must be set")
+	return nil
+}
+
+type SealedSecretManagementVault struct {
+	// Configurable fields when using the transit secrets engine of HashiCorp Vault.
+	// The address of Vault server, e.g. https://vault.example.com:8200.
+	Address string `json:"address"`
+	// The name of the transit key used for decrypting the sealed secret.
+	KeyName string `json:"keyName"`
+	// The path where the transit engine is mounted.
+	// Default is "transit".
+	TransitMount string `json:"transitMount"`
+	// The path to the file containing the Vault token.
+	// The file is read at every decryption so that it can be renewed
+	// by e.g. Vault Agent.
+	TokenFile string `json:"tokenFile"`
+	// The Vault Enterprise namespace.
+	Namespace string `json:"namespace"`
+	// The path to the CA certificate file used to verify the server.
+	CAFile string `json:"caFile"`
+}
+
+func (m *SealedSecretManagementVault) Validate() error {
+	if m.Address == "" {
+		return fmt.Errorf("address must be set")
+	}
+	if m.KeyName == "" {
+		return fmt.Errorf("keyName must be set")
+	}
+	if m.TokenFile == "" {
+		return fmt.Errorf("tokenFile must be set")
 	}
 	return nil
 }
@@ -575,6 +1314,16 @@ func (p *SealedSecretManagement) UnmarshalJSON(data []byte) error {
 		if len(g.Config) > 0 {
 			err = json.Unmarshal(g.Config, p.GCPKMSConfig)
 		}
+	case model.SealedSecretManagementAWSKMS:
+		p.AWSKMSConfig = &SealedSecretManagementAWSKMS{}
+		if len(g.Config) > 0 {
+			err = json.Unmarshal(g.Config, p.AWSKMSConfig)
+		}
+	case model.SealedSecretManagementVault:
+		p.VaultConfig = &SealedSecretManagementVault{}
+		if len(g.Config) > 0 {
+			err = json.Unmarshal(g.Config, p.VaultConfig)
+		}
 	default:
 		err = fmt.Errorf("unsupported sealed secret management type: %s", p.Type)
 	}
@@ -604,6 +1353,58 @@ func (p *PipedEventWatcher) Validate() error {
 	return nil
 }
 
+type PipedLiveStateReporter struct {
+	// The maximum size in bytes of the data sent in one request.
+	// The snapshot larger than this is split into multiple chunks.
+	// Default is 1048576 (1MiB).
+	MaxChunkSize int `json:"maxChunkSize"`
+	// The maximum total size in bytes of the resources reported for each application.
+	// When exceeding, the resources owned by other resources are dropped first.
+	// A resource larger than maxChunkSize is always dropped.
+	// This should be kept under the limit of the control plane.
+	// Default is 33554432 (32MiB).
+	MaxSnapshotSize int `json:"maxSnapshotSize"`
+	// The maximum number of resources reported for each application.
+	// When exceeding, the resources owned by other resources are dropped first.
+	// Default is 0, which means unlimited.
+	MaxResources int `json:"maxResources"`
+}
+
+func (r *PipedLiveStateReporter) Validate() error {
+	if r.MaxChunkSize < 0 {
+		return fmt.Errorf("maxChunkSize must not be negative")
+	}
+	if r.MaxSnapshotSize < 0 {
+		return fmt.Errorf("maxSnapshotSize must not be negative")
+	}
+	if r.MaxResources < 0 {
+		return fmt.Errorf("maxResources must not be negative")
+	}
+	return nil
+}
+
+type PipedResourceActions struct {
+	// Whether the resource actions requested from the web console
+	// should be executed by this piped.
+	// Default is false.
+	Enabled bool `json:"enabled"`
+	// The maximum number of replicas a workload can be scaled to.
+	// Default is 0, which means unlimited.
+	MaxReplicas int `json:"maxReplicas"`
+	// Whether the logs of the live pods can be fetched
+	// via this piped for debugging from the web console.
+	// This is effective only when the resource actions are enabled.
+	// Default is false.
+	PodDebugging bool `json:"podDebugging"`
+}
+
+func (r *PipedResourceActions) Validate() error {
+	if r.MaxReplicas < 0 {
+		return fmt.Errorf("maxReplicas must not be negative")
+	}
+	return nil
+}
+
 type PipedEventWatcherGitRepo struct {
 	// Id of the git repository. This must be unique within
 	// the repos' elements.
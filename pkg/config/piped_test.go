@@ -15,6 +15,9 @@
 package config
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -72,6 +75,14 @@ func TestPipedConfig(t *testing.T) {
 						Password: "basic-password",
 					},
 				},
+				ChartRegistries: []HelmChartRegistry{
+					{
+						Type:     OCIHelmChartRegistry,
+						Address:  "asia-northeast1-docker.pkg.dev",
+						Username: "oci-username",
+						Password: "oci-password",
+					},
+				},
 				CloudProviders: []PipedCloudProvider{
 					{
 						Name: "kubernetes-default",
@@ -104,6 +115,10 @@ func TestPipedConfig(t *testing.T) {
 								"project=gcp-project",
 								"region=us-centra1",
 							},
+							DependencyCheck: &TerraformDependencyCheck{
+								Enabled:  true,
+								Interval: Duration(12 * time.Hour),
+							},
 						},
 					},
 					{
@@ -118,6 +133,10 @@ func TestPipedConfig(t *testing.T) {
 					{
 						Name: "lambda",
 						Type: model.CloudProviderLambda,
+						RateLimit: CloudProviderRateLimit{
+							QPS:   10,
+							Burst: 20,
+						},
 						LambdaConfig: &CloudProviderLambdaConfig{
 							Region: "us-east-1",
 						},
@@ -140,6 +159,14 @@ func TestPipedConfig(t *testing.T) {
 							ApplicationKeyFile: "/etc/piped-secret/datadog-application-key",
 						},
 					},
+					{
+						Name: "newrelic-dev",
+						Type: model.AnalysisProviderNewRelic,
+						NewRelicConfig: &AnalysisProviderNewRelicConfig{
+							AccountID:  12345,
+							APIKeyFile: "/etc/piped-secret/newrelic-api-key",
+						},
+					},
 					{
 						Name: "stackdriver-dev",
 						Type: model.AnalysisProviderStackdriver,
@@ -154,6 +181,9 @@ func TestPipedConfig(t *testing.T) {
 							Name:     "dev-slack",
 							Envs:     []string{"dev"},
 							Receiver: "dev-slack-channel",
+							IgnoreLabels: map[string]string{
+								"notification": "muted",
+							},
 						},
 						{
 							Name:     "prod-slack",
@@ -165,6 +195,12 @@ func TestPipedConfig(t *testing.T) {
 							Name:     "all-events-to-ci",
 							Receiver: "ci-webhook",
 						},
+						{
+							Name:     "prod-failures-to-oncall",
+							Envs:     []string{"prod"},
+							Events:   []string{"DEPLOYMENT_ROLLING_BACK", "DEPLOYMENT_FAILED", "DEPLOYMENT_SUCCEEDED"},
+							Receiver: "oncall-pagerduty",
+						},
 					},
 					Receivers: []NotificationReceiver{
 						{
@@ -185,6 +221,26 @@ func TestPipedConfig(t *testing.T) {
 								URL: "https://pipecd.dev/dev-hook",
 							},
 						},
+						{
+							Name: "oncall-pagerduty",
+							PagerDuty: &NotificationReceiverPagerDuty{
+								RoutingKeyFile: "/etc/piped-secret/pagerduty-routing-key",
+								Severity:       "critical",
+							},
+						},
+						{
+							Name: "prod-approvers-email",
+							Email: &NotificationReceiverEmail{
+								SMTPAddress:      "smtp.example.com:587",
+								SMTPUsername:     "piped",
+								SMTPPasswordFile: "/etc/piped-secret/smtp-password",
+								From:             "piped@example.com",
+								To:               []string{"team@example.com"},
+								Approvers: map[string]string{
+									"alice@example.com": "alice",
+								},
+							},
+						},
 					},
 				},
 				SealedSecretManagement: &SealedSecretManagement{
@@ -303,3 +359,609 @@ func TestPipedEventWatcherValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestPipedSpecGetWorkspaceDir(t *testing.T) {
+	testcases := []struct {
+		name     string
+		spec     PipedSpec
+		expected string
+	}{
+		{
+			name:     "default",
+			spec:     PipedSpec{PipedID: "piped-id"},
+			expected: filepath.Join(os.TempDir(), "piped-piped-id"),
+		},
+		{
+			name:     "configured",
+			spec:     PipedSpec{PipedID: "piped-id", WorkspaceDir: "/dev/shm"},
+			expected: "/dev/shm/piped-piped-id",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.spec.GetWorkspaceDir())
+		})
+	}
+}
+
+func TestPipedRepositoryValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		repo    PipedRepository
+		wantErr bool
+	}{
+		{
+			name: "no config filename",
+			repo: PipedRepository{RepoID: "repo"},
+		},
+		{
+			name: "valid config filenames",
+			repo: PipedRepository{
+				RepoID: "repo",
+				ConfigFilenames: []PipedRepositoryConfigFilename{
+					{Filename: "app.pipecd.yaml"},
+					{Dir: "apps/prod", Filename: "prod.pipecd.yaml"},
+				},
+			},
+		},
+		{
+			name: "missing filename",
+			repo: PipedRepository{
+				RepoID: "repo",
+				ConfigFilenames: []PipedRepositoryConfigFilename{
+					{Dir: "apps/prod"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "filename containing directory",
+			repo: PipedRepository{
+				RepoID: "repo",
+				ConfigFilenames: []PipedRepositoryConfigFilename{
+					{Filename: "prod/app.pipecd.yaml"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.repo.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestHelmChartRegistryValidate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		registry HelmChartRegistry
+		wantType HelmChartRegistryType
+		wantErr  bool
+	}{
+		{
+			name:     "default type",
+			registry: HelmChartRegistry{Address: "ghcr.io", Username: "user", Password: "password"},
+			wantType: OCIHelmChartRegistry,
+		},
+		{
+			name:     "password file",
+			registry: HelmChartRegistry{Address: "ghcr.io", Username: "user", PasswordFile: "/etc/piped-secret/oci-token"},
+			wantType: OCIHelmChartRegistry,
+		},
+		{
+			name:     "unsupported type",
+			registry: HelmChartRegistry{Type: "HTTP", Address: "ghcr.io", Username: "user", Password: "password"},
+			wantType: "HTTP",
+			wantErr:  true,
+		},
+		{
+			name:     "missing address",
+			registry: HelmChartRegistry{Type: OCIHelmChartRegistry, Username: "user", Password: "password"},
+			wantType: OCIHelmChartRegistry,
+			wantErr:  true,
+		},
+		{
+			name:     "address containing scheme",
+			registry: HelmChartRegistry{Address: "oci://ghcr.io", Username: "user", Password: "password"},
+			wantType: OCIHelmChartRegistry,
+			wantErr:  true,
+		},
+		{
+			name:     "missing credentials",
+			registry: HelmChartRegistry{Address: "ghcr.io"},
+			wantType: OCIHelmChartRegistry,
+			wantErr:  true,
+		},
+		{
+			name:     "missing password",
+			registry: HelmChartRegistry{Address: "ghcr.io", Username: "user"},
+			wantType: OCIHelmChartRegistry,
+			wantErr:  true,
+		},
+		{
+			name:     "both password and password file",
+			registry: HelmChartRegistry{Address: "ghcr.io", Username: "user", Password: "password", PasswordFile: "/etc/piped-secret/oci-token"},
+			wantType: OCIHelmChartRegistry,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.registry.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantType, tc.registry.Type)
+		})
+	}
+}
+
+func TestPipedRepositoryGetConfigFilenameMap(t *testing.T) {
+	repo := PipedRepository{
+		RepoID: "repo",
+		ConfigFilenames: []PipedRepositoryConfigFilename{
+			{Filename: "app.pipecd.yaml"},
+			{Dir: "apps/prod/", Filename: "prod.pipecd.yaml"},
+		},
+	}
+	expected := map[string]string{
+		".":         "app.pipecd.yaml",
+		"apps/prod": "prod.pipecd.yaml",
+	}
+	assert.Equal(t, expected, repo.GetConfigFilenameMap())
+}
+
+func TestCloudProviderKubernetesConfigGetVariantLabel(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cfg      CloudProviderKubernetesConfig
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: "pipecd.dev/variant",
+		},
+		{
+			name: "configured",
+			cfg: CloudProviderKubernetesConfig{
+				VariantLabel: "example.com/variant",
+			},
+			expected: "example.com/variant",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.cfg.GetVariantLabel())
+		})
+	}
+}
+
+func TestCloudProviderKubernetesConfigGetAnnotationPrefix(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cfg      CloudProviderKubernetesConfig
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: "pipecd.dev",
+		},
+		{
+			name: "configured",
+			cfg: CloudProviderKubernetesConfig{
+				AnnotationPrefix: "example.com",
+			},
+			expected: "example.com",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.cfg.GetAnnotationPrefix())
+		})
+	}
+}
+
+func TestAnalysisProviderPrometheusConfigValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     AnalysisProviderPrometheusConfig
+		wantErr bool
+	}{
+		{
+			name:    "missing address",
+			wantErr: true,
+		},
+		{
+			name: "valid",
+			cfg: AnalysisProviderPrometheusConfig{
+				Address:           "https://prometheus-0.dev",
+				FailoverAddresses: []string{"https://prometheus-1.dev"},
+				BearerTokenFile:   "/etc/piped-secret/prometheus-token",
+				TLS: &AnalysisProviderTLSConfig{
+					CertFile: "/etc/piped-secret/client.crt",
+					KeyFile:  "/etc/piped-secret/client.key",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "both basic auth and bearer token",
+			cfg: AnalysisProviderPrometheusConfig{
+				Address:         "https://prometheus-0.dev",
+				UsernameFile:    "/etc/piped-secret/username",
+				PasswordFile:    "/etc/piped-secret/password",
+				BearerTokenFile: "/etc/piped-secret/prometheus-token",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing client key",
+			cfg: AnalysisProviderPrometheusConfig{
+				Address: "https://prometheus-0.dev",
+				TLS: &AnalysisProviderTLSConfig{
+					CertFile: "/etc/piped-secret/client.crt",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestAnalysisProviderStackdriverConfigValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     AnalysisProviderStackdriverConfig
+		wantErr bool
+	}{
+		{
+			name:    "default query language",
+			cfg:     AnalysisProviderStackdriverConfig{Project: "project"},
+			wantErr: false,
+		},
+		{
+			name: "promql",
+			cfg: AnalysisProviderStackdriverConfig{
+				Project:       "project",
+				QueryLanguage: StackdriverQueryLanguagePromQL,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported query language",
+			cfg: AnalysisProviderStackdriverConfig{
+				Project:       "project",
+				QueryLanguage: "SQL",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestAnalysisProviderElasticsearchConfigValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     AnalysisProviderElasticsearchConfig
+		wantErr bool
+	}{
+		{
+			name: "valid config",
+			cfg: AnalysisProviderElasticsearchConfig{
+				Address:    "https://elasticsearch:9200",
+				Index:      "logs-*",
+				APIKeyFile: "/etc/piped-secret/es-api-key",
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing index",
+			cfg: AnalysisProviderElasticsearchConfig{
+				Address: "https://elasticsearch:9200",
+			},
+			wantErr: true,
+		},
+		{
+			name: "both basic auth and api key",
+			cfg: AnalysisProviderElasticsearchConfig{
+				Address:      "https://elasticsearch:9200",
+				Index:        "logs-*",
+				UsernameFile: "/etc/piped-secret/es-username",
+				PasswordFile: "/etc/piped-secret/es-password",
+				APIKeyFile:   "/etc/piped-secret/es-api-key",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestPipedChangeManagementProviderValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid servicenow config",
+			data: `{"name":"servicenow","type":"SERVICENOW","config":{"address":"https://example.service-now.com","usernameFile":"/etc/piped-secret/sn-username","passwordFile":"/etc/piped-secret/sn-password"}}`,
+		},
+		{
+			name:    "missing name",
+			data:    `{"type":"SERVICENOW","config":{"address":"https://example.service-now.com","usernameFile":"/etc/piped-secret/sn-username","passwordFile":"/etc/piped-secret/sn-password"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing credentials",
+			data:    `{"name":"servicenow","type":"SERVICENOW","config":{"address":"https://example.service-now.com"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			data:    `{"name":"jira","type":"JIRA"}`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p PipedChangeManagementProvider
+			err := json.Unmarshal([]byte(tc.data), &p)
+			if err == nil {
+				err = p.Validate()
+			}
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestSealedSecretManagementAWSKMSValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     SealedSecretManagementAWSKMS
+		wantErr bool
+	}{
+		{
+			name: "valid config",
+			cfg: SealedSecretManagementAWSKMS{
+				KeyARN: "arn:aws:kms:us-west-2:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+				Region: "us-west-2",
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing key",
+			cfg: SealedSecretManagementAWSKMS{
+				Region: "us-west-2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing region",
+			cfg: SealedSecretManagementAWSKMS{
+				KeyARN: "arn:aws:kms:us-west-2:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestSealedSecretManagementGCPKMSValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     SealedSecretManagementGCPKMS
+		wantErr bool
+	}{
+		{
+			name: "valid config",
+			cfg: SealedSecretManagementGCPKMS{
+				KeyName: "projects/project-id/locations/global/keyRings/key-ring/cryptoKeys/key-name",
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing key",
+			cfg: SealedSecretManagementGCPKMS{
+				DecryptServiceAccountFile: "/etc/piped-secret/decrypt-service-account.json",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestSealedSecretManagementVaultValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     SealedSecretManagementVault
+		wantErr bool
+	}{
+		{
+			name: "valid config",
+			cfg: SealedSecretManagementVault{
+				Address:   "https://vault.example.com:8200",
+				KeyName:   "piped",
+				TokenFile: "/etc/piped-secret/vault-token",
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing address",
+			cfg: SealedSecretManagementVault{
+				KeyName:   "piped",
+				TokenFile: "/etc/piped-secret/vault-token",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing key",
+			cfg: SealedSecretManagementVault{
+				Address:   "https://vault.example.com:8200",
+				TokenFile: "/etc/piped-secret/vault-token",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing token file",
+			cfg: SealedSecretManagementVault{
+				Address: "https://vault.example.com:8200",
+				KeyName: "piped",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestNotificationSlackInteractionValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     NotificationSlackInteraction
+		wantErr bool
+	}{
+		{
+			name: "valid config",
+			cfg: NotificationSlackInteraction{
+				SigningSecretFile: "/etc/piped-secret/slack-signing-secret",
+				Users: map[string]string{
+					"U01234567": "alice",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing signing secret file",
+			cfg: NotificationSlackInteraction{
+				Users: map[string]string{
+					"U01234567": "alice",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing users",
+			cfg: NotificationSlackInteraction{
+				SigningSecretFile: "/etc/piped-secret/slack-signing-secret",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid port",
+			cfg: NotificationSlackInteraction{
+				SigningSecretFile: "/etc/piped-secret/slack-signing-secret",
+				Users: map[string]string{
+					"U01234567": "alice",
+				},
+				Port: 70000,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestNotificationReceiverEmailValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     NotificationReceiverEmail
+		wantErr bool
+	}{
+		{
+			name: "valid config",
+			cfg: NotificationReceiverEmail{
+				SMTPAddress:      "smtp.example.com:587",
+				SMTPUsername:     "piped",
+				SMTPPasswordFile: "/etc/piped-secret/smtp-password",
+				From:             "piped@example.com",
+				To:               []string{"team@example.com"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid config with only approvers",
+			cfg: NotificationReceiverEmail{
+				SMTPAddress: "smtp.example.com:25",
+				From:        "piped@example.com",
+				Approvers: map[string]string{
+					"alice@example.com": "alice",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing smtp address",
+			cfg: NotificationReceiverEmail{
+				From: "piped@example.com",
+				To:   []string{"team@example.com"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing password file",
+			cfg: NotificationReceiverEmail{
+				SMTPAddress:  "smtp.example.com:587",
+				SMTPUsername: "piped",
+				From:         "piped@example.com",
+				To:           []string{"team@example.com"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing from",
+			cfg: NotificationReceiverEmail{
+				SMTPAddress: "smtp.example.com:587",
+				To:          []string{"team@example.com"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing recipients",
+			cfg: NotificationReceiverEmail{
+				SMTPAddress: "smtp.example.com:587",
+				From:        "piped@example.com",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package awskms provides a decrypter for the sealed secrets
+// those were encrypted by a key managed by AWS Key Management Service.
+package awskms
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// DecryptAPIClient is the subset of the KMS client used by Decrypter.
+type DecryptAPIClient interface {
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// Decrypter decrypts the base64 encoded ciphertext
+// produced by the KMS Encrypt API with the configured key.
+type Decrypter struct {
+	client  DecryptAPIClient
+	keyARN  string
+	timeout time.Duration
+}
+
+// NewDecrypter returns a decrypter using the given KMS key.
+func NewDecrypter(client DecryptAPIClient, keyARN string) (*Decrypter, error) {
+	if keyARN == "" {
+		return nil, fmt.Errorf("keyARN is required")
+	}
+	return &Decrypter{
+		client:  client,
+		keyARN:  keyARN,
+		timeout: defaultTimeout,
+	}, nil
+}
+
+// Decrypt decrypts the given base64 encoded ciphertext.
+func (d *Decrypter) Decrypt(encryptedText string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encryptedText)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the encrypted text: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	out, err := d.client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+		// Specifying the key ensures that the ciphertext was encrypted by the expected key.
+		KeyId: aws.String(d.keyARN),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt by aws kms: %w", err)
+	}
+	return string(out.Plaintext), nil
+}
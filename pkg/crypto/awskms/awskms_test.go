@@ -0,0 +1,84 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awskms
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyARN = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+type fakeKMSClient struct{}
+
+func (c *fakeKMSClient) Decrypt(_ context.Context, params *kms.DecryptInput, _ ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if aws.ToString(params.KeyId) != testKeyARN {
+		return nil, errors.New("IncorrectKeyException")
+	}
+	if string(params.CiphertextBlob) != "ciphertext" {
+		return nil, errors.New("InvalidCiphertextException")
+	}
+	return &kms.DecryptOutput{
+		KeyId:     params.KeyId,
+		Plaintext: []byte("plaintext"),
+	}, nil
+}
+
+func TestDecrypt(t *testing.T) {
+	d, err := NewDecrypter(&fakeKMSClient{}, testKeyARN)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		encryptedText string
+		expected      string
+		wantErr       bool
+	}{
+		{
+			name:          "ok",
+			encryptedText: base64.StdEncoding.EncodeToString([]byte("ciphertext")),
+			expected:      "plaintext",
+		},
+		{
+			name:          "not base64 encoded",
+			encryptedText: "ciphertext!",
+			wantErr:       true,
+		},
+		{
+			name:          "invalid ciphertext",
+			encryptedText: base64.StdEncoding.EncodeToString([]byte("invalid")),
+			wantErr:       true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := d.Decrypt(tc.encryptedText)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestNewDecrypterRequiresKey(t *testing.T) {
+	_, err := NewDecrypter(&fakeKMSClient{}, "")
+	assert.Error(t, err)
+}
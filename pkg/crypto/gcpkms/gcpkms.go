@@ -0,0 +1,174 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcpkms provides an encrypter and a decrypter for the sealed secrets
+// those are envelope-encrypted by a key managed by Google Cloud Key Management Service.
+//
+// A random data encryption key is generated for each secret to encrypt it by AES-GCM,
+// and only that key is encrypted by the Cloud KMS key.
+// The output string is the base64 encoded data of:
+//   encrypted key length || encrypted key || AES ciphertext
+package gcpkms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	gax "github.com/googleapis/gax-go/v2"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	// The size of the randomly generated data encryption key.
+	dataKeySize = 32
+)
+
+// EncryptAPIClient is the subset of the KMS client used by Encrypter.
+type EncryptAPIClient interface {
+	Encrypt(ctx context.Context, req *kmspb.EncryptRequest, opts ...gax.CallOption) (*kmspb.EncryptResponse, error)
+}
+
+// DecryptAPIClient is the subset of the KMS client used by Decrypter.
+type DecryptAPIClient interface {
+	Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error)
+}
+
+// Encrypter envelope-encrypts the given text with the configured key.
+type Encrypter struct {
+	client  EncryptAPIClient
+	keyName string
+}
+
+// NewEncrypter returns an encrypter using the given KMS key.
+// The key name must be in the format of
+// projects/*/locations/*/keyRings/*/cryptoKeys/*.
+func NewEncrypter(client EncryptAPIClient, keyName string) (*Encrypter, error) {
+	if keyName == "" {
+		return nil, fmt.Errorf("keyName is required")
+	}
+	return &Encrypter{
+		client:  client,
+		keyName: keyName,
+	}, nil
+}
+
+// Encrypt encrypts the given text and returns the base64 encoded ciphertext.
+func (e *Encrypter) Encrypt(ctx context.Context, text string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	resp, err := e.client.Encrypt(ctx, &kmspb.EncryptRequest{
+		Name:      e.keyName,
+		Plaintext: dataKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt by gcp kms: %w", err)
+	}
+	if len(resp.Ciphertext) > 0xffff {
+		return "", fmt.Errorf("encrypted key is too long")
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	// First 2 bytes are the encrypted key length, so we can separate all the pieces later.
+	ciphertext := make([]byte, 2)
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(resp.Ciphertext)))
+	ciphertext = append(ciphertext, resp.Ciphertext...)
+
+	// Data key is only used once, so zero nonce is ok.
+	zeroNonce := make([]byte, aead.NonceSize())
+	ciphertext = aead.Seal(ciphertext, zeroNonce, []byte(text), nil)
+
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypter decrypts the ciphertext produced by Encrypter with the configured key.
+type Decrypter struct {
+	client  DecryptAPIClient
+	keyName string
+	timeout time.Duration
+}
+
+// NewDecrypter returns a decrypter using the given KMS key.
+func NewDecrypter(client DecryptAPIClient, keyName string) (*Decrypter, error) {
+	if keyName == "" {
+		return nil, fmt.Errorf("keyName is required")
+	}
+	return &Decrypter{
+		client:  client,
+		keyName: keyName,
+		timeout: defaultTimeout,
+	}, nil
+}
+
+// Decrypt decrypts the given base64 encoded ciphertext.
+func (d *Decrypter) Decrypt(encryptedText string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encryptedText)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the encrypted text: %w", err)
+	}
+
+	if len(ciphertext) < 2 {
+		return "", fmt.Errorf("data is too short")
+	}
+	keyLen := int(binary.BigEndian.Uint16(ciphertext))
+	if len(ciphertext) < keyLen+2 {
+		return "", fmt.Errorf("data is too short")
+	}
+	encryptedKey := ciphertext[2 : keyLen+2]
+	ciphertext = ciphertext[keyLen+2:]
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	resp, err := d.client.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:       d.keyName,
+		Ciphertext: encryptedKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt by gcp kms: %w", err)
+	}
+
+	aead, err := newAEAD(resp.Plaintext)
+	if err != nil {
+		return "", err
+	}
+	zeroNonce := make([]byte, aead.NonceSize())
+	plaintext, err := aead.Open(nil, zeroNonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
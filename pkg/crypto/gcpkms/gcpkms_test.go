@@ -0,0 +1,108 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpkms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	gax "github.com/googleapis/gax-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+const testKeyName = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+
+// fakeKMSClient wraps the data with the key name instead of encrypting it.
+type fakeKMSClient struct{}
+
+func (c *fakeKMSClient) Encrypt(_ context.Context, req *kmspb.EncryptRequest, _ ...gax.CallOption) (*kmspb.EncryptResponse, error) {
+	return &kmspb.EncryptResponse{
+		Name:       req.Name,
+		Ciphertext: append([]byte(req.Name+":"), req.Plaintext...),
+	}, nil
+}
+
+func (c *fakeKMSClient) Decrypt(_ context.Context, req *kmspb.DecryptRequest, _ ...gax.CallOption) (*kmspb.DecryptResponse, error) {
+	prefix := []byte(req.Name + ":")
+	if !bytes.HasPrefix(req.Ciphertext, prefix) {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return &kmspb.DecryptResponse{
+		Plaintext: bytes.TrimPrefix(req.Ciphertext, prefix),
+	}, nil
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	client := &fakeKMSClient{}
+	e, err := NewEncrypter(client, testKeyName)
+	require.NoError(t, err)
+	d, err := NewDecrypter(client, testKeyName)
+	require.NoError(t, err)
+
+	encrypted, err := e.Encrypt(context.Background(), "plaintext")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "plaintext")
+
+	decrypted, err := d.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext", decrypted)
+
+	// Decrypting by another key must fail.
+	other, err := NewDecrypter(client, "projects/p/locations/global/keyRings/r/cryptoKeys/other")
+	require.NoError(t, err)
+	_, err = other.Decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestDecryptInvalidData(t *testing.T) {
+	d, err := NewDecrypter(&fakeKMSClient{}, testKeyName)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		encryptedText string
+	}{
+		{
+			name:          "not base64",
+			encryptedText: "!!!",
+		},
+		{
+			name:          "too short",
+			encryptedText: base64.StdEncoding.EncodeToString([]byte{0}),
+		},
+		{
+			name:          "invalid key length",
+			encryptedText: base64.StdEncoding.EncodeToString([]byte{0, 10, 1}),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := d.Decrypt(tc.encryptedText)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewEmptyKeyName(t *testing.T) {
+	_, err := NewEncrypter(&fakeKMSClient{}, "")
+	assert.Error(t, err)
+	_, err = NewDecrypter(&fakeKMSClient{}, "")
+	assert.Error(t, err)
+}
@@ -88,16 +88,25 @@ func (e *HybridEncrypter) Encrypt(text string) (string, error) {
 }
 
 type HybridDecrypter struct {
-	key *rsa.PrivateKey
+	// The current key comes first and the previous ones follow.
+	keys []*rsa.PrivateKey
 }
 
-func NewHybridDecrypter(keyFile string) (*HybridDecrypter, error) {
-	key, err := LoadRSAPrivateKey(keyFile)
-	if err != nil {
-		return nil, err
+// NewHybridDecrypter returns a decrypter using the private key in keyFile.
+// The keys in previousKeyFiles are also tried in order when the data was not
+// encrypted by the current one, so that the secrets encrypted before a key rotation
+// can still be decrypted until they are re-encrypted.
+func NewHybridDecrypter(keyFile string, previousKeyFiles ...string) (*HybridDecrypter, error) {
+	keys := make([]*rsa.PrivateKey, 0, len(previousKeyFiles)+1)
+	for _, f := range append([]string{keyFile}, previousKeyFiles...) {
+		key, err := LoadRSAPrivateKey(f)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
 	}
 	return &HybridDecrypter{
-		key: key,
+		keys: keys,
 	}, nil
 }
 
@@ -122,7 +131,13 @@ func (d *HybridDecrypter) Decrypt(encryptedText string) (string, error) {
 	rsaCiphertext := ciphertext[2 : rsaLen+2]
 	aesCiphertext := ciphertext[rsaLen+2:]
 
-	symKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, d.key, rsaCiphertext, nil)
+	var symKey []byte
+	for _, key := range d.keys {
+		symKey, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, key, rsaCiphertext, nil)
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return "", err
 	}
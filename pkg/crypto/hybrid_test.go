@@ -16,6 +16,8 @@ package crypto
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -52,3 +54,48 @@ data:
 	require.NoError(t, err)
 	assert.Equal(t, text, decryptedText)
 }
+
+func TestHybridDecryptWithPreviousKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hybrid")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	newPrivate, newPublic, err := GenerateRSAPems(DefauleRSAKeySize)
+	require.NoError(t, err)
+	newPrivateFile := filepath.Join(dir, "new-private-rsa-pem")
+	require.NoError(t, ioutil.WriteFile(newPrivateFile, newPrivate, 0600))
+
+	oldPublic, err := ioutil.ReadFile("testdata/public-rsa-pem")
+	require.NoError(t, err)
+
+	encrypt := func(key []byte, text string) string {
+		encrypter, err := NewHybridEncrypter(string(key))
+		require.NoError(t, err)
+		encryptedText, err := encrypter.Encrypt(text)
+		require.NoError(t, err)
+		return encryptedText
+	}
+	encryptedByNew := encrypt(newPublic, "new secret")
+	encryptedByOld := encrypt(oldPublic, "old secret")
+
+	// Only the current key.
+	decrypter, err := NewHybridDecrypter(newPrivateFile)
+	require.NoError(t, err)
+	_, err = decrypter.Decrypt(encryptedByOld)
+	assert.Error(t, err)
+
+	// With the previous key.
+	decrypter, err = NewHybridDecrypter(newPrivateFile, "testdata/private-rsa-pem")
+	require.NoError(t, err)
+
+	decryptedText, err := decrypter.Decrypt(encryptedByNew)
+	require.NoError(t, err)
+	assert.Equal(t, "new secret", decryptedText)
+
+	decryptedText, err = decrypter.Decrypt(encryptedByOld)
+	require.NoError(t, err)
+	assert.Equal(t, "old secret", decryptedText)
+
+	_, err = NewHybridDecrypter(newPrivateFile, "testdata/not-found")
+	assert.Error(t, err)
+}
@@ -18,7 +18,9 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
@@ -125,3 +127,16 @@ func ParseRSAPrivateKeyFromPem(data []byte) (*rsa.PrivateKey, error) {
 	}
 	return nil, fmt.Errorf("invalid key format, it must be a private RSA key")
 }
+
+// RSAPublicKeyFingerprint returns the SHA256 fingerprint of the PKIX, ASN.1 DER form
+// of the given public key, e.g. "SHA256:XXXX". Note that this differs from the one
+// printed by ssh-keygen which hashes the key in the OpenSSH wire format.
+// This is useful to tell which key is active without exposing the whole key.
+func RSAPublicKeyFingerprint(key *rsa.PublicKey) (string, error) {
+	data, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
@@ -55,3 +55,27 @@ func TestLoadRSAKey(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, publicKey)
 }
+
+func TestRSAPublicKeyFingerprint(t *testing.T) {
+	publicKey, err := LoadRSAPublicKey("testdata/public-rsa-pem")
+	require.NoError(t, err)
+
+	fingerprint, err := RSAPublicKeyFingerprint(publicKey)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(fingerprint, "SHA256:"))
+
+	// The fingerprint is stable for the same key.
+	privateKey, err := LoadRSAPrivateKey("testdata/private-rsa-pem")
+	require.NoError(t, err)
+	got, err := RSAPublicKeyFingerprint(&privateKey.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, got)
+
+	_, public, err := GenerateRSAPems(DefauleRSAKeySize)
+	require.NoError(t, err)
+	otherKey, err := ParseRSAPublicKeyFromPem(public)
+	require.NoError(t, err)
+	got, err = RSAPublicKeyFingerprint(otherKey)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, got)
+}
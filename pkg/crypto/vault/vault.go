@@ -0,0 +1,179 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vault provides a decrypter for the sealed secrets
+// those were encrypted by the transit secrets engine of HashiCorp Vault.
+// The sealed secret is the ciphertext returned by the encrypt endpoint
+// as it is, e.g. "vault:v1:XXXX".
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultTransitMount = "transit"
+)
+
+// Decrypter decrypts the ciphertext by the decrypt endpoint of the transit engine.
+type Decrypter struct {
+	client       *http.Client
+	address      string
+	keyName      string
+	transitMount string
+	namespace    string
+	tokenFile    string
+	timeout      time.Duration
+}
+
+type Option func(*Decrypter)
+
+// WithTransitMount sets the path where the transit engine is mounted.
+func WithTransitMount(mount string) Option {
+	return func(d *Decrypter) {
+		if mount != "" {
+			d.transitMount = strings.Trim(mount, "/")
+		}
+	}
+}
+
+// WithNamespace sets the Vault Enterprise namespace.
+func WithNamespace(namespace string) Option {
+	return func(d *Decrypter) {
+		d.namespace = namespace
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to call Vault API.
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Decrypter) {
+		d.client = client
+	}
+}
+
+// NewDecrypter returns a decrypter using the given transit key.
+// The token is read from tokenFile at every decryption
+// so that the one renewed by e.g. Vault Agent can be used.
+func NewDecrypter(address, keyName, tokenFile string, opts ...Option) (*Decrypter, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	if keyName == "" {
+		return nil, fmt.Errorf("keyName is required")
+	}
+	if tokenFile == "" {
+		return nil, fmt.Errorf("tokenFile is required")
+	}
+	d := &Decrypter{
+		client:       &http.Client{},
+		address:      strings.TrimRight(address, "/"),
+		keyName:      keyName,
+		transitMount: defaultTransitMount,
+		tokenFile:    tokenFile,
+		timeout:      defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d, nil
+}
+
+// NewHTTPClient returns an HTTP client trusting the CA certificates in the given file
+// in addition to the system ones.
+func NewHTTPClient(caFile string) (*http.Client, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the ca file: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate was found in the ca file")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: transport}, nil
+}
+
+// Decrypt decrypts the given transit ciphertext.
+func (d *Decrypter) Decrypt(encryptedText string) (string, error) {
+	if !strings.HasPrefix(encryptedText, "vault:") {
+		return "", fmt.Errorf("the encrypted text is not a vault transit ciphertext")
+	}
+	token, err := ioutil.ReadFile(d.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the vault token file: %w", err)
+	}
+	body, err := json.Marshal(map[string]string{"ciphertext": encryptedText})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/v1/%s/decrypt/%s", d.address, d.transitMount, d.keyName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", strings.TrimSpace(string(token)))
+	if d.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", d.namespace)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt by vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response from vault: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP status code from vault: %d, body: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var out struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return "", fmt.Errorf("failed to decode response from vault: %w", err)
+	}
+	// The transit engine always handles the plaintext in base64.
+	plaintext, err := base64.StdEncoding.DecodeString(out.Data.Plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the decrypted text: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecrypt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secrets-transit/decrypt/piped" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != "token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		var in struct {
+			Ciphertext string `json:"ciphertext"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		if in.Ciphertext != "vault:v1:ciphertext" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid ciphertext"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"plaintext":"` + base64.StdEncoding.EncodeToString([]byte("plaintext")) + `"}}`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "vault")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("token\n"), 0600))
+
+	d, err := NewDecrypter(server.URL+"/", "piped", tokenFile, WithTransitMount("/secrets-transit/"), WithNamespace("team"))
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		encryptedText string
+		expected      string
+		wantErr       bool
+	}{
+		{
+			name:          "ok",
+			encryptedText: "vault:v1:ciphertext",
+			expected:      "plaintext",
+		},
+		{
+			name:          "not a transit ciphertext",
+			encryptedText: "ciphertext",
+			wantErr:       true,
+		},
+		{
+			name:          "invalid ciphertext",
+			encryptedText: "vault:v1:other",
+			wantErr:       true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := d.Decrypt(tc.encryptedText)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestDecryptWithRotatedToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "new-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"plaintext":"` + base64.StdEncoding.EncodeToString([]byte("plaintext")) + `"}}`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "vault")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("old-token"), 0600))
+
+	d, err := NewDecrypter(server.URL, "piped", tokenFile)
+	require.NoError(t, err)
+
+	_, err = d.Decrypt("vault:v1:ciphertext")
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("new-token"), 0600))
+	got, err := d.Decrypt("vault:v1:ciphertext")
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext", got)
+}
@@ -31,7 +31,7 @@ var applicationFactory = func() interface{} {
 type ApplicationStore interface {
 	AddApplication(ctx context.Context, app *model.Application) error
 	EnableApplication(ctx context.Context, id string) error
-	DisableApplication(ctx context.Context, id string) error
+	DisableApplication(ctx context.Context, id, reason string) error
 	DeleteApplication(ctx context.Context, id string) error
 	GetApplication(ctx context.Context, id string) (*model.Application, error)
 	ListApplications(ctx context.Context, opts ListOptions) ([]*model.Application, string, error)
@@ -75,18 +75,20 @@ func (s *applicationStore) EnableApplication(ctx context.Context, id string) err
 			return errors.New("unable to enable a deleted application")
 		}
 		app.Disabled = false
+		app.DisabledReason = ""
 		app.UpdatedAt = s.nowFunc().Unix()
 		return nil
 	})
 }
 
-func (s *applicationStore) DisableApplication(ctx context.Context, id string) error {
+func (s *applicationStore) DisableApplication(ctx context.Context, id, reason string) error {
 	return s.ds.Update(ctx, ApplicationModelKind, id, applicationFactory, func(e interface{}) error {
 		app := e.(*model.Application)
 		if app.Deleted {
 			return errors.New("unable to disable a deleted application")
 		}
 		app.Disabled = true
+		app.DisabledReason = reason
 		app.UpdatedAt = s.nowFunc().Unix()
 		return nil
 	})
@@ -176,3 +176,28 @@ func TestListApplications(t *testing.T) {
 		})
 	}
 }
+
+func TestDisableAndEnableApplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := &model.Application{Id: "id"}
+	ds := NewMockDataStore(ctrl)
+	ds.EXPECT().
+		Update(gomock.Any(), "Application", "id", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _ string, _ func() interface{}, updater func(interface{}) error) error {
+			return updater(app)
+		}).
+		Times(2)
+
+	s := NewApplicationStore(ds)
+	err := s.DisableApplication(context.Background(), "id", "incident")
+	assert.NoError(t, err)
+	assert.True(t, app.Disabled)
+	assert.Equal(t, "incident", app.DisabledReason)
+
+	err = s.EnableApplication(context.Background(), "id")
+	assert.NoError(t, err)
+	assert.False(t, app.Disabled)
+	assert.Equal(t, "", app.DisabledReason)
+}
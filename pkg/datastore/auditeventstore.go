@@ -0,0 +1,83 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const AuditEventModelKind = "AuditEvent"
+
+var (
+	auditEventFactory = func() interface{} {
+		return &model.AuditEvent{}
+	}
+)
+
+type AuditEventStore interface {
+	AddAuditEvent(ctx context.Context, e *model.AuditEvent) error
+	ListAuditEvents(ctx context.Context, opts ListOptions) ([]*model.AuditEvent, error)
+}
+
+type auditEventStore struct {
+	backend
+	nowFunc func() time.Time
+}
+
+func NewAuditEventStore(ds DataStore) AuditEventStore {
+	return &auditEventStore{
+		backend: backend{
+			ds: ds,
+		},
+		nowFunc: time.Now,
+	}
+}
+
+func (s *auditEventStore) AddAuditEvent(ctx context.Context, e *model.AuditEvent) error {
+	now := s.nowFunc().Unix()
+	if e.CreatedAt == 0 {
+		e.CreatedAt = now
+	}
+	if e.UpdatedAt == 0 {
+		e.UpdatedAt = now
+	}
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	return s.ds.Create(ctx, AuditEventModelKind, e.Id, e)
+}
+
+func (s *auditEventStore) ListAuditEvents(ctx context.Context, opts ListOptions) ([]*model.AuditEvent, error) {
+	it, err := s.ds.Find(ctx, AuditEventModelKind, opts)
+	if err != nil {
+		return nil, err
+	}
+	es := make([]*model.AuditEvent, 0)
+	for {
+		var e model.AuditEvent
+		err := it.Next(&e)
+		if err == ErrIteratorDone {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, &e)
+	}
+	return es, nil
+}
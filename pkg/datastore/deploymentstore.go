@@ -93,6 +93,7 @@ type DeploymentStore interface {
 	UpdateDeployment(ctx context.Context, id string, updater func(*model.Deployment) error) error
 	PutDeploymentMetadata(ctx context.Context, id string, metadata map[string]string) error
 	PutDeploymentStageMetadata(ctx context.Context, deploymentID, stageID string, metadata map[string]string) error
+	AddDeploymentNote(ctx context.Context, id string, note *model.DeploymentNote) error
 	ListDeployments(ctx context.Context, opts ListOptions) ([]*model.Deployment, string, error)
 	GetDeployment(ctx context.Context, id string) (*model.Deployment, error)
 }
@@ -162,6 +163,22 @@ func (s *deploymentStore) PutDeploymentStageMetadata(ctx context.Context, deploy
 	})
 }
 
+func (s *deploymentStore) AddDeploymentNote(ctx context.Context, id string, note *model.DeploymentNote) error {
+	now := s.nowFunc().Unix()
+	if note.CreatedAt == 0 {
+		note.CreatedAt = now
+	}
+	if err := note.Validate(); err != nil {
+		return err
+	}
+	return s.ds.Update(ctx, DeploymentModelKind, id, deploymentFactory, func(e interface{}) error {
+		d := e.(*model.Deployment)
+		d.Notes = append(d.Notes, note)
+		d.UpdatedAt = now
+		return nil
+	})
+}
+
 func (s *deploymentStore) ListDeployments(ctx context.Context, opts ListOptions) ([]*model.Deployment, string, error) {
 	it, err := s.ds.Find(ctx, DeploymentModelKind, opts)
 	if err != nil {
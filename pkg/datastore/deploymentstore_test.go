@@ -459,3 +459,56 @@ func TestListDeployments(t *testing.T) {
 		})
 	}
 }
+
+func TestAddDeploymentNote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := []struct {
+		name     string
+		note     *model.DeploymentNote
+		stored   *model.Deployment
+		expected []*model.DeploymentNote
+		wantErr  bool
+	}{
+		{
+			name:    "empty text",
+			note:    &model.DeploymentNote{},
+			wantErr: true,
+		},
+		{
+			name: "valid note",
+			note: &model.DeploymentNote{Text: "CHG-123", Commander: "key-id"},
+			stored: &model.Deployment{
+				Notes: []*model.DeploymentNote{{Text: "INC-1", CreatedAt: 1}},
+			},
+			expected: []*model.DeploymentNote{
+				{Text: "INC-1", CreatedAt: 1},
+				{Text: "CHG-123", Commander: "key-id", CreatedAt: 2},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds := NewMockDataStore(ctrl)
+			if tc.stored != nil {
+				ds.EXPECT().
+					Update(gomock.Any(), "Deployment", "id", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, _ Factory, updater Updater) error {
+						return updater(tc.stored)
+					})
+			}
+			s := &deploymentStore{
+				backend: backend{ds: ds},
+				nowFunc: func() time.Time { return time.Unix(2, 0) },
+			}
+			err := s.AddDeploymentNote(context.Background(), "id", tc.note)
+			require.Equal(t, tc.wantErr, err != nil)
+			if tc.stored != nil {
+				assert.Equal(t, tc.expected, tc.stored.Notes)
+			}
+		})
+	}
+}
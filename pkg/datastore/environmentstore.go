@@ -31,6 +31,10 @@ type EnvironmentStore interface {
 	AddEnvironment(ctx context.Context, env *model.Environment) error
 	GetEnvironment(ctx context.Context, id string) (*model.Environment, error)
 	ListEnvironments(ctx context.Context, opts ListOptions) ([]*model.Environment, error)
+	UpdateEnvironmentDesc(ctx context.Context, id, desc string) error
+	UpdateEnvironmentOrder(ctx context.Context, id string, order int32) error
+	EnableEnvironmentProtection(ctx context.Context, id string) error
+	DisableEnvironmentProtection(ctx context.Context, id string) error
 }
 
 type environmentStore struct {
@@ -88,3 +92,47 @@ func (s *environmentStore) ListEnvironments(ctx context.Context, opts ListOption
 	}
 	return envs, nil
 }
+
+func (s *environmentStore) updateEnvironment(ctx context.Context, id string, updater func(*model.Environment) error) error {
+	now := s.nowFunc().Unix()
+	return s.ds.Update(ctx, EnvironmentModelKind, id, environmentFactory, func(e interface{}) error {
+		env := e.(*model.Environment)
+		if err := updater(env); err != nil {
+			return err
+		}
+		env.UpdatedAt = now
+		return env.Validate()
+	})
+}
+
+// UpdateEnvironmentDesc updates the description of the given environment.
+func (s *environmentStore) UpdateEnvironmentDesc(ctx context.Context, id, desc string) error {
+	return s.updateEnvironment(ctx, id, func(env *model.Environment) error {
+		env.Desc = desc
+		return nil
+	})
+}
+
+// UpdateEnvironmentOrder updates the display order of the given environment.
+func (s *environmentStore) UpdateEnvironmentOrder(ctx context.Context, id string, order int32) error {
+	return s.updateEnvironment(ctx, id, func(env *model.Environment) error {
+		env.Order = order
+		return nil
+	})
+}
+
+// EnableEnvironmentProtection marks the given environment as protected.
+func (s *environmentStore) EnableEnvironmentProtection(ctx context.Context, id string) error {
+	return s.updateEnvironment(ctx, id, func(env *model.Environment) error {
+		env.Protected = true
+		return nil
+	})
+}
+
+// DisableEnvironmentProtection marks the given environment as unprotected.
+func (s *environmentStore) DisableEnvironmentProtection(ctx context.Context, id string) error {
+	return s.updateEnvironment(ctx, id, func(env *model.Environment) error {
+		env.Protected = false
+		return nil
+	})
+}
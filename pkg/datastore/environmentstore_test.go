@@ -168,3 +168,40 @@ func TestListEnvironment(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateEnvironment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	env := &model.Environment{
+		Id:        "id",
+		Name:      "prod",
+		ProjectId: "project-id",
+		CreatedAt: 1,
+		UpdatedAt: 1,
+	}
+	ds := NewMockDataStore(ctrl)
+	ds.EXPECT().
+		Update(gomock.Any(), "Environment", "id", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _ string, _ func() interface{}, updater func(interface{}) error) error {
+			return updater(env)
+		}).
+		Times(4)
+
+	s := NewEnvironmentStore(ds)
+	err := s.UpdateEnvironmentDesc(context.Background(), "id", "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "production", env.Desc)
+
+	err = s.UpdateEnvironmentOrder(context.Background(), "id", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), env.Order)
+
+	err = s.EnableEnvironmentProtection(context.Background(), "id")
+	assert.NoError(t, err)
+	assert.True(t, env.Protected)
+
+	err = s.DisableEnvironmentProtection(context.Background(), "id")
+	assert.NoError(t, err)
+	assert.False(t, env.Protected)
+}
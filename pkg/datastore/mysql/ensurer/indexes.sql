@@ -82,3 +82,17 @@ CREATE INDEX event_project_id_created_at_asc ON Event (ProjectId, CreatedAt);
 -- index on `EventKey` ASC, `Name` ASC, `ProjectId` ASC and `CreatedAt` DESC
 ALTER TABLE Event ADD COLUMN EventKey VARCHAR(64) GENERATED ALWAYS AS (data->>"$.event_key") VIRTUAL NOT NULL, ADD COLUMN Name VARCHAR(50) GENERATED ALWAYS AS (data->>"$.name") VIRTUAL NOT NULL;
 CREATE INDEX event_key_name_project_id_created_at_desc ON Event (EventKey, Name, ProjectId, CreatedAt DESC);
+
+--
+-- Session table indexes
+--
+
+-- index on `ProjectId` ASC and `Username` ASC
+CREATE INDEX session_project_id_username ON Session (ProjectId, Username);
+
+--
+-- AuditEvent table indexes
+--
+
+-- index on `ProjectId` ASC and `CreatedAt` DESC
+CREATE INDEX audit_event_project_id_created_at_desc ON AuditEvent (ProjectId, CreatedAt DESC);
@@ -103,3 +103,31 @@ CREATE TABLE IF NOT EXISTS Event (
   CreatedAt INT(11) GENERATED ALWAYS AS (data->>"$.created_at") STORED NOT NULL,
   UpdatedAt INT(11) GENERATED ALWAYS AS (data->>"$.updated_at") STORED NOT NULL
 ) ENGINE=InnoDB;
+
+--
+-- Session table
+--
+
+CREATE TABLE IF NOT EXISTS Session (
+  Id BINARY(16) PRIMARY KEY,
+  Data JSON NOT NULL,
+  ProjectId VARCHAR(50) GENERATED ALWAYS AS (data->>"$.project_id") STORED NOT NULL,
+  Username VARCHAR(100) GENERATED ALWAYS AS (data->>"$.username") STORED NOT NULL,
+  Revoked BOOL GENERATED ALWAYS AS (IF(data->>"$.revoked" = 'true', True, False)) STORED NOT NULL,
+  Extra VARCHAR(100) GENERATED ALWAYS AS (data->>"$._extra") STORED,
+  CreatedAt INT(11) GENERATED ALWAYS AS (data->>"$.created_at") STORED NOT NULL,
+  UpdatedAt INT(11) GENERATED ALWAYS AS (data->>"$.updated_at") STORED NOT NULL
+) ENGINE=InnoDB;
+
+--
+-- AuditEvent table
+--
+
+CREATE TABLE IF NOT EXISTS AuditEvent (
+  Id BINARY(16) PRIMARY KEY,
+  Data JSON NOT NULL,
+  ProjectId VARCHAR(50) GENERATED ALWAYS AS (data->>"$.project_id") STORED NOT NULL,
+  Extra VARCHAR(100) GENERATED ALWAYS AS (data->>"$._extra") STORED,
+  CreatedAt INT(11) GENERATED ALWAYS AS (data->>"$.created_at") STORED NOT NULL,
+  UpdatedAt INT(11) GENERATED ALWAYS AS (data->>"$.updated_at") STORED NOT NULL
+) ENGINE=InnoDB;
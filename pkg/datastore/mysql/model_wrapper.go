@@ -92,6 +92,22 @@ func wrapModel(entity interface{}) (interface{}, error) {
 			Event: *e,
 			Extra: e.Name,
 		}, nil
+	case *model.Session:
+		if e == nil {
+			return nil, fmt.Errorf("nil entity given")
+		}
+		return &session{
+			Session: *e,
+			Extra:   e.Username,
+		}, nil
+	case *model.AuditEvent:
+		if e == nil {
+			return nil, fmt.Errorf("nil entity given")
+		}
+		return &auditEvent{
+			AuditEvent: *e,
+			Extra:      e.Actor,
+		}, nil
 	default:
 		return nil, fmt.Errorf("%T is not supported", e)
 	}
@@ -152,3 +168,13 @@ type event struct {
 	model.Event `json:",inline"`
 	Extra       string `json:"_extra"`
 }
+
+type session struct {
+	model.Session `json:",inline"`
+	Extra         string `json:"_extra"`
+}
+
+type auditEvent struct {
+	model.AuditEvent `json:",inline"`
+	Extra            string `json:"_extra"`
+}
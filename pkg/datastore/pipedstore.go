@@ -32,6 +32,7 @@ var (
 		repos []*model.ApplicationGitRepository,
 		status model.Piped_ConnectionStatus,
 		sse *model.Piped_SealedSecretEncryption,
+		componentStatuses []*model.Piped_ComponentStatus,
 		version string,
 		startedAt int64,
 	) func(piped *model.Piped) error {
@@ -43,11 +44,18 @@ var (
 			if sse != nil {
 				piped.SealedSecretEncryption = sse
 			}
+			piped.ComponentStatuses = componentStatuses
 			piped.Version = version
 			piped.StartedAt = startedAt
 			return nil
 		}
 	}
+	PipedComponentStatusesUpdater = func(componentStatuses []*model.Piped_ComponentStatus) func(piped *model.Piped) error {
+		return func(piped *model.Piped) error {
+			piped.ComponentStatuses = componentStatuses
+			return nil
+		}
+	}
 )
 
 type PipedStore interface {
@@ -57,7 +65,7 @@ type PipedStore interface {
 	UpdatePiped(ctx context.Context, id string, updater func(piped *model.Piped) error) error
 	EnablePiped(ctx context.Context, id string) error
 	DisablePiped(ctx context.Context, id string) error
-	AddKey(ctx context.Context, id, keyHash, creator string, createdAt time.Time) error
+	AddKey(ctx context.Context, id, keyHash, creator string, createdAt time.Time, scope *model.PipedKeyScope) error
 	DeleteOldKeys(ctx context.Context, id string) error
 }
 
@@ -145,10 +153,10 @@ func (s *pipedStore) DisablePiped(ctx context.Context, id string) error {
 	})
 }
 
-func (s *pipedStore) AddKey(ctx context.Context, id, keyHash, creator string, createdAt time.Time) error {
+func (s *pipedStore) AddKey(ctx context.Context, id, keyHash, creator string, createdAt time.Time, scope *model.PipedKeyScope) error {
 	return s.UpdatePiped(ctx, id, func(piped *model.Piped) error {
 		piped.UpdatedAt = time.Now().Unix()
-		return piped.AddKey(keyHash, creator, createdAt)
+		return piped.AddKey(keyHash, creator, createdAt, scope)
 	})
 }
 
@@ -33,6 +33,8 @@ type ProjectStore interface {
 	UpdateProjectStaticAdmin(ctx context.Context, id, username, password string) error
 	EnableStaticAdmin(ctx context.Context, id string) error
 	DisableStaticAdmin(ctx context.Context, id string) error
+	EnablePodDebugging(ctx context.Context, id string) error
+	DisablePodDebugging(ctx context.Context, id string) error
 	UpdateProjectSSOConfig(ctx context.Context, id string, sso *model.ProjectSSOConfig) error
 	UpdateProjectRBACConfig(ctx context.Context, id string, sso *model.ProjectRBACConfig) error
 	GetProject(ctx context.Context, id string) (*model.Project, error)
@@ -105,6 +107,22 @@ func (s *projectStore) DisableStaticAdmin(ctx context.Context, id string) error
 	})
 }
 
+// EnablePodDebugging enables the debugging of live pods from the web console.
+func (s *projectStore) EnablePodDebugging(ctx context.Context, id string) error {
+	return s.UpdateProject(ctx, id, func(p *model.Project) error {
+		p.PodDebuggingEnabled = true
+		return nil
+	})
+}
+
+// DisablePodDebugging disables the debugging of live pods from the web console.
+func (s *projectStore) DisablePodDebugging(ctx context.Context, id string) error {
+	return s.UpdateProject(ctx, id, func(p *model.Project) error {
+		p.PodDebuggingEnabled = false
+		return nil
+	})
+}
+
 // UpdateProjectSSOConfig updates project single sign on settings.
 func (s *projectStore) UpdateProjectSSOConfig(ctx context.Context, id string, sso *model.ProjectSSOConfig) error {
 	return s.UpdateProject(ctx, id, func(p *model.Project) error {
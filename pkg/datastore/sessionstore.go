@@ -0,0 +1,157 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+const SessionModelKind = "Session"
+
+var (
+	sessionFactory = func() interface{} {
+		return &model.Session{}
+	}
+
+	// ErrSessionRefreshTokenReused is returned when the refresh token of a session
+	// was already rotated by another request. The session is revoked in that case.
+	ErrSessionRefreshTokenReused = errors.New("refresh token was already used")
+)
+
+type SessionStore interface {
+	AddSession(ctx context.Context, s *model.Session) error
+	GetSession(ctx context.Context, id string) (*model.Session, error)
+	ListSessions(ctx context.Context, opts ListOptions) ([]*model.Session, error)
+	RefreshSession(ctx context.Context, id, currentRefreshTokenHash, newRefreshTokenHash string) error
+	RevokeSession(ctx context.Context, id, projectID, revokedBy string) error
+}
+
+type sessionStore struct {
+	backend
+	nowFunc func() time.Time
+}
+
+func NewSessionStore(ds DataStore) SessionStore {
+	return &sessionStore{
+		backend: backend{
+			ds: ds,
+		},
+		nowFunc: time.Now,
+	}
+}
+
+func (s *sessionStore) AddSession(ctx context.Context, session *model.Session) error {
+	now := s.nowFunc().Unix()
+	if session.CreatedAt == 0 {
+		session.CreatedAt = now
+	}
+	if session.UpdatedAt == 0 {
+		session.UpdatedAt = now
+	}
+	if session.LastActiveAt == 0 {
+		session.LastActiveAt = now
+	}
+	if err := session.Validate(); err != nil {
+		return err
+	}
+	return s.ds.Create(ctx, SessionModelKind, session.Id, session)
+}
+
+func (s *sessionStore) GetSession(ctx context.Context, id string) (*model.Session, error) {
+	var entity model.Session
+	if err := s.ds.Get(ctx, SessionModelKind, id, &entity); err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (s *sessionStore) ListSessions(ctx context.Context, opts ListOptions) ([]*model.Session, error) {
+	it, err := s.ds.Find(ctx, SessionModelKind, opts)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*model.Session, 0)
+	for {
+		var session model.Session
+		err := it.Next(&session)
+		if err == ErrIteratorDone {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
+
+// RefreshSession rotates the refresh token of the given session
+// and marks the session as active at the current time.
+// The rotation is done only when the stored refresh token hash is still
+// the given current one. Otherwise the token was already used by another request,
+// so the session is revoked and ErrSessionRefreshTokenReused is returned.
+func (s *sessionStore) RefreshSession(ctx context.Context, id, currentRefreshTokenHash, newRefreshTokenHash string) error {
+	var (
+		now    = s.nowFunc().Unix()
+		reused bool
+	)
+	err := s.ds.Update(ctx, SessionModelKind, id, sessionFactory, func(e interface{}) error {
+		session := e.(*model.Session)
+		if session.Revoked {
+			return fmt.Errorf("session %s was already revoked", id)
+		}
+
+		// The update function may be called multiple times when the transaction is retried.
+		reused = session.RefreshTokenHash != currentRefreshTokenHash
+		if reused {
+			session.Revoked = true
+			session.RevokedBy = model.SessionRevokedByReuseDetection
+			session.UpdatedAt = now
+			return session.Validate()
+		}
+
+		session.RefreshTokenHash = newRefreshTokenHash
+		session.LastActiveAt = now
+		session.UpdatedAt = now
+		return session.Validate()
+	})
+	if err != nil {
+		return err
+	}
+	if reused {
+		return ErrSessionRefreshTokenReused
+	}
+	return nil
+}
+
+func (s *sessionStore) RevokeSession(ctx context.Context, id, projectID, revokedBy string) error {
+	now := s.nowFunc().Unix()
+	return s.ds.Update(ctx, SessionModelKind, id, sessionFactory, func(e interface{}) error {
+		session := e.(*model.Session)
+		if session.ProjectId != projectID {
+			return fmt.Errorf("invalid project id, expected %s, got %s", session.ProjectId, projectID)
+		}
+
+		session.Revoked = true
+		session.RevokedBy = revokedBy
+		session.UpdatedAt = now
+		return session.Validate()
+	})
+}
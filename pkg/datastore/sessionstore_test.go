@@ -0,0 +1,233 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestAddSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := []struct {
+		name      string
+		session   *model.Session
+		dsFactory func(*model.Session) DataStore
+		wantErr   bool
+	}{
+		{
+			name:      "Invalid session",
+			session:   &model.Session{},
+			dsFactory: func(d *model.Session) DataStore { return nil },
+			wantErr:   true,
+		},
+		{
+			name: "Valid session",
+			session: &model.Session{
+				Id:        "id",
+				ProjectId: "project-id",
+				Username:  "user",
+				Role: &model.Role{
+					ProjectId:   "project-id",
+					ProjectRole: model.Role_VIEWER,
+				},
+				RefreshTokenHash: "hash",
+				ExpiresAt:        1,
+				CreatedAt:        1,
+				UpdatedAt:        1,
+			},
+			dsFactory: func(d *model.Session) DataStore {
+				ds := NewMockDataStore(ctrl)
+				ds.EXPECT().Create(gomock.Any(), "Session", d.Id, d)
+				return ds
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSessionStore(tc.dsFactory(tc.session))
+			err := s.AddSession(context.Background(), tc.session)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestGetSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := []struct {
+		name    string
+		id      string
+		ds      DataStore
+		wantErr bool
+	}{
+		{
+			name: "successful fetch from datastore",
+			id:   "id",
+			ds: func() DataStore {
+				ds := NewMockDataStore(ctrl)
+				ds.EXPECT().
+					Get(gomock.Any(), "Session", "id", &model.Session{}).
+					Return(nil)
+				return ds
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "failed fetch from datastore",
+			id:   "id",
+			ds: func() DataStore {
+				ds := NewMockDataStore(ctrl)
+				ds.EXPECT().
+					Get(gomock.Any(), "Session", "id", &model.Session{}).
+					Return(errors.New("err"))
+				return ds
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSessionStore(tc.ds)
+			_, err := s.GetSession(context.Background(), tc.id)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := []struct {
+		name    string
+		opts    ListOptions
+		ds      DataStore
+		wantErr error
+	}{
+		{
+			name: "iterator done",
+			opts: ListOptions{},
+			ds: func() DataStore {
+				it := NewMockIterator(ctrl)
+				it.EXPECT().
+					Next(&model.Session{}).
+					Return(ErrIteratorDone)
+
+				ds := NewMockDataStore(ctrl)
+				ds.EXPECT().
+					Find(gomock.Any(), "Session", ListOptions{}).
+					Return(it, nil)
+				return ds
+			}(),
+			wantErr: nil,
+		},
+		{
+			name: "unexpected error occurred",
+			opts: ListOptions{},
+			ds: func() DataStore {
+				it := NewMockIterator(ctrl)
+				it.EXPECT().
+					Next(&model.Session{}).
+					Return(errors.New("test-error"))
+
+				ds := NewMockDataStore(ctrl)
+				ds.EXPECT().
+					Find(gomock.Any(), "Session", ListOptions{}).
+					Return(it, nil)
+				return ds
+			}(),
+			wantErr: errors.New("test-error"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSessionStore(tc.ds)
+			_, err := s.ListSessions(context.Background(), tc.opts)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := []struct {
+		name        string
+		currentHash string
+		wantHash    string
+		wantRevoked bool
+		wantErr     error
+	}{
+		{
+			name:        "rotated",
+			currentHash: "hash",
+			wantHash:    "new-hash",
+			wantErr:     nil,
+		},
+		{
+			name:        "already rotated by another request",
+			currentHash: "old-hash",
+			wantHash:    "hash",
+			wantRevoked: true,
+			wantErr:     ErrSessionRefreshTokenReused,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			session := &model.Session{
+				Id:        "id",
+				ProjectId: "project-id",
+				Username:  "user",
+				Role: &model.Role{
+					ProjectId:   "project-id",
+					ProjectRole: model.Role_VIEWER,
+				},
+				RefreshTokenHash: "hash",
+				ExpiresAt:        1,
+				LastActiveAt:     1,
+				CreatedAt:        1,
+				UpdatedAt:        1,
+			}
+			ds := NewMockDataStore(ctrl)
+			ds.EXPECT().
+				Update(gomock.Any(), "Session", "id", gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, _ string, _ Factory, updater Updater) error {
+					return updater(session)
+				})
+
+			s := NewSessionStore(ds)
+			err := s.RefreshSession(context.Background(), "id", tc.currentHash, "new-hash")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantHash, session.RefreshTokenHash)
+			assert.Equal(t, tc.wantRevoked, session.Revoked)
+		})
+	}
+}
@@ -26,12 +26,14 @@ import (
 	"google.golang.org/api/option"
 
 	"github.com/pipe-cd/pipe/pkg/filestore"
+	"github.com/pipe-cd/pipe/pkg/gcpcredentials"
 )
 
 type Store struct {
 	client          *storage.Client
 	bucket          string
 	credentialsFile string
+	serviceAccount  string
 	httpClient      *http.Client
 	logger          *zap.Logger
 }
@@ -44,6 +46,14 @@ func WithCredentialsFile(path string) Option {
 	}
 }
 
+// WithImpersonateServiceAccount makes the store impersonate the given service account
+// by using the credentials file or the application default credentials.
+func WithImpersonateServiceAccount(email string) Option {
+	return func(s *Store) {
+		s.serviceAccount = email
+	}
+}
+
 func WithHTTPClient(client *http.Client) Option {
 	return func(s *Store) {
 		s.httpClient = client
@@ -65,9 +75,12 @@ func NewStore(ctx context.Context, bucket string, opts ...Option) (*Store, error
 		opt(s)
 	}
 
-	var options []option.ClientOption
-	if s.credentialsFile != "" {
-		options = append(options, option.WithCredentialsFile(s.credentialsFile))
+	options, err := gcpcredentials.ClientOptions(ctx, gcpcredentials.Options{
+		CredentialsFile:           s.credentialsFile,
+		ImpersonateServiceAccount: s.serviceAccount,
+	})
+	if err != nil {
+		return nil, err
 	}
 	if s.httpClient != nil {
 		options = append(options, option.WithHTTPClient(s.httpClient))
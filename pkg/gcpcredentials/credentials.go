@@ -0,0 +1,151 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcpcredentials provides a way to find the credentials
+// for accessing GCP services.
+// In addition to a service account key file, the application default credentials
+// (e.g. the workload identity of the running pod) can be used,
+// and another service account can be impersonated on top of them.
+package gcpcredentials
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	// CloudPlatformScope is the OAuth scope for accessing all GCP services.
+	CloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	impersonatedTokenLifetime = time.Hour
+)
+
+// Options specifies how to find the credentials.
+type Options struct {
+	// The path to the service account key file.
+	// Empty means the application default credentials are used.
+	CredentialsFile string
+	// The email of the service account to impersonate.
+	// The credentials specified above must have the
+	// "roles/iam.serviceAccountTokenCreator" role on that service account.
+	ImpersonateServiceAccount string
+}
+
+// TokenSource returns a token source issuing the access tokens with the given scopes.
+// CloudPlatformScope is used if no scope was given.
+func TokenSource(ctx context.Context, opts Options, scopes ...string) (oauth2.TokenSource, error) {
+	if len(scopes) == 0 {
+		scopes = []string{CloudPlatformScope}
+	}
+
+	base, err := baseTokenSource(ctx, opts.CredentialsFile, scopes)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ImpersonateServiceAccount == "" {
+		return base, nil
+	}
+
+	// Issuing the impersonated token requires the cloud-platform scope.
+	if opts.CredentialsFile != "" {
+		base, err = baseTokenSource(ctx, opts.CredentialsFile, []string{CloudPlatformScope})
+		if err != nil {
+			return nil, err
+		}
+	}
+	service, err := iamcredentials.NewService(ctx, option.WithTokenSource(base))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create iamcredentials service: %w", err)
+	}
+	ts := &impersonatedTokenSource{
+		service:        service,
+		serviceAccount: opts.ImpersonateServiceAccount,
+		scopes:         scopes,
+	}
+	return oauth2.ReuseTokenSource(nil, ts), nil
+}
+
+// ClientOptions returns the client options to be used while creating GCP API clients.
+func ClientOptions(ctx context.Context, opts Options) ([]option.ClientOption, error) {
+	// Keep the default behavior of the clients
+	// when no impersonation is needed.
+	if opts.ImpersonateServiceAccount == "" {
+		if opts.CredentialsFile == "" {
+			return nil, nil
+		}
+		return []option.ClientOption{option.WithCredentialsFile(opts.CredentialsFile)}, nil
+	}
+
+	ts, err := TokenSource(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
+
+func baseTokenSource(ctx context.Context, credentialsFile string, scopes []string) (oauth2.TokenSource, error) {
+	if credentialsFile == "" {
+		ts, err := google.DefaultTokenSource(ctx, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the application default credentials: %w", err)
+		}
+		return ts, nil
+	}
+
+	data, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials file (%w)", err)
+	}
+	creds, err := google.CredentialsFromJSON(ctx, data, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials file (%w)", err)
+	}
+	return creds.TokenSource, nil
+}
+
+type impersonatedTokenSource struct {
+	service        *iamcredentials.Service
+	serviceAccount string
+	scopes         []string
+}
+
+func (s *impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	var (
+		name = fmt.Sprintf("projects/-/serviceAccounts/%s", s.serviceAccount)
+		req  = &iamcredentials.GenerateAccessTokenRequest{
+			Scope:    s.scopes,
+			Lifetime: fmt.Sprintf("%ds", int(impersonatedTokenLifetime.Seconds())),
+		}
+	)
+	resp, err := s.service.Projects.ServiceAccounts.GenerateAccessToken(name, req).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate service account %s: %w", s.serviceAccount, err)
+	}
+	expiry, err := time.Parse(time.RFC3339, resp.ExpireTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expire time of the impersonated token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: resp.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpcredentials
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	iamcredentials "google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+func TestClientOptions(t *testing.T) {
+	ctx := context.Background()
+
+	opts, err := ClientOptions(ctx, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(opts))
+
+	opts, err = ClientOptions(ctx, Options{CredentialsFile: "testdata/service-account.json"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(opts))
+
+	_, err = ClientOptions(ctx, Options{
+		CredentialsFile:           "testdata/not-found.json",
+		ImpersonateServiceAccount: "deployer@project.iam.gserviceaccount.com",
+	})
+	assert.Error(t, err)
+}
+
+func TestImpersonatedTokenSource(t *testing.T) {
+	expiry := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var (
+		path string
+		req  iamcredentials.GenerateAccessTokenRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(iamcredentials.GenerateAccessTokenResponse{
+			AccessToken: "impersonated-token",
+			ExpireTime:  expiry.Format(time.RFC3339),
+		})
+	}))
+	defer srv.Close()
+
+	service, err := iamcredentials.NewService(context.Background(), option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	require.NoError(t, err)
+
+	ts := &impersonatedTokenSource{
+		service:        service,
+		serviceAccount: "deployer@project.iam.gserviceaccount.com",
+		scopes:         []string{CloudPlatformScope},
+	}
+	token, err := ts.Token()
+	require.NoError(t, err)
+
+	assert.Equal(t, "impersonated-token", token.AccessToken)
+	assert.True(t, expiry.Equal(token.Expiry))
+	assert.Equal(t, "/v1/projects/-/serviceAccounts/deployer@project.iam.gserviceaccount.com:generateAccessToken", path)
+	assert.Equal(t, []string{CloudPlatformScope}, req.Scope)
+	assert.Equal(t, "3600s", req.Lifetime)
+}
@@ -26,11 +26,15 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipe/pkg/backoff"
 )
 
 const (
 	defaultUsername = "piped"
 	defaultEmail    = "pipecd.dev@gmail.com"
+	// The maximum time to wait for a command communicating with the remote.
+	commandAttemptTimeout = 10 * time.Minute
 )
 
 // Client is a git client for cloning/fetching git repo.
@@ -107,7 +111,7 @@ func (c *client) Clone(ctx context.Context, repoID, remote, branch, destination
 		if err := os.MkdirAll(filepath.Dir(repoCachePath), os.ModePerm); err != nil && !os.IsExist(err) {
 			return nil, err
 		}
-		out, err := retryCommand(3, time.Second, logger, func() ([]byte, error) {
+		out, err := retryCommand(ctx, 3, time.Second, logger, func(ctx context.Context) ([]byte, error) {
 			return c.runGitCommand(ctx, "", "clone", "--mirror", remote, repoCachePath)
 		})
 		if err != nil {
@@ -120,7 +124,7 @@ func (c *client) Clone(ctx context.Context, repoID, remote, branch, destination
 	} else {
 		// Cache hit. Do a git fetch to keep updated.
 		c.logger.Info(fmt.Sprintf("fetching %s to update the cache", repoID))
-		out, err := retryCommand(3, time.Second, c.logger, func() ([]byte, error) {
+		out, err := retryCommand(ctx, 3, time.Second, c.logger, func(ctx context.Context) ([]byte, error) {
 			return c.runGitCommand(ctx, repoCachePath, "fetch")
 		})
 		if err != nil {
@@ -184,7 +188,7 @@ func (c *client) Clean() error {
 // getLatestRemoteHashForBranch returns the hash of the latest commit of a remote branch.
 func (c *client) getLatestRemoteHashForBranch(ctx context.Context, remote, branch string) (string, error) {
 	ref := "refs/heads/" + branch
-	out, err := retryCommand(3, time.Second, c.logger, func() ([]byte, error) {
+	out, err := retryCommand(ctx, 3, time.Second, c.logger, func(ctx context.Context) ([]byte, error) {
 		return c.runGitCommand(ctx, "", "ls-remote", ref)
 	})
 	if err != nil {
@@ -224,14 +228,39 @@ func (c *client) runGitCommand(ctx context.Context, dir string, args ...string)
 }
 
 // retryCommand retries a command a few times with a constant backoff.
-func retryCommand(retries int, interval time.Duration, logger *zap.Logger, commander func() ([]byte, error)) (out []byte, err error) {
-	for i := 0; i < retries; i++ {
-		out, err = commander()
-		if err == nil {
-			return
+// Each attempt is cancelled if it does not finish within commandAttemptTimeout
+// and the command is not retried if it failed because of the reasons that never change by retrying.
+func retryCommand(ctx context.Context, retries int, interval time.Duration, logger *zap.Logger, commander func(ctx context.Context) ([]byte, error)) (out []byte, err error) {
+	retry := backoff.NewRetry(retries, backoff.NewConstant(interval),
+		backoff.WithAttemptTimeout(commandAttemptTimeout),
+		backoff.WithRetryable(func(_ error) bool {
+			return !isTerminalCommandOutput(out)
+		}),
+	)
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if out, err = commander(ctx); err != nil {
+			logger.Warn(fmt.Sprintf("command was failed %d times, sleep %v before retrying command", retry.Calls(), interval))
 		}
-		logger.Warn(fmt.Sprintf("command was failed %d times, sleep %v before retrying command", i+1, interval))
-		time.Sleep(interval)
-	}
+		return err
+	})
 	return
 }
+
+// terminalCommandOutputs contains the messages of git errors that never succeed by retrying.
+var terminalCommandOutputs = []string{
+	"Authentication failed",
+	"could not read Username",
+	"Permission denied",
+	"Repository not found",
+	"does not appear to be a git repository",
+}
+
+func isTerminalCommandOutput(out []byte) bool {
+	for _, msg := range terminalCommandOutputs {
+		if strings.Contains(string(out), msg) {
+			return true
+		}
+	}
+	return false
+}
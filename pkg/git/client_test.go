@@ -186,35 +186,53 @@ func TestRetryCommand(t *testing.T) {
 	)
 	testcases := []struct {
 		name             string
+		commandOut       []byte
 		commandSuccessAt int
+		expectedRanCount int
 		expectedError    error
 	}{
 		{
 			name:             "success at the first time",
+			commandOut:       commandOut,
 			commandSuccessAt: 1,
+			expectedRanCount: 1,
 			expectedError:    nil,
 		},
 		{
 			name:             "success at the second time",
+			commandOut:       commandOut,
 			commandSuccessAt: 2,
+			expectedRanCount: 2,
 			expectedError:    nil,
 		},
 		{
 			name:             "failure at all",
+			commandOut:       commandOut,
 			commandSuccessAt: 5,
+			expectedRanCount: 3,
+			expectedError:    commandErr,
+		},
+		{
+			name:             "terminal failure is not retried",
+			commandOut:       []byte("remote: Repository not found."),
+			commandSuccessAt: 2,
+			expectedRanCount: 1,
 			expectedError:    commandErr,
 		},
 	}
 	for _, tc := range testcases {
-		ranCount = 0
-		out, err := retryCommand(3, time.Millisecond, logger, func() ([]byte, error) {
-			ranCount++
-			if tc.commandSuccessAt == ranCount {
-				return commandOut, nil
-			}
-			return commandOut, commandErr
+		t.Run(tc.name, func(t *testing.T) {
+			ranCount = 0
+			out, err := retryCommand(context.Background(), 3, time.Millisecond, logger, func(_ context.Context) ([]byte, error) {
+				ranCount++
+				if tc.commandSuccessAt == ranCount {
+					return tc.commandOut, nil
+				}
+				return tc.commandOut, commandErr
+			})
+			assert.Equal(t, tc.commandOut, out)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedRanCount, ranCount)
 		})
-		assert.Equal(t, commandOut, out)
-		assert.Equal(t, tc.expectedError, err)
 	}
 }
@@ -0,0 +1,82 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// e.g. Merge pull request #123 from owner/branch
+	githubMergeRegex = regexp.MustCompile(`^Merge pull request #(\d+) from ([^/\s]+)/\S+$`)
+	// e.g. Add a new feature (#123)
+	githubSquashRegex = regexp.MustCompile(`^(.+) \(#(\d+)\)$`)
+	// e.g. Merge branch 'feature' into 'master'
+	gitlabMergeRegex = regexp.MustCompile(`^Merge branch '.+' into '.+'$`)
+	// e.g. See merge request group/project!123
+	gitlabMergeRequestRegex = regexp.MustCompile(`(?m)^See merge request \S+!(\d+)$`)
+)
+
+// PullRequest represents the pull request (or merge request) merged by a commit.
+type PullRequest struct {
+	Number int
+	Title  string
+	Author string
+}
+
+// PullRequest returns the pull request merged by this commit.
+// It is detected from the commit message generated by the merge
+// or squash button of GitHub and the merge button of GitLab.
+func (c Commit) PullRequest() (PullRequest, bool) {
+	if m := githubMergeRegex.FindStringSubmatch(c.Message); m != nil {
+		number, _ := strconv.Atoi(m[1])
+		return PullRequest{
+			Number: number,
+			Title:  firstLine(c.Body),
+			Author: m[2],
+		}, true
+	}
+	if m := githubSquashRegex.FindStringSubmatch(c.Message); m != nil {
+		number, _ := strconv.Atoi(m[2])
+		return PullRequest{
+			Number: number,
+			Title:  m[1],
+			Author: c.Author,
+		}, true
+	}
+	if gitlabMergeRegex.MatchString(c.Message) {
+		if m := gitlabMergeRequestRegex.FindStringSubmatch(c.Body); m != nil {
+			number, _ := strconv.Atoi(m[1])
+			return PullRequest{
+				Number: number,
+				Title:  firstLine(c.Body),
+				Author: c.Author,
+			}, true
+		}
+	}
+	return PullRequest{}, false
+}
+
+// firstLine returns the first non-empty line of the given text.
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
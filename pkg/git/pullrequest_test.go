@@ -0,0 +1,94 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitPullRequest(t *testing.T) {
+	testcases := []struct {
+		name     string
+		commit   Commit
+		expected PullRequest
+		found    bool
+	}{
+		{
+			name: "github merge commit",
+			commit: Commit{
+				Author:  "merger",
+				Message: "Merge pull request #123 from nghialv/add-feature",
+				Body:    "\nAdd a new feature\n\nSome description",
+			},
+			expected: PullRequest{
+				Number: 123,
+				Title:  "Add a new feature",
+				Author: "nghialv",
+			},
+			found: true,
+		},
+		{
+			name: "github squashed commit",
+			commit: Commit{
+				Author:  "nghialv",
+				Message: "Add a new feature (#123)",
+				Body:    "* Add a new feature\n* Fix test",
+			},
+			expected: PullRequest{
+				Number: 123,
+				Title:  "Add a new feature",
+				Author: "nghialv",
+			},
+			found: true,
+		},
+		{
+			name: "gitlab merge commit",
+			commit: Commit{
+				Author:  "nghialv",
+				Message: "Merge branch 'add-feature' into 'master'",
+				Body:    "Add a new feature\n\nSee merge request pipe-cd/pipe!45",
+			},
+			expected: PullRequest{
+				Number: 45,
+				Title:  "Add a new feature",
+				Author: "nghialv",
+			},
+			found: true,
+		},
+		{
+			name: "gitlab merge commit without merge request",
+			commit: Commit{
+				Author:  "nghialv",
+				Message: "Merge branch 'add-feature' into 'master'",
+			},
+		},
+		{
+			name: "normal commit",
+			commit: Commit{
+				Author:  "nghialv",
+				Message: "Fix issue #123",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr, found := tc.commit.PullRequest()
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.expected, pr)
+		})
+	}
+}
@@ -52,6 +52,35 @@ func MakeCommitURL(repoURL, hash string) (string, error) {
 	return fmt.Sprintf("%s://%s/%s/%s/%s", scheme, u.Host, repoPath, subPath, hash), nil
 }
 
+// MakePullRequestURL builds a link to the HTML page of the pull request, using the given repoURL and number.
+func MakePullRequestURL(repoURL string, number int) (string, error) {
+	u, err := parseGitURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "https"
+	if u.Scheme != "ssh" {
+		scheme = u.Scheme
+	}
+
+	repoPath := strings.Trim(u.Path, "/")
+	repoPath = strings.TrimSuffix(repoPath, ".git")
+
+	subPath := ""
+	switch u.Host {
+	case "gitlab.com":
+		subPath = "-/merge_requests"
+	case "bitbucket.org":
+		subPath = "pull-requests"
+	default:
+		// TODO: Allow users to specify git host
+		subPath = "pull"
+	}
+
+	return fmt.Sprintf("%s://%s/%s/%s/%d", scheme, u.Host, repoPath, subPath, number), nil
+}
+
 // MakeDirURL builds a link to the HTML page of the directory.
 func MakeDirURL(repoURL, dir, branch string) (string, error) {
 	if branch == "" {
@@ -126,6 +155,24 @@ func MakeFileCreationURL(repoURL, dir, branch, filename, value string) (string,
 	return u.String(), nil
 }
 
+// IsSameRepository reports whether the given two remote URLs are pointing to the same repository.
+// The difference of transport schemes, users and the ".git" suffix is ignored.
+func IsSameRepository(a, b string) bool {
+	ua, err := parseGitURL(a)
+	if err != nil {
+		return false
+	}
+	ub, err := parseGitURL(b)
+	if err != nil {
+		return false
+	}
+	normalize := func(u *url.URL) string {
+		path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+		return strings.ToLower(u.Hostname() + "/" + path)
+	}
+	return normalize(ua) == normalize(ub)
+}
+
 var (
 	knownSchemes = map[string]interface{}{
 		"ssh":     struct{}{},
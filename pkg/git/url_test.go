@@ -81,6 +81,55 @@ func TestMakeCommitURL(t *testing.T) {
 	}
 }
 
+func TestMakePullRequestURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoURL string
+		number  int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "ssh to github.com",
+			repoURL: "git@github.com:org/repo.git",
+			number:  12,
+			want:    "https://github.com/org/repo/pull/12",
+		},
+		{
+			name:    "ssh to gitlab.com",
+			repoURL: "git@gitlab.com:org/repo.git",
+			number:  12,
+			want:    "https://gitlab.com/org/repo/-/merge_requests/12",
+		},
+		{
+			name:    "ssh to bitbucket.org",
+			repoURL: "git@bitbucket.org:org/repo.git",
+			number:  12,
+			want:    "https://bitbucket.org/org/repo/pull-requests/12",
+		},
+		{
+			name:    "http to unsupported git host",
+			repoURL: "http://foo.com/org/repo",
+			number:  12,
+			want:    "http://foo.com/org/repo/pull/12",
+		},
+		{
+			name:    "unparseable url",
+			repoURL: "1234abcd",
+			number:  12,
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakePullRequestURL(tt.repoURL, tt.number)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestMakeDirURL(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -384,3 +433,48 @@ func TestParseGitURL(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSameRepository(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want bool
+	}{
+		{
+			name: "SCP-like URL and https",
+			a:    "git@github.com:pipe-cd/pipe.git",
+			b:    "https://github.com/pipe-cd/pipe",
+			want: true,
+		},
+		{
+			name: "ssh and https with trailing slash",
+			a:    "ssh://git@github.com/pipe-cd/pipe.git",
+			b:    "https://github.com/Pipe-CD/pipe/",
+			want: true,
+		},
+		{
+			name: "different repositories",
+			a:    "git@github.com:pipe-cd/pipe.git",
+			b:    "git@github.com:pipe-cd/examples.git",
+			want: false,
+		},
+		{
+			name: "different hosts",
+			a:    "git@github.com:pipe-cd/pipe.git",
+			b:    "git@gitlab.com:pipe-cd/pipe.git",
+			want: false,
+		},
+		{
+			name: "invalid URL",
+			a:    "git@github.com:pipe-cd/pipe.git",
+			b:    "pipe",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsSameRepository(tt.a, tt.b))
+		})
+	}
+}
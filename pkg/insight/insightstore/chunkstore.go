@@ -103,6 +103,58 @@ func PutChunksToCache(cache cache.Cache, chunks insight.Chunks) error {
 	return err
 }
 
+// ChunkCodec encodes insight chunks into bytes to be stored in a shared cache
+// such as Redis, and decodes them back to the original chunk types.
+type ChunkCodec struct{}
+
+type cachedChunk struct {
+	Kind     model.InsightMetricsKind `json:"kind"`
+	FilePath string                   `json:"file_path"`
+	Data     json.RawMessage          `json:"data"`
+}
+
+func (ChunkCodec) Encode(v interface{}) ([]byte, error) {
+	var kind model.InsightMetricsKind
+	switch v.(type) {
+	case *insight.DeployFrequencyChunk:
+		kind = model.InsightMetricsKind_DEPLOYMENT_FREQUENCY
+	case *insight.ChangeFailureRateChunk:
+		kind = model.InsightMetricsKind_CHANGE_FAILURE_RATE
+	default:
+		return nil, fmt.Errorf("unsupported chunk type %T", v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(cachedChunk{
+		Kind:     kind,
+		FilePath: v.(insight.Chunk).GetFilePath(),
+		Data:     data,
+	})
+}
+
+func (ChunkCodec) Decode(data []byte) (interface{}, error) {
+	var cc cachedChunk
+	if err := json.Unmarshal(data, &cc); err != nil {
+		return nil, err
+	}
+	var chunk insight.Chunk
+	switch cc.Kind {
+	case model.InsightMetricsKind_DEPLOYMENT_FREQUENCY:
+		chunk = &insight.DeployFrequencyChunk{}
+	case model.InsightMetricsKind_CHANGE_FAILURE_RATE:
+		chunk = &insight.ChangeFailureRateChunk{}
+	default:
+		return nil, fmt.Errorf("unsupported chunk kind %v", cc.Kind)
+	}
+	if err := json.Unmarshal(cc.Data, chunk); err != nil {
+		return nil, err
+	}
+	chunk.SetFilePath(cc.FilePath)
+	return chunk, nil
+}
+
 func (s *store) getChunk(ctx context.Context, path string, kind model.InsightMetricsKind) (insight.Chunk, error) {
 	obj, err := s.filestore.GetObject(ctx, path)
 	if err != nil {
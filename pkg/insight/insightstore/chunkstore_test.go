@@ -426,3 +426,62 @@ func TestGetChunk(t *testing.T) {
 		})
 	}
 }
+
+func TestChunkCodec(t *testing.T) {
+	testcases := []struct {
+		name    string
+		chunk   interface{}
+		wantErr bool
+	}{
+		{
+			name: "deploy frequency chunk",
+			chunk: &insight.DeployFrequencyChunk{
+				AccumulatedTo: 1609459200,
+				DataPoints: insight.DeployFrequencyDataPoint{
+					Daily: []*insight.DeployFrequency{
+						{
+							Timestamp:   1609459200,
+							DeployCount: 3,
+						},
+					},
+				},
+				FilePath: "insights/deployment-frequency/project/app/2021-01.json",
+			},
+		},
+		{
+			name: "change failure rate chunk",
+			chunk: &insight.ChangeFailureRateChunk{
+				AccumulatedTo: 1609459200,
+				DataPoints: insight.ChangeFailureRateDataPoint{
+					Monthly: []*insight.ChangeFailureRate{
+						{
+							Timestamp:    1609459200,
+							Rate:         0.5,
+							SuccessCount: 1,
+							FailureCount: 1,
+						},
+					},
+				},
+				FilePath: "insights/change-failure-rate/project/app/2021-01.json",
+			},
+		},
+		{
+			name:    "unsupported type",
+			chunk:   "chunk",
+			wantErr: true,
+		},
+	}
+	codec := ChunkCodec{}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := codec.Encode(tc.chunk)
+			assert.Equal(t, tc.wantErr, err != nil)
+			if err != nil {
+				return
+			}
+			got, err := codec.Decode(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.chunk, got)
+		})
+	}
+}
@@ -39,10 +39,12 @@ type Claims struct {
 }
 
 // NewClaims creates a new claims for a given github user.
-func NewClaims(githubUserID, avatarURL string, ttl time.Duration, role model.Role) *Claims {
+// The sessionID is the ID of the web console session this token was issued for.
+func NewClaims(githubUserID, avatarURL string, ttl time.Duration, role model.Role, sessionID string) *Claims {
 	now := time.Now().UTC()
 	return &Claims{
 		StandardClaims: jwtgo.StandardClaims{
+			Id:        sessionID,
 			Subject:   githubUserID,
 			Issuer:    Issuer,
 			IssuedAt:  now.Unix(),
@@ -55,6 +57,11 @@ func NewClaims(githubUserID, avatarURL string, ttl time.Duration, role model.Rol
 	}
 }
 
+// SessionID returns the ID of the session this token was issued for.
+func (c *Claims) SessionID() string {
+	return c.Id
+}
+
 func readKeyFile(method jwtgo.SigningMethod, keyFile string, isSigningKey bool) (interface{}, error) {
 	data, err := ioutil.ReadFile(keyFile)
 	if err != nil {
@@ -28,7 +28,7 @@ func TestSign(t *testing.T) {
 	claims := NewClaims("user-1", "avatar-url", time.Hour, model.Role{
 		ProjectId:   "project-1",
 		ProjectRole: model.Role_ADMIN,
-	})
+	}, "session-1")
 
 	s, err := NewSigner(jwtgo.SigningMethodRS256, "testdata/private.key")
 	require.NoError(t, err)
@@ -41,7 +41,7 @@ func TestVerify(t *testing.T) {
 			claims: NewClaims("user-1", "avatar-url", time.Hour, model.Role{
 				ProjectId:   "project-1",
 				ProjectRole: model.Role_ADMIN,
-			}),
+			}, "session-1"),
 			fail: false,
 		},
 		{
@@ -153,7 +153,7 @@ func TestVerify(t *testing.T) {
 	c := NewClaims("user", "avatar-url", time.Hour, model.Role{
 		ProjectId:   "project",
 		ProjectRole: model.Role_ADMIN,
-	})
+	}, "session-1")
 
 	token, err := rsS.Sign(c)
 	require.NoError(t, err)
@@ -0,0 +1,290 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// ApplicationIDKey is the field key used to log the application ID.
+	ApplicationIDKey = "app-id"
+	// DeploymentIDKey is the field key used to log the deployment ID.
+	DeploymentIDKey = "deployment-id"
+)
+
+// DebugScope represents a set of log entries
+// those were logged with the specified field.
+type DebugScope struct {
+	Key   string
+	Value string
+}
+
+// Levels manages the minimum enabled logging levels of all components.
+// A component is identified by one segment of the logger name,
+// e.g. the level configured for "controller" is applied to
+// all entries logged by "piped.controller.scheduler".
+// When more than one segment has its own level, the last one wins.
+type Levels struct {
+	mu           sync.RWMutex
+	defaultLevel zapcore.Level
+	components   map[string]zapcore.Level
+	// The debug scopes along with their expiration time.
+	debugScopes map[DebugScope]time.Time
+	nowFunc     func() time.Time
+}
+
+// NewLevels creates a new Levels using the given level for
+// all components that do not have their own level.
+func NewLevels(defaultLevel zapcore.Level) *Levels {
+	return &Levels{
+		defaultLevel: defaultLevel,
+		components:   make(map[string]zapcore.Level),
+		debugScopes:  make(map[DebugScope]time.Time),
+		nowFunc:      time.Now,
+	}
+}
+
+// DefaultLevel returns the level used by components that do not have their own level.
+func (l *Levels) DefaultLevel() zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.defaultLevel
+}
+
+// SetDefaultLevel changes the level used by components that do not have their own level.
+func (l *Levels) SetDefaultLevel(level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaultLevel = level
+}
+
+// SetComponentLevel changes the level of the given component.
+func (l *Levels) SetComponentLevel(component string, level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components[component] = level
+}
+
+// ResetComponentLevel makes the given component use the default level again.
+func (l *Levels) ResetComponentLevel(component string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.components, component)
+}
+
+// LevelOf returns the minimum enabled level for the given logger name.
+func (l *Levels) LevelOf(loggerName string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	segments := strings.Split(loggerName, ".")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if level, ok := l.components[segments[i]]; ok {
+			return level
+		}
+	}
+	return l.defaultLevel
+}
+
+// EnableDebug enables the debug level for all entries logged with the field
+// specified by the given scope, regardless of the component's level.
+// It will be automatically disabled after the given ttl.
+func (l *Levels) EnableDebug(scope DebugScope, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.nowFunc()
+	for s, expiration := range l.debugScopes {
+		if !now.Before(expiration) {
+			delete(l.debugScopes, s)
+		}
+	}
+	l.debugScopes[scope] = now.Add(ttl)
+}
+
+// DisableDebug disables the debug level enabled for the given scope.
+func (l *Levels) DisableDebug(scope DebugScope) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.debugScopes, scope)
+}
+
+// isDebugEnabled reports whether the debug level is still enabled for any of the given scopes.
+func (l *Levels) isDebugEnabled(scopes []DebugScope) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := l.nowFunc()
+	for _, s := range scopes {
+		if expiration, ok := l.debugScopes[s]; ok && now.Before(expiration) {
+			return true
+		}
+	}
+	return false
+}
+
+// minLevel returns the lowest level that may be enabled by any component.
+func (l *Levels) minLevel() zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := l.nowFunc()
+	for _, expiration := range l.debugScopes {
+		if now.Before(expiration) {
+			return zapcore.DebugLevel
+		}
+	}
+	min := l.defaultLevel
+	for _, level := range l.components {
+		if level < min {
+			min = level
+		}
+	}
+	return min
+}
+
+type levelsPayload struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components"`
+}
+
+type levelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
+
+// ServeHTTP allows viewing the current levels via GET
+// and changing the level of a component via PUT.
+//
+// The PUT request body must be a JSON like {"component": "controller", "level": "debug"}.
+// An empty component changes the default level while an empty level
+// resets the component to use the default level.
+func (l *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := l.apply(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, fmt.Sprintf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l.payload())
+}
+
+func (l *Levels) apply(req levelRequest) error {
+	if req.Level == "" {
+		if req.Component == "" {
+			return fmt.Errorf("level is required to change the default level")
+		}
+		l.ResetComponentLevel(req.Component)
+		return nil
+	}
+
+	var level zapcore.Level
+	if err := level.Set(req.Level); err != nil {
+		return err
+	}
+	if req.Component == "" {
+		l.SetDefaultLevel(level)
+		return nil
+	}
+	l.SetComponentLevel(req.Component, level)
+	return nil
+}
+
+func (l *Levels) payload() levelsPayload {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	p := levelsPayload{
+		Default:    l.defaultLevel.String(),
+		Components: make(map[string]string, len(l.components)),
+	}
+	for c, level := range l.components {
+		p.Components[c] = level.String()
+	}
+	return p
+}
+
+// levelsCore filters the log entries based on the level of the component
+// that wrote them. The wrapped core is expected to enable all levels.
+type levelsCore struct {
+	zapcore.Core
+	levels *Levels
+	// The scopes this core is belonging to.
+	// They are collected from the fields added via With.
+	scopes []DebugScope
+}
+
+func newLevelsCore(core zapcore.Core, levels *Levels) zapcore.Core {
+	return &levelsCore{
+		Core:   core,
+		levels: levels,
+	}
+}
+
+func (c *levelsCore) Enabled(level zapcore.Level) bool {
+	return c.levels.minLevel().Enabled(level)
+}
+
+func (c *levelsCore) With(fields []zapcore.Field) zapcore.Core {
+	scopes := c.scopes
+	for _, f := range fields {
+		if f.Type != zapcore.StringType {
+			continue
+		}
+		if f.Key != ApplicationIDKey && f.Key != DeploymentIDKey {
+			continue
+		}
+		scopes = append(scopes[:len(scopes):len(scopes)], DebugScope{Key: f.Key, Value: f.String})
+	}
+	return &levelsCore{
+		Core:   c.Core.With(fields),
+		levels: c.levels,
+		scopes: scopes,
+	}
+}
+
+func (c *levelsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.levels.LevelOf(ent.LoggerName).Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	if c.levels.isDebugEnabled(c.scopes) {
+		return c.Core.Check(ent, ce)
+	}
+	return ce
+}
@@ -0,0 +1,175 @@
+// Copyright 2021 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevelOf(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	levels.SetComponentLevel("controller", zapcore.DebugLevel)
+	levels.SetComponentLevel("executor", zapcore.WarnLevel)
+
+	testcases := []struct {
+		loggerName string
+		expected   zapcore.Level
+	}{
+		{
+			loggerName: "",
+			expected:   zapcore.InfoLevel,
+		},
+		{
+			loggerName: "piped.trigger",
+			expected:   zapcore.InfoLevel,
+		},
+		{
+			loggerName: "piped.controller",
+			expected:   zapcore.DebugLevel,
+		},
+		{
+			loggerName: "piped.controller.scheduler",
+			expected:   zapcore.DebugLevel,
+		},
+		{
+			loggerName: "piped.controller.scheduler.executor",
+			expected:   zapcore.WarnLevel,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.loggerName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, levels.LevelOf(tc.loggerName))
+		})
+	}
+
+	levels.ResetComponentLevel("controller")
+	assert.Equal(t, zapcore.InfoLevel, levels.LevelOf("piped.controller"))
+}
+
+func TestLevelsCore(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newLevelsCore(core, levels)).Named("piped")
+
+	logger.Named("trigger").Debug("trigger-debug")
+	logger.Named("controller").Debug("controller-debug")
+	assert.Equal(t, 0, logs.Len())
+
+	levels.SetComponentLevel("controller", zapcore.DebugLevel)
+	logger.Named("trigger").Debug("trigger-debug")
+	logger.Named("controller").With(zap.String("deployment-id", "id")).Debug("controller-debug")
+	entries := logs.TakeAll()
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "controller-debug", entries[0].Message)
+	assert.Equal(t, "id", entries[0].ContextMap()["deployment-id"])
+}
+
+func TestLevelsCoreDebugScope(t *testing.T) {
+	now := time.Now()
+	levels := NewLevels(zapcore.InfoLevel)
+	levels.nowFunc = func() time.Time { return now }
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newLevelsCore(core, levels)).Named("piped")
+
+	scheduler := logger.Named("scheduler").With(zap.String(DeploymentIDKey, "deployment-1"))
+	executor := scheduler.Named("executor").With(zap.String("stage-id", "stage-1"))
+	other := logger.Named("scheduler").With(zap.String(DeploymentIDKey, "deployment-2"))
+
+	levels.EnableDebug(DebugScope{Key: DeploymentIDKey, Value: "deployment-1"}, time.Minute)
+	scheduler.Debug("scheduler-debug")
+	executor.Debug("executor-debug")
+	other.Debug("other-debug")
+	entries := logs.TakeAll()
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "scheduler-debug", entries[0].Message)
+	assert.Equal(t, "executor-debug", entries[1].Message)
+
+	// The debug logging must be disabled after its TTL.
+	now = now.Add(time.Minute)
+	scheduler.Debug("scheduler-debug")
+	executor.Debug("executor-debug")
+	assert.Equal(t, 0, logs.Len())
+}
+
+func TestLevelsServeHTTP(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+
+	testcases := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "get current levels",
+			method:       http.MethodGet,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"default":"info","components":{}}`,
+		},
+		{
+			name:         "change component level",
+			method:       http.MethodPut,
+			body:         `{"component":"controller","level":"debug"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"default":"info","components":{"controller":"debug"}}`,
+		},
+		{
+			name:         "change default level",
+			method:       http.MethodPut,
+			body:         `{"level":"warn"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"default":"warn","components":{"controller":"debug"}}`,
+		},
+		{
+			name:         "reset component level",
+			method:       http.MethodPut,
+			body:         `{"component":"controller"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"default":"warn","components":{}}`,
+		},
+		{
+			name:         "invalid level",
+			method:       http.MethodPut,
+			body:         `{"component":"controller","level":"foo"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "not allowed method",
+			method:       http.MethodDelete,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "http://admin/loglevel", strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			levels.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+}
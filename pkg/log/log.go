@@ -45,6 +45,9 @@ type Configs struct {
 	Level          string
 	Encoding       EncodingType
 	ServiceContext *ServiceContext
+	// Levels is used to control the level of each component at runtime.
+	// When it is specified, it decides the levels instead of the Level field.
+	Levels *Levels
 }
 
 func NewLogger(c Configs) (*zap.Logger, error) {
@@ -54,9 +57,14 @@ func NewLogger(c Configs) (*zap.Logger, error) {
 	}
 	var options []zap.Option
 	if c.ServiceContext != nil && c.Encoding != HumanizeEncoding {
-		options = []zap.Option{
-			zap.Fields(zap.Object("serviceContext", c.ServiceContext)),
-		}
+		options = append(options, zap.Fields(zap.Object("serviceContext", c.ServiceContext)))
+	}
+	if c.Levels != nil {
+		// Enable all levels at the underlying core and leave the filtering to Levels.
+		*level = zapcore.DebugLevel
+		options = append(options, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newLevelsCore(core, c.Levels)
+		}))
 	}
 	logger, err := newConfig(*level, c.Encoding).Build(options...)
 	if err != nil {
@@ -17,9 +17,12 @@ package model
 type AnalysisProviderType string
 
 const (
-	AnalysisProviderPrometheus  AnalysisProviderType = "PROMETHEUS"
-	AnalysisProviderDatadog     AnalysisProviderType = "DATADOG"
-	AnalysisProviderStackdriver AnalysisProviderType = "STACKDRIVER"
+	AnalysisProviderPrometheus    AnalysisProviderType = "PROMETHEUS"
+	AnalysisProviderDatadog       AnalysisProviderType = "DATADOG"
+	AnalysisProviderNewRelic      AnalysisProviderType = "NEWRELIC"
+	AnalysisProviderStackdriver   AnalysisProviderType = "STACKDRIVER"
+	AnalysisProviderLoki          AnalysisProviderType = "LOKI"
+	AnalysisProviderElasticsearch AnalysisProviderType = "ELASTICSEARCH"
 )
 
 func (t AnalysisProviderType) String() string {
@@ -15,18 +15,46 @@
 package model
 
 import (
-	"path/filepath"
+	"path"
+	"strings"
 )
 
 const DefaultDeploymentConfigFileName = ".pipe.yaml"
 
-// GetDeploymentConfigFilePath returns the path to deployment configuration file.
+// GetDeploymentConfigFilePath returns the slash-separated path
+// to deployment configuration file from the repository root.
 func (p ApplicationGitPath) GetDeploymentConfigFilePath() string {
 	filename := DefaultDeploymentConfigFileName
 	if n := p.ConfigFilename; n != "" {
 		filename = n
 	}
-	return filepath.Join(p.Path, filename)
+	return path.Join(p.Path, filename)
+}
+
+// FindConfigFilename returns the default name of the deployment configuration file
+// for the application placed at the given path.
+// The setting of the deepest directory containing the path is used,
+// and DefaultDeploymentConfigFileName is returned when nothing was configured.
+func (r *ApplicationGitRepository) FindConfigFilename(appPath string) string {
+	var (
+		filename = DefaultDeploymentConfigFileName
+		depth    = -1
+	)
+	appPath = path.Clean(appPath)
+	for dir, name := range r.ConfigFilenames {
+		dir = path.Clean(dir)
+		if dir != "." && appPath != dir && !strings.HasPrefix(appPath, dir+"/") {
+			continue
+		}
+		d := strings.Count(dir, "/")
+		if dir == "." {
+			d = -1
+		}
+		if d+1 > depth {
+			filename, depth = name, d+1
+		}
+	}
+	return filename
 }
 
 // HasChanged checks whether the content of sync state has been changed.
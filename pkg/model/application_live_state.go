@@ -24,7 +24,7 @@ func (v ApplicationLiveStateVersion) IsBefore(a ApplicationLiveStateVersion) boo
 	return v.Index < a.Index
 }
 
-func (s KubernetesResourceState) HasDiff(a KubernetesResourceState) bool {
+func (s *KubernetesResourceState) HasDiff(a *KubernetesResourceState) bool {
 	if s.ApiVersion != a.ApiVersion {
 		return true
 	}
@@ -43,16 +43,27 @@ func (s KubernetesResourceState) HasDiff(a KubernetesResourceState) bool {
 	if len(s.ParentIds) != len(a.ParentIds) {
 		return true
 	}
+	if len(s.References) != len(a.References) {
+		return true
+	}
 
 	for i := range s.OwnerIds {
 		if s.OwnerIds[i] != a.OwnerIds[i] {
-			return false
+			return true
 		}
 	}
 
 	for i := range s.ParentIds {
 		if s.ParentIds[i] != a.ParentIds[i] {
-			return false
+			return true
+		}
+	}
+
+	for i := range s.References {
+		if s.References[i].Kind != a.References[i].Kind ||
+			s.References[i].Name != a.References[i].Name ||
+			s.References[i].Namespace != a.References[i].Namespace {
+			return true
 		}
 	}
 
@@ -0,0 +1,71 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubernetesResourceStateHasDiff(t *testing.T) {
+	testcases := []struct {
+		name     string
+		state    *KubernetesResourceState
+		expected bool
+	}{
+		{
+			name: "same",
+			state: &KubernetesResourceState{
+				OwnerIds:   []string{"owner-1"},
+				References: []*KubernetesResourceReference{{Kind: "ConfigMap", Name: "config"}},
+			},
+			expected: false,
+		},
+		{
+			name: "different owner",
+			state: &KubernetesResourceState{
+				OwnerIds:   []string{"owner-2"},
+				References: []*KubernetesResourceReference{{Kind: "ConfigMap", Name: "config"}},
+			},
+			expected: true,
+		},
+		{
+			name: "different reference",
+			state: &KubernetesResourceState{
+				OwnerIds:   []string{"owner-1"},
+				References: []*KubernetesResourceReference{{Kind: "ConfigMap", Name: "config-v2"}},
+			},
+			expected: true,
+		},
+		{
+			name: "no reference",
+			state: &KubernetesResourceState{
+				OwnerIds: []string{"owner-1"},
+			},
+			expected: true,
+		},
+	}
+	base := &KubernetesResourceState{
+		OwnerIds:   []string{"owner-1"},
+		References: []*KubernetesResourceReference{{Kind: "ConfigMap", Name: "config"}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := base.HasDiff(tc.state)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConfigFilename(t *testing.T) {
+	repo := &ApplicationGitRepository{
+		Id: "repo",
+		ConfigFilenames: map[string]string{
+			".":          "app.pipecd.yaml",
+			"apps/prod":  "prod.pipecd.yaml",
+			"apps/prod2": "prod2.pipecd.yaml",
+		},
+	}
+	testcases := []struct {
+		name     string
+		repo     *ApplicationGitRepository
+		path     string
+		expected string
+	}{
+		{
+			name:     "nothing configured",
+			repo:     &ApplicationGitRepository{Id: "repo"},
+			path:     "apps/prod/simple",
+			expected: DefaultDeploymentConfigFileName,
+		},
+		{
+			name:     "repository default",
+			repo:     repo,
+			path:     "apps/dev/simple",
+			expected: "app.pipecd.yaml",
+		},
+		{
+			name:     "directory default",
+			repo:     repo,
+			path:     "apps/prod/simple",
+			expected: "prod.pipecd.yaml",
+		},
+		{
+			name:     "exact directory",
+			repo:     repo,
+			path:     "apps/prod2/",
+			expected: "prod2.pipecd.yaml",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.repo.FindConfigFilename(tc.path))
+		})
+	}
+}
@@ -18,5 +18,19 @@ import "context"
 
 type ReportableCommand struct {
 	*Command
-	Report func(ctx context.Context, status CommandStatus, metadata map[string]string) error
+	Report func(ctx context.Context, status CommandStatus, metadata map[string]string, output []byte) error
+}
+
+// IsAvailableFor reports whether the action can be executed against a Kubernetes resource of the given kind.
+func (a Command_ExecuteResourceAction_Action) IsAvailableFor(kind string) bool {
+	switch a {
+	case Command_ExecuteResourceAction_RESTART_WORKLOAD:
+		return kind == "Deployment" || kind == "StatefulSet" || kind == "DaemonSet"
+	case Command_ExecuteResourceAction_SCALE_WORKLOAD:
+		return kind == "Deployment" || kind == "StatefulSet"
+	case Command_ExecuteResourceAction_DELETE_POD, Command_ExecuteResourceAction_GET_POD_LOGS:
+		return kind == "Pod"
+	default:
+		return false
+	}
 }
@@ -20,6 +20,11 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// MetadataKeyStageApprovers is the key of the stage metadata holding
+// the comma-separated users allowed to approve a WAIT_APPROVAL stage.
+// Empty means any user of the project can approve.
+const MetadataKeyStageApprovers = "Approvers"
+
 var notCompletedDeploymentStatuses = []DeploymentStatus{
 	DeploymentStatus_DEPLOYMENT_PENDING,
 	DeploymentStatus_DEPLOYMENT_PLANNED,
@@ -57,6 +62,8 @@ func IsCompletedStage(status StageStatus) bool {
 		return true
 	case StageStatus_STAGE_CANCELLED:
 		return true
+	case StageStatus_STAGE_SKIPPED:
+		return true
 	}
 	return false
 }
@@ -100,6 +107,8 @@ func CanUpdateStageStatus(cur, next StageStatus) bool {
 		return cur <= StageStatus_STAGE_RUNNING
 	case StageStatus_STAGE_CANCELLED:
 		return cur <= StageStatus_STAGE_RUNNING
+	case StageStatus_STAGE_SKIPPED:
+		return cur <= StageStatus_STAGE_RUNNING
 	}
 	return false
 }
@@ -159,6 +168,16 @@ func (d *Deployment) CloudProviderType() CloudProviderType {
 	}
 }
 
+// FindStage finds the stage with the given id in stage list.
+func (d *Deployment) FindStage(id string) (*PipelineStage, bool) {
+	for _, s := range d.Stages {
+		if s.Id == id {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
 // FindRollbackStage finds the rollback stage in stage list.
 func (d *Deployment) FindRollbackStage() (*PipelineStage, bool) {
 	for i := len(d.Stages) - 1; i >= 0; i-- {
@@ -29,6 +29,10 @@ func (e NotificationEvent) Group() NotificationEventGroup {
 		return NotificationEventGroup_EVENT_APPLICATION_HEALTH
 	case e.Type < 400:
 		return NotificationEventGroup_EVENT_PIPED
+	case e.Type < 500:
+		return NotificationEventGroup_EVENT_ANALYSIS
+	case e.Type < 600:
+		return NotificationEventGroup_EVENT_DEPENDENCY
 	default:
 		return NotificationEventGroup_EVENT_NONE
 	}
@@ -58,10 +62,26 @@ func (e *NotificationEventDeploymentFailed) GetAppName() string {
 	return e.Deployment.ApplicationName
 }
 
+func (e *NotificationEventDeploymentWaitApproval) GetAppName() string {
+	return e.Deployment.ApplicationName
+}
+
 func (e *NotificationEventApplicationSynced) GetAppName() string {
-	return e.Application.Id
+	return e.Application.Name
 }
 
 func (e *NotificationEventApplicationOutOfSync) GetAppName() string {
-	return e.Application.Id
+	return e.Application.Name
+}
+
+func (e *NotificationEventAnalysisStarted) GetAppName() string {
+	return e.Deployment.ApplicationName
+}
+
+func (e *NotificationEventAnalysisFailed) GetAppName() string {
+	return e.Deployment.ApplicationName
+}
+
+func (e *NotificationEventDependencyOutdated) GetAppName() string {
+	return e.Application.Name
 }
@@ -35,6 +35,7 @@ const (
 	SealedSecretManagementSealingKey SealedSecretManagementType = "SEALING_KEY"
 	SealedSecretManagementGCPKMS     SealedSecretManagementType = "GCP_KMS"
 	SealedSecretManagementAWSKMS     SealedSecretManagementType = "AWS_KMS"
+	SealedSecretManagementVault      SealedSecretManagementType = "VAULT"
 )
 
 func (t SealedSecretManagementType) String() string {
@@ -57,23 +58,31 @@ func GeneratePipedKey() (key, hash string, err error) {
 
 // CheckKey checks if the give key is one of the stored keys.
 func (p *Piped) CheckKey(key string) (err error) {
+	_, err = p.FindKey(key)
+	return
+}
+
+// FindKey returns the stored key matching with the given one.
+// The returned key is nil when the given key matched the deprecated KeyHash field,
+// which means the key is not restricted by any scope.
+func (p *Piped) FindKey(key string) (k *PipedKey, err error) {
 	// The KeyHash field was deprecated.
 	// And this block will be removed in the future.
 	if p.KeyHash != "" {
 		err = bcrypt.CompareHashAndPassword([]byte(p.KeyHash), []byte(key))
 		if err == nil {
-			return nil
+			return nil, nil
 		}
 	}
 
 	if len(p.Keys) == 0 {
-		return errors.New("piped does not contain any key")
+		return nil, errors.New("piped does not contain any key")
 	}
 
 	for _, k := range p.Keys {
 		err = bcrypt.CompareHashAndPassword([]byte(k.Hash), []byte(key))
 		if err == nil {
-			return nil
+			return k, nil
 		}
 	}
 
@@ -82,14 +91,19 @@ func (p *Piped) CheckKey(key string) (err error) {
 
 // AddKey adds a new key to the list.
 // A piped can hold a maximum of "pipedMaxKeyNum" keys.
-func (p *Piped) AddKey(hash, creator string, createdAt time.Time) error {
+// The scope restricts the resources the key can access, nil means no restriction.
+func (p *Piped) AddKey(hash, creator string, createdAt time.Time, scope *PipedKeyScope) error {
 	if len(p.Keys) == pipedMaxKeyNum {
 		return fmt.Errorf("number of keys for each piped must be less than or equal to %d, you may need to delete the old keys before adding a new one", pipedMaxKeyNum)
 	}
+	if scope.IsUnrestricted() {
+		scope = nil
+	}
 
 	k := &PipedKey{
 		Hash:      hash,
 		Creator:   creator,
+		Scope:     scope,
 		CreatedAt: createdAt.Unix(),
 	}
 
@@ -125,3 +139,40 @@ func (p *Piped) RedactSensitiveData() {
 		p.Keys[i].Hash = redactedMessage
 	}
 }
+
+// IsUnrestricted returns true if the scope allows accessing all resources.
+func (s *PipedKeyScope) IsUnrestricted() bool {
+	return s == nil || (len(s.RepositoryIds) == 0 && len(s.ApplicationIds) == 0)
+}
+
+// AllowApplication returns true if the application specified by the given
+// application ID and its repository ID can be accessed within the scope.
+func (s *PipedKeyScope) AllowApplication(appID, repoID string) bool {
+	return s.AllowApplicationID(appID) && s.AllowRepository(repoID)
+}
+
+// AllowApplicationID returns true if the given application ID is allowed by the scope.
+// This does not check the repository of the application.
+func (s *PipedKeyScope) AllowApplicationID(appID string) bool {
+	if s == nil || len(s.ApplicationIds) == 0 {
+		return true
+	}
+	return containsString(s.ApplicationIds, appID)
+}
+
+// AllowRepository returns true if the given repository ID is allowed by the scope.
+func (s *PipedKeyScope) AllowRepository(repoID string) bool {
+	if s == nil || len(s.RepositoryIds) == 0 {
+		return true
+	}
+	return containsString(s.RepositoryIds, repoID)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
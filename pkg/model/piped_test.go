@@ -35,7 +35,7 @@ func TestPipedCheckKey(t *testing.T) {
 	require.NoError(t, err)
 
 	p := &Piped{}
-	p.AddKey(hash, "user", time.Now())
+	p.AddKey(hash, "user", time.Now(), nil)
 
 	err = p.CheckKey(key)
 	assert.NoError(t, err)
@@ -54,7 +54,7 @@ func TestAddKey(t *testing.T) {
 
 	now := time.Now()
 
-	err := p.AddKey("hash-1", "user-1", now)
+	err := p.AddKey("hash-1", "user-1", now, nil)
 	assert.NoError(t, err)
 	require.Equal(t, []*PipedKey{
 		{
@@ -64,7 +64,7 @@ func TestAddKey(t *testing.T) {
 		},
 	}, p.Keys)
 
-	err = p.AddKey("hash-2", "user-1", now.Add(time.Second))
+	err = p.AddKey("hash-2", "user-1", now.Add(time.Second), nil)
 	assert.NoError(t, err)
 	require.Equal(t, []*PipedKey{
 		{
@@ -79,7 +79,7 @@ func TestAddKey(t *testing.T) {
 		},
 	}, p.Keys)
 
-	err = p.AddKey("hash-3", "user-3", now.Add(2*time.Second))
+	err = p.AddKey("hash-3", "user-3", now.Add(2*time.Second), nil)
 	assert.Equal(t, errors.New("number of keys for each piped must be less than or equal to 2, you may need to delete the old keys before adding a new one"), err)
 	require.Equal(t, []*PipedKey{
 		{
@@ -236,3 +236,54 @@ func TestPipedRedactSensitiveData(t *testing.T) {
 		})
 	}
 }
+
+func TestPipedKeyScopeAllowApplication(t *testing.T) {
+	testcases := []struct {
+		name     string
+		scope    *PipedKeyScope
+		appID    string
+		repoID   string
+		expected bool
+	}{
+		{
+			name:     "nil scope",
+			appID:    "app-1",
+			repoID:   "repo-1",
+			expected: true,
+		},
+		{
+			name: "allowed application",
+			scope: &PipedKeyScope{
+				ApplicationIds: []string{"app-1"},
+			},
+			appID:    "app-1",
+			repoID:   "repo-1",
+			expected: true,
+		},
+		{
+			name: "disallowed application",
+			scope: &PipedKeyScope{
+				ApplicationIds: []string{"app-1"},
+			},
+			appID:    "app-2",
+			repoID:   "repo-1",
+			expected: false,
+		},
+		{
+			name: "disallowed repository",
+			scope: &PipedKeyScope{
+				ApplicationIds: []string{"app-1"},
+				RepositoryIds:  []string{"repo-2"},
+			},
+			appID:    "app-1",
+			repoID:   "repo-1",
+			expected: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.scope.AllowApplication(tc.appID, tc.repoID)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionRefreshTokenLength = 32
+
+	// SessionRevokedByReuseDetection is recorded as the revoker of the sessions
+	// those were revoked because their rotated refresh token was used again.
+	SessionRevokedByReuseDetection = "refresh-token-reuse-detection"
+)
+
+// GenerateSessionRefreshToken generates a new refresh token for the given session
+// and returns it together with its hash value to be stored.
+func GenerateSessionRefreshToken(sessionID string) (token, hash string, err error) {
+	t := GenerateRandomString(sessionRefreshTokenLength)
+	token = fmt.Sprintf("%s.%s", sessionID, t)
+
+	var encoded []byte
+	encoded, err = bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+	if err != nil {
+		return
+	}
+
+	hash = string(encoded)
+	return
+}
+
+// ExtractSessionID returns the ID of the session that the given refresh token belongs to.
+func ExtractSessionID(refreshToken string) (string, error) {
+	parts := strings.Split(refreshToken, ".")
+	if len(parts) != 2 {
+		return "", errors.New("malformed refresh token")
+	}
+
+	if parts[0] == "" || parts[1] == "" {
+		return "", errors.New("malformed refresh token")
+	}
+
+	return parts[0], nil
+}
+
+// CompareRefreshToken checks whether the given token is the current refresh token of the session.
+func (s *Session) CompareRefreshToken(token string) error {
+	if token == "" {
+		return errors.New("refresh token was empty")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(s.RefreshTokenHash), []byte(token)); err != nil {
+		return fmt.Errorf("wrong refresh token for session %s: %w", s.Id, err)
+	}
+	return nil
+}
+
+// CheckActive returns an error if the session can no longer be used
+// because it was revoked, reached its max lifetime or stayed idle for too long.
+func (s *Session) CheckActive(now time.Time, idleTimeout time.Duration) error {
+	if s.Revoked {
+		return fmt.Errorf("session %s was revoked", s.Id)
+	}
+	if now.Unix() >= s.ExpiresAt {
+		return fmt.Errorf("session %s was expired", s.Id)
+	}
+	if idleTimeout > 0 && now.Sub(time.Unix(s.LastActiveAt, 0)) > idleTimeout {
+		return fmt.Errorf("session %s was idle for more than %v", s.Id, idleTimeout)
+	}
+	return nil
+}
+
+// RedactSensitiveData redacts sensitive data.
+func (s *Session) RedactSensitiveData() {
+	s.RefreshTokenHash = redactedMessage
+}
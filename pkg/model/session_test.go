@@ -0,0 +1,109 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSessionRefreshToken(t *testing.T) {
+	id := "session-id"
+	token, hash, err := GenerateSessionRefreshToken(id)
+	require.NoError(t, err)
+	require.True(t, len(token) > 0)
+	require.True(t, len(hash) > 0)
+
+	parsedID, err := ExtractSessionID(token)
+	require.NoError(t, err)
+	assert.Equal(t, id, parsedID)
+
+	s := &Session{
+		Id:               id,
+		RefreshTokenHash: hash,
+	}
+	assert.NoError(t, s.CompareRefreshToken(token))
+	assert.Error(t, s.CompareRefreshToken(token+"x"))
+	assert.Error(t, s.CompareRefreshToken(""))
+
+	_, err = ExtractSessionID("malformed")
+	assert.Error(t, err)
+}
+
+func TestSessionCheckActive(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	testcases := []struct {
+		name        string
+		session     *Session
+		idleTimeout time.Duration
+		wantErr     bool
+	}{
+		{
+			name: "active",
+			session: &Session{
+				LastActiveAt: now.Add(-time.Hour).Unix(),
+				ExpiresAt:    now.Add(time.Hour).Unix(),
+			},
+			idleTimeout: 2 * time.Hour,
+			wantErr:     false,
+		},
+		{
+			name: "revoked",
+			session: &Session{
+				LastActiveAt: now.Add(-time.Hour).Unix(),
+				ExpiresAt:    now.Add(time.Hour).Unix(),
+				Revoked:      true,
+			},
+			idleTimeout: 2 * time.Hour,
+			wantErr:     true,
+		},
+		{
+			name: "expired",
+			session: &Session{
+				LastActiveAt: now.Add(-time.Hour).Unix(),
+				ExpiresAt:    now.Unix(),
+			},
+			idleTimeout: 2 * time.Hour,
+			wantErr:     true,
+		},
+		{
+			name: "idle for too long",
+			session: &Session{
+				LastActiveAt: now.Add(-3 * time.Hour).Unix(),
+				ExpiresAt:    now.Add(time.Hour).Unix(),
+			},
+			idleTimeout: 2 * time.Hour,
+			wantErr:     true,
+		},
+		{
+			name: "idle timeout is not checked",
+			session: &Session{
+				LastActiveAt: now.Add(-3 * time.Hour).Unix(),
+				ExpiresAt:    now.Add(time.Hour).Unix(),
+			},
+			idleTimeout: 0,
+			wantErr:     false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.session.CheckActive(now, tc.idleTimeout)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -27,6 +27,10 @@ const (
 	// StageAnalysis represents the waiting state for analysing
 	// the application status based on metrics, log, http request...
 	StageAnalysis Stage = "ANALYSIS"
+	// StageChangeRequest represents the waiting state until the change request
+	// in the change management system such as ServiceNow is approved
+	// and its planned window has started.
+	StageChangeRequest Stage = "CHANGE_REQUEST"
 
 	// StageK8sSync represents the state where
 	// all resources should be synced with the Git state.
@@ -49,6 +53,10 @@ const (
 	// StageK8sTrafficRouting represents the state where the traffic to application
 	// should be splitted as the specified percentage to PRIMARY, CANARY, BASELINE variants.
 	StageK8sTrafficRouting Stage = "K8S_TRAFFIC_ROUTING"
+	// StageK8sPartitionRollout represents the state where the pods of the StatefulSet workloads
+	// whose ordinal is greater than or equal to the specified partition have been updated
+	// to the new version/configuration.
+	StageK8sPartitionRollout Stage = "K8S_PARTITION_ROLLOUT"
 
 	// StageTerraformSync synced infrastructure with all the tf defined in Git.
 	// Firstly, it does plan and if there are any changes detected it applies those changes automatically.
@@ -89,3 +97,15 @@ const (
 func (s Stage) String() string {
 	return string(s)
 }
+
+// IsIrreversible reports whether the changes made by this stage can not be reverted
+// by the rollback stage. Once a deployment has started an irreversible stage
+// it should not be cancelled automatically (e.g. by a newer deployment).
+func (s Stage) IsIrreversible() bool {
+	switch s {
+	case StageTerraformSync, StageTerraformApply:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// PayloadSizeUnaryServerInterceptor rejects the requests whose payload is larger than the limit.
+// The limit of each method can be overridden by the given map keyed by
+// the full method name (e.g. /pipe.api.service.pipedservice.PipedService/Ping) or only the method name (e.g. Ping).
+// Zero means no limit.
+// A ResourceExhausted error will be returned to client if the payload was too large.
+func PayloadSizeUnaryServerInterceptor(maxBytes int, methodMaxBytes map[string]int) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		limit := maxBytes
+		if v, ok := methodMaxBytes[info.FullMethod]; ok {
+			limit = v
+		} else if v, ok := methodMaxBytes[methodName(info.FullMethod)]; ok {
+			limit = v
+		}
+		if m, ok := req.(proto.Message); ok && limit > 0 {
+			if size := proto.Size(m); size > limit {
+				return nil, status.Error(codes.ResourceExhausted, fmt.Sprintf("request payload is too large: %d bytes (limit: %d bytes)", size, limit))
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// methodName returns the method name part of the given full method name.
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pipe-cd/pipe/pkg/model"
+)
+
+func TestPayloadSizeUnaryServerInterceptor(t *testing.T) {
+	in := PayloadSizeUnaryServerInterceptor(100, map[string]int{
+		"/pipe.api.service.pipedservice.PipedService/ReportStageLogs": 1000,
+		"Ping": 0,
+	})
+	small := &model.Application{Name: "app"}
+	large := &model.Application{Name: strings.Repeat("a", 500)}
+
+	testcases := []struct {
+		name   string
+		method string
+		req    interface{}
+		code   codes.Code
+	}{
+		{
+			name:   "small request",
+			method: "/pipe.api.service.pipedservice.PipedService/ReportApplicationSyncState",
+			req:    small,
+			code:   codes.OK,
+		},
+		{
+			name:   "large request",
+			method: "/pipe.api.service.pipedservice.PipedService/ReportApplicationSyncState",
+			req:    large,
+			code:   codes.ResourceExhausted,
+		},
+		{
+			name:   "large request to the method with a larger limit",
+			method: "/pipe.api.service.pipedservice.PipedService/ReportStageLogs",
+			req:    large,
+			code:   codes.OK,
+		},
+		{
+			name:   "large request to the method without limit",
+			method: "/pipe.api.service.pipedservice.PipedService/Ping",
+			req:    large,
+			code:   codes.OK,
+		},
+		{
+			name:   "non proto request",
+			method: "/pipe.api.service.pipedservice.PipedService/ReportApplicationSyncState",
+			req:    strings.Repeat("a", 500),
+			code:   codes.OK,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			_, err := in(context.TODO(), tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
@@ -16,8 +16,11 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -27,17 +30,66 @@ type requestValidator interface {
 	Validate() error
 }
 
+// fieldValidationError is implemented by the errors returned by
+// the validators generated by protoc-gen-validate.
+type fieldValidationError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
 // RequestValidationUnaryServerInterceptor validates the request payload if
 // the request implements requestValidator interface.
 // An InvalidArgument with the detail message will be returned to client if
-// the validation was not passed.
+// the validation was not passed. The invalid field is also attached to the status
+// as a BadRequest detail so that clients can find it without parsing the message.
 func RequestValidationUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		if v, ok := req.(requestValidator); ok {
 			if err := v.Validate(); err != nil {
-				return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid request: %v", err))
+				return nil, invalidRequestError(err)
 			}
 		}
 		return handler(ctx, req)
 	}
 }
+
+func invalidRequestError(err error) error {
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("invalid request: %v", err))
+	violation := fieldViolation(err)
+	if violation == nil {
+		return st.Err()
+	}
+	ds, e := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{violation},
+	})
+	if e != nil {
+		return st.Err()
+	}
+	return ds.Err()
+}
+
+// fieldViolation returns the violation of the innermost invalid field
+// whose path is joined with dots, e.g. "Deployment.Id".
+func fieldViolation(err error) *errdetails.BadRequest_FieldViolation {
+	var (
+		fields []string
+		reason string
+	)
+	for err != nil {
+		var fe fieldValidationError
+		if !errors.As(err, &fe) {
+			break
+		}
+		fields = append(fields, fe.Field())
+		reason = fe.Reason()
+		err = fe.Cause()
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       strings.Join(fields, "."),
+		Description: reason,
+	}
+}
@@ -20,6 +20,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type testValidator bool
@@ -64,3 +68,50 @@ func TestRequestValidationUnaryServerInterceptor(t *testing.T) {
 		})
 	}
 }
+
+type testFieldValidationError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e testFieldValidationError) Error() string  { return e.field + ": " + e.reason }
+func (e testFieldValidationError) Field() string  { return e.field }
+func (e testFieldValidationError) Reason() string { return e.reason }
+func (e testFieldValidationError) Cause() error   { return e.cause }
+
+type testNestedValidator struct{}
+
+func (v testNestedValidator) Validate() error {
+	return testFieldValidationError{
+		field:  "GitPath",
+		reason: "embedded message failed validation",
+		cause: testFieldValidationError{
+			field:  "Repo",
+			reason: "value is required",
+		},
+	}
+}
+
+func TestRequestValidationUnaryServerInterceptorErrorDetails(t *testing.T) {
+	in := RequestValidationUnaryServerInterceptor()
+	_, err := in(context.TODO(), testNestedValidator{}, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.Error(t, err)
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	br, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, br.FieldViolations, 1)
+	assert.Equal(t, "GitPath.Repo", br.FieldViolations[0].Field)
+	assert.Equal(t, "value is required", br.FieldViolations[0].Description)
+
+	// No detail is attached for the errors not telling the field.
+	_, err = in(context.TODO(), testValidator(false), nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.Empty(t, status.Convert(err).Details())
+}
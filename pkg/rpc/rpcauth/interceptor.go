@@ -37,9 +37,15 @@ type RBACAuthorizer interface {
 	Authorize(string, model.Role) bool
 }
 
+// applicationRequest is implemented by the requests for a specific application.
+type applicationRequest interface {
+	GetApplicationId() string
+}
+
 // PipedTokenVerifier verifies the given piped token.
+// The scope embedded into the matched key is returned, nil means no restriction.
 type PipedTokenVerifier interface {
-	Verify(ctx context.Context, projectID, pipedID, pipedKey string) error
+	Verify(ctx context.Context, projectID, pipedID, pipedKey string) (*model.PipedKeyScope, error)
 }
 
 // APIKeyVerifier verifies the given API key.
@@ -54,6 +60,7 @@ type (
 		ProjectID string
 		PipedID   string
 		PipedKey  string
+		Scope     *model.PipedKeyScope
 	}
 	apiKeyContextKey struct{}
 )
@@ -67,6 +74,7 @@ var (
 // PipedTokenUnaryServerInterceptor extracts credentials from gRPC metadata
 // and validates it by the specified Verifier.
 // If the token was valid the parsed ProjectID, PipedID, PipedKey will be set to the context.
+// The request for an application out of the scope of the key is rejected.
 func PipedTokenUnaryServerInterceptor(verifier PipedTokenVerifier, logger *zap.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		creds, err := extractCredentials(ctx)
@@ -82,15 +90,20 @@ func PipedTokenUnaryServerInterceptor(verifier PipedTokenVerifier, logger *zap.L
 			logger.Warn(fmt.Sprintf("malformed credentials: %s, err: %v", creds.Data, err))
 			return nil, errUnauthenticated
 		}
-		if err := verifier.Verify(ctx, projectID, pipedID, pipedKey); err != nil {
+		scope, err := verifier.Verify(ctx, projectID, pipedID, pipedKey)
+		if err != nil {
 			logger.Warn("unable to verify piped token", zap.Error(err))
 			return nil, errUnauthenticated
 		}
-		ctx = context.WithValue(ctx, pipedTokenKey, pipedTokenContextValue{
-			ProjectID: projectID,
-			PipedID:   pipedID,
-			PipedKey:  pipedKey,
-		})
+		if r, ok := req.(applicationRequest); ok && !scope.AllowApplicationID(r.GetApplicationId()) {
+			logger.Warn("requested application is out of the scope of piped key",
+				zap.String("piped-id", pipedID),
+				zap.String("application-id", r.GetApplicationId()),
+				zap.String("method", info.FullMethod),
+			)
+			return nil, errPermissionDenied
+		}
+		ctx = ContextWithPipedToken(ctx, projectID, pipedID, pipedKey, scope)
 		return handler(ctx, req)
 	}
 }
@@ -115,15 +128,12 @@ func PipedTokenStreamServerInterceptor(verifier PipedTokenVerifier, logger *zap.
 			logger.Warn(fmt.Sprintf("malformed credentials: %s, err: %v", creds.Data, err))
 			return errUnauthenticated
 		}
-		if err := verifier.Verify(ctx, projectID, pipedID, pipedKey); err != nil {
+		scope, err := verifier.Verify(ctx, projectID, pipedID, pipedKey)
+		if err != nil {
 			logger.Warn("unable to verify piped token", zap.Error(err))
 			return errUnauthenticated
 		}
-		ctx = context.WithValue(ctx, pipedTokenKey, pipedTokenContextValue{
-			ProjectID: projectID,
-			PipedID:   pipedID,
-			PipedKey:  pipedKey,
-		})
+		ctx = ContextWithPipedToken(ctx, projectID, pipedID, pipedKey, scope)
 		wrappedStream := &wrappedServerStream{
 			ServerStream: stream,
 			ctx:          ctx,
@@ -132,6 +142,16 @@ func PipedTokenStreamServerInterceptor(verifier PipedTokenVerifier, logger *zap.
 	}
 }
 
+// ContextWithPipedToken returns a new context in which the given piped token and the scope of its key were attached.
+func ContextWithPipedToken(ctx context.Context, projectID, pipedID, pipedKey string, scope *model.PipedKeyScope) context.Context {
+	return context.WithValue(ctx, pipedTokenKey, pipedTokenContextValue{
+		ProjectID: projectID,
+		PipedID:   pipedID,
+		PipedKey:  pipedKey,
+		Scope:     scope,
+	})
+}
+
 // ExtractPipedToken returns the verified piped key inside a given context.
 func ExtractPipedToken(ctx context.Context) (projectID, pipedID, pipedKey string, err error) {
 	v, ok := ctx.Value(pipedTokenKey).(pipedTokenContextValue)
@@ -145,6 +165,16 @@ func ExtractPipedToken(ctx context.Context) (projectID, pipedID, pipedKey string
 	return
 }
 
+// ExtractPipedKeyScope returns the scope of the verified piped key inside a given context.
+// Nil is returned when the key is not restricted.
+func ExtractPipedKeyScope(ctx context.Context) *model.PipedKeyScope {
+	v, ok := ctx.Value(pipedTokenKey).(pipedTokenContextValue)
+	if !ok {
+		return nil
+	}
+	return v.Scope
+}
+
 // APIKeyUnaryServerInterceptor extracts credentials from gRPC metadata
 // and validates it by the specified Verifier.
 // The valid API key will be set to the context.
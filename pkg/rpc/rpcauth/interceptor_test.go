@@ -40,17 +40,18 @@ func (s *fakeServerStream) Context() context.Context {
 
 type testPipedTokenVerifier struct {
 	pipedKey string
+	scope    *model.PipedKeyScope
 }
 
-func (v testPipedTokenVerifier) Verify(ctx context.Context, projectID, pipedID, pipedKey string) error {
+func (v testPipedTokenVerifier) Verify(ctx context.Context, projectID, pipedID, pipedKey string) (*model.PipedKeyScope, error) {
 	if pipedKey != v.pipedKey {
-		return fmt.Errorf("invalid piped key, want: %s, got: %s", v.pipedKey, pipedKey)
+		return nil, fmt.Errorf("invalid piped key, want: %s, got: %s", v.pipedKey, pipedKey)
 	}
-	return nil
+	return v.scope, nil
 }
 
 func TestPipedTokenUnaryServerInterceptor(t *testing.T) {
-	verifier := testPipedTokenVerifier{"test-piped-key"}
+	verifier := testPipedTokenVerifier{pipedKey: "test-piped-key"}
 	in := PipedTokenUnaryServerInterceptor(verifier, zap.NewNop())
 	testcases := []struct {
 		name             string
@@ -106,8 +107,74 @@ func TestPipedTokenUnaryServerInterceptor(t *testing.T) {
 	}
 }
 
+type testApplicationRequest struct {
+	applicationID string
+}
+
+func (r testApplicationRequest) GetApplicationId() string {
+	return r.applicationID
+}
+
+func TestPipedTokenUnaryServerInterceptorWithScope(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"PIPED-TOKEN test-project-id,test-piped-id,test-piped-key"},
+	})
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	testcases := []struct {
+		name   string
+		scope  *model.PipedKeyScope
+		req    interface{}
+		failed bool
+	}{
+		{
+			name: "unrestricted key",
+			req:  testApplicationRequest{applicationID: "app-2"},
+		},
+		{
+			name: "application in scope",
+			scope: &model.PipedKeyScope{
+				ApplicationIds: []string{"app-1"},
+			},
+			req: testApplicationRequest{applicationID: "app-1"},
+		},
+		{
+			name: "application out of scope",
+			scope: &model.PipedKeyScope{
+				ApplicationIds: []string{"app-1"},
+			},
+			req:    testApplicationRequest{applicationID: "app-2"},
+			failed: true,
+		},
+		{
+			name: "request not for an application",
+			scope: &model.PipedKeyScope{
+				ApplicationIds: []string{"app-1"},
+			},
+			req: struct{}{},
+		},
+		{
+			name: "repository scope is checked by the handler",
+			scope: &model.PipedKeyScope{
+				RepositoryIds: []string{"repo-1"},
+			},
+			req: testApplicationRequest{applicationID: "app-2"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			verifier := testPipedTokenVerifier{pipedKey: "test-piped-key", scope: tc.scope}
+			in := PipedTokenUnaryServerInterceptor(verifier, zap.NewNop())
+			_, err := in(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				assert.Equal(t, tc.scope, ExtractPipedKeyScope(ctx))
+				return nil, nil
+			})
+			assert.Equal(t, tc.failed, err != nil)
+		})
+	}
+}
+
 func TestPipedTokenStreamServerInterceptor(t *testing.T) {
-	verifier := testPipedTokenVerifier{"test-piped-key"}
+	verifier := testPipedTokenVerifier{pipedKey: "test-piped-key"}
 	in := PipedTokenStreamServerInterceptor(verifier, zap.NewNop())
 	testcases := []struct {
 		name             string
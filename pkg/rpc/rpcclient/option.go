@@ -25,6 +25,7 @@ type option struct {
 	tls                          bool
 	certFile                     string
 	requestValidationInterceptor bool
+	unaryInterceptors            []grpc.UnaryClientInterceptor
 	options                      []grpc.DialOption
 }
 
@@ -61,6 +62,14 @@ func WithRequestValidationInterceptor() DialOption {
 	}
 }
 
+// WithUnaryInterceptor adds an interceptor for unary RPCs.
+// The interceptors are executed in the order they were added.
+func WithUnaryInterceptor(interceptor grpc.UnaryClientInterceptor) DialOption {
+	return func(o *option) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptor)
+	}
+}
+
 func WithPerRPCCredentials(creds credentials.PerRPCCredentials) DialOption {
 	return func(o *option) {
 		o.options = append(o.options, grpc.WithPerRPCCredentials(creds))
@@ -81,8 +90,13 @@ func DialOptions(opts ...DialOption) ([]grpc.DialOption, error) {
 		}
 		o.options = append(o.options, grpc.WithTransportCredentials(cred))
 	}
+	var interceptors []grpc.UnaryClientInterceptor
 	if o.requestValidationInterceptor {
-		o.options = append(o.options, grpc.WithUnaryInterceptor(RequestValidationUnaryClientInterceptor()))
+		interceptors = append(interceptors, RequestValidationUnaryClientInterceptor())
+	}
+	interceptors = append(interceptors, o.unaryInterceptors...)
+	if len(interceptors) > 0 {
+		o.options = append(o.options, grpc.WithChainUnaryInterceptor(interceptors...))
 	}
 	return o.options, nil
 }
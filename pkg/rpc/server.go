@@ -17,6 +17,7 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"math"
 	"net"
 	"time"
 
@@ -52,8 +53,14 @@ type Server struct {
 	jwtAuthUnaryInterceptor           grpc.UnaryServerInterceptor
 	requestValidationUnaryInterceptor grpc.UnaryServerInterceptor
 	logUnaryInterceptor               grpc.UnaryServerInterceptor
+	slowRequestLogUnaryInterceptor    grpc.UnaryServerInterceptor
+	payloadSizeUnaryInterceptor       grpc.UnaryServerInterceptor
+	maxRecvMsgSize                    int
 }
 
+// defaultMaxRecvMsgSize is the maximum message size the gRPC server receives by default.
+const defaultMaxRecvMsgSize = 4 * 1024 * 1024
+
 // Option defines a function to set configurable field of Server.
 type Option func(*Server)
 
@@ -106,6 +113,39 @@ func WithLogUnaryInterceptor(logger *zap.Logger) Option {
 	}
 }
 
+// WithSlowRequestLogUnaryInterceptor sets an interceptor for logging requests taking longer than the threshold.
+// The threshold can be overridden for each method by its full name or only its name.
+func WithSlowRequestLogUnaryInterceptor(logger *zap.Logger, threshold time.Duration, methodThresholds map[string]time.Duration) Option {
+	return func(s *Server) {
+		s.slowRequestLogUnaryInterceptor = SlowRequestLogUnaryServerInterceptor(logger.Named("rpc-server"), threshold, methodThresholds)
+	}
+}
+
+// WithPayloadSizeUnaryInterceptor sets an interceptor for rejecting requests larger than the limit.
+// The limit can be overridden for each method by its full name or only its name.
+// The maximum message size the server can receive is also raised to the largest limit if needed.
+// Zero means no limit, so the server can receive messages of any size in that case.
+func WithPayloadSizeUnaryInterceptor(maxBytes int, methodMaxBytes map[string]int) Option {
+	return func(s *Server) {
+		s.payloadSizeUnaryInterceptor = PayloadSizeUnaryServerInterceptor(maxBytes, methodMaxBytes)
+		s.maxRecvMsgSize = recvMsgSizeFor(maxBytes)
+		for _, v := range methodMaxBytes {
+			if size := recvMsgSizeFor(v); size > s.maxRecvMsgSize {
+				s.maxRecvMsgSize = size
+			}
+		}
+	}
+}
+
+// recvMsgSizeFor returns the message size the server must be able to receive
+// for applying the given payload limit.
+func recvMsgSizeFor(limit int) int {
+	if limit <= 0 {
+		return math.MaxInt32
+	}
+	return limit
+}
+
 // WithTLS configures TLS files.
 func WithTLS(certFile, keyFile string) Option {
 	return func(s *Server) {
@@ -191,11 +231,18 @@ func (s *Server) init() error {
 	} else {
 		s.logger.Info("grpc server will be run without tls")
 	}
+	// The default maximum size is used unless a larger one is required.
+	if s.maxRecvMsgSize > defaultMaxRecvMsgSize {
+		opts = append(opts, grpc.MaxRecvMsgSize(s.maxRecvMsgSize))
+	}
 	// Builds a chain of enabled interceptors.
 	var unaryInterceptors []grpc.UnaryServerInterceptor
 	if s.logUnaryInterceptor != nil {
 		unaryInterceptors = append(unaryInterceptors, s.logUnaryInterceptor)
 	}
+	if s.slowRequestLogUnaryInterceptor != nil {
+		unaryInterceptors = append(unaryInterceptors, s.slowRequestLogUnaryInterceptor)
+	}
 	if s.pipedKeyAuthUnaryInterceptor != nil {
 		unaryInterceptors = append(unaryInterceptors, s.pipedKeyAuthUnaryInterceptor)
 	}
@@ -205,6 +252,9 @@ func (s *Server) init() error {
 	if s.jwtAuthUnaryInterceptor != nil {
 		unaryInterceptors = append(unaryInterceptors, s.jwtAuthUnaryInterceptor)
 	}
+	if s.payloadSizeUnaryInterceptor != nil {
+		unaryInterceptors = append(unaryInterceptors, s.payloadSizeUnaryInterceptor)
+	}
 	if s.requestValidationUnaryInterceptor != nil {
 		unaryInterceptors = append(unaryInterceptors, s.requestValidationUnaryInterceptor)
 	}
@@ -17,6 +17,7 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"testing"
 	"time"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/pipe-cd/pipe/pkg/app/helloworld/api"
 	"github.com/pipe-cd/pipe/pkg/app/helloworld/service"
+	"github.com/pipe-cd/pipe/pkg/model"
 	"github.com/pipe-cd/pipe/pkg/rpc/rpcauth"
 	"github.com/pipe-cd/pipe/pkg/rpc/rpcclient"
 )
@@ -39,7 +41,7 @@ func TestMain(m *testing.M) {
 		WithPort(9090),
 		WithGracePeriod(time.Second),
 		WithLogger(logger),
-		WithPipedTokenAuthUnaryInterceptor(testPipedTokenVerifier{"test-piped-key"}, logger),
+		WithPipedTokenAuthUnaryInterceptor(testPipedTokenVerifier{pipedKey: "test-piped-key"}, logger),
 	)
 	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
 	go server.Run(ctx)
@@ -48,13 +50,14 @@ func TestMain(m *testing.M) {
 
 type testPipedTokenVerifier struct {
 	pipedKey string
+	scope    *model.PipedKeyScope
 }
 
-func (v testPipedTokenVerifier) Verify(ctx context.Context, projectID, pipedID, pipedKey string) error {
+func (v testPipedTokenVerifier) Verify(ctx context.Context, projectID, pipedID, pipedKey string) (*model.PipedKeyScope, error) {
 	if pipedKey != v.pipedKey {
-		return fmt.Errorf("invalid piped key, want: %s, got: %s", v.pipedKey, pipedKey)
+		return nil, fmt.Errorf("invalid piped key, want: %s, got: %s", v.pipedKey, pipedKey)
 	}
-	return nil
+	return v.scope, nil
 }
 
 func TestRPCRequestOK(t *testing.T) {
@@ -122,3 +125,42 @@ func TestRPCRequestWithoutCredentials(t *testing.T) {
 	})
 	assert.NotNil(t, err)
 }
+
+func TestWithPayloadSizeUnaryInterceptorMaxRecvMsgSize(t *testing.T) {
+	testcases := []struct {
+		name           string
+		maxBytes       int
+		methodMaxBytes map[string]int
+		expected       int
+	}{
+		{
+			name:     "default limit",
+			maxBytes: 4 * 1024 * 1024,
+			expected: 4 * 1024 * 1024,
+		},
+		{
+			name:           "larger method limit",
+			maxBytes:       4 * 1024 * 1024,
+			methodMaxBytes: map[string]int{"ReportStageLogs": 16 * 1024 * 1024},
+			expected:       16 * 1024 * 1024,
+		},
+		{
+			name:     "no limit",
+			maxBytes: 0,
+			expected: math.MaxInt32,
+		},
+		{
+			name:           "no method limit",
+			maxBytes:       4 * 1024 * 1024,
+			methodMaxBytes: map[string]int{"ReportStageLogs": 0},
+			expected:       math.MaxInt32,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Server{}
+			WithPayloadSizeUnaryInterceptor(tc.maxBytes, tc.methodMaxBytes)(s)
+			assert.Equal(t, tc.expected, s.maxRecvMsgSize)
+		})
+	}
+}
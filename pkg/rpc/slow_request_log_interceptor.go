@@ -0,0 +1,51 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// SlowRequestLogUnaryServerInterceptor logs the unary gRPC requests
+// taking longer than the threshold to be handled.
+// The threshold of each method can be overridden by the given map keyed by
+// the full method name or only the method name. Zero means never logging.
+func SlowRequestLogUnaryServerInterceptor(logger *zap.Logger, threshold time.Duration, methodThresholds map[string]time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		limit := threshold
+		if v, ok := methodThresholds[info.FullMethod]; ok {
+			limit = v
+		} else if v, ok := methodThresholds[methodName(info.FullMethod)]; ok {
+			limit = v
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if duration := time.Since(start); limit > 0 && duration > limit {
+			logger.Warn(fmt.Sprintf("handled a slow unary gRPC request: %s", info.FullMethod),
+				zap.String("code", status.Code(err).String()),
+				zap.Duration("duration", duration),
+				zap.Duration("threshold", limit),
+			)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+)
+
+func TestSlowRequestLogUnaryServerInterceptor(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	in := SlowRequestLogUnaryServerInterceptor(zap.New(core), time.Hour, map[string]time.Duration{
+		"/pipe.api.service.webservice.WebService/ListDeployments": time.Nanosecond,
+		"GetApplication": time.Nanosecond,
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return nil, nil
+	}
+
+	testcases := []struct {
+		method string
+		logged bool
+	}{
+		{
+			method: "/pipe.api.service.webservice.WebService/ListApplications",
+			logged: false,
+		},
+		{
+			method: "/pipe.api.service.webservice.WebService/ListDeployments",
+			logged: true,
+		},
+		{
+			method: "/pipe.api.service.webservice.WebService/GetApplication",
+			logged: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.method, func(t *testing.T) {
+			before := logs.Len()
+			_, err := in(context.TODO(), nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.logged, logs.Len() > before)
+		})
+	}
+}
@@ -63,6 +63,12 @@ def go_repositories():
         sum = "h1:McBGvH3M7n8s6SGuS+UNm8+q5BEmE30cNH/81qy0B4Q=",
         version = "v1.1.1",
     )
+    go_repository(
+        name = "com_github_aws_aws_sdk_go_v2_service_kms",
+        importpath = "github.com/aws/aws-sdk-go-v2/service/kms",
+        sum = "h1:rK1edW1dLtSGr1551ttHqQopajK4Pv9C4ez70dVMQaI=",
+        version = "v1.1.1",
+    )
 
     go_repository(
         name = "com_github_aws_aws_sdk_go_v2_service_internal_accept_encoding",